@@ -24,6 +24,7 @@ import (
 
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/service/healthcheck"
 )
 
 type ConnReq struct {
@@ -47,6 +48,14 @@ type ConnStatsResp struct {
 	Stats           []*connlimit.HostConnStat
 }
 
+// OperationRecordsResp 操作记录查询结果
+type OperationRecordsResp struct {
+	Total   uint32
+	Offset  uint32
+	Limit   uint32
+	Records []*model.RecordEntry
+}
+
 type ScopeLevel struct {
 	Name  string
 	Level string
@@ -80,4 +89,11 @@ type AdminOperateServer interface {
 	ReleaseLeaderElection(ctx context.Context, electKey string) error
 	// GetCMDBInfo get cmdb info
 	GetCMDBInfo(ctx context.Context) ([]model.LocationView, error)
+	// GetOperationRecords query resource operation records
+	GetOperationRecords(ctx context.Context, query map[string]string) (*OperationRecordsResp, error)
+	// GetHealthCheckProtection get health check protection state reported by this node
+	GetHealthCheckProtection(ctx context.Context) (*healthcheck.ProtectionState, error)
+	// SetHealthCheckProtectionMode manual override health check protection mode of the whole cluster,
+	// the override is saved in the store and synchronized by other nodes periodically
+	SetHealthCheckProtectionMode(ctx context.Context, mode string) (*healthcheck.ProtectionState, error)
 }
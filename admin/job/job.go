@@ -53,6 +53,8 @@ func NewMaintainJobs(namingServer service.DiscoverServer, cacheMgn *cache.CacheM
 				storage: storage},
 			"CleanDeletedResources": &cleanDeletedResourceJob{
 				storage: storage},
+			"CleanOperationRecords": &cleanOperationRecordJob{
+				storage: storage},
 		},
 		startedJobs: map[string]maintainJob{},
 		storage:     storage,
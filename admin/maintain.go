@@ -20,7 +20,9 @@ package admin
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime/debug"
+	"strconv"
 	"time"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -34,6 +36,7 @@ import (
 	commonstore "github.com/polarismesh/polaris/common/store"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service/healthcheck"
 )
 
 func (s *Server) GetServerConnections(_ context.Context, req *ConnReq) (*ConnCountResp, error) {
@@ -172,6 +175,23 @@ func (s *Server) GetLastHeartbeat(_ context.Context, req *apiservice.Instance) *
 	return s.healthCheckServer.GetLastHeartbeat(req)
 }
 
+// GetHealthCheckProtection 查询健康检查保护模式的状态
+func (s *Server) GetHealthCheckProtection(_ context.Context) (*healthcheck.ProtectionState, error) {
+	if s.healthCheckServer == nil {
+		return nil, errors.New("health check server not open")
+	}
+	return s.healthCheckServer.ProtectionState(), nil
+}
+
+// SetHealthCheckProtectionMode 人工修改整个集群的健康检查保护模式
+func (s *Server) SetHealthCheckProtectionMode(_ context.Context,
+	mode string) (*healthcheck.ProtectionState, error) {
+	if s.healthCheckServer == nil {
+		return nil, errors.New("health check server not open")
+	}
+	return s.healthCheckServer.SetProtectionMode(healthcheck.ProtectionMode(mode))
+}
+
 func (s *Server) GetLogOutputLevel(_ context.Context) ([]ScopeLevel, error) {
 	scopes := commonlog.Scopes()
 	out := make([]ScopeLevel, 0, len(scopes))
@@ -220,3 +240,43 @@ func (svr *Server) GetCMDBInfo(ctx context.Context) ([]model.LocationView, error
 
 	return ret, nil
 }
+
+// operationRecordFilters 操作记录支持的查询条件
+var operationRecordFilters = []string{
+	"resource_type", "resource_name", "namespace", "operator", "operation_type", "start_time", "end_time",
+}
+
+// GetOperationRecords 按照资源类型、命名空间、操作人、操作类型以及时间范围分页查询操作记录
+func (s *Server) GetOperationRecords(_ context.Context, query map[string]string) (*OperationRecordsResp, error) {
+	offset, limit, err := utils.ParseOffsetAndLimit(query)
+	if err != nil {
+		return nil, err
+	}
+	filter := make(map[string]string, len(operationRecordFilters))
+	for _, key := range operationRecordFilters {
+		if value, ok := query[key]; ok && value != "" {
+			filter[key] = value
+		}
+	}
+	for _, key := range []string{"start_time", "end_time"} {
+		if value, ok := filter[key]; ok {
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("%s must be unix timestamp in seconds", key)
+			}
+		}
+	}
+
+	total, records, err := s.storage.GetOperationRecords(filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []*model.RecordEntry{}
+	}
+	return &OperationRecordsResp{
+		Total:   total,
+		Offset:  offset,
+		Limit:   limit,
+		Records: records,
+	}, nil
+}
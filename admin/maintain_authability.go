@@ -25,6 +25,7 @@ import (
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/service/healthcheck"
 )
 
 var _ AdminOperateServer = (*serverAuthAbility)(nil)
@@ -178,3 +179,44 @@ func (svr *serverAuthAbility) GetCMDBInfo(ctx context.Context) ([]model.Location
 
 	return svr.targetServer.GetCMDBInfo(ctx)
 }
+
+func (svr *serverAuthAbility) GetOperationRecords(ctx context.Context,
+	query map[string]string) (*OperationRecordsResp, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetOperationRecords")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetOperationRecords(ctx, query)
+}
+
+func (svr *serverAuthAbility) GetHealthCheckProtection(ctx context.Context) (*healthcheck.ProtectionState, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Read, "GetHealthCheckProtection")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.GetHealthCheckProtection(ctx)
+}
+
+func (svr *serverAuthAbility) SetHealthCheckProtectionMode(ctx context.Context,
+	mode string) (*healthcheck.ProtectionState, error) {
+	authCtx := svr.collectMaintainAuthContext(ctx, model.Modify, "SetHealthCheckProtectionMode")
+	_, err := svr.strategyMgn.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.targetServer.SetHealthCheckProtectionMode(ctx, mode)
+}
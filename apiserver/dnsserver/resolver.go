@@ -0,0 +1,449 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"math"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// defaultUDPSize 未携带 EDNS0 时 UDP 响应的最大长度
+	defaultUDPSize = 512
+	// ednsUDPSize 本服务通过 EDNS0 声明的 UDP 响应长度
+	ednsUDPSize = 1232
+)
+
+// resolveResult 单次查询的处理结果
+type resolveResult struct {
+	resp     []byte
+	api      string
+	question string
+	rcode    dnsmessage.RCode
+}
+
+// code 将 DNS 响应码转换为统计使用的返回码
+func (r *resolveResult) code() int {
+	switch r.rcode {
+	case dnsmessage.RCodeSuccess:
+		return 200
+	case dnsmessage.RCodeFormatError:
+		return 400
+	case dnsmessage.RCodeRefused:
+		return 403
+	case dnsmessage.RCodeNameError:
+		return 404
+	case dnsmessage.RCodeNotImplemented:
+		return 501
+	default:
+		return 500
+	}
+}
+
+// dnsTarget 查询域名解析出来的服务信息
+type dnsTarget struct {
+	service *model.Service
+	// host 查询 SRV 记录 target 时，域名中携带的实例地址标签
+	host string
+	// base 服务对应的域名，格式为 <service>.<namespace>.<domain>.
+	base string
+}
+
+// dnsResolver 基于服务发现缓存应答 DNS 查询
+type dnsResolver struct {
+	domain   string
+	cacheMgr cachetypes.CacheManager
+}
+
+func newDNSResolver(domain string, cacheMgr cachetypes.CacheManager) *dnsResolver {
+	return &dnsResolver{
+		domain:   domain,
+		cacheMgr: cacheMgr,
+	}
+}
+
+// ttl 记录的 TTL 与缓存的刷新间隔保持一致，客户端缓存的结果不会比服务端缓存更旧
+func (r *dnsResolver) ttl() uint32 {
+	ttl := uint32(math.Ceil(r.cacheMgr.GetUpdateCacheInterval().Seconds()))
+	if ttl < 1 {
+		ttl = 1
+	}
+	return ttl
+}
+
+// resolve 解析请求报文并生成响应报文，无法识别的报文不做响应
+func (r *dnsResolver) resolve(req []byte, udp bool) *resolveResult {
+	result := &resolveResult{api: "Unknown", rcode: dnsmessage.RCodeFormatError}
+	var p dnsmessage.Parser
+	hdr, err := p.Start(req)
+	if err != nil || hdr.Response {
+		return result
+	}
+	resp := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               hdr.ID,
+			Response:         true,
+			OpCode:           hdr.OpCode,
+			Authoritative:    true,
+			RecursionDesired: hdr.RecursionDesired,
+			RCode:            dnsmessage.RCodeFormatError,
+		},
+	}
+	maxSize := maxMessageSize
+	q, err := p.Question()
+	if err == nil {
+		resp.Questions = []dnsmessage.Question{q}
+		result.api = q.Type.String()
+		result.question = q.Name.String()
+		if size, ok := parseEDNS(&p); ok {
+			resp.Additionals = append(resp.Additionals, newOPTResource())
+			if udp {
+				maxSize = size
+			}
+		} else if udp {
+			maxSize = defaultUDPSize
+		}
+		switch {
+		case hdr.OpCode != 0:
+			resp.Header.RCode = dnsmessage.RCodeNotImplemented
+		case q.Class != dnsmessage.ClassINET && q.Class != dnsmessage.ClassANY:
+			resp.Header.RCode = dnsmessage.RCodeRefused
+		default:
+			r.answer(resp, q)
+		}
+	}
+	result.rcode = resp.Header.RCode
+
+	data, err := resp.Pack()
+	if err != nil {
+		log.Errorf("[DNS] pack response of %s error: %v", result.question, err)
+		return result
+	}
+	if len(data) > maxSize {
+		// 超过 UDP 响应长度时仅返回截断标记，由客户端改用 TCP 重试
+		resp.Header.Truncated = true
+		resp.Answers = nil
+		resp.Authorities = nil
+		resp.Additionals = filterOPT(resp.Additionals)
+		if data, err = resp.Pack(); err != nil {
+			log.Errorf("[DNS] pack truncated response of %s error: %v", result.question, err)
+			return result
+		}
+	}
+	result.resp = data
+	return result
+}
+
+// answer 根据查询类型填充应答记录
+func (r *dnsResolver) answer(resp *dnsmessage.Message, q dnsmessage.Question) {
+	name := q.Name.String()
+	if !strings.HasSuffix(strings.ToLower(name), "."+r.domain) {
+		resp.Header.RCode = dnsmessage.RCodeRefused
+		return
+	}
+	target := r.lookup(name, q.Type)
+	if target == nil {
+		resp.Header.RCode = dnsmessage.RCodeNameError
+		resp.Authorities = r.soaRecords()
+		return
+	}
+	instances := r.discoverInstances(target)
+	if target.host != "" && len(instances) == 0 {
+		resp.Header.RCode = dnsmessage.RCodeNameError
+		resp.Authorities = r.soaRecords()
+		return
+	}
+	resp.Header.RCode = dnsmessage.RCodeSuccess
+	switch q.Type {
+	case dnsmessage.TypeA:
+		resp.Answers = r.addressRecords(q.Name, instances, false)
+	case dnsmessage.TypeAAAA:
+		resp.Answers = r.addressRecords(q.Name, instances, true)
+	case dnsmessage.TypeSRV:
+		var extras []dnsmessage.Resource
+		resp.Answers, extras = r.srvRecords(q.Name, target, instances)
+		resp.Additionals = append(extras, resp.Additionals...)
+	}
+	if len(resp.Answers) == 0 {
+		resp.Authorities = r.soaRecords()
+	}
+}
+
+// lookup 解析 <service>.<namespace>.<domain>. 格式的域名，service 中允许包含 '.'。
+// 域名后缀不区分大小写，服务名与命名空间按原样匹配；SRV 查询允许携带 RFC 2782 中的
+// _service._proto 前缀
+func (r *dnsResolver) lookup(name string, qtype dnsmessage.Type) *dnsTarget {
+	prefix := strings.TrimSuffix(name[:len(name)-len(r.domain)], ".")
+	labels := strings.Split(prefix, ".")
+	if qtype == dnsmessage.TypeSRV {
+		for i := 0; i < 2 && len(labels) > 2 && strings.HasPrefix(labels[0], "_"); i++ {
+			labels = labels[1:]
+		}
+	}
+	if len(labels) < 2 {
+		return nil
+	}
+	namespace := labels[len(labels)-1]
+	if svc := r.findService(strings.Join(labels[:len(labels)-1], "."), namespace); svc != nil {
+		return &dnsTarget{service: svc, base: strings.Join(labels, ".") + "." + r.domain}
+	}
+	// SRV 记录中 target 的格式为 <host>.<service>.<namespace>.<domain>.
+	if len(labels) > 2 {
+		if svc := r.findService(strings.Join(labels[1:len(labels)-1], "."), namespace); svc != nil {
+			return &dnsTarget{
+				service: svc,
+				host:    strings.ToLower(labels[0]),
+				base:    strings.Join(labels[1:], ".") + "." + r.domain,
+			}
+		}
+	}
+	return nil
+}
+
+// findService 查找服务，别名返回其指向的源服务
+func (r *dnsResolver) findService(name, namespace string) *model.Service {
+	if name == "" || namespace == "" {
+		return nil
+	}
+	svc := r.cacheMgr.Service().GetServiceByName(name, namespace)
+	if svc != nil && svc.IsAlias() {
+		svc = r.cacheMgr.Service().GetServiceByID(svc.Reference)
+	}
+	return svc
+}
+
+// discoverInstances 获取可以对外提供服务的实例，剔除隔离以及权重为 0 的实例
+func (r *dnsResolver) discoverInstances(target *dnsTarget) []*model.Instance {
+	instances := r.cacheMgr.Instance().DiscoverServiceInstances(target.service.ID, true)
+	ret := make([]*model.Instance, 0, len(instances))
+	for _, ins := range instances {
+		if ins.Isolate() || ins.Weight() == 0 {
+			continue
+		}
+		if target.host != "" && hostLabel(ins.Host()) != target.host {
+			continue
+		}
+		ret = append(ret, ins)
+	}
+	return ret
+}
+
+// addressRecords 生成 A/AAAA 记录，相同地址的实例合并权重，记录顺序按照权重随机打散
+func (r *dnsResolver) addressRecords(name dnsmessage.Name, instances []*model.Instance,
+	ipv6 bool) []dnsmessage.Resource {
+	weights := map[string]uint32{}
+	ips := map[string]net.IP{}
+	hosts := make([]string, 0, len(instances))
+	for _, ins := range instances {
+		ip := parseIP(ins.Host(), ipv6)
+		if ip == nil {
+			continue
+		}
+		key := ip.String()
+		if _, ok := weights[key]; !ok {
+			hosts = append(hosts, key)
+			ips[key] = ip
+		}
+		weights[key] += ins.Weight()
+	}
+	shuffleByWeight(hosts, weights)
+
+	ttl := r.ttl()
+	records := make([]dnsmessage.Resource, 0, len(hosts))
+	for _, host := range hosts {
+		records = append(records, newAddressResource(name, ips[host], ttl))
+	}
+	return records
+}
+
+// srvRecords 生成 SRV 记录，地址为 IP 的实例通过附加记录返回 target 对应的地址
+func (r *dnsResolver) srvRecords(name dnsmessage.Name, target *dnsTarget,
+	instances []*model.Instance) ([]dnsmessage.Resource, []dnsmessage.Resource) {
+	sorted := make([]*model.Instance, len(instances))
+	copy(sorted, instances)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Priority() != sorted[j].Priority() {
+			return sorted[i].Priority() < sorted[j].Priority()
+		}
+		return sorted[i].ID() < sorted[j].ID()
+	})
+
+	ttl := r.ttl()
+	answers := make([]dnsmessage.Resource, 0, len(sorted))
+	extras := make([]dnsmessage.Resource, 0, len(sorted))
+	added := map[string]struct{}{}
+	for _, ins := range sorted {
+		ip := net.ParseIP(ins.Host())
+		targetName := ins.Host()
+		if ip != nil {
+			targetName = hostLabel(ins.Host()) + "." + target.base
+		}
+		srvTarget, err := dnsmessage.NewName(strings.TrimSuffix(targetName, ".") + ".")
+		if err != nil {
+			log.Warnf("[DNS] skip instance %s, invalid srv target %s: %v", ins.ID(), targetName, err)
+			continue
+		}
+		answers = append(answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body: &dnsmessage.SRVResource{
+				Priority: uint16(minUint32(ins.Priority(), math.MaxUint16)),
+				Weight:   uint16(minUint32(ins.Weight(), math.MaxUint16)),
+				Port:     uint16(ins.Port()),
+				Target:   srvTarget,
+			},
+		})
+		if _, ok := added[targetName]; ip == nil || ok {
+			continue
+		}
+		added[targetName] = struct{}{}
+		extras = append(extras, newAddressResource(srvTarget, ip, ttl))
+	}
+	return answers, extras
+}
+
+// soaRecords 否定应答携带 SOA 记录，客户端据此缓存否定结果
+func (r *dnsResolver) soaRecords() []dnsmessage.Resource {
+	zone, err := dnsmessage.NewName(r.domain)
+	if err != nil {
+		return nil
+	}
+	ns, _ := dnsmessage.NewName("ns." + r.domain)
+	mbox, _ := dnsmessage.NewName("hostmaster." + r.domain)
+	ttl := r.ttl()
+	return []dnsmessage.Resource{
+		{
+			Header: dnsmessage.ResourceHeader{Name: zone, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body: &dnsmessage.SOAResource{
+				NS:      ns,
+				MBox:    mbox,
+				Serial:  uint32(time.Now().Unix()),
+				Refresh: 3600,
+				Retry:   600,
+				Expire:  86400,
+				MinTTL:  ttl,
+			},
+		},
+	}
+}
+
+// parseEDNS 读取请求中 OPT 记录声明的 UDP 报文长度
+func parseEDNS(p *dnsmessage.Parser) (int, bool) {
+	if err := p.SkipAllQuestions(); err != nil {
+		return 0, false
+	}
+	if err := p.SkipAllAnswers(); err != nil {
+		return 0, false
+	}
+	if err := p.SkipAllAuthorities(); err != nil {
+		return 0, false
+	}
+	additionals, err := p.AllAdditionals()
+	if err != nil {
+		return 0, false
+	}
+	for _, rr := range additionals {
+		if rr.Header.Type != dnsmessage.TypeOPT {
+			continue
+		}
+		size := int(rr.Header.Class)
+		if size < defaultUDPSize {
+			size = defaultUDPSize
+		}
+		if size > ednsUDPSize {
+			size = ednsUDPSize
+		}
+		return size, true
+	}
+	return 0, false
+}
+
+func newOPTResource() dnsmessage.Resource {
+	var rh dnsmessage.ResourceHeader
+	_ = rh.SetEDNS0(ednsUDPSize, dnsmessage.RCodeSuccess, false)
+	return dnsmessage.Resource{Header: rh, Body: &dnsmessage.OPTResource{}}
+}
+
+func filterOPT(records []dnsmessage.Resource) []dnsmessage.Resource {
+	ret := make([]dnsmessage.Resource, 0, 1)
+	for _, rr := range records {
+		if rr.Header.Type == dnsmessage.TypeOPT {
+			ret = append(ret, rr)
+		}
+	}
+	return ret
+}
+
+func newAddressResource(name dnsmessage.Name, ip net.IP, ttl uint32) dnsmessage.Resource {
+	header := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl}
+	if ip4 := ip.To4(); ip4 != nil {
+		body := &dnsmessage.AResource{}
+		copy(body.A[:], ip4)
+		return dnsmessage.Resource{Header: header, Body: body}
+	}
+	body := &dnsmessage.AAAAResource{}
+	copy(body.AAAA[:], ip.To16())
+	return dnsmessage.Resource{Header: header, Body: body}
+}
+
+// parseIP 解析实例地址，地址族与查询类型不一致时返回 nil
+func parseIP(host string, ipv6 bool) net.IP {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	if (ip.To4() == nil) != ipv6 {
+		return nil
+	}
+	return ip
+}
+
+// hostLabel 将 IP 地址转换为可以作为域名标签的格式，例如 10.0.0.1 转换为 10-0-0-1
+func hostLabel(host string) string {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	return strings.NewReplacer(".", "-", ":", "-").Replace(ip.String())
+}
+
+// shuffleByWeight 按照权重进行不放回的随机排序，权重越大越靠前的概率越高
+func shuffleByWeight(hosts []string, weights map[string]uint32) {
+	keys := make(map[string]float64, len(hosts))
+	for _, host := range hosts {
+		keys[host] = -math.Log(1-rand.Float64()) / float64(weights[host])
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return keys[hosts[i]] < keys[hosts[j]]
+	})
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,213 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	mockcache "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func mockInstance(id, host string, port, weight uint32, isolate bool) *model.Instance {
+	return &model.Instance{
+		Proto: &apiservice.Instance{
+			Id:       wrapperspb.String(id),
+			Host:     wrapperspb.String(host),
+			Port:     wrapperspb.UInt32(port),
+			Weight:   wrapperspb.UInt32(weight),
+			Priority: wrapperspb.UInt32(0),
+			Healthy:  wrapperspb.Bool(true),
+			Isolate:  wrapperspb.Bool(isolate),
+		},
+	}
+}
+
+func testInstances() []*model.Instance {
+	return []*model.Instance{
+		mockInstance("ins-1", "10.0.0.1", 8080, 100, false),
+		mockInstance("ins-2", "10.0.0.1", 8081, 100, false),
+		mockInstance("ins-3", "10.0.0.2", 8080, 50, false),
+		mockInstance("ins-4", "10.0.0.3", 8080, 100, true),
+		mockInstance("ins-5", "10.0.0.4", 8080, 0, false),
+		mockInstance("ins-6", "fd00::1", 8080, 100, false),
+		mockInstance("ins-7", "echo.example.com", 8080, 100, false),
+	}
+}
+
+func newTestResolver(t *testing.T, instances []*model.Instance) *dnsResolver {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	svc := &model.Service{ID: "svc-1", Name: "echo.server", Namespace: "default"}
+	alias := &model.Service{ID: "svc-2", Name: "echo-alias", Namespace: "default", Reference: svc.ID}
+
+	svcCache := mockcache.NewMockServiceCache(ctrl)
+	svcCache.EXPECT().GetServiceByName(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(name, namespace string) *model.Service {
+			if namespace != "default" {
+				return nil
+			}
+			switch name {
+			case svc.Name:
+				return svc
+			case alias.Name:
+				return alias
+			}
+			return nil
+		}).AnyTimes()
+	svcCache.EXPECT().GetServiceByID(svc.ID).Return(svc).AnyTimes()
+
+	insCache := mockcache.NewMockInstanceCache(ctrl)
+	insCache.EXPECT().DiscoverServiceInstances(svc.ID, true).Return(instances).AnyTimes()
+
+	cacheMgr := mockcache.NewMockCacheManager(ctrl)
+	cacheMgr.EXPECT().Service().Return(svcCache).AnyTimes()
+	cacheMgr.EXPECT().Instance().Return(insCache).AnyTimes()
+	cacheMgr.EXPECT().GetUpdateCacheInterval().Return(1500 * time.Millisecond).AnyTimes()
+
+	return newDNSResolver(normalizeDomain("Polaris."), cacheMgr)
+}
+
+func query(t *testing.T, r *dnsResolver, name string, qtype dnsmessage.Type) *dnsmessage.Message {
+	req := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 1024, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	data, err := req.Pack()
+	assert.NoError(t, err)
+	result := r.resolve(data, false)
+	assert.NotNil(t, result.resp)
+
+	resp := &dnsmessage.Message{}
+	assert.NoError(t, resp.Unpack(result.resp))
+	assert.Equal(t, req.Header.ID, resp.Header.ID)
+	assert.True(t, resp.Header.Response)
+	assert.Equal(t, resp.Header.RCode, result.rcode)
+	return resp
+}
+
+func TestDNSResolver_Resolve(t *testing.T) {
+	r := newTestResolver(t, testInstances())
+
+	t.Run("a", func(t *testing.T) {
+		resp := query(t, r, "echo.server.default.polaris.", dnsmessage.TypeA)
+		assert.Equal(t, dnsmessage.RCodeSuccess, resp.Header.RCode)
+		ips := map[[4]byte]struct{}{}
+		for _, rr := range resp.Answers {
+			assert.Equal(t, uint32(2), rr.Header.TTL)
+			ips[rr.Body.(*dnsmessage.AResource).A] = struct{}{}
+		}
+		assert.Equal(t, map[[4]byte]struct{}{{10, 0, 0, 1}: {}, {10, 0, 0, 2}: {}}, ips)
+		assert.Len(t, resp.Answers, 2)
+	})
+
+	t.Run("aaaa", func(t *testing.T) {
+		resp := query(t, r, "echo-alias.default.POLARIS.", dnsmessage.TypeAAAA)
+		assert.Equal(t, dnsmessage.RCodeSuccess, resp.Header.RCode)
+		assert.Len(t, resp.Answers, 1)
+		expect := [16]byte{0xfd, 15: 1}
+		assert.Equal(t, expect, resp.Answers[0].Body.(*dnsmessage.AAAAResource).AAAA)
+	})
+
+	t.Run("srv", func(t *testing.T) {
+		resp := query(t, r, "_http._tcp.echo.server.default.polaris.", dnsmessage.TypeSRV)
+		assert.Equal(t, dnsmessage.RCodeSuccess, resp.Header.RCode)
+		assert.Len(t, resp.Answers, 5)
+		targets := map[string]*dnsmessage.SRVResource{}
+		for _, rr := range resp.Answers {
+			srv := rr.Body.(*dnsmessage.SRVResource)
+			targets[fmt.Sprintf("%s:%d", srv.Target.String(), srv.Port)] = srv
+		}
+		assert.Equal(t, uint16(50), targets["10-0-0-2.echo.server.default.polaris.:8080"].Weight)
+		assert.Contains(t, targets, "10-0-0-1.echo.server.default.polaris.:8081")
+		assert.Contains(t, targets, "fd00--1.echo.server.default.polaris.:8080")
+		assert.Contains(t, targets, "echo.example.com.:8080")
+		// 相同地址的 target 只返回一条附加记录
+		assert.Len(t, resp.Additionals, 3)
+
+		resp = query(t, r, "10-0-0-2.echo.server.default.polaris.", dnsmessage.TypeA)
+		assert.Equal(t, dnsmessage.RCodeSuccess, resp.Header.RCode)
+		assert.Len(t, resp.Answers, 1)
+		assert.Equal(t, [4]byte{10, 0, 0, 2}, resp.Answers[0].Body.(*dnsmessage.AResource).A)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		resp := query(t, r, "unknown.default.polaris.", dnsmessage.TypeA)
+		assert.Equal(t, dnsmessage.RCodeNameError, resp.Header.RCode)
+		assert.Len(t, resp.Authorities, 1)
+
+		// 隔离实例的地址不可解析
+		resp = query(t, r, "10-0-0-3.echo.server.default.polaris.", dnsmessage.TypeA)
+		assert.Equal(t, dnsmessage.RCodeNameError, resp.Header.RCode)
+
+		resp = query(t, r, "echo.server.default.polaris.", dnsmessage.TypeTXT)
+		assert.Equal(t, dnsmessage.RCodeSuccess, resp.Header.RCode)
+		assert.Empty(t, resp.Answers)
+		assert.Len(t, resp.Authorities, 1)
+
+		resp = query(t, r, "echo.server.default.example.com.", dnsmessage.TypeA)
+		assert.Equal(t, dnsmessage.RCodeRefused, resp.Header.RCode)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		result := r.resolve([]byte{0x01}, true)
+		assert.Nil(t, result.resp)
+		assert.Equal(t, 400, result.code())
+	})
+}
+
+func TestDNSResolver_Truncate(t *testing.T) {
+	instances := make([]*model.Instance, 0, 100)
+	for i := 0; i < 100; i++ {
+		instances = append(instances, mockInstance(fmt.Sprintf("ins-%d", i), fmt.Sprintf("10.0.1.%d", i), 8080, 100, false))
+	}
+	r := newTestResolver(t, instances)
+
+	req := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 1},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(
+			"echo.server.default.polaris."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}
+	data, err := req.Pack()
+	assert.NoError(t, err)
+
+	// UDP 响应超过 512 字节时返回截断标记
+	result := r.resolve(data, true)
+	resp := &dnsmessage.Message{}
+	assert.NoError(t, resp.Unpack(result.resp))
+	assert.True(t, resp.Header.Truncated)
+	assert.Empty(t, resp.Answers)
+
+	// TCP 响应返回全部记录
+	result = r.resolve(data, false)
+	resp = &dnsmessage.Message{}
+	assert.NoError(t, resp.Unpack(result.resp))
+	assert.False(t, resp.Header.Truncated)
+	assert.Len(t, resp.Answers, 100)
+}
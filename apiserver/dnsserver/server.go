@@ -0,0 +1,273 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/apiserver"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/service"
+)
+
+const (
+	defaultDomain = "polaris"
+	// maxMessageSize DNS 报文的最大长度
+	maxMessageSize = 65535
+	// tcpIdleTimeout TCP 链接的空闲超时时间
+	tcpIdleTimeout = 10 * time.Second
+	// defaultUDPWorkers 处理 UDP 查询的协程数量
+	defaultUDPWorkers = 64
+	// defaultUDPQueueSize 等待处理的 UDP 查询的最大数量，队列满时直接丢弃查询，由客户端重试
+	defaultUDPQueueSize = 1024
+	// udpDropLogInterval 丢弃 UDP 查询的日志打印间隔
+	udpDropLogInterval = 10 * time.Second
+)
+
+// udpRequest 等待处理的 UDP 查询
+type udpRequest struct {
+	req  []byte
+	addr net.Addr
+}
+
+// DNSServer 基于 DNS 协议的服务发现 API 服务器，支持 A/AAAA/SRV 查询
+type DNSServer struct {
+	listenIP   string
+	listenPort uint32
+	domain     string // 服务域名后缀，格式为 <service>.<namespace>.<domain>.
+
+	udpWorkers   int
+	udpQueueSize int
+
+	udpConn  net.PacketConn
+	listener net.Listener
+	resolver *dnsResolver
+	statis   plugin.Statis
+}
+
+// GetPort 获取端口
+func (d *DNSServer) GetPort() uint32 {
+	return d.listenPort
+}
+
+// GetProtocol 获取Server的协议
+func (d *DNSServer) GetProtocol() string {
+	return "dns"
+}
+
+// Initialize 初始化DNS API服务器
+func (d *DNSServer) Initialize(_ context.Context, option map[string]interface{},
+	_ map[string]apiserver.APIConfig) error {
+	d.listenIP = option["listenIP"].(string)
+	d.listenPort = uint32(option["listenPort"].(int))
+	d.domain = normalizeDomain(defaultDomain)
+	if domain, _ := option["domain"].(string); strings.Trim(domain, ".") != "" {
+		d.domain = normalizeDomain(domain)
+	}
+	d.udpWorkers = defaultUDPWorkers
+	if workers, _ := option["udpWorkers"].(int); workers > 0 {
+		d.udpWorkers = workers
+	}
+	d.udpQueueSize = defaultUDPQueueSize
+	if queueSize, _ := option["udpQueueSize"].(int); queueSize > 0 {
+		d.udpQueueSize = queueSize
+	}
+	return nil
+}
+
+// Run 启动DNS API服务器，同时监听 UDP 与 TCP
+func (d *DNSServer) Run(errCh chan error) {
+	log.Infof("start dnsserver")
+
+	namingServer, err := service.GetServer()
+	if err != nil {
+		log.Errorf("%v", err)
+		errCh <- err
+		return
+	}
+	d.statis = plugin.GetStatis()
+	d.resolver = newDNSResolver(d.domain, namingServer.Cache())
+
+	address := fmt.Sprintf("%v:%v", d.listenIP, d.listenPort)
+	udpConn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		log.Errorf("listen udp error: %v", err)
+		errCh <- err
+		return
+	}
+	d.udpConn = udpConn
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Errorf("listen tcp error: %v", err)
+		_ = udpConn.Close()
+		errCh <- err
+		return
+	}
+	d.listener = listener
+
+	go d.serveTCP(errCh)
+	d.serveUDP(errCh)
+}
+
+// Stop server
+func (d *DNSServer) Stop() {
+	if d.udpConn != nil {
+		_ = d.udpConn.Close()
+	}
+	if d.listener != nil {
+		_ = d.listener.Close()
+	}
+}
+
+// Restart restart server
+func (d *DNSServer) Restart(_ map[string]interface{}, _ map[string]apiserver.APIConfig,
+	_ chan error) error {
+	return nil
+}
+
+// serveUDP 由固定数量的协程处理 UDP 查询，处理不过来时丢弃查询，避免突发流量创建大量的协程
+func (d *DNSServer) serveUDP(errCh chan error) {
+	queue := make(chan *udpRequest, d.udpQueueSize)
+	defer close(queue)
+	for i := 0; i < d.udpWorkers; i++ {
+		go d.udpWorker(queue)
+	}
+
+	var (
+		dropped     int64
+		lastDropLog time.Time
+	)
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := d.udpConn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Errorf("read udp error: %v", err)
+			errCh <- err
+			return
+		}
+		req := make([]byte, n)
+		copy(req, buf[:n])
+		select {
+		case queue <- &udpRequest{req: req, addr: addr}:
+		default:
+			dropped++
+			if time.Since(lastDropLog) >= udpDropLogInterval {
+				log.Warn("[DNS] udp queue is full, drop queries", zap.Int64("dropped", dropped),
+					zap.Int("queue-size", d.udpQueueSize))
+				lastDropLog = time.Now()
+				dropped = 0
+			}
+		}
+	}
+}
+
+func (d *DNSServer) udpWorker(queue <-chan *udpRequest) {
+	for item := range queue {
+		resp := d.handle(item.req, item.addr.String(), true)
+		if resp == nil {
+			continue
+		}
+		if _, err := d.udpConn.WriteTo(resp, item.addr); err != nil {
+			log.Warn("[DNS] write udp response", zap.String("client-addr", item.addr.String()), zap.Error(err))
+		}
+	}
+}
+
+func (d *DNSServer) serveTCP(errCh chan error) {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Errorf("accept error: %v", err)
+			errCh <- err
+			return
+		}
+		go d.handleConnection(conn)
+	}
+}
+
+// handleConnection TCP 报文以两字节长度作为前缀，同一个链接上可以发送多个查询
+func (d *DNSServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+	clientAddr := conn.RemoteAddr().String()
+	lenBuf := make([]byte, 2)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(tcpIdleTimeout))
+		if _, err := io.ReadFull(conn, lenBuf); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint16(lenBuf))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		resp := d.handle(req, clientAddr, false)
+		if resp == nil {
+			return
+		}
+		out := make([]byte, 2+len(resp))
+		binary.BigEndian.PutUint16(out, uint16(len(resp)))
+		copy(out[2:], resp)
+		if _, err := conn.Write(out); err != nil {
+			log.Warn("[DNS] write tcp response", zap.String("client-addr", clientAddr), zap.Error(err))
+			return
+		}
+	}
+}
+
+// handle 处理单个查询报文，并上报统计
+func (d *DNSServer) handle(req []byte, clientAddr string, udp bool) []byte {
+	start := time.Now()
+	result := d.resolver.resolve(req, udp)
+	diff := time.Since(start)
+	if diff > time.Second {
+		log.Info("handling time > 1s",
+			zap.String("client-addr", clientAddr),
+			zap.String("question", result.question),
+			zap.Duration("handling-time", diff),
+		)
+	}
+	if d.statis != nil {
+		d.statis.ReportCallMetrics(metrics.CallMetric{
+			Type:     metrics.ServerCallMetric,
+			API:      result.api,
+			Protocol: "DNS",
+			Code:     result.code(),
+			Duration: diff,
+		})
+	}
+	return result.resp
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.Trim(domain, ".")) + "."
+}
@@ -0,0 +1,87 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package dnsserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDNSServer_ServeUDP(t *testing.T) {
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	d := &DNSServer{
+		udpWorkers:   2,
+		udpQueueSize: 16,
+		udpConn:      udpConn,
+		resolver:     newTestResolver(t, testInstances()),
+	}
+	errCh := make(chan error, 1)
+	stopped := make(chan struct{})
+	go func() {
+		d.serveUDP(errCh)
+		close(stopped)
+	}()
+
+	client, err := net.Dial("udp", udpConn.LocalAddr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+
+	// 查询数量多于处理协程的数量，由固定的协程依次处理
+	const total = 8
+	for i := 0; i < total; i++ {
+		req := dnsmessage.Message{
+			Header: dnsmessage.Header{ID: uint16(i), RecursionDesired: true},
+			Questions: []dnsmessage.Question{
+				{Name: dnsmessage.MustNewName("echo.server.default.polaris."), Type: dnsmessage.TypeA,
+					Class: dnsmessage.ClassINET},
+			},
+		}
+		data, err := req.Pack()
+		assert.NoError(t, err)
+		_, err = client.Write(data)
+		assert.NoError(t, err)
+	}
+
+	ids := map[uint16]struct{}{}
+	buf := make([]byte, maxMessageSize)
+	_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(ids) < total {
+		n, err := client.Read(buf)
+		if !assert.NoError(t, err) {
+			break
+		}
+		resp := &dnsmessage.Message{}
+		assert.NoError(t, resp.Unpack(buf[:n]))
+		assert.Equal(t, dnsmessage.RCodeSuccess, resp.Header.RCode)
+		assert.Len(t, resp.Answers, 2)
+		ids[resp.Header.ID] = struct{}{}
+	}
+
+	d.Stop()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve udp not stopped")
+	}
+	assert.Empty(t, errCh)
+}
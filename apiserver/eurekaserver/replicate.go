@@ -202,6 +202,10 @@ func (h *EurekaServer) shouldReplicate(e model.InstanceEvent) bool {
 		return false
 	}
 
+	// 健康检查保护等服务级别的事件，不需要同步
+	if e.Instance == nil {
+		return false
+	}
 	if len(e.Service) == 0 {
 		eurekalog.Warnf("[EUREKA]fail to replicate, service name is empty for event %s", e)
 		return false
@@ -128,6 +128,7 @@ func TestEurekaServer_renew(t *testing.T) {
 	mockStore.EXPECT().GetUnixSecond(gomock.Any()).AnyTimes().Return(time.Now().Unix(), nil)
 	mockStore.EXPECT().GetServicesCount().Return(uint32(1), nil).AnyTimes()
 	mockStore.EXPECT().StartLeaderElection(gomock.Any()).AnyTimes()
+	mockStore.EXPECT().GetHealthCheckProtection().Return(nil, nil).AnyTimes()
 	mockStore.EXPECT().GetMoreNamespaces(gomock.Any()).Return(nil, nil).AnyTimes()
 	mockStore.EXPECT().Destroy().Return(nil)
 	mockStore.EXPECT().Initialize(gomock.Any()).Return(nil).AnyTimes()
@@ -23,15 +23,22 @@ import (
 	"net"
 	"net/http"
 	"runtime"
+	"strings"
 	"time"
 
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/polarismesh/polaris/auth"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	connhook "github.com/polarismesh/polaris/common/conn/hook"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
@@ -52,6 +59,7 @@ type BaseGrpcServer struct {
 	listenPort      uint32
 	connLimitConfig *connlimit.Config
 	tlsInfo         *secure.TLSInfo
+	certReloader    *secure.CertReloader
 	start           bool
 	restart         bool
 	exitCh          chan struct{}
@@ -63,6 +71,7 @@ type BaseGrpcServer struct {
 	server     *grpc.Server
 	statis     plugin.Statis
 	ratelimit  plugin.Ratelimit
+	userMgn    auth.UserServer
 	OpenMethod map[string]bool
 
 	cache   Cache
@@ -98,16 +107,18 @@ func (b *BaseGrpcServer) Initialize(ctx context.Context, conf map[string]interfa
 		if err != nil {
 			return err
 		}
-		b.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
+		if b.tlsInfo, err = tlsConfig.ToTLSInfo(); err != nil {
+			return err
 		}
 	}
 
 	if ratelimit := plugin.GetRatelimit(); ratelimit != nil {
 		b.log.Infof("[API-Server] %s server open the ratelimit", b.protocol)
 		b.ratelimit = ratelimit
+		// 操作者限流需要解析 token，鉴权模块未初始化时不进行操作者限流
+		if userMgn, err := auth.GetUserServer(); err == nil {
+			b.userMgn = userMgn
+		}
 	}
 
 	return nil
@@ -119,6 +130,9 @@ func (b *BaseGrpcServer) Stop(protocol string) {
 	if b.server != nil {
 		b.server.Stop()
 	}
+	if b.certReloader != nil {
+		b.certReloader.Stop()
+	}
 }
 
 // Run server main loop
@@ -157,15 +171,18 @@ func (b *BaseGrpcServer) Run(errCh chan error, protocol string, initServer InitS
 		bz: b.bz,
 	})
 
-	// 指定使用服务端证书创建一个 TLS credentials
+	// 指定使用服务端证书创建一个 TLS credentials，证书以及信任的 CA 更新后自动重新加载
 	var creds credentials.TransportCredentials
 	if !b.tlsInfo.IsEmpty() {
-		creds, err = credentials.NewServerTLSFromFile(b.tlsInfo.CertFile, b.tlsInfo.KeyFile)
+		b.certReloader, err = secure.NewCertReloader(b.tlsInfo)
 		if err != nil {
 			b.log.Error("failed to create credentials: %v", zap.Error(err))
 			errCh <- err
 			return
 		}
+		b.certReloader.Start()
+		defer b.certReloader.Stop()
+		creds = credentials.NewTLS(b.certReloader.ServerTLSConfig())
 	}
 
 	// 设置 grpc server options
@@ -202,6 +219,7 @@ var notPrintableMethods = map[string]bool{
 
 func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+	ctx = b.withCertPrincipal(ctx)
 	stream := newVirtualStream(ctx,
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamLogger(b.log),
@@ -224,7 +242,12 @@ func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 		}
 
 		// handler执行前，限流
-		if code := b.EnterRatelimit(stream.ClientIP, stream.Method); code != uint32(api.ExecuteSuccess) {
+		code, backoff := b.enterRatelimit(stream.ClientIP, stream.Method)
+		if code == api.ExecuteSuccess {
+			code, backoff = b.enterRequestRatelimit(ctx, stream.Method, req)
+		}
+		if code != api.ExecuteSuccess {
+			setRetryAfter(ctx, backoff)
 			rsp = api.NewResponse(apimodel.Code(code))
 			return
 		}
@@ -244,13 +267,33 @@ func (b *BaseGrpcServer) unaryInterceptor(ctx context.Context, req interface{},
 	return
 }
 
+// withCertPrincipal 双向 tls 下将客户端证书的身份映射为 principal 并注入到 ctx 中
+func (b *BaseGrpcServer) withCertPrincipal(ctx context.Context) context.Context {
+	if !b.tlsInfo.IsMutual() {
+		return ctx
+	}
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+	principal := b.tlsInfo.MapPrincipal(tlsInfo.State.PeerCertificates)
+	if principal == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, utils.ContextCertPrincipalKey, principal)
+}
+
 func (b *BaseGrpcServer) recoverFunc(i interface{}, w http.ResponseWriter) {
 
 }
 
 func (b *BaseGrpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream,
 	info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
-	stream := newVirtualStream(ss.Context(),
+	stream := newVirtualStream(b.withCertPrincipal(ss.Context()),
 		WithVirtualStreamBaseServer(b),
 		WithVirtualStreamServerStream(ss),
 		WithVirtualStreamMethod(info.FullMethod),
@@ -406,24 +449,126 @@ func (b *BaseGrpcServer) Restart(
 
 // EnterRatelimit api ratelimit
 func (b *BaseGrpcServer) EnterRatelimit(ip string, method string) uint32 {
+	code, _ := b.enterRatelimit(ip, method)
+	return code
+}
+
+// EnterStreamRatelimit stream 模式下对每个请求包进行限流，除了 ip 以及接口级别外还包括操作者以及命名空间级别
+func (b *BaseGrpcServer) EnterStreamRatelimit(ctx context.Context, ip string, method string,
+	req interface{}) uint32 {
+	code, _ := b.enterRatelimit(ip, method)
+	if code == api.ExecuteSuccess {
+		code, _ = b.enterRequestRatelimit(ctx, method, req)
+	}
+	return code
+}
+
+// enterRatelimit ip 以及接口级别的限流，被限流时同时返回客户端需要等待的时间
+func (b *BaseGrpcServer) enterRatelimit(ip string, method string) (uint32, time.Duration) {
 	if b.ratelimit == nil {
-		return api.ExecuteSuccess
+		return api.ExecuteSuccess, 0
 	}
 
 	// ipRatelimit
-	if ok := b.ratelimit.Allow(plugin.IPRatelimit, ip); !ok {
+	if ok, backoff := plugin.AllowWithBackoff(b.ratelimit, plugin.IPRatelimit, ip); !ok {
 		b.log.Error("[API-Server][GRPC] ip ratelimit is not allow", zap.String("client-ip", ip),
 			zap.String("method", method))
-		return api.IPRateLimit
+		return api.IPRateLimit, backoff
 	}
 	// apiRatelimit
-	if ok := b.ratelimit.Allow(plugin.APIRatelimit, method); !ok {
+	if ok, backoff := plugin.AllowWithBackoff(b.ratelimit, plugin.APIRatelimit, method); !ok {
 		b.log.Error("[API-Server][GRPC] api rate limit is not allow", zap.String("client-ip", ip),
 			zap.String("method", method))
-		return api.APIRateLimit
+		return api.APIRateLimit, backoff
+	}
+
+	return api.ExecuteSuccess, 0
+}
+
+// enterRequestRatelimit 操作者以及命名空间级别的限流，需要从请求的 token 以及请求体中解析出对应的资源
+func (b *BaseGrpcServer) enterRequestRatelimit(ctx context.Context, method string,
+	req interface{}) (uint32, time.Duration) {
+	if b.ratelimit == nil {
+		return api.ExecuteSuccess, 0
+	}
+
+	if principal := b.parsePrincipal(ctx); principal != "" {
+		if ok, backoff := plugin.AllowWithBackoff(b.ratelimit, plugin.PrincipalRatelimit, principal); !ok {
+			b.log.Error("[API-Server][GRPC] principal rate limit is not allow",
+				zap.String("principal", principal), zap.String("method", method))
+			return api.APIRateLimit, backoff
+		}
 	}
 
-	return api.ExecuteSuccess
+	if !plugin.RatelimitEnabled(b.ratelimit, plugin.NamespaceRatelimit) {
+		return api.ExecuteSuccess, 0
+	}
+	if namespace := parseRequestNamespace(req); namespace != "" {
+		if ok, backoff := plugin.AllowWithBackoff(b.ratelimit, plugin.NamespaceRatelimit, namespace); !ok {
+			b.log.Error("[API-Server][GRPC] namespace rate limit is not allow",
+				zap.String("namespace", namespace), zap.String("method", method))
+			return api.APIRateLimit, backoff
+		}
+	}
+	return api.ExecuteSuccess, 0
+}
+
+// parsePrincipal 解析请求 token 对应的操作者，操作者限流未开启或者 token 无效时返回空
+func (b *BaseGrpcServer) parsePrincipal(ctx context.Context) string {
+	if b.userMgn == nil || !plugin.RatelimitEnabled(b.ratelimit, plugin.PrincipalRatelimit) {
+		return ""
+	}
+	meta, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	tokens := meta[strings.ToLower(utils.HeaderAuthTokenKey)]
+	if len(tokens) == 0 || tokens[0] == "" {
+		return ""
+	}
+	principal, err := b.userMgn.ParsePrincipal(tokens[0])
+	if err != nil {
+		return ""
+	}
+	return principal
+}
+
+// namespaceRequest 直接携带命名空间的请求，比如实例、配置文件等
+type namespaceRequest interface {
+	GetNamespace() *wrapperspb.StringValue
+}
+
+// serviceRequest 通过服务携带命名空间的请求，比如服务发现请求
+type serviceRequest interface {
+	GetService() *apiservice.Service
+}
+
+// configFileRequest 通过配置文件携带命名空间的请求，比如配置发现请求
+type configFileRequest interface {
+	GetConfigFile() *apiconfig.ClientConfigFileInfo
+}
+
+// parseRequestNamespace 获取请求所属的命名空间
+func parseRequestNamespace(req interface{}) string {
+	switch r := req.(type) {
+	case namespaceRequest:
+		return r.GetNamespace().GetValue()
+	case serviceRequest:
+		return r.GetService().GetNamespace().GetValue()
+	case configFileRequest:
+		return r.GetConfigFile().GetNamespace().GetValue()
+	default:
+		return ""
+	}
+}
+
+// setRetryAfter 通过 trailer 告知客户端需要等待多少秒后重试
+func setRetryAfter(ctx context.Context, backoff time.Duration) {
+	if backoff <= 0 {
+		return
+	}
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(strings.ToLower(utils.HeaderRetryAfterKey),
+		utils.RetryAfterSeconds(backoff)))
 }
 
 // AllowAccess api allow access
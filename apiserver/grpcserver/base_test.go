@@ -19,11 +19,20 @@ package grpcserver
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
 	"reflect"
 	"testing"
 
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
+	"github.com/polarismesh/polaris/common/secure"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -101,3 +110,55 @@ func TestConvertContext(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRequestNamespace(t *testing.T) {
+	ins := &apiservice.Instance{Namespace: utils.NewStringValue("ns-1")}
+	if got := parseRequestNamespace(ins); got != "ns-1" {
+		t.Errorf("parseRequestNamespace() = %v, want ns-1", got)
+	}
+	req := &apiservice.DiscoverRequest{Service: &apiservice.Service{Namespace: utils.NewStringValue("ns-2")}}
+	if got := parseRequestNamespace(req); got != "ns-2" {
+		t.Errorf("parseRequestNamespace() = %v, want ns-2", got)
+	}
+	cfgReq := &apiconfig.ConfigDiscoverRequest{
+		ConfigFile: &apiconfig.ClientConfigFileInfo{Namespace: utils.NewStringValue("ns-3")},
+	}
+	if got := parseRequestNamespace(cfgReq); got != "ns-3" {
+		t.Errorf("parseRequestNamespace() = %v, want ns-3", got)
+	}
+	if got := parseRequestNamespace(&apiservice.Response{}); got != "" {
+		t.Errorf("parseRequestNamespace() = %v, want empty", got)
+	}
+}
+
+func TestWithCertPrincipal(t *testing.T) {
+	b := &BaseGrpcServer{tlsInfo: &secure.TLSInfo{
+		CertFile:        "server.crt",
+		KeyFile:         "server.key",
+		ClientAuth:      secure.ClientAuthRequire,
+		IdentityMapping: map[string]string{"ops": "group:ops-id"},
+	}}
+	newCtx := func(cn string) context.Context {
+		return peer.NewContext(mockGrpcContext(map[string]string{}), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8091},
+			AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}},
+			}},
+		})
+	}
+
+	ctx := utils.ConvertGRPCContext(b.withCertPrincipal(newCtx("ops")))
+	if got := utils.ParseCertPrincipal(ctx); got != "group:ops-id" {
+		t.Errorf("withCertPrincipal() = %v, want group:ops-id", got)
+	}
+	ctx = utils.ConvertGRPCContext(b.withCertPrincipal(newCtx("dev")))
+	if got := utils.ParseCertPrincipal(ctx); got != "" {
+		t.Errorf("withCertPrincipal() = %v, want empty", got)
+	}
+	// 没有开启双向 tls 时不解析客户端证书
+	b.tlsInfo.ClientAuth = secure.ClientAuthNone
+	ctx = utils.ConvertGRPCContext(b.withCertPrincipal(newCtx("ops")))
+	if got := utils.ParseCertPrincipal(ctx); got != "" {
+		t.Errorf("withCertPrincipal() = %v, want empty", got)
+	}
+}
@@ -174,7 +174,7 @@ func (g *ConfigGRPCServer) Discover(svr apiconfig.PolarisConfigGRPC_DiscoverServ
 		}
 
 		// stream模式，需要对每个包进行检测
-		if code := g.enterRateLimit(clientIP, method); code != uint32(apimodel.Code_ExecuteSuccess) {
+		if code := g.enterRateLimit(ctx, clientIP, method, in); code != uint32(apimodel.Code_ExecuteSuccess) {
 			resp := api.NewConfigDiscoverResponse(apimodel.Code(code))
 			if err = svr.Send(resp); err != nil {
 				return err
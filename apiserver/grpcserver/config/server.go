@@ -117,8 +117,8 @@ func (g *ConfigGRPCServer) Restart(option map[string]interface{}, apiConf map[st
 }
 
 // enterRateLimit 限流
-func (g *ConfigGRPCServer) enterRateLimit(ip string, method string) uint32 {
-	return g.BaseGrpcServer.EnterRatelimit(ip, method)
+func (g *ConfigGRPCServer) enterRateLimit(ctx context.Context, ip string, method string, req interface{}) uint32 {
+	return g.BaseGrpcServer.EnterStreamRatelimit(ctx, ip, method, req)
 }
 
 // allowAccess 限制访问
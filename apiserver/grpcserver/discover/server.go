@@ -142,8 +142,8 @@ func (g *GRPCServer) Restart(option map[string]interface{}, api map[string]apise
 }
 
 // enterRateLimit 限流
-func (g *GRPCServer) enterRateLimit(ip string, method string) uint32 {
-	return g.BaseGrpcServer.EnterRatelimit(ip, method)
+func (g *GRPCServer) enterRateLimit(ctx context.Context, ip string, method string, req interface{}) uint32 {
+	return g.BaseGrpcServer.EnterStreamRatelimit(ctx, ip, method, req)
 }
 
 // allowAccess 限制访问
@@ -120,7 +120,7 @@ func (g *DiscoverServer) Discover(server apiservice.PolarisGRPC_DiscoverServer)
 		}
 
 		// stream模式，需要对每个包进行检测
-		if code := g.enterRateLimit(clientIP, method); code != uint32(apimodel.Code_ExecuteSuccess) {
+		if code := g.enterRateLimit(ctx, clientIP, method, in); code != uint32(apimodel.Code_ExecuteSuccess) {
 			resp := api.NewDiscoverResponse(apimodel.Code(code))
 			if err = server.Send(resp); err != nil {
 				return err
@@ -20,9 +20,17 @@ package v1
 import (
 	"context"
 	"io"
+	"net"
+	"sort"
+	"strconv"
 
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
 )
@@ -59,6 +67,13 @@ func (g *DiscoverServer) BatchGetHeartbeat(ctx context.Context,
 	if !ok {
 		return &apiservice.GetHeartbeatsResponse{}, nil
 	}
+	if isBeatRecordSnapshot(ctx) {
+		// 全量心跳数据只提供给集群内的其他节点
+		if !isCheckerPeer(ctx, g.healthCheckServer.ListCheckerServer()) {
+			return nil, status.Error(codes.PermissionDenied, "heartbeat snapshot is only available to checker peers")
+		}
+		return exportHeartbeats(ctx, checker)
+	}
 	keys := req.GetInstanceIds()
 	records := make([]*apiservice.HeartbeatRecord, 0, len(keys))
 	for i := range keys {
@@ -80,6 +95,85 @@ func (g *DiscoverServer) BatchGetHeartbeat(ctx context.Context,
 	}, nil
 }
 
+// isBeatRecordSnapshot 是否为获取全量心跳记录的请求
+func isBeatRecordSnapshot(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	return len(md.Get(plugin.BeatRecordSnapshotKey)) > 0
+}
+
+// isCheckerPeer 请求方是否为 polaris.checker 中注册的北极星节点
+func isCheckerPeer(ctx context.Context, checkers []*model.Instance) bool {
+	pr, ok := peer.FromContext(ctx)
+	if !ok || pr.Addr == nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(pr.Addr.String())
+	if err != nil {
+		return false
+	}
+	for i := range checkers {
+		if checkers[i].Host() == host {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBeatRecordSnapshotPage 解析分页拉取全量心跳记录的游标以及分页大小，分页大小不超过 MaxBeatRecordSnapshotLimit
+func parseBeatRecordSnapshotPage(ctx context.Context) (string, int) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", plugin.MaxBeatRecordSnapshotLimit
+	}
+	var (
+		after string
+		limit int
+	)
+	if vals := md.Get(plugin.BeatRecordSnapshotAfterKey); len(vals) > 0 {
+		after = vals[0]
+	}
+	if vals := md.Get(plugin.BeatRecordSnapshotLimitKey); len(vals) > 0 {
+		limit, _ = strconv.Atoi(vals[0])
+	}
+	if limit <= 0 || limit > plugin.MaxBeatRecordSnapshotLimit {
+		limit = plugin.MaxBeatRecordSnapshotLimit
+	}
+	return after, limit
+}
+
+// exportHeartbeats 导出全量心跳记录，用于 follower 节点同步心跳数据副本
+func exportHeartbeats(ctx context.Context, checker plugin.HealthChecker) (*apiservice.GetHeartbeatsResponse, error) {
+	exporter, ok := checker.(plugin.BeatRecordExporter)
+	if !ok {
+		return &apiservice.GetHeartbeatsResponse{}, nil
+	}
+	after, limit := parseBeatRecordSnapshotPage(ctx)
+	ret, err := exporter.ExportRecords(ctx, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(ret))
+	for id := range ret {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	records := make([]*apiservice.HeartbeatRecord, 0, len(ret))
+	for _, id := range ids {
+		resp := ret[id]
+		records = append(records, &apiservice.HeartbeatRecord{
+			InstanceId:       id,
+			LastHeartbeatSec: resp.LastHeartbeatSec,
+			Exist:            resp.Exists,
+		})
+	}
+	return &apiservice.GetHeartbeatsResponse{
+		Records: records,
+	}, nil
+}
+
 // BatchDelHeartbeat 批量删除心跳记录
 func (g *DiscoverServer) BatchDelHeartbeat(ctx context.Context,
 	req *apiservice.DelHeartbeatsRequest) (*apiservice.DelHeartbeatsResponse, error) {
@@ -0,0 +1,69 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package v1
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+func Test_isCheckerPeer(t *testing.T) {
+	checkers := []*model.Instance{
+		{Proto: &apiservice.Instance{Host: wrapperspb.String("10.0.0.1")}},
+		{Proto: &apiservice.Instance{Host: wrapperspb.String("10.0.0.2")}},
+	}
+	withPeer := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 8091},
+		})
+	}
+
+	assert.True(t, isCheckerPeer(withPeer("10.0.0.2"), checkers))
+	assert.False(t, isCheckerPeer(withPeer("10.0.0.3"), checkers))
+	assert.False(t, isCheckerPeer(context.Background(), checkers))
+}
+
+func Test_parseBeatRecordSnapshotPage(t *testing.T) {
+	withLimit := func(limit int) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			plugin.BeatRecordSnapshotAfterKey, "ins-1",
+			plugin.BeatRecordSnapshotLimitKey, strconv.Itoa(limit),
+		))
+	}
+
+	after, limit := parseBeatRecordSnapshotPage(withLimit(100))
+	assert.Equal(t, "ins-1", after)
+	assert.Equal(t, 100, limit)
+
+	_, limit = parseBeatRecordSnapshotPage(withLimit(0))
+	assert.Equal(t, plugin.MaxBeatRecordSnapshotLimit, limit)
+
+	_, limit = parseBeatRecordSnapshotPage(withLimit(plugin.MaxBeatRecordSnapshotLimit + 1))
+	assert.Equal(t, plugin.MaxBeatRecordSnapshotLimit, limit)
+}
@@ -18,6 +18,8 @@
 package v1
 
 import (
+	"context"
+
 	"github.com/polarismesh/polaris/service"
 	"github.com/polarismesh/polaris/service/healthcheck"
 )
@@ -25,7 +27,7 @@ import (
 type DiscoverServer struct {
 	namingServer      service.DiscoverServer
 	healthCheckServer *healthcheck.Server
-	enterRateLimit    func(ip string, method string) uint32
+	enterRateLimit    func(ctx context.Context, ip string, method string, req interface{}) uint32
 	allowAccess       func(method string) bool
 }
 
@@ -53,7 +55,7 @@ func WithHealthCheckerServer(svr *healthcheck.Server) Option {
 	}
 }
 
-func WithEnterRateLimit(f func(ip string, method string) uint32) Option {
+func WithEnterRateLimit(f func(ctx context.Context, ip string, method string, req interface{}) uint32) Option {
 	return func(s *DiscoverServer) {
 		s.enterRateLimit = f
 	}
@@ -106,6 +106,7 @@ func newVirtualStream(ctx context.Context, initOptions ...initVirtualStream) *Vi
 	}
 
 	virtualStream := &VirtualStream{
+		ctx:           ctx,
 		ClientAddress: clientAddress,
 		ClientIP:      clientIP,
 		UserAgent:     userAgent,
@@ -125,6 +126,8 @@ func newVirtualStream(ctx context.Context, initOptions ...initVirtualStream) *Vi
 // VirtualStream 虚拟Stream 继承ServerStream
 type VirtualStream struct {
 	server *BaseGrpcServer
+	// ctx 在 ServerStream 的 context 基础上附加了客户端证书映射的 principal
+	ctx context.Context
 
 	Method        string
 	ClientAddress string
@@ -169,7 +172,7 @@ func (v *VirtualStream) SetTrailer(md metadata.MD) {
 
 // Context returns the context for this stream.
 func (v *VirtualStream) Context() context.Context {
-	return v.stream.Context()
+	return v.ctx
 }
 
 // RecvMsg blocks until it receives a message into m or the stream is
@@ -68,6 +68,11 @@ func (h *HTTPServer) GetAdminAccessServer() *restful.WebService {
 	ws.Route(docs.EnrichGetCMDBInfoApiDocs(ws.GET("/cmdb/info").To(h.GetCMDBInfo)))
 	ws.Route(docs.EnrichGetReportClientsApiDocs(ws.GET("/report/clients").To(h.GetReportClients)))
 	ws.Route(docs.EnrichEnablePprofApiDocs(ws.POST("/pprof/enable").To(h.EnablePprof)))
+	ws.Route(docs.EnrichGetOperationRecordsApiDocs(ws.GET("/operation/records").To(h.GetOperationRecords)))
+	ws.Route(docs.EnrichGetHealthCheckProtectionApiDocs(
+		ws.GET("/healthcheck/protection").To(h.GetHealthCheckProtection)))
+	ws.Route(docs.EnrichSetHealthCheckProtectionModeApiDocs(
+		ws.PUT("/healthcheck/protection").To(h.SetHealthCheckProtectionMode)))
 	return ws
 }
 
@@ -295,6 +300,50 @@ func (h *HTTPServer) GetCMDBInfo(req *restful.Request, rsp *restful.Response) {
 	_ = rsp.WriteAsJson(ret)
 }
 
+// GetOperationRecords 查询资源的操作记录
+func (h *HTTPServer) GetOperationRecords(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+	params := httpcommon.ParseQueryParams(req)
+
+	ret, err := h.maintainServer.GetOperationRecords(ctx, params)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// GetHealthCheckProtection 查询当前节点健康检查保护模式的状态
+func (h *HTTPServer) GetHealthCheckProtection(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	ret, err := h.maintainServer.GetHealthCheckProtection(ctx)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
+// SetHealthCheckProtectionMode 人工修改整个集群的健康检查保护模式，返回处理请求的节点修改后的状态
+func (h *HTTPServer) SetHealthCheckProtectionMode(req *restful.Request, rsp *restful.Response) {
+	ctx := initContext(req)
+
+	var protection struct {
+		Mode string `json:"mode"`
+	}
+	if err := httpcommon.ParseJsonBody(req, &protection); err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	ret, err := h.maintainServer.SetHealthCheckProtectionMode(ctx, protection.Mode)
+	if err != nil {
+		_ = rsp.WriteErrorString(http.StatusBadRequest, err.Error())
+		return
+	}
+	_ = rsp.WriteAsJson(ret)
+}
+
 func (h *HTTPServer) EnablePprof(req *restful.Request, rsp *restful.Response) {
 	var pprofEnable struct {
 		Enable bool `json:"enable"`
@@ -18,7 +18,10 @@
 package httpserver
 
 import (
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/golang/protobuf/proto"
@@ -29,14 +32,24 @@ import (
 	"github.com/polarismesh/polaris/apiserver/httpserver/docs"
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
+const (
+	// loginBindingCookie 外部身份提供者登录时绑定浏览器的 cookie 名称
+	loginBindingCookie = "polaris_login_binding"
+	// loginBindingExpire 绑定 cookie 的有效期，与登录 state 的有效期一致
+	loginBindingExpire = 10 * time.Minute
+)
+
 // GetAuthServer 运维接口
 func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(docs.EnrichAuthStatusApiDocs(ws.GET("/auth/status").To(h.AuthStatus)))
 	//
 	ws.Route(docs.EnrichLoginApiDocs(ws.POST("/user/login").To(h.Login)))
+	ws.Route(docs.EnrichLoginRedirectApiDocs(ws.GET("/user/login/{provider}/redirect").To(h.LoginRedirect)))
+	ws.Route(docs.EnrichLoginCallbackApiDocs(ws.GET("/user/login/{provider}/callback").To(h.LoginCallback)))
 	ws.Route(docs.EnrichGetUsersApiDocs(ws.GET("/users").To(h.GetUsers)))
 	ws.Route(docs.EnrichCreateUsersApiDocs(ws.POST("/users").To(h.CreateUsers)))
 	ws.Route(docs.EnrichDeleteUsersApiDocs(ws.POST("/users/delete").To(h.DeleteUsers)))
@@ -45,6 +58,12 @@ func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(docs.EnrichGetUserTokenApiDocs(ws.GET("/user/token").To(h.GetUserToken)))
 	ws.Route(docs.EnrichUpdateUserTokenApiDocs(ws.PUT("/user/token/status").To(h.UpdateUserToken)))
 	ws.Route(docs.EnrichResetUserTokenApiDocs(ws.PUT("/user/token/refresh").To(h.ResetUserToken)))
+	ws.Route(docs.EnrichCreateAPITokenApiDocs(ws.POST("/user/apitokens").To(h.CreateAPIToken)))
+	ws.Route(docs.EnrichGetAPITokensApiDocs(ws.GET("/user/apitokens").To(h.GetAPITokens)))
+	ws.Route(docs.EnrichRevokeAPITokenApiDocs(ws.POST("/user/apitokens/revoke").To(h.RevokeAPIToken)))
+	ws.Route(docs.EnrichChangeExpiredPasswordApiDocs(ws.PUT("/user/password/expired").To(h.ChangeExpiredPassword)))
+	ws.Route(docs.EnrichGetLoginLocksApiDocs(ws.GET("/user/login/locks").To(h.GetLoginLocks)))
+	ws.Route(docs.EnrichUnlockLoginApiDocs(ws.POST("/user/login/unlock").To(h.UnlockLogin)))
 	//
 	ws.Route(docs.EnrichCreateGroupApiDocs(ws.POST("/usergroup").To(h.CreateGroup)))
 	ws.Route(docs.EnrichUpdateGroupsApiDocs(ws.PUT("/usergroups").To(h.UpdateGroups)))
@@ -61,6 +80,9 @@ func (h *HTTPServer) GetAuthServer(ws *restful.WebService) error {
 	ws.Route(docs.EnrichDeleteStrategiesApiDocs(ws.POST("/auth/strategies/delete").To(h.DeleteStrategies)))
 	ws.Route(docs.EnrichGetStrategiesApiDocs(ws.GET("/auth/strategies").To(h.GetStrategies)))
 	ws.Route(docs.EnrichGetPrincipalResourcesApiDocs(ws.GET("/auth/principal/resources").To(h.GetPrincipalResources)))
+	ws.Route(docs.EnrichUpdateStrategyConditionsApiDocs(
+		ws.PUT("/auth/strategy/conditions").To(h.UpdateStrategyConditions)))
+	ws.Route(docs.EnrichExplainPermissionApiDocs(ws.GET("/auth/strategy/explain").To(h.ExplainPermission)))
 
 	return nil
 }
@@ -96,13 +118,75 @@ func (h *HTTPServer) Login(req *restful.Request, rsp *restful.Response) {
 
 	loginReq := &apisecurity.LoginRequest{}
 
-	_, err := handler.Parse(loginReq)
+	ctx, err := handler.Parse(loginReq)
 	if err != nil {
 		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_ParseException, err.Error()))
 		return
 	}
 
-	handler.WriteHeaderAndProto(h.userMgn.Login(loginReq))
+	handler.WriteHeaderAndProto(h.userMgn.Login(ctx, loginReq))
+}
+
+// LoginRedirect 跳转到外部身份提供者的登录页面
+func (h *HTTPServer) LoginRedirect(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	location, binding, err := h.userMgn.GetLoginRedirect(handler.ParseHeaderContext(),
+		req.PathParameter("provider"))
+	if err != nil {
+		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_NotFoundResource, err.Error()))
+		return
+	}
+	// 绑定值只写入当前浏览器，回调时校验，防止攻击者诱导用户使用攻击者的授权码登录
+	http.SetCookie(rsp.ResponseWriter, &http.Cookie{
+		Name:     loginBindingCookie,
+		Value:    binding,
+		Path:     strings.TrimSuffix(req.Request.URL.Path, "/redirect"),
+		MaxAge:   int(loginBindingExpire / time.Second),
+		HttpOnly: true,
+		Secure:   isSecureRequest(req.Request),
+		// 身份提供者回调是跨站的顶层跳转, 只能使用 Lax
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(rsp.ResponseWriter, req.Request, location, http.StatusFound)
+}
+
+// LoginCallback 外部身份提供者登录完成后的回调
+func (h *HTTPServer) LoginCallback(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	ctx := handler.ParseHeaderContext()
+	var binding string
+	if cookie, err := req.Request.Cookie(loginBindingCookie); err == nil {
+		binding = cookie.Value
+	}
+	// 绑定值只能使用一次
+	http.SetCookie(rsp.ResponseWriter, &http.Cookie{
+		Name:     loginBindingCookie,
+		Path:     strings.TrimSuffix(req.Request.URL.Path, "/callback"),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(req.Request),
+		SameSite: http.SameSiteLaxMode,
+	})
+	if errCode := req.QueryParameter("error"); errCode != "" {
+		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess,
+			errCode+" "+req.QueryParameter("error_description")))
+		return
+	}
+	handler.WriteHeaderAndProto(h.userMgn.LoginCallback(ctx, req.PathParameter("provider"),
+		req.QueryParameter("code"), req.QueryParameter("state"), binding))
+}
+
+// isSecureRequest 请求是否通过 https 访问，经过反向代理时以 X-Forwarded-Proto 为准
+func isSecureRequest(req *http.Request) bool {
+	return req.TLS != nil || strings.EqualFold(req.Header.Get("X-Forwarded-Proto"), "https")
 }
 
 // CreateUsers 批量创建用户
@@ -249,6 +333,106 @@ func (h *HTTPServer) ResetUserToken(req *restful.Request, rsp *restful.Response)
 	handler.WriteHeaderAndProto(h.userMgn.ResetUserToken(ctx, user))
 }
 
+// CreateAPIToken 创建具名 API token
+func (h *HTTPServer) CreateAPIToken(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	token := &model.APIToken{}
+	if err := req.ReadEntity(token); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.APITokenResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.userMgn.CreateAPIToken(handler.ParseHeaderContext(), token)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// GetAPITokens 查询 API token 列表
+func (h *HTTPServer) GetAPITokens(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	queryParams := httpcommon.ParseQueryParams(req)
+	resp := h.userMgn.GetAPITokens(handler.ParseHeaderContext(), queryParams)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// RevokeAPIToken 吊销 API token
+func (h *HTTPServer) RevokeAPIToken(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	token := &model.APIToken{}
+	if err := req.ReadEntity(token); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.APITokenResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.userMgn.RevokeAPIToken(handler.ParseHeaderContext(), token.ID)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// ChangeExpiredPassword 使用原密码修改已经过期的密码
+func (h *HTTPServer) ChangeExpiredPassword(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	changeReq := &model.ChangeExpiredPasswordRequest{}
+	if err := req.ReadEntity(changeReq); err != nil {
+		handler.WriteHeaderAndProto(api.NewAuthResponseWithMsg(apimodel.Code_ParseException, err.Error()))
+		return
+	}
+
+	handler.WriteHeaderAndProto(h.userMgn.ChangeExpiredPassword(handler.ParseHeaderContext(), changeReq))
+}
+
+// GetLoginLocks 查询用户或者来源IP的登录锁定状态
+func (h *HTTPServer) GetLoginLocks(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	queryParams := httpcommon.ParseQueryParams(req)
+	resp := h.userMgn.GetLoginLocks(handler.ParseHeaderContext(), queryParams)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// UnlockLogin 解除用户或者来源IP的登录锁定
+func (h *HTTPServer) UnlockLogin(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	unlockReq := &model.LoginUnlockRequest{}
+	if err := req.ReadEntity(unlockReq); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.LoginLockResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.userMgn.UnlockLogin(handler.ParseHeaderContext(), unlockReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
 // CreateGroup 创建用户组
 func (h *HTTPServer) CreateGroup(req *restful.Request, rsp *restful.Response) {
 	handler := &httpcommon.Handler{
@@ -498,3 +682,66 @@ func (h *HTTPServer) GetPrincipalResources(req *restful.Request, rsp *restful.Re
 
 	handler.WriteHeaderAndProto(h.strategyMgn.GetPrincipalResources(ctx, queryParams))
 }
+
+// UpdateStrategyConditions 修改鉴权策略的生效条件
+func (h *HTTPServer) UpdateStrategyConditions(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	conditionsReq := &model.StrategyConditionsRequest{}
+	if err := req.ReadEntity(conditionsReq); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.StrategyConditionsResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.strategyMgn.UpdateStrategyConditions(handler.ParseHeaderContext(), conditionsReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// ExplainPermission 解释某个 principal 对资源的操作为什么被允许或者拒绝
+func (h *HTTPServer) ExplainPermission(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	queryParams := httpcommon.ParseQueryParams(req)
+	explainReq := &model.AuthExplainRequest{
+		PrincipalID:  queryParams["principal_id"],
+		ResourceType: queryParams["resource_type"],
+		ResourceID:   queryParams["resource_id"],
+		Operation:    queryParams["operation"],
+		SourceIP:     queryParams["source_ip"],
+		Protocol:     queryParams["protocol"],
+	}
+	if val := queryParams["principal_type"]; val != "" {
+		principalType, err := strconv.Atoi(val)
+		if err != nil {
+			handler.WriteHeaderAndJSON(api.InvalidParameter, &model.AuthExplainResponse{
+				Code: api.InvalidParameter,
+				Info: "invalid principal_type " + val,
+			})
+			return
+		}
+		explainReq.PrincipalType = model.PrincipalType(principalType)
+	}
+	if val := queryParams["time"]; val != "" {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			handler.WriteHeaderAndJSON(api.InvalidParameter, &model.AuthExplainResponse{
+				Code: api.InvalidParameter,
+				Info: "invalid time " + val + ", format must be RFC3339",
+			})
+			return
+		}
+		explainReq.Time = t
+	}
+
+	resp := h.strategyMgn.ExplainPermission(handler.ParseHeaderContext(), explainReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
@@ -18,7 +18,10 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/emicklei/go-restful/v3"
@@ -29,6 +32,7 @@ import (
 
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -472,8 +476,252 @@ func (h *HTTPServer) UpsertAndReleaseConfigFile(req *restful.Request, rsp *restf
 		handler.WriteHeaderAndProto(api.NewConfigResponseWithInfo(apimodel.Code_ParseException, err.Error()))
 		return
 	}
+	publishReq := &model.ConfigFilePublishRequest{ConfigFilePublishInfo: configFile}
+	// 携带 base_version 时，只有基于最新的全量发布修改才允许发布
+	if val := req.QueryParameter("base_version"); val != "" {
+		if publishReq.BaseVersion, err = strconv.ParseUint(val, 10, 64); err != nil {
+			handler.WriteHeaderAndProto(api.NewConfigResponseWithInfo(apimodel.Code_ParseException,
+				"invalid base_version"))
+			return
+		}
+	}
+
+	handler.WriteHeaderAndProto(h.configServer.UpsertAndReleaseConfigFileOnBase(ctx, publishReq))
+}
+
+// DiffConfigFile 对比配置文件的两个版本，默认对比当前生效的发布与编辑中的内容
+func (h *HTTPServer) DiffConfigFile(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	diffReq := &model.ConfigFileDiffRequest{
+		Namespace: req.QueryParameter("namespace"),
+		Group:     req.QueryParameter("group"),
+		FileName:  req.QueryParameter("name"),
+	}
+	var err error
+	if diffReq.From, err = parseDiffSource(req, "from", model.ConfigFileSourceActive); err == nil {
+		diffReq.To, err = parseDiffSource(req, "to", model.ConfigFileSourceWorking)
+	}
+	if err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigFileDiffResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.configServer.DiffConfigFile(handler.ParseHeaderContext(), diffReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// MergeConfigFile 检查基于旧版本修改的内容能否与最新的全量发布合并
+func (h *HTTPServer) MergeConfigFile(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	mergeReq := &model.ConfigFileMergeRequest{}
+	if err := req.ReadEntity(mergeReq); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigFileMergeResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.configServer.MergeConfigFile(handler.ParseHeaderContext(), mergeReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// GetConfigFileSchemas 查询配置分组下的内容校验规则
+func (h *HTTPServer) GetConfigFileSchemas(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	filters := httpcommon.ParseQueryParams(req)
+	resp := h.configServer.GetConfigFileSchemas(handler.ParseHeaderContext(), filters)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// UpsertConfigFileSchema 创建或者更新配置分组/配置文件的内容校验规则
+func (h *HTTPServer) UpsertConfigFileSchema(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	schema := &model.ConfigFileSchema{}
+	if err := req.ReadEntity(schema); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigFileSchemaResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.configServer.UpsertConfigFileSchema(handler.ParseHeaderContext(), schema)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
 
-	handler.WriteHeaderAndProto(h.configServer.UpsertAndReleaseConfigFile(ctx, configFile))
+// DeleteConfigFileSchema 删除配置分组/配置文件的内容校验规则
+func (h *HTTPServer) DeleteConfigFileSchema(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	schema := &model.ConfigFileSchema{
+		Namespace: req.QueryParameter("namespace"),
+		Group:     req.QueryParameter("group"),
+		FileName:  req.QueryParameter("name"),
+	}
+	resp := h.configServer.DeleteConfigFileSchema(handler.ParseHeaderContext(), schema)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// ValidateConfigFile 试运行配置内容校验，不会保存配置
+func (h *HTTPServer) ValidateConfigFile(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	validateReq := &model.ConfigFileValidateRequest{}
+	if err := req.ReadEntity(validateReq); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigFileValidateResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.configServer.ValidateConfigFile(handler.ParseHeaderContext(), validateReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// CreateConfigReleaseRequest 创建配置发布申请
+func (h *HTTPServer) CreateConfigReleaseRequest(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	releaseReq := &model.ConfigReleaseRequest{}
+	if err := req.ReadEntity(releaseReq); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigReleaseRequestResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.configServer.CreateConfigReleaseRequest(handler.ParseHeaderContext(), releaseReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// GetConfigReleaseRequests 查询配置发布申请
+func (h *HTTPServer) GetConfigReleaseRequests(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	filters := httpcommon.ParseQueryParams(req)
+	resp := h.configServer.GetConfigReleaseRequests(handler.ParseHeaderContext(), filters)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// ApproveConfigReleaseRequest 审批通过配置发布申请
+func (h *HTTPServer) ApproveConfigReleaseRequest(req *restful.Request, rsp *restful.Response) {
+	h.handleConfigReleaseRequestAction(req, rsp, h.configServer.ApproveConfigReleaseRequest)
+}
+
+// RejectConfigReleaseRequest 驳回配置发布申请
+func (h *HTTPServer) RejectConfigReleaseRequest(req *restful.Request, rsp *restful.Response) {
+	h.handleConfigReleaseRequestAction(req, rsp, h.configServer.RejectConfigReleaseRequest)
+}
+
+// CancelConfigReleaseRequest 取消配置发布申请
+func (h *HTTPServer) CancelConfigReleaseRequest(req *restful.Request, rsp *restful.Response) {
+	h.handleConfigReleaseRequestAction(req, rsp, h.configServer.CancelConfigReleaseRequest)
+}
+
+func (h *HTTPServer) handleConfigReleaseRequestAction(req *restful.Request, rsp *restful.Response,
+	action func(context.Context, *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	actionReq := &model.ConfigReleaseRequestAction{}
+	if err := req.ReadEntity(actionReq); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigReleaseRequestResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := action(handler.ParseHeaderContext(), actionReq)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// CreateConfigGrayRollout 创建按比例灰度发布
+func (h *HTTPServer) CreateConfigGrayRollout(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	rollout := &model.ConfigGrayRollout{}
+	if err := req.ReadEntity(rollout); err != nil {
+		handler.WriteHeaderAndJSON(api.ParseException, &model.ConfigGrayRolloutResponse{
+			Code: api.ParseException,
+			Info: err.Error(),
+		})
+		return
+	}
+
+	resp := h.configServer.CreateConfigGrayRollout(handler.ParseHeaderContext(), rollout)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// GetConfigGrayRollout 查询配置文件的按比例灰度发布
+func (h *HTTPServer) GetConfigGrayRollout(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	filters := httpcommon.ParseQueryParams(req)
+	resp := h.configServer.GetConfigGrayRollout(handler.ParseHeaderContext(), filters)
+	handler.WriteHeaderAndJSON(resp.Code, resp)
+}
+
+// parseDiffSource 解析 {prefix}、{prefix}_name、{prefix}_id 查询参数
+func parseDiffSource(req *restful.Request, prefix string,
+	defaultType model.ConfigFileSourceType) (model.ConfigFileSource, error) {
+	source := model.ConfigFileSource{
+		Type: model.ConfigFileSourceType(req.QueryParameter(prefix)),
+		Name: req.QueryParameter(prefix + "_name"),
+	}
+	if source.Type == "" {
+		source.Type = defaultType
+	}
+	if val := req.QueryParameter(prefix + "_id"); val != "" {
+		id, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return source, fmt.Errorf("invalid %s_id", prefix)
+		}
+		source.ID = id
+	}
+	return source, nil
 }
 
 // StopGrayConfigFileReleases .
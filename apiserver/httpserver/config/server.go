@@ -89,6 +89,13 @@ func (h *HTTPServer) addDefaultReadAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichGetConfigFileReleaseApiDocs(ws.GET("/configfiles/release").To(h.GetConfigFileRelease)))
 	ws.Route(docs.EnrichGetConfigFileReleaseHistoryApiDocs(ws.GET("/configfiles/releasehistory").
 		To(h.GetConfigFileReleaseHistory)))
+	ws.Route(docs.EnrichDiffConfigFileApiDocs(ws.GET("/configfiles/diff").To(h.DiffConfigFile)))
+	ws.Route(docs.EnrichGetConfigFileSchemasApiDocs(ws.GET("/configfiles/schemas").To(h.GetConfigFileSchemas)))
+	ws.Route(docs.EnrichValidateConfigFileApiDocs(ws.POST("/configfiles/validate").To(h.ValidateConfigFile)))
+	ws.Route(docs.EnrichGetConfigReleaseRequestsApiDocs(
+		ws.GET("/configfiles/release/requests").To(h.GetConfigReleaseRequests)))
+	ws.Route(docs.EnrichGetConfigGrayRolloutApiDocs(
+		ws.GET("/configfiles/release/gray/rollout").To(h.GetConfigGrayRollout)))
 	ws.Route(docs.EnrichGetAllConfigFileTemplatesApiDocs(ws.GET("/configfiletemplates").To(h.GetAllConfigFileTemplates)))
 }
 
@@ -121,6 +128,32 @@ func (h *HTTPServer) addDefaultAccess(ws *restful.WebService) {
 	ws.Route(docs.EnrichGetConfigFileReleaseApiDocs(ws.GET("/configfiles/release/versions").To(h.GetConfigFileReleaseVersions)))
 	ws.Route(docs.EnrichUpsertAndReleaseConfigFileApiDocs(ws.POST("/configfiles/createandpub").To(h.UpsertAndReleaseConfigFile)))
 	ws.Route(docs.EnrichStopBetaReleaseConfigFileApiDocs(ws.POST("/configfiles/releases/stopbeta").To(h.StopGrayConfigFileReleases)))
+	ws.Route(docs.EnrichDiffConfigFileApiDocs(ws.GET("/configfiles/diff").To(h.DiffConfigFile)))
+	ws.Route(docs.EnrichMergeConfigFileApiDocs(ws.POST("/configfiles/merge").To(h.MergeConfigFile)))
+
+	// 配置内容校验规则
+	ws.Route(docs.EnrichGetConfigFileSchemasApiDocs(ws.GET("/configfiles/schemas").To(h.GetConfigFileSchemas)))
+	ws.Route(docs.EnrichUpsertConfigFileSchemaApiDocs(ws.POST("/configfiles/schema").To(h.UpsertConfigFileSchema)))
+	ws.Route(docs.EnrichDeleteConfigFileSchemaApiDocs(ws.DELETE("/configfiles/schema").To(h.DeleteConfigFileSchema)))
+	ws.Route(docs.EnrichValidateConfigFileApiDocs(ws.POST("/configfiles/validate").To(h.ValidateConfigFile)))
+
+	// 配置发布申请
+	ws.Route(docs.EnrichCreateConfigReleaseRequestApiDocs(
+		ws.POST("/configfiles/release/request").To(h.CreateConfigReleaseRequest)))
+	ws.Route(docs.EnrichGetConfigReleaseRequestsApiDocs(
+		ws.GET("/configfiles/release/requests").To(h.GetConfigReleaseRequests)))
+	ws.Route(docs.EnrichApproveConfigReleaseRequestApiDocs(
+		ws.POST("/configfiles/release/request/approve").To(h.ApproveConfigReleaseRequest)))
+	ws.Route(docs.EnrichRejectConfigReleaseRequestApiDocs(
+		ws.POST("/configfiles/release/request/reject").To(h.RejectConfigReleaseRequest)))
+	ws.Route(docs.EnrichCancelConfigReleaseRequestApiDocs(
+		ws.POST("/configfiles/release/request/cancel").To(h.CancelConfigReleaseRequest)))
+
+	// 按比例灰度发布
+	ws.Route(docs.EnrichCreateConfigGrayRolloutApiDocs(
+		ws.POST("/configfiles/release/gray/rollout").To(h.CreateConfigGrayRollout)))
+	ws.Route(docs.EnrichGetConfigGrayRolloutApiDocs(
+		ws.GET("/configfiles/release/gray/rollout").To(h.GetConfigGrayRollout)))
 
 	// 配置文件发布历史
 	ws.Route(docs.EnrichGetConfigFileReleaseHistoryApiDocs(ws.GET("/configfiles/releasehistory").
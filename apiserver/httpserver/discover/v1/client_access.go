@@ -44,6 +44,9 @@ func (h *HTTPServerV1) ReportClient(req *restful.Request, rsp *restful.Response)
 		handler.WriteHeaderAndProto(api.NewResponseWithMsg(apimodel.Code_ParseException, err.Error()))
 		return
 	}
+	if configError := req.HeaderParameter(utils.HeaderConfigErrorKey); configError != "" {
+		ctx = context.WithValue(ctx, utils.ContextConfigErrorKey, configError)
+	}
 
 	handler.WriteHeaderAndProto(h.namingServer.ReportClient(ctx, client))
 }
@@ -25,6 +25,7 @@ import (
 
 	"github.com/polarismesh/polaris/admin"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/service/healthcheck"
 )
 
 var (
@@ -149,3 +150,53 @@ func EnrichEnablePprofApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 			Enable bool `json:"enable"`
 		}{})
 }
+
+func EnrichGetOperationRecordsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询资源操作记录").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("resource_type", "资源类型").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("resource_name", "资源名称").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("operator", "操作人").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("operation_type", "操作类型").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("start_time", "开始时间，秒级时间戳").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("end_time", "结束时间，秒级时间戳").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("offset", "查询偏移量").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("limit", "查询条数").DataType(typeNameInteger).Required(false)).
+		Returns(0, "", admin.OperationRecordsResp{})
+}
+
+func EnrichGetHealthCheckProtectionApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询健康检查保护模式的状态，实例数按照整个集群统计").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Returns(0, "", healthcheck.ProtectionState{})
+}
+
+func EnrichSetHealthCheckProtectionModeApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("人工修改整个集群的健康检查保护模式，支持 auto/on/off。修改保存在存储中，处理请求的节点立即生效，"+
+			"其他节点在 5 秒内同步生效，节点重启后依然保留").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Reads(struct {
+			Mode string `json:"mode"`
+		}{}).
+		Returns(0, "", healthcheck.ProtectionState{})
+}
+
+func EnrichGetPrometheusInstancesApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("prometheus http_sd 服务发现，返回服务实例").
+		Metadata(restfulspec.KeyOpenAPITags, maintainApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("service", "服务名").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("protocol", "实例协议，多个协议使用逗号分隔").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("healthy", "实例健康状态").DataType(typeNameBool).Required(false)).
+		Param(restful.QueryParameter("isolate", "实例隔离状态").DataType(typeNameBool).Required(false)).
+		Param(restful.QueryParameter("keys", "实例元数据的key，多个使用逗号分隔").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("values", "实例元数据的value，与keys一一对应").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("revision", "上一次返回的版本，与 If-None-Match 作用相同").
+			DataType(typeNameString).Required(false)).
+		Returns(0, "", []model.PrometheusTarget{})
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	restfulspec "github.com/polarismesh/go-restful-openapi/v2"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
 )
 
 var (
@@ -116,6 +118,34 @@ func EnrichGetPrincipalResourcesApiDocs(r *restful.RouteBuilder) *restful.RouteB
 		}{})
 }
 
+func EnrichUpdateStrategyConditionsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("修改鉴权策略的生效条件，包括来源IP、时间窗口、请求来源以及资源标签选择器，conditions为空时清除生效条件").
+		Metadata(restfulspec.KeyOpenAPITags, authApiTags).
+		Reads(model.StrategyConditionsRequest{}, "update auth strategy conditions").
+		Returns(0, "", model.StrategyConditionsResponse{})
+}
+
+func EnrichExplainPermissionApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("解释某个用户/用户组对资源的操作为什么被允许或者拒绝").
+		Metadata(restfulspec.KeyOpenAPITags, authApiTags).
+		Param(restful.QueryParameter("principal_id", "用户或者用户组ID").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("principal_type", "Principal类别，1为用户，2为用户组").
+			DataType(typeNameInteger).Required(true)).
+		Param(restful.QueryParameter("resource_type", "资源类型，Namespaces/Services/ConfigGroups").
+			DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("resource_id", "资源ID").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("operation", "操作，read/create/modify/delete，默认为modify").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("source_ip", "请求来源IP").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("protocol", "请求来源，console/client，默认为console").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("time", "请求时间，RFC3339格式，默认为当前时间").
+			DataType(typeNameString).Required(false)).
+		Returns(0, "", model.AuthExplainResponse{})
+}
+
 func EnrichGetStrategyApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("获取鉴权策略详细").
@@ -152,6 +182,35 @@ func EnrichLoginApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 		}{})
 }
 
+func EnrichLoginRedirectApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("跳转到外部身份提供者的登录页面，同时写入绑定当前浏览器的 cookie，回调时校验").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.PathParameter("provider", "外部身份提供者名称").
+			DataType(typeNameString).
+			Required(true)).
+		Returns(302, "", nil)
+}
+
+func EnrichLoginCallbackApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("外部身份提供者登录回调").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.PathParameter("provider", "外部身份提供者名称").
+			DataType(typeNameString).
+			Required(true)).
+		Param(restful.QueryParameter("code", "授权码").
+			DataType(typeNameString).
+			Required(true)).
+		Param(restful.QueryParameter("state", "登录跳转时生成的 state").
+			DataType(typeNameString).
+			Required(true)).
+		Returns(0, "", struct {
+			BaseResponse
+			LoginResponse *apisecurity.LoginResponse `json:"loginResponse"`
+		}{})
+}
+
 func EnrichGetUsersApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("根据相关条件对用户列表进行查询").
@@ -253,6 +312,61 @@ func EnrichResetUserTokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder
 		}{})
 }
 
+func EnrichCreateAPITokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("创建具名API Token，token原文只在创建时返回").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(model.APIToken{}, "create api token").
+		Returns(0, "", model.APITokenResponse{})
+}
+
+func EnrichGetAPITokensApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询API Token列表").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.QueryParameter("id", "API Token ID").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("name", "API Token名称").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("principal_type", "所属成员类型，1为用户，2为用户组").
+			DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("principal_id", "所属用户或者用户组ID").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("offset", "查询偏移量").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("limit", "查询条数").DataType(typeNameInteger).Required(false)).
+		Returns(0, "", model.APITokenBatchResponse{})
+}
+
+func EnrichRevokeAPITokenApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("吊销API Token").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(model.APIToken{}, "revoke api token, only id is required").
+		Returns(0, "", model.APITokenResponse{})
+}
+
+func EnrichChangeExpiredPasswordApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("使用原密码修改已经过期的密码，不需要携带token").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(model.ChangeExpiredPasswordRequest{}, "change expired password").
+		Returns(0, "", BaseResponse{})
+}
+
+func EnrichGetLoginLocksApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询用户或者来源IP的登录失败次数以及锁定状态").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Param(restful.QueryParameter("user_id", "用户ID").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("ip", "来源IP，只有超级账户可以查询").DataType(typeNameString).Required(false)).
+		Returns(0, "", model.LoginLockResponse{})
+}
+
+func EnrichUnlockLoginApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("解除用户或者来源IP的登录锁定").
+		Metadata(restfulspec.KeyOpenAPITags, usersApiTags).
+		Reads(model.LoginUnlockRequest{}, "unlock login, user_id or ip is required").
+		Returns(0, "", model.LoginLockResponse{})
+}
+
 func EnrichCreateGroupApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
 	return r.
 		Doc("创建用户组").
@@ -22,6 +22,8 @@ import (
 	restfulspec "github.com/polarismesh/go-restful-openapi/v2"
 	"github.com/polarismesh/specification/source/go/api/v1/config_manage"
 	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+
+	"github.com/polarismesh/polaris/common/model"
 )
 
 var (
@@ -88,6 +90,8 @@ func EnrichUpsertAndReleaseConfigFileApiDocs(r *restful.RouteBuilder) *restful.R
 	return r.
 		Doc("创建/更新并发布配置文件").
 		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Param(restful.QueryParameter("base_version", "修改所基于的全量发布版本，不是最新版本时拒绝发布").
+			DataType(typeNameInteger).Required(false)).
 		Reads(apiconfig.ConfigFilePublishInfo{}).
 		Returns(0, "", BaseResponse{})
 }
@@ -306,3 +310,137 @@ func EnrichGetAllConfigEncryptAlgorithms(r *restful.RouteBuilder) *restful.Route
 		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
 		Returns(0, "", config_manage.ConfigEncryptAlgorithmResponse{})
 }
+
+func EnrichDiffConfigFileApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("对比配置文件的两个版本").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("group", "配置文件分组").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("name", "配置文件名").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("from", "对比来源: working/active/gray/release/history").
+			DataType(typeNameString).Required(false).DefaultValue("active")).
+		Param(restful.QueryParameter("from_name", "from 为 release 时的发布名称").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("from_id", "from 为 history 时的发布历史 ID").
+			DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("to", "对比目标: working/active/gray/release/history").
+			DataType(typeNameString).Required(false).DefaultValue("working")).
+		Param(restful.QueryParameter("to_name", "to 为 release 时的发布名称").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("to_id", "to 为 history 时的发布历史 ID").
+			DataType(typeNameInteger).Required(false)).
+		Returns(0, "", model.ConfigFileDiffResponse{})
+}
+
+func EnrichMergeConfigFileApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("检查基于旧版本的修改能否与最新发布合并").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigFileMergeRequest{}).
+		Returns(0, "", model.ConfigFileMergeResponse{})
+}
+
+func EnrichGetConfigFileSchemasApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询配置分组下的内容校验规则").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("group", "配置文件分组").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("name", "配置文件名，指定时只返回该配置文件上的规则，为空字符串时返回分组级别的规则").
+			DataType(typeNameString).Required(false)).
+		Returns(0, "", model.ConfigFileSchemaBatchResponse{})
+}
+
+func EnrichUpsertConfigFileSchemaApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("创建或者更新内容校验规则，file_name 为空时对整个分组生效").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigFileSchema{}).
+		Returns(0, "", model.ConfigFileSchemaResponse{})
+}
+
+func EnrichDeleteConfigFileSchemaApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("删除内容校验规则").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("group", "配置文件分组").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("name", "配置文件名，为空时删除分组级别的规则").
+			DataType(typeNameString).Required(false)).
+		Returns(0, "", model.ConfigFileSchemaResponse{})
+}
+
+func EnrichValidateConfigFileApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("试运行配置内容校验").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigFileValidateRequest{}).
+		Returns(0, "", model.ConfigFileValidateResponse{})
+}
+
+func EnrichCreateConfigReleaseRequestApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("创建配置发布申请，审批通过并且到达计划发布时间后自动发布").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigReleaseRequest{}).
+		Returns(0, "", model.ConfigReleaseRequestResponse{})
+}
+
+func EnrichGetConfigReleaseRequestsApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询配置发布申请").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Param(restful.QueryParameter("id", "申请ID").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("group", "配置文件分组").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("file_name", "配置文件名").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("status", "申请状态，pending/approved/releasing/released/failed/rejected/cancelled").
+			DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("create_by", "申请人").DataType(typeNameString).Required(false)).
+		Param(restful.QueryParameter("offset", "查询偏移量").DataType(typeNameInteger).Required(false)).
+		Param(restful.QueryParameter("limit", "查询条数").DataType(typeNameInteger).Required(false)).
+		Returns(0, "", model.ConfigReleaseRequestBatchResponse{})
+}
+
+func EnrichApproveConfigReleaseRequestApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("审批通过配置发布申请").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigReleaseRequestAction{}).
+		Returns(0, "", model.ConfigReleaseRequestResponse{})
+}
+
+func EnrichRejectConfigReleaseRequestApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("驳回配置发布申请").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigReleaseRequestAction{}).
+		Returns(0, "", model.ConfigReleaseRequestResponse{})
+}
+
+func EnrichCancelConfigReleaseRequestApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("取消配置发布申请").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigReleaseRequestAction{}).
+		Returns(0, "", model.ConfigReleaseRequestResponse{})
+}
+
+func EnrichCreateConfigGrayRolloutApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("创建按比例灰度发布，按阶段自动扩大灰度比例，最后一个阶段结束后全量发布，异常客户端达到阈值时自动终止").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Reads(model.ConfigGrayRollout{}).
+		Returns(0, "", model.ConfigGrayRolloutResponse{})
+}
+
+func EnrichGetConfigGrayRolloutApiDocs(r *restful.RouteBuilder) *restful.RouteBuilder {
+	return r.
+		Doc("查询配置文件最近一次的按比例灰度发布").
+		Metadata(restfulspec.KeyOpenAPITags, configConsoleApiTags).
+		Param(restful.QueryParameter("namespace", "命名空间").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("group", "配置文件分组").DataType(typeNameString).Required(true)).
+		Param(restful.QueryParameter("file_name", "配置文件名").DataType(typeNameString).Required(true)).
+		Returns(0, "", model.ConfigGrayRolloutResponse{})
+}
@@ -19,10 +19,15 @@ package httpserver
 
 import (
 	"context"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/emicklei/go-restful/v3"
 
+	"github.com/polarismesh/polaris/apiserver/httpserver/docs"
 	httpcommon "github.com/polarismesh/polaris/apiserver/httpserver/utils"
+	api "github.com/polarismesh/polaris/common/api/v1"
 )
 
 func (h *HTTPServer) GetClientServer(ws *restful.WebService) error {
@@ -53,6 +58,7 @@ func (h *HTTPServer) GetPrometheusDiscoveryServer(include []string) (*restful.We
 
 func (h *HTTPServer) addPrometheusDefaultAccess(ws *restful.WebService) {
 	ws.Route(ws.GET("/clients").To(h.GetPrometheusClients))
+	ws.Route(docs.EnrichGetPrometheusInstancesApiDocs(ws.GET("/instances").To(h.GetPrometheusInstances)))
 }
 
 // GetPrometheusClients 对接 prometheus 基于 http 的 service discovery
@@ -61,3 +67,29 @@ func (h *HTTPServer) GetPrometheusClients(req *restful.Request, rsp *restful.Res
 	ret := h.namingServer.GetPrometheusTargets(context.Background(), queryParams)
 	_ = rsp.WriteAsJson(ret.Response)
 }
+
+// GetPrometheusInstances 对接 prometheus 基于 http 的 service discovery，返回服务实例
+// 通过 ETag 返回结果的版本，prometheus 带上 If-None-Match 轮询时结果没有变化直接返回 304
+func (h *HTTPServer) GetPrometheusInstances(req *restful.Request, rsp *restful.Response) {
+	handler := &httpcommon.Handler{
+		Request:  req,
+		Response: rsp,
+	}
+
+	queryParams := httpcommon.ParseQueryParams(req)
+	if etag := req.HeaderParameter("If-None-Match"); etag != "" {
+		queryParams["revision"] = strings.Trim(strings.TrimPrefix(etag, "W/"), "\"")
+	}
+	ret := h.namingServer.GetPrometheusInstanceTargets(handler.ParseHeaderContext(), queryParams)
+	if ret.Revision != "" {
+		rsp.AddHeader("ETag", strconv.Quote(ret.Revision))
+	}
+	switch ret.Code {
+	case api.ExecuteSuccess:
+		_ = rsp.WriteAsJson(ret.Response)
+	case api.DataNoChange:
+		rsp.WriteHeader(http.StatusNotModified)
+	default:
+		_ = rsp.WriteHeaderAndJson(int(ret.Code/1000), ret, restful.MIME_JSON)
+	}
+}
@@ -18,8 +18,11 @@
 package httpserver
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -72,6 +75,7 @@ type HTTPServer struct {
 	listenPort      uint32
 	connLimitConfig *connlimit.Config
 	tlsInfo         *secure.TLSInfo
+	certReloader    *secure.CertReloader
 	option          map[string]interface{}
 	openAPI         map[string]apiserver.APIConfig
 	start           bool
@@ -163,10 +167,8 @@ func (h *HTTPServer) Initialize(ctx context.Context, option map[string]interface
 		if err != nil {
 			return err
 		}
-		h.tlsInfo = &secure.TLSInfo{
-			CertFile:      tlsConfig.CertFile,
-			KeyFile:       tlsConfig.KeyFile,
-			TrustedCAFile: tlsConfig.TrustedCAFile,
+		if h.tlsInfo, err = tlsConfig.ToTLSInfo(); err != nil {
+			return err
 		}
 	}
 
@@ -256,7 +258,7 @@ func (h *HTTPServer) Run(errCh chan error) {
 		return
 	}
 
-	server := http.Server{Addr: address, Handler: wsContainer, WriteTimeout: 1 * time.Minute}
+	server := http.Server{Addr: address, Handler: h.certPrincipalHandler(wsContainer), WriteTimeout: 1 * time.Minute}
 	var ln net.Listener
 	ln, err = net.Listen("tcp", address)
 	if err != nil {
@@ -283,7 +285,17 @@ func (h *HTTPServer) Run(errCh chan error) {
 	if h.tlsInfo.IsEmpty() {
 		err = server.Serve(ln)
 	} else {
-		err = server.ServeTLS(ln, h.tlsInfo.CertFile, h.tlsInfo.KeyFile)
+		// 证书以及信任的 CA 更新后自动重新加载
+		h.certReloader, err = secure.NewCertReloader(h.tlsInfo)
+		if err != nil {
+			log.Errorf("load tls cert err: %s", err.Error())
+			errCh <- err
+			return
+		}
+		h.certReloader.Start()
+		defer h.certReloader.Stop()
+		server.TLSConfig = h.certReloader.ServerTLSConfig()
+		err = server.ServeTLS(ln, "", "")
 	}
 	if err != nil {
 		log.Errorf("%+v", err)
@@ -298,6 +310,21 @@ func (h *HTTPServer) Run(errCh chan error) {
 	log.Infof("httpserver stop")
 }
 
+// certPrincipalHandler 双向 tls 下将客户端证书的身份映射为 principal 并注入到请求的 context 中
+func (h *HTTPServer) certPrincipalHandler(next http.Handler) http.Handler {
+	if !h.tlsInfo.IsMutual() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			if principal := h.tlsInfo.MapPrincipal(r.TLS.PeerCertificates); principal != "" {
+				r = r.WithContext(context.WithValue(r.Context(), utils.ContextCertPrincipalKey, principal))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // Stop shutdown server
 func (h *HTTPServer) Stop() {
 	// 释放connLimit的数据，如果没有开启，也需要执行一下
@@ -639,26 +666,140 @@ func (h *HTTPServer) enterRateLimit(req *restful.Request, rsp *restful.Response)
 	if len(segments) != 2 {
 		return nil
 	}
-	if ok := h.rateLimit.Allow(plugin.IPRatelimit, segments[0]); !ok {
+	if ok, backoff := plugin.AllowWithBackoff(h.rateLimit, plugin.IPRatelimit, segments[0]); !ok {
 		log.Error("ip ratelimit is not allow", zap.String("client", address),
 			utils.ZapRequestID(rid))
-		httpcommon.HTTPResponse(req, rsp, api.IPRateLimit)
+		h.rateLimitResponse(req, rsp, api.IPRateLimit, backoff)
 		return errors.New("ip ratelimit is not allow")
 	}
 
 	// 接口级限流
 	apiName := fmt.Sprintf("%s:%s", req.Request.Method,
 		strings.TrimSuffix(req.Request.URL.Path, "/"))
-	if ok := h.rateLimit.Allow(plugin.APIRatelimit, apiName); !ok {
+	if ok, backoff := plugin.AllowWithBackoff(h.rateLimit, plugin.APIRatelimit, apiName); !ok {
 		log.Error("api ratelimit is not allow", zap.String("client", address),
 			utils.ZapRequestID(rid), zap.String("api", apiName))
-		httpcommon.HTTPResponse(req, rsp, api.APIRateLimit)
+		h.rateLimitResponse(req, rsp, api.APIRateLimit, backoff)
 		return errors.New("api ratelimit is not allow")
 	}
 
+	// 操作者级限流，只有携带了合法 token 的请求才会被统计
+	if principal := h.parsePrincipal(req); principal != "" {
+		if ok, backoff := plugin.AllowWithBackoff(h.rateLimit, plugin.PrincipalRatelimit, principal); !ok {
+			log.Error("principal ratelimit is not allow", zap.String("client", address),
+				utils.ZapRequestID(rid), zap.String("principal", principal))
+			h.rateLimitResponse(req, rsp, api.APIRateLimit, backoff)
+			return errors.New("principal ratelimit is not allow")
+		}
+	}
+
+	// 命名空间级限流，命名空间从请求参数或者请求体中获取
+	if !plugin.RatelimitEnabled(h.rateLimit, plugin.NamespaceRatelimit) {
+		return nil
+	}
+	for _, namespace := range parseRequestNamespaces(req) {
+		if ok, backoff := plugin.AllowWithBackoff(h.rateLimit, plugin.NamespaceRatelimit, namespace); !ok {
+			log.Error("namespace ratelimit is not allow", zap.String("client", address),
+				utils.ZapRequestID(rid), zap.String("namespace", namespace))
+			h.rateLimitResponse(req, rsp, api.APIRateLimit, backoff)
+			return errors.New("namespace ratelimit is not allow")
+		}
+	}
+
 	return nil
 }
 
+// parseRequestNamespaces 获取请求涉及的命名空间，优先使用请求参数，否则从 JSON 请求体中解析，
+// 读取后的请求体会被还原，不影响后续的处理
+func parseRequestNamespaces(req *restful.Request) []string {
+	if namespace := req.QueryParameter("namespace"); namespace != "" {
+		return []string{namespace}
+	}
+	body := req.Request.Body
+	if body == nil || body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(body, utils.MaxRequestBodySize))
+	req.Request.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}
+	if err != nil {
+		return nil
+	}
+	return parseBodyNamespaces(data)
+}
+
+// replayBody 还原已经被读取过的请求体
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// parseBodyNamespaces 解析请求体中的命名空间，支持单个对象以及对象数组，对象的命名空间来自
+// namespace 字段或者 service.namespace 字段
+func parseBodyNamespaces(data []byte) []string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	type namespaceBody struct {
+		Namespace string `json:"namespace"`
+		Service   struct {
+			Namespace string `json:"namespace"`
+		} `json:"service"`
+	}
+	var items []namespaceBody
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil
+		}
+	} else {
+		item := namespaceBody{}
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil
+		}
+		items = append(items, item)
+	}
+
+	namespaces := make([]string, 0, len(items))
+	exists := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		namespace := item.Namespace
+		if namespace == "" {
+			namespace = item.Service.Namespace
+		}
+		if _, ok := exists[namespace]; ok || namespace == "" {
+			continue
+		}
+		exists[namespace] = struct{}{}
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// parsePrincipal 解析请求 token 对应的操作者，操作者限流未开启或者 token 无效时返回空
+func (h *HTTPServer) parsePrincipal(req *restful.Request) string {
+	if h.userMgn == nil || !plugin.RatelimitEnabled(h.rateLimit, plugin.PrincipalRatelimit) {
+		return ""
+	}
+	token := req.HeaderParameter(utils.HeaderAuthTokenKey)
+	if token == "" {
+		return ""
+	}
+	principal, err := h.userMgn.ParsePrincipal(token)
+	if err != nil {
+		return ""
+	}
+	return principal
+}
+
+// rateLimitResponse 返回限流的应答，并通过 Retry-After 告知客户端需要等待的时间
+func (h *HTTPServer) rateLimitResponse(req *restful.Request, rsp *restful.Response, code uint32,
+	backoff time.Duration) {
+	if backoff > 0 {
+		rsp.AddHeader(utils.HeaderRetryAfterKey, utils.RetryAfterSeconds(backoff))
+	}
+	httpcommon.HTTPResponse(req, rsp, code)
+}
+
 func (h *HTTPServer) recoverFunc(i interface{}, w http.ResponseWriter) {
 	log.Errorf("panic %+v", i)
 	obj := &service_manage.Response{}
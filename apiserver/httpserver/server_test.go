@@ -0,0 +1,56 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequestNamespaces(t *testing.T) {
+	newRequest := func(target, body string) *restful.Request {
+		return restful.NewRequest(httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+	}
+
+	t.Run("query", func(t *testing.T) {
+		req := newRequest("/naming/v1/services?namespace=ns-1", `{"namespace":"ns-2"}`)
+		assert.Equal(t, []string{"ns-1"}, parseRequestNamespaces(req))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		body := `[{"namespace":"ns-1"},{"service":{"namespace":"ns-2"}},{"namespace":"ns-1"}]`
+		req := newRequest("/naming/v1/instances", body)
+		assert.Equal(t, []string{"ns-1", "ns-2"}, parseRequestNamespaces(req))
+
+		// 请求体需要能被再次读取
+		data, err := io.ReadAll(req.Request.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(data))
+	})
+
+	t.Run("invalid-body", func(t *testing.T) {
+		req := newRequest("/naming/v1/instances", `namespace=ns-1`)
+		assert.Empty(t, parseRequestNamespaces(req))
+	})
+}
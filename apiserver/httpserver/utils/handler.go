@@ -96,6 +96,10 @@ func (h *Handler) postParseMessage(requestID string) (context.Context, error) {
 	ctx = context.WithValue(ctx, utils.StringContext("request-id"), requestID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-id"), platformID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-token"), platformToken)
+	ctx = context.WithValue(ctx, utils.ContextClientAddress, h.Request.Request.RemoteAddr)
+	if principal := utils.ParseCertPrincipal(h.Request.Request.Context()); principal != "" {
+		ctx = context.WithValue(ctx, utils.ContextCertPrincipalKey, principal)
+	}
 	if token != "" {
 		ctx = context.WithValue(ctx, utils.StringContext("polaris-token"), token)
 	}
@@ -142,6 +146,9 @@ func (h *Handler) ParseHeaderContext() context.Context {
 	ctx = context.WithValue(ctx, utils.StringContext("platform-id"), platformID)
 	ctx = context.WithValue(ctx, utils.StringContext("platform-token"), platformToken)
 	ctx = context.WithValue(ctx, utils.ContextClientAddress, h.Request.Request.RemoteAddr)
+	if principal := utils.ParseCertPrincipal(h.Request.Request.Context()); principal != "" {
+		ctx = context.WithValue(ctx, utils.ContextCertPrincipalKey, principal)
+	}
 	if token != "" {
 		ctx = context.WithValue(ctx, utils.StringContext("polaris-token"), token)
 	}
@@ -331,6 +338,22 @@ func (h *Handler) WriteHeaderAndProto(obj api.ResponseMessage) {
 	}
 }
 
+// WriteHeaderAndJSON 返回Code和非 proto 定义的 JSON 对象
+func (h *Handler) WriteHeaderAndJSON(code uint32, obj interface{}) {
+	requestID := h.Request.HeaderParameter(utils.PolarisRequestID)
+	h.Request.SetAttribute(utils.PolarisCode, code)
+	status := int(code / 1000)
+
+	if code != api.ExecuteSuccess {
+		h.Response.AddHeader(utils.PolarisCode, fmt.Sprintf("%d", code))
+		h.Response.AddHeader(utils.PolarisMessage, api.Code2Info(code))
+	}
+	h.Response.AddHeader(utils.PolarisRequestID, requestID)
+	if err := h.Response.WriteHeaderAndJson(status, obj, restful.MIME_JSON); err != nil {
+		accesslog.Error(err.Error(), utils.ZapRequestID(requestID))
+	}
+}
+
 // WriteHeaderAndProtoV2 返回Code和Proto
 func (h *Handler) WriteHeaderAndProtoV2(obj api.ResponseMessageV2) {
 	requestID := h.Request.HeaderParameter(utils.PolarisRequestID)
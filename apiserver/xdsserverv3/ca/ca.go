@@ -0,0 +1,584 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+var log = commonlog.GetScopeOrDefaultByName(commonlog.XDSLoggerName)
+
+const (
+	// clockSkew 签发证书时 NotBefore 向前偏移, 兼容节点之间的时钟误差
+	clockSkew = 5 * time.Minute
+	// minRSAKeyBits CSR 中 RSA 公钥的最小长度
+	minRSAKeyBits = 2048
+)
+
+// Identity 工作负载的服务身份
+type Identity struct {
+	Namespace string
+	Service   string
+}
+
+// SpiffeID 服务身份对应的 SPIFFE ID, 格式为 spiffe://{trustDomain}/ns/{namespace}/sa/{service}
+func (i Identity) SpiffeID(trustDomain string) string {
+	return fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", trustDomain, i.Namespace, i.Service)
+}
+
+// WorkloadCert 签发给工作负载的证书, 私钥由工作负载自行生成并且只保存在工作负载本地
+type WorkloadCert struct {
+	Identity Identity
+	SpiffeID string
+	// CertChainPEM 工作负载证书以及签发的根证书
+	CertChainPEM []byte
+	NotBefore    time.Time
+	NotAfter     time.Time
+}
+
+// workloadKey 同一个服务身份下的多个工作负载使用不同的私钥, 按照公钥区分
+type workloadKey struct {
+	Identity  Identity
+	PublicKey string
+}
+
+// KeyCrypto 加密根证书私钥的加密插件
+type KeyCrypto interface {
+	Encrypt(plaintext string, key []byte) (string, error)
+	Decrypt(ciphertext string, key []byte) (string, error)
+}
+
+// RootStore 根证书的持久化存储, 集群内所有节点通过存储共享同一个根证书
+type RootStore interface {
+	// CreateCARootIfAbsent 信任域下没有根证书时保存 root, 返回该信任域当前生效的根证书
+	CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error)
+	// GetCARoot 查询信任域当前生效的根证书, 不存在时返回 nil
+	GetCARoot(trustDomain string) (*model.CARoot, error)
+	// RotateCARoot 信任域当前的根证书为 prevCertPEM 时替换为 root, 返回该信任域当前生效的根证书
+	RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error)
+}
+
+// CertificateAuthority 内置 CA, 根据工作负载提交的 CSR 为每个服务身份签发短期的 SPIFFE 工作负载证书
+type CertificateAuthority struct {
+	cfg       *Config
+	rootStore RootStore
+	keyCrypto KeyCrypto
+	cryptoKey []byte
+
+	lock     sync.RWMutex
+	rootCert *x509.Certificate
+	rootKey  crypto.Signer
+	rootPEM  []byte
+	// prevRoot 轮转前的根证书, 过期之前仍然保留在信任链中, 保证轮转期间旧根证书签发的工作负载证书仍然被信任
+	prevRoot *x509.Certificate
+	// workloads 已签发的工作负载证书
+	workloads map[workloadKey]*WorkloadCert
+}
+
+// NewCertificateAuthority 创建内置 CA, 设置了外部根证书时导入外部根证书, 否则使用存储中该信任域的根证书,
+// 存储中没有时生成自签根证书并保存, 保证重启以及集群内所有节点使用同一个根证书. 自签根证书的私钥使用 keyCrypto
+// 加密后才会保存到存储中. rootStore 为 nil 时根证书只保存在内存中
+func NewCertificateAuthority(cfg *Config, rootStore RootStore, keyCrypto KeyCrypto) (*CertificateAuthority, error) {
+	cfg.setDefault()
+	if err := cfg.Verify(); err != nil {
+		return nil, err
+	}
+	ca := &CertificateAuthority{
+		cfg:       cfg,
+		workloads: map[workloadKey]*WorkloadCert{},
+	}
+	if cfg.RootCertFile != "" {
+		if _, err := ca.loadRootFiles(); err != nil {
+			return nil, err
+		}
+		return ca, nil
+	}
+
+	certPEM, keyPEM, err := ca.generateRoot(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if rootStore == nil {
+		if err := ca.ImportRoot(certPEM, keyPEM); err != nil {
+			return nil, err
+		}
+		return ca, nil
+	}
+
+	if keyCrypto == nil || cfg.RootKeyCryptoKey == "" {
+		return nil, errors.New("[XDS][CA] rootKeyCryptoKey is required to save the self-signed root key in store, " +
+			"or set rootCertFile and rootKeyFile")
+	}
+	ca.rootStore = rootStore
+	ca.keyCrypto = keyCrypto
+	if ca.cryptoKey, err = base64.StdEncoding.DecodeString(cfg.RootKeyCryptoKey); err != nil {
+		return nil, err
+	}
+	root, err := ca.sealRoot(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	// 其他节点或者之前的启动已经生成过根证书时, 丢弃本次生成的根证书
+	if root, err = rootStore.CreateCARootIfAbsent(root); err != nil {
+		return nil, err
+	}
+	if err := ca.openRoot(root); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// TrustDomain SPIFFE 信任域
+func (ca *CertificateAuthority) TrustDomain() string {
+	return ca.cfg.TrustDomain
+}
+
+// RotateInterval 检查证书是否需要轮转的间隔
+func (ca *CertificateAuthority) RotateInterval() time.Duration {
+	return ca.cfg.RotateInterval
+}
+
+// RootCert 当前根证书
+func (ca *CertificateAuthority) RootCert() *x509.Certificate {
+	ca.lock.RLock()
+	defer ca.lock.RUnlock()
+	return ca.rootCert
+}
+
+// PrevRootCert 轮转前的根证书, 没有发生过轮转或者已经过期时返回 nil
+func (ca *CertificateAuthority) PrevRootCert() *x509.Certificate {
+	ca.lock.RLock()
+	defer ca.lock.RUnlock()
+	return ca.trustedPrevRoot(time.Now())
+}
+
+// trustedPrevRoot 仍然需要被信任的轮转前的根证书, 调用方需要持有读锁
+func (ca *CertificateAuthority) trustedPrevRoot(now time.Time) *x509.Certificate {
+	if ca.prevRoot == nil || now.After(ca.prevRoot.NotAfter) {
+		return nil
+	}
+	return ca.prevRoot
+}
+
+// RootCertPEM 当前根证书
+func (ca *CertificateAuthority) RootCertPEM() []byte {
+	ca.lock.RLock()
+	defer ca.lock.RUnlock()
+	return ca.rootPEM
+}
+
+// TrustBundlePEM 工作负载需要信任的根证书, 包括当前根证书以及仍未过期的轮转前的根证书
+func (ca *CertificateAuthority) TrustBundlePEM() []byte {
+	ca.lock.RLock()
+	defer ca.lock.RUnlock()
+	prev := ca.trustedPrevRoot(time.Now())
+	if prev == nil {
+		return ca.rootPEM
+	}
+	bundle := make([]byte, 0, len(ca.rootPEM)*2)
+	bundle = append(bundle, ca.rootPEM...)
+	return append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: prev.Raw})...)
+}
+
+// ImportRoot 导入外部根证书, 之前的根证书在过期之前仍然保留在信任链中, 已签发的工作负载证书在到达轮转时间后
+// 由新的根证书重新签发
+func (ca *CertificateAuthority) ImportRoot(certPEM, keyPEM []byte) error {
+	rootCert, rootKey, err := parseRoot(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	ca.setRoot(rootCert, rootKey, ca.rootCert)
+	return nil
+}
+
+// setRoot 切换根证书, 调用方需要持有写锁
+func (ca *CertificateAuthority) setRoot(rootCert *x509.Certificate, rootKey crypto.Signer, prev *x509.Certificate) {
+	if ca.rootCert != nil && bytes.Equal(ca.rootCert.Raw, rootCert.Raw) {
+		return
+	}
+	ca.rootCert = rootCert
+	ca.rootKey = rootKey
+	ca.rootPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootCert.Raw})
+	ca.prevRoot = prev
+	log.Infof("[XDS][CA] use root cert subject: %s, not after: %s", rootCert.Subject.String(),
+		rootCert.NotAfter.Format(time.RFC3339))
+}
+
+// RotateRoot 检查根证书是否需要轮转, 返回根证书是否发生了变化. 外部根证书在文件内容变化时重新导入,
+// 自签根证书在剩余有效期小于 rootRotateBefore 时生成新的根证书, 集群内只有一个节点的轮转会生效,
+// 其他节点从存储中获取轮转后的根证书
+func (ca *CertificateAuthority) RotateRoot(now time.Time) (bool, error) {
+	if ca.cfg.RootCertFile != "" {
+		return ca.loadRootFiles()
+	}
+
+	changed := false
+	if ca.rootStore != nil {
+		root, err := ca.rootStore.GetCARoot(ca.cfg.TrustDomain)
+		if err != nil {
+			return false, err
+		}
+		if root != nil && root.CertPEM != string(ca.RootCertPEM()) {
+			if err := ca.openRoot(root); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+	if !now.Add(ca.cfg.RootRotateBefore).After(ca.RootCert().NotAfter) {
+		return changed, nil
+	}
+
+	certPEM, keyPEM, err := ca.generateRoot(now)
+	if err != nil {
+		return changed, err
+	}
+	if ca.rootStore == nil {
+		return true, ca.ImportRoot(certPEM, keyPEM)
+	}
+	root, err := ca.sealRoot(certPEM, keyPEM)
+	if err != nil {
+		return changed, err
+	}
+	if root, err = ca.rootStore.RotateCARoot(root, string(ca.RootCertPEM())); err != nil {
+		return changed, err
+	}
+	return true, ca.openRoot(root)
+}
+
+// loadRootFiles 读取外部根证书文件, 返回根证书是否发生了变化
+func (ca *CertificateAuthority) loadRootFiles() (bool, error) {
+	certPEM, err := os.ReadFile(ca.cfg.RootCertFile)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(certPEM, ca.RootCertPEM()) {
+		return false, nil
+	}
+	keyPEM, err := os.ReadFile(ca.cfg.RootKeyFile)
+	if err != nil {
+		return false, err
+	}
+	rootCert, rootKey, err := parseRoot(certPEM, keyPEM)
+	if err != nil {
+		return false, err
+	}
+
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	if ca.rootCert != nil && bytes.Equal(ca.rootCert.Raw, rootCert.Raw) {
+		return false, nil
+	}
+	ca.setRoot(rootCert, rootKey, ca.rootCert)
+	return true, nil
+}
+
+// sealRoot 加密根证书私钥, 生成保存到存储中的根证书
+func (ca *CertificateAuthority) sealRoot(certPEM, keyPEM []byte) (*model.CARoot, error) {
+	sealed, err := ca.keyCrypto.Encrypt(string(keyPEM), ca.cryptoKey)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &model.CARoot{
+		TrustDomain:   ca.cfg.TrustDomain,
+		CertPEM:       string(certPEM),
+		KeyPEM:        sealed,
+		KeyCryptoAlgo: ca.cfg.RootKeyCryptoAlgo,
+		CreateTime:    now,
+		ModifyTime:    now,
+	}, nil
+}
+
+// openRoot 解密存储中的根证书私钥并切换为该根证书
+func (ca *CertificateAuthority) openRoot(root *model.CARoot) error {
+	if root.KeyCryptoAlgo != ca.cfg.RootKeyCryptoAlgo {
+		return fmt.Errorf("[XDS][CA] root key of trust domain %s is encrypted by %q, but rootKeyCryptoAlgo is %q",
+			root.TrustDomain, root.KeyCryptoAlgo, ca.cfg.RootKeyCryptoAlgo)
+	}
+	keyPEM, err := ca.keyCrypto.Decrypt(root.KeyPEM, ca.cryptoKey)
+	if err != nil {
+		return fmt.Errorf("[XDS][CA] decrypt root key of trust domain %s: %w", root.TrustDomain, err)
+	}
+	rootCert, rootKey, err := parseRoot([]byte(root.CertPEM), []byte(keyPEM))
+	if err != nil {
+		return err
+	}
+	var prev *x509.Certificate
+	if block, _ := pem.Decode([]byte(root.PrevCertPEM)); block != nil {
+		if prev, err = x509.ParseCertificate(block.Bytes); err != nil {
+			return err
+		}
+	}
+
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	ca.setRoot(rootCert, rootKey, prev)
+	return nil
+}
+
+// generateRoot 生成自签根证书, 返回 PEM 格式的根证书以及私钥
+func (ca *CertificateAuthority) generateRoot(now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"polaris"},
+			CommonName:   "Polaris Mesh Root CA",
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(ca.cfg.RootCertTTL),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: ca.cfg.TrustDomain}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// GetWorkloadCert 根据工作负载提交的 CSR 获取服务身份的工作负载证书, 证书不存在或者即将过期时重新签发.
+// 签发的证书只使用 CSR 中的公钥, 证书的主体以及 SPIFFE ID 由服务身份决定
+func (ca *CertificateAuthority) GetWorkloadCert(id Identity, csrPEM []byte) (*WorkloadCert, error) {
+	csr, err := ParseCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(csr.RawSubjectPublicKeyInfo)
+	key := workloadKey{Identity: id, PublicKey: hex.EncodeToString(sum[:])}
+
+	now := time.Now()
+	ca.lock.RLock()
+	cert, ok := ca.workloads[key]
+	ca.lock.RUnlock()
+	if ok && !ca.needRotate(cert, now) {
+		return cert, nil
+	}
+
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+	// double check, 避免并发重复签发
+	if cert, ok := ca.workloads[key]; ok && !ca.needRotate(cert, now) {
+		return cert, nil
+	}
+	cert, err = ca.issue(id, csr, now)
+	if err != nil {
+		return nil, err
+	}
+	ca.workloads[key] = cert
+	return cert, nil
+}
+
+// EvictExpiring 清理即将过期的工作负载证书, 返回被清理的服务身份. 在线的工作负载重新获取证书时使用 CSR 重新签发,
+// 已经下线的工作负载的证书不会一直保留
+func (ca *CertificateAuthority) EvictExpiring(now time.Time) []Identity {
+	ca.lock.Lock()
+	defer ca.lock.Unlock()
+
+	evicted := make([]Identity, 0, 4)
+	for key, cert := range ca.workloads {
+		if !ca.needRotate(cert, now) {
+			continue
+		}
+		delete(ca.workloads, key)
+		evicted = append(evicted, key.Identity)
+	}
+	return evicted
+}
+
+// ListWorkloadCerts 列出当前已签发的工作负载证书
+func (ca *CertificateAuthority) ListWorkloadCerts() []*WorkloadCert {
+	ca.lock.RLock()
+	defer ca.lock.RUnlock()
+
+	ret := make([]*WorkloadCert, 0, len(ca.workloads))
+	for i := range ca.workloads {
+		ret = append(ret, ca.workloads[i])
+	}
+	return ret
+}
+
+func (ca *CertificateAuthority) needRotate(cert *WorkloadCert, now time.Time) bool {
+	return now.Add(ca.cfg.RotateBefore).After(cert.NotAfter)
+}
+
+// issue 使用 CSR 中的公钥签发工作负载证书, 调用方需要持有写锁
+func (ca *CertificateAuthority) issue(id Identity, csr *x509.CertificateRequest,
+	now time.Time) (*WorkloadCert, error) {
+	if id.Namespace == "" || id.Service == "" {
+		return nil, fmt.Errorf("[XDS][CA] invalid workload identity %+v", id)
+	}
+	spiffeID := id.SpiffeID(ca.cfg.TrustDomain)
+	uri, err := url.Parse(spiffeID)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	notAfter := now.Add(ca.cfg.WorkloadCertTTL)
+	// 工作负载证书的有效期不能超过根证书
+	if notAfter.After(ca.rootCert.NotAfter) {
+		notAfter = ca.rootCert.NotAfter
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"polaris"},
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.rootCert, csr.PublicKey, ca.rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	chain = append(chain, ca.rootPEM...)
+	return &WorkloadCert{
+		Identity:     id,
+		SpiffeID:     spiffeID,
+		CertChainPEM: chain,
+		NotBefore:    tmpl.NotBefore,
+		NotAfter:     notAfter,
+	}, nil
+}
+
+// ParseCSR 解析并校验工作负载提交的 PEM 格式 CSR, CSR 需要由对应的私钥签名, 并且使用足够强度的公钥
+func ParseCSR(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("[XDS][CA] invalid csr pem")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("[XDS][CA] invalid csr signature: %w", err)
+	}
+	switch pub := csr.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() && pub.Curve != elliptic.P384() {
+			return nil, errors.New("[XDS][CA] csr ecdsa key must use P-256 or P-384")
+		}
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("[XDS][CA] csr rsa key must be at least %d bits", minRSAKeyBits)
+		}
+	case ed25519.PublicKey:
+	default:
+		return nil, errors.New("[XDS][CA] unsupported csr key type")
+	}
+	return csr, nil
+}
+
+// parseRoot 解析根证书以及私钥, 校验根证书可以签发证书并且和私钥匹配
+func parseRoot(certPEM, keyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, nil, errors.New("[XDS][CA] invalid root cert pem")
+	}
+	rootCert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rootCert.IsCA || rootCert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, nil, errors.New("[XDS][CA] root cert can not be used to sign certificates")
+	}
+	rootKey, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, ok := rootKey.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(rootCert.PublicKey) {
+		return nil, nil, errors.New("[XDS][CA] root key does not match root cert")
+	}
+	return rootCert, rootKey, nil
+}
+
+func parsePrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("[XDS][CA] invalid root key pem")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("[XDS][CA] unsupported root key type")
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("[XDS][CA] unsupported root key format")
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
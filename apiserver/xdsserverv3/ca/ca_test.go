@@ -0,0 +1,382 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin/crypto/aes"
+)
+
+var testCryptoKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+
+func verifyWorkloadCert(t *testing.T, rootPEM []byte, cert *WorkloadCert) *x509.Certificate {
+	roots := x509.NewCertPool()
+	assert.True(t, roots.AppendCertsFromPEM(rootPEM))
+	block, _ := pem.Decode(cert.CertChainPEM)
+	assert.NotNil(t, block)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+	return leaf
+}
+
+// newCSR 模拟工作负载在本地生成私钥以及 CSR
+func newCSR(t *testing.T, tmpl *x509.CertificateRequest) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	if tmpl == nil {
+		tmpl = &x509.CertificateRequest{}
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	assert.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func newRootPEM(t *testing.T, ttl time.Duration) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "external root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(ttl),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func Test_CertificateAuthority_Issue(t *testing.T) {
+	ca, err := NewCertificateAuthority(&Config{Enable: true}, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, ca.RootCert().IsCA)
+
+	id := Identity{Namespace: "default", Service: "order"}
+	// CSR 中申请的身份不生效, 证书的 SPIFFE ID 由服务身份决定
+	key, csrPEM := newCSR(t, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "admin"},
+		URIs:    []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/ns/default/sa/admin"}},
+	})
+	cert, err := ca.GetWorkloadCert(id, csrPEM)
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://cluster.local/ns/default/sa/order", cert.SpiffeID)
+
+	leaf := verifyWorkloadCert(t, ca.RootCertPEM(), cert)
+	assert.Equal(t, 1, len(leaf.URIs))
+	assert.Equal(t, cert.SpiffeID, leaf.URIs[0].String())
+	assert.Empty(t, leaf.Subject.CommonName)
+	assert.True(t, key.PublicKey.Equal(leaf.PublicKey))
+	assert.True(t, cert.NotAfter.Sub(cert.NotBefore) <= DefaultWorkloadCertTTL+clockSkew)
+
+	// 证书未到轮转时间, 返回缓存的证书
+	again, err := ca.GetWorkloadCert(id, csrPEM)
+	assert.NoError(t, err)
+	assert.Equal(t, cert, again)
+
+	// 同一个服务身份的其他工作负载使用自己的私钥
+	otherKey, otherCSR := newCSR(t, nil)
+	other, err := ca.GetWorkloadCert(id, otherCSR)
+	assert.NoError(t, err)
+	assert.True(t, otherKey.PublicKey.Equal(verifyWorkloadCert(t, ca.RootCertPEM(), other).PublicKey))
+	assert.Equal(t, 2, len(ca.ListWorkloadCerts()))
+
+	_, err = ca.GetWorkloadCert(Identity{Namespace: "default"}, csrPEM)
+	assert.Error(t, err)
+}
+
+func Test_ParseCSR(t *testing.T) {
+	_, csrPEM := newCSR(t, nil)
+	_, err := ParseCSR(csrPEM)
+	assert.NoError(t, err)
+
+	_, err = ParseCSR([]byte("not a csr"))
+	assert.Error(t, err)
+
+	// 签名被篡改
+	block, _ := pem.Decode(csrPEM)
+	block.Bytes[len(block.Bytes)-1] ^= 0xff
+	_, err = ParseCSR(pem.EncodeToMemory(block))
+	assert.Error(t, err)
+
+	// 公钥强度不足
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, weak)
+	assert.NoError(t, err)
+	_, err = ParseCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+	assert.Error(t, err)
+}
+
+func Test_CertificateAuthority_EvictExpiring(t *testing.T) {
+	ca, err := NewCertificateAuthority(&Config{
+		Enable:          true,
+		WorkloadCertTTL: time.Hour,
+		RotateBefore:    20 * time.Minute,
+	}, nil, nil)
+	assert.NoError(t, err)
+
+	id := Identity{Namespace: "default", Service: "order"}
+	_, csrPEM := newCSR(t, nil)
+	cert, err := ca.GetWorkloadCert(id, csrPEM)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, len(ca.EvictExpiring(time.Now().Add(10*time.Minute))))
+	assert.Equal(t, []Identity{id}, ca.EvictExpiring(time.Now().Add(45*time.Minute)))
+	assert.Equal(t, 0, len(ca.ListWorkloadCerts()))
+
+	newCert, err := ca.GetWorkloadCert(id, csrPEM)
+	assert.NoError(t, err)
+	assert.NotEqual(t, cert.CertChainPEM, newCert.CertChainPEM)
+}
+
+func Test_CertificateAuthority_ImportRoot(t *testing.T) {
+	certPEM, keyPEM := newRootPEM(t, 12*time.Hour)
+
+	ca, err := NewCertificateAuthority(&Config{Enable: true}, nil, nil)
+	assert.NoError(t, err)
+	oldRootPEM := ca.RootCertPEM()
+	id := Identity{Namespace: "default", Service: "order"}
+	_, csrPEM := newCSR(t, nil)
+	oldCert, err := ca.GetWorkloadCert(id, csrPEM)
+	assert.NoError(t, err)
+
+	// 私钥与根证书不匹配
+	_, otherKeyPEM := newRootPEM(t, time.Hour)
+	assert.Error(t, ca.ImportRoot(certPEM, otherKeyPEM))
+
+	assert.NoError(t, ca.ImportRoot(certPEM, keyPEM))
+	assert.Equal(t, certPEM, ca.RootCertPEM())
+	// 旧根证书在过期之前仍然被信任, 已签发的工作负载证书继续使用到轮转时间
+	bundle := ca.TrustBundlePEM()
+	assert.True(t, strings.HasPrefix(string(bundle), string(certPEM)))
+	assert.Contains(t, string(bundle), string(oldRootPEM))
+	verifyWorkloadCert(t, bundle, oldCert)
+	assert.Equal(t, 1, len(ca.ListWorkloadCerts()))
+
+	ca.EvictExpiring(time.Now().Add(DefaultWorkloadCertTTL))
+	cert, err := ca.GetWorkloadCert(id, csrPEM)
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldCert.CertChainPEM, cert.CertChainPEM)
+	verifyWorkloadCert(t, certPEM, cert)
+	// 工作负载证书有效期不能超过根证书
+	assert.False(t, cert.NotAfter.After(ca.RootCert().NotAfter))
+}
+
+func Test_CertificateAuthority_RootFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "root-cert.pem"), filepath.Join(dir, "root-key.pem")
+	certPEM, keyPEM := newRootPEM(t, 12*time.Hour)
+	assert.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	assert.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+
+	ca, err := NewCertificateAuthority(&Config{Enable: true, RootCertFile: certFile, RootKeyFile: keyFile},
+		&memoryRootStore{roots: map[string]*model.CARoot{}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, certPEM, ca.RootCertPEM())
+
+	changed, err := ca.RotateRoot(time.Now())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// 替换根证书文件后自动轮转
+	newCertPEM, newKeyPEM := newRootPEM(t, 24*time.Hour)
+	assert.NoError(t, os.WriteFile(certFile, newCertPEM, 0600))
+	assert.NoError(t, os.WriteFile(keyFile, newKeyPEM, 0600))
+	changed, err = ca.RotateRoot(time.Now())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, newCertPEM, ca.RootCertPEM())
+	assert.Contains(t, string(ca.TrustBundlePEM()), string(certPEM))
+}
+
+// memoryRootStore 模拟集群共享的存储, 只保存第一次写入的根证书, 轮转时比较并替换
+type memoryRootStore struct {
+	roots map[string]*model.CARoot
+}
+
+func (m *memoryRootStore) CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error) {
+	if exist, ok := m.roots[root.TrustDomain]; ok {
+		return exist, nil
+	}
+	m.roots[root.TrustDomain] = root
+	return root, nil
+}
+
+func (m *memoryRootStore) GetCARoot(trustDomain string) (*model.CARoot, error) {
+	return m.roots[trustDomain], nil
+}
+
+func (m *memoryRootStore) RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error) {
+	exist := m.roots[root.TrustDomain]
+	if exist.CertPEM != prevCertPEM {
+		return exist, nil
+	}
+	saved := *root
+	saved.PrevCertPEM = prevCertPEM
+	m.roots[root.TrustDomain] = &saved
+	return &saved, nil
+}
+
+func Test_CertificateAuthority_SharedRoot(t *testing.T) {
+	rootStore := &memoryRootStore{roots: map[string]*model.CARoot{}}
+	keyCrypto := &aes.AESCrypto{}
+
+	// 自签根证书保存到存储时必须设置私钥的加密密钥
+	_, err := NewCertificateAuthority(&Config{Enable: true}, rootStore, keyCrypto)
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(rootStore.roots))
+
+	first, err := NewCertificateAuthority(&Config{Enable: true, RootKeyCryptoKey: testCryptoKey}, rootStore,
+		keyCrypto)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rootStore.roots))
+	saved := rootStore.roots[DefaultTrustDomain]
+	assert.Equal(t, saved.CertPEM, string(first.RootCertPEM()))
+	// 存储中不保存明文私钥
+	assert.Equal(t, DefaultRootKeyCryptoAlgo, saved.KeyCryptoAlgo)
+	assert.NotContains(t, saved.KeyPEM, "PRIVATE KEY")
+
+	// 重启或者其他节点使用存储中的根证书, 不会重新生成
+	second, err := NewCertificateAuthority(&Config{Enable: true, RootKeyCryptoKey: testCryptoKey}, rootStore,
+		keyCrypto)
+	assert.NoError(t, err)
+	assert.Equal(t, first.RootCertPEM(), second.RootCertPEM())
+
+	// 加密密钥错误时无法解密存储中的根证书私钥
+	wrongKey := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210"))
+	_, err = NewCertificateAuthority(&Config{Enable: true, RootKeyCryptoKey: wrongKey}, rootStore, keyCrypto)
+	assert.Error(t, err)
+
+	// 不同节点签发的工作负载证书互相信任
+	_, csrPEM := newCSR(t, nil)
+	cert, err := second.GetWorkloadCert(Identity{Namespace: "default", Service: "order"}, csrPEM)
+	assert.NoError(t, err)
+	verifyWorkloadCert(t, first.RootCertPEM(), cert)
+
+	// 不同信任域使用不同的根证书
+	other, err := NewCertificateAuthority(&Config{Enable: true, TrustDomain: "other.local",
+		RootKeyCryptoKey: testCryptoKey}, rootStore, keyCrypto)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RootCertPEM(), other.RootCertPEM())
+}
+
+func Test_CertificateAuthority_RotateRoot(t *testing.T) {
+	rootStore := &memoryRootStore{roots: map[string]*model.CARoot{}}
+	keyCrypto := &aes.AESCrypto{}
+	newCA := func() *CertificateAuthority {
+		ca, err := NewCertificateAuthority(&Config{Enable: true, RootKeyCryptoKey: testCryptoKey}, rootStore,
+			keyCrypto)
+		assert.NoError(t, err)
+		return ca
+	}
+	first, second := newCA(), newCA()
+	oldRootPEM := first.RootCertPEM()
+	_, csrPEM := newCSR(t, nil)
+	oldCert, err := second.GetWorkloadCert(Identity{Namespace: "default", Service: "order"}, csrPEM)
+	assert.NoError(t, err)
+
+	// 根证书剩余有效期充足时不轮转
+	changed, err := first.RotateRoot(time.Now())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	expiring := first.RootCert().NotAfter.Add(-DefaultRootCertTTL / 20)
+	changed, err = first.RotateRoot(expiring)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotEqual(t, oldRootPEM, first.RootCertPEM())
+	assert.Equal(t, string(oldRootPEM), rootStore.roots[DefaultTrustDomain].PrevCertPEM)
+	assert.NotContains(t, rootStore.roots[DefaultTrustDomain].KeyPEM, "PRIVATE KEY")
+
+	// 其他节点从存储中获取轮转后的根证书, 而不是再次轮转
+	changed, err = second.RotateRoot(expiring)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, first.RootCertPEM(), second.RootCertPEM())
+	assert.Equal(t, first.TrustBundlePEM(), second.TrustBundlePEM())
+	changed, err = second.RotateRoot(expiring)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// 轮转前签发的工作负载证书在信任链中仍然有效
+	verifyWorkloadCert(t, second.TrustBundlePEM(), oldCert)
+}
+
+func Test_ParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(map[interface{}]interface{}{
+		"enable":          true,
+		"workloadCertTTL": "6h",
+	})
+	assert.NoError(t, err)
+	assert.True(t, cfg.Enable)
+	assert.Equal(t, DefaultTrustDomain, cfg.TrustDomain)
+	assert.Equal(t, 2*time.Hour, cfg.RotateBefore)
+	assert.Equal(t, DefaultRootCertTTL/10, cfg.RootRotateBefore)
+	assert.Equal(t, DefaultRootKeyCryptoAlgo, cfg.RootKeyCryptoAlgo)
+
+	_, err = ParseConfig(map[interface{}]interface{}{
+		"enable":       true,
+		"rootCertFile": "root.pem",
+	})
+	assert.Error(t, err)
+
+	_, err = ParseConfig(map[interface{}]interface{}{
+		"workloadCertTTL": "1h",
+		"rotateBefore":    "2h",
+	})
+	assert.Error(t, err)
+
+	_, err = ParseConfig(map[interface{}]interface{}{
+		"workloadCertTTL":  "24h",
+		"rootRotateBefore": "12h",
+	})
+	assert.Error(t, err)
+
+	_, err = ParseConfig(map[interface{}]interface{}{
+		"rootKeyCryptoKey": "not base64!",
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ca
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// DefaultTrustDomain 默认的 SPIFFE 信任域
+	DefaultTrustDomain = "cluster.local"
+	// DefaultWorkloadCertTTL 默认工作负载证书的有效期
+	DefaultWorkloadCertTTL = 24 * time.Hour
+	// DefaultRootCertTTL 默认自签根证书的有效期
+	DefaultRootCertTTL = 10 * 365 * 24 * time.Hour
+	// DefaultRotateInterval 默认检查工作负载证书是否需要轮转的间隔
+	DefaultRotateInterval = time.Minute
+	// DefaultRootKeyCryptoAlgo 默认加密根证书私钥的算法
+	DefaultRootKeyCryptoAlgo = "AES"
+)
+
+// Config 内置 CA 的配置
+type Config struct {
+	// Enable 是否开启内置 CA, 开启后通过 SDS 向 Envoy 下发工作负载证书
+	Enable bool `mapstructure:"enable"`
+	// TrustDomain SPIFFE 信任域
+	TrustDomain string `mapstructure:"trustDomain"`
+	// WorkloadCertTTL 工作负载证书的有效期
+	WorkloadCertTTL time.Duration `mapstructure:"workloadCertTTL"`
+	// RotateBefore 证书剩余有效期小于该值时重新签发, 默认为有效期的 1/3
+	RotateBefore time.Duration `mapstructure:"rotateBefore"`
+	// RotateInterval 检查证书是否需要轮转的间隔
+	RotateInterval time.Duration `mapstructure:"rotateInterval"`
+	// RootCertTTL 自签根证书的有效期, 导入外部根证书时不生效
+	RootCertTTL time.Duration `mapstructure:"rootCertTTL"`
+	// RootRotateBefore 自签根证书剩余有效期小于该值时轮转根证书, 默认为有效期的 1/10, 需要大于工作负载证书的有效期
+	RootRotateBefore time.Duration `mapstructure:"rootRotateBefore"`
+	// RootKeyCryptoAlgo 自签根证书私钥保存到存储之前使用的加密插件
+	RootKeyCryptoAlgo string `mapstructure:"rootKeyCryptoAlgo"`
+	// RootKeyCryptoKey 加密自签根证书私钥的密钥, base64 编码, 不设置外部根证书时必须设置
+	RootKeyCryptoKey string `mapstructure:"rootKeyCryptoKey"`
+	// RootCertFile 外部根证书文件, 需要和 RootKeyFile 同时设置, 文件内容变化时自动轮转
+	RootCertFile string `mapstructure:"rootCertFile"`
+	// RootKeyFile 外部根证书的私钥文件
+	RootKeyFile string `mapstructure:"rootKeyFile"`
+}
+
+// ParseConfig 解析内置 CA 配置
+func ParseConfig(raw map[interface{}]interface{}) (*Config, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	config := &Config{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	config.setDefault()
+	if err := config.Verify(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) setDefault() {
+	if c.TrustDomain == "" {
+		c.TrustDomain = DefaultTrustDomain
+	}
+	if c.WorkloadCertTTL <= 0 {
+		c.WorkloadCertTTL = DefaultWorkloadCertTTL
+	}
+	if c.RotateBefore <= 0 {
+		c.RotateBefore = c.WorkloadCertTTL / 3
+	}
+	if c.RotateInterval <= 0 {
+		c.RotateInterval = DefaultRotateInterval
+	}
+	if c.RootCertTTL <= 0 {
+		c.RootCertTTL = DefaultRootCertTTL
+	}
+	if c.RootRotateBefore <= 0 {
+		c.RootRotateBefore = c.RootCertTTL / 10
+	}
+	if c.RootKeyCryptoAlgo == "" {
+		c.RootKeyCryptoAlgo = DefaultRootKeyCryptoAlgo
+	}
+}
+
+// Verify 校验配置
+func (c *Config) Verify() error {
+	if c.RotateBefore >= c.WorkloadCertTTL {
+		return errors.New("[XDS][CA] rotateBefore must be less than workloadCertTTL")
+	}
+	if (c.RootCertFile == "") != (c.RootKeyFile == "") {
+		return errors.New("[XDS][CA] rootCertFile and rootKeyFile must be set together")
+	}
+	if c.RootRotateBefore >= c.RootCertTTL || c.RootRotateBefore <= c.WorkloadCertTTL {
+		return errors.New("[XDS][CA] rootRotateBefore must be between workloadCertTTL and rootCertTTL")
+	}
+	if c.RootKeyCryptoKey != "" {
+		if _, err := base64.StdEncoding.DecodeString(c.RootKeyCryptoKey); err != nil {
+			return errors.New("[XDS][CA] rootKeyCryptoKey must be base64 encoded")
+		}
+	}
+	return nil
+}
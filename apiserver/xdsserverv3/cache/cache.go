@@ -74,6 +74,7 @@ func NewUpdateResourcesRequest() *UpdateResourcesRequest {
 	return &UpdateResourcesRequest{
 		lock:               &sync.Mutex{},
 		Lds:                map[string]map[string]types.Resource{},
+		Sds:                map[string]map[string]types.Resource{},
 		NamespaceResources: map[string]*NamespaceUpdateResourcesRequest{},
 	}
 }
@@ -83,6 +84,8 @@ type UpdateResourcesRequest struct {
 	lock *sync.Mutex
 	// Lds LDS 相关的资源
 	Lds map[string]map[string]types.Resource
+	// Sds SDS 相关的资源, 按照 Envoy Node 维度下发各自的工作负载证书
+	Sds map[string]map[string]types.Resource
 	// NamespaceResources .
 	NamespaceResources map[string]*NamespaceUpdateResourcesRequest
 }
@@ -202,6 +205,13 @@ type ResourcesContainer struct {
 	VersionMap map[string]string
 }
 
+func newResourcesContainer() *ResourcesContainer {
+	return &ResourcesContainer{
+		Resources:  map[string]types.Resource{},
+		VersionMap: map[string]string{},
+	}
+}
+
 func (s *ResourcesContainer) updateGlobalRevision() {
 	s.GlobalVersion = utils.NewUUID()
 }
@@ -218,17 +228,12 @@ func (s *ResourcesContainer) ConstructVersionMap(modified []string) error {
 
 	if len(modified) == 0 {
 		// construct all resource
-		for _, res := range s.Resources {
-			// Hash our version in here and build the version map.
-			marshaledResource, err := cachev3.MarshalResource(res)
+		for name, res := range s.Resources {
+			v, err := hashResource(res)
 			if err != nil {
 				return err
 			}
-			v := cachev3.HashResource(marshaledResource)
-			if v == "" {
-				return fmt.Errorf("failed to build resource version: %w", err)
-			}
-			s.VersionMap[cachev3.GetResourceName(res)] = v
+			s.VersionMap[name] = v
 		}
 		return nil
 	}
@@ -237,20 +242,72 @@ func (s *ResourcesContainer) ConstructVersionMap(modified []string) error {
 		if !ok {
 			continue
 		}
-		// Hash our version in here and build the version map.
-		marshaledResource, err := cachev3.MarshalResource(res)
+		v, err := hashResource(res)
 		if err != nil {
 			return err
 		}
-		v := cachev3.HashResource(marshaledResource)
-		if v == "" {
-			return fmt.Errorf("failed to build resource version: %w", err)
-		}
 		s.VersionMap[name] = v
 	}
 	return nil
 }
 
+// applyChanges 将待删除、待更新的资源合并到容器中, 只有内容真正发生变化的资源才会刷新其版本, 返回发生变化的资源名称
+// 容器内任意资源发生变化时才会刷新 GlobalVersion, 避免没有变化的推送
+func (s *ResourcesContainer) applyChanges(upserts map[string]types.Resource,
+	removes map[string]struct{}) ([]string, error) {
+	if s.Resources == nil {
+		s.Resources = map[string]types.Resource{}
+	}
+	if s.VersionMap == nil {
+		if err := s.ConstructVersionMap(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	changed := make([]string, 0, len(upserts)+len(removes))
+	for name := range removes {
+		if _, ok := upserts[name]; ok {
+			// 同一批次内既删除又更新, 以更新为准
+			continue
+		}
+		if _, ok := s.Resources[name]; !ok {
+			continue
+		}
+		delete(s.Resources, name)
+		delete(s.VersionMap, name)
+		changed = append(changed, name)
+	}
+	for name, res := range upserts {
+		v, err := hashResource(res)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := s.Resources[name]; ok && s.VersionMap[name] == v {
+			continue
+		}
+		s.Resources[name] = res
+		s.VersionMap[name] = v
+		changed = append(changed, name)
+	}
+	if len(changed) > 0 || s.GlobalVersion == "" {
+		s.updateGlobalRevision()
+	}
+	return changed, nil
+}
+
+// hashResource 计算单个 xDS 资源的版本
+func hashResource(res types.Resource) (string, error) {
+	marshaledResource, err := cachev3.MarshalResource(res)
+	if err != nil {
+		return "", err
+	}
+	v := cachev3.HashResource(marshaledResource)
+	if v == "" {
+		return "", fmt.Errorf("failed to build resource %s version", cachev3.GetResourceName(res))
+	}
+	return v, nil
+}
+
 func newNamespaceResourcesContainer(ns string) *NamespaceResourcesContainer {
 	return &NamespaceResourcesContainer{
 		namespace:          ns,
@@ -295,6 +352,8 @@ type ResourceCache struct {
 	ads bool
 	// ldsResources 记录 Envoy Node LDS 的资源记录信息
 	ldsResources map[string]*ResourcesContainer
+	// sdsResources 记录 Envoy Node SDS 的资源记录信息
+	sdsResources map[string]*ResourcesContainer
 	// namespaceContainer 按照命名空间级别隔离 xDS resources
 	namespaceContainer map[string]*NamespaceResourcesContainer
 	// status information for all nodes indexed by node IDs
@@ -317,6 +376,7 @@ func NewResourceCache(hook CacheHook) *ResourceCache {
 		hook:               hook,
 		ads:                true,
 		ldsResources:       make(map[string]*ResourcesContainer),
+		sdsResources:       make(map[string]*ResourcesContainer),
 		namespaceContainer: make(map[string]*NamespaceResourcesContainer),
 		status:             make(map[string]*NamespaceStatusInfo),
 	}
@@ -329,20 +389,95 @@ func (sc *ResourceCache) CleanEnvoyNodeCache(node *corev3.Node) error {
 	defer sc.mu.Unlock()
 
 	delete(sc.ldsResources, node.GetId())
+	delete(sc.sdsResources, node.GetId())
 	return nil
 }
 
-func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *UpdateResourcesRequest) {
-	// 更新 LDS 资源信息
-	for nodeId, resources := range req.Lds {
-		if _, ok := sc.ldsResources[nodeId]; !ok {
-			sc.ldsResources[nodeId] = &ResourcesContainer{
-				Resources: resources,
+// changedResources 记录本次更新中实际发生变化的 xDS 资源类型, 只有发生变化的类型才需要通知 watch
+type changedResources struct {
+	// lds 发生变化的 Envoy Node ID
+	lds map[string]struct{}
+	// sds 发生变化的 Envoy Node ID
+	sds map[string]struct{}
+	// namespaces 命名空间下发生变化的 xDS 资源类型
+	namespaces map[string]map[resource.XDSType]struct{}
+}
+
+func newChangedResources() *changedResources {
+	return &changedResources{
+		lds:        map[string]struct{}{},
+		sds:        map[string]struct{}{},
+		namespaces: map[string]map[resource.XDSType]struct{}{},
+	}
+}
+
+func (c *changedResources) markNamespace(ns string, xdsType resource.XDSType) {
+	if _, ok := c.namespaces[ns]; !ok {
+		c.namespaces[ns] = map[resource.XDSType]struct{}{}
+	}
+	c.namespaces[ns][xdsType] = struct{}{}
+}
+
+func (c *changedResources) isChanged(client *resource.XDSClient, xdsType resource.XDSType) bool {
+	switch xdsType {
+	case resource.LDS:
+		_, ok := c.lds[client.GetNodeID()]
+		return ok
+	case resource.SDS:
+		_, ok := c.sds[client.GetNodeID()]
+		return ok
+	}
+	_, ok := c.namespaces[client.GetSelfNamespace()][xdsType]
+	return ok
+}
+
+func (sc *ResourceCache) applyContainerChanges(container *ResourcesContainer, res *TypeResources) (bool, error) {
+	changed, err := container.applyChanges(res.UpsertResources, res.RemoveResources)
+	if err != nil {
+		return false, err
+	}
+	return len(changed) > 0, nil
+}
+
+// updateNodeResources 更新 Envoy Node 级别的全量资源, 不在本次结果中的资源需要移除
+func (sc *ResourceCache) updateNodeResources(containers map[string]*ResourcesContainer,
+	nodeResources map[string]map[string]types.Resource, changed map[string]struct{}) error {
+	for nodeId, resources := range nodeResources {
+		container, ok := containers[nodeId]
+		if !ok {
+			container = newResourcesContainer()
+			containers[nodeId] = container
+		}
+		removes := map[string]struct{}{}
+		for name := range container.Resources {
+			if _, exist := resources[name]; !exist {
+				removes[name] = struct{}{}
 			}
-			sc.ldsResources[nodeId].updateGlobalRevision()
-			_ = sc.ldsResources[nodeId].ConstructVersionMap(nil)
+		}
+		isChanged, err := sc.applyContainerChanges(container, &TypeResources{
+			UpsertResources: resources,
+			RemoveResources: removes,
+		})
+		if err != nil {
+			return err
+		}
+		if isChanged {
+			changed[nodeId] = struct{}{}
 		}
 	}
+	return nil
+}
+
+func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *UpdateResourcesRequest) (*changedResources, error) {
+	changes := newChangedResources()
+
+	// 更新 LDS/SDS 资源信息, 均为 Envoy Node 级别的全量资源
+	if err := sc.updateNodeResources(sc.ldsResources, req.Lds, changes.lds); err != nil {
+		return nil, err
+	}
+	if err := sc.updateNodeResources(sc.sdsResources, req.Sds, changes.sds); err != nil {
+		return nil, err
+	}
 
 	namespaceResources := req.NamespaceResources
 	for ns, nsResources := range namespaceResources {
@@ -353,10 +488,14 @@ func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *Updat
 		namespaceContainer := sc.namespaceContainer[ns]
 
 		// OnDemand 场景下的资源直接一把更新
-		demandResources := map[resource.XDSType]*ResourcesContainer{}
-		for xdsType, res := range nsResources.DemandResources {
-			demandResources[xdsType] = &ResourcesContainer{
-				Resources: res.UpsertResources,
+		if len(nsResources.DemandResources) > 0 {
+			demandResources := map[resource.XDSType]*ResourcesContainer{}
+			for xdsType, res := range nsResources.DemandResources {
+				container := newResourcesContainer()
+				if _, err := container.applyChanges(res.UpsertResources, nil); err != nil {
+					return nil, err
+				}
+				demandResources[xdsType] = container
 			}
 			namespaceContainer.demandResources = demandResources
 		}
@@ -367,48 +506,38 @@ func (sc *ResourceCache) updateResourceContainer(ctx context.Context, req *Updat
 				namespaceContainer.tlsResources[tlsMode] = make(map[resource.XDSType]*ResourcesContainer, 32)
 			}
 			for typeUrl, res := range xdsResources {
-				if container, ok := namespaceContainer.tlsResources[tlsMode][typeUrl]; !ok {
-					container = &ResourcesContainer{
-						Resources: res.UpsertResources,
-					}
+				container, ok := namespaceContainer.tlsResources[tlsMode][typeUrl]
+				if !ok {
+					container = newResourcesContainer()
 					namespaceContainer.tlsResources[tlsMode][typeUrl] = container
-				} else {
-					for name := range res.RemoveResources {
-						delete(container.Resources, name)
-						delete(container.VersionMap, name)
-					}
-					modified := make([]string, 0, len(res.UpsertResources))
-					for name, res := range res.UpsertResources {
-						container.Resources[name] = res
-						modified = append(modified, name)
-					}
 				}
-				namespaceContainer.tlsResources[tlsMode][typeUrl].updateGlobalRevision()
+				changed, err := sc.applyContainerChanges(container, res)
+				if err != nil {
+					return nil, err
+				}
+				if changed {
+					changes.markNamespace(ns, typeUrl)
+				}
 			}
 		}
 
 		// 更新非 LDS 的 XDS 规则到 ResourceContainer 中
-		for typeUrl, resources := range nsResources.NormalResources {
-			if container, ok := namespaceContainer.resourcesContainer[typeUrl]; !ok {
-				container = &ResourcesContainer{
-					Resources: resources.UpsertResources,
-				}
+		for typeUrl, res := range nsResources.NormalResources {
+			container, ok := namespaceContainer.resourcesContainer[typeUrl]
+			if !ok {
+				container = newResourcesContainer()
 				namespaceContainer.resourcesContainer[typeUrl] = container
-			} else {
-				for name := range resources.RemoveResources {
-					delete(container.Resources, name)
-					delete(container.VersionMap, name)
-				}
-				modified := make([]string, 0, len(resources.UpsertResources))
-				for name, res := range resources.UpsertResources {
-					container.Resources[name] = res
-					modified = append(modified, name)
-				}
 			}
-
-			namespaceContainer.resourcesContainer[typeUrl].updateGlobalRevision()
+			changed, err := sc.applyContainerChanges(container, res)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				changes.markNamespace(ns, typeUrl)
+			}
 		}
 	}
+	return changes, nil
 }
 
 // UpdateResources updates a snapshot for a node.
@@ -416,72 +545,75 @@ func (sc *ResourceCache) UpdateResources(ctx context.Context, req *UpdateResourc
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 
-	// updateResourceContainer 更新所有 XDS 资源的容器
-	sc.updateResourceContainer(ctx, req)
+	// updateResourceContainer 更新所有 XDS 资源的容器, 并且记录实际发生变化的资源类型
+	changes, err := sc.updateResourceContainer(ctx, req)
+	if err != nil {
+		log.Errorf("failed to update xds resource container: %s", err)
+		return err
+	}
 
-	for ns, nsStatus := range sc.status {
+	for _, nsStatus := range sc.status {
 		for _, info := range nsStatus.status {
-			info.mu.Lock()
-			defer info.mu.Unlock()
-			for id, watch := range info.watches {
-				watchType := resource.FormatTypeUrl(watch.Request.TypeUrl)
-				container, exists := sc.loadResourceContainer(info.client, watchType)
-				if !exists {
-					continue
-				}
-				curVersion := container.GlobalVersion
-				if curVersion != watch.Request.VersionInfo {
-					log.Debugf("respond open watch %d %s%v with new version %q", id, watch.Request.TypeUrl,
-						watch.Request.ResourceNames, curVersion)
-
-					resources := container.Resources
-					if err := sc.respond(ctx, watch.Request, watch.Response, resources, curVersion, false); err != nil {
-						return err
-					}
-					// discard the watch
-					delete(info.watches, id)
-				}
+			if err := sc.notifyWatches(ctx, info, changes); err != nil {
+				return err
 			}
+		}
+	}
+	return nil
+}
 
-			namespaceContainer, exist := sc.namespaceContainer[ns]
-			if !exist {
-				continue
-			}
-			// We only calculate version hashes when using delta. We don't
-			// want to do this when using SOTW so we can avoid unnecessary
-			// computational cost if not using delta.
-			if len(info.deltaWatches) > 0 {
-				for _, container := range namespaceContainer.resourcesContainer {
-					if err := container.ConstructVersionMap(nil); err != nil {
-						log.Errorf("failed to compute version for snapshot resources inline: %s", err)
-						return err
-					}
-				}
-			}
+// notifyWatches 通知 Envoy Node 上发生了变化的资源对应的 watch
+func (sc *ResourceCache) notifyWatches(ctx context.Context, info *statusInfo, changes *changedResources) error {
+	info.mu.Lock()
+	defer info.mu.Unlock()
 
-			// process our delta watches
-			for id, watch := range info.deltaWatches {
-				watchType := resource.FormatTypeUrl(watch.Request.TypeUrl)
-				container, exist := sc.loadResourceContainer(info.client, watchType)
-				if !exist {
-					continue
-				}
-				res, err := sc.respondDelta(
-					ctx,
-					container,
-					watch.Request,
-					watch.Response,
-					watch.StreamState,
-				)
-				if err != nil {
-					return err
-				}
-				// If we detect a nil response here, that means there has been no state change
-				// so we don't want to respond or remove any existing resource watches
-				if res != nil {
-					delete(info.deltaWatches, id)
-				}
+	for id, watch := range info.watches {
+		watchType := resource.FormatTypeUrl(watch.Request.TypeUrl)
+		if !changes.isChanged(info.client, watchType) {
+			continue
+		}
+		container, exists := sc.loadResourceContainer(info.client, watchType)
+		if !exists {
+			continue
+		}
+		curVersion := container.GlobalVersion
+		if curVersion != watch.Request.VersionInfo {
+			log.Debugf("respond open watch %d %s%v with new version %q", id, watch.Request.TypeUrl,
+				watch.Request.ResourceNames, curVersion)
+
+			resources := container.Resources
+			if err := sc.respond(ctx, watch.Request, watch.Response, resources, curVersion, false); err != nil {
+				return err
 			}
+			// discard the watch
+			delete(info.watches, id)
+		}
+	}
+
+	// process our delta watches, 资源版本在更新容器时已经按资源粒度计算, 这里只需要对比差异
+	for id, watch := range info.deltaWatches {
+		watchType := resource.FormatTypeUrl(watch.Request.TypeUrl)
+		if !changes.isChanged(info.client, watchType) {
+			continue
+		}
+		container, exist := sc.loadResourceContainer(info.client, watchType)
+		if !exist {
+			continue
+		}
+		res, err := sc.respondDelta(
+			ctx,
+			container,
+			watch.Request,
+			watch.Response,
+			watch.StreamState,
+		)
+		if err != nil {
+			return err
+		}
+		// If we detect a nil response here, that means there has been no state change
+		// so we don't want to respond or remove any existing resource watches
+		if res != nil {
+			delete(info.deltaWatches, id)
 		}
 	}
 	return nil
@@ -681,8 +813,11 @@ func (sc *ResourceCache) CreateDeltaWatch(request *cachev3.DeltaRequest, state s
 	// - we attempted to issue a response, but the caller is already up to date
 	delayedResponse := !exists
 	if exists {
-		if err := container.ConstructVersionMap(nil); err != nil {
-			log.Errorf("failed to compute version for snapshot resources inline: %s", err)
+		// 资源版本在更新容器时已经增量维护, 这里只兜底处理尚未构建的场景
+		if container.VersionMap == nil {
+			if err := container.ConstructVersionMap(nil); err != nil {
+				log.Errorf("failed to compute version for snapshot resources inline: %s", err)
+			}
 		}
 		response, err := sc.respondDelta(context.Background(), container, request, value, state)
 		if err != nil {
@@ -826,6 +961,9 @@ func (sc *ResourceCache) loadResourceContainer(client *resource.XDSClient, watch
 	case resource.LDS:
 		// 获取到 Envoy Node 对应希望看到的 ldsRes 资源
 		container, exists = sc.ldsResources[client.GetNodeID()]
+	case resource.SDS:
+		// 获取到 Envoy Node 对应的证书资源
+		container, exists = sc.sdsResources[client.GetNodeID()]
 	case resource.CDS:
 		switch client.TLSMode {
 		case resource.TLSModeNone:
@@ -864,8 +1002,12 @@ func (sc *ResourceCache) GetResources(typeUrl resource.XDSType, ns, nodeId strin
 	defer sc.mu.RUnlock()
 
 	var data *ResourcesContainer
-	if typeUrl == resource.LDS {
-		val, ok := sc.ldsResources[nodeId]
+	if typeUrl == resource.LDS || typeUrl == resource.SDS {
+		containers := sc.ldsResources
+		if typeUrl == resource.SDS {
+			containers = sc.sdsResources
+		}
+		val, ok := containers[nodeId]
 		if !ok {
 			return map[string]types.Resource{}
 		}
@@ -0,0 +1,142 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+)
+
+func testCluster(name string, timeout time.Duration) *cluster.Cluster {
+	return &cluster.Cluster{
+		Name:           name,
+		ConnectTimeout: durationpb.New(timeout),
+	}
+}
+
+func testCDSRequest(upserts []types.Resource, removes []types.Resource) *UpdateResourcesRequest {
+	req := NewUpdateResourcesRequest()
+	if len(upserts) > 0 {
+		req.AddNormalNamespaces("default", resource.CDS, upserts)
+	}
+	if len(removes) > 0 {
+		req.RemoveNormalNamespaces("default", resource.TLSModeNone, resource.CDS, removes)
+	}
+	return req
+}
+
+func receiveDelta(t *testing.T, ch chan cachev3.DeltaResponse) *cachev3.RawDeltaResponse {
+	select {
+	case resp := <-ch:
+		return resp.(*cachev3.RawDeltaResponse)
+	case <-time.After(time.Second):
+		t.Fatal("wait delta response timeout")
+	}
+	return nil
+}
+
+func Test_ResourceCache_DeltaWatch(t *testing.T) {
+	sc := NewResourceCache(nil)
+	err := sc.UpdateResources(context.Background(), testCDSRequest([]types.Resource{
+		testCluster("service-a", time.Second),
+		testCluster("service-b", time.Second),
+	}, nil))
+	assert.NoError(t, err)
+
+	node := &corev3.Node{Id: "default/12345~127.0.0.1"}
+	req := &discovery.DeltaDiscoveryRequest{Node: node, TypeUrl: resourcev3.ClusterType}
+	state := stream.NewStreamState(true, nil)
+	ch := make(chan cachev3.DeltaResponse, 1)
+
+	// 首次 wildcard 请求, 返回全量资源
+	sc.CreateDeltaWatch(req, state, ch)
+	resp := receiveDelta(t, ch)
+	assert.Equal(t, 2, len(resp.Resources))
+	assert.Equal(t, 0, len(resp.RemovedResources))
+
+	// 客户端已是最新版本, 需要挂起 watch
+	state.SetResourceVersions(resp.NextVersionMap)
+	cancel := sc.CreateDeltaWatch(req, state, ch)
+	assert.NotNil(t, cancel)
+	assert.Equal(t, 0, len(ch))
+
+	// 资源内容没有变化, 不应该推送, 全局版本也不应该变化
+	oldVersion := sc.namespaceContainer["default"].resourcesContainer[resource.CDS].GlobalVersion
+	err = sc.UpdateResources(context.Background(), testCDSRequest([]types.Resource{
+		testCluster("service-a", time.Second),
+	}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ch))
+	assert.Equal(t, oldVersion, sc.namespaceContainer["default"].resourcesContainer[resource.CDS].GlobalVersion)
+
+	// 只下发发生变化以及被删除的资源
+	err = sc.UpdateResources(context.Background(), testCDSRequest([]types.Resource{
+		testCluster("service-b", 2*time.Second),
+	}, []types.Resource{
+		testCluster("service-a", time.Second),
+	}))
+	assert.NoError(t, err)
+	resp = receiveDelta(t, ch)
+	assert.Equal(t, []string{"service-b"}, cachev3.GetResourceNames(resp.Resources))
+	assert.Equal(t, []string{"service-a"}, resp.RemovedResources)
+	assert.Equal(t, 1, len(resp.NextVersionMap))
+	assert.NotEqual(t, state.GetResourceVersions()["service-b"], resp.NextVersionMap["service-b"])
+	assert.NotEqual(t, oldVersion, sc.namespaceContainer["default"].resourcesContainer[resource.CDS].GlobalVersion)
+}
+
+func Test_ResourceCache_LDSReplace(t *testing.T) {
+	sc := NewResourceCache(nil)
+	nodeID := "default/12345~127.0.0.1"
+	update := func(names ...string) {
+		res := make([]types.Resource, 0, len(names))
+		for _, name := range names {
+			res = append(res, &listener.Listener{Name: name})
+		}
+		err := sc.UpdateResources(context.Background(), &UpdateResourcesRequest{
+			Lds: map[string]map[string]types.Resource{
+				nodeID: cachev3.IndexRawResourcesByName(res),
+			},
+		})
+		assert.NoError(t, err)
+	}
+
+	update("listener-a", "listener-b")
+	version := sc.ldsResources[nodeID].GlobalVersion
+	update("listener-a", "listener-b")
+	assert.Equal(t, version, sc.ldsResources[nodeID].GlobalVersion)
+
+	// LDS 为 Node 级别全量资源, 不存在的资源需要被移除
+	update("listener-a")
+	assert.NotEqual(t, version, sc.ldsResources[nodeID].GlobalVersion)
+	assert.Equal(t, 1, len(sc.ldsResources[nodeID].Resources))
+	assert.Equal(t, 1, len(sc.ldsResources[nodeID].VersionMap))
+}
@@ -39,6 +39,8 @@ import (
 // CDSBuilder .
 type CDSBuilder struct {
 	svr service.DiscoverServer
+	// rateLimitCluster 全局限流服务的 cluster, 未开启全局限流服务时为 nil
+	rateLimitCluster *cluster.Cluster
 }
 
 func (cds *CDSBuilder) Init(svr service.DiscoverServer) {
@@ -54,6 +56,10 @@ func (cds *CDSBuilder) Generate(option *resource.BuildOption) (interface{}, erro
 
 	// 默认 passthrough cluster
 	clusters = append(clusters, resource.PassthroughCluster)
+	// 开启全局限流服务时, HCM 中的 ratelimit filter 通过该 cluster 访问北极星
+	if cds.rateLimitCluster != nil {
+		clusters = append(clusters, cds.rateLimitCluster)
+	}
 
 	switch option.TrafficDirection {
 	case core.TrafficDirection_INBOUND:
@@ -144,6 +150,7 @@ func (cds *CDSBuilder) makeCluster(svcInfo *resource.ServiceInfo,
 		c.LbSubsetConfig = resource.MakeLbSubsetConfig(svcInfo)
 		c.OutlierDetection = resource.MakeOutlierDetection(svcInfo)
 		c.HealthChecks = resource.MakeHealthCheck(svcInfo)
+		c.CircuitBreakers = resource.MakeCircuitBreakers(svcInfo)
 	}
 	return c
 }
@@ -20,11 +20,17 @@ package xdsserverv3
 import (
 	"net/http"
 	"strings"
+	"time"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	tlstrans "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 )
 
@@ -62,6 +68,9 @@ func (x *XDSServer) listXDSResource(resp http.ResponseWriter, req *http.Request)
 		}
 		res = copyData
 	}
+	if resource.FromSimpleXDS(cType) == resource.SDS {
+		res = redactSecrets(res)
+	}
 
 	data := map[string]interface{}{
 		"code": apimodel.Code_ExecuteSuccess,
@@ -73,3 +82,122 @@ func (x *XDSServer) listXDSResource(resp http.ResponseWriter, req *http.Request)
 	resp.WriteHeader(http.StatusOK)
 	_, _ = resp.Write([]byte(ret))
 }
+
+func (x *XDSServer) listCACerts(resp http.ResponseWriter, req *http.Request) {
+	if x.ca == nil {
+		ret := utils.MustJson(map[string]interface{}{
+			"code": apimodel.Code_ExecuteSuccess,
+			"info": "built-in ca not enabled",
+		})
+		resp.WriteHeader(http.StatusOK)
+		_, _ = resp.Write([]byte(ret))
+		return
+	}
+
+	workloads := make([]map[string]interface{}, 0, 8)
+	for _, cert := range x.ca.ListWorkloadCerts() {
+		workloads = append(workloads, map[string]interface{}{
+			"spiffe_id":  cert.SpiffeID,
+			"not_before": cert.NotBefore.Format(time.RFC3339),
+			"not_after":  cert.NotAfter.Format(time.RFC3339),
+		})
+	}
+	root := x.ca.RootCert()
+	caInfo := map[string]interface{}{
+		"trust_domain": x.ca.TrustDomain(),
+		"root": map[string]interface{}{
+			"subject":   root.Subject.String(),
+			"not_after": root.NotAfter.Format(time.RFC3339),
+		},
+		"workloads": workloads,
+	}
+	if prev := x.ca.PrevRootCert(); prev != nil {
+		caInfo["prev_root"] = map[string]interface{}{
+			"subject":   prev.Subject.String(),
+			"not_after": prev.NotAfter.Format(time.RFC3339),
+		}
+	}
+	data := map[string]interface{}{
+		"code": apimodel.Code_ExecuteSuccess,
+		"info": "execute success",
+		"data": caInfo,
+	}
+
+	ret := utils.MustJson(data)
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write([]byte(ret))
+}
+
+// getServiceXDSConfig 查询某个服务在出流量场景下生成的 Envoy 配置, 包括 Cluster、ClusterLoadAssignment 以及 VirtualHost,
+// 用于排查熔断、主动探测规则转换后的实际效果
+func (x *XDSServer) getServiceXDSConfig(resp http.ResponseWriter, req *http.Request) {
+	service := req.URL.Query().Get("service")
+	namespace := req.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	if service == "" {
+		ret := utils.MustJson(map[string]interface{}{
+			"code": apimodel.Code_InvalidServiceName,
+			"info": "service is required",
+		})
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(ret))
+		return
+	}
+
+	svcKey := model.ServiceKey{Namespace: namespace, Name: service}
+	name := resource.MakeServiceName(svcKey, corev3.TrafficDirection_OUTBOUND, &resource.BuildOption{})
+	config := map[string]interface{}{
+		"cluster":                 x.cache.GetResources(resource.CDS, namespace, "")[name],
+		"cluster_load_assignment": x.cache.GetResources(resource.EDS, namespace, "")[name],
+	}
+	vhostNames := map[string]struct{}{
+		name: {},
+		resource.MakeVHDSServiceName(resource.OutBoundRouteConfigName+"/", svcKey): {},
+	}
+	vhosts := make([]*route.VirtualHost, 0, 2)
+	for _, res := range []resource.XDSType{resource.RDS, resource.VHDS} {
+		for _, item := range x.cache.GetResources(res, namespace, "") {
+			switch v := item.(type) {
+			case *route.RouteConfiguration:
+				for _, vhost := range v.GetVirtualHosts() {
+					if _, ok := vhostNames[vhost.GetName()]; ok {
+						vhosts = append(vhosts, vhost)
+					}
+				}
+			case *route.VirtualHost:
+				if _, ok := vhostNames[v.GetName()]; ok {
+					vhosts = append(vhosts, v)
+				}
+			}
+		}
+	}
+	config["virtual_hosts"] = vhosts
+
+	data := map[string]interface{}{
+		"code": apimodel.Code_ExecuteSuccess,
+		"info": "execute success",
+		"data": config,
+	}
+
+	ret := utils.MustJson(data)
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write([]byte(ret))
+}
+
+// redactSecrets 调试接口不能输出工作负载证书的私钥
+func redactSecrets(res map[string]types.Resource) map[string]types.Resource {
+	ret := make(map[string]types.Resource, len(res))
+	for k, v := range res {
+		secret, ok := v.(*tlstrans.Secret)
+		if !ok || secret.GetTlsCertificate() == nil {
+			ret[k] = v
+			continue
+		}
+		secret = proto.Clone(secret).(*tlstrans.Secret)
+		secret.GetTlsCertificate().PrivateKey = nil
+		ret[k] = secret
+	}
+	return ret
+}
@@ -107,15 +107,14 @@ func (eds *EDSBuilder) buildServiceEndpoint(serviceInfo *resource.ServiceInfo) [
 							},
 						},
 					},
-					HealthCheckConfig: &endpoint.Endpoint_HealthCheckConfig{
-						DisableActiveHealthCheck: true,
-					},
+					HealthCheckConfig: resource.MakeEndpointHealthCheckConfig(serviceInfo),
 				},
 			},
 			HealthStatus:        resource.FormatEndpointHealth(instance),
 			LoadBalancingWeight: utils.NewUInt32Value(instance.GetWeight().GetValue()),
 			Metadata:            resource.GenEndpointMetaFromPolarisIns(instance),
 		}
+		resource.FillBaselineLaneMeta(ep.Metadata, instance, serviceInfo)
 		locality[region][zone][campus] = append(locality[region][zone][campus], ep)
 	}
 
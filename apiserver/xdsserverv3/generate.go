@@ -24,12 +24,14 @@ import (
 	"sync"
 	"time"
 
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/ca"
 	"github.com/polarismesh/polaris/apiserver/xdsserverv3/cache"
 	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
 	"github.com/polarismesh/polaris/common/metrics"
@@ -54,9 +56,15 @@ type XdsResourceGenerator struct {
 	versionNum      *atomic.Uint64
 	xdsNodesMgr     *resource.XDSNodeManager
 	svcInfoProvider CurrentServiceInfoProvider
+	// ca 内置 CA, 未开启时为 nil
+	ca *ca.CertificateAuthority
+	// verifyCredential 校验 Envoy 获取工作负载证书时携带的访问凭据
+	verifyCredential WorkloadCredentialVerifier
+	// rateLimitCluster 全局限流服务的 cluster, 未开启全局限流服务时为 nil
+	rateLimitCluster *cluster.Cluster
 }
 
-// Generate 构建 XDS 资源缓存数据信息
+// Generate 构建 XDS 资源缓存数据信息, 仅针对 needUpdate/needRemove 中发生变化的服务增量构建
 func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRemove ServiceInfos) {
 	updateRequest := cache.NewUpdateResourcesRequest()
 
@@ -79,10 +87,10 @@ func (x *XdsResourceGenerator) Generate(versionLocal string, needUpdate, needRem
 			// 恢复 TLSMode
 			opt.TLSMode = resource.TLSModeNone
 			x.buildUpdateRequest(updateRequest, resource.EDS, opt, isRemove)
-			x.buildUpdateRequest(updateRequest, resource.RDS, opt, isRemove)
+			x.buildRDSUpdateRequest(updateRequest, opt, isRemove)
 			// 开启按需 Demand
 			opt.OpenEnvoyDemand()
-			x.buildUpdateRequest(updateRequest, resource.RDS, opt, isRemove)
+			x.buildRDSUpdateRequest(updateRequest, opt, isRemove)
 		}
 
 		// CDS/EDS/VHDS 一起构建
@@ -165,11 +173,92 @@ func (x *XdsResourceGenerator) buildOneEnvoyXDSCache(node *resource.XDSClient) e
 	// 构建 INBOUND LDS 资源
 	buildCache(resource.LDS, opt)
 
-	return x.cache.UpdateResources(context.Background(), &cache.UpdateResourcesRequest{
+	req := &cache.UpdateResourcesRequest{
 		Lds: map[string]map[string]types.Resource{
 			opt.Client.ID: cachev3.IndexRawResourcesByName(finalResources),
 		},
-	})
+	}
+	if sds, ok := x.buildEnvoyNodeSDS(opt); ok {
+		req.Sds = map[string]map[string]types.Resource{
+			opt.Client.ID: sds,
+		}
+	}
+	return x.cache.UpdateResources(context.Background(), req)
+}
+
+// buildEnvoyNodeSDS 构建 Envoy Node 的证书资源, 只有开启内置 CA 时才会构建
+func (x *XdsResourceGenerator) buildEnvoyNodeSDS(opt *resource.BuildOption) (map[string]types.Resource, bool) {
+	if x.ca == nil {
+		return nil, false
+	}
+	xxds, err := x.generateXDSResource(resource.SDS, opt)
+	if err != nil {
+		log.Error("[XDS][Envoy] generate envoy node sds resource fail", zap.String("node", opt.Client.ID),
+			zap.Error(err))
+		return nil, false
+	}
+	return cachev3.IndexRawResourcesByName(xxds), true
+}
+
+// rotateEnvoyNodeCerts 轮转根证书以及即将过期的工作负载证书, 并且重新构建在线 Envoy Node 的证书资源
+func (x *XdsResourceGenerator) rotateEnvoyNodeCerts() {
+	now := time.Now()
+	if changed, err := x.ca.RotateRoot(now); err != nil {
+		log.Error("[XDS][Envoy] rotate root cert fail", zap.Error(err))
+	} else if changed {
+		log.Info("[XDS][Envoy] root cert rotated", zap.String("trust-domain", x.ca.TrustDomain()))
+	}
+	x.ca.EvictExpiring(now)
+
+	req := cache.NewUpdateResourcesRequest()
+	for _, node := range x.xdsNodesMgr.ListEnvoyNodes() {
+		// 只处理仍然在线并且开启了 TLS 的节点
+		if x.xdsNodesMgr.GetNode(node.ID) == nil || !resource.EnableTLS(node.TLSMode) {
+			continue
+		}
+		opt := &resource.BuildOption{
+			RunType:   node.RunType,
+			Client:    node,
+			TLSMode:   node.TLSMode,
+			Namespace: node.GetSelfNamespace(),
+			SelfService: model.ServiceKey{
+				Namespace: node.GetSelfNamespace(),
+				Name:      node.GetSelfService(),
+			},
+		}
+		if sds, ok := x.buildEnvoyNodeSDS(opt); ok {
+			req.Sds[node.ID] = sds
+		}
+	}
+	if len(req.Sds) == 0 {
+		return
+	}
+	if err := x.cache.UpdateResources(context.Background(), req); err != nil {
+		log.Error("[XDS][Envoy] update envoy node sds resource fail", zap.Error(err))
+	}
+}
+
+// buildRDSUpdateRequest 构建 RDS 资源. Sidecar OUTBOUND 的 RouteConfiguration 是命名空间级别的聚合资源,
+// 只能基于命名空间下当前全量的服务重新构建, 否则仅携带变更服务会导致其他服务的 VirtualHost 丢失
+func (x *XdsResourceGenerator) buildRDSUpdateRequest(req *cache.UpdateResourcesRequest,
+	opt *resource.BuildOption, isRemove bool) {
+
+	if opt.RunType != resource.RunTypeSidecar || opt.TrafficDirection != corev3.TrafficDirection_OUTBOUND {
+		x.buildUpdateRequest(req, resource.RDS, opt, isRemove)
+		return
+	}
+
+	aggregateOpt := *opt
+	var services map[model.ServiceKey]*resource.ServiceInfo
+	if x.svcInfoProvider != nil {
+		services = x.svcInfoProvider()[opt.Namespace]
+	}
+	// 命名空间下仍然存在服务时, 删除服务只需要重新构建聚合资源即可
+	if len(services) > 0 {
+		aggregateOpt.Services = services
+		isRemove = false
+	}
+	x.buildUpdateRequest(req, resource.RDS, &aggregateOpt, isRemove)
 }
 
 func (x *XdsResourceGenerator) buildUpdateRequest(req *cache.UpdateResourcesRequest, xdsType resource.XDSType,
@@ -181,6 +270,9 @@ func (x *XdsResourceGenerator) buildUpdateRequest(req *cache.UpdateResourcesRequ
 		log.Error("[XDS][Envoy] generate xds resource fail", zap.Error(err))
 		return
 	}
+	if opt.ForceDelete {
+		xxds = filterSharedResources(xxds)
+	}
 
 	switch opt.TLSMode {
 	case resource.TLSModeNone:
@@ -198,6 +290,19 @@ func (x *XdsResourceGenerator) buildUpdateRequest(req *cache.UpdateResourcesRequ
 	}
 }
 
+// filterSharedResources 过滤掉不属于某个服务的公共资源, 比如 PassthroughCluster, 删除服务时不能清理这部份资源
+func filterSharedResources(xxds []types.Resource) []types.Resource {
+	ret := make([]types.Resource, 0, len(xxds))
+	for i := range xxds {
+		switch cachev3.GetResourceName(xxds[i]) {
+		case resource.PassthroughClusterName, resource.RateLimitClusterName:
+			continue
+		}
+		ret = append(ret, xxds[i])
+	}
+	return ret
+}
+
 func (x *XdsResourceGenerator) generateXDSResource(xdsType resource.XDSType,
 	opt *resource.BuildOption) ([]types.Resource, error) {
 
@@ -222,7 +327,7 @@ func (x *XdsResourceGenerator) generateXDSResource(xdsType resource.XDSType,
 	)
 	switch xdsType {
 	case resource.CDS:
-		xdsBuilder = &CDSBuilder{}
+		xdsBuilder = &CDSBuilder{rateLimitCluster: x.rateLimitCluster}
 	case resource.EDS:
 		xdsBuilder = &EDSBuilder{}
 	case resource.LDS:
@@ -231,6 +336,8 @@ func (x *XdsResourceGenerator) generateXDSResource(xdsType resource.XDSType,
 		xdsBuilder = &RDSBuilder{}
 	case resource.VHDS:
 		xdsBuilder = &VHDSBuilder{}
+	case resource.SDS:
+		xdsBuilder = &SDSBuilder{ca: x.ca, verify: x.verifyCredential}
 	default:
 		return nil, ErrorNoSupportXDSType
 	}
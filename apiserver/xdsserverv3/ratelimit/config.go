@@ -0,0 +1,119 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/polarismesh/polaris/common/redispool"
+)
+
+const (
+	// DefaultClusterNamespace 默认北极星节点所在的命名空间
+	DefaultClusterNamespace = "Polaris"
+	// DefaultClusterService 默认北极星节点所在的服务
+	DefaultClusterService = "polaris.checker"
+	// DefaultRefreshInterval 默认重新计算本节点配额比例的周期
+	DefaultRefreshInterval = 5 * time.Second
+)
+
+// Config Envoy 全局限流服务的配置
+type Config struct {
+	// Enable 是否开启全局限流服务, 开启后 Envoy 通过 RLS 协议向北极星查询分布式限流规则的配额
+	Enable bool `mapstructure:"enable"`
+	// Address Envoy 访问限流服务的地址, 格式为 host:port, 默认为本节点 xDS 服务的地址.
+	// 所有节点共享同一份计数, 因此建议配置为北极星集群的负载均衡地址, 避免 Envoy 集中访问某一个节点
+	Address string `mapstructure:"address"`
+	// Redis 全局配额计数器使用的 redis, 所有北极星节点共享同一份计数, 开启全局限流服务时必须配置.
+	// redis 不可用时, 全局配额按照节点的权重分摊到各个节点上, 各节点独立计数, 并且在 5 秒内不再访问 redis;
+	// 还没有获取到节点列表时无法确定本节点的配额, 拒绝请求
+	Redis map[string]interface{} `mapstructure:"redis"`
+	// ClusterNamespace 北极星节点所在的命名空间, 用于 redis 不可用时按照节点的权重分摊全局配额
+	ClusterNamespace string `mapstructure:"clusterNamespace"`
+	// ClusterService 北极星节点所在的服务
+	ClusterService string `mapstructure:"clusterService"`
+	// RefreshInterval 重新计算本节点配额比例的周期
+	RefreshInterval time.Duration `mapstructure:"refreshInterval"`
+
+	redisConfig *redispool.Config
+}
+
+// ParseConfig 解析全局限流服务配置
+func ParseConfig(raw map[interface{}]interface{}) (*Config, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	config := &Config{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	config.setDefault()
+	if err := config.Verify(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) setDefault() {
+	if c.ClusterNamespace == "" {
+		c.ClusterNamespace = DefaultClusterNamespace
+	}
+	if c.ClusterService == "" {
+		c.ClusterService = DefaultClusterService
+	}
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = DefaultRefreshInterval
+	}
+}
+
+// Verify 校验配置
+func (c *Config) Verify() error {
+	if c.RefreshInterval < 0 {
+		return errors.New("ratelimit refreshInterval must be positive")
+	}
+	if c.Enable && len(c.Redis) == 0 {
+		return errors.New("ratelimit requires redis to share the global quota")
+	}
+	if len(c.Redis) != 0 {
+		data, err := json.Marshal(c.Redis)
+		if err != nil {
+			return err
+		}
+		redisConfig := &redispool.Config{}
+		if err := json.Unmarshal(data, redisConfig); err != nil {
+			return err
+		}
+		if err := redisConfig.Validate(); err != nil {
+			return err
+		}
+		c.redisConfig = redisConfig
+	}
+	return nil
+}
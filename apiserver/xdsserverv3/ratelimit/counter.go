@@ -0,0 +1,51 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/polarismesh/polaris/common/redispool"
+)
+
+// QuotaCounter 全局配额计数器, 所有北极星节点共享同一份计数, 使得分布式限流规则的配额不受请求落到哪个节点的影响
+type QuotaCounter interface {
+	// Incr 在 key 当前的统计窗口内增加 hits 个计数, 统计窗口按照计数器自身的时钟划分, 避免各个节点的时钟误差
+	// 导致计数被拆分到不同的窗口. 返回增加后窗口内的总计数以及距离窗口重置的时间
+	Incr(ctx context.Context, key string, hits uint32, duration time.Duration) (uint64, time.Duration, error)
+}
+
+// redisQuotaCounter 基于 redis 的固定窗口计数器, redis 访问失败后在退避时间内直接返回错误, 不再访问 redis
+type redisQuotaCounter struct {
+	quota *redispool.QuotaClient
+}
+
+func newRedisQuotaCounter(client redis.UniversalClient) QuotaCounter {
+	return &redisQuotaCounter{
+		quota: redispool.NewQuotaClient(client, redispool.DefaultQuotaTimeout, redispool.DefaultQuotaBackoff),
+	}
+}
+
+// Incr 在同一个脚本中切换窗口、增加计数并设置过期时间, 避免计数永不过期
+func (c *redisQuotaCounter) Incr(ctx context.Context, key string, hits uint32,
+	duration time.Duration) (uint64, time.Duration, error) {
+	return c.quota.IncrWindow(ctx, key, hits, duration)
+}
@@ -0,0 +1,90 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// quotaWindow 固定时间窗口的配额计数器, 窗口长度为限流规则的统计周期
+type quotaWindow struct {
+	mu sync.Mutex
+	// maxAmount 整个北极星集群在一个周期内的总配额
+	maxAmount uint32
+	// duration 统计周期
+	duration time.Duration
+	// start 当前窗口的开始时间
+	start time.Time
+	// used 当前窗口已经使用的配额
+	used uint32
+	// lastAccess 最后一次访问时间, 用于清理过期的计数器
+	lastAccess time.Time
+}
+
+func newQuotaWindow(maxAmount uint32, duration time.Duration) *quotaWindow {
+	return &quotaWindow{
+		maxAmount: maxAmount,
+		duration:  duration,
+	}
+}
+
+// limit 根据本节点的配额比例计算本节点在一个周期内的配额, 至少保留一个配额. 比例为 0 表示本节点的配额未知, 不分配配额
+func (w *quotaWindow) limit(scale float64) uint32 {
+	if scale == 0 {
+		return 0
+	}
+	limit := uint32(math.Ceil(float64(w.maxAmount) * scale))
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// acquire 申请 hits 个配额, 返回是否申请成功、当前窗口剩余的配额以及距离窗口重置的时间
+func (w *quotaWindow) acquire(now time.Time, hits uint32, scale float64) (bool, uint32, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastAccess = now
+	start := now.Truncate(w.duration)
+	if !start.Equal(w.start) {
+		w.start = start
+		w.used = 0
+	}
+	limit := w.limit(scale)
+	reset := w.start.Add(w.duration).Sub(now)
+	if uint64(w.used)+uint64(hits) > uint64(limit) {
+		// 配额比例变小时, 已经使用的配额可能超过新的配额
+		var remaining uint32
+		if w.used < limit {
+			remaining = limit - w.used
+		}
+		return false, remaining, reset
+	}
+	w.used += hits
+	return true, limit - w.used, reset
+}
+
+// expired 计数器超过两个周期没有被访问时认为已经过期
+func (w *quotaWindow) expired(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return now.Sub(w.lastAccess) > 2*w.duration
+}
@@ -0,0 +1,383 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	regexp "github.com/dlclark/regexp2"
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/redispool"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// pathDescriptorKey 接口路径对应的描述符 key
+	pathDescriptorKey = ":path"
+	// callerServiceDescriptorKey 主调服务对应的描述符 key
+	callerServiceDescriptorKey = "source_cluster"
+	// callerIPDescriptorKey 主调 IP 对应的描述符 key
+	callerIPDescriptorKey = "remote_address"
+	// sharedQuotaKeyPrefix 全局配额计数器的 key 前缀
+	sharedQuotaKeyPrefix = "polaris_rls|"
+)
+
+// RuleProvider 获取服务的限流规则
+type RuleProvider func(svcKey model.ServiceKey) []*model.RateLimit
+
+// NodesProvider 获取北极星集群的节点列表
+type NodesProvider func(namespace, service string) ([]*model.Instance, error)
+
+// Authorizer 校验 RLS 请求的调用方是否可以使用该服务的全局配额
+type Authorizer func(ctx context.Context, svcKey model.ServiceKey) error
+
+// Server 实现 Envoy 的 envoy.service.ratelimit.v3.RateLimitService, 根据北极星的分布式限流规则计算配额.
+// 描述符与规则的对应关系和 RDS 下发的 rate_limits 保持一致. 所有节点通过全局配额计数器共享同一份计数,
+// 计数器不可用时, 全局配额按照北极星节点的权重分摊到各个节点上, 由各个节点独立计数
+type Server struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+
+	conf  *Config
+	rules RuleProvider
+	nodes NodesProvider
+	// authorize 校验调用方的身份以及权限, 为 nil 时不做校验
+	authorize Authorizer
+	// counter 全局配额计数器
+	counter QuotaCounter
+	// scale 本节点的配额比例, 为 0 表示还没有获取到北极星集群的节点列表
+	scale *atomic.Float64
+	// windows 规则配额计数器, key 为 ruleID|revision|amount 下标
+	windows sync.Map
+	// regexes 已经编译的正则表达式
+	regexes sync.Map
+}
+
+// NewServer 创建全局限流服务
+func NewServer(conf *Config, rules RuleProvider, nodes NodesProvider, authorize Authorizer) *Server {
+	s := &Server{
+		conf:      conf,
+		rules:     rules,
+		nodes:     nodes,
+		authorize: authorize,
+		scale:     atomic.NewFloat64(1),
+	}
+	if nodes != nil {
+		// 获取到节点列表之前不知道本节点的配额, 计数器不可用时拒绝请求而不是使用全部的配额
+		s.scale.Store(0)
+	}
+	if conf.redisConfig != nil {
+		s.counter = newRedisQuotaCounter(redispool.NewRedisClient(conf.redisConfig))
+	}
+	return s
+}
+
+// Run 定时重新计算本节点的配额比例, 并清理过期的配额计数器
+func (s *Server) Run(ctx context.Context) {
+	s.refresh(time.Now())
+	ticker := time.NewTicker(s.conf.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.refresh(now)
+		}
+	}
+}
+
+func (s *Server) refresh(now time.Time) {
+	scale := s.computeScale()
+	if old := s.scale.Swap(scale); math.Abs(old-scale) > 1e-6 {
+		log.Infof("[XDSV3][RLS] global ratelimit scale change from %.4f to %.4f", old, scale)
+	}
+	s.windows.Range(func(key, value interface{}) bool {
+		if value.(*quotaWindow).expired(now) {
+			s.windows.Delete(key)
+		}
+		return true
+	})
+}
+
+// computeScale 计算计数器不可用时本节点的配额比例. 无法获取节点列表时沿用上一次的比例, 从未获取成功时比例为 0,
+// 本节点不在列表中时
+// 按照平均权重估算本节点的比例, 避免每个节点都使用全部的配额
+func (s *Server) computeScale() float64 {
+	current := s.scale.Load()
+	if s.nodes == nil {
+		return current
+	}
+	nodes, err := s.nodes(s.conf.ClusterNamespace, s.conf.ClusterService)
+	if err != nil {
+		log.Error("[XDSV3][RLS] fetch polaris cluster nodes fail, keep fallback quota scale",
+			zap.Float64("scale", current), zap.Error(err))
+		return current
+	}
+	scale, ok := model.HostWeightRatio(nodes, utils.LocalHost)
+	if scale == 0 {
+		log.Error("[XDSV3][RLS] no available polaris cluster nodes, keep fallback quota scale",
+			zap.Float64("scale", current))
+		return current
+	}
+	if !ok {
+		log.Error("[XDSV3][RLS] local host not in polaris cluster nodes, estimate fallback quota scale",
+			zap.String("host", utils.LocalHost), zap.Float64("scale", scale))
+	}
+	return scale
+}
+
+// ShouldRateLimit 对请求中的每个描述符找到对应的分布式限流规则并申请配额, 任意一个描述符超过配额则整个请求被限流
+func (s *Server) ShouldRateLimit(ctx context.Context,
+	req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	resp := &rlsv3.RateLimitResponse{
+		OverallCode: rlsv3.RateLimitResponse_OK,
+		Statuses:    make([]*rlsv3.RateLimitResponse_DescriptorStatus, 0, len(req.GetDescriptors())),
+	}
+	svcKey, ok := parseDomain(req.GetDomain())
+	if !ok {
+		for range req.GetDescriptors() {
+			resp.Statuses = append(resp.Statuses, &rlsv3.RateLimitResponse_DescriptorStatus{
+				Code: rlsv3.RateLimitResponse_OK,
+			})
+		}
+		return resp, nil
+	}
+	// 调用方只能消耗自己有权限的服务的配额
+	if s.authorize != nil {
+		if err := s.authorize(ctx, svcKey); err != nil {
+			log.Warn("[XDSV3][RLS] ratelimit request is not allowed", zap.String("domain", req.GetDomain()),
+				zap.Error(err))
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	hits := req.GetHitsAddend()
+	if hits == 0 {
+		hits = 1
+	}
+	rules := s.globalRules(svcKey)
+	now := time.Now()
+	for _, descriptor := range req.GetDescriptors() {
+		status := s.acquire(ctx, rules, descriptor, hits, now)
+		if status.Code == rlsv3.RateLimitResponse_OVER_LIMIT {
+			resp.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		}
+		resp.Statuses = append(resp.Statuses, status)
+	}
+	return resp, nil
+}
+
+// globalRules 过滤出服务开启的分布式 QPS 限流规则
+func (s *Server) globalRules(svcKey model.ServiceKey) []*model.RateLimit {
+	var ret []*model.RateLimit
+	for _, rule := range s.rules(svcKey) {
+		if rule == nil || rule.Proto == nil || rule.Proto.GetDisable().GetValue() {
+			continue
+		}
+		if rule.Proto.GetType() != apitraffic.Rule_GLOBAL || rule.Proto.GetResource() != apitraffic.Rule_QPS {
+			continue
+		}
+		ret = append(ret, rule)
+	}
+	return ret
+}
+
+// acquire 在所有匹配描述符的规则中申请配额, 返回剩余配额最少的规则状态
+func (s *Server) acquire(ctx context.Context, rules []*model.RateLimit, descriptor *ratelimitv3.RateLimitDescriptor,
+	hits uint32, now time.Time) *rlsv3.RateLimitResponse_DescriptorStatus {
+	entries := make(map[string][]string, len(descriptor.GetEntries()))
+	for _, entry := range descriptor.GetEntries() {
+		entries[entry.GetKey()] = append(entries[entry.GetKey()], entry.GetValue())
+	}
+
+	var status *rlsv3.RateLimitResponse_DescriptorStatus
+	for _, rule := range rules {
+		if !s.matchRule(rule.Proto, entries) {
+			continue
+		}
+		for i, amount := range rule.Proto.GetAmounts() {
+			duration := amount.GetValidDuration().AsDuration()
+			if amount.GetMaxAmount().GetValue() == 0 || duration <= 0 {
+				continue
+			}
+			key := rule.ID + "|" + rule.Revision + "|" + strconv.Itoa(i)
+			pass, limit, remaining, reset := s.acquireQuota(ctx, key, amount.GetMaxAmount().GetValue(),
+				duration, hits, now)
+			current := &rlsv3.RateLimitResponse_DescriptorStatus{
+				Code:               rlsv3.RateLimitResponse_OK,
+				CurrentLimit:       makeCurrentLimit(rule.Name, limit, duration),
+				LimitRemaining:     remaining,
+				DurationUntilReset: durationpb.New(reset),
+			}
+			if !pass {
+				current.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+			}
+			if status == nil || current.Code > status.Code ||
+				(current.Code == status.Code && current.LimitRemaining < status.LimitRemaining) {
+				status = current
+			}
+		}
+	}
+	if status == nil {
+		return &rlsv3.RateLimitResponse_DescriptorStatus{Code: rlsv3.RateLimitResponse_OK}
+	}
+	return status
+}
+
+// acquireQuota 申请配额, 返回是否申请成功、配额上限、剩余配额以及距离窗口重置的时间.
+// 使用所有节点共享的全局计数器, 计数器不可用时退化为按照节点权重分摊的本地计数, 访问失败的错误日志由计数器在进入退避时输出一次
+func (s *Server) acquireQuota(ctx context.Context, key string, maxAmount uint32, duration time.Duration,
+	hits uint32, now time.Time) (bool, uint32, uint32, time.Duration) {
+	if s.counter != nil {
+		counterKey := sharedQuotaKeyPrefix + key
+		used, reset, err := s.counter.Incr(ctx, counterKey, hits, duration)
+		if err == nil {
+			if used > uint64(maxAmount) {
+				return false, maxAmount, 0, reset
+			}
+			return true, maxAmount, maxAmount - uint32(used), reset
+		}
+	}
+
+	val, _ := s.windows.LoadOrStore(key, newQuotaWindow(maxAmount, duration))
+	window := val.(*quotaWindow)
+	scale := s.scale.Load()
+	pass, remaining, reset := window.acquire(now, hits, scale)
+	return pass, window.limit(scale), remaining, reset
+}
+
+// matchRule 判断描述符是否由该规则生成, 描述符的 key 以及取值需要和 resource.BuildRateLimitDescriptors 保持一致.
+// 描述符的 key 集合必须和规则完全一致, 避免一个请求同时消耗多条规则的配额
+func (s *Server) matchRule(rule *apitraffic.Rule, entries map[string][]string) bool {
+	keys := map[string]struct{}{pathDescriptorKey: {}}
+	methodName := rule.GetMethod().GetValue().GetValue()
+	if methodName == "" {
+		methodName = "/"
+	}
+	if !containsValue(entries[pathDescriptorKey], methodName) {
+		return false
+	}
+	for _, arg := range rule.GetArguments() {
+		// 仅支持文本类型参数
+		if arg.GetValue().GetValueType() != apimodel.MatchString_TEXT {
+			continue
+		}
+		descriptorKey := strings.ToLower(arg.GetType().String()) + "." + arg.GetKey()
+		descriptorValue := arg.GetValue().GetValue().GetValue()
+		switch arg.GetType() {
+		case apitraffic.MatchArgument_HEADER, apitraffic.MatchArgument_QUERY:
+			// header 以及 query 由 Envoy 完成匹配, 描述符的取值为规则中的取值
+			if !containsValue(entries[descriptorKey], descriptorValue) {
+				return false
+			}
+		case apitraffic.MatchArgument_METHOD:
+			if !s.matchAnyValue(entries[descriptorKey], arg.GetValue()) {
+				return false
+			}
+		case apitraffic.MatchArgument_CALLER_SERVICE:
+			descriptorKey = callerServiceDescriptorKey
+			if !containsValue(entries[descriptorKey], fmt.Sprintf("%s|%s", arg.GetKey(), descriptorValue)) {
+				return false
+			}
+		case apitraffic.MatchArgument_CALLER_IP:
+			descriptorKey = callerIPDescriptorKey
+			if !s.matchAnyValue(entries[descriptorKey], arg.GetValue()) {
+				return false
+			}
+		default:
+			continue
+		}
+		keys[descriptorKey] = struct{}{}
+	}
+	return len(keys) == len(entries)
+}
+
+func (s *Server) matchAnyValue(values []string, matcher *apimodel.MatchString) bool {
+	for _, value := range values {
+		if utils.MatchString(value, matcher, s.compileRegex) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) compileRegex(expr string) *regexp.Regexp {
+	if val, ok := s.regexes.Load(expr); ok {
+		return val.(*regexp.Regexp)
+	}
+	regex, err := regexp.Compile(expr, regexp.RE2)
+	if err != nil {
+		log.Error("[XDSV3][RLS] compile regex failed", zap.String("regex", expr), zap.Error(err))
+		return nil
+	}
+	s.regexes.Store(expr, regex)
+	return regex
+}
+
+func containsValue(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDomain 解析 RLS 请求的 domain, 格式为 {service}.{namespace}, 和 HCM 中 ratelimit filter 的配置保持一致
+func parseDomain(domain string) (model.ServiceKey, bool) {
+	index := strings.LastIndex(domain, ".")
+	if index <= 0 || index == len(domain)-1 {
+		return model.ServiceKey{}, false
+	}
+	return model.ServiceKey{Namespace: domain[index+1:], Name: domain[:index]}, true
+}
+
+// makeCurrentLimit 统计周期刚好为 Envoy 支持的时间单位时才返回当前的限流阈值
+func makeCurrentLimit(name string, limit uint32, duration time.Duration) *rlsv3.RateLimitResponse_RateLimit {
+	units := map[time.Duration]rlsv3.RateLimitResponse_RateLimit_Unit{
+		time.Second:    rlsv3.RateLimitResponse_RateLimit_SECOND,
+		time.Minute:    rlsv3.RateLimitResponse_RateLimit_MINUTE,
+		time.Hour:      rlsv3.RateLimitResponse_RateLimit_HOUR,
+		24 * time.Hour: rlsv3.RateLimitResponse_RateLimit_DAY,
+	}
+	unit, ok := units[duration]
+	if !ok {
+		return nil
+	}
+	return &rlsv3.RateLimitResponse_RateLimit{
+		Name:            name,
+		RequestsPerUnit: limit,
+		Unit:            unit,
+	}
+}
@@ -0,0 +1,319 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func mockRateLimit(id string, ruleType apitraffic.Rule_Type, maxAmount uint32,
+	args ...*apitraffic.MatchArgument) *model.RateLimit {
+	return &model.RateLimit{
+		ID:       id,
+		Name:     id,
+		Revision: "v1",
+		Proto: &apitraffic.Rule{
+			Id:   wrapperspb.String(id),
+			Type: ruleType,
+			Method: &apimodel.MatchString{
+				Type:  apimodel.MatchString_EXACT,
+				Value: wrapperspb.String("/echo"),
+			},
+			Arguments: args,
+			Amounts: []*apitraffic.Amount{
+				{
+					MaxAmount:     wrapperspb.UInt32(maxAmount),
+					ValidDuration: durationpb.New(time.Minute),
+				},
+			},
+		},
+	}
+}
+
+// toDescriptor 使用 RDS 生成的描述符构造 RLS 请求, 保证两边的描述符保持一致
+func toDescriptor(rule *model.RateLimit) *ratelimitv3.RateLimitDescriptor {
+	_, descriptors := resource.BuildRateLimitDescriptors(rule.Proto)
+	return &ratelimitv3.RateLimitDescriptor{Entries: descriptors[0].Entries}
+}
+
+func TestServer_ShouldRateLimit(t *testing.T) {
+	headerArg := &apitraffic.MatchArgument{
+		Type: apitraffic.MatchArgument_HEADER,
+		Key:  "user",
+		Value: &apimodel.MatchString{
+			Type:      apimodel.MatchString_EXACT,
+			Value:     wrapperspb.String("foo"),
+			ValueType: apimodel.MatchString_TEXT,
+		},
+	}
+	pathRule := mockRateLimit("path", apitraffic.Rule_GLOBAL, 2)
+	headerRule := mockRateLimit("header", apitraffic.Rule_GLOBAL, 1, headerArg)
+	localRule := mockRateLimit("local", apitraffic.Rule_LOCAL, 1)
+
+	svr := NewServer(&Config{}, func(svcKey model.ServiceKey) []*model.RateLimit {
+		if svcKey.Namespace != "default" || svcKey.Name != "echo.svc" {
+			return nil
+		}
+		return []*model.RateLimit{pathRule, headerRule, localRule}
+	}, nil, nil)
+
+	shouldRateLimit := func(rule *model.RateLimit) *rlsv3.RateLimitResponse {
+		resp, err := svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+			Domain:      "echo.svc.default",
+			Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(rule)},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, resp.GetStatuses(), 1)
+		return resp
+	}
+
+	// 携带 header 的描述符只消耗 header 规则的配额
+	resp := shouldRateLimit(headerRule)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Equal(t, uint32(0), resp.GetStatuses()[0].GetLimitRemaining())
+	assert.Equal(t, rlsv3.RateLimitResponse_RateLimit_MINUTE, resp.GetStatuses()[0].GetCurrentLimit().GetUnit())
+	resp = shouldRateLimit(headerRule)
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	// 本地限流规则和 path 规则的描述符相同, 但是不由全局限流服务处理
+	resp = shouldRateLimit(pathRule)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Equal(t, uint32(1), resp.GetStatuses()[0].GetLimitRemaining())
+	resp = shouldRateLimit(pathRule)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	resp = shouldRateLimit(pathRule)
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	// 没有匹配规则的描述符直接放通
+	resp, err := svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain: "echo.svc.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			{Entries: []*ratelimitv3.RateLimitDescriptor_Entry{{Key: ":path", Value: "/other"}}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Nil(t, resp.GetStatuses()[0].GetCurrentLimit())
+
+	// 无法解析的 domain 直接放通
+	resp, err = svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "unknown",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(pathRule)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+}
+
+func TestServer_Authorize(t *testing.T) {
+	rule := mockRateLimit("path", apitraffic.Rule_GLOBAL, 1)
+	svr := NewServer(&Config{}, func(svcKey model.ServiceKey) []*model.RateLimit {
+		return []*model.RateLimit{rule}
+	}, nil, func(ctx context.Context, svcKey model.ServiceKey) error {
+		if svcKey.Namespace == "default" && svcKey.Name == "echo" {
+			return nil
+		}
+		return errors.New("no permission")
+	})
+
+	// 没有权限的调用方不能消耗其他服务的配额
+	_, err := svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "other.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(rule)},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "echo.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(rule)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+}
+
+func TestServer_ClusterScale(t *testing.T) {
+	mockNode := func(host string, weight uint32, healthy bool) *model.Instance {
+		return &model.Instance{Proto: &apiservice.Instance{
+			Host:    wrapperspb.String(host),
+			Weight:  wrapperspb.UInt32(weight),
+			Healthy: wrapperspb.Bool(healthy),
+		}}
+	}
+	rule := mockRateLimit("path", apitraffic.Rule_GLOBAL, 10)
+	nodes := []*model.Instance{
+		mockNode(utils.LocalHost, 100, true),
+		mockNode("127.0.0.2", 100, true),
+		mockNode("127.0.0.3", 100, true),
+		mockNode("127.0.0.4", 100, false),
+	}
+	var fetchErr error
+	svr := NewServer(&Config{ClusterNamespace: DefaultClusterNamespace, ClusterService: DefaultClusterService},
+		func(svcKey model.ServiceKey) []*model.RateLimit {
+			return []*model.RateLimit{rule}
+		}, func(namespace, service string) ([]*model.Instance, error) {
+			return nodes, fetchErr
+		}, nil)
+
+	// 还没有获取到节点列表时不知道本节点的配额, 计数器不可用时拒绝请求
+	resp, err := svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "echo.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(rule)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	svr.refresh(time.Now())
+	resp, err = svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+		Domain:      "echo.default",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(rule)},
+	})
+	assert.NoError(t, err)
+	// 三个健康节点平分配额, 本节点的配额向上取整为 4
+	assert.Equal(t, uint32(4), resp.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+	assert.Equal(t, uint32(3), resp.GetStatuses()[0].GetLimitRemaining())
+
+	// 获取节点列表失败时沿用上一次的比例
+	fetchErr = errors.New("mock error")
+	svr.refresh(time.Now())
+	assert.InDelta(t, 1.0/3, svr.scale.Load(), 1e-6)
+
+	// 本节点不在节点列表中时按照平均权重估算, 不会使用全部的配额
+	fetchErr = nil
+	nodes = nodes[1:]
+	svr.refresh(time.Now())
+	assert.InDelta(t, 1.0/3, svr.scale.Load(), 1e-6)
+	nodes = nodes[1:]
+	svr.refresh(time.Now())
+	assert.InDelta(t, 0.5, svr.scale.Load(), 1e-6)
+}
+
+// memoryQuotaCounter 模拟所有节点共享的全局配额计数器
+type memoryQuotaCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+	err    error
+}
+
+func (c *memoryQuotaCounter) Incr(_ context.Context, key string, hits uint32,
+	duration time.Duration) (uint64, time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, 0, c.err
+	}
+	c.counts[key] += uint64(hits)
+	return c.counts[key], duration, nil
+}
+
+func TestServer_SharedQuota(t *testing.T) {
+	rule := mockRateLimit("path", apitraffic.Rule_GLOBAL, 3)
+	counter := &memoryQuotaCounter{counts: map[string]uint64{}}
+	// 两个北极星节点共享同一个计数器
+	nodes := make([]*Server, 0, 2)
+	for i := 0; i < 2; i++ {
+		svr := NewServer(&Config{}, func(svcKey model.ServiceKey) []*model.RateLimit {
+			return []*model.RateLimit{rule}
+		}, nil, nil)
+		svr.counter = counter
+		nodes = append(nodes, svr)
+	}
+
+	shouldRateLimit := func(svr *Server) *rlsv3.RateLimitResponse {
+		resp, err := svr.ShouldRateLimit(context.Background(), &rlsv3.RateLimitRequest{
+			Domain:      "echo.default",
+			Descriptors: []*ratelimitv3.RateLimitDescriptor{toDescriptor(rule)},
+		})
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := shouldRateLimit(nodes[0])
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Equal(t, uint32(3), resp.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+	assert.Equal(t, uint32(2), resp.GetStatuses()[0].GetLimitRemaining())
+	resp = shouldRateLimit(nodes[1])
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Equal(t, uint32(1), resp.GetStatuses()[0].GetLimitRemaining())
+	resp = shouldRateLimit(nodes[0])
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	// 请求落到哪个节点都消耗同一份配额
+	resp = shouldRateLimit(nodes[1])
+	assert.Equal(t, rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	// 计数器不可用时退化为本节点独立计数
+	counter.err = errors.New("redis unavailable")
+	resp = shouldRateLimit(nodes[1])
+	assert.Equal(t, rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	assert.Equal(t, uint32(2), resp.GetStatuses()[0].GetLimitRemaining())
+}
+
+func TestParseConfig_Redis(t *testing.T) {
+	conf, err := ParseConfig(map[interface{}]interface{}{
+		"enable": true,
+		"redis": map[interface{}]interface{}{
+			"kvAddr": "127.0.0.1:6379",
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, conf.redisConfig)
+	assert.Equal(t, "127.0.0.1:6379", conf.redisConfig.KvAddr)
+
+	// 开启全局限流服务时必须配置共享计数器
+	_, err = ParseConfig(map[interface{}]interface{}{
+		"enable": true,
+	})
+	assert.Error(t, err)
+}
+
+func TestQuotaWindow_Acquire(t *testing.T) {
+	window := newQuotaWindow(2, time.Second)
+	now := time.Unix(100, 0)
+
+	pass, remaining, reset := window.acquire(now, 1, 1)
+	assert.True(t, pass)
+	assert.Equal(t, uint32(1), remaining)
+	assert.Equal(t, time.Second, reset)
+	pass, _, _ = window.acquire(now.Add(100*time.Millisecond), 2, 1)
+	assert.False(t, pass)
+
+	// 进入下一个窗口后重新计数
+	pass, remaining, _ = window.acquire(now.Add(time.Second), 2, 1)
+	assert.True(t, pass)
+	assert.Equal(t, uint32(0), remaining)
+
+	assert.False(t, window.expired(now.Add(2*time.Second)))
+	assert.True(t, window.expired(now.Add(4*time.Second)))
+}
@@ -173,9 +173,14 @@ func (rds *RDSBuilder) makeSidecarInBoundRoutes(selfService model.ServiceKey,
 		}
 		currentRoute.GetRoute().RateLimits = limits
 	}
-	return []*route.Route{
+	routes := []*route.Route{
 		currentRoute,
 	}
+	// 服务作为泳道入口时, 对命中泳道规则的请求进行染色
+	if svcInfo, ok := opt.Services[selfService]; ok {
+		routes = resource.MakeLaneEntryRoutes(routes, svcInfo)
+	}
+	return routes
 }
 
 // ---------------------- Envoy Gateway ---------------------- //
@@ -0,0 +1,493 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	previoushosts "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// defaultRetryBudgetPercent 重试请求占活跃请求的最大百分比
+	defaultRetryBudgetPercent = 20
+	// defaultMinRetryConcurrency 重试预算的最小并发重试数, 保证请求量很低时仍然可以重试
+	defaultMinRetryConcurrency = 3
+	// defaultRouteRetries 方法级熔断规则生成的路由重试次数
+	defaultRouteRetries = 1
+	// defaultHealthCheckInterval 主动探测规则没有设置探测间隔时的默认值
+	defaultHealthCheckInterval = 10 * time.Second
+	// defaultHealthCheckTimeout 主动探测规则没有设置超时时间时的默认值
+	defaultHealthCheckTimeout = time.Second
+	// defaultUnhealthyThreshold 主动探测连续失败多少次后认为实例不健康
+	defaultUnhealthyThreshold = 3
+	// defaultHealthyThreshold 主动探测连续成功多少次后认为实例恢复
+	defaultHealthyThreshold = 1
+	// retryOnConnectFailure 连接失败类的错误始终允许重试
+	retryOnConnectFailure = "reset,connect-failure,refused-stream"
+)
+
+// matchFaultTarget 规则的目标服务是否为当前服务, 规则没有设置或者设置为 * 时认为匹配
+func matchFaultTarget(namespace, service string, svc *ServiceInfo) bool {
+	if namespace != "" && namespace != utils.MatchAll && namespace != svc.Namespace {
+		return false
+	}
+	if service != "" && service != utils.MatchAll && service != svc.Name {
+		return false
+	}
+	return true
+}
+
+// filterCircuitBreakerRules 过滤出当前服务指定级别下开启的熔断规则
+func filterCircuitBreakerRules(svc *ServiceInfo, level apifault.Level) []*apifault.CircuitBreakerRule {
+	rules := make([]*apifault.CircuitBreakerRule, 0, 4)
+	for _, rule := range svc.CircuitBreaker.GetRules() {
+		if !rule.GetEnable() || rule.GetLevel() != level {
+			continue
+		}
+		dest := rule.GetRuleMatcher().GetDestination()
+		if !matchFaultTarget(dest.GetNamespace(), dest.GetService(), svc) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// MakeOutlierDetection Translate the circuit breaker configuration of Polaris into OutlierDetection
+// 实例级熔断规则优先, 没有实例级规则时使用服务级规则, 服务级熔断允许摘除全部实例
+// Envoy 每个集群只有一份摘除配置, 多条规则合并时取最严格的配置: 最小的错误数以及错误率阈值、最短的统计周期、
+// 最长的熔断时长以及最大的摘除比例
+func MakeOutlierDetection(serviceInfo *ServiceInfo) *cluster.OutlierDetection {
+	maxEjection := uint32(0)
+	rules := filterCircuitBreakerRules(serviceInfo, apifault.Level_INSTANCE)
+	if len(rules) == 0 {
+		rules = filterCircuitBreakerRules(serviceInfo, apifault.Level_SERVICE)
+		maxEjection = 100
+	}
+
+	var (
+		errorCount, errorPercent, minimumRequest uint32
+		interval, sleepWindow, ejectionPercent   uint32
+	)
+	for _, rule := range rules {
+		for _, trigger := range rule.GetTriggerCondition() {
+			switch trigger.GetTriggerType() {
+			case apifault.TriggerCondition_CONSECUTIVE_ERROR:
+				if trigger.GetErrorCount() == 0 {
+					continue
+				}
+				errorCount = minNonZero(errorCount, trigger.GetErrorCount())
+			case apifault.TriggerCondition_ERROR_RATE:
+				if trigger.GetErrorPercent() == 0 {
+					continue
+				}
+				if errorPercent == 0 || trigger.GetErrorPercent() < errorPercent {
+					errorPercent = trigger.GetErrorPercent()
+					minimumRequest = trigger.GetMinimumRequest()
+				}
+			default:
+				continue
+			}
+			interval = minNonZero(interval, trigger.GetInterval())
+		}
+		if rule.GetMaxEjectionPercent() > ejectionPercent {
+			ejectionPercent = rule.GetMaxEjectionPercent()
+		}
+		if rule.GetRecoverCondition().GetSleepWindow() > sleepWindow {
+			sleepWindow = rule.GetRecoverCondition().GetSleepWindow()
+		}
+	}
+	// not config or close circuit breaker
+	if errorCount == 0 && errorPercent == 0 {
+		return nil
+	}
+
+	outlierDetection := &cluster.OutlierDetection{
+		// 默认关闭 Envoy 内置的各类摘除策略, 只开启熔断规则配置了的策略
+		EnforcingConsecutive_5Xx:               &wrappers.UInt32Value{Value: 0},
+		EnforcingConsecutiveGatewayFailure:     &wrappers.UInt32Value{Value: 0},
+		EnforcingSuccessRate:                   &wrappers.UInt32Value{Value: 0},
+		EnforcingLocalOriginSuccessRate:        &wrappers.UInt32Value{Value: 0},
+		EnforcingConsecutiveLocalOriginFailure: &wrappers.UInt32Value{Value: 0},
+		// 区分连接失败、超时等本地错误和上游返回的错误, 本地错误同样计入熔断条件
+		SplitExternalLocalOriginErrors: true,
+	}
+	if interval > 0 {
+		outlierDetection.Interval = durationpb.New(time.Duration(interval) * time.Second)
+	}
+	if errorCount > 0 {
+		outlierDetection.Consecutive_5Xx = &wrappers.UInt32Value{Value: errorCount}
+		outlierDetection.EnforcingConsecutive_5Xx = &wrappers.UInt32Value{Value: 100}
+		outlierDetection.ConsecutiveLocalOriginFailure = &wrappers.UInt32Value{Value: errorCount}
+		outlierDetection.EnforcingConsecutiveLocalOriginFailure = &wrappers.UInt32Value{Value: 100}
+	}
+	if errorPercent > 0 {
+		outlierDetection.FailurePercentageThreshold = &wrappers.UInt32Value{Value: errorPercent}
+		outlierDetection.EnforcingFailurePercentage = &wrappers.UInt32Value{Value: 100}
+		outlierDetection.EnforcingFailurePercentageLocalOrigin = &wrappers.UInt32Value{Value: 100}
+		outlierDetection.FailurePercentageRequestVolume = &wrappers.UInt32Value{Value: minimumRequest}
+		outlierDetection.FailurePercentageMinimumHosts = &wrappers.UInt32Value{Value: 1}
+	}
+	if ejectionPercent > 0 {
+		maxEjection = ejectionPercent
+	}
+	if maxEjection > 0 {
+		outlierDetection.MaxEjectionPercent = &wrappers.UInt32Value{Value: maxEjection}
+	}
+	if sleepWindow > 0 {
+		outlierDetection.BaseEjectionTime = durationpb.New(time.Duration(sleepWindow) * time.Second)
+	}
+	return outlierDetection
+}
+
+// minNonZero 返回两个值中较小的非零值
+func minNonZero(cur, val uint32) uint32 {
+	if val == 0 {
+		return cur
+	}
+	if cur == 0 || val < cur {
+		return val
+	}
+	return cur
+}
+
+// MakeCircuitBreakers 生成 Cluster 的熔断阈值
+// 北极星的熔断规则没有连接数以及并发请求数的限制, 因此只在服务存在开启的熔断规则时限制重试:
+// 方法级熔断规则会生成路由重试, 通过重试预算限制重试请求占活跃请求的比例, 避免故障期间重试放大上游的压力
+func MakeCircuitBreakers(serviceInfo *ServiceInfo) *cluster.CircuitBreakers {
+	found := false
+	for _, level := range []apifault.Level{apifault.Level_SERVICE, apifault.Level_METHOD, apifault.Level_INSTANCE} {
+		if len(filterCircuitBreakerRules(serviceInfo, level)) > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &cluster.CircuitBreakers{
+		Thresholds: []*cluster.CircuitBreakers_Thresholds{
+			{
+				Priority:       core.RoutingPriority_DEFAULT,
+				TrackRemaining: true,
+				RetryBudget: &cluster.CircuitBreakers_Thresholds_RetryBudget{
+					BudgetPercent:       &typev3.Percent{Value: defaultRetryBudgetPercent},
+					MinRetryConcurrency: &wrappers.UInt32Value{Value: defaultMinRetryConcurrency},
+				},
+			},
+		},
+	}
+}
+
+// MakeCircuitBreakerRoutes 将方法级熔断规则转换为路由级别的重试以及超时配置
+// 对每一条原始路由, 按照熔断规则的方法生成携带重试/超时的路由并放在原始路由之前, 保证原有路由规则的匹配顺序不变
+func MakeCircuitBreakerRoutes(routes []*route.Route, serviceInfo *ServiceInfo) []*route.Route {
+	rules := filterCircuitBreakerRules(serviceInfo, apifault.Level_METHOD)
+	type methodPolicy struct {
+		path    *route.RouteMatch
+		retry   *route.RetryPolicy
+		timeout *durationpb.Duration
+	}
+	policies := make([]*methodPolicy, 0, len(rules))
+	for _, rule := range rules {
+		match := makeMethodRouteMatch(rule.GetRuleMatcher().GetDestination().GetMethod())
+		if match == nil {
+			continue
+		}
+		retry, timeout := makeRetryPolicy(rule)
+		policies = append(policies, &methodPolicy{path: match, retry: retry, timeout: timeout})
+	}
+	if len(policies) == 0 {
+		return routes
+	}
+
+	ret := make([]*route.Route, 0, len(routes)*(len(policies)+1))
+	for _, item := range routes {
+		// 只有不限定 path 的路由才能追加方法级的匹配
+		if item.GetMatch().GetPrefix() != "/" || item.GetRoute() == nil {
+			ret = append(ret, item)
+			continue
+		}
+		for _, policy := range policies {
+			methodRoute := proto.Clone(item).(*route.Route)
+			methodRoute.Match.PathSpecifier = policy.path.PathSpecifier
+			methodRoute.GetRoute().RetryPolicy = policy.retry
+			if policy.timeout != nil {
+				methodRoute.GetRoute().Timeout = policy.timeout
+			}
+			ret = append(ret, methodRoute)
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// makeMethodRouteMatch 将熔断规则的方法匹配转换为路由的 path 匹配
+func makeMethodRouteMatch(method *apimodel.MatchString) *route.RouteMatch {
+	value := method.GetValue().GetValue()
+	if value == "" || value == utils.MatchAll {
+		return nil
+	}
+	switch method.GetType() {
+	case apimodel.MatchString_EXACT:
+		return &route.RouteMatch{PathSpecifier: &route.RouteMatch_Path{Path: value}}
+	case apimodel.MatchString_REGEX:
+		return &route.RouteMatch{PathSpecifier: &route.RouteMatch_SafeRegex{SafeRegex: &v32.RegexMatcher{
+			Regex: value,
+		}}}
+	case apimodel.MatchString_IN:
+		paths := strings.Split(value, ",")
+		for i := range paths {
+			paths[i] = regexp.QuoteMeta(strings.TrimSpace(paths[i]))
+		}
+		return &route.RouteMatch{PathSpecifier: &route.RouteMatch_SafeRegex{SafeRegex: &v32.RegexMatcher{
+			Regex: strings.Join(paths, "|"),
+		}}}
+	default:
+		return nil
+	}
+}
+
+// makeRetryPolicy 根据熔断规则的错误判断条件生成重试策略, 返回码条件转换为可重试状态码, 时延条件转换为单次请求超时
+func makeRetryPolicy(rule *apifault.CircuitBreakerRule) (*route.RetryPolicy, *durationpb.Duration) {
+	retryOn := []string{retryOnConnectFailure}
+	var (
+		statusCodes []uint32
+		perTry      time.Duration
+	)
+	for _, condition := range rule.GetErrorConditions() {
+		value := condition.GetCondition().GetValue().GetValue()
+		switch condition.GetInputType() {
+		case apifault.ErrorCondition_RET_CODE:
+			codes, ok := parseRetCodes(condition.GetCondition())
+			if !ok {
+				// 无法精确解析的返回码条件, 按照 5xx 处理
+				retryOn = append(retryOn, "5xx")
+				continue
+			}
+			statusCodes = append(statusCodes, codes...)
+		case apifault.ErrorCondition_DELAY:
+			delay, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			if err != nil || delay == 0 {
+				continue
+			}
+			perTry = time.Duration(delay) * time.Millisecond
+		}
+	}
+	if len(statusCodes) > 0 {
+		retryOn = append(retryOn, "retriable-status-codes")
+	}
+
+	policy := &route.RetryPolicy{
+		RetryOn:              strings.Join(retryOn, ","),
+		NumRetries:           &wrappers.UInt32Value{Value: defaultRouteRetries},
+		RetriableStatusCodes: statusCodes,
+		// 重试时优先选择其他实例
+		RetryHostPredicate: []*route.RetryPolicy_RetryHostPredicate{
+			{
+				Name: "envoy.retry_host_predicates.previous_hosts",
+				ConfigType: &route.RetryPolicy_RetryHostPredicate_TypedConfig{
+					TypedConfig: MustNewAny(&previoushosts.PreviousHostsPredicate{}),
+				},
+			},
+		},
+		HostSelectionRetryMaxAttempts: 3,
+	}
+	if perTry == 0 {
+		return policy, nil
+	}
+	policy.PerTryTimeout = durationpb.New(perTry)
+	return policy, durationpb.New(perTry * time.Duration(defaultRouteRetries+1))
+}
+
+// parseRetCodes 解析返回码条件, 只支持 EXACT 以及 IN 两种可以精确枚举的匹配方式
+func parseRetCodes(condition *apimodel.MatchString) ([]uint32, bool) {
+	value := condition.GetValue().GetValue()
+	var items []string
+	switch condition.GetType() {
+	case apimodel.MatchString_EXACT:
+		items = []string{value}
+	case apimodel.MatchString_IN:
+		items = strings.Split(value, ",")
+	default:
+		return nil, false
+	}
+	codes := make([]uint32, 0, len(items))
+	for _, item := range items {
+		code, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32)
+		if err != nil {
+			return nil, false
+		}
+		codes = append(codes, uint32(code))
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i] < codes[j]
+	})
+	return codes, len(codes) > 0
+}
+
+// selectFaultDetectRule 选出当前服务生效的主动探测规则
+// Envoy 每个集群只支持一个主动探测配置, 因此只选择一条可以转换的规则: 精确指定目标服务的规则优先,
+// 同等条件下按照规则 ID 排序保证每次生成的配置一致
+func selectFaultDetectRule(serviceInfo *ServiceInfo) *apifault.FaultDetectRule {
+	var (
+		selected      *apifault.FaultDetectRule
+		selectedScore int
+	)
+	for _, rule := range serviceInfo.FaultDetect.GetRules() {
+		target := rule.GetTargetService()
+		if !matchFaultTarget(target.GetNamespace(), target.GetService(), serviceInfo) {
+			continue
+		}
+		if !isValidFaultDetectRule(rule) {
+			continue
+		}
+		score := 0
+		if target.GetNamespace() == serviceInfo.Namespace {
+			score++
+		}
+		if target.GetService() == serviceInfo.Name {
+			score += 2
+		}
+		if selected == nil || score > selectedScore ||
+			(score == selectedScore && rule.GetId() < selected.GetId()) {
+			selected, selectedScore = rule, score
+		}
+	}
+	return selected
+}
+
+// isValidFaultDetectRule 规则是否可以转换为 Envoy 的主动探测配置
+func isValidFaultDetectRule(rule *apifault.FaultDetectRule) bool {
+	switch rule.GetProtocol() {
+	case apifault.FaultDetectRule_HTTP:
+		// Envoy 要求 HTTP 探测必须设置 path, 并且不支持 CONNECT 方法
+		config := rule.GetHttpConfig()
+		return config.GetUrl() != "" && config.GetMethod() != core.RequestMethod_CONNECT.String()
+	case apifault.FaultDetectRule_TCP:
+		return rule.GetTcpConfig() != nil
+	default:
+		return false
+	}
+}
+
+// MakeHealthCheck Translate the FaultDetector configuration of Polaris into HealthCheck
+func MakeHealthCheck(serviceInfo *ServiceInfo) []*core.HealthCheck {
+	rule := selectFaultDetectRule(serviceInfo)
+	if rule == nil {
+		return nil
+	}
+	// 熔断规则的恢复条件决定实例连续探测成功多少次后恢复
+	healthyThreshold := uint32(defaultHealthyThreshold)
+	for _, rule := range serviceInfo.CircuitBreaker.GetRules() {
+		if rule.GetEnable() && rule.GetRecoverCondition().GetConsecutiveSuccess() > healthyThreshold {
+			healthyThreshold = rule.GetRecoverCondition().GetConsecutiveSuccess()
+		}
+	}
+
+	timeout, interval := defaultHealthCheckTimeout, defaultHealthCheckInterval
+	if rule.GetTimeout() > 0 {
+		timeout = time.Duration(rule.GetTimeout()) * time.Second
+	}
+	if rule.GetInterval() > 0 {
+		interval = time.Duration(rule.GetInterval()) * time.Second
+	}
+	healthCheck := &core.HealthCheck{
+		Timeout:            durationpb.New(timeout),
+		Interval:           durationpb.New(interval),
+		UnhealthyThreshold: &wrappers.UInt32Value{Value: defaultUnhealthyThreshold},
+		HealthyThreshold:   &wrappers.UInt32Value{Value: healthyThreshold},
+	}
+	if rule.GetProtocol() == apifault.FaultDetectRule_HTTP {
+		config := rule.GetHttpConfig()
+		var headers []*core.HeaderValueOption
+		for _, item := range config.GetHeaders() {
+			header := core.HeaderValueOption{
+				Header: &core.HeaderValue{
+					Key:   item.Key,
+					Value: item.Value,
+				},
+			}
+			headers = append(headers, &header)
+		}
+
+		httpHealthCheck := &core.HealthCheck_HttpHealthCheck{
+			Path:                config.Url,
+			Method:              core.RequestMethod(core.RequestMethod_value[config.Method]),
+			RequestHeadersToAdd: headers,
+		}
+		if config.GetBody() != "" {
+			httpHealthCheck.Send = &core.HealthCheck_Payload{
+				Payload: &core.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(config.GetBody()))},
+			}
+		}
+		healthCheck.HealthChecker = &core.HealthCheck_HttpHealthCheck_{HttpHealthCheck: httpHealthCheck}
+	} else {
+		config := rule.GetTcpConfig()
+		var receives []*core.HealthCheck_Payload
+		for _, item := range config.GetReceive() {
+			if item == "" {
+				continue
+			}
+			receives = append(receives, &core.HealthCheck_Payload{
+				Payload: &core.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(item))},
+			})
+		}
+		// 没有设置发送内容时只做连接探测, Envoy 不允许空的 payload
+		tcpHealthCheck := &core.HealthCheck_TcpHealthCheck{Receive: receives}
+		if config.GetSend() != "" {
+			tcpHealthCheck.Send = &core.HealthCheck_Payload{
+				Payload: &core.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(config.GetSend()))},
+			}
+		}
+		healthCheck.HealthChecker = &core.HealthCheck_TcpHealthCheck_{TcpHealthCheck: tcpHealthCheck}
+	}
+	return []*core.HealthCheck{healthCheck}
+}
+
+// MakeEndpointHealthCheckConfig 生成实例的主动探测配置, 没有探测规则时关闭主动探测, 探测规则指定了端口时使用该端口进行探测
+func MakeEndpointHealthCheckConfig(serviceInfo *ServiceInfo) *endpoint.Endpoint_HealthCheckConfig {
+	rule := selectFaultDetectRule(serviceInfo)
+	if rule == nil {
+		return &endpoint.Endpoint_HealthCheckConfig{
+			DisableActiveHealthCheck: true,
+		}
+	}
+	return &endpoint.Endpoint_HealthCheckConfig{
+		PortValue: rule.GetPort(),
+	}
+}
@@ -0,0 +1,333 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func mockFaultServiceInfo(rules []*apifault.CircuitBreakerRule, detects []*apifault.FaultDetectRule) *ServiceInfo {
+	return &ServiceInfo{
+		Name:           "svc",
+		Namespace:      "default",
+		ServiceKey:     model.ServiceKey{Namespace: "default", Name: "svc"},
+		CircuitBreaker: &apifault.CircuitBreaker{Rules: rules},
+		FaultDetect:    &apifault.FaultDetector{Rules: detects},
+	}
+}
+
+func mockMatchString(matchType apimodel.MatchString_MatchStringType, value string) *apimodel.MatchString {
+	return &apimodel.MatchString{Type: matchType, Value: utils.NewStringValue(value)}
+}
+
+func TestMakeOutlierDetection(t *testing.T) {
+	t.Run("没有开启的规则", func(t *testing.T) {
+		svc := mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+			{
+				Enable: false,
+				Level:  apifault.Level_INSTANCE,
+				TriggerCondition: []*apifault.TriggerCondition{
+					{TriggerType: apifault.TriggerCondition_CONSECUTIVE_ERROR, ErrorCount: 5},
+				},
+			},
+		}, nil)
+		assert.Nil(t, MakeOutlierDetection(svc))
+	})
+
+	t.Run("实例级连续错误", func(t *testing.T) {
+		svc := mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+			{
+				Enable:             true,
+				Level:              apifault.Level_INSTANCE,
+				MaxEjectionPercent: 50,
+				RuleMatcher: &apifault.RuleMatcher{
+					Destination: &apifault.RuleMatcher_DestinationService{Namespace: "default", Service: "svc"},
+				},
+				TriggerCondition: []*apifault.TriggerCondition{
+					{TriggerType: apifault.TriggerCondition_CONSECUTIVE_ERROR, ErrorCount: 5, Interval: 10},
+				},
+				RecoverCondition: &apifault.RecoverCondition{SleepWindow: 30},
+			},
+		}, nil)
+		od := MakeOutlierDetection(svc)
+		assert.NotNil(t, od)
+		assert.Equal(t, uint32(5), od.GetConsecutive_5Xx().GetValue())
+		assert.Equal(t, uint32(100), od.GetEnforcingConsecutive_5Xx().GetValue())
+		assert.Equal(t, uint32(50), od.GetMaxEjectionPercent().GetValue())
+		assert.Equal(t, 10*time.Second, od.GetInterval().AsDuration())
+		assert.Equal(t, 30*time.Second, od.GetBaseEjectionTime().AsDuration())
+		assert.True(t, od.GetSplitExternalLocalOriginErrors())
+		assert.Equal(t, uint32(5), od.GetConsecutiveLocalOriginFailure().GetValue())
+		assert.Equal(t, uint32(100), od.GetEnforcingConsecutiveLocalOriginFailure().GetValue())
+		assert.NoError(t, od.Validate())
+	})
+
+	t.Run("服务级错误率", func(t *testing.T) {
+		svc := mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+			{
+				Enable: true,
+				Level:  apifault.Level_SERVICE,
+				RuleMatcher: &apifault.RuleMatcher{
+					Destination: &apifault.RuleMatcher_DestinationService{Namespace: "*", Service: "*"},
+				},
+				TriggerCondition: []*apifault.TriggerCondition{
+					{TriggerType: apifault.TriggerCondition_ERROR_RATE, ErrorPercent: 40, MinimumRequest: 10},
+				},
+			},
+			{
+				// 其他服务的规则不生效
+				Enable: true,
+				Level:  apifault.Level_INSTANCE,
+				RuleMatcher: &apifault.RuleMatcher{
+					Destination: &apifault.RuleMatcher_DestinationService{Namespace: "default", Service: "other"},
+				},
+				TriggerCondition: []*apifault.TriggerCondition{
+					{TriggerType: apifault.TriggerCondition_CONSECUTIVE_ERROR, ErrorCount: 5},
+				},
+			},
+		}, nil)
+		od := MakeOutlierDetection(svc)
+		assert.NotNil(t, od)
+		assert.Nil(t, od.GetConsecutive_5Xx())
+		assert.Equal(t, uint32(0), od.GetEnforcingConsecutive_5Xx().GetValue())
+		assert.Equal(t, uint32(40), od.GetFailurePercentageThreshold().GetValue())
+		assert.Equal(t, uint32(10), od.GetFailurePercentageRequestVolume().GetValue())
+		assert.Equal(t, uint32(100), od.GetMaxEjectionPercent().GetValue())
+		assert.Equal(t, uint32(0), od.GetEnforcingConsecutiveLocalOriginFailure().GetValue())
+		assert.Equal(t, uint32(100), od.GetEnforcingFailurePercentageLocalOrigin().GetValue())
+		assert.NoError(t, od.Validate())
+	})
+
+	t.Run("多条规则合并", func(t *testing.T) {
+		svc := mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+			{
+				Enable:             true,
+				Level:              apifault.Level_INSTANCE,
+				MaxEjectionPercent: 30,
+				TriggerCondition: []*apifault.TriggerCondition{
+					{TriggerType: apifault.TriggerCondition_CONSECUTIVE_ERROR, ErrorCount: 10, Interval: 20},
+				},
+				RecoverCondition: &apifault.RecoverCondition{SleepWindow: 10},
+			},
+			{
+				Enable:             true,
+				Level:              apifault.Level_INSTANCE,
+				MaxEjectionPercent: 60,
+				TriggerCondition: []*apifault.TriggerCondition{
+					{TriggerType: apifault.TriggerCondition_CONSECUTIVE_ERROR, ErrorCount: 3},
+					{TriggerType: apifault.TriggerCondition_ERROR_RATE, ErrorPercent: 50, MinimumRequest: 20, Interval: 5},
+				},
+				RecoverCondition: &apifault.RecoverCondition{SleepWindow: 60},
+			},
+		}, nil)
+		od := MakeOutlierDetection(svc)
+		assert.NotNil(t, od)
+		assert.NoError(t, od.Validate())
+		assert.Equal(t, uint32(3), od.GetConsecutive_5Xx().GetValue())
+		assert.Equal(t, uint32(50), od.GetFailurePercentageThreshold().GetValue())
+		assert.Equal(t, uint32(20), od.GetFailurePercentageRequestVolume().GetValue())
+		assert.Equal(t, 5*time.Second, od.GetInterval().AsDuration())
+		assert.Equal(t, uint32(60), od.GetMaxEjectionPercent().GetValue())
+		assert.Equal(t, 60*time.Second, od.GetBaseEjectionTime().AsDuration())
+	})
+}
+
+func TestMakeCircuitBreakers(t *testing.T) {
+	assert.Nil(t, MakeCircuitBreakers(mockFaultServiceInfo(nil, nil)))
+	assert.Nil(t, MakeCircuitBreakers(mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+		{Enable: false, Level: apifault.Level_SERVICE},
+	}, nil)))
+
+	breakers := MakeCircuitBreakers(mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+		{Enable: true, Level: apifault.Level_METHOD},
+	}, nil))
+	assert.NotNil(t, breakers)
+	assert.NoError(t, breakers.Validate())
+	assert.Len(t, breakers.GetThresholds(), 1)
+	threshold := breakers.GetThresholds()[0]
+	assert.True(t, threshold.GetTrackRemaining())
+	assert.Equal(t, float64(defaultRetryBudgetPercent), threshold.GetRetryBudget().GetBudgetPercent().GetValue())
+	assert.Equal(t, uint32(defaultMinRetryConcurrency), threshold.GetRetryBudget().GetMinRetryConcurrency().GetValue())
+}
+
+func TestMakeHealthCheck(t *testing.T) {
+	breakers := []*apifault.CircuitBreakerRule{
+		{
+			Enable:           true,
+			Level:            apifault.Level_INSTANCE,
+			RecoverCondition: &apifault.RecoverCondition{ConsecutiveSuccess: 3},
+		},
+	}
+
+	t.Run("多条规则只生成一个探测", func(t *testing.T) {
+		svc := mockFaultServiceInfo(breakers, []*apifault.FaultDetectRule{
+			{
+				Id:            "b",
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "*", Service: "*"},
+				Protocol:      apifault.FaultDetectRule_TCP,
+				Port:          9090,
+				TcpConfig:     &apifault.TcpProtocolConfig{Send: "ping", Receive: []string{"pong"}},
+			},
+			{
+				Id:            "c",
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "default", Service: "svc"},
+				Protocol:      apifault.FaultDetectRule_HTTP,
+				Port:          8080,
+				Interval:      5,
+				HttpConfig: &apifault.HttpProtocolConfig{
+					Method: "GET",
+					Url:    "/health",
+				},
+			},
+			{
+				// 没有 path 的 HTTP 探测无法转换
+				Id:            "a",
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "default", Service: "svc"},
+				Protocol:      apifault.FaultDetectRule_HTTP,
+				Port:          7070,
+				HttpConfig:    &apifault.HttpProtocolConfig{Method: "GET"},
+			},
+			{
+				Id:            "0",
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "default", Service: "other"},
+				Protocol:      apifault.FaultDetectRule_TCP,
+				TcpConfig:     &apifault.TcpProtocolConfig{Send: "ping"},
+			},
+		})
+
+		checks := MakeHealthCheck(svc)
+		assert.Len(t, checks, 1)
+		assert.NoError(t, checks[0].Validate())
+		assert.Equal(t, "/health", checks[0].GetHttpHealthCheck().GetPath())
+		assert.Equal(t, 5*time.Second, checks[0].GetInterval().AsDuration())
+		assert.Equal(t, defaultHealthCheckTimeout, checks[0].GetTimeout().AsDuration())
+		assert.Equal(t, uint32(3), checks[0].GetHealthyThreshold().GetValue())
+
+		hcConfig := MakeEndpointHealthCheckConfig(svc)
+		assert.NoError(t, hcConfig.Validate())
+		assert.False(t, hcConfig.GetDisableActiveHealthCheck())
+		assert.Equal(t, uint32(8080), hcConfig.GetPortValue())
+	})
+
+	t.Run("TCP探测", func(t *testing.T) {
+		svc := mockFaultServiceInfo(breakers, []*apifault.FaultDetectRule{
+			{
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "default", Service: "svc"},
+				Protocol:      apifault.FaultDetectRule_TCP,
+				TcpConfig:     &apifault.TcpProtocolConfig{Send: "ping", Receive: []string{"pong"}},
+			},
+		})
+		checks := MakeHealthCheck(svc)
+		assert.Len(t, checks, 1)
+		assert.NoError(t, checks[0].Validate())
+		assert.Equal(t, "70696e67", checks[0].GetTcpHealthCheck().GetSend().GetText())
+		assert.Equal(t, "706f6e67", checks[0].GetTcpHealthCheck().GetReceive()[0].GetText())
+		assert.Equal(t, defaultHealthCheckInterval, checks[0].GetInterval().AsDuration())
+		assert.Equal(t, uint32(0), MakeEndpointHealthCheckConfig(svc).GetPortValue())
+	})
+
+	t.Run("TCP只探测连接", func(t *testing.T) {
+		svc := mockFaultServiceInfo(nil, []*apifault.FaultDetectRule{
+			{
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "default", Service: "svc"},
+				Protocol:      apifault.FaultDetectRule_TCP,
+				TcpConfig:     &apifault.TcpProtocolConfig{},
+			},
+		})
+		checks := MakeHealthCheck(svc)
+		assert.Len(t, checks, 1)
+		assert.NoError(t, checks[0].Validate())
+		assert.Nil(t, checks[0].GetTcpHealthCheck().GetSend())
+	})
+
+	t.Run("没有可用的探测规则", func(t *testing.T) {
+		svc := mockFaultServiceInfo(nil, []*apifault.FaultDetectRule{
+			{
+				TargetService: &apifault.FaultDetectRule_DestinationService{Namespace: "default", Service: "svc"},
+				Protocol:      apifault.FaultDetectRule_HTTP,
+				Port:          8080,
+				HttpConfig:    &apifault.HttpProtocolConfig{Method: "CONNECT", Url: "/health"},
+			},
+		})
+		assert.Nil(t, MakeHealthCheck(svc))
+		assert.True(t, MakeEndpointHealthCheckConfig(svc).GetDisableActiveHealthCheck())
+
+		hcConfig := MakeEndpointHealthCheckConfig(mockFaultServiceInfo(nil, nil))
+		assert.True(t, hcConfig.GetDisableActiveHealthCheck())
+	})
+}
+
+func TestMakeCircuitBreakerRoutes(t *testing.T) {
+	svc := mockFaultServiceInfo([]*apifault.CircuitBreakerRule{
+		{
+			Enable: true,
+			Level:  apifault.Level_METHOD,
+			RuleMatcher: &apifault.RuleMatcher{
+				Destination: &apifault.RuleMatcher_DestinationService{
+					Namespace: "default",
+					Service:   "svc",
+					Method:    mockMatchString(apimodel.MatchString_EXACT, "/api/echo"),
+				},
+			},
+			ErrorConditions: []*apifault.ErrorCondition{
+				{
+					InputType: apifault.ErrorCondition_RET_CODE,
+					Condition: mockMatchString(apimodel.MatchString_IN, "503, 502"),
+				},
+				{
+					InputType: apifault.ErrorCondition_DELAY,
+					Condition: mockMatchString(apimodel.MatchString_EXACT, "200"),
+				},
+			},
+		},
+	}, nil)
+
+	defaultRoute := &route.Route{
+		Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+		Action: &route.Route_Route{Route: &route.RouteAction{
+			ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "OUTBOUND|default|svc"},
+		}},
+	}
+	routes := MakeCircuitBreakerRoutes([]*route.Route{defaultRoute}, svc)
+	assert.Len(t, routes, 2)
+	// 原始路由保持不变并且放在最后
+	assert.Equal(t, defaultRoute, routes[1])
+	assert.Nil(t, routes[1].GetRoute().GetRetryPolicy())
+
+	methodRoute := routes[0]
+	assert.Equal(t, "/api/echo", methodRoute.GetMatch().GetPath())
+	assert.Equal(t, "OUTBOUND|default|svc", methodRoute.GetRoute().GetCluster())
+	retry := methodRoute.GetRoute().GetRetryPolicy()
+	assert.Equal(t, retryOnConnectFailure+",retriable-status-codes", retry.GetRetryOn())
+	assert.Equal(t, []uint32{502, 503}, retry.GetRetriableStatusCodes())
+	assert.Equal(t, 200*time.Millisecond, retry.GetPerTryTimeout().AsDuration())
+	assert.Equal(t, 400*time.Millisecond, methodRoute.GetRoute().GetTimeout().AsDuration())
+
+	// 没有方法级规则时不修改路由
+	routes = MakeCircuitBreakerRoutes([]*route.Route{defaultRoute}, mockFaultServiceInfo(nil, nil))
+	assert.Len(t, routes, 1)
+}
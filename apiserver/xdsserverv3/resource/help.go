@@ -18,7 +18,6 @@
 package resource
 
 import (
-	"encoding/hex"
 	"fmt"
 	"math"
 	"strconv"
@@ -48,7 +47,6 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	_struct "github.com/golang/protobuf/ptypes/struct"
 	"github.com/golang/protobuf/ptypes/wrappers"
-	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
@@ -707,7 +705,16 @@ func MakeDefaultFilterChain() *listenerv3.FilterChain {
 	}
 }
 
-func makeRateLimitHCMFilter(svcKey model.ServiceKey) []*hcm.HttpFilter {
+// makeRateLimitHCMFilter 构建限流 filter, 全局限流服务需要校验调用方的身份, 因此 Envoy Node 访问全局限流服务时
+// 携带自身 metadata 中的北极星访问凭据
+func makeRateLimitHCMFilter(svcKey model.ServiceKey, opt *BuildOption) []*hcm.HttpFilter {
+	var initialMetadata []*core.HeaderValue
+	if opt != nil && opt.Client != nil && opt.Client.Metadata[SidecarSDSAccessToken] != "" {
+		initialMetadata = append(initialMetadata, &core.HeaderValue{
+			Key:   strings.ToLower(utils.HeaderAuthTokenKey),
+			Value: opt.Client.Metadata[SidecarSDSAccessToken],
+		})
+	}
 	return []*hcm.HttpFilter{
 		{
 			Name: "envoy.filters.http.local_ratelimit",
@@ -730,10 +737,11 @@ func makeRateLimitHCMFilter(svcKey model.ServiceKey) []*hcm.HttpFilter {
 						GrpcService: &corev3.GrpcService{
 							TargetSpecifier: &corev3.GrpcService_EnvoyGrpc_{
 								EnvoyGrpc: &corev3.GrpcService_EnvoyGrpc{
-									ClusterName: "polaris_ratelimit",
+									ClusterName: RateLimitClusterName,
 								},
 							},
-							Timeout: durationpb.New(time.Second),
+							Timeout:         durationpb.New(time.Second),
+							InitialMetadata: initialMetadata,
 						},
 						TransportApiVersion: core.ApiVersion_V3,
 					},
@@ -785,7 +793,7 @@ func MakeSidecarBoundHCM(svcKey model.ServiceKey, trafficDirection corev3.Traffi
 		},
 	}
 	if trafficDirection == corev3.TrafficDirection_INBOUND {
-		hcmFilters = append(makeRateLimitHCMFilter(svcKey), hcmFilters...)
+		hcmFilters = append(makeRateLimitHCMFilter(svcKey, opt), hcmFilters...)
 	}
 	if opt.IsDemand() {
 		hcmFilters = append([]*hcm.HttpFilter{
@@ -818,7 +826,7 @@ func MakeSidecarBoundHCM(svcKey model.ServiceKey, trafficDirection corev3.Traffi
 }
 
 func MakeGatewayBoundHCM(svcKey model.ServiceKey, opt *BuildOption) *hcm.HttpConnectionManager {
-	hcmFilters := makeRateLimitHCMFilter(svcKey)
+	hcmFilters := makeRateLimitHCMFilter(svcKey, opt)
 	hcmFilters = append(hcmFilters, &hcm.HttpFilter{
 		Name: wellknown.Router,
 		ConfigType: &hcm.HttpFilter_TypedConfig{
@@ -898,12 +906,14 @@ func MakeGatewayLocalRateLimit(rateLimitCache types.RateLimitCache, pathSpecifie
 			continue
 		}
 		actions, descriptors := BuildRateLimitDescriptors(rule)
-		rateLimitConf.Descriptors = descriptors
 		ratelimitRule := &route.RateLimit{Actions: actions}
 		switch rule.GetType() {
 		case apitraffic.Rule_LOCAL:
+			// 本地限流的配额由 local_ratelimit 的描述符控制
+			rateLimitConf.Descriptors = append(rateLimitConf.Descriptors, descriptors...)
 			ratelimitRule.Stage = wrapperspb.UInt32(LocalRateLimitStage)
 		case apitraffic.Rule_GLOBAL:
+			// 分布式限流的配额由北极星的全局限流服务计算
 			ratelimitRule.Stage = wrapperspb.UInt32(DistributedRateLimitStage)
 		}
 		ratelimits = append(ratelimits, ratelimitRule)
@@ -934,12 +944,14 @@ func MakeSidecarLocalRateLimit(rateLimitCache types.RateLimitCache,
 			continue
 		}
 		actions, descriptors := BuildRateLimitDescriptors(rule)
-		rateLimitConf.Descriptors = descriptors
 		ratelimitRule := &route.RateLimit{Actions: actions}
 		switch rule.GetType() {
 		case apitraffic.Rule_LOCAL:
+			// 本地限流的配额由 local_ratelimit 的描述符控制
+			rateLimitConf.Descriptors = append(rateLimitConf.Descriptors, descriptors...)
 			ratelimitRule.Stage = wrapperspb.UInt32(LocalRateLimitStage)
 		case apitraffic.Rule_GLOBAL:
+			// 分布式限流的配额由北极星的全局限流服务计算
 			ratelimitRule.Stage = wrapperspb.UInt32(DistributedRateLimitStage)
 		}
 		ratelimits = append(ratelimits, ratelimitRule)
@@ -948,107 +960,21 @@ func MakeSidecarLocalRateLimit(rateLimitCache types.RateLimitCache,
 	return ratelimits, filters, nil
 }
 
-// Translate the circuit breaker configuration of Polaris into OutlierDetection
-func MakeOutlierDetection(serviceInfo *ServiceInfo) *cluster.OutlierDetection {
-	circuitBreaker := serviceInfo.CircuitBreaker
-	if circuitBreaker == nil || len(circuitBreaker.Rules) == 0 {
-		return nil
-	}
-	var rule *apifault.CircuitBreakerRule
-	for _, item := range circuitBreaker.Rules {
-		if item.Level == apifault.Level_INSTANCE {
-			rule = item
-			break
-		}
-	}
-	// not config or close circuit breaker
-	if rule == nil || len(rule.TriggerCondition) == 0 || !rule.Enable {
-		return nil
-	}
-	triggerCondtion := rule.TriggerCondition[0]
-	outlierDetection := &cluster.OutlierDetection{}
-	outlierDetection.Interval = durationpb.New(time.Duration(triggerCondtion.GetInterval()) * time.Second)
-	outlierDetection.Consecutive_5Xx = &wrappers.UInt32Value{
-		Value: triggerCondtion.GetErrorCount()}
-	outlierDetection.FailurePercentageThreshold = &wrappers.UInt32Value{
-		Value: triggerCondtion.GetErrorPercent()}
-	outlierDetection.FailurePercentageRequestVolume = &wrappers.UInt32Value{
-		Value: triggerCondtion.GetMinimumRequest()}
-	if rule.RecoverCondition != nil {
-		outlierDetection.BaseEjectionTime =
-			durationpb.New(time.Duration(rule.GetRecoverCondition().GetSleepWindow()) * time.Second)
-	}
-
-	return outlierDetection
-}
-
-// Translate the FaultDetector configuration of Polaris into HealthCheck
-func MakeHealthCheck(serviceInfo *ServiceInfo) []*core.HealthCheck {
-	if serviceInfo.FaultDetect == nil || len(serviceInfo.FaultDetect.Rules) == 0 {
-		return nil
-	}
-	var healthChecks []*core.HealthCheck
-	for _, rule := range serviceInfo.FaultDetect.Rules {
-		healthCheck := &core.HealthCheck{
-			Timeout:            durationpb.New(time.Duration(rule.GetTimeout()) * time.Second),
-			Interval:           durationpb.New(time.Duration(rule.GetInterval()) * time.Second),
-			UnhealthyThreshold: &wrappers.UInt32Value{Value: 3},
-			HealthyThreshold:   &wrappers.UInt32Value{Value: 1},
-		}
-		if rule.GetProtocol() == apifault.FaultDetectRule_HTTP {
-			config := rule.GetHttpConfig()
-			if config == nil {
-				continue
-			}
-			var headers []*core.HeaderValueOption
-			for _, item := range config.GetHeaders() {
-				header := core.HeaderValueOption{
-					Header: &core.HeaderValue{
-						Key:   item.Key,
-						Value: item.Value,
-					},
-				}
-				headers = append(headers, &header)
-			}
-
-			httpHealthCheck := &core.HealthCheck_HttpHealthCheck{
-				Path:                config.Url,
-				Method:              core.RequestMethod(core.RequestMethod_value[config.Method]),
-				RequestHeadersToAdd: headers,
-			}
-			healthCheck.HealthChecker = &core.HealthCheck_HttpHealthCheck_{HttpHealthCheck: httpHealthCheck}
-			healthChecks = append(healthChecks, healthCheck)
-		} else if rule.GetProtocol() == apifault.FaultDetectRule_TCP {
-			config := rule.GetTcpConfig()
-			if config == nil {
-				continue
-			}
-			var receives []*core.HealthCheck_Payload
-			for _, item := range config.GetReceive() {
-				receives = append(receives, &core.HealthCheck_Payload{
-					Payload: &core.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(item))},
-				})
-			}
-			tcpHealthCheck := &core.HealthCheck_TcpHealthCheck{
-				Send: &core.HealthCheck_Payload{
-					Payload: &core.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(config.Send))},
-				},
-				Receive: receives,
-			}
-			healthCheck.HealthChecker = &core.HealthCheck_TcpHealthCheck_{TcpHealthCheck: tcpHealthCheck}
-			healthChecks = append(healthChecks, healthCheck)
-		}
-	}
-	return healthChecks
-}
-
 func MakeLbSubsetConfig(serviceInfo *ServiceInfo) *cluster.Cluster_LbSubsetConfig {
 	rules := FilterInboundRouterRule(serviceInfo)
-	if len(rules) == 0 {
+	isLaneDestination := IsLaneDestination(serviceInfo)
+	if len(rules) == 0 && !isLaneDestination {
 		return nil
 	}
 
 	var subsetSelectors []*cluster.Cluster_LbSubsetConfig_LbSubsetSelector
+	if isLaneDestination {
+		// 泳道的降级在生成路由时处理, 泳道下没有实例时不能再降级到其他实例
+		subsetSelectors = append(subsetSelectors, &cluster.Cluster_LbSubsetConfig_LbSubsetSelector{
+			Keys:           []string{LaneLabelKey},
+			FallbackPolicy: cluster.Cluster_LbSubsetConfig_LbSubsetSelector_NO_FALLBACK,
+		})
+	}
 	for _, rule := range rules {
 		// 对每一个 destination 产生一个 subset
 		for _, destination := range rule.GetDestinations() {
@@ -0,0 +1,267 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"sort"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	v32 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/golang/protobuf/ptypes"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	// LaneHeaderKey 泳道染色的请求头, 和 SDK 保持一致, 取值为 {泳道组名称}/{泳道名称}
+	LaneHeaderKey = "service-lane"
+	// LaneLabelKey 实例所属泳道的标签, 取值为泳道规则的 default_label_value, 没有该标签的实例属于基线泳道
+	LaneLabelKey = "lane"
+	// BaselineLaneValue 基线泳道实例在 Envoy 中的泳道标签取值
+	BaselineLaneValue = ""
+)
+
+// laneRule 泳道规则以及其所在的泳道组
+type laneRule struct {
+	group *apitraffic.LaneGroup
+	rule  *apitraffic.LaneRule
+}
+
+// tag 泳道的染色标识
+func (l *laneRule) tag() string {
+	return l.group.GetName() + "/" + l.rule.GetName()
+}
+
+// filterLaneRules 过滤出服务作为入口或者目标服务时开启的泳道规则, 按照优先级从高到低排序
+func filterLaneRules(svc *ServiceInfo, isEntry bool) []*laneRule {
+	ret := make([]*laneRule, 0, 4)
+	for _, group := range svc.Lanes {
+		matched := false
+		if isEntry {
+			matched = isLaneEntry(svc, group)
+		} else {
+			matched = isLaneDestination(svc, group)
+		}
+		if !matched {
+			continue
+		}
+		for _, rule := range group.GetRules() {
+			if !rule.GetEnable() {
+				continue
+			}
+			ret = append(ret, &laneRule{group: group, rule: rule})
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].rule.GetPriority() < ret[j].rule.GetPriority()
+	})
+	return ret
+}
+
+func isLaneDestination(svc *ServiceInfo, group *apitraffic.LaneGroup) bool {
+	for _, dest := range group.GetDestinations() {
+		if svc.MatchService(dest.GetNamespace(), dest.GetService()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLaneEntry 服务是否为泳道的流量入口, 网关类型的入口由网关自身完成染色
+func isLaneEntry(svc *ServiceInfo, group *apitraffic.LaneGroup) bool {
+	for _, entry := range group.GetEntries() {
+		if model.TrafficEntryType(entry.GetType()) != model.TrafficEntry_MicroService {
+			continue
+		}
+		selector := &apitraffic.ServiceSelector{}
+		if err := ptypes.UnmarshalAny(entry.GetSelector(), selector); err != nil {
+			log.Errorf("[XDS][Lane] unmarshal lane group(%s) entry selector fail: %s", group.GetName(), err.Error())
+			continue
+		}
+		if svc.MatchService(selector.GetNamespace(), selector.GetService()) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLaneDestination 服务是否为某个泳道组的目标服务
+func IsLaneDestination(svc *ServiceInfo) bool {
+	for _, group := range svc.Lanes {
+		if isLaneDestination(svc, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// countLaneInstances 统计指定泳道下可用的实例数量
+func countLaneInstances(svc *ServiceInfo, lane string) int {
+	count := 0
+	for _, ins := range svc.Instances {
+		if !IsNormalEndpoint(ins) || !ins.GetHealthy().GetValue() {
+			continue
+		}
+		if ins.GetMetadata()[LaneLabelKey] == lane {
+			count++
+		}
+	}
+	return count
+}
+
+// MakeLaneEntryRoutes 服务作为泳道入口时, 对还没有染色并且命中泳道规则的请求添加染色请求头, 由业务透传到下游服务.
+// 染色路由克隆自原始路由并放在原始路由之前
+func MakeLaneEntryRoutes(routes []*route.Route, svc *ServiceInfo) []*route.Route {
+	rules := filterLaneRules(svc, true)
+	if len(rules) == 0 {
+		return routes
+	}
+	ret := make([]*route.Route, 0, len(routes)*(len(rules)+1))
+	for _, item := range routes {
+		if item.GetMatch().GetPrefix() != "/" || item.GetRoute() == nil {
+			ret = append(ret, item)
+			continue
+		}
+		for _, rule := range rules {
+			for _, match := range makeLaneTrafficMatches(rule.rule.GetTrafficMatchRule()) {
+				laneRoute := proto.Clone(item).(*route.Route)
+				match.Headers = append(match.Headers, &route.HeaderMatcher{
+					Name: LaneHeaderKey,
+					HeaderMatchSpecifier: &route.HeaderMatcher_PresentMatch{
+						PresentMatch: false,
+					},
+				})
+				laneRoute.Match = match
+				laneRoute.RequestHeadersToAdd = append(laneRoute.RequestHeadersToAdd, &core.HeaderValueOption{
+					Header: &core.HeaderValue{
+						Key:   LaneHeaderKey,
+						Value: rule.tag(),
+					},
+					Append: wrapperspb.Bool(false),
+				})
+				ret = append(ret, laneRoute)
+			}
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// makeLaneTrafficMatches 将泳道的流量匹配规则转换为路由匹配, Envoy 的路由匹配条件之间是与的关系,
+// 因此 OR 模式下每个匹配参数单独生成一条路由
+func makeLaneTrafficMatches(matchRule *apitraffic.TrafficMatchRule) []*route.RouteMatch {
+	newMatch := func(args []*apitraffic.SourceMatch) *route.RouteMatch {
+		match := &route.RouteMatch{
+			PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+		}
+		BuildSidecarRouteMatch(match, &apitraffic.SourceService{Arguments: args})
+		return match
+	}
+	args := matchRule.GetArguments()
+	if len(args) == 0 {
+		return []*route.RouteMatch{newMatch(nil)}
+	}
+	if matchRule.GetMatchMode() == apitraffic.TrafficMatchRule_AND {
+		return []*route.RouteMatch{newMatch(args)}
+	}
+	matches := make([]*route.RouteMatch, 0, len(args))
+	for _, arg := range args {
+		matches = append(matches, newMatch([]*apitraffic.SourceMatch{arg}))
+	}
+	return matches
+}
+
+// MakeLaneRoutes 服务作为泳道的目标服务时, 已经染色的请求路由到对应泳道的实例, 没有染色的请求路由到基线泳道的实例.
+// 泳道下没有可用实例时, 宽松模式的泳道降级到基线泳道, 严格模式的泳道不降级
+func MakeLaneRoutes(trafficDirection core.TrafficDirection, routes []*route.Route, svc *ServiceInfo,
+	opt *BuildOption) []*route.Route {
+	rules := filterLaneRules(svc, false)
+	if len(rules) == 0 {
+		return routes
+	}
+	hasBaseline := countLaneInstances(svc, BaselineLaneValue) > 0
+
+	ret := make([]*route.Route, 0, len(routes)+len(rules))
+	for _, rule := range rules {
+		laneRoute := MakeDefaultRoute(trafficDirection, svc.ServiceKey, opt)
+		laneRoute.Match.Headers = []*route.HeaderMatcher{
+			{
+				Name: LaneHeaderKey,
+				HeaderMatchSpecifier: &route.HeaderMatcher_StringMatch{
+					StringMatch: &v32.StringMatcher{
+						MatchPattern: &v32.StringMatcher_Exact{Exact: rule.tag()},
+					},
+				},
+			},
+		}
+		lane := rule.rule.GetDefaultLabelValue()
+		if countLaneInstances(svc, lane) == 0 && rule.rule.GetMatchMode() == apitraffic.LaneRule_PERMISSIVE {
+			lane = BaselineLaneValue
+		}
+		if lane != BaselineLaneValue || hasBaseline {
+			laneRoute.GetRoute().MetadataMatch = makeLaneMetadataMatch(lane)
+		}
+		ret = append(ret, laneRoute)
+	}
+	for _, item := range routes {
+		// 只有默认路由需要限制到基线泳道, 路由规则生成的路由保持原有的实例标签匹配
+		if hasBaseline && isDefaultRoute(item) {
+			item.GetRoute().MetadataMatch = makeLaneMetadataMatch(BaselineLaneValue)
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+func isDefaultRoute(item *route.Route) bool {
+	return item.GetMatch().GetPrefix() == "/" && len(item.GetMatch().GetHeaders()) == 0 &&
+		len(item.GetMatch().GetQueryParameters()) == 0 && item.GetRoute().GetCluster() != ""
+}
+
+func makeLaneMetadataMatch(lane string) *core.Metadata {
+	return &core.Metadata{
+		FilterMetadata: map[string]*_struct.Struct{
+			"envoy.lb": {
+				Fields: map[string]*_struct.Value{
+					LaneLabelKey: {Kind: &_struct.Value_StringValue{StringValue: lane}},
+				},
+			},
+		},
+	}
+}
+
+// FillBaselineLaneMeta 泳道目标服务中没有泳道标签的实例属于基线泳道, 补充基线泳道的标签用于 Envoy 的 subset 匹配
+func FillBaselineLaneMeta(meta *core.Metadata, ins *apiservice.Instance, svc *ServiceInfo) {
+	if _, ok := ins.GetMetadata()[LaneLabelKey]; ok || !IsLaneDestination(svc) {
+		return
+	}
+	lbMeta, ok := meta.GetFilterMetadata()["envoy.lb"]
+	if !ok {
+		return
+	}
+	if lbMeta.Fields == nil {
+		lbMeta.Fields = map[string]*_struct.Value{}
+	}
+	lbMeta.Fields[LaneLabelKey] = &_struct.Value{Kind: &_struct.Value_StringValue{StringValue: BaselineLaneValue}}
+}
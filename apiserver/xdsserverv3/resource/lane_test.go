@@ -0,0 +1,211 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/golang/protobuf/ptypes"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func mockLaneInstance(lane string) *apiservice.Instance {
+	ins := &apiservice.Instance{
+		Host:     utils.NewStringValue("127.0.0.1"),
+		Weight:   utils.NewUInt32Value(100),
+		Healthy:  utils.NewBoolValue(true),
+		Metadata: map[string]string{},
+	}
+	if lane != "" {
+		ins.Metadata[LaneLabelKey] = lane
+	}
+	return ins
+}
+
+func mockLaneServiceInfo(t *testing.T, mode apitraffic.LaneRule_LaneMatchMode,
+	instances ...*apiservice.Instance) *ServiceInfo {
+	selector, err := ptypes.MarshalAny(&apitraffic.ServiceSelector{Namespace: "default", Service: "entry"})
+	assert.NoError(t, err)
+	group := &apitraffic.LaneGroup{
+		Name: "group",
+		Entries: []*apitraffic.TrafficEntry{
+			{Type: string(model.TrafficEntry_MicroService), Selector: selector},
+		},
+		Destinations: []*apitraffic.DestinationGroup{
+			{Namespace: "default", Service: "entry"},
+			{Namespace: "default", Service: "dest"},
+		},
+		Rules: []*apitraffic.LaneRule{
+			{
+				Name:              "gray",
+				Enable:            true,
+				DefaultLabelValue: "gray",
+				MatchMode:         mode,
+				TrafficMatchRule: &apitraffic.TrafficMatchRule{
+					MatchMode: apitraffic.TrafficMatchRule_OR,
+					Arguments: []*apitraffic.SourceMatch{
+						{
+							Type: apitraffic.SourceMatch_HEADER,
+							Key:  "user",
+							Value: &apimodel.MatchString{
+								Type:  apimodel.MatchString_EXACT,
+								Value: utils.NewStringValue("foo"),
+							},
+						},
+						{
+							Type: apitraffic.SourceMatch_QUERY,
+							Key:  "env",
+							Value: &apimodel.MatchString{
+								Type:  apimodel.MatchString_EXACT,
+								Value: utils.NewStringValue("gray"),
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:   "disabled",
+				Enable: false,
+			},
+		},
+	}
+	return &ServiceInfo{
+		Name:       "dest",
+		Namespace:  "default",
+		ServiceKey: model.ServiceKey{Namespace: "default", Name: "dest"},
+		Instances:  instances,
+		Lanes:      []*apitraffic.LaneGroup{group},
+	}
+}
+
+func laneMetadataValue(meta *core.Metadata) (string, bool) {
+	lbMeta, ok := meta.GetFilterMetadata()["envoy.lb"]
+	if !ok {
+		return "", false
+	}
+	val, ok := lbMeta.GetFields()[LaneLabelKey]
+	return val.GetStringValue(), ok
+}
+
+func TestMakeLaneRoutes(t *testing.T) {
+	opt := &BuildOption{TrafficDirection: core.TrafficDirection_OUTBOUND}
+
+	t.Run("泳道以及基线都有实例", func(t *testing.T) {
+		svc := mockLaneServiceInfo(t, apitraffic.LaneRule_STRICT, mockLaneInstance("gray"), mockLaneInstance(""))
+		routes := MakeLaneRoutes(core.TrafficDirection_OUTBOUND,
+			[]*route.Route{MakeDefaultRoute(core.TrafficDirection_OUTBOUND, svc.ServiceKey, opt)}, svc, opt)
+		assert.Len(t, routes, 2)
+
+		laneRoute := routes[0]
+		assert.Equal(t, LaneHeaderKey, laneRoute.GetMatch().GetHeaders()[0].GetName())
+		assert.Equal(t, "group/gray", laneRoute.GetMatch().GetHeaders()[0].GetStringMatch().GetExact())
+		lane, ok := laneMetadataValue(laneRoute.GetRoute().GetMetadataMatch())
+		assert.True(t, ok)
+		assert.Equal(t, "gray", lane)
+
+		// 没有染色的请求只路由到基线实例
+		lane, ok = laneMetadataValue(routes[1].GetRoute().GetMetadataMatch())
+		assert.True(t, ok)
+		assert.Equal(t, BaselineLaneValue, lane)
+	})
+
+	t.Run("宽松模式泳道没有实例时降级到基线", func(t *testing.T) {
+		svc := mockLaneServiceInfo(t, apitraffic.LaneRule_PERMISSIVE, mockLaneInstance(""))
+		routes := MakeLaneRoutes(core.TrafficDirection_OUTBOUND,
+			[]*route.Route{MakeDefaultRoute(core.TrafficDirection_OUTBOUND, svc.ServiceKey, opt)}, svc, opt)
+		lane, ok := laneMetadataValue(routes[0].GetRoute().GetMetadataMatch())
+		assert.True(t, ok)
+		assert.Equal(t, BaselineLaneValue, lane)
+	})
+
+	t.Run("严格模式泳道没有实例时不降级", func(t *testing.T) {
+		svc := mockLaneServiceInfo(t, apitraffic.LaneRule_STRICT, mockLaneInstance(""))
+		routes := MakeLaneRoutes(core.TrafficDirection_OUTBOUND,
+			[]*route.Route{MakeDefaultRoute(core.TrafficDirection_OUTBOUND, svc.ServiceKey, opt)}, svc, opt)
+		lane, ok := laneMetadataValue(routes[0].GetRoute().GetMetadataMatch())
+		assert.True(t, ok)
+		assert.Equal(t, "gray", lane)
+	})
+
+	t.Run("不是泳道的目标服务", func(t *testing.T) {
+		svc := mockLaneServiceInfo(t, apitraffic.LaneRule_STRICT, mockLaneInstance(""))
+		svc.Name = "other"
+		svc.ServiceKey.Name = "other"
+		routes := MakeLaneRoutes(core.TrafficDirection_OUTBOUND,
+			[]*route.Route{MakeDefaultRoute(core.TrafficDirection_OUTBOUND, svc.ServiceKey, opt)}, svc, opt)
+		assert.Len(t, routes, 1)
+		assert.Nil(t, routes[0].GetRoute().GetMetadataMatch())
+		assert.Nil(t, MakeLbSubsetConfig(svc))
+	})
+}
+
+func TestMakeLaneEntryRoutes(t *testing.T) {
+	svc := mockLaneServiceInfo(t, apitraffic.LaneRule_STRICT, mockLaneInstance(""))
+	opt := &BuildOption{TrafficDirection: core.TrafficDirection_INBOUND}
+	baseRoute := MakeDefaultRoute(core.TrafficDirection_INBOUND, svc.ServiceKey, opt)
+
+	// dest 不是泳道入口
+	assert.Len(t, MakeLaneEntryRoutes([]*route.Route{baseRoute}, svc), 1)
+
+	svc.Name = "entry"
+	svc.ServiceKey.Name = "entry"
+	routes := MakeLaneEntryRoutes([]*route.Route{baseRoute}, svc)
+	// OR 模式下每个匹配参数生成一条染色路由
+	assert.Len(t, routes, 3)
+	assert.Equal(t, baseRoute, routes[2])
+
+	headerRoute := routes[0]
+	assert.Len(t, headerRoute.GetMatch().GetHeaders(), 2)
+	assert.Equal(t, "user", headerRoute.GetMatch().GetHeaders()[0].GetName())
+	assert.Equal(t, LaneHeaderKey, headerRoute.GetMatch().GetHeaders()[1].GetName())
+	assert.False(t, headerRoute.GetMatch().GetHeaders()[1].GetPresentMatch())
+	assert.Equal(t, LaneHeaderKey, headerRoute.GetRequestHeadersToAdd()[0].GetHeader().GetKey())
+	assert.Equal(t, "group/gray", headerRoute.GetRequestHeadersToAdd()[0].GetHeader().GetValue())
+
+	queryRoute := routes[1]
+	assert.Equal(t, "env", queryRoute.GetMatch().GetQueryParameters()[0].GetName())
+	assert.Equal(t, baseRoute.GetRoute().GetCluster(), queryRoute.GetRoute().GetCluster())
+}
+
+func TestFillBaselineLaneMeta(t *testing.T) {
+	svc := mockLaneServiceInfo(t, apitraffic.LaneRule_STRICT)
+	baseline := mockLaneInstance("")
+	meta := GenEndpointMetaFromPolarisIns(baseline)
+	FillBaselineLaneMeta(meta, baseline, svc)
+	lane, ok := laneMetadataValue(meta)
+	assert.True(t, ok)
+	assert.Equal(t, BaselineLaneValue, lane)
+
+	gray := mockLaneInstance("gray")
+	meta = GenEndpointMetaFromPolarisIns(gray)
+	FillBaselineLaneMeta(meta, gray, svc)
+	lane, _ = laneMetadataValue(meta)
+	assert.Equal(t, "gray", lane)
+
+	subset := MakeLbSubsetConfig(svc)
+	assert.NotNil(t, subset)
+	assert.Equal(t, []string{LaneLabelKey}, subset.GetSubsetSelectors()[0].GetKeys())
+}
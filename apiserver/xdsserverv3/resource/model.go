@@ -41,6 +41,8 @@ const (
 	InBoundRouteConfigName  = "polaris-inbound-cluster"
 	OdcdsRouteConfigName    = "polaris-odcds-router"
 	InternalOdcdsHeader     = "internal-service-cluster"
+	// RateLimitClusterName 北极星全局限流服务对应的 cluster
+	RateLimitClusterName = "polaris_ratelimit"
 )
 
 const (
@@ -103,6 +105,8 @@ func FromSimpleXDS(s string) XDSType {
 		return RLS
 	case "vhds":
 		return VHDS
+	case "sds":
+		return SDS
 	default:
 		return UnknownXDS
 	}
@@ -122,6 +126,8 @@ func FormatTypeUrl(typeUrl string) XDSType {
 		return RLS
 	case resourcev3.VirtualHostType:
 		return VHDS
+	case resourcev3.SecretType:
+		return SDS
 	default:
 		return UnknownXDS
 	}
@@ -146,6 +152,9 @@ func (x XDSType) ResourceType() resourcev3.Type {
 	if x == VHDS {
 		return resourcev3.VirtualHostType
 	}
+	if x == SDS {
+		return resourcev3.SecretType
+	}
 	return resourcev3.AnyType
 }
 
@@ -168,6 +177,9 @@ func (x XDSType) String() string {
 	if x == VHDS {
 		return resourcev3.VirtualHostType
 	}
+	if x == SDS {
+		return resourcev3.SecretType
+	}
 	return resourcev3.AnyType
 }
 
@@ -213,6 +225,8 @@ type ServiceInfo struct {
 	CircuitBreakerRevision string
 	FaultDetect            *fault_tolerance.FaultDetector
 	FaultDetectRevision    string
+	Lanes                  []*traffic_manage.LaneGroup
+	LaneRevision           string
 }
 
 func (s *ServiceInfo) Equal(o *ServiceInfo) bool {
@@ -232,6 +246,9 @@ func (s *ServiceInfo) Equal(o *ServiceInfo) bool {
 	if s.FaultDetectRevision != o.FaultDetectRevision {
 		return false
 	}
+	if s.LaneRevision != o.LaneRevision {
+		return false
+	}
 	return true
 }
 
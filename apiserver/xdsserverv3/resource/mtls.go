@@ -26,6 +26,13 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
+const (
+	// SDSDefaultSecretName 工作负载证书的 SDS Secret 名称
+	SDSDefaultSecretName = "default"
+	// SDSRootCASecretName 根证书的 SDS Secret 名称
+	SDSRootCASecretName = "ROOTCA"
+)
+
 var DefaultSdsConfig = &core.ConfigSource{
 	ConfigSourceSpecifier: &core.ConfigSource_ApiConfigSource{
 		ApiConfigSource: &core.ApiConfigSource{
@@ -55,7 +62,7 @@ var MTLSTransportSocketMatch = &structpb.Struct{
 var OutboundCommonTLSContext = &tlstrans.CommonTlsContext{
 	TlsCertificateSdsSecretConfigs: []*tlstrans.SdsSecretConfig{
 		{
-			Name:      "default",
+			Name:      SDSDefaultSecretName,
 			SdsConfig: DefaultSdsConfig,
 		},
 	},
@@ -63,7 +70,7 @@ var OutboundCommonTLSContext = &tlstrans.CommonTlsContext{
 		CombinedValidationContext: &tlstrans.CommonTlsContext_CombinedCertificateValidationContext{
 			DefaultValidationContext: &tlstrans.CertificateValidationContext{},
 			ValidationContextSdsSecretConfig: &tlstrans.SdsSecretConfig{
-				Name:      "ROOTCA",
+				Name:      SDSRootCASecretName,
 				SdsConfig: DefaultSdsConfig,
 			},
 		},
@@ -84,7 +91,7 @@ var InboundCommonTLSContext = &tlstrans.CommonTlsContext{
 	},
 	TlsCertificateSdsSecretConfigs: []*tlstrans.SdsSecretConfig{
 		{
-			Name:      "default",
+			Name:      SDSDefaultSecretName,
 			SdsConfig: DefaultSdsConfig,
 		},
 	},
@@ -100,7 +107,7 @@ var InboundCommonTLSContext = &tlstrans.CommonTlsContext{
 				},
 			},
 			ValidationContextSdsSecretConfig: &tlstrans.SdsSecretConfig{
-				Name:      "ROOTCA",
+				Name:      SDSRootCASecretName,
 				SdsConfig: DefaultSdsConfig,
 			},
 		},
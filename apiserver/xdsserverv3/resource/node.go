@@ -68,6 +68,16 @@ const (
 	SidecarOpenOnDemandFeature = "sidecar.polarismesh.cn/openOnDemand"
 	// SidecarOpenOnDemandServer .
 	SidecarOpenOnDemandServer = "sidecar.polarismesh.cn/demandServer"
+	// SidecarSDSAccessToken xds metadata key, the polaris access token proves the service identity of envoy node,
+	// the built-in CA only issues workload certificate when the token has write permission on the service,
+	// and the global ratelimit service only serves the quota of the service when the token has read permission
+	SidecarSDSAccessToken = "sidecar.polarismesh.cn/sdsAccessToken"
+	// SidecarSDSCSR xds metadata key, the PEM encoded certificate signing request of the workload, the private key
+	// is generated and kept by the workload itself, the built-in CA only signs the public key of the request
+	SidecarSDSCSR = "sidecar.polarismesh.cn/sdsCSR"
+	// SidecarSDSPrivateKeyFile xds metadata key, the path of the workload private key on the envoy node,
+	// envoy reads the private key of the workload certificate from this file
+	SidecarSDSPrivateKeyFile = "sidecar.polarismesh.cn/sdsPrivateKeyFile"
 )
 
 type EnvoyNodeView struct {
@@ -283,7 +293,7 @@ func (n *XDSClient) toView() *EnvoyNodeView {
 		Namespace:    n.Namespace,
 		IPAddr:       n.IPAddr,
 		PodIP:        n.PodIP,
-		Metadata:     n.Metadata,
+		Metadata:     n.displayMetadata(),
 		Version:      n.Version,
 		TLSMode:      n.TLSMode,
 		OpenOnDemand: n.OpenOnDemand,
@@ -300,7 +310,20 @@ func (n *XDSClient) ResourceKey() string {
 
 func (n *XDSClient) String() string {
 	return fmt.Sprintf("nodeid=%s|type=%v|user=%s|addr=%s|version=%s|tls=%s|meta=%s", n.Node.GetId(),
-		n.RunType, n.User, n.IPAddr, n.Version, n.TLSMode, toJSON(n.Metadata))
+		n.RunType, n.User, n.IPAddr, n.Version, n.TLSMode, toJSON(n.displayMetadata()))
+}
+
+// displayMetadata 用于日志以及调试接口输出的 metadata, 隐藏访问凭据
+func (n *XDSClient) displayMetadata() map[string]string {
+	if _, ok := n.Metadata[SidecarSDSAccessToken]; !ok {
+		return n.Metadata
+	}
+	ret := make(map[string]string, len(n.Metadata))
+	for k, v := range n.Metadata {
+		ret[k] = v
+	}
+	ret[SidecarSDSAccessToken] = "******"
+	return ret
 }
 
 func (n *XDSClient) IsGateway() bool {
@@ -0,0 +1,69 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+
+	ratelimitfilter "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+	tlstrans "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestMakeRateLimitCluster(t *testing.T) {
+	c, err := MakeRateLimitCluster("polaris.polaris-system:15010", false)
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimitClusterName, c.GetName())
+	assert.Nil(t, c.GetTransportSocket())
+
+	// xds 监听开启 TLS 后, 访问限流服务同样需要使用 TLS
+	c, err = MakeRateLimitCluster("polaris.polaris-system:15010", true)
+	assert.NoError(t, err)
+	tlsCtx := &tlstrans.UpstreamTlsContext{}
+	assert.NoError(t, c.GetTransportSocket().GetTypedConfig().UnmarshalTo(tlsCtx))
+	assert.Equal(t, "polaris.polaris-system", tlsCtx.GetSni())
+
+	_, err = MakeRateLimitCluster("polaris.polaris-system", true)
+	assert.Error(t, err)
+}
+
+func TestMakeRateLimitHCMFilter(t *testing.T) {
+	svcKey := model.ServiceKey{Namespace: "default", Name: "echo"}
+	parse := func(opt *BuildOption) *ratelimitfilter.RateLimit {
+		filters := makeRateLimitHCMFilter(svcKey, opt)
+		assert.Len(t, filters, 2)
+		conf := &ratelimitfilter.RateLimit{}
+		assert.NoError(t, filters[1].GetTypedConfig().UnmarshalTo(conf))
+		return conf
+	}
+
+	conf := parse(&BuildOption{})
+	assert.Equal(t, "echo.default", conf.GetDomain())
+	assert.Empty(t, conf.GetRateLimitService().GetGrpcService().GetInitialMetadata())
+
+	// 访问全局限流服务时携带 Envoy Node 自身的访问凭据
+	conf = parse(&BuildOption{Client: &XDSClient{Metadata: map[string]string{
+		SidecarSDSAccessToken: "echo-token",
+	}}})
+	metadata := conf.GetRateLimitService().GetGrpcService().GetInitialMetadata()
+	assert.Len(t, metadata, 1)
+	assert.Equal(t, "x-polaris-token", metadata[0].GetKey())
+	assert.Equal(t, "echo-token", metadata[0].GetValue())
+}
@@ -0,0 +1,124 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package xdsserverv3
+
+import (
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tlstrans "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/ca"
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/service"
+)
+
+// WorkloadCredentialVerifier 校验 Envoy 携带的访问凭据是否可以代表该服务身份
+type WorkloadCredentialVerifier func(id ca.Identity, token string) error
+
+// SDSBuilder 基于内置 CA 构建 Envoy 的证书资源
+type SDSBuilder struct {
+	ca     *ca.CertificateAuthority
+	verify WorkloadCredentialVerifier
+}
+
+func (sds *SDSBuilder) Init(svr service.DiscoverServer) {
+}
+
+// Generate 生成 Envoy Node 的工作负载证书以及根证书, 只有开启了 TLS 的节点才需要下发. 工作负载的私钥由工作负载
+// 自行生成并保存在本地, 通过 node metadata 提交 CSR, 下发的证书资源只引用本地的私钥文件
+func (sds *SDSBuilder) Generate(option *resource.BuildOption) (interface{}, error) {
+	resources := make([]types.Resource, 0, 2)
+	if sds.ca == nil || !option.HasTls() {
+		return resources, nil
+	}
+
+	resources = append(resources, &tlstrans.Secret{
+		Name: resource.SDSRootCASecretName,
+		Type: &tlstrans.Secret_ValidationContext{
+			ValidationContext: &tlstrans.CertificateValidationContext{
+				TrustedCa: inlineBytes(sds.ca.TrustBundlePEM()),
+			},
+		},
+	})
+
+	// 服务信息不存在或者不精确，无法确认工作负载身份，只下发根证书
+	if !option.SelfService.IsExact() {
+		return resources, nil
+	}
+	id := ca.Identity{
+		Namespace: option.SelfService.Namespace,
+		Service:   option.SelfService.Name,
+	}
+	// node metadata 由客户端自行设置, 只有携带了拥有该服务写权限的访问凭据才能证明工作负载身份, 否则只下发根证书
+	if sds.verify == nil {
+		return resources, nil
+	}
+	if err := sds.verify(id, accessToken(option.Client)); err != nil {
+		log.Warnf("[XDS][SDS] envoy node can not prove identity %s, only serve root ca: %s",
+			id.SpiffeID(sds.ca.TrustDomain()), err.Error())
+		return resources, nil
+	}
+	csrPEM, keyFile := workloadCSR(option.Client)
+	if csrPEM == "" || keyFile == "" {
+		log.Warnf("[XDS][SDS] envoy node of %s does not provide csr or private key file, only serve root ca",
+			id.SpiffeID(sds.ca.TrustDomain()))
+		return resources, nil
+	}
+	cert, err := sds.ca.GetWorkloadCert(id, []byte(csrPEM))
+	if err != nil {
+		log.Warnf("[XDS][SDS] sign csr of %s fail, only serve root ca: %s", id.SpiffeID(sds.ca.TrustDomain()),
+			err.Error())
+		return resources, nil
+	}
+	resources = append(resources, &tlstrans.Secret{
+		Name: resource.SDSDefaultSecretName,
+		Type: &tlstrans.Secret_TlsCertificate{
+			TlsCertificate: &tlstrans.TlsCertificate{
+				CertificateChain: inlineBytes(cert.CertChainPEM),
+				PrivateKey: &core.DataSource{
+					Specifier: &core.DataSource_Filename{
+						Filename: keyFile,
+					},
+				},
+			},
+		},
+	})
+	return resources, nil
+}
+
+func accessToken(client *resource.XDSClient) string {
+	if client == nil {
+		return ""
+	}
+	return client.Metadata[resource.SidecarSDSAccessToken]
+}
+
+func workloadCSR(client *resource.XDSClient) (string, string) {
+	if client == nil {
+		return "", ""
+	}
+	return client.Metadata[resource.SidecarSDSCSR], client.Metadata[resource.SidecarSDSPrivateKeyFile]
+}
+
+func inlineBytes(data []byte) *core.DataSource {
+	return &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{
+			InlineBytes: data,
+		},
+	}
+}
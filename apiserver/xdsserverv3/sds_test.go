@@ -0,0 +1,126 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package xdsserverv3
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	tlstrans "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/ca"
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestSDSBuilder_Generate(t *testing.T) {
+	authority, err := ca.NewCertificateAuthority(&ca.Config{Enable: true}, nil, nil)
+	assert.NoError(t, err)
+	// 模拟鉴权模块: order-token 只拥有 default/order 的写权限
+	builder := &SDSBuilder{ca: authority, verify: func(id ca.Identity, token string) error {
+		if token == "order-token" && id == (ca.Identity{Namespace: "default", Service: "order"}) {
+			return nil
+		}
+		return errors.New("no permission")
+	}}
+
+	opt := &resource.BuildOption{
+		RunType:     resource.RunTypeSidecar,
+		Namespace:   "default",
+		TLSMode:     resource.TLSModeNone,
+		SelfService: model.ServiceKey{Namespace: "default", Name: "order"},
+	}
+	// 没有开启 TLS 的节点不需要下发证书
+	ret, err := builder.Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(cachev3.IndexRawResourcesByName(ret.([]types.Resource))))
+
+	// 没有携带访问凭据, 无法证明服务身份, 只下发根证书
+	opt.TLSMode = resource.TLSModeStrict
+	ret, err = builder.Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ret.([]types.Resource)))
+
+	// 访问凭据没有该服务的权限
+	opt.Client = &resource.XDSClient{
+		Metadata: map[string]string{
+			resource.SidecarSDSAccessToken: "user-token",
+		},
+	}
+	ret, err = builder.Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ret.([]types.Resource)))
+
+	// 没有设置凭据校验时不下发工作负载证书
+	opt.Client.Metadata[resource.SidecarSDSAccessToken] = "order-token"
+	ret, err = (&SDSBuilder{ca: authority}).Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ret.([]types.Resource)))
+
+	// 没有提交 CSR 以及本地私钥文件时不下发工作负载证书
+	ret, err = builder.Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ret.([]types.Resource)))
+
+	// CSR 无效
+	opt.Client.Metadata[resource.SidecarSDSCSR] = "invalid csr"
+	opt.Client.Metadata[resource.SidecarSDSPrivateKeyFile] = "/etc/polaris-sidecar/certs/key.pem"
+	ret, err = builder.Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ret.([]types.Resource)))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	assert.NoError(t, err)
+	opt.Client.Metadata[resource.SidecarSDSCSR] = string(pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE REQUEST", Bytes: der}))
+	ret, err = builder.Generate(opt)
+	assert.NoError(t, err)
+	secrets := cachev3.IndexRawResourcesByName(ret.([]types.Resource))
+	assert.Equal(t, 2, len(secrets))
+	rootCA := secrets[resource.SDSRootCASecretName].(*tlstrans.Secret)
+	assert.Equal(t, authority.TrustBundlePEM(), rootCA.GetValidationContext().GetTrustedCa().GetInlineBytes())
+	workload := secrets[resource.SDSDefaultSecretName].(*tlstrans.Secret)
+	// 下发的证书使用工作负载本地生成的私钥, 私钥不经过控制面
+	block, _ := pem.Decode(workload.GetTlsCertificate().GetCertificateChain().GetInlineBytes())
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(leaf.PublicKey))
+	assert.Empty(t, workload.GetTlsCertificate().GetPrivateKey().GetInlineBytes())
+	assert.Equal(t, "/etc/polaris-sidecar/certs/key.pem", workload.GetTlsCertificate().GetPrivateKey().GetFilename())
+
+	// 调试接口需要隐藏私钥
+	redacted := redactSecrets(secrets)
+	assert.Nil(t, redacted[resource.SDSDefaultSecretName].(*tlstrans.Secret).GetTlsCertificate().GetPrivateKey())
+	assert.NotNil(t, workload.GetTlsCertificate().GetPrivateKey())
+
+	// 无法确认服务身份时只下发根证书
+	opt.SelfService = model.ServiceKey{Namespace: "default"}
+	ret, err = builder.Generate(opt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ret.([]types.Resource)))
+}
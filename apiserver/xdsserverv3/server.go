@@ -19,37 +19,51 @@ package xdsserverv3
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
 	"time"
 
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	clusterservice "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
 	discoverygrpc "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	endpointservice "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
 	healthservice "github.com/envoyproxy/go-control-plane/envoy/service/health/v3"
 	listenerservice "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
 	routeservice "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
 	runtimeservice "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
 	secretservice "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/server/sotw/v3"
 	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"github.com/polarismesh/polaris/apiserver"
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/ca"
 	xdscache "github.com/polarismesh/polaris/apiserver/xdsserverv3/cache"
+	"github.com/polarismesh/polaris/apiserver/xdsserverv3/ratelimit"
 	"github.com/polarismesh/polaris/apiserver/xdsserverv3/resource"
+	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	connlimit "github.com/polarismesh/polaris/common/conn/limit"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/secure"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
 	"github.com/polarismesh/polaris/service"
 	"github.com/polarismesh/polaris/service/healthcheck"
+	"github.com/polarismesh/polaris/store"
 )
 
 type ResourceServer interface {
@@ -70,10 +84,16 @@ type XDSServer struct {
 	versionNum      *atomic.Uint64
 	server          *grpc.Server
 	connLimitConfig *connlimit.Config
+	tlsInfo         *secure.TLSInfo
+	certReloader    *secure.CertReloader
 
 	nodeMgr           *resource.XDSNodeManager
 	registryInfo      *utils.AtomicValue[ServiceInfos]
 	resourceGenerator *XdsResourceGenerator
+	// ca 内置 CA, 通过 SDS 向开启了 TLS 的 Envoy 下发工作负载证书
+	ca *ca.CertificateAuthority
+	// rls Envoy 全局限流服务, 未开启时为 nil
+	rls *ratelimit.Server
 
 	active         *atomic.Bool
 	finishCtx      context.Context
@@ -114,12 +134,73 @@ func (x *XDSServer) Initialize(ctx context.Context, option map[string]interface{
 		}
 		x.connLimitConfig = connConfig
 	}
+	if raw, _ := option["tls"].(map[interface{}]interface{}); raw != nil {
+		tlsConfig, err := secure.ParseTLSConfig(raw)
+		if err != nil {
+			return err
+		}
+		if x.tlsInfo, err = tlsConfig.ToTLSInfo(); err != nil {
+			return err
+		}
+	}
+	if raw, _ := option["ca"].(map[interface{}]interface{}); raw != nil {
+		caConfig, err := ca.ParseConfig(raw)
+		if err != nil {
+			return err
+		}
+		if caConfig.Enable {
+			// Envoy 通过 node metadata 携带访问凭据证明服务身份, 不允许在明文连接上传输
+			if x.tlsInfo.IsEmpty() {
+				err := errors.New("[XDS][CA] built-in ca requires tls on xds listener")
+				log.Errorf("init xds built-in ca err: %s", err.Error())
+				return err
+			}
+			s, err := store.GetStore()
+			if err != nil {
+				return err
+			}
+			// 自签根证书的私钥通过加密插件加密后才会保存到存储中
+			var keyCrypto ca.KeyCrypto
+			if caConfig.RootCertFile == "" {
+				if keyCrypto, err = plugin.GetCryptoManager().GetCrypto(caConfig.RootKeyCryptoAlgo); err != nil {
+					log.Errorf("init xds built-in ca err: %s", err.Error())
+					return err
+				}
+			}
+			if x.ca, err = ca.NewCertificateAuthority(caConfig, s, keyCrypto); err != nil {
+				log.Errorf("init xds built-in ca err: %s", err.Error())
+				return err
+			}
+		}
+	}
+	var rateLimitCluster *cluster.Cluster
+	if raw, _ := option["ratelimit"].(map[interface{}]interface{}); raw != nil {
+		rlsConfig, err := ratelimit.ParseConfig(raw)
+		if err != nil {
+			return err
+		}
+		if rlsConfig.Enable {
+			if rlsConfig.Address == "" {
+				rlsConfig.Address = net.JoinHostPort(utils.LocalHost, strconv.FormatUint(uint64(x.listenPort), 10))
+			}
+			if rateLimitCluster, err = resource.MakeRateLimitCluster(rlsConfig.Address,
+				!x.tlsInfo.IsEmpty()); err != nil {
+				log.Errorf("init xds global ratelimit service err: %s", err.Error())
+				return err
+			}
+			x.rls = ratelimit.NewServer(rlsConfig, x.fetchRateLimitRules, x.fetchClusterNodes,
+				x.authorizeRateLimit)
+		}
+	}
 	x.resourceGenerator = &XdsResourceGenerator{
-		namingServer:    x.namingServer,
-		cache:           x.cache,
-		versionNum:      x.versionNum,
-		xdsNodesMgr:     x.nodeMgr,
-		svcInfoProvider: x.fetchCurrentServices,
+		namingServer:     x.namingServer,
+		cache:            x.cache,
+		versionNum:       x.versionNum,
+		xdsNodesMgr:      x.nodeMgr,
+		svcInfoProvider:  x.fetchCurrentServices,
+		ca:               x.ca,
+		verifyCredential: x.verifyWorkloadCredential,
+		rateLimitCluster: rateLimitCluster,
 	}
 	resource.Init()
 	return nil
@@ -133,6 +214,18 @@ func (x *XDSServer) Run(errCh chan error) {
 	srv := serverv3.NewServer(ctx, x.cache, cb, sotw.WithOrderedADS())
 	var grpcOptions []grpc.ServerOption
 	grpcOptions = append(grpcOptions, grpc.MaxConcurrentStreams(1000))
+	// 指定使用服务端证书创建 TLS credentials, 证书以及信任的 CA 更新后自动重新加载
+	if !x.tlsInfo.IsEmpty() {
+		reloader, err := secure.NewCertReloader(x.tlsInfo)
+		if err != nil {
+			log.Errorf("failed to create xds credentials: %v", err)
+			errCh <- err
+			return
+		}
+		reloader.Start()
+		x.certReloader = reloader
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(reloader.ServerTLSConfig())))
+	}
 	grpcServer := grpc.NewServer(grpcOptions...)
 	x.server = grpcServer
 	address := fmt.Sprintf("%v:%v", x.listenIP, x.listenPort)
@@ -155,6 +248,12 @@ func (x *XDSServer) Run(errCh chan error) {
 	}
 
 	registerServer(grpcServer, srv, x)
+	if x.ca != nil {
+		go x.startCertRotateTask(x.ctx, x.ca.RotateInterval())
+	}
+	if x.rls != nil {
+		go x.rls.Run(x.ctx)
+	}
 	log.Infof("management server listening on %d\n", x.listenPort)
 	if err = grpcServer.Serve(listener); err != nil {
 		log.Errorf("%v", err)
@@ -175,6 +274,24 @@ func registerServer(grpcServer *grpc.Server, server serverv3.Server, x *XDSServe
 	secretservice.RegisterSecretDiscoveryServiceServer(grpcServer, server)
 	runtimeservice.RegisterRuntimeDiscoveryServiceServer(grpcServer, server)
 	healthservice.RegisterHealthDiscoveryServiceServer(grpcServer, x)
+	if x.rls != nil {
+		rlsv3.RegisterRateLimitServiceServer(grpcServer, x.rls)
+	}
+}
+
+// fetchRateLimitRules 获取服务的限流规则, 供全局限流服务使用
+func (x *XDSServer) fetchRateLimitRules(svcKey model.ServiceKey) []*model.RateLimit {
+	rules, _ := x.namingServer.Cache().RateLimit().GetRateLimitRules(svcKey)
+	return rules
+}
+
+// fetchClusterNodes 获取北极星集群的节点列表, 全局限流服务按照节点的权重分摊配额
+func (x *XDSServer) fetchClusterNodes(namespace, service string) ([]*model.Instance, error) {
+	svc := x.namingServer.Cache().Service().GetServiceByName(service, namespace)
+	if svc == nil {
+		return nil, errors.New("polaris cluster service not found")
+	}
+	return x.namingServer.Cache().Instance().GetInstancesByServiceID(svc.ID), nil
 }
 
 // Stop 停止服务
@@ -183,6 +300,9 @@ func (x *XDSServer) Stop() {
 	if x.server != nil {
 		x.server.Stop()
 	}
+	if x.certReloader != nil {
+		x.certReloader.Stop()
+	}
 }
 
 // Restart 重启服务
@@ -322,6 +442,117 @@ func (x *XDSServer) startSynTask(ctx context.Context) {
 	}
 }
 
+// verifyWorkloadCredential 校验 Envoy 携带的北极星访问凭据, 凭据需要有效且未被吊销, 并且对应的用户或用户组
+// 拥有该服务的写权限, 才能获取该服务的工作负载证书
+func (x *XDSServer) verifyWorkloadCredential(id ca.Identity, token string) error {
+	if token == "" {
+		return errors.New("access token is empty")
+	}
+	reqCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, token)
+	return x.checkServicePermission(reqCtx, model.ServiceKey{Namespace: id.Namespace, Name: id.Service},
+		model.Modify, "FetchWorkloadCert")
+}
+
+// authorizeRateLimit 校验全局限流服务的调用方是否可以使用该服务的配额. 双向 tls 下客户端证书为该服务的
+// 工作负载证书时直接放行, 否则需要携带北极星访问凭据 (token 或者客户端证书映射的 principal), 并且拥有该服务的读权限
+func (x *XDSServer) authorizeRateLimit(ctx context.Context, svcKey model.ServiceKey) error {
+	var certs []*x509.Certificate
+	if pr, ok := peer.FromContext(ctx); ok && x.tlsInfo.IsMutual() {
+		if tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo); ok {
+			certs = tlsInfo.State.PeerCertificates
+		}
+	}
+	if x.ca != nil && len(certs) > 0 {
+		id := ca.Identity{Namespace: svcKey.Namespace, Service: svcKey.Name}
+		if secure.CertIdentity(certs[0]) == id.SpiffeID(x.ca.TrustDomain()) {
+			return nil
+		}
+	}
+
+	reqCtx := context.Background()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if tokens := md.Get(utils.HeaderAuthTokenKey); len(tokens) > 0 && tokens[0] != "" {
+			reqCtx = context.WithValue(reqCtx, utils.ContextAuthTokenKey, tokens[0])
+		}
+	}
+	if principal := x.tlsInfo.MapPrincipal(certs); principal != "" {
+		reqCtx = context.WithValue(reqCtx, utils.ContextCertPrincipalKey, principal)
+	}
+	if utils.ParseAuthToken(reqCtx) == "" && utils.ParseCertPrincipal(reqCtx) == "" {
+		return errors.New("ratelimit request without credential")
+	}
+	return x.checkServicePermission(reqCtx, svcKey, model.Read, "ShouldRateLimit")
+}
+
+// checkServicePermission 校验请求携带的北极星访问凭据, 凭据需要有效、未被吊销并且不是匿名用户,
+// 对应的用户或用户组需要拥有该服务的 op 权限
+func (x *XDSServer) checkServicePermission(reqCtx context.Context, svcKey model.ServiceKey,
+	op model.ResourceOperation, method string) error {
+	strategySvr, err := auth.GetStrategyServer()
+	if err != nil {
+		return err
+	}
+	userSvr, err := auth.GetUserServer()
+	if err != nil {
+		return err
+	}
+	checker := strategySvr.GetAuthChecker()
+	// 未开启客户端鉴权时无法确认凭据对应的服务身份
+	if !checker.IsOpenClientAuth() {
+		return errors.New("client auth is not open")
+	}
+	svc := x.namingServer.Cache().Service().GetServiceByName(svcKey.Name, svcKey.Namespace)
+	if svc == nil {
+		return fmt.Errorf("service %s/%s not found", svcKey.Namespace, svcKey.Name)
+	}
+
+	authCtx := model.NewAcquireContext(
+		model.WithRequestContext(reqCtx),
+		model.WithOperation(op),
+		model.WithModule(model.DiscoverModule),
+		model.WithMethod(method),
+		model.WithFromClient(),
+		model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+			apisecurity.ResourceType_Services: {
+				{ID: svc.ID, Owner: svc.Owner},
+			},
+		}),
+	)
+	if err := userSvr.CheckCredential(authCtx); err != nil {
+		return err
+	}
+	attachVal, _ := authCtx.GetAttachment(model.TokenDetailInfoKey)
+	operator, ok := attachVal.(auth.OperatorInfo)
+	if !ok || operator.Anonymous || operator.Disable {
+		return model.ErrorTokenInvalid
+	}
+	if !checker.AllowResourceOperate(authCtx, &model.ResourceOpInfo{
+		ResourceType: apisecurity.ResourceType_Services,
+		Namespace:    svcKey.Namespace,
+		ResourceName: svcKey.Name,
+		ResourceID:   svc.ID,
+		Operation:    op,
+	}) {
+		return fmt.Errorf("no permission on service %s/%s", svcKey.Namespace, svcKey.Name)
+	}
+	return nil
+}
+
+// startCertRotateTask 定期轮转即将过期的工作负载证书
+func (x *XDSServer) startCertRotateTask(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			x.resourceGenerator.rotateEnvoyNodeCerts()
+		case <-ctx.Done():
+			log.Info("stop rotate xds workload cert task")
+			return
+		}
+	}
+}
+
 func (x *XDSServer) fetchCurrentServices() ServiceInfos {
 	return x.registryInfo.Load()
 }
@@ -440,6 +671,16 @@ func (x *XDSServer) getRegistryInfoWithCache(ctx context.Context,
 				svc.FaultDetectRevision = faultDetectResp.FaultDetector.Revision
 				svc.FaultDetect = faultDetectResp.FaultDetector
 			}
+
+			// 获取泳道配置
+			laneResp := x.namingServer.GetLaneRuleWithCache(ctx, s)
+			if laneResp.GetCode().GetValue() != api.ExecuteSuccess {
+				log.Errorf("[XDSV3] error sync lane for %s, info : %s",
+					svc.Name, laneResp.Info.GetValue())
+				return fmt.Errorf("error sync lane for %s", svc.Name)
+			}
+			svc.LaneRevision = laneResp.GetService().GetRevision().GetValue()
+			svc.Lanes = laneResp.GetLanes()
 		}
 	}
 
@@ -466,8 +707,18 @@ func (x *XDSServer) DebugHandlers() []model.DebugHandler {
 		},
 		{
 			Path:    "/debug/apiserver/xds/resources",
-			Desc:    "Query the list of Envoy nodes, eg. /debug/apiserver/xds/resources?type=&nodeId=, type is [eds,cds,rds,vhds,lds]",
+			Desc:    "Query the list of Envoy nodes, eg. /debug/apiserver/xds/resources?type=&nodeId=, type is [eds,cds,rds,vhds,lds,sds]",
 			Handler: x.listXDSResource,
 		},
+		{
+			Path:    "/debug/apiserver/xds/ca",
+			Desc:    "Query the root cert and issued workload certs of the built-in CA",
+			Handler: x.listCACerts,
+		},
+		{
+			Path:    "/debug/apiserver/xds/service_config",
+			Desc:    "Query the generated outbound envoy config of a service, eg. /debug/apiserver/xds/service_config?namespace=&service=",
+			Handler: x.getServiceXDSConfig,
+		},
 	}
 }
@@ -111,5 +111,8 @@ func (vhds *VHDSBuilder) makeSidecarOutBoundRoutes(trafficDirection corev3.Traff
 	} else {
 		routes = append(routes, matchAllRoute)
 	}
-	return routes
+	// 泳道规则转换为按照染色请求头匹配的路由
+	routes = resource.MakeLaneRoutes(trafficDirection, routes, serviceInfo, opt)
+	// 方法级熔断规则转换为路由级别的重试以及超时
+	return resource.MakeCircuitBreakerRoutes(routes, serviceInfo)
 }
@@ -67,6 +67,15 @@ type StrategyServer interface {
 	GetAuthChecker() AuthChecker
 	// AfterResourceOperation 操作完资源的后置处理逻辑
 	AfterResourceOperation(afterCtx *model.AcquireContext) error
+	// StrategyConditionOperator
+	StrategyConditionOperator
+}
+
+type StrategyConditionOperator interface {
+	// UpdateStrategyConditions 修改鉴权策略的生效条件
+	UpdateStrategyConditions(ctx context.Context, req *model.StrategyConditionsRequest) *model.StrategyConditionsResponse
+	// ExplainPermission 解释某个 principal 对资源的操作为什么被允许或者拒绝
+	ExplainPermission(ctx context.Context, req *model.AuthExplainRequest) *model.AuthExplainResponse
 }
 
 // UserServer 用户数据管理 server
@@ -76,9 +85,19 @@ type UserServer interface {
 	// Name 用户数据管理server名称
 	Name() string
 	// Login 登录动作
-	Login(req *apisecurity.LoginRequest) *apiservice.Response
+	Login(ctx context.Context, req *apisecurity.LoginRequest) *apiservice.Response
 	// CheckCredential 检查当前操作用户凭证
 	CheckCredential(authCtx *model.AcquireContext) error
+	// GetLoginRedirect 获取外部身份提供者的登录页面地址，以及需要写入浏览器 cookie 的绑定值
+	GetLoginRedirect(ctx context.Context, provider string) (string, string, error)
+	// LoginCallback 外部身份提供者登录完成后的回调，binding 为发起登录时写入浏览器 cookie 的绑定值
+	LoginCallback(ctx context.Context, provider, code, state, binding string) *apiservice.Response
+	// APITokenOperator
+	APITokenOperator
+	// LoginSecurityOperator
+	LoginSecurityOperator
+	// ParsePrincipal 解析 token 对应的操作者，只校验 token 是否有效，不做权限检查，返回值格式参考 PrincipalName
+	ParsePrincipal(token string) (string, error)
 	// UserOperator
 	UserOperator
 	// GroupOperator
@@ -106,6 +125,24 @@ type UserOperator interface {
 	ResetUserToken(ctx context.Context, user *apisecurity.User) *apiservice.Response
 }
 
+type APITokenOperator interface {
+	// CreateAPIToken 为用户或者用户组创建具名 API token
+	CreateAPIToken(ctx context.Context, token *model.APIToken) *model.APITokenResponse
+	// GetAPITokens 查询 API token 列表
+	GetAPITokens(ctx context.Context, query map[string]string) *model.APITokenBatchResponse
+	// RevokeAPIToken 吊销 API token
+	RevokeAPIToken(ctx context.Context, id string) *model.APITokenResponse
+}
+
+type LoginSecurityOperator interface {
+	// ChangeExpiredPassword 使用原密码修改已经过期的密码
+	ChangeExpiredPassword(ctx context.Context, req *model.ChangeExpiredPasswordRequest) *apiservice.Response
+	// UnlockLogin 解除用户或者来源IP的登录锁定
+	UnlockLogin(ctx context.Context, req *model.LoginUnlockRequest) *model.LoginLockResponse
+	// GetLoginLocks 查询用户或者来源IP的登录锁定状态
+	GetLoginLocks(ctx context.Context, query map[string]string) *model.LoginLockResponse
+}
+
 type GroupOperator interface {
 	// CreateGroup 创建用户组
 	CreateGroup(ctx context.Context, group *apisecurity.UserGroup) *apiservice.Response
@@ -158,6 +195,8 @@ type OperatorInfo struct {
 	Disable bool
 	// 是否属于匿名操作者
 	Anonymous bool
+	// APIToken 如果当前是具名 API token，该值才能有信息
+	APIToken *model.APIToken
 }
 
 func NewAnonymous() OperatorInfo {
@@ -174,6 +213,14 @@ func IsEmptyOperator(t OperatorInfo) bool {
 	return t.Origin == "" || t.Anonymous
 }
 
+// PrincipalName 操作者的唯一名称，格式为 user:{用户名} 或者 group:{用户组名}，用于限流等按照操作者统计的场景
+func PrincipalName(role model.PrincipalType, name string) string {
+	if role == model.PrincipalGroup {
+		return "group:" + name
+	}
+	return "user:" + name
+}
+
 // IsSubAccount 当前 token 对应的账户类型
 func IsSubAccount(t OperatorInfo) bool {
 	return t.Role == model.SubAccountUserRole
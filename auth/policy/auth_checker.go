@@ -18,6 +18,10 @@
 package policy
 
 import (
+	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/pkg/errors"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	"go.uber.org/zap"
@@ -97,9 +101,8 @@ func (d *DefaultAuthChecker) AllowResourceOperate(ctx *model.AcquireContext, opI
 			return model.PrincipalGroup
 		}(),
 	}
-
-	editable := d.cacheMgr.AuthStrategy().IsResourceEditable(principal, opInfo.ResourceType, opInfo.ResourceID)
-	return editable
+	// 与写操作的鉴权使用同一个判断逻辑，策略的生效条件以及资源标签选择器同样生效
+	return d.explain(principal, opInfo.ResourceType, opInfo.ResourceID, requestAttributes(ctx)).Allowed
 }
 
 // CheckClientPermission 执行检查客户端动作判断是否有权限，并且对 RequestContext 注入操作者数据
@@ -201,13 +204,10 @@ func (d *DefaultAuthChecker) CheckPermission(authCtx *model.AcquireContext) (boo
 
 // doCheckPermission 执行权限检查
 func (d *DefaultAuthChecker) doCheckPermission(authCtx *model.AcquireContext) (bool, error) {
-
-	var checkNamespace, checkSvc, checkCfgGroup bool
-
-	reqRes := authCtx.GetAccessResources()
-	nsResEntries := reqRes[apisecurity.ResourceType_Namespaces]
-	svcResEntries := reqRes[apisecurity.ResourceType_Services]
-	cfgResEntries := reqRes[apisecurity.ResourceType_ConfigGroups]
+	// TODO 后续可针对读写操作进行鉴权, 并且可以针对具体的方法调用进行鉴权控制
+	if authCtx.GetOperation() == model.Read {
+		return true, nil
+	}
 
 	principleID, _ := authCtx.GetAttachments()[model.OperatorIDKey].(string)
 	principleType, _ := authCtx.GetAttachments()[model.OperatorPrincipalType].(model.PrincipalType)
@@ -215,35 +215,193 @@ func (d *DefaultAuthChecker) doCheckPermission(authCtx *model.AcquireContext) (b
 		PrincipalID:   principleID,
 		PrincipalRole: principleType,
 	}
-	checkNamespace = d.checkAction(p, apisecurity.ResourceType_Namespaces, nsResEntries, authCtx)
-	checkSvc = d.checkAction(p, apisecurity.ResourceType_Services, svcResEntries, authCtx)
-	checkCfgGroup = d.checkAction(p, apisecurity.ResourceType_ConfigGroups, cfgResEntries, authCtx)
+	attrs := requestAttributes(authCtx)
 
-	checkAllResEntries := checkNamespace && checkSvc && checkCfgGroup
+	reqRes := authCtx.GetAccessResources()
+	for _, resType := range checkResourceTypes {
+		for _, entry := range reqRes[resType] {
+			if ret := d.explain(p, resType, entry.ID, attrs); !ret.Allowed {
+				log.Debug("[Auth][Checker] permission denied", utils.RequestID(authCtx.GetRequestContext()),
+					zap.String("resource", entry.ID), zap.String("reason", ret.Reason))
+				return false, ErrorNotPermission
+			}
+		}
+	}
+	return true, nil
+}
 
-	var err error
-	if !checkAllResEntries {
-		err = ErrorNotPermission
+// requestAttributes 从请求上下文中解析参与策略条件计算的请求属性
+func requestAttributes(authCtx *model.AcquireContext) *model.RequestAttributes {
+	attrs := &model.RequestAttributes{
+		SourceIP: utils.ParseClientIP(authCtx.GetRequestContext()),
+		Protocol: model.ProtocolConsole,
+		Time:     time.Now(),
 	}
-	return checkAllResEntries, err
+	if authCtx.IsFromClient() {
+		attrs.Protocol = model.ProtocolClient
+	}
+	return attrs
 }
 
-// checkAction 检查操作是否和策略匹配
-func (d *DefaultAuthChecker) checkAction(principal model.Principal,
-	resType apisecurity.ResourceType, resources []model.ResourceEntry, ctx *model.AcquireContext) bool {
-	// TODO 后续可针对读写操作进行鉴权, 并且可以针对具体的方法调用进行鉴权控制
+var checkResourceTypes = []apisecurity.ResourceType{
+	apisecurity.ResourceType_Namespaces,
+	apisecurity.ResourceType_Services,
+	apisecurity.ResourceType_ConfigGroups,
+}
 
-	switch ctx.GetOperation() {
-	case model.Read:
-		return true
-	default:
-		for _, entry := range resources {
-			if !d.cacheMgr.AuthStrategy().IsResourceEditable(principal, resType, entry.ID) {
-				return false
+// explain 计算 principal 是否可以编辑某个资源，并给出每个关联策略的判断依据
+//
+//	case 1. 资源没有关联任何策略，并且没有被任何策略的资源标签选择器选中，任何人都可以编辑
+//	case 2. principal 以及其所在的用户组关联的策略中，只要有一个策略包含该资源(明确的资源ID、* 或者标签选择器)
+//	        并且满足策略的生效条件，即可编辑
+func (d *DefaultAuthChecker) explain(principal model.Principal, resType apisecurity.ResourceType,
+	resID string, attrs *model.RequestAttributes) *model.AuthExplainResult {
+	ret := &model.AuthExplainResult{}
+	var (
+		labels       map[string]string
+		labelsLoaded bool
+	)
+	resourceLabels := func() map[string]string {
+		if !labelsLoaded {
+			labels = d.resourceLabels(resType, resID)
+			labelsLoaded = true
+		}
+		return labels
+	}
+	if !d.isResourceGoverned(resType, resID, resourceLabels) {
+		ret.Allowed = true
+		ret.Reason = "resource is not linked to any strategy, everyone can operate it"
+		return ret
+	}
+
+	visited := map[string]struct{}{}
+	for _, item := range d.principalsWithGroups(principal) {
+		for _, rule := range d.principalStrategies(item) {
+			if _, ok := visited[rule.ID]; ok {
+				continue
 			}
+			visited[rule.ID] = struct{}{}
+
+			explain := &model.StrategyExplain{
+				ID:        rule.ID,
+				Name:      rule.Name,
+				Principal: d.principalName(item),
+			}
+			ret.Strategies = append(ret.Strategies, explain)
+
+			covered, how := coverResource(rule, resType, resID, resourceLabels)
+			if !covered {
+				explain.Reason = "strategy does not contain the resource"
+				continue
+			}
+			if ok, reason := rule.Conditions.Match(attrs); !ok {
+				explain.Reason = "conditions not match: " + reason
+				continue
+			}
+			explain.Matched = true
+			explain.Reason = how
+			if !ret.Allowed {
+				ret.Allowed = true
+				ret.Reason = fmt.Sprintf("allowed by strategy %s(%s)", rule.Name, rule.ID)
+			}
+		}
+	}
+	if !ret.Allowed {
+		ret.Reason = "no strategy of the principal allows to operate the resource"
+	}
+	return ret
+}
+
+// isResourceGoverned 资源是否受鉴权策略保护，策略明确包含该资源或者策略的资源标签选择器选中了该资源
+func (d *DefaultAuthChecker) isResourceGoverned(resType apisecurity.ResourceType, resID string,
+	labels func() map[string]string) bool {
+	if d.cacheMgr.AuthStrategy().IsResourceLinkStrategy(resType, resID) {
+		return true
+	}
+	for _, rule := range d.cacheMgr.AuthStrategy().GetSelectorStrategies() {
+		if rule.Conditions.SelectResource(resType, labels()) {
+			return true
+		}
+	}
+	return false
+}
+
+// principalsWithGroups 用户需要同时计算其所在用户组关联的策略
+func (d *DefaultAuthChecker) principalsWithGroups(principal model.Principal) []model.Principal {
+	principals := []model.Principal{principal}
+	if principal.PrincipalRole != model.PrincipalUser {
+		return principals
+	}
+	for _, groupID := range d.cacheMgr.User().GetUserLinkGroupIds(principal.PrincipalID) {
+		principals = append(principals, model.Principal{
+			PrincipalID:   groupID,
+			PrincipalRole: model.PrincipalGroup,
+		})
+	}
+	return principals
+}
+
+func (d *DefaultAuthChecker) principalStrategies(principal model.Principal) []*model.StrategyDetail {
+	if principal.PrincipalRole == model.PrincipalUser {
+		return d.cacheMgr.AuthStrategy().GetStrategyDetailsByUID(principal.PrincipalID)
+	}
+	return d.cacheMgr.AuthStrategy().GetStrategyDetailsByGroupID(principal.PrincipalID)
+}
+
+func (d *DefaultAuthChecker) principalName(principal model.Principal) string {
+	name := principal.PrincipalID
+	if principal.PrincipalRole == model.PrincipalUser {
+		if user := d.cacheMgr.User().GetUserByID(principal.PrincipalID); user != nil {
+			name = user.Name
 		}
+	} else if group := d.cacheMgr.User().GetGroup(principal.PrincipalID); group != nil {
+		name = group.Name
+	}
+	return auth.PrincipalName(principal.PrincipalRole, name)
+}
+
+// resourceLabels 获取资源的标签，目前只有服务以及配置分组支持标签
+func (d *DefaultAuthChecker) resourceLabels(resType apisecurity.ResourceType, resID string) map[string]string {
+	switch resType {
+	case apisecurity.ResourceType_Services:
+		if svc := d.cacheMgr.Service().GetServiceByID(resID); svc != nil {
+			return svc.Meta
+		}
+	case apisecurity.ResourceType_ConfigGroups:
+		id, err := strconv.ParseUint(resID, 10, 64)
+		if err != nil {
+			return nil
+		}
+		if group := d.cacheMgr.ConfigGroup().GetGroupByID(id); group != nil {
+			return group.Metadata
+		}
+	}
+	return nil
+}
+
+// coverResource 策略是否包含该资源
+func coverResource(rule *model.StrategyDetail, resType apisecurity.ResourceType, resID string,
+	labels func() map[string]string) (bool, string) {
+	hasAll := false
+	for _, res := range rule.Resources {
+		if res.ResType != int32(resType) {
+			continue
+		}
+		if res.ResID == resID {
+			return true, "strategy contains the resource"
+		}
+		if res.ResID == "*" {
+			hasAll = true
+		}
+	}
+	if hasAll {
+		return true, "strategy contains all resources of the type"
+	}
+	if rule.Conditions != nil && len(rule.Conditions.ResourceSelectors) != 0 &&
+		rule.Conditions.SelectResource(resType, labels()) {
+		return true, "resource labels match the strategy resource selector"
 	}
-	return true
+	return false, ""
 }
 
 func (d *DefaultAuthChecker) SetCacheMgr(mgr cachetypes.CacheManager) {
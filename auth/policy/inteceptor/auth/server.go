@@ -126,6 +126,25 @@ func (svr *Server) GetPrincipalResources(ctx context.Context, query map[string]s
 	return svr.nextSvr.GetPrincipalResources(ctx, query)
 }
 
+// UpdateStrategyConditions 修改鉴权策略的生效条件
+func (svr *Server) UpdateStrategyConditions(ctx context.Context,
+	req *model.StrategyConditionsRequest) *model.StrategyConditionsResponse {
+	ctx, rsp := svr.verifyAuth(ctx, WriteOp, MustOwner)
+	if rsp != nil {
+		return &model.StrategyConditionsResponse{Code: rsp.GetCode().GetValue(), Info: rsp.GetInfo().GetValue()}
+	}
+	return svr.nextSvr.UpdateStrategyConditions(ctx, req)
+}
+
+// ExplainPermission 解释某个 principal 对资源的操作为什么被允许或者拒绝
+func (svr *Server) ExplainPermission(ctx context.Context, req *model.AuthExplainRequest) *model.AuthExplainResponse {
+	ctx, rsp := svr.verifyAuth(ctx, ReadOp, MustOwner)
+	if rsp != nil {
+		return &model.AuthExplainResponse{Code: rsp.GetCode().GetValue(), Info: rsp.GetInfo().GetValue()}
+	}
+	return svr.nextSvr.ExplainPermission(ctx, req)
+}
+
 // GetAuthChecker 获取鉴权检查器
 func (svr *Server) GetAuthChecker() auth.AuthChecker {
 	return svr.nextSvr.GetAuthChecker()
@@ -0,0 +1,206 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// UpdateStrategyConditions 修改鉴权策略的生效条件，conditions 为空时清除生效条件
+func (svr *Server) UpdateStrategyConditions(ctx context.Context,
+	req *model.StrategyConditionsRequest) *model.StrategyConditionsResponse {
+	requestID := utils.ParseRequestID(ctx)
+	if req == nil || req.ID == "" {
+		return newStrategyConditionsResponse(apimodel.Code_InvalidParameter, errors.New("strategy id is empty"), nil)
+	}
+	if err := req.Conditions.Parse(); err != nil {
+		return newStrategyConditionsResponse(apimodel.Code_InvalidParameter, err, nil)
+	}
+
+	strategy, err := svr.storage.GetStrategyDetail(req.ID)
+	if err != nil {
+		log.Error("[Auth][Strategy] get strategy from store", utils.ZapRequestID(requestID), zap.Error(err))
+		return newStrategyConditionsResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if strategy == nil {
+		return newStrategyConditionsResponse(apimodel.Code_NotFoundAuthStrategyRule, nil, nil)
+	}
+	if !canOperateStrategy(ctx, strategy) {
+		log.Error("[Auth][Strategy] modify strategy conditions denied, current user not owner",
+			utils.ZapRequestID(requestID), zap.String("user", utils.ParseUserID(ctx)),
+			zap.String("owner", strategy.Owner), zap.String("strategy", strategy.ID))
+		return newStrategyConditionsResponse(apimodel.Code_NotAllowedAccess, nil, nil)
+	}
+
+	if req.Conditions.IsEmpty() {
+		req.Conditions = nil
+	}
+	if err := svr.storage.UpdateStrategyConditions(req.ID, req.Conditions); err != nil {
+		log.Error("[Auth][Strategy] update strategy conditions into store", utils.ZapRequestID(requestID),
+			zap.Error(err))
+		return newStrategyConditionsResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+
+	log.Info("[Auth][Strategy] update strategy conditions", utils.ZapRequestID(requestID),
+		zap.String("id", req.ID))
+	svr.RecordHistory(strategyConditionsRecordEntry(ctx, strategy, req))
+
+	return newStrategyConditionsResponse(apimodel.Code_ExecuteSuccess, nil, req)
+}
+
+// ExplainPermission 解释 principal 对某个资源的操作为什么被允许或者拒绝
+func (svr *Server) ExplainPermission(ctx context.Context, req *model.AuthExplainRequest) *model.AuthExplainResponse {
+	resType, err := checkExplainRequest(req)
+	if err != nil {
+		return newAuthExplainResponse(apimodel.Code_InvalidParameter, err, nil)
+	}
+
+	var owner string
+	switch req.PrincipalType {
+	case model.PrincipalUser:
+		user := svr.cacheMgr.User().GetUserByID(req.PrincipalID)
+		if user == nil {
+			return newAuthExplainResponse(apimodel.Code_NotFoundUser, nil, nil)
+		}
+		owner = user.Owner
+		if owner == "" {
+			owner = user.ID
+		}
+	case model.PrincipalGroup:
+		group := svr.cacheMgr.User().GetGroup(req.PrincipalID)
+		if group == nil {
+			return newAuthExplainResponse(apimodel.Code_NotFoundUserGroup, nil, nil)
+		}
+		owner = group.Owner
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole && owner != utils.ParseOwnerID(ctx) {
+		return newAuthExplainResponse(apimodel.Code_NotAllowedAccess, nil, nil)
+	}
+
+	if model.APITokenActions[req.Operation] == model.Read {
+		return newAuthExplainResponse(apimodel.Code_ExecuteSuccess, nil, &model.AuthExplainResult{
+			Allowed: true,
+			Reason:  "read operation is always allowed",
+		})
+	}
+	if req.Time.IsZero() {
+		req.Time = time.Now()
+	}
+	attrs := &model.RequestAttributes{
+		SourceIP: req.SourceIP,
+		Protocol: req.Protocol,
+		Time:     req.Time,
+	}
+	principal := model.Principal{
+		PrincipalID:   req.PrincipalID,
+		PrincipalRole: req.PrincipalType,
+	}
+	return newAuthExplainResponse(apimodel.Code_ExecuteSuccess, nil,
+		svr.checker.explain(principal, resType, req.ResourceID, attrs))
+}
+
+// checkExplainRequest 检查解释请求的参数，并补充默认值
+func checkExplainRequest(req *model.AuthExplainRequest) (apisecurity.ResourceType, error) {
+	if req == nil {
+		return 0, errors.New("request is empty")
+	}
+	if req.PrincipalID == "" {
+		return 0, errors.New("principal_id is empty")
+	}
+	if req.PrincipalType != model.PrincipalUser && req.PrincipalType != model.PrincipalGroup {
+		return 0, fmt.Errorf("invalid principal_type %d", req.PrincipalType)
+	}
+	resType, ok := apisecurity.ResourceType_value[req.ResourceType]
+	if !ok {
+		return 0, fmt.Errorf("invalid resource_type %s", req.ResourceType)
+	}
+	if req.ResourceID == "" {
+		return 0, errors.New("resource_id is empty")
+	}
+	if req.Operation == "" {
+		req.Operation = model.APITokenActionModify
+	}
+	if _, ok := model.APITokenActions[req.Operation]; !ok {
+		return 0, fmt.Errorf("invalid operation %s", req.Operation)
+	}
+	if req.Protocol == "" {
+		req.Protocol = model.ProtocolConsole
+	}
+	if req.Protocol != model.ProtocolConsole && req.Protocol != model.ProtocolClient {
+		return 0, fmt.Errorf("invalid protocol %s", req.Protocol)
+	}
+	return apisecurity.ResourceType(resType), nil
+}
+
+// canOperateStrategy 鉴权策略只能被 admin 或者自己的 owner 对应的用户修改
+func canOperateStrategy(ctx context.Context, strategy *model.StrategyDetail) bool {
+	if authcommon.ParseUserRole(ctx) == model.AdminUserRole {
+		return true
+	}
+	return utils.ParseIsOwner(ctx) && utils.ParseUserID(ctx) == strategy.Owner
+}
+
+func newStrategyConditionsResponse(code apimodel.Code, err error,
+	req *model.StrategyConditionsRequest) *model.StrategyConditionsResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	resp := &model.StrategyConditionsResponse{Code: uint32(code), Info: info}
+	if req != nil {
+		resp.ID = req.ID
+		resp.Conditions = req.Conditions
+	}
+	return resp
+}
+
+func newAuthExplainResponse(code apimodel.Code, err error, ret *model.AuthExplainResult) *model.AuthExplainResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.AuthExplainResponse{Code: uint32(code), Info: info, Result: ret}
+}
+
+// strategyConditionsRecordEntry 生成修改鉴权策略生效条件的操作记录
+func strategyConditionsRecordEntry(ctx context.Context, strategy *model.StrategyDetail,
+	req *model.StrategyConditionsRequest) *model.RecordEntry {
+	detail, _ := json.Marshal(req)
+	return &model.RecordEntry{
+		ResourceType:  model.RAuthStrategy,
+		ResourceName:  fmt.Sprintf("%s(%s)", strategy.Name, strategy.ID),
+		OperationType: model.OUpdate,
+		Operator:      utils.ParseOperator(ctx),
+		Detail:        string(detail),
+		HappenTime:    time.Now(),
+	}
+}
@@ -0,0 +1,241 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/policy"
+	defaultuser "github.com/polarismesh/polaris/auth/user"
+	"github.com/polarismesh/polaris/cache"
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_DefaultAuthChecker_StrategyConditions(t *testing.T) {
+	reset(false)
+	eventhub.InitEventHub()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := createMockUser(10)
+	groups := createMockUserGroup(users)
+
+	namespaces := createMockNamespace(len(users)+len(groups)+10, users[0].ID)
+	services := createMockService(namespaces)
+	serviceMap := convertServiceSliceToMap(services)
+	strategies, _ := createMockStrategy(users, groups, services[:len(users)+len(groups)])
+
+	// users[1] 只能在 10.0.0.0/8 网段内操作 services[1]
+	strategies[1].Conditions = parseConditions(t, `{"source_ips": ["10.0.0.0/8"]}`)
+	// users[2] 可以操作所有带有 team=payments 标签的服务
+	strategies[2].Conditions = parseConditions(t,
+		`{"resource_selectors": [{"resource_type": "Services", "labels": {"team": "payments"}}]}`)
+	services[3].Meta = map[string]string{"team": "payments"}
+	// 只被标签选择器选中、没有被任何策略明确包含的服务同样受保护
+	selectedIndex := len(users) + len(groups) + 2
+	services[selectedIndex].Meta = map[string]string{"team": "payments"}
+
+	cfg, storage := initCache(ctrl)
+
+	storage.EXPECT().GetUsersForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(users, nil)
+	storage.EXPECT().GetGroupsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(groups, nil)
+	storage.EXPECT().GetStrategyDetailsForCache(gomock.Any(), gomock.Any()).AnyTimes().Return(strategies, nil)
+	storage.EXPECT().GetMoreNamespaces(gomock.Any()).AnyTimes().Return(namespaces, nil)
+	storage.EXPECT().GetMoreServices(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(serviceMap, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cacheMgr, err := cache.TestCacheInitialize(ctx, cfg, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		cacheMgr.Close()
+	})
+	if err := cacheMgr.OpenResourceCache([]cachetypes.ConfigEntry{
+		{Name: cachetypes.InstanceName},
+		{Name: cachetypes.ServiceName},
+	}...); err != nil {
+		t.Fatal(err)
+	}
+
+	_, proxySvr, err := defaultuser.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxySvr.Initialize(&auth.Config{
+		User: &auth.UserConfig{
+			Name: auth.DefaultUserMgnPluginName,
+			Option: map[string]interface{}{
+				"salt": "polarismesh@2021",
+			},
+		},
+	}, storage, cacheMgr)
+
+	innerSvr, svr, err := policy.BuildServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svr.Initialize(&auth.Config{
+		Strategy: &auth.StrategyConfig{
+			Name: auth.DefaultPolicyPluginName,
+		},
+	}, storage, cacheMgr, proxySvr); err != nil {
+		t.Fatal(err)
+	}
+	checker := svr.GetAuthChecker()
+
+	_ = cacheMgr.TestUpdate()
+
+	checkService := func(user *model.User, svc *model.Service, clientAddr string) error {
+		ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)
+		ctx = context.WithValue(ctx, utils.ContextClientAddress, clientAddr)
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithMethod("Test_DefaultAuthChecker_StrategyConditions"),
+			model.WithOperation(model.Modify),
+			model.WithModule(model.DiscoverModule),
+			model.WithAccessResources(map[apisecurity.ResourceType][]model.ResourceEntry{
+				apisecurity.ResourceType_Services: {
+					{
+						ID:    svc.ID,
+						Owner: svc.Owner,
+					},
+				},
+			}),
+		)
+		_, err := checker.CheckConsolePermission(authCtx)
+		return err
+	}
+
+	t.Run("来源IP满足策略条件", func(t *testing.T) {
+		assert.NoError(t, checkService(users[1], services[1], "10.1.2.3:8080"))
+	})
+
+	t.Run("来源IP不满足策略条件", func(t *testing.T) {
+		assert.Error(t, checkService(users[1], services[1], "192.168.1.1:8080"))
+	})
+
+	t.Run("资源标签选择器授权", func(t *testing.T) {
+		assert.NoError(t, checkService(users[2], services[3], "127.0.0.1:8080"))
+		assert.Error(t, checkService(users[4], services[3], "127.0.0.1:8080"))
+	})
+
+	t.Run("资源只被标签选择器选中", func(t *testing.T) {
+		assert.NoError(t, checkService(users[2], services[selectedIndex], "127.0.0.1:8080"))
+		assert.Error(t, checkService(users[4], services[selectedIndex], "127.0.0.1:8080"))
+	})
+
+	// 控制台的可编辑标记与写操作的鉴权使用同一个判断逻辑
+	editable := func(user *model.User, svc *model.Service, clientAddr string) bool {
+		ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, user.Token)
+		ctx = context.WithValue(ctx, utils.ContextClientAddress, clientAddr)
+		authCtx := model.NewAcquireContext(model.WithRequestContext(ctx), model.WithModule(model.DiscoverModule))
+		assert.NoError(t, proxySvr.CheckCredential(authCtx))
+		return checker.AllowResourceOperate(authCtx, &model.ResourceOpInfo{
+			ResourceType: apisecurity.ResourceType_Services,
+			Namespace:    svc.Namespace,
+			ResourceName: svc.Name,
+			ResourceID:   svc.ID,
+			Operation:    model.Modify,
+		})
+	}
+
+	t.Run("可编辑标记-策略条件以及标签选择器", func(t *testing.T) {
+		assert.True(t, editable(users[1], services[1], "10.1.2.3:8080"))
+		assert.False(t, editable(users[1], services[1], "192.168.1.1:8080"))
+		assert.True(t, editable(users[2], services[selectedIndex], "127.0.0.1:8080"))
+		assert.False(t, editable(users[4], services[selectedIndex], "127.0.0.1:8080"))
+	})
+
+	ownerCtx := context.WithValue(context.Background(), utils.ContextOwnerIDKey, users[0].ID)
+	ownerCtx = context.WithValue(ownerCtx, utils.ContextUserRoleIDKey, model.OwnerUserRole)
+
+	t.Run("解释鉴权结果-条件不满足", func(t *testing.T) {
+		resp := innerSvr.ExplainPermission(ownerCtx, &model.AuthExplainRequest{
+			PrincipalID:   users[1].ID,
+			PrincipalType: model.PrincipalUser,
+			ResourceType:  apisecurity.ResourceType_Services.String(),
+			ResourceID:    services[1].ID,
+			SourceIP:      "192.168.1.1",
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.Code, resp.Info)
+		assert.False(t, resp.Result.Allowed)
+		var explain *model.StrategyExplain
+		for _, item := range resp.Result.Strategies {
+			if item.ID == strategies[1].ID {
+				explain = item
+			}
+		}
+		assert.NotNil(t, explain)
+		assert.False(t, explain.Matched)
+		assert.Contains(t, explain.Reason, "source ip")
+	})
+
+	t.Run("解释鉴权结果-允许", func(t *testing.T) {
+		resp := innerSvr.ExplainPermission(ownerCtx, &model.AuthExplainRequest{
+			PrincipalID:   users[2].ID,
+			PrincipalType: model.PrincipalUser,
+			ResourceType:  apisecurity.ResourceType_Services.String(),
+			ResourceID:    services[3].ID,
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.Code, resp.Info)
+		assert.True(t, resp.Result.Allowed)
+		assert.Contains(t, resp.Result.Reason, strategies[2].ID)
+	})
+
+	t.Run("解释鉴权结果-资源未关联策略", func(t *testing.T) {
+		freeIndex := len(users) + len(groups) + 1
+		resp := innerSvr.ExplainPermission(ownerCtx, &model.AuthExplainRequest{
+			PrincipalID:   users[1].ID,
+			PrincipalType: model.PrincipalUser,
+			ResourceType:  apisecurity.ResourceType_Services.String(),
+			ResourceID:    services[freeIndex].ID,
+		})
+		assert.True(t, resp.Result.Allowed)
+		assert.Equal(t, 0, len(resp.Result.Strategies))
+	})
+
+	t.Run("解释鉴权结果-参数错误", func(t *testing.T) {
+		resp := innerSvr.ExplainPermission(ownerCtx, &model.AuthExplainRequest{
+			PrincipalID:   users[1].ID,
+			PrincipalType: model.PrincipalUser,
+			ResourceType:  "Unknown",
+			ResourceID:    services[1].ID,
+		})
+		assert.Equal(t, uint32(apimodel.Code_InvalidParameter), resp.Code)
+	})
+}
+
+func parseConditions(t *testing.T, data string) *model.StrategyConditions {
+	conditions, err := model.ParseStrategyConditions(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conditions
+}
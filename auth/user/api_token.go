@@ -0,0 +1,426 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// apiTokenCacheTTL API token 在本地缓存的时间，吊销的 token 在其他节点上最多延迟该时间失效
+	apiTokenCacheTTL = 10 * time.Second
+	// apiTokenLastUsedInterval 最近使用时间的更新间隔，避免每次请求都写存储
+	apiTokenLastUsedInterval = time.Minute
+)
+
+// cachedAPIToken 本地缓存的 API token
+type cachedAPIToken struct {
+	token    *model.APIToken
+	loadTime time.Time
+	// lastUsed 最近一次使用的时间，单位为秒
+	lastUsed atomic.Int64
+}
+
+// CreateAPIToken 为用户或者用户组创建具名 API token，token 原文只会在本次请求中返回
+func (svr *Server) CreateAPIToken(ctx context.Context, req *model.APIToken) *model.APITokenResponse {
+	if err := checkCreateAPIToken(req); err != nil {
+		return newAPITokenResponse(apimodel.Code_InvalidParameter, err, nil)
+	}
+
+	switch req.PrincipalType {
+	case model.PrincipalUser:
+		user := svr.cacheMgr.User().GetUserByID(req.PrincipalID)
+		if user == nil {
+			return newAPITokenResponse(apimodel.Code_NotFoundUser, nil, nil)
+		}
+		req.Owner = user.Owner
+		if req.Owner == "" {
+			req.Owner = user.ID
+		}
+	case model.PrincipalGroup:
+		group := svr.cacheMgr.User().GetGroup(req.PrincipalID)
+		if group == nil {
+			return newAPITokenResponse(apimodel.Code_NotFoundUserGroup, nil, nil)
+		}
+		req.Owner = group.Owner
+	}
+
+	req.ID = utils.NewUUID()
+	token, err := createAPIToken(req.ID, svr.authOpt.Salt)
+	if err != nil {
+		log.Error("[Auth][APIToken] create api token", utils.RequestID(ctx), zap.Error(err))
+		return newAPITokenResponse(apimodel.Code_ExecuteException, nil, nil)
+	}
+	req.TokenHash = hashAPIToken(token)
+	req.LastUsedTime = time.Time{}
+	req.CreateBy = utils.ParseUserName(ctx)
+	if err := svr.storage.AddAPIToken(req); err != nil {
+		log.Error("[Auth][APIToken] save api token into store", utils.RequestID(ctx), zap.Error(err))
+		return newAPITokenResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+
+	log.Info("[Auth][APIToken] create api token", utils.RequestID(ctx), zap.String("id", req.ID),
+		zap.String("name", req.Name), zap.String("principal", req.PrincipalID))
+	svr.RecordHistory(apiTokenRecordEntry(ctx, req, model.OCreate))
+
+	resp := newAPITokenResponse(apimodel.Code_ExecuteSuccess, nil, req)
+	resp.Token = token
+	return resp
+}
+
+// GetAPITokens 查询 API token 列表，支持按照 id、principal_type、principal_id、owner 以及 name 过滤
+func (svr *Server) GetAPITokens(ctx context.Context, query map[string]string) *model.APITokenBatchResponse {
+	offset, limit, err := utils.ParseOffsetAndLimit(query)
+	if err != nil {
+		return &model.APITokenBatchResponse{
+			Code: uint32(apimodel.Code_InvalidParameter),
+			Info: err.Error(),
+		}
+	}
+	if id := query["id"]; id != "" {
+		token, err := svr.storage.GetAPIToken(id)
+		if err != nil {
+			log.Error("[Auth][APIToken] get api token from store", utils.RequestID(ctx), zap.Error(err))
+			code := commonstore.StoreCode2APICode(err)
+			return &model.APITokenBatchResponse{Code: uint32(code), Info: api.Code2Info(uint32(code))}
+		}
+		ret := &model.APITokenBatchResponse{
+			Code:      api.ExecuteSuccess,
+			Info:      api.Code2Info(api.ExecuteSuccess),
+			APITokens: []*model.APIToken{},
+		}
+		if token != nil && matchAPITokenFilter(token, query) {
+			ret.Total = 1
+			ret.APITokens = append(ret.APITokens, apiTokenView(token))
+		}
+		return ret
+	}
+
+	total, tokens, err := svr.storage.GetAPITokens(query, offset, limit)
+	if err != nil {
+		log.Error("[Auth][APIToken] query api tokens from store", utils.RequestID(ctx), zap.Error(err))
+		code := commonstore.StoreCode2APICode(err)
+		return &model.APITokenBatchResponse{Code: uint32(code), Info: api.Code2Info(uint32(code))}
+	}
+	ret := make([]*model.APIToken, 0, len(tokens))
+	for i := range tokens {
+		ret = append(ret, apiTokenView(tokens[i]))
+	}
+	return &model.APITokenBatchResponse{
+		Code:      api.ExecuteSuccess,
+		Info:      api.Code2Info(api.ExecuteSuccess),
+		Total:     total,
+		APITokens: ret,
+	}
+}
+
+// RevokeAPIToken 吊销 API token，吊销后 token 立即在本节点失效
+func (svr *Server) RevokeAPIToken(ctx context.Context, id string) *model.APITokenResponse {
+	token, err := svr.storage.GetAPIToken(id)
+	if err != nil {
+		log.Error("[Auth][APIToken] get api token from store", utils.RequestID(ctx), zap.Error(err))
+		return newAPITokenResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if token == nil {
+		return newAPITokenResponse(apimodel.Code_NotFoundResource, errors.New("api token not exist"), nil)
+	}
+	if err := svr.storage.DeleteAPIToken(id); err != nil {
+		log.Error("[Auth][APIToken] revoke api token", utils.RequestID(ctx), zap.Error(err))
+		return newAPITokenResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	svr.apiTokens.Delete(id)
+
+	log.Info("[Auth][APIToken] revoke api token", utils.RequestID(ctx), zap.String("id", id))
+	svr.RecordHistory(apiTokenRecordEntry(ctx, token, model.ODelete))
+	return newAPITokenResponse(apimodel.Code_ExecuteSuccess, nil, token)
+}
+
+// checkAPIToken 校验具名 API token，并将操作者替换为 token 所属的用户或者用户组
+func (svr *Server) checkAPIToken(tokenInfo *auth.OperatorInfo) error {
+	item, err := svr.loadAPIToken(tokenInfo.APIToken.ID)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return model.ErrorTokenNotExist
+	}
+	token := item.token
+	if subtle.ConstantTimeCompare([]byte(hashAPIToken(tokenInfo.Origin)), []byte(token.TokenHash)) != 1 {
+		return model.ErrorTokenNotExist
+	}
+	now := time.Now()
+	if token.IsExpired(now) {
+		return model.ErrorTokenExpired
+	}
+	svr.touchAPIToken(item, now)
+
+	tokenInfo.APIToken = token
+	tokenInfo.OperatorID = token.PrincipalID
+	tokenInfo.IsUserToken = token.PrincipalType == model.PrincipalUser
+	return nil
+}
+
+// loadAPIToken 优先从本地缓存中获取 API token，缓存过期后重新从存储中加载
+func (svr *Server) loadAPIToken(id string) (*cachedAPIToken, error) {
+	if val, ok := svr.apiTokens.Load(id); ok {
+		item := val.(*cachedAPIToken)
+		if time.Since(item.loadTime) < apiTokenCacheTTL {
+			return item, nil
+		}
+	}
+	token, err := svr.storage.GetAPIToken(id)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		svr.apiTokens.Delete(id)
+		return nil, nil
+	}
+	item := &cachedAPIToken{token: token, loadTime: time.Now()}
+	if !token.LastUsedTime.IsZero() {
+		item.lastUsed.Store(token.LastUsedTime.Unix())
+	}
+	svr.apiTokens.Store(id, item)
+	return item, nil
+}
+
+// touchAPIToken 异步更新 API token 最近一次使用的时间，同一个 token 每分钟最多写一次存储
+func (svr *Server) touchAPIToken(item *cachedAPIToken, now time.Time) {
+	last := item.lastUsed.Load()
+	if now.Unix()-last < int64(apiTokenLastUsedInterval/time.Second) {
+		return
+	}
+	if !item.lastUsed.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+	id := item.token.ID
+	go func() {
+		if err := svr.storage.UpdateAPITokenLastUsed(id, now); err != nil {
+			log.Error("[Auth][APIToken] update api token last used time", zap.String("id", id), zap.Error(err))
+		}
+	}()
+}
+
+// checkAPITokenScope 检查本次操作是否在 API token 的授权范围内
+// 限制了范围的 token 不允许访问用户、用户组以及鉴权策略等鉴权模块的接口
+// 没有关联具体资源的操作无法判断资源类型以及命名空间，只有携带了命名空间过滤条件的列表查询才允许访问
+func (svr *Server) checkAPITokenScope(authCtx *model.AcquireContext, token *model.APIToken) error {
+	if !token.IsScoped() {
+		return nil
+	}
+	if authCtx.GetModule() == model.AuthModule {
+		return model.ErrorTokenScopeForbidden
+	}
+	if !token.AllowOperation(authCtx.GetOperation()) {
+		return model.ErrorTokenScopeForbidden
+	}
+	hasResources := false
+	for resType, entries := range authCtx.GetAccessResources() {
+		if len(entries) == 0 {
+			continue
+		}
+		hasResources = true
+		if !token.AllowResourceType(resType) {
+			return model.ErrorTokenScopeForbidden
+		}
+		if len(token.Namespaces) == 0 {
+			continue
+		}
+		for _, entry := range entries {
+			namespace, ok := svr.resourceNamespace(resType, entry)
+			if !ok || !token.AllowNamespace(namespace) {
+				return model.ErrorTokenScopeForbidden
+			}
+		}
+	}
+	if hasResources || (len(token.Namespaces) == 0 && len(token.ResourceTypes) == 0) {
+		return nil
+	}
+	return restrictNamespaceFilter(authCtx, token)
+}
+
+// restrictNamespaceFilter 将列表查询的命名空间条件限制在 token 授权的命名空间内
+// 查询没有指定命名空间时，token 只授权了一个命名空间则改写为该命名空间，否则拒绝
+func restrictNamespaceFilter(authCtx *model.AcquireContext, token *model.APIToken) error {
+	filter := authCtx.GetNamespaceFilter()
+	if filter == nil || authCtx.GetOperation() != model.Read {
+		return model.ErrorTokenScopeForbidden
+	}
+	if !token.AllowResourceType(filter.ResourceType) {
+		return model.ErrorTokenScopeForbidden
+	}
+	if len(token.Namespaces) == 0 {
+		return nil
+	}
+	namespace := filter.Query[filter.Key]
+	if namespace == "" {
+		if len(token.Namespaces) != 1 {
+			return model.ErrorTokenScopeForbidden
+		}
+		filter.Query[filter.Key] = token.Namespaces[0]
+		return nil
+	}
+	if !token.AllowNamespace(namespace) {
+		return model.ErrorTokenScopeForbidden
+	}
+	return nil
+}
+
+// resourceNamespace 获取资源所在的命名空间
+func (svr *Server) resourceNamespace(resType apisecurity.ResourceType, entry model.ResourceEntry) (string, bool) {
+	switch resType {
+	case apisecurity.ResourceType_Namespaces:
+		return entry.ID, true
+	case apisecurity.ResourceType_Services:
+		svc := svr.cacheMgr.Service().GetServiceByID(entry.ID)
+		if svc == nil {
+			return "", false
+		}
+		return svc.Namespace, true
+	case apisecurity.ResourceType_ConfigGroups:
+		id, err := strconv.ParseUint(entry.ID, 10, 64)
+		if err != nil {
+			return "", false
+		}
+		group := svr.cacheMgr.ConfigGroup().GetGroupByID(id)
+		if group == nil {
+			return "", false
+		}
+		return group.Namespace, true
+	default:
+		return "", false
+	}
+}
+
+// checkCreateAPIToken 检查创建 API token 的请求
+func checkCreateAPIToken(req *model.APIToken) error {
+	if req == nil {
+		return errors.New("empty request")
+	}
+	if err := CheckName(utils.NewStringValue(req.Name)); err != nil {
+		return fmt.Errorf("invalid api token name: %w", err)
+	}
+	if req.PrincipalType != model.PrincipalUser && req.PrincipalType != model.PrincipalGroup {
+		return errors.New("principal_type must be user or group")
+	}
+	if req.PrincipalID == "" {
+		return errors.New("principal_id is empty")
+	}
+	if !req.ExpireTime.IsZero() && !req.ExpireTime.After(time.Now()) {
+		return errors.New("expire_time must be later than now")
+	}
+	for _, action := range req.Actions {
+		if _, ok := model.APITokenActions[action]; !ok {
+			return fmt.Errorf("invalid action %s", action)
+		}
+	}
+	for _, resType := range req.ResourceTypes {
+		if _, ok := apisecurity.ResourceType_value[resType]; !ok {
+			return fmt.Errorf("invalid resource type %s", resType)
+		}
+	}
+	for _, namespace := range req.Namespaces {
+		if namespace == "" {
+			return errors.New("namespace is empty")
+		}
+	}
+	return nil
+}
+
+// matchAPITokenFilter 按照 id 查询时，token 需要同时满足其他的过滤条件
+func matchAPITokenFilter(token *model.APIToken, query map[string]string) bool {
+	if expect := query["principal_type"]; expect != "" && expect != strconv.Itoa(int(token.PrincipalType)) {
+		return false
+	}
+	if expect := query["principal_id"]; expect != "" && expect != token.PrincipalID {
+		return false
+	}
+	if expect := query["owner"]; expect != "" && expect != token.Owner {
+		return false
+	}
+	if expect := query["name"]; expect != "" && expect != token.Name {
+		return false
+	}
+	return true
+}
+
+// createAPIToken 创建具名 API token，格式与用户 token 一致，随机部分使用完整的 UUID
+func createAPIToken(id string, salt string) (string, error) {
+	secret := strings.ReplaceAll(uuid.NewString(), "-", "")
+	val := fmt.Sprintf("%s/%s", model.TokenForAPIToken, id)
+	return encryptMessage([]byte(salt), fmt.Sprintf(TokenPattern, secret, val))
+}
+
+// hashAPIToken 计算 token 的摘要，存储中只保存摘要
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiTokenView 返回给调用方的 token 信息，不包含 token 的摘要
+func apiTokenView(token *model.APIToken) *model.APIToken {
+	if token == nil {
+		return nil
+	}
+	ret := *token
+	ret.TokenHash = ""
+	return &ret
+}
+
+func newAPITokenResponse(code apimodel.Code, err error, token *model.APIToken) *model.APITokenResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.APITokenResponse{Code: uint32(code), Info: info, APIToken: apiTokenView(token)}
+}
+
+// apiTokenRecordEntry 生成 API token 的操作记录
+func apiTokenRecordEntry(ctx context.Context, token *model.APIToken,
+	operationType model.OperationType) *model.RecordEntry {
+	detail, _ := json.Marshal(apiTokenView(token))
+	return &model.RecordEntry{
+		ResourceType:  model.RAPIToken,
+		ResourceName:  fmt.Sprintf("%s(%s)", token.Name, token.ID),
+		OperationType: operationType,
+		Operator:      utils.ParseOperator(ctx),
+		Detail:        string(detail),
+		HappenTime:    time.Now(),
+	}
+}
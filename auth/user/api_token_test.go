@@ -0,0 +1,368 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+type apiTokenTest struct {
+	svr     *Server
+	storage *storemock.MockStore
+	svcs    *cachemock.MockServiceCache
+	user    *model.User
+	saved   map[string]*model.APIToken
+}
+
+func newAPITokenTest(t *testing.T) *apiTokenTest {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	storage := storemock.NewMockStore(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	users := cachemock.NewMockUserCache(ctrl)
+	svcs := cachemock.NewMockServiceCache(ctrl)
+	cacheMgr.EXPECT().User().AnyTimes().Return(users)
+	cacheMgr.EXPECT().Service().AnyTimes().Return(svcs)
+
+	user := &model.User{ID: "ci-id", Name: "ci", Owner: "owner-id", Type: model.SubAccountUserRole,
+		TokenEnable: true}
+	users.EXPECT().GetUserByID(user.ID).AnyTimes().Return(user)
+
+	at := &apiTokenTest{
+		svr: &Server{
+			authOpt:  &AuthConfig{Salt: "polarismesh@2021"},
+			storage:  storage,
+			cacheMgr: cacheMgr,
+		},
+		storage: storage,
+		svcs:    svcs,
+		user:    user,
+		saved:   map[string]*model.APIToken{},
+	}
+	storage.EXPECT().AddAPIToken(gomock.Any()).AnyTimes().DoAndReturn(func(token *model.APIToken) error {
+		saved := *token
+		saved.Valid = true
+		at.saved[token.ID] = &saved
+		return nil
+	})
+	storage.EXPECT().GetAPIToken(gomock.Any()).AnyTimes().DoAndReturn(func(id string) (*model.APIToken, error) {
+		return at.saved[id], nil
+	})
+	storage.EXPECT().DeleteAPIToken(gomock.Any()).AnyTimes().DoAndReturn(func(id string) error {
+		delete(at.saved, id)
+		return nil
+	})
+	storage.EXPECT().UpdateAPITokenLastUsed(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	return at
+}
+
+func (at *apiTokenTest) create(t *testing.T, req *model.APIToken) string {
+	req.Name = "ci-pipeline"
+	req.PrincipalType = model.PrincipalUser
+	req.PrincipalID = at.user.ID
+	resp := at.svr.CreateAPIToken(context.Background(), req)
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.Code, resp.Info)
+	assert.NotEmpty(t, resp.Token)
+	assert.Empty(t, resp.APIToken.TokenHash)
+	return resp.Token
+}
+
+func (at *apiTokenTest) check(token string, module model.BzModule, op model.ResourceOperation,
+	resources map[apisecurity.ResourceType][]model.ResourceEntry) (*model.AcquireContext, error) {
+	ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, token)
+	authCtx := model.NewAcquireContext(
+		model.WithRequestContext(ctx),
+		model.WithModule(module),
+		model.WithOperation(op),
+		model.WithAccessResources(resources),
+	)
+	return authCtx, at.svr.CheckCredential(authCtx)
+}
+
+func TestAPIToken_CheckCredential(t *testing.T) {
+	at := newAPITokenTest(t)
+	token := at.create(t, &model.APIToken{})
+
+	saved := at.saved[mustDecodeAPITokenID(t, at.svr, token)]
+	assert.Equal(t, "owner-id", saved.Owner)
+	assert.Equal(t, hashAPIToken(token), saved.TokenHash)
+
+	authCtx, err := at.check(token, model.DiscoverModule, model.Modify, nil)
+	assert.NoError(t, err)
+	val, ok := authCtx.GetAttachment(model.TokenDetailInfoKey)
+	assert.True(t, ok)
+	operator := val.(auth.OperatorInfo)
+	assert.True(t, operator.IsUserToken)
+	assert.Equal(t, at.user.ID, operator.OperatorID)
+	assert.Equal(t, "owner-id", operator.OwnerID)
+	assert.Equal(t, saved.ID, utils.ParseAPITokenID(authCtx.GetRequestContext()))
+	assert.Equal(t, at.user.ID, utils.ParseUserID(authCtx.GetRequestContext()))
+
+	// 摘要不一致的 token 视为不存在
+	forged, err := createAPIToken(saved.ID, at.svr.authOpt.Salt)
+	assert.NoError(t, err)
+	_, err = at.check(forged, model.DiscoverModule, model.Read, nil)
+	assert.ErrorIs(t, err, model.ErrorTokenNotExist)
+}
+
+func TestAPIToken_Expired(t *testing.T) {
+	at := newAPITokenTest(t)
+	token := at.create(t, &model.APIToken{ExpireTime: time.Now().Add(time.Hour)})
+	_, err := at.check(token, model.DiscoverModule, model.Read, nil)
+	assert.NoError(t, err)
+
+	saved := at.saved[mustDecodeAPITokenID(t, at.svr, token)]
+	saved.ExpireTime = time.Now().Add(-time.Second)
+	_, err = at.check(token, model.DiscoverModule, model.Read, nil)
+	assert.ErrorIs(t, err, model.ErrorTokenExpired)
+	assert.Equal(t, apimodel.Code_TokenDisabled, model.ConvertToErrCode(err))
+}
+
+func TestAPIToken_Scope(t *testing.T) {
+	at := newAPITokenTest(t)
+	token := at.create(t, &model.APIToken{
+		Namespaces:    []string{"prod"},
+		ResourceTypes: []string{apisecurity.ResourceType_Namespaces.String(), apisecurity.ResourceType_Services.String()},
+		Actions:       []string{model.APITokenActionRead, model.APITokenActionModify},
+	})
+	at.svcs.EXPECT().GetServiceByID("svc-prod").AnyTimes().Return(&model.Service{ID: "svc-prod", Namespace: "prod"})
+	at.svcs.EXPECT().GetServiceByID("svc-test").AnyTimes().Return(&model.Service{ID: "svc-test", Namespace: "test"})
+
+	resources := func(resType apisecurity.ResourceType,
+		ids ...string) map[apisecurity.ResourceType][]model.ResourceEntry {
+		entries := make([]model.ResourceEntry, 0, len(ids))
+		for _, id := range ids {
+			entries = append(entries, model.ResourceEntry{ID: id})
+		}
+		return map[apisecurity.ResourceType][]model.ResourceEntry{resType: entries}
+	}
+
+	tests := []struct {
+		name      string
+		module    model.BzModule
+		op        model.ResourceOperation
+		resources map[apisecurity.ResourceType][]model.ResourceEntry
+		err       error
+	}{
+		{
+			name:      "read namespace in scope",
+			module:    model.DiscoverModule,
+			op:        model.Read,
+			resources: resources(apisecurity.ResourceType_Namespaces, "prod"),
+		},
+		{
+			name:      "modify service in scope",
+			module:    model.DiscoverModule,
+			op:        model.Modify,
+			resources: resources(apisecurity.ResourceType_Services, "svc-prod"),
+		},
+		{
+			name:      "service out of namespace scope",
+			module:    model.DiscoverModule,
+			op:        model.Read,
+			resources: resources(apisecurity.ResourceType_Services, "svc-prod", "svc-test"),
+			err:       model.ErrorTokenScopeForbidden,
+		},
+		{
+			name:      "action out of scope",
+			module:    model.DiscoverModule,
+			op:        model.Delete,
+			resources: resources(apisecurity.ResourceType_Namespaces, "prod"),
+			err:       model.ErrorTokenScopeForbidden,
+		},
+		{
+			name:      "resource type out of scope",
+			module:    model.ConfigModule,
+			op:        model.Read,
+			resources: resources(apisecurity.ResourceType_ConfigGroups, "1"),
+			err:       model.ErrorTokenScopeForbidden,
+		},
+		{
+			name:   "auth module",
+			module: model.AuthModule,
+			op:     model.Read,
+			err:    model.ErrorTokenScopeForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := at.check(token, tt.module, tt.op, tt.resources)
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestAPIToken_ScopeListQuery(t *testing.T) {
+	at := newAPITokenTest(t)
+	token := at.create(t, &model.APIToken{
+		Namespaces:    []string{"prod"},
+		ResourceTypes: []string{apisecurity.ResourceType_Services.String()},
+	})
+	multiToken := at.create(t, &model.APIToken{Namespaces: []string{"prod", "test"}})
+
+	list := func(token string, op model.ResourceOperation, resType apisecurity.ResourceType,
+		query map[string]string) error {
+		ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, token)
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithModule(model.DiscoverModule),
+			model.WithOperation(op),
+		)
+		authCtx.SetNamespaceFilter(resType, query, "namespace")
+		return at.svr.CheckCredential(authCtx)
+	}
+
+	t.Run("force token namespace", func(t *testing.T) {
+		query := map[string]string{"name": "echo"}
+		assert.NoError(t, list(token, model.Read, apisecurity.ResourceType_Services, query))
+		assert.Equal(t, "prod", query["namespace"])
+	})
+
+	t.Run("namespace in scope", func(t *testing.T) {
+		query := map[string]string{"namespace": "prod"}
+		assert.NoError(t, list(token, model.Read, apisecurity.ResourceType_Services, query))
+		assert.Equal(t, "prod", query["namespace"])
+	})
+
+	t.Run("namespace out of scope", func(t *testing.T) {
+		for _, namespace := range []string{"test", "pro*"} {
+			err := list(token, model.Read, apisecurity.ResourceType_Services, map[string]string{"namespace": namespace})
+			assert.ErrorIs(t, err, model.ErrorTokenScopeForbidden)
+		}
+	})
+
+	t.Run("resource type out of scope", func(t *testing.T) {
+		err := list(token, model.Read, apisecurity.ResourceType_ConfigGroups, map[string]string{})
+		assert.ErrorIs(t, err, model.ErrorTokenScopeForbidden)
+	})
+
+	t.Run("without namespace filter", func(t *testing.T) {
+		_, err := at.check(token, model.DiscoverModule, model.Read, nil)
+		assert.ErrorIs(t, err, model.ErrorTokenScopeForbidden)
+		err = list(token, model.Read, apisecurity.ResourceType_Services, nil)
+		assert.ErrorIs(t, err, model.ErrorTokenScopeForbidden)
+	})
+
+	t.Run("not read", func(t *testing.T) {
+		err := list(token, model.Modify, apisecurity.ResourceType_Services, map[string]string{"namespace": "prod"})
+		assert.ErrorIs(t, err, model.ErrorTokenScopeForbidden)
+	})
+
+	t.Run("multiple namespaces", func(t *testing.T) {
+		// 无法用一个查询条件表示多个命名空间，必须显式指定
+		err := list(multiToken, model.Read, apisecurity.ResourceType_Services, map[string]string{})
+		assert.ErrorIs(t, err, model.ErrorTokenScopeForbidden)
+		query := map[string]string{"namespace": "test"}
+		assert.NoError(t, list(multiToken, model.Read, apisecurity.ResourceType_Services, query))
+	})
+}
+
+func TestAPIToken_Revoke(t *testing.T) {
+	at := newAPITokenTest(t)
+	token := at.create(t, &model.APIToken{})
+	id := mustDecodeAPITokenID(t, at.svr, token)
+
+	_, err := at.check(token, model.DiscoverModule, model.Read, nil)
+	assert.NoError(t, err)
+
+	resp := at.svr.RevokeAPIToken(context.Background(), id)
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.Code, resp.Info)
+	_, err = at.check(token, model.DiscoverModule, model.Read, nil)
+	assert.ErrorIs(t, err, model.ErrorTokenNotExist)
+
+	resp = at.svr.RevokeAPIToken(context.Background(), id)
+	assert.Equal(t, uint32(apimodel.Code_NotFoundResource), resp.Code)
+}
+
+func TestAPIToken_LastUsed(t *testing.T) {
+	at := newAPITokenTest(t)
+	token := at.create(t, &model.APIToken{})
+	id := mustDecodeAPITokenID(t, at.svr, token)
+
+	item, err := at.svr.loadAPIToken(id)
+	assert.NoError(t, err)
+	now := time.Now()
+	at.svr.touchAPIToken(item, now)
+	assert.Equal(t, now.Unix(), item.lastUsed.Load())
+
+	// 一分钟内重复使用不会更新
+	at.svr.touchAPIToken(item, now.Add(30*time.Second))
+	assert.Equal(t, now.Unix(), item.lastUsed.Load())
+
+	at.svr.touchAPIToken(item, now.Add(apiTokenLastUsedInterval))
+	assert.Equal(t, now.Add(apiTokenLastUsedInterval).Unix(), item.lastUsed.Load())
+}
+
+func TestAPIToken_CheckCreate(t *testing.T) {
+	valid := func() *model.APIToken {
+		return &model.APIToken{Name: "ci", PrincipalType: model.PrincipalUser, PrincipalID: "ci-id"}
+	}
+	tests := []struct {
+		name   string
+		modify func(token *model.APIToken)
+		valid  bool
+	}{
+		{name: "valid", modify: func(token *model.APIToken) {}, valid: true},
+		{name: "empty name", modify: func(token *model.APIToken) { token.Name = "" }},
+		{name: "invalid principal type", modify: func(token *model.APIToken) { token.PrincipalType = 0 }},
+		{name: "empty principal", modify: func(token *model.APIToken) { token.PrincipalID = "" }},
+		{name: "expired", modify: func(token *model.APIToken) { token.ExpireTime = time.Now().Add(-time.Minute) }},
+		{name: "invalid action", modify: func(token *model.APIToken) { token.Actions = []string{"write"} }},
+		{name: "invalid resource type", modify: func(token *model.APIToken) { token.ResourceTypes = []string{"users"} }},
+		{name: "empty namespace", modify: func(token *model.APIToken) { token.Namespaces = []string{""} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := valid()
+			tt.modify(token)
+			err := checkCreateAPIToken(token)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+		})
+	}
+}
+
+func mustDecodeAPITokenID(t *testing.T, svr *Server, token string) string {
+	operator, err := svr.decodeToken(token)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, operator.APIToken) {
+		t.FailNow()
+	}
+	return operator.APIToken.ID
+}
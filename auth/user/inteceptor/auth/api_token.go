@@ -0,0 +1,117 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// CreateAPIToken 创建 API token
+// Case 1: 用户 token 只能由用户自己、所属的主账户或者超级账户创建
+// Case 2: 用户组 token 只能由用户组的 owner 或者超级账户创建
+func (svr *Server) CreateAPIToken(ctx context.Context, token *model.APIToken) *model.APITokenResponse {
+	ctx, rsp := svr.verifyAPITokenAuth(ctx, WriteOp)
+	if rsp != nil {
+		return toAPITokenResponse(rsp)
+	}
+	switch token.PrincipalType {
+	case model.PrincipalUser:
+		targetUser := svr.GetUserHelper().GetUserByID(ctx, token.PrincipalID)
+		if targetUser == nil {
+			return toAPITokenResponse(api.NewAuthResponse(apimodel.Code_NotFoundUser))
+		}
+		if !checkUserViewPermission(ctx, targetUser) {
+			return toAPITokenResponse(api.NewAuthResponse(apimodel.Code_NotAllowedAccess))
+		}
+	case model.PrincipalGroup:
+		saveGroup := svr.GetUserHelper().GetGroup(ctx, &apisecurity.UserGroup{
+			Id: wrapperspb.String(token.PrincipalID),
+		})
+		if saveGroup == nil {
+			return toAPITokenResponse(api.NewAuthResponse(apimodel.Code_NotFoundUserGroup))
+		}
+		if authcommon.ParseUserRole(ctx) != model.AdminUserRole &&
+			saveGroup.GetOwner().GetValue() != utils.ParseUserID(ctx) {
+			return toAPITokenResponse(api.NewAuthResponse(apimodel.Code_NotAllowedAccess))
+		}
+	}
+	return svr.nextSvr.CreateAPIToken(ctx, token)
+}
+
+// GetAPITokens 查询 API token 列表，非超级账户只能看到自己有权限管理的 token
+func (svr *Server) GetAPITokens(ctx context.Context, query map[string]string) *model.APITokenBatchResponse {
+	ctx, rsp := svr.verifyAPITokenAuth(ctx, ReadOp)
+	if rsp != nil {
+		return &model.APITokenBatchResponse{Code: rsp.GetCode().GetValue(), Info: rsp.GetInfo().GetValue()}
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		if utils.ParseIsOwner(ctx) {
+			query["owner"] = utils.ParseUserID(ctx)
+		} else {
+			query["principal_type"] = strconv.Itoa(int(model.PrincipalUser))
+			query["principal_id"] = utils.ParseUserID(ctx)
+		}
+	}
+	return svr.nextSvr.GetAPITokens(ctx, query)
+}
+
+// RevokeAPIToken 吊销 API token，权限要求与创建一致
+func (svr *Server) RevokeAPIToken(ctx context.Context, id string) *model.APITokenResponse {
+	ctx, rsp := svr.verifyAPITokenAuth(ctx, WriteOp)
+	if rsp != nil {
+		return toAPITokenResponse(rsp)
+	}
+	if authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		queryResp := svr.GetAPITokens(ctx, map[string]string{"id": id})
+		if queryResp.Code != api.ExecuteSuccess {
+			return &model.APITokenResponse{Code: queryResp.Code, Info: queryResp.Info}
+		}
+		if len(queryResp.APITokens) == 0 {
+			return toAPITokenResponse(api.NewAuthResponse(apimodel.Code_NotAllowedAccess))
+		}
+	}
+	return svr.nextSvr.RevokeAPIToken(ctx, id)
+}
+
+// verifyAPITokenAuth API token 只能通过用户自身的 token 管理，不允许使用 API token 再派生或者吊销 API token
+func (svr *Server) verifyAPITokenAuth(ctx context.Context, isWrite bool) (context.Context, *apiservice.Response) {
+	ctx, rsp := svr.verifyAuth(ctx, isWrite, NotOwner)
+	if rsp != nil {
+		return nil, rsp
+	}
+	if utils.ParseAPITokenID(ctx) != "" {
+		log.Error("[Auth][Server] api token can not manage api tokens", utils.RequestID(ctx))
+		return nil, api.NewAuthResponse(apimodel.Code_OperationRoleForbidden)
+	}
+	return ctx, nil
+}
+
+func toAPITokenResponse(rsp *apiservice.Response) *model.APITokenResponse {
+	return &model.APITokenResponse{Code: rsp.GetCode().GetValue(), Info: rsp.GetInfo().GetValue()}
+}
@@ -0,0 +1,84 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	authcommon "github.com/polarismesh/polaris/common/model/auth"
+)
+
+// ChangeExpiredPassword 使用原密码修改过期的密码，此时用户无法登录获取 token，由原密码完成身份校验
+func (svr *Server) ChangeExpiredPassword(ctx context.Context,
+	req *model.ChangeExpiredPasswordRequest) *apiservice.Response {
+	return svr.nextSvr.ChangeExpiredPassword(ctx, req)
+}
+
+// UnlockLogin 解除登录锁定
+// Case 1: 用户的登录锁定只能由所属的主账户或者超级账户解除
+// Case 2: 来源IP的登录锁定只能由超级账户解除
+func (svr *Server) UnlockLogin(ctx context.Context, req *model.LoginUnlockRequest) *model.LoginLockResponse {
+	if req == nil {
+		return toLoginLockResponse(api.NewAuthResponse(apimodel.Code_EmptyRequest))
+	}
+	ctx, rsp := svr.verifyLoginLockAuth(ctx, WriteOp, req.UserID, req.IP)
+	if rsp != nil {
+		return toLoginLockResponse(rsp)
+	}
+	return svr.nextSvr.UnlockLogin(ctx, req)
+}
+
+// GetLoginLocks 查询登录锁定状态，权限要求与解除锁定一致
+func (svr *Server) GetLoginLocks(ctx context.Context, query map[string]string) *model.LoginLockResponse {
+	ctx, rsp := svr.verifyLoginLockAuth(ctx, ReadOp, query["user_id"], query["ip"])
+	if rsp != nil {
+		return toLoginLockResponse(rsp)
+	}
+	return svr.nextSvr.GetLoginLocks(ctx, query)
+}
+
+// verifyLoginLockAuth 检查当前操作者是否可以管理目标用户以及来源IP的登录锁定
+func (svr *Server) verifyLoginLockAuth(ctx context.Context, isWrite bool,
+	userID, ip string) (context.Context, *apiservice.Response) {
+	ctx, rsp := svr.verifyAuth(ctx, isWrite, MustOwner)
+	if rsp != nil {
+		return nil, rsp
+	}
+	if ip != "" && authcommon.ParseUserRole(ctx) != model.AdminUserRole {
+		return nil, api.NewAuthResponse(apimodel.Code_NotAllowedAccess)
+	}
+	if userID != "" {
+		targetUser := svr.GetUserHelper().GetUserByID(ctx, userID)
+		if targetUser == nil {
+			return nil, api.NewAuthResponse(apimodel.Code_NotFoundUser)
+		}
+		if !checkUserViewPermission(ctx, targetUser) {
+			return nil, api.NewAuthResponse(apimodel.Code_NotAllowedAccess)
+		}
+	}
+	return ctx, nil
+}
+
+func toLoginLockResponse(rsp *apiservice.Response) *model.LoginLockResponse {
+	return &model.LoginLockResponse{Code: rsp.GetCode().GetValue(), Info: rsp.GetInfo().GetValue()}
+}
@@ -68,8 +68,19 @@ func (svr *Server) Name() string {
 }
 
 // Login 登录动作
-func (svr *Server) Login(req *apisecurity.LoginRequest) *apiservice.Response {
-	return svr.nextSvr.Login(req)
+func (svr *Server) Login(ctx context.Context, req *apisecurity.LoginRequest) *apiservice.Response {
+	return svr.nextSvr.Login(ctx, req)
+}
+
+// GetLoginRedirect 获取外部身份提供者的登录页面地址
+func (svr *Server) GetLoginRedirect(ctx context.Context, provider string) (string, string, error) {
+	return svr.nextSvr.GetLoginRedirect(ctx, provider)
+}
+
+// LoginCallback 外部身份提供者登录完成后的回调
+func (svr *Server) LoginCallback(ctx context.Context, provider, code, state,
+	binding string) *apiservice.Response {
+	return svr.nextSvr.LoginCallback(ctx, provider, code, state, binding)
 }
 
 // CheckCredential 检查当前操作用户凭证
@@ -77,6 +88,11 @@ func (svr *Server) CheckCredential(authCtx *model.AcquireContext) error {
 	return svr.nextSvr.CheckCredential(authCtx)
 }
 
+// ParsePrincipal 解析 token 对应的操作者
+func (svr *Server) ParsePrincipal(token string) (string, error) {
+	return svr.nextSvr.ParsePrincipal(token)
+}
+
 // GetUserHelper
 func (svr *Server) GetUserHelper() auth.UserHelper {
 	return svr.nextSvr.GetUserHelper()
@@ -0,0 +1,390 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/auth/user/provider"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// providersKey 外部身份提供者在 auth.user.option 中的配置项
+	providersKey = "providers"
+	// loginStateExpire OIDC 登录 state 的有效期
+	loginStateExpire = 10 * time.Minute
+	// loginBindingSize 绑定浏览器的随机值长度
+	loginBindingSize = 32
+)
+
+var (
+	// ErrorLoginProviderNotFound 外部身份提供者不存在
+	ErrorLoginProviderNotFound = errors.New("login provider not found")
+	// ErrorInvalidLoginState 登录回调的 state 不合法或者已过期
+	ErrorInvalidLoginState = errors.New("invalid login state")
+)
+
+// loginProvider 外部身份提供者以及对应的配置
+type loginProvider struct {
+	provider.Provider
+	conf *provider.Config
+}
+
+// parseProviders 解析外部身份提供者配置，yaml 解析出来的嵌套 map 无法直接进行 json 序列化，因此单独解析
+func parseProviders(option map[string]interface{}) ([]*provider.Config, error) {
+	raw, ok := option[providersKey]
+	if !ok {
+		return nil, nil
+	}
+	return provider.ParseConfigs(raw)
+}
+
+func withoutProviders(option map[string]interface{}) map[string]interface{} {
+	if _, ok := option[providersKey]; !ok {
+		return option
+	}
+	ret := make(map[string]interface{}, len(option))
+	for k, v := range option {
+		if k != providersKey {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// initProviders 根据配置创建外部身份提供者
+func (svr *Server) initProviders() error {
+	svr.providers = make([]*loginProvider, 0, len(svr.authOpt.Providers))
+	names := map[string]struct{}{}
+	for _, conf := range svr.authOpt.Providers {
+		if _, ok := names[conf.Name]; ok {
+			return fmt.Errorf("login provider(%s) duplicate", conf.Name)
+		}
+		names[conf.Name] = struct{}{}
+		p, err := provider.New(conf)
+		if err != nil {
+			return err
+		}
+		log.Info("[Auth][User] init login provider", zap.String("name", conf.Name), zap.String("type", conf.Type))
+		svr.providers = append(svr.providers, &loginProvider{Provider: p, conf: conf})
+	}
+	return nil
+}
+
+func (svr *Server) getProvider(name string) *loginProvider {
+	for _, p := range svr.providers {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// findPasswordProvider 查找用户名密码登录时需要使用的外部身份提供者
+// 1. 用户已存在时，只有来源是外部身份提供者的用户才通过外部身份提供者认证
+// 2. 用户不存在时，使用主账户与登录请求匹配的第一个外部身份提供者，登录请求未指定主账户时使用第一个
+func (svr *Server) findPasswordProvider(user *model.User, ownerName string) *loginProvider {
+	if user != nil {
+		p := svr.getProvider(user.Source)
+		if p == nil {
+			return nil
+		}
+		if _, ok := p.Provider.(provider.PasswordProvider); !ok {
+			return nil
+		}
+		return p
+	}
+	for _, p := range svr.providers {
+		if _, ok := p.Provider.(provider.PasswordProvider); !ok {
+			continue
+		}
+		if ownerName == "" || ownerName == p.conf.Owner {
+			return p
+		}
+	}
+	return nil
+}
+
+// loginByPassword 通过外部身份提供者校验用户名密码并登录
+func (svr *Server) loginByPassword(p *loginProvider, username, password string) *apiservice.Response {
+	identity, err := p.Provider.(provider.PasswordProvider).Authenticate(context.Background(), username, password)
+	if err != nil {
+		log.Error("[Auth][User] login provider authenticate", zap.String("provider", p.Name()),
+			zap.String("name", username), zap.Error(err))
+		if errors.Is(err, provider.ErrorAuthenticateFail) {
+			return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
+		}
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
+	}
+	return svr.loginByIdentity(p, identity)
+}
+
+// GetLoginRedirect 获取外部身份提供者的登录页面地址，同时返回需要写入浏览器 cookie 的绑定值
+// state 以及 nonce 都由绑定值派生，回调时只有携带同一个绑定值的浏览器才能完成登录，防止登录 CSRF
+func (svr *Server) GetLoginRedirect(ctx context.Context, name string) (string, string, error) {
+	p := svr.getProvider(name)
+	if p == nil {
+		return "", "", ErrorLoginProviderNotFound
+	}
+	redirect, ok := p.Provider.(provider.RedirectProvider)
+	if !ok {
+		return "", "", ErrorLoginProviderNotFound
+	}
+	binding, err := newLoginBinding()
+	if err != nil {
+		return "", "", err
+	}
+	state := svr.signLoginState(name, binding, time.Now().Add(loginStateExpire))
+	location, err := redirect.AuthCodeURL(state, svr.loginNonce(binding))
+	if err != nil {
+		return "", "", err
+	}
+	return location, binding, nil
+}
+
+// LoginCallback 外部身份提供者登录完成后的回调，使用授权码换取身份信息并登录
+func (svr *Server) LoginCallback(ctx context.Context, name, code, state, binding string) *apiservice.Response {
+	p := svr.getProvider(name)
+	if p == nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotFoundResource, ErrorLoginProviderNotFound.Error())
+	}
+	redirect, ok := p.Provider.(provider.RedirectProvider)
+	if !ok {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotFoundResource, ErrorLoginProviderNotFound.Error())
+	}
+	if err := svr.verifyLoginState(name, state, binding); err != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, err.Error())
+	}
+	identity, err := redirect.Exchange(ctx, code, svr.loginNonce(binding))
+	if err != nil {
+		log.Error("[Auth][User] login provider exchange code", utils.RequestID(ctx),
+			zap.String("provider", name), zap.Error(err))
+		if errors.Is(err, provider.ErrorAuthenticateFail) {
+			return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, err.Error())
+		}
+		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, err.Error())
+	}
+	return svr.loginByIdentity(p, identity)
+}
+
+// newLoginBinding 生成绑定浏览器的随机值
+func newLoginBinding() (string, error) {
+	data := make([]byte, loginBindingSize)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// loginNonce 由绑定值派生 OIDC 的 nonce，id_token 中的 nonce 必须与之一致，防止 id_token 被重放
+func (svr *Server) loginNonce(binding string) string {
+	mac := hmac.New(sha256.New, []byte(svr.authOpt.Salt))
+	_, _ = mac.Write([]byte("nonce|" + binding))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signLoginState 生成带签名以及过期时间的 state，state 中携带绑定值的摘要，防止登录回调被伪造
+func (svr *Server) signLoginState(name, binding string, expire time.Time) string {
+	digest := sha256.Sum256([]byte(binding))
+	payload := name + "|" + strconv.FormatInt(expire.Unix(), 10) + "|" +
+		base64.RawURLEncoding.EncodeToString(digest[:])
+	mac := hmac.New(sha256.New, []byte(svr.authOpt.Salt))
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (svr *Server) verifyLoginState(name, state, binding string) error {
+	if binding == "" {
+		return ErrorInvalidLoginState
+	}
+	parts := strings.Split(state, ".")
+	if len(parts) != 2 {
+		return ErrorInvalidLoginState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrorInvalidLoginState
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrorInvalidLoginState
+	}
+	mac := hmac.New(sha256.New, []byte(svr.authOpt.Salt))
+	_, _ = mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return ErrorInvalidLoginState
+	}
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 3 || fields[0] != name {
+		return ErrorInvalidLoginState
+	}
+	expire, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || time.Now().Unix() > expire {
+		return ErrorInvalidLoginState
+	}
+	// state 只能由发起登录的浏览器使用
+	digest := sha256.Sum256([]byte(binding))
+	if !hmac.Equal([]byte(fields[2]), []byte(base64.RawURLEncoding.EncodeToString(digest[:]))) {
+		return ErrorInvalidLoginState
+	}
+	return nil
+}
+
+// loginByIdentity 外部身份认证通过后，自动创建对应的子账户并同步用户组，返回登录信息
+func (svr *Server) loginByIdentity(p *loginProvider, identity *provider.Identity) *apiservice.Response {
+	owner, err := svr.storage.GetUserByName(p.conf.Owner, "")
+	if err != nil {
+		log.Error("[Auth][User] get login provider owner", zap.String("provider", p.Name()), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if owner == nil {
+		log.Error("[Auth][User] login provider owner not found", zap.String("provider", p.Name()),
+			zap.String("owner", p.conf.Owner))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotFoundOwnerUser, p.conf.Owner)
+	}
+
+	user, err := svr.provisionUser(p, owner, identity)
+	if err != nil {
+		log.Error("[Auth][User] provision login provider user", zap.String("provider", p.Name()),
+			zap.String("name", identity.Name), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if user.Source != p.Name() {
+		// 同名的本地账户或者其他身份提供者的账户，不允许被接管
+		log.Error("[Auth][User] user source not match login provider", zap.String("provider", p.Name()),
+			zap.String("name", identity.Name), zap.String("source", user.Source))
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
+	}
+	if err := svr.syncProviderGroups(p, owner, user, identity); err != nil {
+		log.Error("[Auth][User] sync login provider user groups", zap.String("provider", p.Name()),
+			zap.String("name", identity.Name), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+
+	return api.NewLoginResponse(apimodel.Code_ExecuteSuccess, &apisecurity.LoginResponse{
+		UserId:  utils.NewStringValue(user.ID),
+		OwnerId: utils.NewStringValue(user.Owner),
+		Token:   utils.NewStringValue(user.Token),
+		Name:    utils.NewStringValue(user.Name),
+		Role:    utils.NewStringValue(model.UserRoleNames[user.Type]),
+	})
+}
+
+// provisionUser 获取外部身份对应的子账户，不存在时自动创建
+func (svr *Server) provisionUser(p *loginProvider, owner *model.User,
+	identity *provider.Identity) (*model.User, error) {
+	user, err := svr.storage.GetUserByName(identity.Name, owner.ID)
+	if err != nil || user != nil {
+		return user, err
+	}
+
+	// 外部身份提供者的账户不能使用密码直接登录，这里设置一个随机密码
+	req := &apisecurity.User{
+		Name:     utils.NewStringValue(identity.Name),
+		Password: utils.NewStringValue(utils.NewUUID()),
+		Owner:    utils.NewStringValue(owner.ID),
+		Source:   utils.NewStringValue(p.Name()),
+		Comment:  utils.NewStringValue(fmt.Sprintf("provisioned by login provider %s", p.Name())),
+	}
+	user, err = svr.createUserModel(req, model.OwnerUserRole)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = identity.Email
+	if err := svr.storage.AddUser(user); err != nil {
+		return nil, err
+	}
+	log.Info("[Auth][User] provision login provider user", zap.String("provider", p.Name()),
+		zap.String("name", identity.Name), zap.String("owner", owner.Name))
+	svr.RecordHistory(userRecordEntry(context.Background(), req, user, model.OCreate))
+	return user, nil
+}
+
+// syncProviderGroups 根据外部用户组同步子账户所属的用户组，只调整 groupMapping 中出现的用户组
+func (svr *Server) syncProviderGroups(p *loginProvider, owner, user *model.User,
+	identity *provider.Identity) error {
+	expect := map[string]struct{}{}
+	for _, name := range p.conf.MapGroups(identity.Groups) {
+		expect[name] = struct{}{}
+	}
+	managed := map[string]struct{}{}
+	for _, name := range p.conf.GroupMapping {
+		managed[name] = struct{}{}
+	}
+
+	for name := range managed {
+		group, err := svr.storage.GetGroupByName(name, owner.ID)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			log.Warn("[Auth][User] login provider mapping group not found", zap.String("provider", p.Name()),
+				zap.String("group", name))
+			continue
+		}
+		detail, err := svr.storage.GetGroup(group.ID)
+		if err != nil {
+			return err
+		}
+		if detail == nil {
+			continue
+		}
+		_, isMember := detail.UserIds[user.ID]
+		_, shouldMember := expect[name]
+		if isMember == shouldMember {
+			continue
+		}
+		modify := &model.ModifyUserGroup{
+			ID:          group.ID,
+			Owner:       group.Owner,
+			Token:       group.Token,
+			TokenEnable: group.TokenEnable,
+			Comment:     group.Comment,
+		}
+		if shouldMember {
+			modify.AddUserIds = []string{user.ID}
+		} else {
+			modify.RemoveUserIds = []string{user.ID}
+		}
+		if err := svr.storage.UpdateGroup(modify); err != nil {
+			return err
+		}
+		log.Info("[Auth][User] sync login provider user group", zap.String("provider", p.Name()),
+			zap.String("name", user.Name), zap.String("group", name), zap.Bool("member", shouldMember))
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/user/provider"
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+// stubProvider 测试用的外部身份提供者，同时支持用户名密码以及跳转登录
+type stubProvider struct {
+	name      string
+	passwords map[string]string
+	groups    map[string][]string
+	// exchangeNonce 最近一次换取授权码时使用的 nonce
+	exchangeNonce string
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) Type() string {
+	return "stub"
+}
+
+func (p *stubProvider) Authenticate(ctx context.Context, username, password string) (*provider.Identity, error) {
+	if pwd, ok := p.passwords[username]; !ok || pwd != password {
+		return nil, provider.ErrorAuthenticateFail
+	}
+	return &provider.Identity{Name: username, Groups: p.groups[username]}, nil
+}
+
+func (p *stubProvider) AuthCodeURL(state, nonce string) (string, error) {
+	return "http://idp/authorize?" + url.Values{"state": {state}, "nonce": {nonce}}.Encode(), nil
+}
+
+func (p *stubProvider) Exchange(ctx context.Context, code, nonce string) (*provider.Identity, error) {
+	p.exchangeNonce = nonce
+	return p.Authenticate(ctx, code, p.passwords[code])
+}
+
+type providerTest struct {
+	svr     *Server
+	storage *storemock.MockStore
+	users   *cachemock.MockUserCache
+	owner   *model.User
+	stub    *stubProvider
+}
+
+func newProviderTest(t *testing.T) *providerTest {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	storage := storemock.NewMockStore(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	users := cachemock.NewMockUserCache(ctrl)
+	cacheMgr.EXPECT().User().AnyTimes().Return(users)
+
+	stub := &stubProvider{
+		name:      "corp",
+		passwords: map[string]string{"alice": "alice-pwd", "bob": "bob-pwd"},
+		groups:    map[string][]string{"alice": {"cn=dev,ou=groups,dc=example"}},
+	}
+	svr := &Server{
+		authOpt:  &AuthConfig{Salt: "polarismesh@2021"},
+		storage:  storage,
+		cacheMgr: cacheMgr,
+		providers: []*loginProvider{{
+			Provider: stub,
+			conf: &provider.Config{
+				Name:  "corp",
+				Owner: "polaris",
+				GroupMapping: map[string]string{
+					"dev": "developers",
+					"ops": "operators",
+				},
+			},
+		}},
+	}
+	owner := &model.User{ID: "owner-id", Name: "polaris", Type: model.OwnerUserRole}
+	storage.EXPECT().GetUserByName("polaris", "").AnyTimes().Return(owner, nil)
+	return &providerTest{svr: svr, storage: storage, users: users, owner: owner, stub: stub}
+}
+
+func TestLoginProvider_Provision(t *testing.T) {
+	pt := newProviderTest(t)
+
+	var created *model.User
+	pt.users.EXPECT().GetUserByName("alice", "alice").Return(nil)
+	pt.storage.EXPECT().GetUserByName("alice", "owner-id").Return(nil, nil)
+	pt.storage.EXPECT().AddUser(gomock.Any()).DoAndReturn(func(user *model.User) error {
+		created = user
+		return nil
+	})
+	pt.storage.EXPECT().GetGroupByName("developers", "owner-id").Return(&model.UserGroup{
+		ID: "dev-id", Name: "developers", Owner: "owner-id", Token: "dev-token", TokenEnable: true,
+	}, nil)
+	pt.storage.EXPECT().GetGroup("dev-id").Return(&model.UserGroupDetail{
+		UserGroup: &model.UserGroup{ID: "dev-id"}, UserIds: map[string]struct{}{},
+	}, nil)
+	// 映射的用户组不存在时忽略
+	pt.storage.EXPECT().GetGroupByName("operators", "owner-id").Return(nil, nil)
+	pt.storage.EXPECT().UpdateGroup(gomock.Any()).DoAndReturn(func(modify *model.ModifyUserGroup) error {
+		assert.Equal(t, "dev-id", modify.ID)
+		// 用户组原有的 token 信息需要保留
+		assert.Equal(t, "dev-token", modify.Token)
+		assert.True(t, modify.TokenEnable)
+		assert.Equal(t, []string{created.ID}, modify.AddUserIds)
+		return nil
+	})
+
+	rsp := pt.svr.Login(context.Background(), &apisecurity.LoginRequest{
+		Name:     utils.NewStringValue("alice"),
+		Password: utils.NewStringValue("alice-pwd"),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+	assert.Equal(t, "corp", created.Source)
+	assert.Equal(t, "owner-id", created.Owner)
+	assert.Equal(t, model.SubAccountUserRole, created.Type)
+	assert.Equal(t, created.Token, rsp.GetLoginResponse().GetToken().GetValue())
+}
+
+func TestLoginProvider_ExistUser(t *testing.T) {
+	pt := newProviderTest(t)
+
+	t.Run("wrong_password", func(t *testing.T) {
+		pt.users.EXPECT().GetUserByName("alice", "polaris").Return(&model.User{ID: "alice-id", Source: "corp"})
+		rsp := pt.svr.Login(context.Background(), &apisecurity.LoginRequest{
+			Name:     utils.NewStringValue("alice"),
+			Owner:    utils.NewStringValue("polaris"),
+			Password: utils.NewStringValue("wrong"),
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+	})
+
+	t.Run("remove_group", func(t *testing.T) {
+		alice := &model.User{ID: "alice-id", Name: "alice", Owner: "owner-id", Source: "corp",
+			Type: model.SubAccountUserRole}
+		pt.stub.groups["alice"] = nil
+		pt.users.EXPECT().GetUserByName("alice", "polaris").Return(alice)
+		pt.storage.EXPECT().GetUserByName("alice", "owner-id").Return(alice, nil)
+		pt.storage.EXPECT().GetGroupByName("developers", "owner-id").Return(&model.UserGroup{ID: "dev-id"}, nil)
+		pt.storage.EXPECT().GetGroup("dev-id").Return(&model.UserGroupDetail{
+			UserGroup: &model.UserGroup{ID: "dev-id"}, UserIds: map[string]struct{}{"alice-id": {}},
+		}, nil)
+		pt.storage.EXPECT().GetGroupByName("operators", "owner-id").Return(nil, nil)
+		pt.storage.EXPECT().UpdateGroup(gomock.Any()).DoAndReturn(func(modify *model.ModifyUserGroup) error {
+			assert.Equal(t, []string{"alice-id"}, modify.RemoveUserIds)
+			return nil
+		})
+		rsp := pt.svr.Login(context.Background(), &apisecurity.LoginRequest{
+			Name:     utils.NewStringValue("alice"),
+			Owner:    utils.NewStringValue("polaris"),
+			Password: utils.NewStringValue("alice-pwd"),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+	})
+
+	t.Run("local_user_not_taken_over", func(t *testing.T) {
+		pt.users.EXPECT().GetUserByName("bob", "polaris").Return(nil)
+		pt.storage.EXPECT().GetUserByName("bob", "owner-id").Return(&model.User{ID: "bob-id", Name: "bob"}, nil)
+		rsp := pt.svr.Login(context.Background(), &apisecurity.LoginRequest{
+			Name:     utils.NewStringValue("bob"),
+			Owner:    utils.NewStringValue("polaris"),
+			Password: utils.NewStringValue("bob-pwd"),
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+	})
+
+	t.Run("local_login_disabled", func(t *testing.T) {
+		pt.svr.authOpt.DisableLocalLogin = true
+		defer func() {
+			pt.svr.authOpt.DisableLocalLogin = false
+		}()
+		pt.users.EXPECT().GetUserByName("carol", "polaris").Return(&model.User{
+			ID: "carol-id", Name: "carol", Type: model.SubAccountUserRole})
+		rsp := pt.svr.Login(context.Background(), &apisecurity.LoginRequest{
+			Name:     utils.NewStringValue("carol"),
+			Owner:    utils.NewStringValue("polaris"),
+			Password: utils.NewStringValue("carol-pwd"),
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+	})
+}
+
+func TestLoginProvider_State(t *testing.T) {
+	pt := newProviderTest(t)
+
+	location, binding, err := pt.svr.GetLoginRedirect(context.Background(), "corp")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, binding)
+	u, err := url.Parse(location)
+	assert.NoError(t, err)
+	state, nonce := u.Query().Get("state"), u.Query().Get("nonce")
+	assert.Equal(t, pt.svr.loginNonce(binding), nonce)
+	assert.NoError(t, pt.svr.verifyLoginState("corp", state, binding))
+	assert.ErrorIs(t, pt.svr.verifyLoginState("other", state, binding), ErrorInvalidLoginState)
+	assert.ErrorIs(t, pt.svr.verifyLoginState("corp", state+"x", binding), ErrorInvalidLoginState)
+	expired := pt.svr.signLoginState("corp", binding, time.Now().Add(-time.Second))
+	assert.ErrorIs(t, pt.svr.verifyLoginState("corp", expired, binding), ErrorInvalidLoginState)
+
+	t.Run("other_browser", func(t *testing.T) {
+		// 攻击者的 state 不能在其他浏览器中使用
+		_, other, err := pt.svr.GetLoginRedirect(context.Background(), "corp")
+		assert.NoError(t, err)
+		assert.NotEqual(t, binding, other)
+		assert.ErrorIs(t, pt.svr.verifyLoginState("corp", state, other), ErrorInvalidLoginState)
+		assert.ErrorIs(t, pt.svr.verifyLoginState("corp", state, ""), ErrorInvalidLoginState)
+
+		pt.stub.exchangeNonce = ""
+		rsp := pt.svr.LoginCallback(context.Background(), "corp", "alice", state, other)
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+		assert.Empty(t, pt.stub.exchangeNonce)
+	})
+
+	t.Run("exchange_with_nonce", func(t *testing.T) {
+		rsp := pt.svr.LoginCallback(context.Background(), "corp", "unknown", state, binding)
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+		assert.Equal(t, nonce, pt.stub.exchangeNonce)
+	})
+
+	_, _, err = pt.svr.GetLoginRedirect(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrorLoginProviderNotFound)
+
+	rsp := pt.svr.LoginCallback(context.Background(), "corp", "alice", "forged", binding)
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+}
+
+func TestParseProviders(t *testing.T) {
+	svr := &Server{}
+	err := svr.parseOptions(&auth.Config{User: &auth.UserConfig{Option: map[string]interface{}{
+		"salt":              "polarismesh@2021",
+		"disableLocalLogin": true,
+		"providers": []interface{}{
+			map[interface{}]interface{}{
+				"name":  "corp",
+				"type":  "ldap",
+				"owner": "polaris",
+				"groupMapping": map[interface{}]interface{}{
+					"dev": "developers",
+				},
+				"option": map[interface{}]interface{}{
+					"url":      "ldap://127.0.0.1:389",
+					"baseDn":   "dc=example",
+					"startTls": true,
+				},
+			},
+		},
+	}}})
+	assert.NoError(t, err)
+	assert.True(t, svr.authOpt.DisableLocalLogin)
+	assert.Len(t, svr.authOpt.Providers, 1)
+	assert.Equal(t, "developers", svr.authOpt.Providers[0].GroupMapping["dev"])
+	assert.NoError(t, svr.initProviders())
+	assert.Equal(t, provider.TypeLDAP, svr.getProvider("corp").Type())
+}
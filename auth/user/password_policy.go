@@ -0,0 +1,440 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// errLoginLockTarget 解除或者查询登录锁定时没有指定用户或者来源IP
+var errLoginLockTarget = errors.New("user_id or ip is required")
+
+const (
+	// defaultLoginWindowSeconds 默认的登录失败统计窗口
+	defaultLoginWindowSeconds = 900
+	// defaultLoginLockSeconds 默认的登录锁定时间
+	defaultLoginLockSeconds = 900
+	// passwordRecordCacheTTL 密码修改时间在本地缓存的时间，其他节点上修改密码后最多延迟该时间生效
+	passwordRecordCacheTTL = 10 * time.Second
+)
+
+// cachedPasswordRecord 本地缓存的用户密码修改时间，record 为空表示用户没有修改记录
+type cachedPasswordRecord struct {
+	record   *model.UserPasswordRecord
+	loadTime time.Time
+}
+
+// PasswordPolicy 本地账户的密码策略，默认不做额外限制
+type PasswordPolicy struct {
+	// MinLength 密码最小长度，不能超过 17
+	MinLength int `json:"minLength" xml:"minLength"`
+	// RequireUpper 必须包含大写字母
+	RequireUpper bool `json:"requireUpper" xml:"requireUpper"`
+	// RequireLower 必须包含小写字母
+	RequireLower bool `json:"requireLower" xml:"requireLower"`
+	// RequireDigit 必须包含数字
+	RequireDigit bool `json:"requireDigit" xml:"requireDigit"`
+	// RequireSpecial 必须包含特殊字符
+	RequireSpecial bool `json:"requireSpecial" xml:"requireSpecial"`
+	// HistoryCount 新密码不能与最近 N 次使用过的密码相同
+	HistoryCount int `json:"historyCount" xml:"historyCount"`
+	// MaxAgeDays 密码的最长使用天数，过期后需要修改密码才能登录
+	MaxAgeDays int `json:"maxAgeDays" xml:"maxAgeDays"`
+}
+
+// Verify 检查密码策略配置是否合法
+func (p *PasswordPolicy) Verify() error {
+	if p == nil {
+		return nil
+	}
+	if p.MinLength < 0 || p.MinLength > 17 {
+		return errors.New("[Auth][Config] passwordPolicy.minLength must between 0 ~ 17")
+	}
+	if p.HistoryCount < 0 || p.MaxAgeDays < 0 {
+		return errors.New("[Auth][Config] passwordPolicy.historyCount and maxAgeDays can not be negative")
+	}
+	return nil
+}
+
+// Check 检查密码是否满足长度以及字符类型的要求
+func (p *PasswordPolicy) Check(password string) error {
+	if p == nil {
+		return nil
+	}
+	if len(password) < p.MinLength {
+		return fmt.Errorf("password len need at least %d", p.MinLength)
+	}
+	var upper, lower, digit, special bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			special = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		return errors.New("password must contain upper case letter")
+	}
+	if p.RequireLower && !lower {
+		return errors.New("password must contain lower case letter")
+	}
+	if p.RequireDigit && !digit {
+		return errors.New("password must contain digit")
+	}
+	if p.RequireSpecial && !special {
+		return errors.New("password must contain special character")
+	}
+	return nil
+}
+
+// needRecord 是否需要记录密码的修改历史
+func (p *PasswordPolicy) needRecord() bool {
+	return p != nil && (p.HistoryCount > 0 || p.MaxAgeDays > 0)
+}
+
+// maxAge 密码的最长使用时间，0 表示不过期
+func (p *PasswordPolicy) maxAge() time.Duration {
+	if p == nil || p.MaxAgeDays <= 0 {
+		return 0
+	}
+	return time.Duration(p.MaxAgeDays) * 24 * time.Hour
+}
+
+// LoginLockout 登录失败锁定配置，失败次数阈值为 0 表示不开启对应维度的锁定
+type LoginLockout struct {
+	// MaxFailures 统计窗口内同一个用户允许的最大登录失败次数
+	MaxFailures int `json:"maxFailures" xml:"maxFailures"`
+	// IPMaxFailures 统计窗口内同一个来源IP允许的最大登录失败次数
+	IPMaxFailures int `json:"ipMaxFailures" xml:"ipMaxFailures"`
+	// WindowSeconds 登录失败次数的统计窗口
+	WindowSeconds int64 `json:"windowSeconds" xml:"windowSeconds"`
+	// LockSeconds 达到阈值后的锁定时间
+	LockSeconds int64 `json:"lockSeconds" xml:"lockSeconds"`
+}
+
+// Verify 检查登录锁定配置是否合法，并设置默认值
+func (l *LoginLockout) Verify() error {
+	if l == nil {
+		return nil
+	}
+	if l.MaxFailures < 0 || l.IPMaxFailures < 0 || l.WindowSeconds < 0 || l.LockSeconds < 0 {
+		return errors.New("[Auth][Config] loginLockout can not be negative")
+	}
+	if l.WindowSeconds == 0 {
+		l.WindowSeconds = defaultLoginWindowSeconds
+	}
+	if l.LockSeconds == 0 {
+		l.LockSeconds = defaultLoginLockSeconds
+	}
+	return nil
+}
+
+// threshold 获取某个维度的失败次数阈值
+func (l *LoginLockout) threshold(key string) int {
+	if l == nil {
+		return 0
+	}
+	if strings.HasPrefix(key, model.LoginLockIPPrefix) {
+		return l.IPMaxFailures
+	}
+	return l.MaxFailures
+}
+
+// loginLockKeys 本次登录需要统计的锁定 key，只包含开启了锁定的维度
+func (svr *Server) loginLockKeys(ip string, user *model.User) []string {
+	lockout := svr.authOpt.LoginLockout
+	if lockout == nil {
+		return nil
+	}
+	keys := make([]string, 0, 2)
+	if lockout.IPMaxFailures > 0 && ip != "" {
+		keys = append(keys, model.IPLoginLockKey(ip))
+	}
+	if lockout.MaxFailures > 0 && user != nil {
+		keys = append(keys, model.UserLoginLockKey(user.ID))
+	}
+	return keys
+}
+
+// checkLoginLocked 检查本次登录的来源IP或者用户是否处于锁定状态
+func (svr *Server) checkLoginLocked(ip string, user *model.User) *apiservice.Response {
+	now := time.Now()
+	for _, key := range svr.loginLockKeys(ip, user) {
+		lock, err := svr.storage.GetLoginLock(key)
+		if err != nil {
+			log.Error("[Auth][User] get login lock", zap.String("key", key), zap.Error(err))
+			return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+		}
+		if lock.IsLocked(now) {
+			log.Warn("[Auth][User] login is locked", zap.String("key", key), zap.Time("until", lock.LockedUntil))
+			return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorLoginLocked.Error())
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure 记录一次登录失败，统计窗口内失败次数达到阈值后锁定。计数的读取、累加以及锁定判断
+// 由存储层在同一个事务中完成，多个节点同时记录失败时不会互相覆盖
+func (svr *Server) recordLoginFailure(ip string, user *model.User) {
+	lockout := svr.authOpt.LoginLockout
+	now := time.Now()
+	for _, key := range svr.loginLockKeys(ip, user) {
+		lock, err := svr.storage.IncrLoginFailure(&model.LoginFailure{
+			Key:       key,
+			Time:      now,
+			Threshold: lockout.threshold(key),
+			Window:    time.Duration(lockout.WindowSeconds) * time.Second,
+			LockTime:  time.Duration(lockout.LockSeconds) * time.Second,
+		})
+		if err != nil {
+			log.Error("[Auth][User] incr login failure", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		if lock.IsLocked(now) {
+			log.Warn("[Auth][User] too many login failures, lock login", zap.String("key", key),
+				zap.Time("until", lock.LockedUntil))
+		}
+	}
+}
+
+// resetLoginFailure 登录成功后清理用户维度的失败计数，来源IP维度的计数不清理
+func (svr *Server) resetLoginFailure(user *model.User) {
+	lockout := svr.authOpt.LoginLockout
+	if lockout == nil || lockout.MaxFailures <= 0 || user == nil {
+		return
+	}
+	if err := svr.storage.DeleteLoginLock(model.UserLoginLockKey(user.ID)); err != nil {
+		log.Error("[Auth][User] delete login lock", zap.String("user", user.ID), zap.Error(err))
+	}
+}
+
+// checkPasswordExpired 检查用户的密码是否已经过期，没有修改记录的用户以当前时间作为起始时间
+func (svr *Server) checkPasswordExpired(user *model.User) *apiservice.Response {
+	maxAge := svr.authOpt.PasswordPolicy.maxAge()
+	if maxAge == 0 {
+		return nil
+	}
+	record, err := svr.storage.GetUserPasswordRecord(user.ID)
+	if err != nil {
+		log.Error("[Auth][User] get user password record", zap.String("user", user.ID), zap.Error(err))
+		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
+	}
+	if record == nil {
+		svr.savePasswordRecord(user.ID, user.Password)
+		return nil
+	}
+	if time.Since(record.ChangeTime) > maxAge {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorPasswordExpired.Error())
+	}
+	return nil
+}
+
+// passwordExpired 用户的密码是否已经过期，密码修改记录优先从本地缓存中获取。密码过期后用户的
+// token 不能再访问控制台接口，用户（包括主账户 admin）需要通过 PUT /core/v1/user/password/expired
+// 使用原密码修改密码，之后重新登录即可恢复
+func (svr *Server) passwordExpired(userID string) bool {
+	maxAge := svr.authOpt.PasswordPolicy.maxAge()
+	if maxAge == 0 {
+		return false
+	}
+	var record *model.UserPasswordRecord
+	if val, ok := svr.passwordRecords.Load(userID); ok && time.Since(val.(*cachedPasswordRecord).loadTime) <
+		passwordRecordCacheTTL {
+		record = val.(*cachedPasswordRecord).record
+	} else {
+		saved, err := svr.storage.GetUserPasswordRecord(userID)
+		if err != nil {
+			log.Error("[Auth][User] get user password record", zap.String("user", userID), zap.Error(err))
+			return false
+		}
+		record = saved
+		svr.passwordRecords.Store(userID, &cachedPasswordRecord{record: record, loadTime: time.Now()})
+	}
+	return record != nil && time.Since(record.ChangeTime) > maxAge
+}
+
+// checkNewPassword 检查新密码是否满足密码策略，userID 不为空时还需要检查最近使用过的密码
+func (svr *Server) checkNewPassword(userID, password string) error {
+	policy := svr.authOpt.PasswordPolicy
+	if err := policy.Check(password); err != nil {
+		return err
+	}
+	if policy == nil || policy.HistoryCount <= 0 || userID == "" {
+		return nil
+	}
+	record, err := svr.storage.GetUserPasswordRecord(userID)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return nil
+	}
+	for i, hash := range record.History {
+		if i >= policy.HistoryCount {
+			break
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return model.ErrorPasswordReused
+		}
+	}
+	return nil
+}
+
+// savePasswordRecord 记录用户新的密码 hash 以及修改时间
+func (svr *Server) savePasswordRecord(userID, hash string) {
+	policy := svr.authOpt.PasswordPolicy
+	if !policy.needRecord() {
+		return
+	}
+	record, err := svr.storage.GetUserPasswordRecord(userID)
+	if err != nil {
+		log.Error("[Auth][User] get user password record", zap.String("user", userID), zap.Error(err))
+		return
+	}
+	if record == nil {
+		record = &model.UserPasswordRecord{UserID: userID}
+	}
+	history := append([]string{hash}, record.History...)
+	if keep := policy.HistoryCount; len(history) > keep {
+		if keep < 1 {
+			keep = 1
+		}
+		history = history[:keep]
+	}
+	record.History = history
+	record.ChangeTime = time.Now()
+	if err := svr.storage.SaveUserPasswordRecord(record); err != nil {
+		log.Error("[Auth][User] save user password record", zap.String("user", userID), zap.Error(err))
+	}
+	svr.passwordRecords.Delete(userID)
+}
+
+// ChangeExpiredPassword 使用原密码修改密码，用于密码过期后无法登录获取 token 的场景
+func (svr *Server) ChangeExpiredPassword(ctx context.Context,
+	req *model.ChangeExpiredPasswordRequest) *apiservice.Response {
+	if req == nil || req.Name == "" || req.OldPassword == "" {
+		return api.NewAuthResponse(apimodel.Code_BadRequest)
+	}
+	if err := CheckPassword(utils.NewStringValue(req.NewPassword)); err != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_InvalidUserPassword, err.Error())
+	}
+	ownerName := req.Owner
+	if ownerName == "" {
+		ownerName = req.Name
+	}
+	ip := utils.ParseClientIP(ctx)
+	user := svr.cacheMgr.User().GetUserByName(req.Name, ownerName)
+	if rsp := svr.checkLoginLocked(ip, user); rsp != nil {
+		return rsp
+	}
+	if user == nil {
+		svr.recordLoginFailure(ip, nil)
+		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+		svr.recordLoginFailure(ip, user)
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
+	}
+	svr.resetLoginFailure(user)
+
+	// 原密码已经校验通过，这里以用户自身的身份修改密码
+	ctx = context.WithValue(ctx, utils.ContextUserRoleIDKey, user.Type)
+	return svr.UpdateUserPassword(ctx, &apisecurity.ModifyUserPassword{
+		Id:          utils.NewStringValue(user.ID),
+		OldPassword: utils.NewStringValue(req.OldPassword),
+		NewPassword: utils.NewStringValue(req.NewPassword),
+	})
+}
+
+// UnlockLogin 解除用户或者来源IP的登录锁定
+func (svr *Server) UnlockLogin(ctx context.Context, req *model.LoginUnlockRequest) *model.LoginLockResponse {
+	if req == nil || (req.UserID == "" && req.IP == "") {
+		return newLoginLockResponse(apimodel.Code_BadRequest, errLoginLockTarget)
+	}
+	keys := make([]string, 0, 2)
+	if req.UserID != "" {
+		keys = append(keys, model.UserLoginLockKey(req.UserID))
+	}
+	if req.IP != "" {
+		keys = append(keys, model.IPLoginLockKey(req.IP))
+	}
+	for _, key := range keys {
+		if err := svr.storage.DeleteLoginLock(key); err != nil {
+			log.Error("[Auth][User] delete login lock", utils.RequestID(ctx), zap.String("key", key), zap.Error(err))
+			return newLoginLockResponse(commonstore.StoreCode2APICode(err), err)
+		}
+		log.Info("[Auth][User] unlock login", utils.RequestID(ctx), zap.String("key", key),
+			zap.String("operator", utils.ParseOperator(ctx)))
+	}
+	return newLoginLockResponse(apimodel.Code_ExecuteSuccess, nil)
+}
+
+// GetLoginLocks 查询用户或者来源IP的登录失败计数以及锁定状态
+func (svr *Server) GetLoginLocks(ctx context.Context, query map[string]string) *model.LoginLockResponse {
+	keys := make([]string, 0, 2)
+	if userID := query["user_id"]; userID != "" {
+		keys = append(keys, model.UserLoginLockKey(userID))
+	}
+	if ip := query["ip"]; ip != "" {
+		keys = append(keys, model.IPLoginLockKey(ip))
+	}
+	if len(keys) == 0 {
+		return newLoginLockResponse(apimodel.Code_BadRequest, errLoginLockTarget)
+	}
+	resp := newLoginLockResponse(apimodel.Code_ExecuteSuccess, nil)
+	for _, key := range keys {
+		lock, err := svr.storage.GetLoginLock(key)
+		if err != nil {
+			log.Error("[Auth][User] get login lock", utils.RequestID(ctx), zap.String("key", key), zap.Error(err))
+			return newLoginLockResponse(commonstore.StoreCode2APICode(err), err)
+		}
+		if lock != nil {
+			resp.Locks = append(resp.Locks, lock)
+		}
+	}
+	return resp
+}
+
+func newLoginLockResponse(code apimodel.Code, err error) *model.LoginLockResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.LoginLockResponse{Code: uint32(code), Info: info}
+}
@@ -0,0 +1,281 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package defaultuser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	cachemock "github.com/polarismesh/polaris/cache/mock"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true,
+		RequireDigit: true, RequireSpecial: true}
+	tests := []struct {
+		password string
+		ok       bool
+	}{
+		{password: "Ab1!", ok: false},
+		{password: "abcdef1!", ok: false},
+		{password: "ABCDEF1!", ok: false},
+		{password: "Abcdefg!", ok: false},
+		{password: "Abcdefg1", ok: false},
+		{password: "Abcdef1!", ok: true},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.password)
+		assert.Equal(t, tt.ok, err == nil, tt.password)
+	}
+	// 未配置密码策略时不做限制
+	var empty *PasswordPolicy
+	assert.NoError(t, empty.Check("a"))
+}
+
+func TestAuthConfig_VerifySecurity(t *testing.T) {
+	cfg := DefaultUserConfig()
+	cfg.PasswordPolicy = &PasswordPolicy{MinLength: 18}
+	assert.Error(t, cfg.Verify())
+
+	cfg = DefaultUserConfig()
+	cfg.LoginLockout = &LoginLockout{MaxFailures: 3}
+	assert.NoError(t, cfg.Verify())
+	assert.Equal(t, int64(defaultLoginWindowSeconds), cfg.LoginLockout.WindowSeconds)
+	assert.Equal(t, int64(defaultLoginLockSeconds), cfg.LoginLockout.LockSeconds)
+}
+
+type securityTest struct {
+	svr     *Server
+	user    *model.User
+	locks   map[string]*model.LoginLock
+	records map[string]*model.UserPasswordRecord
+}
+
+func newSecurityTest(t *testing.T, cfg *AuthConfig) *securityTest {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	pwd, err := bcrypt.GenerateFromPassword([]byte("polaris@1"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	st := &securityTest{
+		user:    &model.User{ID: "user-id", Name: "alice", Password: string(pwd), Type: model.OwnerUserRole},
+		locks:   map[string]*model.LoginLock{},
+		records: map[string]*model.UserPasswordRecord{},
+	}
+
+	storage := storemock.NewMockStore(ctrl)
+	cacheMgr := cachemock.NewMockCacheManager(ctrl)
+	users := cachemock.NewMockUserCache(ctrl)
+	cacheMgr.EXPECT().User().AnyTimes().Return(users)
+	users.EXPECT().GetUserByID(gomock.Any()).AnyTimes().DoAndReturn(func(id string) *model.User {
+		if id == st.user.ID {
+			return st.user
+		}
+		return nil
+	})
+	users.EXPECT().GetUserByName(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(name, owner string) *model.User {
+			if name == st.user.Name {
+				return st.user
+			}
+			return nil
+		})
+	storage.EXPECT().GetLoginLock(gomock.Any()).AnyTimes().DoAndReturn(func(key string) (*model.LoginLock, error) {
+		return st.locks[key], nil
+	})
+	storage.EXPECT().IncrLoginFailure(gomock.Any()).AnyTimes().DoAndReturn(
+		func(failure *model.LoginFailure) (*model.LoginLock, error) {
+			lock := failure.Apply(st.locks[failure.Key])
+			st.locks[failure.Key] = lock
+			return lock, nil
+		})
+	storage.EXPECT().DeleteLoginLock(gomock.Any()).AnyTimes().DoAndReturn(func(key string) error {
+		delete(st.locks, key)
+		return nil
+	})
+	storage.EXPECT().GetUserPasswordRecord(gomock.Any()).AnyTimes().DoAndReturn(
+		func(userID string) (*model.UserPasswordRecord, error) {
+			return st.records[userID], nil
+		})
+	storage.EXPECT().SaveUserPasswordRecord(gomock.Any()).AnyTimes().DoAndReturn(
+		func(record *model.UserPasswordRecord) error {
+			st.records[record.UserID] = record
+			return nil
+		})
+	storage.EXPECT().GetUser(st.user.ID).AnyTimes().DoAndReturn(func(id string) (*model.User, error) {
+		copied := *st.user
+		return &copied, nil
+	})
+	storage.EXPECT().UpdateUser(gomock.Any()).AnyTimes().DoAndReturn(func(user *model.User) error {
+		st.user = user
+		return nil
+	})
+
+	cfg.Salt = "polarismesh@2021"
+	assert.NoError(t, cfg.Verify())
+	token, err := createUserToken(st.user.ID, cfg.Salt)
+	assert.NoError(t, err)
+	st.user.Token = token
+	st.user.TokenEnable = true
+	st.svr = &Server{authOpt: cfg, storage: storage, cacheMgr: cacheMgr}
+	return st
+}
+
+func (st *securityTest) login(ip, name, password string) uint32 {
+	ctx := context.WithValue(context.Background(), utils.ContextClientAddress, ip+":8080")
+	rsp := st.svr.Login(ctx, &apisecurity.LoginRequest{
+		Name:     utils.NewStringValue(name),
+		Password: utils.NewStringValue(password),
+	})
+	return rsp.GetCode().GetValue()
+}
+
+// checkCredential 使用用户自身的 token 发起一次控制台或者客户端请求的鉴权
+func (st *securityTest) checkCredential(fromClient bool) error {
+	ctx := context.WithValue(context.Background(), utils.ContextAuthTokenKey, st.user.Token)
+	authCtx := model.NewAcquireContext(model.WithRequestContext(ctx), model.WithModule(model.DiscoverModule))
+	if fromClient {
+		authCtx.SetFromClient()
+	}
+	return st.svr.CheckCredential(authCtx)
+}
+
+func TestLogin_UserLockout(t *testing.T) {
+	st := newSecurityTest(t, &AuthConfig{LoginLockout: &LoginLockout{MaxFailures: 3}})
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("127.0.0.1", "alice", "wrong"))
+	}
+	// 达到失败次数之后，即使密码正确也无法登录，更换来源IP也一样
+	lock := st.locks[model.UserLoginLockKey(st.user.ID)]
+	assert.True(t, lock.IsLocked(time.Now()))
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("127.0.0.2", "alice", "polaris@1"))
+
+	resp := st.svr.GetLoginLocks(context.Background(), map[string]string{"user_id": st.user.ID})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.Code)
+	assert.Len(t, resp.Locks, 1)
+
+	resp = st.svr.UnlockLogin(context.Background(), &model.LoginUnlockRequest{})
+	assert.Equal(t, uint32(apimodel.Code_BadRequest), resp.Code)
+	resp = st.svr.UnlockLogin(context.Background(), &model.LoginUnlockRequest{UserID: st.user.ID})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.Code)
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("127.0.0.1", "alice", "polaris@1"))
+
+	// 登录成功后清理失败计数
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("127.0.0.1", "alice", "wrong"))
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("127.0.0.1", "alice", "polaris@1"))
+	assert.Nil(t, st.locks[model.UserLoginLockKey(st.user.ID)])
+}
+
+func TestLogin_IPLockout(t *testing.T) {
+	st := newSecurityTest(t, &AuthConfig{LoginLockout: &LoginLockout{IPMaxFailures: 2}})
+
+	// 不存在的用户同样计入来源IP的失败次数
+	assert.Equal(t, uint32(apimodel.Code_NotFoundUser), st.login("10.0.0.1", "bob", "wrong"))
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("10.0.0.1", "alice", "wrong"))
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("10.0.0.1", "alice", "polaris@1"))
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("10.0.0.2", "alice", "polaris@1"))
+	assert.Nil(t, st.locks[model.UserLoginLockKey(st.user.ID)])
+
+	// 统计窗口过期之后重新计数
+	st.locks[model.IPLoginLockKey("10.0.0.3")] = &model.LoginLock{
+		Key:         model.IPLoginLockKey("10.0.0.3"),
+		FailCount:   1,
+		WindowStart: time.Now().Add(-time.Hour),
+	}
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("10.0.0.3", "alice", "wrong"))
+	assert.Equal(t, 1, st.locks[model.IPLoginLockKey("10.0.0.3")].FailCount)
+}
+
+func TestPasswordPolicy_HistoryAndExpire(t *testing.T) {
+	st := newSecurityTest(t, &AuthConfig{PasswordPolicy: &PasswordPolicy{
+		MinLength: 8, RequireDigit: true, HistoryCount: 2, MaxAgeDays: 30,
+	}})
+	ctx := context.Background()
+
+	// 首次登录时补充密码修改记录
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("127.0.0.1", "alice", "polaris@1"))
+	assert.NotNil(t, st.records[st.user.ID])
+
+	update := func(password string) uint32 {
+		return st.svr.UpdateUserPassword(ctx, &apisecurity.ModifyUserPassword{
+			Id:          utils.NewStringValue(st.user.ID),
+			NewPassword: utils.NewStringValue(password),
+		}).GetCode().GetValue()
+	}
+	assert.Equal(t, uint32(apimodel.Code_InvalidUserPassword), update("polaris"))
+	assert.Equal(t, uint32(apimodel.Code_InvalidUserPassword), update("polaris@1"))
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), update("polaris@2"))
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), update("polaris@3"))
+	assert.Len(t, st.records[st.user.ID].History, 2)
+	// 超出历史记录数量的密码可以再次使用
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), update("polaris@1"))
+
+	// 密码过期之后无法登录，需要使用原密码修改
+	st.records[st.user.ID].ChangeTime = time.Now().Add(-31 * 24 * time.Hour)
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("127.0.0.1", "alice", "polaris@1"))
+	// 已经获取的 token 也不能继续访问控制台接口，客户端请求不受影响
+	assert.ErrorIs(t, st.checkCredential(false), model.ErrorPasswordExpired)
+	assert.NoError(t, st.checkCredential(true))
+
+	rsp := st.svr.ChangeExpiredPassword(ctx, &model.ChangeExpiredPasswordRequest{
+		Name: "alice", OldPassword: "wrong", NewPassword: "polaris@4",
+	})
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.GetCode().GetValue())
+	rsp = st.svr.ChangeExpiredPassword(ctx, &model.ChangeExpiredPasswordRequest{
+		Name: "alice", OldPassword: "polaris@1", NewPassword: "polaris@3",
+	})
+	assert.Equal(t, uint32(apimodel.Code_InvalidUserPassword), rsp.GetCode().GetValue())
+	rsp = st.svr.ChangeExpiredPassword(ctx, &model.ChangeExpiredPasswordRequest{
+		Name: "alice", OldPassword: "polaris@1", NewPassword: "polaris@4",
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue())
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("127.0.0.1", "alice", "polaris@4"))
+}
+
+func TestPasswordPolicy_AdminExpireRecover(t *testing.T) {
+	st := newSecurityTest(t, &AuthConfig{PasswordPolicy: &PasswordPolicy{MaxAgeDays: 30}})
+	st.user.Type = model.AdminUserRole
+	ctx := context.Background()
+
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("127.0.0.1", "alice", "polaris@1"))
+	assert.NoError(t, st.checkCredential(false))
+
+	// 主账户同样受密码有效期限制，过期后通过原密码修改密码恢复登录以及控制台访问
+	st.records[st.user.ID].ChangeTime = time.Now().Add(-31 * 24 * time.Hour)
+	st.svr.passwordRecords.Delete(st.user.ID)
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), st.login("127.0.0.1", "alice", "polaris@1"))
+	assert.ErrorIs(t, st.checkCredential(false), model.ErrorPasswordExpired)
+
+	rsp := st.svr.ChangeExpiredPassword(ctx, &model.ChangeExpiredPasswordRequest{
+		Name: "alice", OldPassword: "polaris@1", NewPassword: "polaris@2",
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue())
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), st.login("127.0.0.1", "alice", "polaris@2"))
+	assert.NoError(t, st.checkCredential(false))
+}
@@ -0,0 +1,189 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig LDAP 身份提供者配置
+type LDAPConfig struct {
+	// URL LDAP 服务地址，比如 ldap://127.0.0.1:389 或者 ldaps://127.0.0.1:636
+	URL string `json:"url" mapstructure:"url"`
+	// BindDN 查询用户时使用的服务账户，为空时匿名查询
+	BindDN string `json:"bindDn" mapstructure:"bindDn"`
+	// BindPassword 服务账户的密码
+	BindPassword string `json:"bindPassword" mapstructure:"bindPassword"`
+	// BaseDN 查询用户的根节点
+	BaseDN string `json:"baseDn" mapstructure:"baseDn"`
+	// UserAttribute 用户名对应的属性，默认为 uid
+	UserAttribute string `json:"userAttribute" mapstructure:"userAttribute"`
+	// GroupAttribute 用户所属用户组对应的属性，默认为 memberOf
+	GroupAttribute string `json:"groupAttribute" mapstructure:"groupAttribute"`
+	// EmailAttribute 用户邮箱对应的属性，默认为 mail
+	EmailAttribute string `json:"emailAttribute" mapstructure:"emailAttribute"`
+	// StartTLS 使用 ldap:// 连接时是否通过 StartTLS 升级为加密连接
+	StartTLS bool `json:"startTls" mapstructure:"startTls"`
+	// AllowInsecure 是否允许不加密的 ldap:// 连接，此时用户密码以明文传输，只应该在测试环境中开启
+	AllowInsecure bool `json:"allowInsecure" mapstructure:"allowInsecure"`
+	// InsecureSkipVerify ldaps 或者 StartTLS 时是否跳过证书校验
+	InsecureSkipVerify bool `json:"insecureSkipVerify" mapstructure:"insecureSkipVerify"`
+	// Timeout 请求 LDAP 服务的超时时间，默认为 5s
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// ldapProvider 通过 LDAP bind 校验用户名密码的身份提供者
+type ldapProvider struct {
+	name   string
+	conf   *LDAPConfig
+	host   string
+	useTLS bool
+}
+
+// NewLDAPProvider 创建 LDAP 身份提供者
+func NewLDAPProvider(name string, conf *LDAPConfig) (PasswordProvider, error) {
+	if conf.URL == "" || conf.BaseDN == "" {
+		return nil, fmt.Errorf("ldap provider(%s) url and baseDn are required", name)
+	}
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap provider(%s) invalid url: %w", name, err)
+	}
+	p := &ldapProvider{name: name, conf: conf, host: u.Hostname()}
+	switch u.Scheme {
+	case "ldap":
+		// bind 请求中携带的是明文密码，不加密的连接必须显式开启
+		if !conf.StartTLS && !conf.AllowInsecure {
+			return nil, fmt.Errorf("ldap provider(%s) ldap:// without startTls sends password in plain text, "+
+				"use ldaps:// or enable startTls, or set allowInsecure to accept it", name)
+		}
+	case "ldaps":
+		p.useTLS = true
+	default:
+		return nil, fmt.Errorf("ldap provider(%s) url scheme(%s) not support", name, u.Scheme)
+	}
+	if conf.UserAttribute == "" {
+		conf.UserAttribute = "uid"
+	}
+	if conf.GroupAttribute == "" {
+		conf.GroupAttribute = "memberOf"
+	}
+	if conf.EmailAttribute == "" {
+		conf.EmailAttribute = "mail"
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 5 * time.Second
+	}
+	return p, nil
+}
+
+// Name 身份提供者名称
+func (p *ldapProvider) Name() string {
+	return p.name
+}
+
+// Type 身份提供者类型
+func (p *ldapProvider) Type() string {
+	return TypeLDAP
+}
+
+// Authenticate 先使用服务账户查询出用户的 DN 以及所属用户组，再使用用户的 DN 和密码进行 bind
+func (p *ldapProvider) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	// 密码为空时 LDAP 会当作匿名 bind 处理，必须拒绝
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("%w: username or password is empty", ErrorAuthenticateFail)
+	}
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if p.conf.BindDN != "" {
+		if err := conn.Bind(p.conf.BindDN, p.conf.BindPassword); err != nil {
+			return nil, fmt.Errorf("ldap provider(%s) service account bind fail: %w", p.name, err)
+		}
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(p.conf.BaseDN, ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases, 2, int(p.conf.Timeout/time.Second), false,
+		fmt.Sprintf("(%s=%s)", ldap.EscapeFilter(p.conf.UserAttribute), ldap.EscapeFilter(username)),
+		[]string{p.conf.GroupAttribute, p.conf.EmailAttribute}, nil))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap provider(%s) search user fail: %w", p.name, err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return nil, fmt.Errorf("%w: user %s not found or not unique", ErrorAuthenticateFail, username)
+	}
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, fmt.Errorf("%w: %s", ErrorAuthenticateFail, err.Error())
+		}
+		return nil, fmt.Errorf("ldap provider(%s) user bind fail: %w", p.name, err)
+	}
+
+	identity := &Identity{
+		Name:   username,
+		Groups: entry.GetEqualFoldAttributeValues(p.conf.GroupAttribute),
+	}
+	if mails := entry.GetEqualFoldAttributeValues(p.conf.EmailAttribute); len(mails) != 0 {
+		identity.Email = mails[0]
+	}
+	return identity, nil
+}
+
+func (p *ldapProvider) dial(ctx context.Context) (*ldap.Conn, error) {
+	timeout := p.conf.Timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	opts := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: timeout})}
+	if p.useTLS {
+		opts = append(opts, ldap.DialWithTLSConfig(p.tlsConfig()))
+	}
+	conn, err := ldap.DialURL(p.conf.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ldap provider(%s) connect %s fail: %w", p.name, p.conf.URL, err)
+	}
+	conn.SetTimeout(timeout)
+	if !p.useTLS && p.conf.StartTLS {
+		// 服务端不支持 StartTLS 时直接失败，不能退化为明文连接
+		if err := conn.StartTLS(p.tlsConfig()); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("ldap provider(%s) start tls with %s fail: %w", p.name, p.conf.URL, err)
+		}
+	}
+	return conn, nil
+}
+
+func (p *ldapProvider) tlsConfig() *tls.Config {
+	return &tls.Config{
+		ServerName:         p.host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: p.conf.InsecureSkipVerify,
+	}
+}
@@ -0,0 +1,265 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLDAPUser 测试 LDAP 服务中的用户
+type fakeLDAPUser struct {
+	dn       string
+	uid      string
+	password string
+	groups   []string
+}
+
+// fakeLDAPServer 进程内的 LDAP 服务，只支持 StartTLS、simple bind 以及相等条件的查询
+type fakeLDAPServer struct {
+	listener net.Listener
+	users    []*fakeLDAPUser
+	// tlsConfig 不为空时支持 StartTLS
+	tlsConfig *tls.Config
+}
+
+func newFakeLDAPServer(t *testing.T, users ...*fakeLDAPUser) *fakeLDAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeLDAPServer{listener: listener, users: users}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reply := func(id int64, op *ber.Packet) {
+		packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+		packet.AppendChild(op)
+		_, _ = conn.Write(packet.Bytes())
+	}
+	result := func(tag ber.Tag, code int64) *ber.Packet {
+		op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+		op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+		op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		return op
+	}
+	str := func(p *ber.Packet) string {
+		return p.Data.String()
+	}
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationExtendedRequest:
+			if s.tlsConfig == nil || str(op.Children[0]) != "1.3.6.1.4.1.1466.20037" {
+				reply(id, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError))
+				continue
+			}
+			reply(id, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess))
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+		case ldap.ApplicationBindRequest:
+			dn, password := str(op.Children[1]), str(op.Children[2])
+			code := int64(ldap.LDAPResultInvalidCredentials)
+			for _, user := range s.users {
+				if user.dn == dn && user.password == password {
+					code = ldap.LDAPResultSuccess
+				}
+			}
+			reply(id, result(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			filter := op.Children[6]
+			attr, value := str(filter.Children[0]), str(filter.Children[1])
+			for _, user := range s.users {
+				if attr != "uid" || user.uid != value {
+					continue
+				}
+				reply(id, fakeLDAPEntry(user.dn, map[string][]string{
+					"memberOf": user.groups,
+					"mail":     {user.uid + "@example.com"},
+				}))
+			}
+			reply(id, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+// fakeLDAPEntry 生成查询结果中的条目
+func fakeLDAPEntry(dn string, attrs map[string][]string) *ber.Packet {
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, ""))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, val := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, val, ""))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	entry.AppendChild(list)
+	return entry
+}
+
+func TestLDAPProvider(t *testing.T) {
+	server := newFakeLDAPServer(t,
+		&fakeLDAPUser{dn: "cn=admin,dc=example", password: "admin"},
+		&fakeLDAPUser{
+			dn:       "uid=bob,ou=people,dc=example",
+			uid:      "bob",
+			password: "bob-pwd",
+			groups:   []string{"cn=dev,ou=groups,dc=example", "cn=ops,ou=groups,dc=example"},
+		},
+	)
+	defer server.listener.Close()
+
+	p, err := New(&Config{
+		Name:  "corp-ldap",
+		Type:  TypeLDAP,
+		Owner: "polaris",
+		Option: map[interface{}]interface{}{
+			"url":           "ldap://" + server.listener.Addr().String(),
+			"bindDn":        "cn=admin,dc=example",
+			"bindPassword":  "admin",
+			"baseDn":        "dc=example",
+			"timeout":       "1s",
+			"allowInsecure": true,
+		},
+	})
+	assert.NoError(t, err)
+	authenticator := p.(PasswordProvider)
+	assert.Equal(t, TypeLDAP, authenticator.Type())
+
+	identity, err := authenticator.Authenticate(context.Background(), "bob", "bob-pwd")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", identity.Name)
+	assert.Equal(t, "bob@example.com", identity.Email)
+	assert.Equal(t, []string{"cn=dev,ou=groups,dc=example", "cn=ops,ou=groups,dc=example"}, identity.Groups)
+
+	_, err = authenticator.Authenticate(context.Background(), "bob", "wrong")
+	assert.ErrorIs(t, err, ErrorAuthenticateFail)
+
+	// 空密码会被 LDAP 当作匿名 bind，必须直接拒绝
+	_, err = authenticator.Authenticate(context.Background(), "bob", "")
+	assert.ErrorIs(t, err, ErrorAuthenticateFail)
+
+	_, err = authenticator.Authenticate(context.Background(), "carol", "pwd")
+	assert.ErrorIs(t, err, ErrorAuthenticateFail)
+}
+
+func TestLDAPProvider_StartTLS(t *testing.T) {
+	server := newFakeLDAPServer(t, &fakeLDAPUser{dn: "uid=bob,ou=people,dc=example", uid: "bob",
+		password: "bob-pwd"})
+	defer server.listener.Close()
+	server.tlsConfig = &tls.Config{Certificates: []tls.Certificate{newTestCertificate(t)}}
+
+	option := func(extra map[interface{}]interface{}) map[interface{}]interface{} {
+		ret := map[interface{}]interface{}{
+			"url":     "ldap://" + server.listener.Addr().String(),
+			"baseDn":  "dc=example",
+			"timeout": "1s",
+		}
+		for k, v := range extra {
+			ret[k] = v
+		}
+		return ret
+	}
+
+	// 明文的 ldap:// 默认不允许使用
+	_, err := New(&Config{Name: "corp-ldap", Type: TypeLDAP, Owner: "polaris", Option: option(nil)})
+	assert.Error(t, err)
+
+	p, err := New(&Config{Name: "corp-ldap", Type: TypeLDAP, Owner: "polaris", Option: option(
+		map[interface{}]interface{}{"startTls": true, "insecureSkipVerify": true})})
+	assert.NoError(t, err)
+	identity, err := p.(PasswordProvider).Authenticate(context.Background(), "bob", "bob-pwd")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", identity.Name)
+
+	// 没有跳过证书校验时，自签名的证书校验失败
+	p, err = New(&Config{Name: "corp-ldap", Type: TypeLDAP, Owner: "polaris", Option: option(
+		map[interface{}]interface{}{"startTls": true})})
+	assert.NoError(t, err)
+	_, err = p.(PasswordProvider).Authenticate(context.Background(), "bob", "bob-pwd")
+	assert.Error(t, err)
+
+	// 服务端不支持 StartTLS 时不能退化为明文连接
+	server.tlsConfig = nil
+	p, err = New(&Config{Name: "corp-ldap", Type: TypeLDAP, Owner: "polaris", Option: option(
+		map[interface{}]interface{}{"startTls": true, "insecureSkipVerify": true})})
+	assert.NoError(t, err)
+	_, err = p.(PasswordProvider).Authenticate(context.Background(), "bob", "bob-pwd")
+	assert.Error(t, err)
+}
+
+// newTestCertificate 生成 127.0.0.1 的自签名证书
+func newTestCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
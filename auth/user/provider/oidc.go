@@ -0,0 +1,323 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package provider
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+const (
+	defaultUsernameClaim = "preferred_username"
+	defaultGroupsClaim   = "groups"
+)
+
+// supportedSigningAlgs 允许的 id_token 签名算法，不允许 none 以及 HMAC 等对称算法
+var supportedSigningAlgs = []string{
+	oidc.RS256, oidc.RS384, oidc.RS512,
+	oidc.ES256, oidc.ES384, oidc.ES512,
+	oidc.PS256, oidc.PS384, oidc.PS512,
+}
+
+// OIDCConfig OIDC 身份提供者配置
+type OIDCConfig struct {
+	// Issuer 身份提供者的 issuer，未配置各个 endpoint 时通过 {issuer}/.well-known/openid-configuration 获取
+	Issuer string `json:"issuer" mapstructure:"issuer"`
+	// ClientID 在身份提供者注册的客户端 ID
+	ClientID string `json:"clientId" mapstructure:"clientId"`
+	// ClientSecret 在身份提供者注册的客户端密钥
+	ClientSecret string `json:"clientSecret" mapstructure:"clientSecret"`
+	// RedirectURL 登录完成后的回调地址，即北极星的 /core/v1/user/login/{provider}/callback
+	RedirectURL string `json:"redirectUrl" mapstructure:"redirectUrl"`
+	// Scopes 申请的 scope，默认为 openid profile email
+	Scopes []string `json:"scopes" mapstructure:"scopes"`
+	// AuthorizationEndpoint 授权地址
+	AuthorizationEndpoint string `json:"authorizationEndpoint" mapstructure:"authorizationEndpoint"`
+	// TokenEndpoint 换取 token 的地址
+	TokenEndpoint string `json:"tokenEndpoint" mapstructure:"tokenEndpoint"`
+	// JWKSURI 校验 id_token 签名的公钥地址
+	JWKSURI string `json:"jwksUri" mapstructure:"jwksUri"`
+	// UsernameClaim 作为用户名的 claim，默认为 preferred_username
+	UsernameClaim string `json:"usernameClaim" mapstructure:"usernameClaim"`
+	// GroupsClaim 作为用户组的 claim，默认为 groups
+	GroupsClaim string `json:"groupsClaim" mapstructure:"groupsClaim"`
+	// Timeout 请求身份提供者的超时时间，默认为 5s
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// oidcProvider OIDC 授权码模式的身份提供者
+type oidcProvider struct {
+	name   string
+	conf   *OIDCConfig
+	client *http.Client
+
+	lock sync.RWMutex
+	// 是否已经通过 discovery 获取到了各个 endpoint
+	discovered bool
+	// verifier 校验 id_token，JWKS 公钥由其缓存，遇到未知的 kid 时重新拉取以支持身份提供者轮换密钥
+	verifier *oidc.IDTokenVerifier
+	nowFunc  func() time.Time
+}
+
+// NewOIDCProvider 创建 OIDC 身份提供者
+func NewOIDCProvider(name string, conf *OIDCConfig) (RedirectProvider, error) {
+	if conf.Issuer == "" || conf.ClientID == "" || conf.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider(%s) issuer, clientId and redirectUrl are required", name)
+	}
+	if len(conf.Scopes) == 0 {
+		conf.Scopes = []string{"openid", "profile", "email"}
+	}
+	if conf.UsernameClaim == "" {
+		conf.UsernameClaim = defaultUsernameClaim
+	}
+	if conf.GroupsClaim == "" {
+		conf.GroupsClaim = defaultGroupsClaim
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 5 * time.Second
+	}
+	p := &oidcProvider{
+		name:    name,
+		conf:    conf,
+		client:  &http.Client{Timeout: conf.Timeout},
+		nowFunc: time.Now,
+	}
+	// 配置了全部 endpoint 时不需要 discovery
+	if conf.AuthorizationEndpoint != "" && conf.TokenEndpoint != "" && conf.JWKSURI != "" {
+		p.discovered = true
+		p.verifier = p.newVerifier()
+	}
+	return p, nil
+}
+
+// Name 身份提供者名称
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+// Type 身份提供者类型
+func (p *oidcProvider) Type() string {
+	return TypeOIDC
+}
+
+// AuthCodeURL 外部登录页面的地址
+func (p *oidcProvider) AuthCodeURL(state, nonce string) (string, error) {
+	if err := p.discover(context.Background()); err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.conf.ClientID)
+	query.Set("redirect_uri", p.conf.RedirectURL)
+	query.Set("scope", strings.Join(p.conf.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+
+	endpoint := p.conf.AuthorizationEndpoint
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + query.Encode(), nil
+	}
+	return endpoint + "?" + query.Encode(), nil
+}
+
+// Exchange 使用授权码换取 id_token，并校验 id_token 的签名、有效期以及 nonce
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	if code == "" {
+		return nil, fmt.Errorf("%w: authorization code is empty", ErrorAuthenticateFail)
+	}
+	if nonce == "" {
+		return nil, fmt.Errorf("%w: nonce is empty", ErrorAuthenticateFail)
+	}
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.conf.RedirectURL)
+	form.Set("client_id", p.conf.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.conf.TokenEndpoint,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.conf.ClientID), url.QueryEscape(p.conf.ClientSecret))
+
+	tokenRsp := struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	if err := p.doJSON(req, &tokenRsp); err != nil {
+		return nil, err
+	}
+	if tokenRsp.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrorAuthenticateFail, tokenRsp.Error, tokenRsp.ErrorDescription)
+	}
+	if tokenRsp.IDToken == "" {
+		return nil, fmt.Errorf("%w: id_token not found in token response", ErrorAuthenticateFail)
+	}
+
+	claims, err := p.verify(ctx, tokenRsp.IDToken)
+	if err != nil {
+		return nil, err
+	}
+	// id_token 必须是本次登录跳转申请的，防止 id_token 被重放，见 OIDC Core 3.1.3.7
+	if val, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(val), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: id_token nonce not match", ErrorAuthenticateFail)
+	}
+	return p.identity(claims)
+}
+
+// identity 从 id_token 的 claims 中解析身份信息
+func (p *oidcProvider) identity(claims map[string]interface{}) (*Identity, error) {
+	name, _ := claims[p.conf.UsernameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: claim %s not found in id_token", ErrorAuthenticateFail, p.conf.UsernameClaim)
+	}
+	identity := &Identity{Name: name}
+	identity.Email, _ = claims["email"].(string)
+	switch groups := claims[p.conf.GroupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if val, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, val)
+			}
+		}
+	case string:
+		identity.Groups = append(identity.Groups, groups)
+	}
+	return identity, nil
+}
+
+// verify 校验 id_token 的签名以及 iss/aud/exp 等信息，返回 claims
+func (p *oidcProvider) verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+	p.lock.RLock()
+	verifier := p.verifier
+	p.lock.RUnlock()
+
+	idToken, err := verifier.Verify(oidc.ClientContext(ctx, p.client), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorAuthenticateFail, err.Error())
+	}
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed id_token claims", ErrorAuthenticateFail)
+	}
+	// 存在多个 audience 或者携带了 azp 时，azp 必须是当前客户端，见 OIDC Core 3.1.3.7
+	azp, hasAzp := claims["azp"]
+	if len(idToken.Audience) > 1 || hasAzp {
+		if azp != p.conf.ClientID {
+			return nil, fmt.Errorf("%w: unexpected authorized party", ErrorAuthenticateFail)
+		}
+	}
+	return claims, nil
+}
+
+// discover 通过 openid-configuration 获取各个 endpoint
+func (p *oidcProvider) discover(ctx context.Context) error {
+	p.lock.RLock()
+	discovered := p.discovered
+	p.lock.RUnlock()
+	if discovered {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(p.conf.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return err
+	}
+	meta := struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}{}
+	if err := p.doJSON(req, &meta); err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.discovered {
+		return nil
+	}
+	if p.conf.AuthorizationEndpoint == "" {
+		p.conf.AuthorizationEndpoint = meta.AuthorizationEndpoint
+	}
+	if p.conf.TokenEndpoint == "" {
+		p.conf.TokenEndpoint = meta.TokenEndpoint
+	}
+	if p.conf.JWKSURI == "" {
+		p.conf.JWKSURI = meta.JWKSURI
+	}
+	if p.conf.AuthorizationEndpoint == "" || p.conf.TokenEndpoint == "" || p.conf.JWKSURI == "" {
+		return fmt.Errorf("oidc provider(%s) discovery endpoints incomplete", p.name)
+	}
+	p.discovered = true
+	p.verifier = p.newVerifier()
+	return nil
+}
+
+// newVerifier 创建 id_token 校验器，拉取 JWKS 时使用身份提供者的 http client 以及超时配置
+func (p *oidcProvider) newVerifier() *oidc.IDTokenVerifier {
+	keySet := oidc.NewRemoteKeySet(oidc.ClientContext(context.Background(), p.client), p.conf.JWKSURI)
+	return oidc.NewVerifier(p.conf.Issuer, keySet, &oidc.Config{
+		ClientID:             p.conf.ClientID,
+		SupportedSigningAlgs: supportedSigningAlgs,
+		Now:                  p.nowFunc,
+	})
+}
+
+// doJSON 发送请求并解析 json 应答
+func (p *oidcProvider) doJSON(req *http.Request, target interface{}) error {
+	rsp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("oidc provider(%s) request %s status %d: %w", p.name, req.URL.Path, rsp.StatusCode, err)
+	}
+	if rsp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("oidc provider(%s) request %s status %d", p.name, req.URL.Path, rsp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubIdP 测试用的 OIDC 身份提供者
+type stubIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+	codes  map[string]bool
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &stubIdP{key: key, codes: map[string]bool{"good-code": true}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		if clientID != "polaris" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		_ = r.ParseForm()
+		if !idp.codes[r.PostForm.Get("code")] {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"id_token":     idp.sign(t, "key-1", idp.claims),
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	idp.claims = map[string]interface{}{
+		"iss":                idp.server.URL,
+		"aud":                "polaris",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"nonce":              "nonce-1",
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"dev", "ops"},
+	}
+	return idp
+}
+
+func (idp *stubIdP) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	content := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(content))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestOIDCProvider(t *testing.T, idp *stubIdP) *oidcProvider {
+	p, err := New(&Config{
+		Name:  "sso",
+		Type:  TypeOIDC,
+		Owner: "polaris",
+		Option: map[interface{}]interface{}{
+			"issuer":       idp.server.URL,
+			"clientId":     "polaris",
+			"clientSecret": "secret",
+			"redirectUrl":  "http://polaris/core/v1/user/login/sso/callback",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*oidcProvider)
+}
+
+func TestOIDCProvider(t *testing.T) {
+	idp := newStubIdP(t)
+	defer idp.server.Close()
+	p := newTestOIDCProvider(t, idp)
+
+	t.Run("auth_code_url", func(t *testing.T) {
+		location, err := p.AuthCodeURL("state-1", "nonce-1")
+		assert.NoError(t, err)
+		u, err := url.Parse(location)
+		assert.NoError(t, err)
+		assert.Equal(t, "/authorize", u.Path)
+		assert.Equal(t, "state-1", u.Query().Get("state"))
+		assert.Equal(t, "nonce-1", u.Query().Get("nonce"))
+		assert.Equal(t, "polaris", u.Query().Get("client_id"))
+		assert.Equal(t, "openid profile email", u.Query().Get("scope"))
+	})
+
+	t.Run("exchange", func(t *testing.T) {
+		identity, err := p.Exchange(context.Background(), "good-code", "nonce-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", identity.Name)
+		assert.Equal(t, "alice@example.com", identity.Email)
+		assert.Equal(t, []string{"dev", "ops"}, identity.Groups)
+	})
+
+	t.Run("wrong_nonce", func(t *testing.T) {
+		_, err := p.Exchange(context.Background(), "good-code", "nonce-2")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+		_, err = p.Exchange(context.Background(), "good-code", "")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+
+		delete(idp.claims, "nonce")
+		defer func() {
+			idp.claims["nonce"] = "nonce-1"
+		}()
+		_, err = p.Exchange(context.Background(), "good-code", "nonce-1")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+
+	t.Run("bad_code", func(t *testing.T) {
+		_, err := p.Exchange(context.Background(), "bad-code", "nonce-1")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		idp.claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		defer func() {
+			idp.claims["exp"] = time.Now().Add(time.Hour).Unix()
+		}()
+		_, err := p.Exchange(context.Background(), "good-code", "nonce-1")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+
+	t.Run("wrong_audience", func(t *testing.T) {
+		idp.claims["aud"] = "other"
+		defer func() {
+			idp.claims["aud"] = "polaris"
+		}()
+		_, err := p.Exchange(context.Background(), "good-code", "nonce-1")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+
+	t.Run("multiple_audience_without_azp", func(t *testing.T) {
+		idp.claims["aud"] = []string{"polaris", "other"}
+		defer func() {
+			idp.claims["aud"] = "polaris"
+		}()
+		_, err := p.Exchange(context.Background(), "good-code", "nonce-1")
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+
+		idp.claims["azp"] = "polaris"
+		defer delete(idp.claims, "azp")
+		_, err = p.Exchange(context.Background(), "good-code", "nonce-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("bad_signature", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		token := (&stubIdP{key: other}).sign(t, "key-1", idp.claims)
+		_, err = p.verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+
+	t.Run("symmetric_alg", func(t *testing.T) {
+		header, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": "key-1", "typ": "JWT"})
+		payload, _ := json.Marshal(idp.claims)
+		content := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(content))
+		token := content + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		_, err := p.verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+
+	t.Run("unknown_kid", func(t *testing.T) {
+		_, err := p.verify(context.Background(), idp.sign(t, "key-2", idp.claims))
+		assert.ErrorIs(t, err, ErrorAuthenticateFail)
+	})
+}
+
+func TestConfig_MapGroups(t *testing.T) {
+	conf := &Config{GroupMapping: map[string]string{
+		"dev":                         "polaris-dev",
+		"cn=ops,ou=groups,dc=example": "polaris-ops",
+		"admin":                       "polaris-dev",
+	}}
+	groups := conf.MapGroups([]string{
+		"cn=dev,ou=groups,dc=example",
+		"cn=ops,ou=groups,dc=example",
+		"admin",
+		"unknown",
+	})
+	assert.Equal(t, []string{"polaris-dev", "polaris-ops"}, groups)
+}
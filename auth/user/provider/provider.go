@@ -0,0 +1,168 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// TypeOIDC OIDC 授权码模式登录
+	TypeOIDC = "oidc"
+	// TypeLDAP LDAP 用户名密码登录
+	TypeLDAP = "ldap"
+)
+
+var (
+	// ErrorAuthenticateFail 外部身份提供者认证失败
+	ErrorAuthenticateFail = errors.New("external identity authenticate fail")
+)
+
+// Identity 外部身份提供者认证通过后返回的身份信息
+type Identity struct {
+	// Name 用户名，作为北极星子账户的名称
+	Name string
+	// Email 用户邮箱
+	Email string
+	// Groups 用户在外部身份提供者中所属的用户组
+	Groups []string
+}
+
+// Provider 外部身份提供者
+type Provider interface {
+	// Name 身份提供者名称，同时作为自动创建的子账户的 source
+	Name() string
+	// Type 身份提供者类型
+	Type() string
+}
+
+// PasswordProvider 通过用户名密码进行认证的身份提供者，比如 LDAP
+type PasswordProvider interface {
+	Provider
+	// Authenticate 校验用户名密码
+	Authenticate(ctx context.Context, username, password string) (*Identity, error)
+}
+
+// RedirectProvider 需要跳转到外部登录页面进行认证的身份提供者，比如 OIDC 授权码模式
+type RedirectProvider interface {
+	Provider
+	// AuthCodeURL 外部登录页面的地址，nonce 会被身份提供者写入 id_token
+	AuthCodeURL(state, nonce string) (string, error)
+	// Exchange 使用回调的授权码换取身份信息，id_token 中的 nonce 必须与登录跳转时的一致
+	Exchange(ctx context.Context, code, nonce string) (*Identity, error)
+}
+
+// Config 身份提供者配置
+type Config struct {
+	// Name 身份提供者名称
+	Name string `json:"name" mapstructure:"name"`
+	// Type 身份提供者类型，oidc 或者 ldap
+	Type string `json:"type" mapstructure:"type"`
+	// Owner 自动创建的子账户所属的主账户名称
+	Owner string `json:"owner" mapstructure:"owner"`
+	// GroupMapping 外部用户组到北极星用户组名称的映射，外部用户组可以是完整的 DN 或者第一个 RDN 的值
+	GroupMapping map[string]string `json:"groupMapping" mapstructure:"groupMapping"`
+	// Option 身份提供者自身的配置
+	Option interface{} `json:"option" mapstructure:"option"`
+}
+
+// MapGroups 将外部用户组转换为北极星用户组名称
+func (c *Config) MapGroups(groups []string) []string {
+	ret := make([]string, 0, len(groups))
+	seen := map[string]struct{}{}
+	for _, group := range groups {
+		name, ok := c.GroupMapping[group]
+		if !ok {
+			name, ok = c.GroupMapping[firstRDNValue(group)]
+		}
+		if !ok {
+			continue
+		}
+		if _, exist := seen[name]; exist {
+			continue
+		}
+		seen[name] = struct{}{}
+		ret = append(ret, name)
+	}
+	return ret
+}
+
+// firstRDNValue 获取 DN 中第一个 RDN 的值，比如 cn=dev,ou=groups,dc=example 返回 dev
+func firstRDNValue(dn string) string {
+	rdn := strings.SplitN(dn, ",", 2)[0]
+	if index := strings.Index(rdn, "="); index >= 0 {
+		return strings.TrimSpace(rdn[index+1:])
+	}
+	return dn
+}
+
+// New 根据配置创建身份提供者
+func New(conf *Config) (Provider, error) {
+	if conf.Name == "" {
+		return nil, errors.New("login provider name is empty")
+	}
+	if conf.Owner == "" {
+		return nil, fmt.Errorf("login provider(%s) owner is empty", conf.Name)
+	}
+	switch conf.Type {
+	case TypeOIDC:
+		opt := &OIDCConfig{}
+		if err := decodeOption(conf.Option, opt); err != nil {
+			return nil, err
+		}
+		return NewOIDCProvider(conf.Name, opt)
+	case TypeLDAP:
+		opt := &LDAPConfig{}
+		if err := decodeOption(conf.Option, opt); err != nil {
+			return nil, err
+		}
+		return NewLDAPProvider(conf.Name, opt)
+	default:
+		return nil, fmt.Errorf("login provider(%s) type(%s) not support", conf.Name, conf.Type)
+	}
+}
+
+// decodeOption 解析身份提供者的配置，配置可能来自 yaml 解析出来的 map[interface{}]interface{}
+func decodeOption(option interface{}, target interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           target,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(option)
+}
+
+// ParseConfigs 解析身份提供者配置列表
+func ParseConfigs(raw interface{}) ([]*Config, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var confs []*Config
+	if err := decodeOption(raw, &confs); err != nil {
+		return nil, err
+	}
+	return confs, nil
+}
@@ -18,8 +18,10 @@
 package defaultuser
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"sync"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
@@ -27,6 +29,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/polarismesh/polaris/auth"
+	"github.com/polarismesh/polaris/auth/user/provider"
 	cachetypes "github.com/polarismesh/polaris/cache/api"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
@@ -39,6 +42,14 @@ import (
 type AuthConfig struct {
 	// Salt 相关密码、token加密的salt
 	Salt string `json:"salt" xml:"salt"`
+	// DisableLocalLogin 关闭本地账户密码登录，只允许通过外部身份提供者登录，admin 账户不受影响
+	DisableLocalLogin bool `json:"disableLocalLogin" xml:"disableLocalLogin"`
+	// Providers 外部身份提供者配置
+	Providers []*provider.Config `json:"-" xml:"-"`
+	// PasswordPolicy 本地账户的密码策略
+	PasswordPolicy *PasswordPolicy `json:"passwordPolicy" xml:"passwordPolicy"`
+	// LoginLockout 登录失败锁定策略
+	LoginLockout *LoginLockout `json:"loginLockout" xml:"loginLockout"`
 }
 
 // Verify 检查配置是否合法
@@ -50,8 +61,10 @@ func (cfg *AuthConfig) Verify() error {
 	default:
 		return errors.New("[Auth][Config] salt len must 16 | 24 | 32")
 	}
-
-	return nil
+	if err := cfg.PasswordPolicy.Verify(); err != nil {
+		return err
+	}
+	return cfg.LoginLockout.Verify()
 }
 
 // DefaultUserConfig 返回一个默认的鉴权配置
@@ -68,6 +81,12 @@ type Server struct {
 	history  plugin.History
 	cacheMgr cachetypes.CacheManager
 	helper   auth.UserHelper
+	// providers 外部身份提供者，按照配置的顺序排列
+	providers []*loginProvider
+	// apiTokens 具名 API token 的本地缓存，key 为 token ID，value 为 *cachedAPIToken
+	apiTokens sync.Map
+	// passwordRecords 用户密码修改记录的本地缓存，key 为用户 ID，value 为 *cachedPasswordRecord
+	passwordRecords sync.Map
 }
 
 // Name of the user operator plugin
@@ -91,7 +110,7 @@ func (svr *Server) Initialize(authOpt *auth.Config, storage store.Store, cacheMg
 		log.Warnf("Not Found History Log Plugin")
 	}
 	svr.helper = &DefaultUserHelper{svr: svr}
-	return nil
+	return svr.initProviders()
 }
 
 func (svr *Server) parseOptions(options *auth.Config) error {
@@ -110,7 +129,10 @@ func (svr *Server) parseOptions(options *auth.Config) error {
 		if len(options.Option) > 0 {
 			log.Warn("auth.user.option or auth.strategy.option has set, auth.option will ignore")
 		}
-		userContentBytes, err = json.Marshal(options.User.Option)
+		if cfg.Providers, err = parseProviders(options.User.Option); err != nil {
+			return err
+		}
+		userContentBytes, err = json.Marshal(withoutProviders(options.User.Option))
 		if err != nil {
 			return err
 		}
@@ -119,7 +141,10 @@ func (svr *Server) parseOptions(options *auth.Config) error {
 		}
 	} else {
 		log.Warn("[Auth][Checker] auth.option has deprecated, use auth.user.option and auth.strategy.option instead.")
-		authContentBytes, err = json.Marshal(options.Option)
+		if cfg.Providers, err = parseProviders(options.Option); err != nil {
+			return err
+		}
+		authContentBytes, err = json.Marshal(withoutProviders(options.Option))
 		if err != nil {
 			return err
 		}
@@ -135,25 +160,45 @@ func (svr *Server) parseOptions(options *auth.Config) error {
 }
 
 // Login 登录动作
-func (svr *Server) Login(req *apisecurity.LoginRequest) *apiservice.Response {
+func (svr *Server) Login(ctx context.Context, req *apisecurity.LoginRequest) *apiservice.Response {
 	username := req.GetName().GetValue()
 	ownerName := req.GetOwner().GetValue()
 	if ownerName == "" {
 		ownerName = username
 	}
+	ip := utils.ParseClientIP(ctx)
 	user := svr.cacheMgr.User().GetUserByName(username, ownerName)
+	if rsp := svr.checkLoginLocked(ip, user); rsp != nil {
+		return rsp
+	}
+	if p := svr.findPasswordProvider(user, req.GetOwner().GetValue()); p != nil {
+		rsp := svr.loginByPassword(p, username, req.GetPassword().GetValue())
+		if rsp.GetCode().GetValue() == uint32(apimodel.Code_NotAllowedAccess) {
+			svr.recordLoginFailure(ip, user)
+		}
+		return rsp
+	}
 	if user == nil {
+		svr.recordLoginFailure(ip, nil)
 		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
 	}
+	if svr.authOpt.DisableLocalLogin && user.Type != model.AdminUserRole {
+		return api.NewAuthResponseWithMsg(apimodel.Code_NotAllowedAccess, "local login is disabled")
+	}
 
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.GetPassword().GetValue()))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			svr.recordLoginFailure(ip, user)
 			return api.NewAuthResponseWithMsg(
 				apimodel.Code_NotAllowedAccess, model.ErrorWrongUsernameOrPassword.Error())
 		}
 		return api.NewAuthResponseWithMsg(apimodel.Code_ExecuteException, model.ErrorWrongUsernameOrPassword.Error())
 	}
+	svr.resetLoginFailure(user)
+	if rsp := svr.checkPasswordExpired(user); rsp != nil {
+		return rsp
+	}
 
 	return api.NewLoginResponse(apimodel.Code_ExecuteSuccess, &apisecurity.LoginResponse{
 		UserId:  utils.NewStringValue(user.ID),
@@ -31,6 +31,7 @@ import (
 
 	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/secure"
 )
 
 // decodeToken 解析 token 信息，如果 t == ""，直接返回一个空对象
@@ -59,9 +60,32 @@ func (svr *Server) decodeToken(t string) (auth.OperatorInfo, error) {
 		OperatorID:  detail[1],
 		Role:        model.UnknownUserRole,
 	}
+	if detail[0] == model.TokenForAPIToken {
+		// 具名 API token 的详细信息以及所属的用户/用户组在 checkToken 中加载
+		tokenInfo.APIToken = &model.APIToken{ID: detail[1]}
+	}
 	return tokenInfo, nil
 }
 
+// certPrincipalToken 获取客户端证书映射的 principal 对应的 token，principal 格式为 user:{用户ID} 或者 group:{用户组ID}
+func (svr *Server) certPrincipalToken(principal string) (string, error) {
+	switch {
+	case strings.HasPrefix(principal, secure.PrincipalUserPrefix):
+		user := svr.cacheMgr.User().GetUserByID(strings.TrimPrefix(principal, secure.PrincipalUserPrefix))
+		if user == nil {
+			return "", model.ErrorNoUser
+		}
+		return user.Token, nil
+	case strings.HasPrefix(principal, secure.PrincipalGroupPrefix):
+		group := svr.cacheMgr.User().GetGroup(strings.TrimPrefix(principal, secure.PrincipalGroupPrefix))
+		if group == nil {
+			return "", model.ErrorNoUserGroup
+		}
+		return group.Token, nil
+	}
+	return "", model.ErrorTokenInvalid
+}
+
 // checkToken 对 token 进行检查，如果 token 是一个空，直接返回默认值，但是不返回错误
 // return {owner-id} {is-owner} {error}
 func (svr *Server) checkToken(tokenInfo *auth.OperatorInfo) (string, bool, error) {
@@ -69,6 +93,12 @@ func (svr *Server) checkToken(tokenInfo *auth.OperatorInfo) (string, bool, error
 		return "", false, nil
 	}
 
+	if tokenInfo.APIToken != nil {
+		if err := svr.checkAPIToken(tokenInfo); err != nil {
+			return "", false, err
+		}
+	}
+
 	id := tokenInfo.OperatorID
 	if tokenInfo.IsUserToken {
 		user := svr.cacheMgr.User().GetUserByID(id)
@@ -76,11 +106,12 @@ func (svr *Server) checkToken(tokenInfo *auth.OperatorInfo) (string, bool, error
 			return "", false, model.ErrorNoUser
 		}
 
-		if tokenInfo.Origin != user.Token {
-			return "", false, model.ErrorTokenNotExist
+		if tokenInfo.APIToken == nil {
+			if tokenInfo.Origin != user.Token {
+				return "", false, model.ErrorTokenNotExist
+			}
+			tokenInfo.Disable = !user.TokenEnable
 		}
-
-		tokenInfo.Disable = !user.TokenEnable
 		if user.Owner == "" {
 			return user.ID, true, nil
 		}
@@ -92,11 +123,12 @@ func (svr *Server) checkToken(tokenInfo *auth.OperatorInfo) (string, bool, error
 		return "", false, model.ErrorNoUserGroup
 	}
 
-	if tokenInfo.Origin != group.Token {
-		return "", false, model.ErrorTokenNotExist
+	if tokenInfo.APIToken == nil {
+		if tokenInfo.Origin != group.Token {
+			return "", false, model.ErrorTokenNotExist
+		}
+		tokenInfo.Disable = !group.TokenEnable
 	}
-
-	tokenInfo.Disable = !group.TokenEnable
 	return group.Owner, false, nil
 }
 
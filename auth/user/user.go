@@ -79,6 +79,9 @@ func (svr *Server) CreateUser(ctx context.Context, req *apisecurity.User) *apise
 	if checkErrResp := checkCreateUser(req); checkErrResp != nil {
 		return checkErrResp
 	}
+	if err := svr.checkNewPassword("", req.GetPassword().GetValue()); err != nil {
+		return api.NewUserResponseWithMsg(apimodel.Code_InvalidUserPassword, err.Error(), req)
+	}
 
 	// 如果创建的目标账户类型是非子账户，则 ownerId 需要设置为 “”
 	if convertCreateUserRole(authcommon.ParseUserRole(ctx)) != model.SubAccountUserRole {
@@ -125,6 +128,7 @@ func (svr *Server) createUser(ctx context.Context, req *apisecurity.User) *apise
 		log.Error("[Auth][User] add user into store", utils.RequestID(ctx), zap.Error(err))
 		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
 	}
+	svr.savePasswordRecord(data.ID, data.Password)
 
 	log.Info("[Auth][User] create user", utils.RequestID(ctx), zap.String("name", req.GetName().GetValue()))
 	svr.RecordHistory(userRecordEntry(ctx, req, data, model.OCreate))
@@ -183,6 +187,10 @@ func (svr *Server) UpdateUserPassword(ctx context.Context, req *apisecurity.Modi
 		return api.NewAuthResponse(apimodel.Code_NotFoundUser)
 	}
 
+	if err := svr.checkNewPassword(user.ID, req.GetNewPassword().GetValue()); err != nil {
+		return api.NewAuthResponseWithMsg(apimodel.Code_InvalidUserPassword, err.Error())
+	}
+
 	ignoreOrigin := authcommon.ParseUserRole(ctx) == model.AdminUserRole ||
 		authcommon.ParseUserRole(ctx) == model.OwnerUserRole
 	data, needUpdate, err := updateUserPasswordAttribute(ignoreOrigin, user, req)
@@ -203,6 +211,7 @@ func (svr *Server) UpdateUserPassword(ctx context.Context, req *apisecurity.Modi
 			zap.Error(err))
 		return api.NewAuthResponse(commonstore.StoreCode2APICode(err))
 	}
+	svr.savePasswordRecord(user.ID, data.Password)
 
 	log.Info("[Auth][User] update user", utils.RequestID(ctx), zap.String("user-id", req.Id.GetValue()))
 
@@ -421,6 +430,16 @@ func (svr *Server) ResetUserToken(ctx context.Context, req *apisecurity.User) *a
 func (svr *Server) CheckCredential(authCtx *model.AcquireContext) error {
 	checkErr := func() error {
 		authToken := utils.ParseAuthToken(authCtx.GetRequestContext())
+		// 没有携带 token 时，使用双向 tls 下客户端证书映射的 principal 作为操作者
+		if principal := utils.ParseCertPrincipal(authCtx.GetRequestContext()); authToken == "" && principal != "" {
+			token, err := svr.certPrincipalToken(principal)
+			if err != nil {
+				log.Error("[Auth][Checker] parse cert principal", utils.RequestID(authCtx.GetRequestContext()),
+					zap.String("principal", principal), zap.Error(err))
+				return err
+			}
+			authToken = token
+		}
 		operator, err := svr.decodeToken(authToken)
 		if err != nil {
 			log.Error("[Auth][Checker] decode token", utils.RequestID(authCtx.GetRequestContext()), zap.Error(err))
@@ -433,11 +452,30 @@ func (svr *Server) CheckCredential(authCtx *model.AcquireContext) error {
 			return err
 		}
 
+		// 密码过期后用户 token 不能再访问控制台接口，客户端使用的 token 不受影响
+		if operator.IsUserToken && operator.APIToken == nil && authCtx.IsFromConsole() &&
+			svr.passwordExpired(operator.OperatorID) {
+			log.Warn("[Auth][Checker] user password expired", utils.RequestID(authCtx.GetRequestContext()),
+				zap.String("user", operator.OperatorID))
+			return model.ErrorPasswordExpired
+		}
+
+		if operator.APIToken != nil {
+			if err := svr.checkAPITokenScope(authCtx, operator.APIToken); err != nil {
+				log.Error("[Auth][Checker] check api token scope", utils.RequestID(authCtx.GetRequestContext()),
+					zap.String("token", operator.APIToken.ID), zap.Error(err))
+				return err
+			}
+		}
+
 		operator.OwnerID = ownerId
 		ctx := authCtx.GetRequestContext()
 		ctx = context.WithValue(ctx, utils.ContextIsOwnerKey, isOwner)
 		ctx = context.WithValue(ctx, utils.ContextUserIDKey, operator.OperatorID)
 		ctx = context.WithValue(ctx, utils.ContextOwnerIDKey, ownerId)
+		if operator.APIToken != nil {
+			ctx = context.WithValue(ctx, utils.ContextAPITokenIDKey, operator.APIToken.ID)
+		}
 		authCtx.SetRequestContext(ctx)
 		svr.parseOperatorInfo(operator, authCtx)
 		if operator.Disable {
@@ -460,6 +498,29 @@ func (svr *Server) CheckCredential(authCtx *model.AcquireContext) error {
 	return nil
 }
 
+// ParsePrincipal 解析 token 对应的操作者，只校验 token 是否有效，不做权限检查
+func (svr *Server) ParsePrincipal(token string) (string, error) {
+	operator, err := svr.decodeToken(token)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := svr.checkToken(&operator); err != nil {
+		return "", err
+	}
+	if operator.IsUserToken {
+		user := svr.cacheMgr.User().GetUserByID(operator.OperatorID)
+		if user == nil {
+			return "", model.ErrorNoUser
+		}
+		return auth.PrincipalName(model.PrincipalUser, user.Name), nil
+	}
+	group := svr.cacheMgr.User().GetGroup(operator.OperatorID)
+	if group == nil {
+		return "", model.ErrorNoUserGroup
+	}
+	return auth.PrincipalName(model.PrincipalGroup, group.Name), nil
+}
+
 func (svr *Server) parseOperatorInfo(operator auth.OperatorInfo, authCtx *model.AcquireContext) {
 	ctx := authCtx.GetRequestContext()
 	if operator.IsUserToken {
@@ -325,7 +325,7 @@ func Test_server_Login(t *testing.T) {
 	defer userTest.Clean()
 
 	t.Run("正常登陆", func(t *testing.T) {
-		rsp := userTest.svr.Login(&apisecurity.LoginRequest{
+		rsp := userTest.svr.Login(context.Background(), &apisecurity.LoginRequest{
 			Name:     &wrappers.StringValue{Value: userTest.users[0].Name},
 			Password: &wrappers.StringValue{Value: "polaris"},
 		})
@@ -334,7 +334,7 @@ func Test_server_Login(t *testing.T) {
 	})
 
 	t.Run("错误的密码", func(t *testing.T) {
-		rsp := userTest.svr.Login(&apisecurity.LoginRequest{
+		rsp := userTest.svr.Login(context.Background(), &apisecurity.LoginRequest{
 			Name:     &wrappers.StringValue{Value: userTest.users[0].Name},
 			Password: &wrappers.StringValue{Value: "polaris_123"},
 		})
@@ -345,6 +345,57 @@ func Test_server_Login(t *testing.T) {
 	})
 }
 
+func Test_server_CheckCredential_CertPrincipal(t *testing.T) {
+
+	userTest := newUserTest(t)
+	defer userTest.Clean()
+
+	check := func(ctx context.Context) (*model.AcquireContext, error) {
+		authCtx := model.NewAcquireContext(
+			model.WithRequestContext(ctx),
+			model.WithModule(model.DiscoverModule),
+			model.WithOperation(model.Modify),
+		)
+		return authCtx, userTest.svr.CheckCredential(authCtx)
+	}
+	operatorOf := func(authCtx *model.AcquireContext) auth.OperatorInfo {
+		val, _ := authCtx.GetAttachment(model.TokenDetailInfoKey)
+		return val.(auth.OperatorInfo)
+	}
+
+	t.Run("证书映射到用户", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.ContextCertPrincipalKey, "user:"+userTest.users[1].ID)
+		authCtx, err := check(ctx)
+		assert.NoError(t, err)
+		operator := operatorOf(authCtx)
+		assert.True(t, operator.IsUserToken)
+		assert.Equal(t, userTest.users[1].ID, operator.OperatorID)
+	})
+
+	t.Run("证书映射到用户组", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.ContextCertPrincipalKey, "group:"+userTest.groups[1].ID)
+		authCtx, err := check(ctx)
+		assert.NoError(t, err)
+		operator := operatorOf(authCtx)
+		assert.False(t, operator.IsUserToken)
+		assert.Equal(t, userTest.groups[1].ID, operator.OperatorID)
+	})
+
+	t.Run("携带token时以token为准", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.ContextCertPrincipalKey, "user:"+userTest.users[1].ID)
+		ctx = context.WithValue(ctx, utils.ContextAuthTokenKey, userTest.users[2].Token)
+		authCtx, err := check(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, userTest.users[2].ID, operatorOf(authCtx).OperatorID)
+	})
+
+	t.Run("映射的用户不存在", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), utils.ContextCertPrincipalKey, "user:not-exist")
+		_, err := check(ctx)
+		assert.ErrorIs(t, err, model.ErrorNoUser)
+	})
+}
+
 func Test_server_UpdateUser(t *testing.T) {
 
 	userTest := newUserTest(t)
@@ -29,6 +29,7 @@ import (
 	"github.com/polarismesh/polaris/apiserver"
 	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/log"
 	"github.com/polarismesh/polaris/config"
 	"github.com/polarismesh/polaris/namespace"
@@ -51,6 +52,7 @@ type Config struct {
 	Store        store.Config       `yaml:"store"`
 	Auth         auth.Config        `yaml:"auth"`
 	Plugin       plugin.Config      `yaml:"plugin"`
+	EventHub     eventhub.Config    `yaml:"eventhub"`
 }
 
 // Bootstrap 启动引导配置
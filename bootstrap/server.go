@@ -99,6 +99,10 @@ func Start(configFilePath string) {
 
 	metrics.InitMetrics()
 	eventhub.InitEventHub()
+	if err = eventhub.RegisterPersistentTopics(&cfg.EventHub); err != nil {
+		fmt.Printf("[ERROR] register eventhub persistent topics fail: %v\n", err)
+		return
+	}
 
 	// 设置插件配置
 	plugin.SetPluginConfig(&cfg.Plugin)
@@ -548,6 +548,8 @@ type (
 		GetStrategyDetailsByGroupID(groupId string) []*model.StrategyDetail
 		// IsResourceLinkStrategy 该资源是否关联了鉴权策略
 		IsResourceLinkStrategy(resType apisecurity.ResourceType, resId string) bool
+		// GetSelectorStrategies 获取设置了资源标签选择器的策略
+		GetSelectorStrategies() []*model.StrategyDetail
 		// IsResourceEditable 判断该资源是否可以操作
 		IsResourceEditable(principal model.Principal, resType apisecurity.ResourceType, resId string) bool
 		// ForceSync 强制同步鉴权策略到cache (串行)
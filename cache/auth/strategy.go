@@ -48,6 +48,8 @@ type strategyCache struct {
 	namespace2Strategy   *utils.SyncMap[string, *utils.SyncSet[string]]
 	service2Strategy     *utils.SyncMap[string, *utils.SyncSet[string]]
 	configGroup2Strategy *utils.SyncMap[string, *utils.SyncSet[string]]
+	// selectorStrategies 设置了资源标签选择器的策略
+	selectorStrategies *utils.SyncMap[string, *model.StrategyDetail]
 
 	lastMtime    int64
 	userCache    *userCache
@@ -70,6 +72,7 @@ func (sc *strategyCache) Initialize(c map[string]interface{}) error {
 	sc.namespace2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.service2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.configGroup2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	sc.selectorStrategies = utils.NewSyncMap[string, *model.StrategyDetail]()
 	sc.singleFlight = new(singleflight.Group)
 	sc.lastMtime = 0
 	return nil
@@ -119,6 +122,11 @@ func (sc *strategyCache) setStrategys(strategies []*model.StrategyDetail) (map[s
 
 	for index := range strategies {
 		rule := strategies[index]
+		if rule.Valid && rule.Conditions != nil && len(rule.Conditions.ResourceSelectors) != 0 {
+			sc.selectorStrategies.Store(rule.ID, rule)
+		} else {
+			sc.selectorStrategies.Delete(rule.ID)
+		}
 		if !rule.Valid {
 			sc.strategys.Delete(rule.ID)
 			remove++
@@ -292,6 +300,7 @@ func (sc *strategyCache) Clear() error {
 	sc.namespace2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.service2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
 	sc.configGroup2Strategy = utils.NewSyncMap[string, *utils.SyncSet[string]]()
+	sc.selectorStrategies = utils.NewSyncMap[string, *model.StrategyDetail]()
 	sc.lastMtime = 0
 	return nil
 }
@@ -418,6 +427,15 @@ func (sc *strategyCache) getStrategyDetails(uid string, gid string) []*model.Str
 	return result
 }
 
+// GetSelectorStrategies 获取设置了资源标签选择器的策略
+func (sc *strategyCache) GetSelectorStrategies() []*model.StrategyDetail {
+	ret := make([]*model.StrategyDetail, 0, 4)
+	sc.selectorStrategies.Range(func(_ string, rule *model.StrategyDetail) {
+		ret = append(ret, rule)
+	})
+	return ret
+}
+
 // IsResourceLinkStrategy 校验
 func (sc *strategyCache) IsResourceLinkStrategy(resType apisecurity.ResourceType, resId string) bool {
 	hasLinkRule := func(sets *utils.SyncSet[string]) bool {
@@ -20,6 +20,7 @@ package gray
 import (
 	"bytes"
 	"encoding/json"
+	"strconv"
 	"time"
 
 	regexp "github.com/dlclark/regexp2"
@@ -142,7 +143,34 @@ func (gc *grayCache) HitGrayRule(name string, labels map[string]string) bool {
 		return false
 	}
 
-	return grayMatch(rule, labels)
+	return grayMatch(rule, withGrayBucket(name, rule, labels))
+}
+
+// withGrayBucket 灰度规则中包含按比例灰度的分桶条件时，根据客户端 ID 计算客户端所在的分桶
+func withGrayBucket(name string, rule []*apimodel.ClientLabel, labels map[string]string) map[string]string {
+	needBucket := false
+	for i := range rule {
+		if rule[i].Key == model.ClientLabel_Bucket {
+			needBucket = true
+			break
+		}
+	}
+	if !needBucket {
+		return labels
+	}
+	clientId := labels[model.ClientLabel_ID]
+	if clientId == "" {
+		clientId = labels[model.ClientLabel_IP]
+	}
+	ret := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		ret[k] = v
+	}
+	delete(ret, model.ClientLabel_Bucket)
+	if clientId != "" {
+		ret[model.ClientLabel_Bucket] = strconv.Itoa(model.ClientGrayBucket(name, clientId))
+	}
+	return ret
 }
 
 func grayMatch(rule []*apimodel.ClientLabel, labels map[string]string) bool {
@@ -18,11 +18,14 @@
 package gray
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
 )
 
 func TestMatch(t *testing.T) {
@@ -58,3 +61,30 @@ func TestMatch(t *testing.T) {
 	})
 	assert.Equal(t, ok, true)
 }
+
+func TestMatchPercentage(t *testing.T) {
+	name := "config@default@group@file"
+	rule := model.BuildPercentageGrayRule(30)
+
+	hit := 0
+	for i := 0; i < 1000; i++ {
+		labels := withGrayBucket(name, rule, map[string]string{
+			model.ClientLabel_ID: fmt.Sprintf("client-%d", i),
+		})
+		if grayMatch(rule, labels) {
+			hit++
+		}
+	}
+	assert.InDelta(t, 300, hit, 60)
+
+	// 同一个客户端的分桶结果是稳定的，比例扩大后仍然命中
+	labels := withGrayBucket(name, rule, map[string]string{model.ClientLabel_IP: "127.0.0.1"})
+	if grayMatch(rule, labels) {
+		assert.True(t, grayMatch(model.BuildPercentageGrayRule(50), labels))
+	}
+	assert.True(t, grayMatch(model.BuildPercentageGrayRule(100), labels))
+
+	// 客户端伪造的分桶不生效，缺少客户端标识时不命中
+	labels = withGrayBucket(name, rule, map[string]string{model.ClientLabel_Bucket: "0"})
+	assert.False(t, grayMatch(rule, labels))
+}
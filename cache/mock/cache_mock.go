@@ -2721,6 +2721,20 @@ func (mr *MockStrategyCacheMockRecorder) GetStrategyDetailsByGroupID(groupId int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStrategyDetailsByGroupID", reflect.TypeOf((*MockStrategyCache)(nil).GetStrategyDetailsByGroupID), groupId)
 }
 
+// GetSelectorStrategies mocks base method.
+func (m *MockStrategyCache) GetSelectorStrategies() []*model.StrategyDetail {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSelectorStrategies")
+	ret0, _ := ret[0].([]*model.StrategyDetail)
+	return ret0
+}
+
+// GetSelectorStrategies indicates an expected call of GetSelectorStrategies.
+func (mr *MockStrategyCacheMockRecorder) GetSelectorStrategies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSelectorStrategies", reflect.TypeOf((*MockStrategyCache)(nil).GetSelectorStrategies))
+}
+
 // GetStrategyDetailsByUID mocks base method.
 func (m *MockStrategyCache) GetStrategyDetailsByUID(uid string) []*model.StrategyDetail {
 	m.ctrl.T.Helper()
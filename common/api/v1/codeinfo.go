@@ -167,6 +167,12 @@ const (
 	NotFoundResourceConfigFile     = uint32(apimodel.Code_NotFoundResourceConfigFile)
 	InvalidConfigFileTemplateName  = uint32(apimodel.Code_InvalidConfigFileTemplateName)
 	InvalidMatchRule               = uint32(apimodel.Code_InvalidMatchRule)
+	// InvalidConfigFileSchema 配置校验规则本身不合法，规范中暂未定义该错误码
+	InvalidConfigFileSchema = uint32(400811)
+	// ConfigFileSchemaViolation 配置内容不满足挂载的校验规则，规范中暂未定义该错误码
+	ConfigFileSchemaViolation = uint32(400812)
+	// ConfigReleaseRequestStatusConflict 发布申请当前的状态不允许执行该操作，规范中暂未定义该错误码
+	ConfigReleaseRequestStatusConflict = uint32(400813)
 
 	// 鉴权相关错误码
 	InvalidUserOwners         = uint32(apimodel.Code_InvalidUserOwners)
@@ -327,10 +333,13 @@ var code2info = map[uint32]string{
 	InvalidConfigFileFormat:        "invalid config file format, support json,xml,html,properties,text,yaml",
 	InvalidConfigFileTags: "invalid config file tags, tags should be pair, like key1,value1,key2,value2, " +
 		"both key and value should not blank",
-	InvalidWatchConfigFileFormat:  "invalid watch config file format",
-	NotFoundResourceConfigFile:    "config file not existed",
-	InvalidConfigFileTemplateName: "invalid config file template name",
-	InvalidMatchRule:              "invalid gray config beta labels",
+	InvalidWatchConfigFileFormat:       "invalid watch config file format",
+	NotFoundResourceConfigFile:         "config file not existed",
+	InvalidConfigFileTemplateName:      "invalid config file template name",
+	InvalidMatchRule:                   "invalid gray config beta labels",
+	InvalidConfigFileSchema:            "invalid config file schema",
+	ConfigFileSchemaViolation:          "config file content violates the schema",
+	ConfigReleaseRequestStatusConflict: "config release request status not allow this operation",
 
 	// 鉴权错误
 	NotFoundUser:             "not found user",
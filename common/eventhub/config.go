@@ -0,0 +1,83 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eventhub
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultRetentionSize = 512 * 1024 * 1024
+	defaultRetentionTime = 72 * time.Hour
+)
+
+// Config eventhub 配置
+type Config struct {
+	// DisablePersistent 关闭持久化主题，全部主题都只在内存中投递
+	DisablePersistent bool `yaml:"disablePersistent"`
+	// DataDir 持久化主题的数据目录，为空时使用 DefaultDataDir
+	DataDir string `yaml:"dataDir"`
+	// SegmentSize 单个分段文件的最大字节数
+	SegmentSize int64 `yaml:"segmentSize"`
+	// RetentionSize 单个主题全部分段的总字节数上限，默认 512MB
+	RetentionSize int64 `yaml:"retentionSize"`
+	// RetentionTime 分段最后一次写入后的保留时间，默认 72h
+	RetentionTime time.Duration `yaml:"retentionTime"`
+}
+
+// persistentTopics 需要至少投递一次的主题以及对应的编解码器
+var persistentTopics = map[string]Codec{
+	// 配置发布事件丢失会导致客户端长轮询无法感知配置变更
+	ConfigFilePublishTopic: NewJSONCodec[*PublishConfigFileEvent](),
+}
+
+// RegisterPersistentTopics 按照配置注册持久化主题，需要在任何 Subscribe 或者 Publish 之前调用
+func RegisterPersistentTopics(cfg *Config) error {
+	if globalEventHub == nil {
+		return ErrorEventhubNotInitialize
+	}
+	return globalEventHub.registerPersistentTopics(cfg)
+}
+
+func (eh *eventHub) registerPersistentTopics(cfg *Config) error {
+	if cfg == nil || cfg.DisablePersistent {
+		return nil
+	}
+	retentionSize, retentionTime := cfg.RetentionSize, cfg.RetentionTime
+	if retentionSize <= 0 {
+		retentionSize = defaultRetentionSize
+	}
+	if retentionTime <= 0 {
+		retentionTime = defaultRetentionTime
+	}
+	for name, codec := range persistentTopics {
+		err := eh.RegisterPublisher(name, PublishOption{
+			Persistent:    true,
+			DataDir:       cfg.DataDir,
+			SegmentSize:   cfg.SegmentSize,
+			RetentionSize: retentionSize,
+			RetentionTime: retentionTime,
+			Codec:         codec,
+		})
+		if err != nil {
+			return fmt.Errorf("register persistent topic %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -59,6 +59,8 @@ type eventHub struct {
 	mu     sync.RWMutex
 }
 
+// RegisterPublisher register topic publisher, persistent topic must be registered
+// before any Subscribe or Publish call on it
 func RegisterPublisher(topic string, opt PublishOption) error {
 	if globalEventHub == nil {
 		return ErrorEventhubNotInitialize
@@ -67,6 +69,9 @@ func RegisterPublisher(topic string, opt PublishOption) error {
 }
 
 func (eh *eventHub) RegisterPublisher(topic string, opt PublishOption) error {
+	if opt.Persistent {
+		return eh.createPersistentTopic(topic, opt)
+	}
 	_ = eh.createTopic(topic, opt)
 	return nil
 }
@@ -83,8 +88,7 @@ func Publish(topic string, event Event) error {
 
 func (eh *eventHub) Publish(topic string, event Event) error {
 	t := eh.loadOrStoreTopic(topic)
-	t.publish(eh.ctx, event)
-	return nil
+	return t.publish(eh.ctx, event)
 }
 
 // Subscribe subscribe topic
@@ -0,0 +1,358 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eventhub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/log"
+)
+
+const (
+	// OffsetLatest 从最新写入的位置开始消费
+	OffsetLatest int64 = -1
+	// OffsetEarliest 从当前保留的最早的位置开始消费
+	OffsetEarliest int64 = -2
+	// DeadLetterTopicSuffix 死信主题名称的后缀
+	DeadLetterTopicSuffix = ".dlq"
+	// DefaultDataDir 持久化主题默认的数据目录
+	DefaultDataDir = "./data/eventhub"
+
+	defaultMaxRetry      = 3
+	defaultRetryInterval = time.Second
+	flushInterval        = time.Second
+)
+
+var (
+	ErrorCodecNotSet        = errors.New("eventhub persistent topic codec not set")
+	ErrorTopicModeConflict  = errors.New("eventhub topic already exists in memory mode")
+	ErrorDurableNameInUse   = errors.New("eventhub durable subscription name already in use")
+	errSubscriptionFinished = errors.New("eventhub subscription finished")
+)
+
+// Codec 持久化主题中事件的编解码器
+type Codec interface {
+	// Encode 将事件编码为写入磁盘的数据
+	Encode(Event) ([]byte, error)
+	// Decode 将磁盘中的数据解码为事件
+	Decode([]byte) (Event, error)
+}
+
+type jsonCodec[T any] struct{}
+
+// NewJSONCodec 创建基于 json 的编解码器，解码后的事件类型为 T
+func NewJSONCodec[T any]() Codec {
+	return jsonCodec[T]{}
+}
+
+// Encode .
+func (jsonCodec[T]) Encode(event Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// Decode .
+func (jsonCodec[T]) Decode(data []byte) (Event, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DeadLetter 重试多次仍然处理失败的事件，会被投递到 DeadLetterTopic 中
+type DeadLetter struct {
+	// Topic 事件所属的主题
+	Topic string
+	// Subscriber 处理失败的订阅者名称
+	Subscriber string
+	// Offset 事件在主题中的 offset
+	Offset int64
+	// Error 最后一次处理失败的原因
+	Error string
+	// Payload 事件编码后的原始数据，可以通过主题的 Codec 解码
+	Payload []byte
+	// CreateTime 进入死信队列的时间
+	CreateTime time.Time
+}
+
+// DeadLetterTopic 返回持久化主题对应的死信主题名称
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterTopicSuffix
+}
+
+// persistence 持久化主题的存储，事件写入分段日志，订阅者按照各自的 offset 拉取
+type persistence struct {
+	topic   string
+	log     *segmentLog
+	offsets *offsetStore
+	codec   Codec
+	// dlq 死信主题，死信主题自身没有死信主题
+	dlq *topic
+
+	mu       sync.Mutex
+	durables map[string]struct{}
+}
+
+func newPersistence(name string, opt PublishOption) (*persistence, error) {
+	if opt.Codec == nil {
+		return nil, ErrorCodecNotSet
+	}
+	dataDir := opt.DataDir
+	if dataDir == "" {
+		dataDir = DefaultDataDir
+	}
+	dir := filepath.Join(dataDir, name)
+	segLog, err := openSegmentLog(dir, opt)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := openOffsetStore(dir)
+	if err != nil {
+		_ = segLog.close()
+		return nil, err
+	}
+	return &persistence{
+		topic:    name,
+		log:      segLog,
+		offsets:  offsets,
+		codec:    opt.Codec,
+		durables: map[string]struct{}{},
+	}, nil
+}
+
+func (p *persistence) publish(event Event) error {
+	data, err := p.codec.Encode(event)
+	if err != nil {
+		return err
+	}
+	_, err = p.log.append(data)
+	return err
+}
+
+// acquire 占用持久订阅的名称，同一个名称同时只能有一个订阅者
+func (p *persistence) acquire(durable string) error {
+	if durable == "" {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.durables[durable]; ok {
+		return ErrorDurableNameInUse
+	}
+	p.durables[durable] = struct{}{}
+	return nil
+}
+
+func (p *persistence) release(durable string) {
+	if durable == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.durables, durable)
+}
+
+// startOffset 计算订阅者开始消费的位置，显式指定的 offset 优先于已提交的 offset
+func (p *persistence) startOffset(opts *SubOptions) int64 {
+	start := OffsetLatest
+	if opts.Durable != "" {
+		if committed, ok := p.offsets.get(opts.Durable); ok {
+			start = committed
+		}
+	}
+	if opts.ReplayFrom {
+		start = opts.StartOffset
+	}
+	switch start {
+	case OffsetLatest:
+		return p.log.latest()
+	case OffsetEarliest:
+		return p.log.earliest()
+	default:
+		return start
+	}
+}
+
+// run 定期刷新消费位点并按照保留策略清理分段
+func (p *persistence) run(ctx context.Context, closeCh <-chan struct{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.offsets.flush(); err != nil {
+				log.Errorf("[EventHub] topic:%s flush offsets error:%s", p.topic, err.Error())
+			}
+			p.log.cleanup()
+		case <-closeCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *persistence) close() {
+	if err := p.offsets.flush(); err != nil {
+		log.Errorf("[EventHub] topic:%s flush offsets error:%s", p.topic, err.Error())
+	}
+	if err := p.log.close(); err != nil {
+		log.Errorf("[EventHub] topic:%s close log error:%s", p.topic, err.Error())
+	}
+}
+
+// consume 持久化主题的订阅者按顺序拉取事件，处理成功或者投递到死信主题后才会提交 offset，
+// 保证事件至少被处理一次
+func (s *subscription) consume(ctx context.Context, p *persistence, offset int64) {
+	defer p.release(s.opts.Durable)
+	for {
+		select {
+		case <-s.closeCh:
+			log.Infof("[EventHub] subscription:%s consume close", s.name)
+			return
+		case <-ctx.Done():
+			log.Infof("[EventHub] subscription:%s consume close by context cancel", s.name)
+			return
+		default:
+		}
+
+		waitCh := p.log.waitCh()
+		data, err := p.log.read(offset)
+		switch {
+		case err == nil:
+		case errors.Is(err, errNoMoreRecord):
+			select {
+			case <-waitCh:
+			case <-s.closeCh:
+			case <-ctx.Done():
+			}
+			continue
+		case errors.Is(err, errOffsetTooSmall):
+			earliest := p.log.earliest()
+			log.Warnf("[EventHub] subscription:%s offset:%d removed by retention, skip to %d",
+				s.name, offset, earliest)
+			offset = earliest
+			continue
+		default:
+			log.Errorf("[EventHub] subscription:%s read offset:%d error:%s", s.name, offset, err.Error())
+			return
+		}
+
+		event, err := p.codec.Decode(data)
+		if err == nil {
+			err = s.deliver(ctx, event)
+		}
+		if errors.Is(err, errSubscriptionFinished) {
+			return
+		}
+		if err != nil {
+			s.deadLetter(p, offset, data, err)
+		}
+		offset++
+		if s.opts.Durable != "" {
+			p.offsets.commit(s.opts.Durable, offset)
+		}
+	}
+}
+
+// deliver 调用订阅者处理事件，失败时按照配置的间隔重试
+func (s *subscription) deliver(ctx context.Context, event Event) error {
+	var err error
+	for i := 0; i <= s.opts.MaxRetry; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(s.opts.RetryInterval):
+			case <-s.closeCh:
+				return errSubscriptionFinished
+			case <-ctx.Done():
+				return errSubscriptionFinished
+			}
+		}
+		if err = s.handler.OnEvent(ctx, s.handler.PreProcess(ctx, event)); err == nil {
+			return nil
+		}
+		log.Warnf("[EventHub] subscription:%s handle event error:%s, retry:%d", s.name, err.Error(), i)
+	}
+	return err
+}
+
+func (s *subscription) deadLetter(p *persistence, offset int64, data []byte, cause error) {
+	log.Errorf("[EventHub] subscription:%s give up event offset:%d error:%s", s.name, offset, cause.Error())
+	if p.dlq == nil {
+		return
+	}
+	subscriber := s.opts.Durable
+	if subscriber == "" {
+		subscriber = s.name
+	}
+	err := p.dlq.publish(context.Background(), &DeadLetter{
+		Topic:      p.topic,
+		Subscriber: subscriber,
+		Offset:     offset,
+		Error:      cause.Error(),
+		Payload:    data,
+		CreateTime: time.Now(),
+	})
+	if err != nil {
+		log.Errorf("[EventHub] subscription:%s publish dead letter error:%s", s.name, err.Error())
+	}
+}
+
+// createPersistentTopic 创建持久化主题以及对应的死信主题
+func (e *eventHub) createPersistentTopic(name string, opt PublishOption) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if t, ok := e.topics[name]; ok {
+		if t.persist == nil {
+			return fmt.Errorf("%w: %s", ErrorTopicModeConflict, name)
+		}
+		return nil
+	}
+	if _, ok := e.topics[DeadLetterTopic(name)]; ok {
+		return fmt.Errorf("%w: %s", ErrorTopicModeConflict, DeadLetterTopic(name))
+	}
+
+	p, err := newPersistence(name, opt)
+	if err != nil {
+		return err
+	}
+	dlqOpt := opt
+	dlqOpt.Codec = NewJSONCodec[*DeadLetter]()
+	dlq, err := newPersistence(DeadLetterTopic(name), dlqOpt)
+	if err != nil {
+		p.close()
+		return err
+	}
+	dlqTopic := newTopic(dlq.topic)
+	dlqTopic.persist = dlq
+	p.dlq = dlqTopic
+	t := newTopic(name)
+	t.persist = p
+
+	e.topics[name] = t
+	e.topics[dlqTopic.name] = dlqTopic
+	go t.run(e.ctx)
+	go dlqTopic.run(e.ctx)
+	return nil
+}
@@ -0,0 +1,185 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eventhub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+type recordEventHandler struct {
+	mu     sync.Mutex
+	events []int
+	fail   func(int) bool
+}
+
+func (h *recordEventHandler) PreProcess(_ context.Context, value any) any {
+	return value
+}
+
+func (h *recordEventHandler) OnEvent(_ context.Context, value any) error {
+	v := value.(int)
+	if h.fail != nil && h.fail(v) {
+		return errors.New("mock handle fail")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, v)
+	return nil
+}
+
+func (h *recordEventHandler) received() []int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]int(nil), h.events...)
+}
+
+func persistentOption(dir string) PublishOption {
+	return PublishOption{
+		Persistent: true,
+		DataDir:    dir,
+		Codec:      NewJSONCodec[int](),
+	}
+}
+
+func TestEventHub_PersistentDurableResume(t *testing.T) {
+	dir := t.TempDir()
+	eh := createEventhub()
+	assert.NoError(t, eh.RegisterPublisher("persist1", persistentOption(dir)))
+
+	handler := &recordEventHandler{}
+	_, err := eh.Subscribe("persist1", handler, WithDurable("sub"))
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, eh.Publish("persist1", i))
+	}
+	assert.Eventually(t, func() bool { return len(handler.received()) == 10 }, 5*time.Second, 10*time.Millisecond)
+	eh.shutdown()
+
+	// 重启后订阅者从已提交的位置继续消费，不会重复收到已处理的事件
+	eh = createEventhub()
+	defer eh.shutdown()
+	assert.NoError(t, eh.RegisterPublisher("persist1", persistentOption(dir)))
+	for i := 10; i < 15; i++ {
+		assert.NoError(t, eh.Publish("persist1", i))
+	}
+	handler = &recordEventHandler{}
+	_, err = eh.Subscribe("persist1", handler, WithDurable("sub"))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(handler.received()) == 5 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{10, 11, 12, 13, 14}, handler.received())
+
+	_, err = eh.Subscribe("persist1", &recordEventHandler{}, WithDurable("sub"))
+	assert.ErrorIs(t, err, ErrorDurableNameInUse)
+
+	// 指定 offset 重放历史事件
+	replay := &recordEventHandler{}
+	_, err = eh.Subscribe("persist1", replay, WithStartOffset(OffsetEarliest))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(replay.received()) == 15 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestEventHub_PersistentDeadLetter(t *testing.T) {
+	eh := createEventhub()
+	defer eh.shutdown()
+	assert.NoError(t, eh.RegisterPublisher("persist2", persistentOption(t.TempDir())))
+
+	handler := &recordEventHandler{fail: func(v int) bool { return v == 1 }}
+	_, err := eh.Subscribe("persist2", handler, WithDurable("sub"), WithRetry(2, time.Millisecond))
+	assert.NoError(t, err)
+
+	letters := make(chan *DeadLetter, 1)
+	_, err = eh.Subscribe(DeadLetterTopic("persist2"), &funcSubscriber{
+		handlerFunc: func(_ context.Context, value any) error {
+			letters <- value.(*DeadLetter)
+			return nil
+		},
+	}, WithStartOffset(OffsetEarliest))
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, eh.Publish("persist2", i))
+	}
+	assert.Eventually(t, func() bool { return len(handler.received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{0, 2}, handler.received())
+
+	select {
+	case letter := <-letters:
+		assert.Equal(t, "persist2", letter.Topic)
+		assert.Equal(t, "sub", letter.Subscriber)
+		assert.Equal(t, int64(1), letter.Offset)
+		event, err := NewJSONCodec[int]().Decode(letter.Payload)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("dead letter not received")
+	}
+}
+
+func TestEventHub_PersistentRegister(t *testing.T) {
+	eh := createEventhub()
+	defer eh.shutdown()
+
+	err := eh.RegisterPublisher("persist3", PublishOption{Persistent: true, DataDir: t.TempDir()})
+	assert.ErrorIs(t, err, ErrorCodecNotSet)
+
+	_ = eh.loadOrStoreTopic("persist4")
+	err = eh.RegisterPublisher("persist4", persistentOption(t.TempDir()))
+	assert.ErrorIs(t, err, ErrorTopicModeConflict)
+}
+
+func TestEventHub_RegisterPersistentTopics(t *testing.T) {
+	eh := createEventhub()
+	defer eh.shutdown()
+	assert.NoError(t, eh.registerPersistentTopics(&Config{DataDir: t.TempDir()}))
+
+	received := make(chan any, 1)
+	_, err := eh.Subscribe(ConfigFilePublishTopic, &funcSubscriber{
+		handlerFunc: func(_ context.Context, event any) error {
+			received <- event
+			return nil
+		},
+	}, WithDurable("watcher"))
+	assert.NoError(t, err)
+	assert.NoError(t, eh.Publish(ConfigFilePublishTopic, &PublishConfigFileEvent{
+		Message: &model.SimpleConfigFileRelease{
+			ConfigFileReleaseKey: &model.ConfigFileReleaseKey{Namespace: "ns", Group: "g", FileName: "f"},
+			Version:              3,
+		},
+	}))
+
+	select {
+	case event := <-received:
+		// 持久化主题中的事件经过编解码后仍然是订阅者期望的类型
+		publish, ok := event.(*PublishConfigFileEvent)
+		assert.True(t, ok)
+		assert.Equal(t, "f", publish.Message.FileName)
+		assert.Equal(t, uint64(3), publish.Message.Version)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config file publish event not received")
+	}
+	// 配置发布主题以持久化模式注册
+	assert.True(t, eh.topics[ConfigFilePublishTopic].persist != nil)
+}
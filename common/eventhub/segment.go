@@ -0,0 +1,372 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eventhub
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/log"
+)
+
+const (
+	segmentFileSuffix  = ".log"
+	offsetFileName     = "offsets.json"
+	recordHeaderSize   = 8
+	defaultSegmentSize = 64 * 1024 * 1024
+)
+
+var (
+	// errOffsetTooSmall 读取的 offset 已经被清理
+	errOffsetTooSmall = errors.New("eventhub offset already removed by retention")
+	// errNoMoreRecord 读取的 offset 还没有写入数据
+	errNoMoreRecord = errors.New("eventhub no more record")
+	// errCorruptRecord 记录的校验和不一致
+	errCorruptRecord = errors.New("eventhub corrupt record")
+)
+
+// segment 一个分段文件，文件名为该分段第一条记录的 offset
+type segment struct {
+	base      int64
+	path      string
+	file      *os.File
+	positions []int64
+	size      int64
+	mtime     time.Time
+}
+
+func (s *segment) nextOffset() int64 {
+	return s.base + int64(len(s.positions))
+}
+
+// segmentLog 基于本地磁盘的分段日志，每条记录的格式为 [长度 4 字节][crc32 4 字节][数据]
+type segmentLog struct {
+	mu            sync.RWMutex
+	dir           string
+	segmentSize   int64
+	retentionSize int64
+	retentionTime time.Duration
+	segments      []*segment
+	// notifyCh 每次追加记录后关闭并替换，用于唤醒等待新记录的订阅者
+	notifyCh chan struct{}
+}
+
+func openSegmentLog(dir string, opt PublishOption) (*segmentLog, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	l := &segmentLog{
+		dir:           dir,
+		segmentSize:   opt.SegmentSize,
+		retentionSize: opt.RetentionSize,
+		retentionTime: opt.RetentionTime,
+		notifyCh:      make(chan struct{}),
+	}
+	if l.segmentSize <= 0 {
+		l.segmentSize = defaultSegmentSize
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	bases := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentFileSuffix) {
+			continue
+		}
+		base, err := strconv.ParseInt(strings.TrimSuffix(name, segmentFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool {
+		return bases[i] < bases[j]
+	})
+	for _, base := range bases {
+		seg, err := l.openSegment(base)
+		if err != nil {
+			_ = l.close()
+			return nil, err
+		}
+		l.segments = append(l.segments, seg)
+	}
+	if len(l.segments) == 0 {
+		seg, err := l.openSegment(0)
+		if err != nil {
+			return nil, err
+		}
+		l.segments = append(l.segments, seg)
+	}
+	return l, nil
+}
+
+// openSegment 打开分段文件并重建记录索引，文件尾部不完整的记录会被截断
+func (l *segmentLog) openSegment(base int64) (*segment, error) {
+	path := filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, segmentFileSuffix))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	seg := &segment{
+		base:  base,
+		path:  path,
+		file:  file,
+		mtime: info.ModTime(),
+	}
+	var pos int64
+	header := make([]byte, recordHeaderSize)
+	for pos+recordHeaderSize <= info.Size() {
+		if _, err := file.ReadAt(header, pos); err != nil {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		if pos+recordHeaderSize+length > info.Size() {
+			break
+		}
+		data := make([]byte, length)
+		if _, err := file.ReadAt(data, pos+recordHeaderSize); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+		seg.positions = append(seg.positions, pos)
+		pos += recordHeaderSize + length
+	}
+	if pos != info.Size() {
+		log.Warnf("[EventHub] segment:%s truncate broken tail from %d to %d", path, info.Size(), pos)
+		if err := file.Truncate(pos); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	seg.size = pos
+	return seg, nil
+}
+
+// append 追加一条记录，返回记录的 offset
+func (l *segmentLog) append(data []byte) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	active := l.segments[len(l.segments)-1]
+	if active.size > 0 && active.size+recordHeaderSize+int64(len(data)) > l.segmentSize {
+		seg, err := l.openSegment(active.nextOffset())
+		if err != nil {
+			return 0, err
+		}
+		l.segments = append(l.segments, seg)
+		active = seg
+		l.cleanupLocked()
+	}
+
+	buf := make([]byte, recordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(data))
+	copy(buf[recordHeaderSize:], data)
+	if _, err := active.file.WriteAt(buf, active.size); err != nil {
+		return 0, err
+	}
+	offset := active.nextOffset()
+	active.positions = append(active.positions, active.size)
+	active.size += int64(len(buf))
+	active.mtime = time.Now()
+
+	close(l.notifyCh)
+	l.notifyCh = make(chan struct{})
+	return offset, nil
+}
+
+// read 读取指定 offset 的记录
+func (l *segmentLog) read(offset int64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if offset < l.segments[0].base {
+		return nil, errOffsetTooSmall
+	}
+	idx := sort.Search(len(l.segments), func(i int) bool {
+		return l.segments[i].base > offset
+	}) - 1
+	seg := l.segments[idx]
+	if offset >= seg.nextOffset() {
+		return nil, errNoMoreRecord
+	}
+	pos := seg.positions[offset-seg.base]
+	header := make([]byte, recordHeaderSize)
+	if _, err := seg.file.ReadAt(header, pos); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[:4]))
+	if _, err := seg.file.ReadAt(data, pos+recordHeaderSize); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errCorruptRecord
+	}
+	return data, nil
+}
+
+// earliest 当前保留的第一条记录的 offset
+func (l *segmentLog) earliest() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.segments[0].base
+}
+
+// latest 下一条写入记录的 offset
+func (l *segmentLog) latest() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.segments[len(l.segments)-1].nextOffset()
+}
+
+// waitCh 返回在下一次追加记录时关闭的 channel
+func (l *segmentLog) waitCh() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.notifyCh
+}
+
+// cleanup 按照保留策略删除过期的分段
+func (l *segmentLog) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cleanupLocked()
+}
+
+func (l *segmentLog) cleanupLocked() {
+	var total int64
+	for _, seg := range l.segments {
+		total += seg.size
+	}
+	// 正在写入的分段永远不会被删除
+	for len(l.segments) > 1 {
+		oldest := l.segments[0]
+		overSize := l.retentionSize > 0 && total > l.retentionSize
+		expired := l.retentionTime > 0 && time.Since(oldest.mtime) > l.retentionTime
+		if !overSize && !expired {
+			return
+		}
+		_ = oldest.file.Close()
+		if err := os.Remove(oldest.path); err != nil {
+			log.Errorf("[EventHub] remove segment:%s error:%s", oldest.path, err.Error())
+		}
+		log.Infof("[EventHub] remove segment:%s by retention", oldest.path)
+		total -= oldest.size
+		l.segments = l.segments[1:]
+	}
+}
+
+func (l *segmentLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var errs []error
+	for _, seg := range l.segments {
+		if err := seg.file.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := seg.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// offsetStore 持久化订阅者的消费位点，key 为订阅者名称
+type offsetStore struct {
+	mu      sync.Mutex
+	path    string
+	offsets map[string]int64
+	dirty   bool
+}
+
+func openOffsetStore(dir string) (*offsetStore, error) {
+	s := &offsetStore{
+		path:    filepath.Join(dir, offsetFileName),
+		offsets: map[string]int64{},
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.offsets); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *offsetStore) get(name string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.offsets[name]
+	return offset, ok
+}
+
+func (s *offsetStore) commit(name string, offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.offsets[name]; ok && cur == offset {
+		return
+	}
+	s.offsets[name] = offset
+	s.dirty = true
+}
+
+// flush 将变更的消费位点写入磁盘，先写临时文件再重命名保证原子性
+func (s *offsetStore) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	data, err := json.Marshal(s.offsets)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
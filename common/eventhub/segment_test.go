@@ -0,0 +1,103 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package eventhub
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_segmentLog_appendRead(t *testing.T) {
+	dir := t.TempDir()
+	l, err := openSegmentLog(dir, PublishOption{SegmentSize: 64})
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		offset, err := l.append([]byte(fmt.Sprintf("event-%d", i)))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i), offset)
+	}
+	assert.Greater(t, len(l.segments), 1)
+
+	data, err := l.read(7)
+	assert.NoError(t, err)
+	assert.Equal(t, "event-7", string(data))
+	_, err = l.read(10)
+	assert.ErrorIs(t, err, errNoMoreRecord)
+	assert.NoError(t, l.close())
+
+	// 模拟写入一半时进程退出，重新打开后丢弃不完整的记录
+	last := l.segments[len(l.segments)-1]
+	f, err := os.OpenFile(last.path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, _ = f.Write([]byte{0, 0, 0, 9, 1})
+	_ = f.Close()
+
+	l, err = openSegmentLog(dir, PublishOption{SegmentSize: 64})
+	assert.NoError(t, err)
+	defer l.close()
+	assert.Equal(t, int64(0), l.earliest())
+	assert.Equal(t, int64(10), l.latest())
+	offset, err := l.append([]byte("event-10"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), offset)
+	data, err = l.read(10)
+	assert.NoError(t, err)
+	assert.Equal(t, "event-10", string(data))
+}
+
+func Test_segmentLog_retention(t *testing.T) {
+	l, err := openSegmentLog(t.TempDir(), PublishOption{SegmentSize: 32, RetentionSize: 64})
+	assert.NoError(t, err)
+	defer l.close()
+	for i := 0; i < 20; i++ {
+		_, err := l.append([]byte(fmt.Sprintf("event-%02d", i)))
+		assert.NoError(t, err)
+	}
+	assert.Greater(t, l.earliest(), int64(0))
+	_, err = l.read(0)
+	assert.ErrorIs(t, err, errOffsetTooSmall)
+	data, err := l.read(19)
+	assert.NoError(t, err)
+	assert.Equal(t, "event-19", string(data))
+
+	l.retentionSize = 0
+	l.retentionTime = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	l.cleanup()
+	assert.Len(t, l.segments, 1)
+}
+
+func Test_offsetStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openOffsetStore(dir)
+	assert.NoError(t, err)
+	_, ok := s.get("sub")
+	assert.False(t, ok)
+	s.commit("sub", 12)
+	assert.NoError(t, s.flush())
+
+	s, err = openOffsetStore(dir)
+	assert.NoError(t, err)
+	offset, ok := s.get("sub")
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), offset)
+}
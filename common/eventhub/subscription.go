@@ -19,6 +19,7 @@ package eventhub
 
 import (
 	"context"
+	"time"
 
 	"github.com/polarismesh/polaris/common/log"
 )
@@ -71,7 +72,9 @@ type subscription struct {
 
 func newSubscription(name string, handler Handler, opts ...SubOption) *subscription {
 	subOpts := &SubOptions{
-		QueueSize: defaultQueueSize,
+		QueueSize:     defaultQueueSize,
+		MaxRetry:      defaultMaxRetry,
+		RetryInterval: defaultRetryInterval,
 	}
 	for _, o := range opts {
 		o(subOpts)
@@ -136,6 +139,15 @@ type SubOption func(s *SubOptions)
 // SubOptions subscripion options
 type SubOptions struct {
 	QueueSize int
+	// Durable 持久订阅的名称，只对持久化主题生效，消费位点按照名称保存，重启后继续消费
+	Durable string
+	// ReplayFrom 为 true 时从 StartOffset 开始消费，忽略已经提交的消费位点
+	ReplayFrom  bool
+	StartOffset int64
+	// MaxRetry 持久化主题中事件处理失败后的重试次数，超过后投递到死信主题
+	MaxRetry int
+	// RetryInterval 持久化主题中事件处理失败后的重试间隔
+	RetryInterval time.Duration
 }
 
 // WithQueueSize set event queue size
@@ -145,7 +157,42 @@ func WithQueueSize(size int) SubOption {
 	}
 }
 
+// WithDurable set durable subscription name
+func WithDurable(name string) SubOption {
+	return func(s *SubOptions) {
+		s.Durable = name
+	}
+}
+
+// WithStartOffset replay events from offset, OffsetEarliest and OffsetLatest are also accepted
+func WithStartOffset(offset int64) SubOption {
+	return func(s *SubOptions) {
+		s.ReplayFrom = true
+		s.StartOffset = offset
+	}
+}
+
+// WithRetry set max retry times and retry interval before event goes to dead letter topic
+func WithRetry(maxRetry int, interval time.Duration) SubOption {
+	return func(s *SubOptions) {
+		s.MaxRetry = maxRetry
+		s.RetryInterval = interval
+	}
+}
+
 // PublishOption .
 type PublishOption struct {
 	WaitHaveSub bool
+	// Persistent 持久化模式，事件写入本地磁盘的分段日志中，订阅者按照各自的 offset 消费
+	Persistent bool
+	// DataDir 持久化数据目录，为空时使用 DefaultDataDir
+	DataDir string
+	// SegmentSize 单个分段文件的最大字节数
+	SegmentSize int64
+	// RetentionSize 全部分段的总字节数上限，超出后删除最旧的分段
+	RetentionSize int64
+	// RetentionTime 分段最后一次写入后的保留时间
+	RetentionTime time.Duration
+	// Codec 事件的编解码器，持久化模式必须设置
+	Codec Codec
 }
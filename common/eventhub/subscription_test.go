@@ -62,7 +62,9 @@ func Test_newSubscription(t *testing.T) {
 				closeCh: make(chan struct{}),
 				handler: &noopEventHandler{},
 				opts: &SubOptions{
-					QueueSize: 100,
+					QueueSize:     100,
+					MaxRetry:      defaultMaxRetry,
+					RetryInterval: defaultRetryInterval,
 				},
 			},
 		},
@@ -78,7 +80,9 @@ func Test_newSubscription(t *testing.T) {
 				closeCh: make(chan struct{}),
 				handler: &noopEventHandler{},
 				opts: &SubOptions{
-					QueueSize: defaultQueueSize,
+					QueueSize:     defaultQueueSize,
+					MaxRetry:      defaultMaxRetry,
+					RetryInterval: defaultRetryInterval,
 				},
 			},
 		},
@@ -95,7 +99,9 @@ func Test_newSubscription(t *testing.T) {
 				closeCh: make(chan struct{}),
 				handler: &noopEventHandler{},
 				opts: &SubOptions{
-					QueueSize: defaultQueueSize,
+					QueueSize:     defaultQueueSize,
+					MaxRetry:      defaultMaxRetry,
+					RetryInterval: defaultRetryInterval,
 				},
 			},
 		},
@@ -34,6 +34,8 @@ type topic struct {
 	closeCh chan struct{}
 	subs    map[string]*subscription
 	mu      sync.RWMutex
+	// persist 不为空时为持久化主题
+	persist *persistence
 }
 
 func newTopic(name string) *topic {
@@ -47,11 +49,15 @@ func newTopic(name string) *topic {
 }
 
 // publish publish msg to topic
-func (t *topic) publish(ctx context.Context, event Event) {
+func (t *topic) publish(ctx context.Context, event Event) error {
 	if log.DebugEnabled() {
 		log.Debugf("[EventHub] publish topic:%s, event:%v", t.name, event)
 	}
+	if t.persist != nil {
+		return t.persist.publish(event)
+	}
 	t.queue <- event
+	return nil
 }
 
 // subscribe subscribe msg from topic
@@ -60,6 +66,11 @@ func (t *topic) subscribe(ctx context.Context, handler Handler,
 
 	subID := uuid.NewString()
 	sub := newSubscription(subID, handler, opts...)
+	if t.persist != nil {
+		if err := t.persist.acquire(sub.opts.Durable); err != nil {
+			return nil, err
+		}
+	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -74,7 +85,12 @@ func (t *topic) subscribe(ctx context.Context, handler Handler,
 		},
 	}
 
-	go sub.receive(newCtx)
+	if t.persist != nil {
+		// 订阅返回前确定消费的起始位置，避免订阅后立即发布的事件被跳过
+		go sub.consume(newCtx, t.persist, t.persist.startOffset(sub.opts))
+	} else {
+		go sub.receive(newCtx)
+	}
 	return subscribtionCtx, nil
 }
 
@@ -98,11 +114,20 @@ func (t *topic) close(ctx context.Context) {
 		sub.close()
 		delete(t.subs, sub.name)
 	}
+	if t.persist != nil {
+		t.persist.close()
+	}
 }
 
 // run read msg from topic queue and send to all subscription
 func (t *topic) run(ctx context.Context) {
 	log.Infof("[EventHub] topic:%s run dispatch", t.name)
+	if t.persist != nil {
+		// 持久化主题的订阅者自行从日志中拉取事件
+		t.persist.run(ctx, t.closeCh)
+		log.Infof("[EventHub] topic:%s run stop", t.name)
+		return
+	}
 	for {
 		select {
 		case msg := <-t.queue:
@@ -35,6 +35,8 @@ const (
 	CacheNamespaceEventTopic = "cache_namespace_event"
 	// ClientEventTopic .
 	ClientEventTopic = "client_event"
+	// ConfigClientErrorTopic client report config file error
+	ConfigClientErrorTopic = "config_client_error"
 )
 
 // PublishConfigFileEvent 事件对象，包含类型和事件消息
@@ -42,6 +44,11 @@ type PublishConfigFileEvent struct {
 	Message *model.SimpleConfigFileRelease
 }
 
+// ConfigClientErrorEvent 客户端上报的配置文件使用异常事件
+type ConfigClientErrorEvent struct {
+	Errors []*model.ConfigClientError
+}
+
 // EventType common event type
 type EventType int
 
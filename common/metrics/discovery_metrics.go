@@ -96,6 +96,14 @@ func registerDiscoveryMetrics() {
 		},
 	}, []string{LabelNamespace, LabelService})
 
+	healthCheckProtection = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "health_check_protection",
+		Help: "whether health check is under protection, service label is empty means global protection",
+		ConstLabels: map[string]string{
+			LabelServerNode: utils.LocalHost,
+		},
+	}, []string{LabelNamespace, LabelService})
+
 	_ = GetRegistry().Register(serviceCount)
 	_ = GetRegistry().Register(serviceOnlineCount)
 	_ = GetRegistry().Register(serviceAbnormalCount)
@@ -105,6 +113,7 @@ func registerDiscoveryMetrics() {
 	_ = GetRegistry().Register(instanceAbnormalCount)
 	_ = GetRegistry().Register(instanceIsolateCount)
 	_ = GetRegistry().Register(clientInstanceTotal)
+	_ = GetRegistry().Register(healthCheckProtection)
 }
 
 func GetClientInstanceTotal() prometheus.Gauge {
@@ -142,3 +151,7 @@ func GetInstanceIsolateCountl() *prometheus.GaugeVec {
 func GetInstanceAbnormalCountl() *prometheus.GaugeVec {
 	return instanceAbnormalCount
 }
+
+func GetHealthCheckProtection() *prometheus.GaugeVec {
+	return healthCheckProtection
+}
@@ -93,6 +93,16 @@ func registerSysMetrics() {
 		labelBatchJobLabel,
 	})
 
+	historyRecordFallback = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "history_record_fallback",
+		Help: "operation records written to the fallback log instead of the store",
+		ConstLabels: map[string]string{
+			"polaris_server_instance": utils.LocalHost,
+		},
+	}, []string{
+		labelReason,
+	})
+
 	_ = registry.Register(instanceAsyncRegisCost)
 	_ = registry.Register(instanceRegisTaskExpire)
 	_ = registry.Register(redisReadFailure)
@@ -100,6 +110,7 @@ func registerSysMetrics() {
 	_ = registry.Register(redisAliveStatus)
 	_ = registry.Register(cacheUpdateCost)
 	_ = registry.Register(batchJobUnFinishJobs)
+	_ = registry.Register(historyRecordFallback)
 
 	go func() {
 		lastRedisReadFailureReport.Store(time.Now())
@@ -179,3 +190,11 @@ func ReportFinishBatchJob(label string, count int64) {
 		labelBatchJobLabel: label,
 	}).Sub(float64(count))
 }
+
+// ReportHistoryRecordFallback report operation records written to the fallback log
+func ReportHistoryRecordFallback(reason string, count int) {
+	if historyRecordFallback == nil {
+		return
+	}
+	historyRecordFallback.WithLabelValues(reason).Add(float64(count))
+}
@@ -38,6 +38,7 @@ const (
 	labelCacheType        = "cache_type"
 	labelCacheUpdateCount = "cache_update_count"
 	labelBatchJobLabel    = "batch_label"
+	labelReason           = "reason"
 )
 
 // CallMetricType .
@@ -174,6 +175,7 @@ var (
 	instanceOnlineCount   *prometheus.GaugeVec
 	instanceAbnormalCount *prometheus.GaugeVec
 	instanceIsolateCount  *prometheus.GaugeVec
+	healthCheckProtection *prometheus.GaugeVec
 )
 
 var (
@@ -200,4 +202,6 @@ var (
 	cacheUpdateCost *prometheus.HistogramVec
 	// batchJobUnFinishJobs .
 	batchJobUnFinishJobs *prometheus.GaugeVec
+	// historyRecordFallback 未能写入存储层，转写到兜底日志的操作记录数量
+	historyRecordFallback *prometheus.CounterVec
 )
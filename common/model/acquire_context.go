@@ -49,6 +49,19 @@ type AcquireContext struct {
 	fromClient bool
 	// allowAnonymous 是否允许匿名用户
 	allowAnonymous bool
+	// namespaceFilter 列表查询的命名空间过滤条件
+	namespaceFilter *NamespaceFilter
+}
+
+// NamespaceFilter 列表查询中的命名空间过滤条件
+// 列表查询没有关联具体的资源，限制了命名空间的 API token 只能通过该条件把查询范围收敛到授权的命名空间内
+type NamespaceFilter struct {
+	// ResourceType 查询的资源类型
+	ResourceType apisecurity.ResourceType
+	// Query 查询条件，鉴权时可能被改写
+	Query map[string]string
+	// Key 查询条件中命名空间对应的 key
+	Key string
 }
 
 // NewAcquireContext 创建一个请求响应
@@ -256,6 +269,20 @@ func (authCtx *AcquireContext) SetAllowAnonymous(a bool) {
 	authCtx.allowAnonymous = a
 }
 
+// SetNamespaceFilter 设置本次列表查询的命名空间过滤条件
+func (authCtx *AcquireContext) SetNamespaceFilter(resType apisecurity.ResourceType, query map[string]string,
+	key string) {
+	if query == nil {
+		return
+	}
+	authCtx.namespaceFilter = &NamespaceFilter{ResourceType: resType, Query: query, Key: key}
+}
+
+// GetNamespaceFilter 获取本次列表查询的命名空间过滤条件，不是列表查询时返回 nil
+func (authCtx *AcquireContext) GetNamespaceFilter() *NamespaceFilter {
+	return authCtx.namespaceFilter
+}
+
 // ResourceOpInfo 资源的数据操作信息
 type ResourceOpInfo struct {
 	ResourceType apisecurity.ResourceType
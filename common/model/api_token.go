@@ -0,0 +1,139 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+)
+
+const (
+	// APITokenActionRead 只读动作
+	APITokenActionRead = "read"
+	// APITokenActionCreate 创建动作
+	APITokenActionCreate = "create"
+	// APITokenActionModify 修改动作
+	APITokenActionModify = "modify"
+	// APITokenActionDelete 删除动作
+	APITokenActionDelete = "delete"
+)
+
+var (
+	// ErrorTokenExpired token 已经过期
+	ErrorTokenExpired error = errors.New("token already expired")
+
+	// ErrorTokenScopeForbidden 操作超出了 token 的授权范围
+	ErrorTokenScopeForbidden error = errors.New("operation out of token scope")
+
+	// APITokenActions 动作名称与资源操作的映射
+	APITokenActions = map[string]ResourceOperation{
+		APITokenActionRead:   Read,
+		APITokenActionCreate: Create,
+		APITokenActionModify: Modify,
+		APITokenActionDelete: Delete,
+	}
+)
+
+// APIToken 用户或者用户组的具名 API token，可以设置过期时间以及限制可以操作的命名空间、资源类型和动作
+type APIToken struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// PrincipalType token 所属的成员类型，用户或者用户组
+	PrincipalType PrincipalType `json:"principal_type"`
+	// PrincipalID token 所属的用户或者用户组 ID
+	PrincipalID string `json:"principal_id"`
+	// Owner token 所属成员的主账户 ID
+	Owner string `json:"owner"`
+	// TokenHash token 的 sha256 摘要，token 原文只在创建时返回一次
+	TokenHash string `json:"token_hash,omitempty"`
+	// Namespaces 允许操作的命名空间，为空时不限制
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ResourceTypes 允许操作的资源类型，取值参考 apisecurity.ResourceType，为空时不限制
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	// Actions 允许的动作，取值为 read、create、modify、delete，为空时不限制
+	Actions []string `json:"actions,omitempty"`
+	// ExpireTime 过期时间，为空时永不过期
+	ExpireTime time.Time `json:"expire_time,omitempty"`
+	// LastUsedTime 最近一次使用的时间，精确到分钟
+	LastUsedTime time.Time `json:"last_used_time,omitempty"`
+	Comment      string    `json:"comment,omitempty"`
+	Valid        bool      `json:"-"`
+	CreateTime   time.Time `json:"create_time"`
+	CreateBy     string    `json:"create_by"`
+	ModifyTime   time.Time `json:"modify_time"`
+}
+
+// IsExpired token 是否已经过期
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return !t.ExpireTime.IsZero() && !now.Before(t.ExpireTime)
+}
+
+// IsScoped token 是否限制了授权范围
+func (t *APIToken) IsScoped() bool {
+	return len(t.Namespaces) != 0 || len(t.ResourceTypes) != 0 || len(t.Actions) != 0
+}
+
+// AllowOperation 是否允许执行该动作
+func (t *APIToken) AllowOperation(op ResourceOperation) bool {
+	if len(t.Actions) == 0 {
+		return true
+	}
+	for _, action := range t.Actions {
+		if APITokenActions[action] == op {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowResourceType 是否允许操作该类型的资源
+func (t *APIToken) AllowResourceType(resType apisecurity.ResourceType) bool {
+	return len(t.ResourceTypes) == 0 || containsString(t.ResourceTypes, resType.String())
+}
+
+// AllowNamespace 是否允许操作该命名空间下的资源
+func (t *APIToken) AllowNamespace(namespace string) bool {
+	return len(t.Namespaces) == 0 || containsString(t.Namespaces, namespace)
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+// APITokenResponse API token 接口的返回，Token 只在创建时返回
+type APITokenResponse struct {
+	Code     uint32    `json:"code"`
+	Info     string    `json:"info"`
+	Token    string    `json:"token,omitempty"`
+	APIToken *APIToken `json:"api_token,omitempty"`
+}
+
+// APITokenBatchResponse 查询 API token 接口的返回
+type APITokenBatchResponse struct {
+	Code      uint32      `json:"code"`
+	Info      string      `json:"info"`
+	Total     uint32      `json:"total"`
+	APITokens []*APIToken `json:"api_tokens"`
+}
@@ -61,7 +61,7 @@ func ConvertToErrCode(err error) apimodel.Code {
 		return apimodel.Code_TokenNotExisted
 	}
 
-	if errors.Is(err, ErrorTokenDisabled) {
+	if errors.Is(err, ErrorTokenDisabled) || errors.Is(err, ErrorTokenExpired) {
 		return apimodel.Code_TokenDisabled
 	}
 
@@ -82,6 +82,7 @@ const (
 	TokenDetailInfoKey string = "TokenInfo"
 	TokenForUser       string = "uid"
 	TokenForUserGroup  string = "groupid"
+	TokenForAPIToken   string = "apitoken"
 
 	ResourceAttachmentKey string = "resource_attachment"
 )
@@ -332,6 +333,8 @@ type StrategyDetail struct {
 	Default    bool
 	Owner      string
 	Resources  []StrategyResource
+	// Conditions 策略的生效条件，为空时策略无条件生效
+	Conditions *StrategyConditions
 	Valid      bool
 	Revision   string
 	CreateTime time.Time
@@ -0,0 +1,321 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+)
+
+const (
+	// ProtocolConsole 控制台、OpenAPI 的请求
+	ProtocolConsole = "console"
+	// ProtocolClient 客户端 SDK 的请求
+	ProtocolClient = "client"
+)
+
+// StrategyConditions 鉴权策略的生效条件，多个条件之间为与的关系，未设置的条件不做限制
+type StrategyConditions struct {
+	// SourceIPs 允许的请求来源，支持 IP 以及 CIDR
+	SourceIPs []string `json:"source_ips,omitempty"`
+	// TimeWindows 允许的时间窗口，满足任意一个即可
+	TimeWindows []*TimeWindow `json:"time_windows,omitempty"`
+	// Protocols 允许的请求来源类型，取值为 console、client
+	Protocols []string `json:"protocols,omitempty"`
+	// ResourceSelectors 按照资源标签选择资源，策略对标签匹配的资源同样生效，被选中的资源同样视为受保护的资源
+	ResourceSelectors []*ResourceSelector `json:"resource_selectors,omitempty"`
+
+	cidrs []*net.IPNet
+}
+
+// TimeWindow 时间窗口，End 小于 Start 时表示跨越零点
+type TimeWindow struct {
+	// Weekdays 生效的星期，0 表示周日，为空时每天都生效
+	Weekdays []int `json:"weekdays,omitempty"`
+	// Start 开始时间，格式为 HH:MM
+	Start string `json:"start"`
+	// End 结束时间，格式为 HH:MM
+	End string `json:"end"`
+	// Timezone 时区，例如 Asia/Shanghai，为空时使用服务端的时区
+	Timezone string `json:"timezone,omitempty"`
+
+	start, end int
+	location   *time.Location
+}
+
+// ResourceSelector 资源标签选择器，资源的标签需要包含全部的 Labels
+type ResourceSelector struct {
+	// ResourceType 资源类型，取值为 Services、ConfigGroups
+	ResourceType string            `json:"resource_type"`
+	Labels       map[string]string `json:"labels"`
+}
+
+// RequestAttributes 参与策略条件计算的请求属性
+type RequestAttributes struct {
+	SourceIP string
+	Protocol string
+	Time     time.Time
+}
+
+// ParseStrategyConditions 从 JSON 中解析鉴权策略的生效条件，data 为空时返回 nil
+func ParseStrategyConditions(data string) (*StrategyConditions, error) {
+	if data == "" {
+		return nil, nil
+	}
+	conditions := &StrategyConditions{}
+	if err := json.Unmarshal([]byte(data), conditions); err != nil {
+		return nil, err
+	}
+	if err := conditions.Parse(); err != nil {
+		return nil, err
+	}
+	if conditions.IsEmpty() {
+		return nil, nil
+	}
+	return conditions, nil
+}
+
+// IsEmpty 是否没有设置任何条件
+func (c *StrategyConditions) IsEmpty() bool {
+	return c == nil || (len(c.SourceIPs) == 0 && len(c.TimeWindows) == 0 && len(c.Protocols) == 0 &&
+		len(c.ResourceSelectors) == 0)
+}
+
+// Parse 校验并解析条件，条件在使用前必须先调用该方法
+func (c *StrategyConditions) Parse() error {
+	if c == nil {
+		return nil
+	}
+	c.cidrs = make([]*net.IPNet, 0, len(c.SourceIPs))
+	for _, item := range c.SourceIPs {
+		cidr := item
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid source ip %s", item)
+		}
+		c.cidrs = append(c.cidrs, ipNet)
+	}
+	for _, window := range c.TimeWindows {
+		if window == nil {
+			return errors.New("time window is empty")
+		}
+		if err := window.parse(); err != nil {
+			return err
+		}
+	}
+	for _, protocol := range c.Protocols {
+		if protocol != ProtocolConsole && protocol != ProtocolClient {
+			return fmt.Errorf("invalid protocol %s", protocol)
+		}
+	}
+	for _, selector := range c.ResourceSelectors {
+		if selector == nil || len(selector.Labels) == 0 {
+			return errors.New("resource selector labels is empty")
+		}
+		if selector.ResourceType != apisecurity.ResourceType_Services.String() &&
+			selector.ResourceType != apisecurity.ResourceType_ConfigGroups.String() {
+			return fmt.Errorf("resource selector not support resource type %s", selector.ResourceType)
+		}
+	}
+	return nil
+}
+
+// Match 判断请求是否满足条件，不满足时返回原因
+func (c *StrategyConditions) Match(attrs *RequestAttributes) (bool, string) {
+	if c == nil {
+		return true, ""
+	}
+	if len(c.cidrs) != 0 {
+		ip := net.ParseIP(attrs.SourceIP)
+		matched := false
+		for _, cidr := range c.cidrs {
+			if ip != nil && cidr.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, fmt.Sprintf("source ip %s not in %v", attrs.SourceIP, c.SourceIPs)
+		}
+	}
+	if len(c.TimeWindows) != 0 {
+		matched := false
+		for _, window := range c.TimeWindows {
+			if window.contains(attrs.Time) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, fmt.Sprintf("time %s not in any time window", attrs.Time.Format(time.RFC3339))
+		}
+	}
+	if len(c.Protocols) != 0 && !containsString(c.Protocols, attrs.Protocol) {
+		return false, fmt.Sprintf("protocol %s not in %v", attrs.Protocol, c.Protocols)
+	}
+	return true, ""
+}
+
+// SelectResource 资源是否被标签选择器选中
+func (c *StrategyConditions) SelectResource(resType apisecurity.ResourceType, labels map[string]string) bool {
+	if c == nil {
+		return false
+	}
+	for _, selector := range c.ResourceSelectors {
+		if selector.ResourceType != resType.String() {
+			continue
+		}
+		matched := true
+		for k, v := range selector.Labels {
+			if val, ok := labels[k]; !ok || val != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *TimeWindow) parse() error {
+	var err error
+	if w.start, err = parseClock(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = parseClock(w.End); err != nil {
+		return err
+	}
+	for _, day := range w.Weekdays {
+		if day < int(time.Sunday) || day > int(time.Saturday) {
+			return fmt.Errorf("invalid weekday %d", day)
+		}
+	}
+	w.location = time.Local
+	if w.Timezone != "" {
+		if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %s", w.Timezone)
+		}
+	}
+	return nil
+}
+
+func (w *TimeWindow) contains(t time.Time) bool {
+	if w.location == nil {
+		return false
+	}
+	t = t.In(w.location)
+	minute := t.Hour()*60 + t.Minute()
+	weekday := t.Weekday()
+	inWindow := false
+	if w.start <= w.end {
+		inWindow = minute >= w.start && minute < w.end
+	} else if minute >= w.start {
+		inWindow = true
+	} else if minute < w.end {
+		// 跨越零点的时间窗口，零点之后的部分属于前一天开始的窗口
+		inWindow = true
+		weekday = (weekday + 6) % 7
+	}
+	if !inWindow {
+		return false
+	}
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, day := range w.Weekdays {
+		if time.Weekday(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %s, format must be HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// StrategyConditionsRequest 修改鉴权策略生效条件的请求
+type StrategyConditionsRequest struct {
+	ID         string              `json:"id"`
+	Conditions *StrategyConditions `json:"conditions"`
+}
+
+// StrategyConditionsResponse 鉴权策略生效条件接口的返回
+type StrategyConditionsResponse struct {
+	Code       uint32              `json:"code"`
+	Info       string              `json:"info"`
+	ID         string              `json:"id,omitempty"`
+	Conditions *StrategyConditions `json:"conditions,omitempty"`
+}
+
+// AuthExplainRequest 解释某个 principal 是否可以对资源执行某个动作
+type AuthExplainRequest struct {
+	PrincipalID   string        `json:"principal_id"`
+	PrincipalType PrincipalType `json:"principal_type"`
+	ResourceType  string        `json:"resource_type"`
+	ResourceID    string        `json:"resource_id"`
+	// Operation 取值为 read、create、modify、delete
+	Operation string `json:"operation"`
+	SourceIP  string `json:"source_ip"`
+	// Protocol 取值为 console、client
+	Protocol string    `json:"protocol"`
+	Time     time.Time `json:"time"`
+}
+
+// AuthExplainResult 鉴权结果以及判断的依据
+type AuthExplainResult struct {
+	Allowed    bool               `json:"allowed"`
+	Reason     string             `json:"reason"`
+	Strategies []*StrategyExplain `json:"strategies,omitempty"`
+}
+
+// StrategyExplain 单个鉴权策略的判断结果
+type StrategyExplain struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Principal 通过哪个成员关联到该策略，格式参考 auth.PrincipalName
+	Principal string `json:"principal"`
+	Matched   bool   `json:"matched"`
+	Reason    string `json:"reason"`
+}
+
+// AuthExplainResponse 鉴权解释接口的返回
+type AuthExplainResponse struct {
+	Code   uint32             `json:"code"`
+	Info   string             `json:"info"`
+	Result *AuthExplainResult `json:"result,omitempty"`
+}
@@ -0,0 +1,97 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrategyConditions_Match(t *testing.T) {
+	conditions, err := ParseStrategyConditions(`{
+		"source_ips": ["10.0.0.0/8", "192.168.1.1"],
+		"time_windows": [{"weekdays": [1, 2, 3, 4, 5], "start": "22:00", "end": "06:00", "timezone": "UTC"}],
+		"protocols": ["console"]
+	}`)
+	assert.NoError(t, err)
+
+	// 周一 23:00 UTC
+	monday := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	attrs := &RequestAttributes{SourceIP: "10.1.2.3", Protocol: ProtocolConsole, Time: monday}
+	ok, _ := conditions.Match(attrs)
+	assert.True(t, ok)
+
+	// 跨越零点的部分属于前一天开始的时间窗口，周六 05:00 属于周五的时间窗口
+	attrs.Time = time.Date(2024, 1, 6, 5, 0, 0, 0, time.UTC)
+	ok, _ = conditions.Match(attrs)
+	assert.True(t, ok)
+
+	// 周日 05:00 属于周六的时间窗口，不在生效的星期内
+	attrs.Time = time.Date(2024, 1, 7, 5, 0, 0, 0, time.UTC)
+	ok, reason := conditions.Match(attrs)
+	assert.False(t, ok)
+	assert.Contains(t, reason, "time window")
+
+	attrs.Time = monday
+	attrs.SourceIP = "192.168.1.2"
+	ok, reason = conditions.Match(attrs)
+	assert.False(t, ok)
+	assert.Contains(t, reason, "source ip")
+
+	attrs.SourceIP = "192.168.1.1"
+	attrs.Protocol = ProtocolClient
+	ok, reason = conditions.Match(attrs)
+	assert.False(t, ok)
+	assert.Contains(t, reason, "protocol")
+
+	var empty *StrategyConditions
+	ok, _ = empty.Match(attrs)
+	assert.True(t, ok)
+}
+
+func TestStrategyConditions_Parse(t *testing.T) {
+	_, err := ParseStrategyConditions(`{"source_ips": ["10.0.0.0/33"]}`)
+	assert.Error(t, err)
+	_, err = ParseStrategyConditions(`{"time_windows": [{"start": "25:00", "end": "06:00"}]}`)
+	assert.Error(t, err)
+	_, err = ParseStrategyConditions(`{"protocols": ["grpc"]}`)
+	assert.Error(t, err)
+	_, err = ParseStrategyConditions(`{"resource_selectors": [{"resource_type": "Namespaces", "labels": {"a": "b"}}]}`)
+	assert.Error(t, err)
+
+	conditions, err := ParseStrategyConditions(`{}`)
+	assert.NoError(t, err)
+	assert.Nil(t, conditions)
+}
+
+func TestStrategyConditions_SelectResource(t *testing.T) {
+	conditions, err := ParseStrategyConditions(`{
+		"resource_selectors": [{"resource_type": "Services", "labels": {"team": "payments"}}]
+	}`)
+	assert.NoError(t, err)
+
+	assert.True(t, conditions.SelectResource(apisecurity.ResourceType_Services,
+		map[string]string{"team": "payments", "env": "prod"}))
+	assert.False(t, conditions.SelectResource(apisecurity.ResourceType_Services,
+		map[string]string{"team": "orders"}))
+	assert.False(t, conditions.SelectResource(apisecurity.ResourceType_ConfigGroups,
+		map[string]string{"team": "payments"}))
+}
@@ -0,0 +1,36 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// CARoot xds 内置 CA 的根证书, 同一个信任域下集群内所有节点共享同一个根证书
+type CARoot struct {
+	// TrustDomain SPIFFE 信任域
+	TrustDomain string
+	// CertPEM 根证书
+	CertPEM string
+	// KeyPEM 使用 KeyCryptoAlgo 加密后的根证书私钥, 存储中不保存明文私钥
+	KeyPEM string
+	// KeyCryptoAlgo 加密私钥使用的加密算法
+	KeyCryptoAlgo string
+	// PrevCertPEM 轮转前的根证书, 过期之前仍然需要被信任
+	PrevCertPEM string
+	CreateTime  time.Time
+	ModifyTime  time.Time
+}
@@ -124,6 +124,8 @@ const (
 type PrometheusDiscoveryResponse struct {
 	Code     uint32
 	Response []PrometheusTarget
+	// Revision 服务发现结果的版本，结果不变时版本不变，用作 http_sd 的 ETag
+	Revision string `json:",omitempty"`
 }
 
 // PrometheusTarget 用于对接 prometheus service discovery 的数据结构
@@ -141,4 +143,6 @@ const (
 	ClientLabel_Version = "CLIENT_VERSION"
 	// ClientLabel_Language 客户端语言
 	ClientLabel_Language = "CLIENT_LANGUAGE"
+	// ClientLabel_Bucket 客户端所在的灰度分桶，由服务端根据客户端 ID（没有时使用客户端 IP）计算，客户端无需上报
+	ClientLabel_Bucket = "CLIENT_BUCKET"
 )
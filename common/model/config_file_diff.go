@@ -0,0 +1,167 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+
+// ConfigFileSourceType 参与对比的配置内容来源
+type ConfigFileSourceType string
+
+const (
+	// ConfigFileSourceWorking 编辑中尚未发布的配置文件
+	ConfigFileSourceWorking ConfigFileSourceType = "working"
+	// ConfigFileSourceActive 当前生效的全量发布
+	ConfigFileSourceActive ConfigFileSourceType = "active"
+	// ConfigFileSourceGray 正在灰度中的发布
+	ConfigFileSourceGray ConfigFileSourceType = "gray"
+	// ConfigFileSourceRelease 指定名称的发布
+	ConfigFileSourceRelease ConfigFileSourceType = "release"
+	// ConfigFileSourceHistory 指定 ID 的发布历史
+	ConfigFileSourceHistory ConfigFileSourceType = "history"
+)
+
+// LineDiffOp 行级别差异的操作类型
+type LineDiffOp string
+
+const (
+	LineDiffAdd    LineDiffOp = "add"
+	LineDiffDelete LineDiffOp = "delete"
+)
+
+// KeyDiffOp 配置项级别差异的操作类型
+type KeyDiffOp string
+
+const (
+	KeyDiffAdd    KeyDiffOp = "add"
+	KeyDiffDelete KeyDiffOp = "delete"
+	KeyDiffModify KeyDiffOp = "modify"
+)
+
+// ConfigFileSource 参与对比的一方
+type ConfigFileSource struct {
+	// Type 内容来源
+	Type ConfigFileSourceType `json:"type"`
+	// Name Type 为 release 时表示发布名称
+	Name string `json:"name,omitempty"`
+	// ID Type 为 history 时表示发布历史的 ID
+	ID uint64 `json:"id,omitempty"`
+}
+
+// ConfigFileDiffRequest 配置文件内容对比请求
+type ConfigFileDiffRequest struct {
+	Namespace string           `json:"namespace"`
+	Group     string           `json:"group"`
+	FileName  string           `json:"file_name"`
+	From      ConfigFileSource `json:"from"`
+	To        ConfigFileSource `json:"to"`
+}
+
+// ConfigFileSnapshot 参与对比的配置内容
+type ConfigFileSnapshot struct {
+	ConfigFileSource
+	Version uint64 `json:"version,omitempty"`
+	Md5     string `json:"md5"`
+	Format  string `json:"format"`
+	Content string `json:"-"`
+}
+
+// LineDiff 一行内容的差异
+type LineDiff struct {
+	Op LineDiffOp `json:"op"`
+	// FromLine 在 from 中的行号，从 1 开始，新增的行为 0
+	FromLine int `json:"from_line"`
+	// ToLine 在 to 中的行号，从 1 开始，删除的行为 0
+	ToLine  int    `json:"to_line"`
+	Content string `json:"content"`
+}
+
+// KeyDiff 一个配置项的差异
+type KeyDiff struct {
+	Key  string    `json:"key"`
+	Op   KeyDiffOp `json:"op"`
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to,omitempty"`
+}
+
+// ConfigFileDiff 配置文件内容对比结果
+type ConfigFileDiff struct {
+	Namespace string              `json:"namespace"`
+	Group     string              `json:"group"`
+	FileName  string              `json:"file_name"`
+	From      *ConfigFileSnapshot `json:"from"`
+	To        *ConfigFileSnapshot `json:"to"`
+	Identical bool                `json:"identical"`
+	Lines     []*LineDiff         `json:"lines"`
+	// Keys 按照配置格式解析后配置项级别的差异，仅支持 yaml/json/properties
+	Keys []*KeyDiff `json:"keys,omitempty"`
+	// KeysError 配置内容无法按照格式解析时的原因
+	KeysError string `json:"keys_error,omitempty"`
+}
+
+// ConfigFileMergeRequest 三方合并检查请求
+type ConfigFileMergeRequest struct {
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	FileName  string `json:"file_name"`
+	// BaseVersion 修改内容时所基于的全量发布版本
+	BaseVersion uint64 `json:"base_version"`
+	// Content 修改后的内容
+	Content string `json:"content"`
+}
+
+// ConfigFilePublishRequest 基于某个全量发布版本创建/更新配置文件并发布的请求
+type ConfigFilePublishRequest struct {
+	*config_manage.ConfigFilePublishInfo
+	// BaseVersion 修改内容时所基于的全量发布版本，非 0 时只有基于最新的全量发布修改才允许发布
+	BaseVersion uint64 `json:"base_version"`
+}
+
+// MergeConflict 合并时的一处冲突，行号均基于 base 内容
+type MergeConflict struct {
+	BaseStart int      `json:"base_start"`
+	BaseEnd   int      `json:"base_end"`
+	Base      []string `json:"base"`
+	Ours      []string `json:"ours"`
+	Theirs    []string `json:"theirs"`
+}
+
+// ConfigFileMergeResult 三方合并检查结果
+type ConfigFileMergeResult struct {
+	BaseVersion   uint64 `json:"base_version"`
+	LatestVersion uint64 `json:"latest_version"`
+	// Stale 基于的版本已经不是最新的全量发布
+	Stale bool `json:"stale"`
+	// Conflict 修改内容与最新发布之间存在冲突
+	Conflict  bool             `json:"conflict"`
+	Merged    string           `json:"merged,omitempty"`
+	Conflicts []*MergeConflict `json:"conflicts,omitempty"`
+}
+
+// ConfigFileDiffResponse 配置文件内容对比接口的返回
+type ConfigFileDiffResponse struct {
+	Code uint32          `json:"code"`
+	Info string          `json:"info"`
+	Diff *ConfigFileDiff `json:"diff,omitempty"`
+}
+
+// ConfigFileMergeResponse 三方合并检查接口的返回
+type ConfigFileMergeResponse struct {
+	Code   uint32                 `json:"code"`
+	Info   string                 `json:"info"`
+	Result *ConfigFileMergeResult `json:"result,omitempty"`
+}
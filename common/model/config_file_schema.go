@@ -0,0 +1,93 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import "time"
+
+const (
+	// ConfigFileSchemaTypeJSON 使用 JSON Schema 校验 json/yaml 格式的配置内容
+	ConfigFileSchemaTypeJSON = "json_schema"
+	// ConfigFileSchemaTypeProperties 使用配置项规则校验 properties 格式的配置内容
+	ConfigFileSchemaTypeProperties = "properties_rule"
+)
+
+// ConfigFileSchema 挂载在配置分组或者配置文件上的内容校验规则，FileName 为空时对整个分组生效，
+// 配置文件级别的规则优先于分组级别的规则
+type ConfigFileSchema struct {
+	Id        uint64 `json:"id,omitempty"`
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	FileName  string `json:"file_name,omitempty"`
+	// Type json_schema 或者 properties_rule
+	Type string `json:"type"`
+	// Schema 规则内容，均为 JSON 文本
+	Schema     string    `json:"schema"`
+	Comment    string    `json:"comment,omitempty"`
+	CreateTime time.Time `json:"create_time,omitempty"`
+	CreateBy   string    `json:"create_by,omitempty"`
+	ModifyTime time.Time `json:"modify_time,omitempty"`
+	ModifyBy   string    `json:"modify_by,omitempty"`
+}
+
+// SchemaViolation 配置内容不满足规则的一处原因
+type SchemaViolation struct {
+	// Path 出错的位置，json/yaml 为 JSON Pointer 风格的路径，properties 为配置项的 key
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ConfigFileValidateRequest 配置内容校验的请求，只做校验不会保存
+type ConfigFileValidateRequest struct {
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	FileName  string `json:"file_name"`
+	// Format 为空时使用已经存在的配置文件的格式
+	Format  string `json:"format,omitempty"`
+	Content string `json:"content"`
+	// Schema 不为空时使用该规则校验，用于保存规则之前的试运行，否则使用已经挂载的规则
+	Schema *ConfigFileSchema `json:"schema,omitempty"`
+}
+
+// ConfigFileValidateResult 配置内容校验的结果
+type ConfigFileValidateResult struct {
+	Valid bool `json:"valid"`
+	// Schema 本次校验使用的规则，没有规则时为空
+	Schema     *ConfigFileSchema  `json:"schema,omitempty"`
+	Violations []*SchemaViolation `json:"violations,omitempty"`
+}
+
+// ConfigFileSchemaResponse 配置校验规则接口的返回
+type ConfigFileSchemaResponse struct {
+	Code   uint32            `json:"code"`
+	Info   string            `json:"info"`
+	Schema *ConfigFileSchema `json:"schema,omitempty"`
+}
+
+// ConfigFileSchemaBatchResponse 查询配置校验规则接口的返回
+type ConfigFileSchemaBatchResponse struct {
+	Code    uint32              `json:"code"`
+	Info    string              `json:"info"`
+	Total   uint32              `json:"total"`
+	Schemas []*ConfigFileSchema `json:"schemas"`
+}
+
+// ConfigFileValidateResponse 配置内容校验接口的返回
+type ConfigFileValidateResponse struct {
+	Code   uint32                    `json:"code"`
+	Info   string                    `json:"info"`
+	Result *ConfigFileValidateResult `json:"result,omitempty"`
+}
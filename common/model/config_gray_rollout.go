@@ -0,0 +1,137 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// GrayRolloutRunning 正在按阶段推进灰度
+	GrayRolloutRunning = "running"
+	// GrayRolloutCompleted 所有阶段完成，灰度版本已经全量发布
+	GrayRolloutCompleted = "completed"
+	// GrayRolloutAborted 上报异常的客户端数量达到阈值，灰度已经自动终止
+	GrayRolloutAborted = "aborted"
+	// GrayRolloutStopped 灰度发布被人工停止或者被其他发布覆盖
+	GrayRolloutStopped = "stopped"
+	// GrayRolloutFailed 推进灰度阶段或者全量发布失败
+	GrayRolloutFailed = "failed"
+)
+
+// ConfigGrayRolloutStep 按比例灰度的一个阶段
+type ConfigGrayRolloutStep struct {
+	// Percentage 命中灰度的客户端比例，取值 (0, 100]
+	Percentage uint32 `json:"percentage"`
+	// Duration 阶段持续的秒数，到期后自动进入下一个阶段，最后一个阶段到期后全量发布
+	Duration int64 `json:"duration"`
+}
+
+// ConfigGrayRollout 配置文件按比例灰度发布，每个配置文件同时只有一个
+type ConfigGrayRollout struct {
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	FileName  string `json:"file_name"`
+	// ReleaseName 灰度版本的名称，为空时自动生成
+	ReleaseName        string                   `json:"release_name,omitempty"`
+	ReleaseDescription string                   `json:"release_description,omitempty"`
+	Comment            string                   `json:"comment,omitempty"`
+	Steps              []*ConfigGrayRolloutStep `json:"steps"`
+	// CurrentStep 当前所处的阶段下标
+	CurrentStep int `json:"current_step"`
+	// StepTime 进入当前阶段的时间
+	StepTime time.Time `json:"step_time"`
+	// ErrorThreshold 上报异常的客户端数量达到该值时自动终止灰度，为 0 时不自动终止
+	ErrorThreshold int `json:"error_threshold"`
+	// ErrorClients 上报过灰度版本异常的客户端
+	ErrorClients []string `json:"error_clients"`
+	Status       string   `json:"status"`
+	// Message 终止或者失败的原因
+	Message string `json:"message,omitempty"`
+	// Revision 每次更新递增，用于多个节点并发更新时的乐观锁
+	Revision   uint64    `json:"revision"`
+	CreateTime time.Time `json:"create_time"`
+	CreateBy   string    `json:"create_by"`
+	ModifyTime time.Time `json:"modify_time"`
+	ModifyBy   string    `json:"modify_by"`
+}
+
+// Key 灰度发布的唯一标识
+func (r *ConfigGrayRollout) Key() string {
+	return r.Namespace + "@" + r.Group + "@" + r.FileName
+}
+
+// Step 当前所处的阶段
+func (r *ConfigGrayRollout) Step() *ConfigGrayRolloutStep {
+	if r.CurrentStep < 0 || r.CurrentStep >= len(r.Steps) {
+		return nil
+	}
+	return r.Steps[r.CurrentStep]
+}
+
+// AddErrorClient 记录上报异常的客户端，返回是否为新增的客户端
+func (r *ConfigGrayRollout) AddErrorClient(clientId string) bool {
+	for _, item := range r.ErrorClients {
+		if item == clientId {
+			return false
+		}
+	}
+	r.ErrorClients = append(r.ErrorClients, clientId)
+	return true
+}
+
+// ReachErrorThreshold 上报异常的客户端数量是否达到自动终止的阈值
+func (r *ConfigGrayRollout) ReachErrorThreshold() bool {
+	return r.ErrorThreshold > 0 && len(r.ErrorClients) >= r.ErrorThreshold
+}
+
+// ConfigGrayRolloutResponse 按比例灰度接口的返回
+type ConfigGrayRolloutResponse struct {
+	Code    uint32             `json:"code"`
+	Info    string             `json:"info"`
+	Rollout *ConfigGrayRollout `json:"rollout,omitempty"`
+}
+
+// ConfigClientError 客户端上报的配置文件使用异常，格式为 namespace@group@file_name@release_name
+type ConfigClientError struct {
+	ClientId    string
+	Namespace   string
+	Group       string
+	FileName    string
+	ReleaseName string
+}
+
+// ParseConfigClientErrors 解析客户端上报的配置文件异常，多个文件之间使用逗号分隔，格式不正确的部分直接忽略
+func ParseConfigClientErrors(clientId, value string) []*ConfigClientError {
+	ret := make([]*ConfigClientError, 0, 1)
+	for _, item := range strings.Split(value, ",") {
+		tokens := strings.Split(strings.TrimSpace(item), "@")
+		if len(tokens) != 4 || tokens[0] == "" || tokens[1] == "" || tokens[2] == "" {
+			continue
+		}
+		ret = append(ret, &ConfigClientError{
+			ClientId:    clientId,
+			Namespace:   tokens[0],
+			Group:       tokens[1],
+			FileName:    tokens[2],
+			ReleaseName: tokens[3],
+		})
+	}
+	return ret
+}
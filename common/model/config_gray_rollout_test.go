@@ -0,0 +1,53 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfigClientErrors(t *testing.T) {
+	ret := ParseConfigClientErrors("client-1", "ns@group@file@release-1, ns@group@file2@,invalid@value")
+	assert.Equal(t, 2, len(ret))
+	assert.Equal(t, &ConfigClientError{
+		ClientId:    "client-1",
+		Namespace:   "ns",
+		Group:       "group",
+		FileName:    "file",
+		ReleaseName: "release-1",
+	}, ret[0])
+	assert.Equal(t, "file2", ret[1].FileName)
+	assert.Equal(t, "", ret[1].ReleaseName)
+
+	assert.Equal(t, 0, len(ParseConfigClientErrors("client-1", "")))
+}
+
+func TestConfigGrayRollout_ErrorThreshold(t *testing.T) {
+	rollout := &ConfigGrayRollout{ErrorThreshold: 2}
+	assert.True(t, rollout.AddErrorClient("client-1"))
+	assert.False(t, rollout.AddErrorClient("client-1"))
+	assert.False(t, rollout.ReachErrorThreshold())
+	assert.True(t, rollout.AddErrorClient("client-2"))
+	assert.True(t, rollout.ReachErrorThreshold())
+
+	// 阈值为 0 时不自动终止
+	rollout.ErrorThreshold = 0
+	assert.False(t, rollout.ReachErrorThreshold())
+}
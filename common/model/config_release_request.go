@@ -0,0 +1,125 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package model
+
+import "time"
+
+const (
+	// ReleaseRequestPending 等待审批
+	ReleaseRequestPending = "pending"
+	// ReleaseRequestApproved 审批通过，等待到达计划发布时间
+	ReleaseRequestApproved = "approved"
+	// ReleaseRequestReleasing 已经被调度任务领取，正在发布
+	ReleaseRequestReleasing = "releasing"
+	// ReleaseRequestReleased 发布成功
+	ReleaseRequestReleased = "released"
+	// ReleaseRequestFailed 发布失败
+	ReleaseRequestFailed = "failed"
+	// ReleaseRequestRejected 被审批人驳回
+	ReleaseRequestRejected = "rejected"
+	// ReleaseRequestCancelled 被申请人取消
+	ReleaseRequestCancelled = "cancelled"
+)
+
+// ConfigReleaseApprovers 可以审批发布申请的用户以及用户组，均为 ID
+type ConfigReleaseApprovers struct {
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// ConfigReleaseApproval 一次审批记录
+type ConfigReleaseApproval struct {
+	UserId   string    `json:"user_id"`
+	UserName string    `json:"user_name"`
+	Approved bool      `json:"approved"`
+	Comment  string    `json:"comment,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// ConfigReleaseRequest 配置发布申请，审批通过并且到达计划发布时间后由调度任务执行发布
+type ConfigReleaseRequest struct {
+	Id        string `json:"id"`
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	FileName  string `json:"file_name"`
+	// ReleaseName 发布时使用的版本名称，为空时自动生成
+	ReleaseName        string `json:"release_name,omitempty"`
+	ReleaseDescription string `json:"release_description,omitempty"`
+	Comment            string `json:"comment,omitempty"`
+	// Md5 创建申请时配置文件内容的 md5，执行发布时内容发生变化则发布失败，保证发布的是审批过的内容
+	Md5 string `json:"md5"`
+	// ScheduleTime 计划发布时间，为空时审批通过后立即发布
+	ScheduleTime time.Time `json:"schedule_time,omitempty"`
+	// RequiredApprovals 需要的审批通过人数，为 0 时不需要审批
+	RequiredApprovals int                      `json:"required_approvals"`
+	Approvers         ConfigReleaseApprovers   `json:"approvers"`
+	Approvals         []*ConfigReleaseApproval `json:"approvals"`
+	Status            string                   `json:"status"`
+	// Message 驳回、取消或者发布失败的原因
+	Message string `json:"message,omitempty"`
+	// ReleaseTime 实际执行发布的时间
+	ReleaseTime time.Time `json:"release_time,omitempty"`
+	// Revision 每次更新递增，用于并发审批时的乐观锁
+	Revision   uint64    `json:"revision"`
+	CreatorId  string    `json:"creator_id,omitempty"`
+	CreateTime time.Time `json:"create_time"`
+	CreateBy   string    `json:"create_by"`
+	ModifyTime time.Time `json:"modify_time"`
+	ModifyBy   string    `json:"modify_by"`
+}
+
+// IsFinished 申请是否已经结束，结束的申请不能再进行任何操作
+func (r *ConfigReleaseRequest) IsFinished() bool {
+	switch r.Status {
+	case ReleaseRequestReleased, ReleaseRequestFailed, ReleaseRequestRejected, ReleaseRequestCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// ApprovedCount 审批通过的人数
+func (r *ConfigReleaseRequest) ApprovedCount() int {
+	cnt := 0
+	for _, item := range r.Approvals {
+		if item.Approved {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// ConfigReleaseRequestAction 审批、驳回以及取消发布申请的请求
+type ConfigReleaseRequestAction struct {
+	Id      string `json:"id"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ConfigReleaseRequestResponse 发布申请接口的返回
+type ConfigReleaseRequestResponse struct {
+	Code    uint32                `json:"code"`
+	Info    string                `json:"info"`
+	Request *ConfigReleaseRequest `json:"request,omitempty"`
+}
+
+// ConfigReleaseRequestBatchResponse 查询发布申请接口的返回
+type ConfigReleaseRequestBatchResponse struct {
+	Code     uint32                  `json:"code"`
+	Info     string                  `json:"info"`
+	Total    uint32                  `json:"total"`
+	Requests []*ConfigReleaseRequest `json:"requests"`
+}
@@ -19,7 +19,11 @@ package model
 
 import (
 	"fmt"
+	"hash/fnv"
 	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 )
 
 type GrayModule string
@@ -46,3 +50,29 @@ type GrayResource struct {
 func GetGrayConfigRealseKey(release *SimpleConfigFileRelease) string {
 	return fmt.Sprintf("%v@%v@%v@%v", GrayModuleConfig, release.Namespace, release.Group, release.FileName)
 }
+
+// GrayBucketCount 按比例灰度时客户端被划分的分桶数量
+const GrayBucketCount = 100
+
+// ClientGrayBucket 计算客户端在灰度资源中所在的分桶，取值范围 [0, GrayBucketCount)
+// 分桶与灰度资源相关，避免所有的灰度总是由同一批客户端先承担
+func ClientGrayBucket(grayName, clientId string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(grayName))
+	_, _ = h.Write([]byte("@"))
+	_, _ = h.Write([]byte(clientId))
+	return int(h.Sum32() % GrayBucketCount)
+}
+
+// BuildPercentageGrayRule 生成按比例灰度的匹配规则，分桶落在 [0, percentage) 的客户端命中灰度
+func BuildPercentageGrayRule(percentage uint32) []*apimodel.ClientLabel {
+	return []*apimodel.ClientLabel{
+		{
+			Key: ClientLabel_Bucket,
+			Value: &apimodel.MatchString{
+				Type:  apimodel.MatchString_RANGE,
+				Value: &wrappers.StringValue{Value: fmt.Sprintf("0~%d", int(percentage)-1)},
+			},
+		},
+	}
+}
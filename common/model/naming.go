@@ -527,8 +527,19 @@ const (
 	MetadataRegisterFrom                = "internal-register-from"
 	MetadataInternalMetaHealthCheckPath = "internal-healthcheck_path"
 	MetadataInternalMetaTraceSampling   = "internal-trace_sampling"
+	// 主动探测健康检查的参数，探测的 HTTP 路径复用 MetadataInternalMetaHealthCheckPath
+	MetadataInternalMetaHealthCheckProtocol     = "internal-healthcheck_protocol"
+	MetadataInternalMetaHealthCheckPort         = "internal-healthcheck_port"
+	MetadataInternalMetaHealthCheckExpectStatus = "internal-healthcheck_expect_status"
+	MetadataInternalMetaHealthCheckExpectBody   = "internal-healthcheck_expect_body"
+	MetadataInternalMetaHealthCheckGrpcService  = "internal-healthcheck_grpc_service"
 )
 
+// IsProbeHealthCheck 实例元数据中设置了探测协议时，由服务端主动探测实例的健康状态，而不是依赖客户端心跳
+func IsProbeHealthCheck(metadata map[string]string) bool {
+	return metadata[MetadataInternalMetaHealthCheckProtocol] != ""
+}
+
 // Instance 组合了api的Instance对象
 type Instance struct {
 	Proto             *apiservice.Instance
@@ -715,6 +726,29 @@ func (i *Instance) MallocProto() {
 	}
 }
 
+// HostWeightRatio 计算 host 上的实例权重在所有可用实例中的占比, 不健康、隔离以及权重为0的实例不参与计算.
+// host 不在可用实例中时按照可用实例的平均权重估算 host 的占比并返回 false, 没有可用实例时返回 0 以及 false
+func HostWeightRatio(instances []*Instance, host string) (float64, bool) {
+	var self, total, count uint64
+	for _, ins := range instances {
+		if !ins.Healthy() || ins.Isolate() || ins.Weight() == 0 {
+			continue
+		}
+		count++
+		total += uint64(ins.Weight())
+		if ins.Host() == host {
+			self += uint64(ins.Weight())
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	if self == 0 {
+		return 1 / float64(count+1), false
+	}
+	return float64(self) / float64(total), true
+}
+
 // InstanceStore 对应store层（database）的对象
 type InstanceStore struct {
 	ID                string
@@ -900,6 +934,10 @@ const (
 	EventInstanceUpdate InstanceEventType = "InstanceUpdate"
 	// EventClientOffline .
 	EventClientOffline InstanceEventType = "ClientOffline"
+	// EventHealthCheckProtectionOpen health check enter protection, service is empty means global protection
+	EventHealthCheckProtectionOpen InstanceEventType = "HealthCheckProtectionOpen"
+	// EventHealthCheckProtectionClose health check exit protection, service is empty means global protection
+	EventHealthCheckProtectionClose InstanceEventType = "HealthCheckProtectionClose"
 )
 
 // CtxEventKeyMetadata 用于将metadata从Context中传入并取出
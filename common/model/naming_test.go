@@ -107,3 +107,9 @@ func TestRateLimit_Arguments2Labels(t *testing.T) {
 	assert.True(t, hasValue)
 	assert.Equal(t, "127.0.0.1", value.Value.GetValue())
 }
+
+func TestIsProbeHealthCheck(t *testing.T) {
+	assert.False(t, IsProbeHealthCheck(nil))
+	assert.False(t, IsProbeHealthCheck(map[string]string{MetadataInternalMetaHealthCheckPath: "/health"}))
+	assert.True(t, IsProbeHealthCheck(map[string]string{MetadataInternalMetaHealthCheckProtocol: "http"}))
+}
@@ -45,6 +45,12 @@ const (
 	OUpdateEnable OperationType = "UpdateEnable"
 	// ORollback Rollback resource
 	ORollback OperationType = "Rollback"
+	// OApprove Approve resource
+	OApprove OperationType = "Approve"
+	// OReject Reject resource
+	OReject OperationType = "Reject"
+	// OCancel Cancel resource
+	OCancel OperationType = "Cancel"
 )
 
 // Resource Operating resources
@@ -52,26 +58,31 @@ type Resource string
 
 // Define the type of resource type
 const (
-	RNamespace          Resource = "Namespace"
-	RService            Resource = "Service"
-	RRouting            Resource = "Routing"
-	RCircuitBreaker     Resource = "CircuitBreaker"
-	RInstance           Resource = "Instance"
-	RRateLimit          Resource = "RateLimit"
-	RUser               Resource = "User"
-	RUserGroup          Resource = "UserGroup"
-	RUserGroupRelation  Resource = "UserGroupRelation"
-	RAuthStrategy       Resource = "AuthStrategy"
-	RConfigGroup        Resource = "ConfigGroup"
-	RConfigFile         Resource = "ConfigFile"
-	RConfigFileRelease  Resource = "ConfigFileRelease"
-	RCircuitBreakerRule Resource = "CircuitBreakerRule"
-	RFaultDetectRule    Resource = "FaultDetectRule"
-	RServiceContract    Resource = "ServiceContract"
+	RNamespace            Resource = "Namespace"
+	RService              Resource = "Service"
+	RRouting              Resource = "Routing"
+	RCircuitBreaker       Resource = "CircuitBreaker"
+	RInstance             Resource = "Instance"
+	RRateLimit            Resource = "RateLimit"
+	RUser                 Resource = "User"
+	RUserGroup            Resource = "UserGroup"
+	RUserGroupRelation    Resource = "UserGroupRelation"
+	RAuthStrategy         Resource = "AuthStrategy"
+	RAPIToken             Resource = "APIToken"
+	RConfigGroup          Resource = "ConfigGroup"
+	RConfigFile           Resource = "ConfigFile"
+	RConfigFileRelease    Resource = "ConfigFileRelease"
+	RConfigFileSchema     Resource = "ConfigFileSchema"
+	RConfigReleaseRequest Resource = "ConfigReleaseRequest"
+	RConfigGrayRollout    Resource = "ConfigGrayRollout"
+	RCircuitBreakerRule   Resource = "CircuitBreakerRule"
+	RFaultDetectRule      Resource = "FaultDetectRule"
+	RServiceContract      Resource = "ServiceContract"
 )
 
 // RecordEntry Operation records
 type RecordEntry struct {
+	ID            uint64
 	ResourceType  Resource
 	ResourceName  string
 	Namespace     string
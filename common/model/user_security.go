@@ -0,0 +1,127 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// LoginLockUserPrefix 按照用户统计登录失败次数的 key 前缀
+	LoginLockUserPrefix = "user:"
+	// LoginLockIPPrefix 按照来源IP统计登录失败次数的 key 前缀
+	LoginLockIPPrefix = "ip:"
+)
+
+var (
+	// ErrorLoginLocked 登录失败次数过多，暂时被锁定
+	ErrorLoginLocked error = errors.New("too many login failures, temporarily locked")
+	// ErrorPasswordExpired 密码已经过期，需要修改密码后才能登录
+	ErrorPasswordExpired error = errors.New("password expired, please change password")
+	// ErrorPasswordReused 新密码与最近使用过的密码重复
+	ErrorPasswordReused error = errors.New("password has been used recently")
+)
+
+// LoginLock 登录失败的计数以及锁定状态，可以针对用户或者来源IP
+type LoginLock struct {
+	// Key 格式为 user:{用户ID} 或者 ip:{来源IP}
+	Key string `json:"key"`
+	// FailCount 当前统计窗口内的登录失败次数
+	FailCount int `json:"fail_count"`
+	// WindowStart 当前统计窗口的开始时间
+	WindowStart time.Time `json:"window_start"`
+	// LockedUntil 锁定的截止时间，为空表示没有被锁定
+	LockedUntil time.Time `json:"locked_until,omitempty"`
+	ModifyTime  time.Time `json:"modify_time"`
+}
+
+// IsLocked 当前是否处于锁定状态
+func (l *LoginLock) IsLocked(now time.Time) bool {
+	return l != nil && !l.LockedUntil.IsZero() && now.Before(l.LockedUntil)
+}
+
+// LoginFailure 一次登录失败的计数请求，存储层在同一个事务中读取当前计数、累加并判断是否需要锁定，
+// 避免多个节点同时记录失败时互相覆盖计数
+type LoginFailure struct {
+	// Key 格式为 user:{用户ID} 或者 ip:{来源IP}
+	Key string
+	// Time 登录失败的时间，由发起请求的节点设置，保证各个副本计算结果一致
+	Time time.Time
+	// Threshold 统计窗口内允许的最大失败次数
+	Threshold int
+	// Window 失败次数的统计窗口
+	Window time.Duration
+	// LockTime 达到阈值后的锁定时间
+	LockTime time.Duration
+}
+
+// Apply 在当前的计数以及锁定状态上记录本次失败，返回新的计数以及锁定状态
+func (f *LoginFailure) Apply(lock *LoginLock) *LoginLock {
+	if lock == nil || f.Time.Sub(lock.WindowStart) > f.Window {
+		lock = &LoginLock{Key: f.Key, WindowStart: f.Time}
+	}
+	lock.FailCount++
+	if lock.FailCount >= f.Threshold {
+		lock.LockedUntil = f.Time.Add(f.LockTime)
+		// 锁定之后重新开始统计
+		lock.FailCount = 0
+		lock.WindowStart = f.Time
+	}
+	return lock
+}
+
+// UserLoginLockKey 用户维度的登录锁定 key
+func UserLoginLockKey(userID string) string {
+	return LoginLockUserPrefix + userID
+}
+
+// IPLoginLockKey 来源IP维度的登录锁定 key
+func IPLoginLockKey(ip string) string {
+	return LoginLockIPPrefix + ip
+}
+
+// UserPasswordRecord 用户最近使用过的密码以及最近一次修改密码的时间
+type UserPasswordRecord struct {
+	UserID string
+	// History 最近使用过的密码 hash，按照时间倒序排列，第一个为当前密码
+	History []string
+	// ChangeTime 最近一次修改密码的时间
+	ChangeTime time.Time
+}
+
+// LoginUnlockRequest 解除登录锁定的请求，UserID 和 IP 至少设置一个
+type LoginUnlockRequest struct {
+	UserID string `json:"user_id"`
+	IP     string `json:"ip"`
+}
+
+// LoginLockResponse 登录锁定相关接口的返回
+type LoginLockResponse struct {
+	Code  uint32       `json:"code"`
+	Info  string       `json:"info"`
+	Locks []*LoginLock `json:"locks,omitempty"`
+}
+
+// ChangeExpiredPasswordRequest 使用原密码修改已经过期的密码，不需要携带 token
+type ChangeExpiredPasswordRequest struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
@@ -0,0 +1,169 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package redispool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/polarismesh/polaris/common/log"
+)
+
+const (
+	// DefaultQuotaTimeout 访问共享配额的默认超时时间
+	DefaultQuotaTimeout = 200 * time.Millisecond
+	// DefaultQuotaBackoff 访问共享配额失败后，默认在该时间内不再访问 redis
+	DefaultQuotaBackoff = 5 * time.Second
+)
+
+// ErrQuotaUnavailable 共享配额处于失败后的退避时间内，调用方需要直接使用本地配额
+var ErrQuotaUnavailable = errors.New("shared quota is unavailable, backoff after redis failure")
+
+// redisNowScript 以 redis 的时间作为当前时间，单位为微秒，避免各个节点的时钟误差导致配额计算错误
+const redisNowScript = `
+redis.replicate_commands()
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+`
+
+// tokenBucketScript 令牌桶的状态保存在 redis 的 hash 中，ARGV 为每秒补充的令牌数以及令牌桶大小，
+// 返回是否获取到令牌以及获取不到时需要等待的微秒数
+var tokenBucketScript = redis.NewScript(redisNowScript + `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000000)
+	ts = now
+end
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// fixedWindowScript 使用 redis 的时间划分固定窗口，窗口的开始时间和计数保存在同一个 hash 中，ARGV 为增加的计数以及
+// 窗口长度（毫秒），返回增加后窗口内的总计数以及距离窗口重置的微秒数
+var fixedWindowScript = redis.NewScript(redisNowScript + `
+local duration = tonumber(ARGV[2]) * 1000
+local start = now - (now % duration)
+if tonumber(redis.call('HGET', KEYS[1], 'start')) ~= start then
+	redis.call('HSET', KEYS[1], 'start', start, 'count', 0)
+end
+local count = redis.call('HINCRBY', KEYS[1], 'count', tonumber(ARGV[1]))
+redis.call('PEXPIRE', KEYS[1], 2 * tonumber(ARGV[2]))
+return {count, start + duration - now}
+`)
+
+// QuotaClient 基于 redis 的跨节点共享配额。访问 redis 失败后的退避时间内直接返回 ErrQuotaUnavailable，
+// 退避结束后只放行一个请求探测 redis 是否恢复，避免 redis 故障时每个请求都等待超时，也避免错误日志刷屏
+type QuotaClient struct {
+	client  redis.UniversalClient
+	timeout time.Duration
+	backoff time.Duration
+	// retryAt 退避结束的时间，单位为纳秒，为 0 表示 redis 可用
+	retryAt atomic.Int64
+}
+
+// NewQuotaClient 创建共享配额客户端，timeout 以及 backoff 不大于 0 时使用默认值
+func NewQuotaClient(client redis.UniversalClient, timeout, backoff time.Duration) *QuotaClient {
+	if timeout <= 0 {
+		timeout = DefaultQuotaTimeout
+	}
+	if backoff <= 0 {
+		backoff = DefaultQuotaBackoff
+	}
+	return &QuotaClient{client: client, timeout: timeout, backoff: backoff}
+}
+
+// ReserveToken 从 key 对应的令牌桶中获取一个令牌，r 为每秒补充的令牌数，b 为令牌桶大小，获取不到时返回需要等待的时间
+func (q *QuotaClient) ReserveToken(key string, r int, b int) (bool, time.Duration, error) {
+	ret, err := q.run(context.Background(), tokenBucketScript, key, r, b)
+	if err != nil {
+		return false, 0, err
+	}
+	return ret[0] == 1, time.Duration(ret[1]) * time.Microsecond, nil
+}
+
+// IncrWindow 在 key 当前的固定窗口内增加 hits 个计数，窗口按照 redis 的时钟划分，返回增加后窗口内的总计数以及距离窗口重置的时间
+func (q *QuotaClient) IncrWindow(ctx context.Context, key string, hits uint32,
+	duration time.Duration) (uint64, time.Duration, error) {
+	durationMs := duration.Milliseconds()
+	if durationMs <= 0 {
+		return 0, 0, errors.New("invalid quota duration")
+	}
+	ret, err := q.run(ctx, fixedWindowScript, key, hits, durationMs)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint64(ret[0]), time.Duration(ret[1]) * time.Microsecond, nil
+}
+
+// run 执行配额脚本，脚本固定返回两个整数
+func (q *QuotaClient) run(ctx context.Context, script *redis.Script, key string,
+	args ...interface{}) ([]int64, error) {
+	if !q.acquireProbe(time.Now()) {
+		return nil, ErrQuotaUnavailable
+	}
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+	ret, err := script.Run(ctx, q.client, []string{key}, args...).Int64Slice()
+	if err == nil && len(ret) != 2 {
+		err = errors.New("invalid shared quota script result")
+	}
+	if err != nil {
+		q.retryAt.Store(time.Now().Add(q.backoff).UnixNano())
+		log.Errorf("[RedisPool] access shared quota(%s) fail, skip redis in the next %v: %v", key, q.backoff, err)
+		return nil, err
+	}
+	if q.retryAt.Load() != 0 {
+		q.retryAt.Store(0)
+		log.Infof("[RedisPool] shared quota recovered")
+	}
+	return ret, nil
+}
+
+// acquireProbe 是否可以访问 redis。退避时间内返回 false，退避结束后只有一个调用方可以访问 redis 探测是否恢复，
+// 探测期间其他调用方继续退避
+func (q *QuotaClient) acquireProbe(now time.Time) bool {
+	retryAt := q.retryAt.Load()
+	if retryAt == 0 {
+		return true
+	}
+	if now.UnixNano() < retryAt {
+		return false
+	}
+	return q.retryAt.CompareAndSwap(retryAt, now.Add(q.backoff).UnixNano())
+}
@@ -0,0 +1,48 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package redispool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_QuotaClientBackoff(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	quota := NewQuotaClient(client, 0, time.Minute)
+
+	// 第一次访问 redis 失败，进入退避
+	_, _, err := quota.ReserveToken("quota", 10, 10)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrQuotaUnavailable))
+
+	// 退避时间内不再访问 redis
+	_, _, err = quota.ReserveToken("quota", 10, 10)
+	assert.True(t, errors.Is(err, ErrQuotaUnavailable))
+	assert.False(t, quota.acquireProbe(time.Now()))
+
+	// 退避结束后只有一个调用方可以探测
+	retryAt := time.Now().Add(2 * time.Minute)
+	assert.True(t, quota.acquireProbe(retryAt))
+	assert.False(t, quota.acquireProbe(retryAt))
+}
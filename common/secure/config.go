@@ -18,6 +18,10 @@
 package secure
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/polarismesh/polaris/common/log"
@@ -37,6 +41,45 @@ type TLSConfig struct {
 	// InsecureSkipVerify tls 的一个配置
 	// 客户端是否验证证书和服务器主机名
 	InsecureSkipVerify bool `mapstructure:"insecureSkipTlsVerify"`
+
+	// ClientAuth 客户端证书校验模式 none | optional | require，默认为 none，开启时使用 TrustedCAFile 校验客户端证书
+	ClientAuth string `mapstructure:"clientAuth"`
+	// ReloadInterval 检查证书以及 CA 文件是否变化的间隔，文件变化后自动重新加载
+	ReloadInterval time.Duration `mapstructure:"reloadInterval"`
+	// IdentityMapping 客户端证书身份（SPIFFE ID 或者 CN）到 principal 的映射
+	// principal 的格式为 user:{用户ID} 或者 group:{用户组ID}
+	IdentityMapping map[string]string `mapstructure:"identityMapping"`
+}
+
+// ToTLSInfo 检查配置并转换为监听使用的 tls 配置信息
+func (c *TLSConfig) ToTLSInfo() (*TLSInfo, error) {
+	mode := ClientAuthMode(strings.ToLower(c.ClientAuth))
+	switch mode {
+	case "":
+		mode = ClientAuthNone
+	case ClientAuthNone, ClientAuthOptional, ClientAuthRequire:
+	default:
+		return nil, fmt.Errorf("tls clientAuth(%s) must be none | optional | require", c.ClientAuth)
+	}
+	if mode != ClientAuthNone && c.TrustedCAFile == "" {
+		return nil, fmt.Errorf("tls clientAuth(%s) need trustedCAFile", c.ClientAuth)
+	}
+	for identity, principal := range c.IdentityMapping {
+		if !strings.HasPrefix(principal, PrincipalUserPrefix) && !strings.HasPrefix(principal, PrincipalGroupPrefix) {
+			return nil, fmt.Errorf("tls identityMapping %s -> %s, principal must be user:{id} or group:{id}",
+				identity, principal)
+		}
+	}
+	return &TLSInfo{
+		CertFile:        c.CertFile,
+		KeyFile:         c.KeyFile,
+		TrustedCAFile:   c.TrustedCAFile,
+		ServerName:      c.ServerName,
+		ClientAuth:      mode,
+		ClientCertAuth:  mode == ClientAuthRequire,
+		ReloadInterval:  c.ReloadInterval,
+		IdentityMapping: c.IdentityMapping,
+	}, nil
 }
 
 // ParseTLSConfig 解析 tls 配置
@@ -0,0 +1,191 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package secure
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/log"
+)
+
+const (
+	// defaultReloadInterval 默认检查证书文件变化的间隔
+	defaultReloadInterval = 10 * time.Second
+)
+
+// CertReloader 从磁盘加载服务端证书以及客户端的信任 CA，文件发生变化后自动重新加载，
+// 用于证书以及 CA 轮转时不需要重启监听
+type CertReloader struct {
+	info *TLSInfo
+
+	lock      sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// NewCertReloader 创建证书加载器，首次加载失败时直接返回错误
+func NewCertReloader(info *TLSInfo) (*CertReloader, error) {
+	if info.IsEmpty() {
+		return nil, errors.New("tls cert file and key file are required")
+	}
+	r := &CertReloader{
+		info:     info,
+		modTimes: map[string]time.Time{},
+		stopCh:   make(chan struct{}),
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start 定时检查证书文件是否发生变化
+func (r *CertReloader) Start() {
+	interval := r.info.ReloadInterval
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-ticker.C:
+				if reloaded, err := r.Reload(); err != nil {
+					log.Errorf("[Secure] reload tls cert(%s) fail, keep using the old one: %s",
+						r.info.CertFile, err.Error())
+				} else if reloaded {
+					log.Infof("[Secure] reload tls cert(%s) and trusted ca(%s)", r.info.CertFile, r.info.TrustedCAFile)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止检查证书文件
+func (r *CertReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+}
+
+// Reload 文件有变化时重新加载证书以及 CA，加载失败时保留原有的证书
+func (r *CertReloader) Reload() (bool, error) {
+	files := []string{r.info.CertFile, r.info.KeyFile}
+	if r.info.IsMutual() {
+		files = append(files, r.info.TrustedCAFile)
+	}
+	modTimes := make(map[string]time.Time, len(files))
+	changed := false
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes[file] = stat.ModTime()
+		if old, ok := r.modTimes[file]; !ok || !old.Equal(stat.ModTime()) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.info.CertFile, r.info.KeyFile)
+	if err != nil {
+		return false, err
+	}
+	var clientCAs *x509.CertPool
+	if r.info.IsMutual() {
+		pem, err := os.ReadFile(r.info.TrustedCAFile)
+		if err != nil {
+			return false, err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, fmt.Errorf("no valid certificate in trusted ca file %s", r.info.TrustedCAFile)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	return true, nil
+}
+
+// ServerTLSConfig 监听使用的 tls 配置，每次握手都会使用最新加载的证书以及 CA
+func (r *CertReloader) ServerTLSConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+	switch r.info.ClientAuth {
+	case ClientAuthRequire:
+		cfg.ClientAuth = tls.RequireAnyClientCert
+	case ClientAuthOptional:
+		cfg.ClientAuth = tls.RequestClientCert
+	}
+	if r.info.IsMutual() {
+		// 信任的 CA 会发生变化，因此不使用 tls.Config.ClientCAs，在这里使用最新的 CA 自行校验证书链。
+		// VerifyPeerCertificate 在会话恢复时不会被调用，VerifyConnection 对完整握手以及会话恢复都会生效
+		cfg.VerifyConnection = r.verifyConnection
+	}
+	return cfg
+}
+
+func (r *CertReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert, nil
+}
+
+// verifyConnection 使用当前信任的 CA 校验客户端证书，客户端没有携带证书时是否放行由 ClientAuth 决定
+func (r *CertReloader) verifyConnection(cs tls.ConnectionState) error {
+	certs := cs.PeerCertificates
+	if len(certs) == 0 {
+		return nil
+	}
+	r.lock.RLock()
+	roots := r.clientCAs
+	r.lock.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
@@ -0,0 +1,325 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package secure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发证书，返回证书以及私钥的 PEM
+func (ca *testCA) issue(t *testing.T, cn string, uri string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+	}
+	if uri != "" {
+		u, err := url.Parse(uri)
+		assert.NoError(t, err)
+		tmpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// handshake 使用客户端证书完成一次握手，返回服务端看到的客户端证书
+func handshake(t *testing.T, serverCfg *tls.Config, serverCA *testCA,
+	clientCert *tls.Certificate) ([]*x509.Certificate, error) {
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	clientCfg := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	if clientCert != nil {
+		clientCfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	state, err := handshakeWithConfig(t, serverCfg, clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	return state.PeerCertificates, nil
+}
+
+// handshakeWithConfig 使用指定的客户端配置完成一次握手，返回服务端的连接状态
+func handshakeWithConfig(t *testing.T, serverCfg, clientCfg *tls.Config) (tls.ConnectionState, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+		if err != nil {
+			return
+		}
+		defer client.Close()
+		// 服务端校验失败时客户端不一定能感知，这里读一次等待服务端关闭连接，同时接收会话票据
+		_, _ = client.Read(make([]byte, 1))
+	}()
+
+	conn, err := ln.Accept()
+	assert.NoError(t, err)
+	server := tls.Server(conn, serverCfg)
+	err = server.Handshake()
+	state := server.ConnectionState()
+	_ = conn.Close()
+	<-done
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	return state, nil
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	otherCA := newTestCA(t, "other-ca")
+
+	info := &TLSInfo{
+		CertFile:      filepath.Join(dir, "server.crt"),
+		KeyFile:       filepath.Join(dir, "server.key"),
+		TrustedCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:    ClientAuthRequire,
+		IdentityMapping: map[string]string{
+			"spiffe://polaris/ns/default/sa/ci": "user:ci-id",
+			"ops":                               "group:ops-id",
+		},
+	}
+	modTime := time.Now().Add(-time.Minute)
+	certPEM, keyPEM := serverCA.issue(t, "polaris", "", x509.ExtKeyUsageServerAuth)
+	writeFile(t, info.CertFile, certPEM, modTime)
+	writeFile(t, info.KeyFile, keyPEM, modTime)
+	writeFile(t, info.TrustedCAFile, clientCA.pem, modTime)
+
+	reloader, err := NewCertReloader(info)
+	assert.NoError(t, err)
+	cfg := reloader.ServerTLSConfig()
+
+	loadPair := func(ca *testCA, cn, uri string) *tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, cn, uri, x509.ExtKeyUsageClientAuth)
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		assert.NoError(t, err)
+		return &pair
+	}
+
+	t.Run("spiffe-id", func(t *testing.T) {
+		certs, err := handshake(t, cfg, serverCA, loadPair(clientCA, "ci", "spiffe://polaris/ns/default/sa/ci"))
+		assert.NoError(t, err)
+		assert.Equal(t, "spiffe://polaris/ns/default/sa/ci", CertIdentity(certs[0]))
+		assert.Equal(t, "user:ci-id", info.MapPrincipal(certs))
+	})
+
+	t.Run("common-name", func(t *testing.T) {
+		certs, err := handshake(t, cfg, serverCA, loadPair(clientCA, "ops", ""))
+		assert.NoError(t, err)
+		assert.Equal(t, "group:ops-id", info.MapPrincipal(certs))
+	})
+
+	t.Run("require-client-cert", func(t *testing.T) {
+		_, err := handshake(t, cfg, serverCA, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("untrusted-client-cert", func(t *testing.T) {
+		_, err := handshake(t, cfg, serverCA, loadPair(otherCA, "ops", ""))
+		assert.Error(t, err)
+	})
+
+	t.Run("rotate-trusted-ca", func(t *testing.T) {
+		reloaded, err := reloader.Reload()
+		assert.NoError(t, err)
+		assert.False(t, reloaded)
+
+		writeFile(t, info.TrustedCAFile, append(append([]byte{}, clientCA.pem...), otherCA.pem...), time.Now())
+		reloaded, err = reloader.Reload()
+		assert.NoError(t, err)
+		assert.True(t, reloaded)
+
+		_, err = handshake(t, cfg, serverCA, loadPair(otherCA, "ops", ""))
+		assert.NoError(t, err)
+	})
+
+	t.Run("keep-old-cert-when-reload-fail", func(t *testing.T) {
+		writeFile(t, info.CertFile, []byte("invalid"), time.Now().Add(time.Minute))
+		_, err := reloader.Reload()
+		assert.Error(t, err)
+
+		_, err = handshake(t, cfg, serverCA, loadPair(clientCA, "ops", ""))
+		assert.NoError(t, err)
+	})
+}
+
+func TestCertReloader_ResumedSessionAfterRotateCA(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	otherCA := newTestCA(t, "other-ca")
+
+	info := &TLSInfo{
+		CertFile:      filepath.Join(dir, "server.crt"),
+		KeyFile:       filepath.Join(dir, "server.key"),
+		TrustedCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:    ClientAuthRequire,
+	}
+	modTime := time.Now().Add(-time.Minute)
+	certPEM, keyPEM := serverCA.issue(t, "polaris", "", x509.ExtKeyUsageServerAuth)
+	writeFile(t, info.CertFile, certPEM, modTime)
+	writeFile(t, info.KeyFile, keyPEM, modTime)
+	writeFile(t, info.TrustedCAFile, clientCA.pem, modTime)
+
+	reloader, err := NewCertReloader(info)
+	assert.NoError(t, err)
+	serverCfg := reloader.ServerTLSConfig()
+
+	clientPEM, clientKey := clientCA.issue(t, "ops", "", x509.ExtKeyUsageClientAuth)
+	pair, err := tls.X509KeyPair(clientPEM, clientKey)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	clientCfg := &tls.Config{
+		RootCAs:            roots,
+		ServerName:         "localhost",
+		Certificates:       []tls.Certificate{pair},
+		ClientSessionCache: tls.NewLRUClientSessionCache(4),
+	}
+
+	_, err = handshakeWithConfig(t, serverCfg, clientCfg)
+	assert.NoError(t, err)
+	state, err := handshakeWithConfig(t, serverCfg, clientCfg)
+	assert.NoError(t, err)
+	assert.True(t, state.DidResume)
+
+	// 客户端 CA 不再被信任后，恢复的会话同样需要拒绝
+	writeFile(t, info.TrustedCAFile, otherCA.pem, time.Now())
+	reloaded, err := reloader.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	_, err = handshakeWithConfig(t, serverCfg, clientCfg)
+	assert.Error(t, err)
+}
+
+func TestCertReloader_OptionalClientCert(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+
+	info := &TLSInfo{
+		CertFile:      filepath.Join(dir, "server.crt"),
+		KeyFile:       filepath.Join(dir, "server.key"),
+		TrustedCAFile: filepath.Join(dir, "ca.crt"),
+		ClientAuth:    ClientAuthOptional,
+	}
+	certPEM, keyPEM := serverCA.issue(t, "polaris", "", x509.ExtKeyUsageServerAuth)
+	writeFile(t, info.CertFile, certPEM, time.Now())
+	writeFile(t, info.KeyFile, keyPEM, time.Now())
+	writeFile(t, info.TrustedCAFile, clientCA.pem, time.Now())
+
+	reloader, err := NewCertReloader(info)
+	assert.NoError(t, err)
+	certs, err := handshake(t, reloader.ServerTLSConfig(), serverCA, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, certs)
+	assert.Empty(t, info.MapPrincipal(certs))
+}
+
+func TestTLSConfig_ToTLSInfo(t *testing.T) {
+	cfg, err := ParseTLSConfig(map[interface{}]interface{}{
+		"certFile":       "server.crt",
+		"keyFile":        "server.key",
+		"trustedCAFile":  "ca.crt",
+		"clientAuth":     "require",
+		"reloadInterval": "30s",
+		"identityMapping": map[interface{}]interface{}{
+			"spiffe://polaris/ns/default/sa/ci": "user:ci-id",
+		},
+	})
+	assert.NoError(t, err)
+	info, err := cfg.ToTLSInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, ClientAuthRequire, info.ClientAuth)
+	assert.True(t, info.IsMutual())
+	assert.Equal(t, 30*time.Second, info.ReloadInterval)
+	assert.Equal(t, "user:ci-id", info.IdentityMapping["spiffe://polaris/ns/default/sa/ci"])
+
+	info, err = (&TLSConfig{CertFile: "server.crt", KeyFile: "server.key"}).ToTLSInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, ClientAuthNone, info.ClientAuth)
+	assert.False(t, info.IsMutual())
+
+	_, err = (&TLSConfig{ClientAuth: "always"}).ToTLSInfo()
+	assert.Error(t, err)
+	_, err = (&TLSConfig{ClientAuth: "optional"}).ToTLSInfo()
+	assert.Error(t, err)
+	_, err = (&TLSConfig{IdentityMapping: map[string]string{"ci": "ci-id"}}).ToTLSInfo()
+	assert.Error(t, err)
+}
@@ -19,6 +19,27 @@ package secure
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+// ClientAuthMode 监听对客户端证书的校验模式
+type ClientAuthMode string
+
+const (
+	// ClientAuthNone 不要求客户端证书
+	ClientAuthNone ClientAuthMode = "none"
+	// ClientAuthOptional 客户端可以不携带证书，携带时必须校验通过
+	ClientAuthOptional ClientAuthMode = "optional"
+	// ClientAuthRequire 客户端必须携带校验通过的证书
+	ClientAuthRequire ClientAuthMode = "require"
+
+	// PrincipalUserPrefix 证书身份映射到用户时 principal 的前缀
+	PrincipalUserPrefix = "user:"
+	// PrincipalGroupPrefix 证书身份映射到用户组时 principal 的前缀
+	PrincipalGroupPrefix = "group:"
+	// spiffeScheme SPIFFE ID 的 URI scheme
+	spiffeScheme = "spiffe"
 )
 
 // TLSInfo tls 配置信息
@@ -62,6 +83,13 @@ type TLSInfo struct {
 	// AllowedHostname 必须与 TLS 匹配的 IP 地址或主机名
 	// 是由客户端提供的证书
 	AllowedHostname string
+
+	// ClientAuth 客户端证书的校验模式
+	ClientAuth ClientAuthMode
+	// ReloadInterval 检查证书以及 CA 文件是否变化的间隔
+	ReloadInterval time.Duration
+	// IdentityMapping 客户端证书身份到 principal 的映射
+	IdentityMapping map[string]string
 }
 
 // IsEmpty 检查 tls 配置信息是否为空 当证书和密钥同时存在时才不为空
@@ -74,3 +102,29 @@ func (t *TLSInfo) IsEmpty() bool {
 	}
 	return true
 }
+
+// IsMutual 是否开启了客户端证书校验
+func (t *TLSInfo) IsMutual() bool {
+	return !t.IsEmpty() && t.ClientAuth != "" && t.ClientAuth != ClientAuthNone
+}
+
+// MapPrincipal 将客户端证书的身份映射为 principal，没有证书或者没有配置映射时返回空
+func (t *TLSInfo) MapPrincipal(certs []*x509.Certificate) string {
+	if t == nil || len(certs) == 0 || len(t.IdentityMapping) == 0 {
+		return ""
+	}
+	return t.IdentityMapping[CertIdentity(certs[0])]
+}
+
+// CertIdentity 获取证书的身份标识，优先使用 URI SAN 中的 SPIFFE ID，没有时使用 Subject CN
+func CertIdentity(cert *x509.Certificate) string {
+	if cert == nil {
+		return ""
+	}
+	for _, uri := range cert.URIs {
+		if uri.Scheme == spiffeScheme {
+			return uri.String()
+		}
+	}
+	return cert.Subject.CommonName
+}
@@ -24,9 +24,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
@@ -340,12 +343,21 @@ func ParseClientIP(ctx context.Context) string {
 		return ""
 	}
 	rid, _ := ctx.Value(ContextClientAddress).(string)
-	if strings.Contains(rid, ":") {
-		return strings.Split(rid, ":")[0]
+	if host, _, err := net.SplitHostPort(rid); err == nil {
+		return host
 	}
 	return rid
 }
 
+// RetryAfterSeconds 将限流的退避时间转换为 Retry-After 的秒数，不足一秒按照一秒计算
+func RetryAfterSeconds(backoff time.Duration) string {
+	seconds := int64(math.Ceil(backoff.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
+
 // ParseAuthToken 从ctx中获取token
 func ParseAuthToken(ctx context.Context) string {
 	if ctx == nil {
@@ -356,6 +368,16 @@ func ParseAuthToken(ctx context.Context) string {
 	return token
 }
 
+// ParseConfigError 从ctx中获取客户端上报的配置文件异常
+func ParseConfigError(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	value, _ := ctx.Value(ContextConfigErrorKey).(string)
+	return value
+}
+
 // ParseIsOwner 从ctx中获取token
 func ParseIsOwner(ctx context.Context) bool {
 	if ctx == nil {
@@ -376,6 +398,26 @@ func ParseUserID(ctx context.Context) string {
 	return userID
 }
 
+// ParseCertPrincipal 从ctx中解析客户端证书映射的 principal，只有开启双向 tls 的监听才会设置
+func ParseCertPrincipal(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	principal, _ := ctx.Value(ContextCertPrincipalKey).(string)
+	return principal
+}
+
+// ParseAPITokenID 从ctx中解析当前请求使用的 API token ID
+func ParseAPITokenID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	tokenID, _ := ctx.Value(ContextAPITokenIDKey).(string)
+	return tokenID
+}
+
 // ParseUserName 从ctx解析用户名称
 func ParseUserName(ctx context.Context) string {
 	if ctx == nil {
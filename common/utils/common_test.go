@@ -19,6 +19,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/wrappers"
 )
@@ -57,3 +58,21 @@ func TestCheckResourceName(t *testing.T) {
 		})
 	}
 }
+
+// TestRetryAfterSeconds tests the RetryAfterSeconds function
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		backoff time.Duration
+		want    string
+	}{
+		{backoff: 0, want: "1"},
+		{backoff: 200 * time.Millisecond, want: "1"},
+		{backoff: time.Second, want: "1"},
+		{backoff: 1500 * time.Millisecond, want: "2"},
+	}
+	for _, tt := range tests {
+		if got := RetryAfterSeconds(tt.backoff); got != tt.want {
+			t.Errorf("RetryAfterSeconds(%v) = %v, want %v", tt.backoff, got, tt.want)
+		}
+	}
+}
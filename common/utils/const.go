@@ -46,6 +46,10 @@ const (
 	HeaderOwnerIDKey string = "X-Owner-ID"
 	// HeaderUserRoleKey user role key
 	HeaderUserRoleKey string = "X-Polaris-User-Role"
+	// HeaderConfigErrorKey 客户端上报时携带的配置文件异常，格式为 namespace@group@file_name@release_name，多个使用逗号分隔
+	HeaderConfigErrorKey string = "X-Polaris-Config-Error"
+	// HeaderRetryAfterKey 请求被限流时告知客户端需要等待多少秒后重试，gRPC 下通过同名的 trailer 返回
+	HeaderRetryAfterKey string = "Retry-After"
 
 	// ContextAuthTokenKey auth token key
 	ContextAuthTokenKey = StringContext(HeaderAuthTokenKey)
@@ -59,12 +63,18 @@ const (
 	ContextUserRoleIDKey = StringContext(HeaderUserRoleKey)
 	// ContextAuthContextKey auth context key
 	ContextAuthContextKey = StringContext("X-Polaris-AuthContext")
+	// ContextAPITokenIDKey api token id key, only set when request is authorized by api token
+	ContextAPITokenIDKey = StringContext("X-Polaris-API-Token-ID")
+	// ContextCertPrincipalKey principal mapped from the client certificate of mutual tls
+	ContextCertPrincipalKey = StringContext("X-Polaris-Cert-Principal")
 	// ContextUserNameKey users name key
 	ContextUserNameKey = StringContext("X-User-Name")
 	// ContextClientAddress client address key
 	ContextClientAddress = StringContext("client-address")
 	// ContextOpenAsyncRegis open async register key
 	ContextOpenAsyncRegis = StringContext("client-asyncRegis")
+	// ContextConfigErrorKey config file error reported by client
+	ContextConfigErrorKey = StringContext(HeaderConfigErrorKey)
 	// ContextGrpcHeader grpc header key
 	ContextGrpcHeader = StringContext("grpc-header")
 	// ContextIsFromClient is from client
@@ -216,7 +216,7 @@ func IsNotEqualMap(req map[string]string, old map[string]string) bool {
 
 // ConvertGRPCContext 将GRPC上下文转换成内部上下文
 func ConvertGRPCContext(ctx context.Context) context.Context {
-	var requestID, userAgent, token string
+	var requestID, userAgent, token, configError string
 
 	meta, exist := metadata.FromIncomingContext(ctx)
 	if exist {
@@ -231,6 +231,9 @@ func ConvertGRPCContext(ctx context.Context) context.Context {
 		if tokens := meta["x-polaris-token"]; len(tokens) > 0 {
 			token = tokens[0]
 		}
+		if errs := meta[strings.ToLower(HeaderConfigErrorKey)]; len(errs) > 0 {
+			configError = strings.Join(errs, ",")
+		}
 	} else {
 		meta = metadata.MD{}
 	}
@@ -247,6 +250,8 @@ func ConvertGRPCContext(ctx context.Context) context.Context {
 		}
 	}
 
+	principal := ParseCertPrincipal(ctx)
+
 	ctx = context.Background()
 	ctx = context.WithValue(ctx, ContextGrpcHeader, meta)
 	ctx = context.WithValue(ctx, StringContext("request-id"), requestID)
@@ -254,6 +259,12 @@ func ConvertGRPCContext(ctx context.Context) context.Context {
 	ctx = context.WithValue(ctx, ContextClientAddress, address)
 	ctx = context.WithValue(ctx, StringContext("user-agent"), userAgent)
 	ctx = context.WithValue(ctx, ContextAuthTokenKey, token)
+	if configError != "" {
+		ctx = context.WithValue(ctx, ContextConfigErrorKey, configError)
+	}
+	if principal != "" {
+		ctx = context.WithValue(ctx, ContextCertPrincipalKey, principal)
+	}
 
 	return ctx
 }
@@ -93,8 +93,14 @@ type ConfigFileReleaseOperate interface {
 	GetConfigFileReleaseHistories(ctx context.Context, filter map[string]string) *apiconfig.ConfigBatchQueryResponse
 	// UpsertAndReleaseConfigFile 创建/更新配置文件并发布
 	UpsertAndReleaseConfigFile(ctx context.Context, req *apiconfig.ConfigFilePublishInfo) *apiconfig.ConfigResponse
+	// UpsertAndReleaseConfigFileOnBase 基于某个全量发布版本创建/更新配置文件并发布，基于的版本不是最新版本时拒绝发布
+	UpsertAndReleaseConfigFileOnBase(ctx context.Context, req *model.ConfigFilePublishRequest) *apiconfig.ConfigResponse
 	// StopGrayConfigFileReleases 停止所有的灰度发布配置
 	StopGrayConfigFileReleases(ctx context.Context, reqs []*apiconfig.ConfigFileRelease) *apiconfig.ConfigBatchWriteResponse
+	// DiffConfigFile 对比配置文件两个来源的内容
+	DiffConfigFile(ctx context.Context, req *model.ConfigFileDiffRequest) *model.ConfigFileDiffResponse
+	// MergeConfigFile 检查基于某个发布版本修改的内容能否与最新的全量发布三方合并
+	MergeConfigFile(ctx context.Context, req *model.ConfigFileMergeRequest) *model.ConfigFileMergeResponse
 }
 
 // ConfigFileClientOperate 给客户端提供服务接口，不同的上层协议抽象的公共服务逻辑
@@ -132,6 +138,44 @@ type ConfigFileTemplateOperate interface {
 	GetConfigFileTemplate(ctx context.Context, name string) *apiconfig.ConfigResponse
 }
 
+// ConfigFileSchemaOperate config file schema operate
+type ConfigFileSchemaOperate interface {
+	// UpsertConfigFileSchema create or update the schema attached to a config group or config file
+	UpsertConfigFileSchema(ctx context.Context, req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse
+	// DeleteConfigFileSchema delete the schema attached to a config group or config file
+	DeleteConfigFileSchema(ctx context.Context, req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse
+	// GetConfigFileSchemas query schemas of the config group
+	GetConfigFileSchemas(ctx context.Context, filter map[string]string) *model.ConfigFileSchemaBatchResponse
+	// ValidateConfigFile dry-run the schema validation of config file content
+	ValidateConfigFile(ctx context.Context, req *model.ConfigFileValidateRequest) *model.ConfigFileValidateResponse
+}
+
+// ConfigReleaseRequestOperate config release request operate
+type ConfigReleaseRequestOperate interface {
+	// CreateConfigReleaseRequest create a scheduled or approval-gated config release request
+	CreateConfigReleaseRequest(ctx context.Context,
+		req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse
+	// ApproveConfigReleaseRequest approve config release request
+	ApproveConfigReleaseRequest(ctx context.Context,
+		action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse
+	// RejectConfigReleaseRequest reject config release request
+	RejectConfigReleaseRequest(ctx context.Context,
+		action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse
+	// CancelConfigReleaseRequest cancel config release request
+	CancelConfigReleaseRequest(ctx context.Context,
+		action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse
+	// GetConfigReleaseRequests query config release requests
+	GetConfigReleaseRequests(ctx context.Context, filter map[string]string) *model.ConfigReleaseRequestBatchResponse
+}
+
+// ConfigGrayRolloutOperate config gray rollout operate
+type ConfigGrayRolloutOperate interface {
+	// CreateConfigGrayRollout publish a gray release and advance its percentage step by step
+	CreateConfigGrayRollout(ctx context.Context, rollout *model.ConfigGrayRollout) *model.ConfigGrayRolloutResponse
+	// GetConfigGrayRollout query the latest gray rollout of the config file
+	GetConfigGrayRollout(ctx context.Context, filter map[string]string) *model.ConfigGrayRolloutResponse
+}
+
 // ConfigCenterServer 配置中心server
 type ConfigCenterServer interface {
 	ConfigFileGroupOperate
@@ -139,6 +183,9 @@ type ConfigCenterServer interface {
 	ConfigFileReleaseOperate
 	ConfigFileClientOperate
 	ConfigFileTemplateOperate
+	ConfigFileSchemaOperate
+	ConfigReleaseRequestOperate
+	ConfigGrayRolloutOperate
 }
 
 // ResourceHook The listener is placed before and after the resource operation, only normal flow
@@ -0,0 +1,293 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+// DiffConfigFile 对比配置文件两个来源的内容，来源可以是编辑中的配置、全量发布、灰度发布或者发布历史
+func (s *Server) DiffConfigFile(ctx context.Context, req *model.ConfigFileDiffRequest) *model.ConfigFileDiffResponse {
+	fileKey := &model.ConfigFileKey{Namespace: req.Namespace, Group: req.Group, Name: req.FileName}
+	from, code, err := s.loadConfigFileSnapshot(ctx, fileKey, req.From)
+	if err != nil || code != apimodel.Code_ExecuteSuccess {
+		return newConfigFileDiffResponse(code, err, nil)
+	}
+	to, code, err := s.loadConfigFileSnapshot(ctx, fileKey, req.To)
+	if err != nil || code != apimodel.Code_ExecuteSuccess {
+		return newConfigFileDiffResponse(code, err, nil)
+	}
+
+	diff := &model.ConfigFileDiff{
+		Namespace: req.Namespace,
+		Group:     req.Group,
+		FileName:  req.FileName,
+		From:      from,
+		To:        to,
+		Identical: from.Md5 == to.Md5,
+		Lines:     diffLines(from.Content, to.Content),
+	}
+	format := to.Format
+	if format == "" {
+		format = from.Format
+	}
+	keys, err := diffKeys(format, from.Content, to.Content)
+	if err != nil {
+		diff.KeysError = err.Error()
+	} else {
+		diff.Keys = keys
+	}
+	return newConfigFileDiffResponse(apimodel.Code_ExecuteSuccess, nil, diff)
+}
+
+// MergeConfigFile 检查基于 BaseVersion 修改的内容能否与最新的全量发布进行三方合并
+func (s *Server) MergeConfigFile(ctx context.Context,
+	req *model.ConfigFileMergeRequest) *model.ConfigFileMergeResponse {
+	fileKey := &model.ConfigFileKey{Namespace: req.Namespace, Group: req.Group, Name: req.FileName}
+	active, err := s.storage.GetConfigFileActiveRelease(fileKey)
+	if err != nil {
+		log.Error("[Config][Merge] get active config file release.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), utils.ZapFileName(req.FileName),
+			zap.Error(err))
+		return newConfigFileMergeResponse(commonstore.StoreCode2APICode(err), err, nil)
+	}
+	result, code, err := s.mergeConfigFile(ctx, fileKey, active, req.BaseVersion, req.Content)
+	return newConfigFileMergeResponse(code, err, result)
+}
+
+// mergeConfigFile 以 baseVersion 对应的发布内容为共同祖先，合并 content 以及最新全量发布 active 的修改
+func (s *Server) mergeConfigFile(ctx context.Context, fileKey *model.ConfigFileKey, active *model.ConfigFileRelease,
+	baseVersion uint64, content string) (*model.ConfigFileMergeResult, apimodel.Code, error) {
+	result := &model.ConfigFileMergeResult{BaseVersion: baseVersion}
+	theirs := ""
+	if active != nil {
+		result.LatestVersion = active.Version
+		release, err := s.chains.AfterGetFileRelease(ctx, active)
+		if err != nil {
+			return nil, apimodel.Code_ExecuteException, err
+		}
+		theirs = release.Content
+	}
+	if result.LatestVersion == baseVersion {
+		result.Merged = content
+		return result, apimodel.Code_ExecuteSuccess, nil
+	}
+
+	result.Stale = true
+	base := ""
+	if baseVersion > 0 {
+		history, err := s.findReleaseHistoryByVersion(ctx, fileKey, baseVersion)
+		if err != nil {
+			return nil, commonstore.StoreCode2APICode(err), err
+		}
+		if history == nil {
+			return nil, apimodel.Code_NotFoundResource,
+				fmt.Errorf("release version %d not found in release histories", baseVersion)
+		}
+		base = history.Content
+	}
+	merged, conflicts := threeWayMerge(base, content, theirs)
+	result.Merged = merged
+	result.Conflicts = conflicts
+	result.Conflict = len(conflicts) > 0
+	return result, apimodel.Code_ExecuteSuccess, nil
+}
+
+// checkBaseVersion 检查修改所基于的发布版本是否仍然是最新的全量发布，不是最新版本时返回三方合并的结果
+func (s *Server) checkBaseVersion(ctx context.Context, tx store.Tx, fileKey *model.ConfigFileKey,
+	baseVersion uint64, content string) *model.ConfigFileMergeResult {
+	active, err := s.storage.GetConfigFileActiveReleaseTx(tx, fileKey)
+	if err != nil {
+		log.Error("[Config][Merge] get active config file release when check base version.",
+			utils.RequestID(ctx), utils.ZapNamespace(fileKey.Namespace), utils.ZapGroup(fileKey.Group),
+			utils.ZapFileName(fileKey.Name), zap.Error(err))
+		return &model.ConfigFileMergeResult{BaseVersion: baseVersion, Stale: true, Conflict: true}
+	}
+	if active == nil && baseVersion == 0 || active != nil && active.Version == baseVersion {
+		return nil
+	}
+	result, _, err := s.mergeConfigFile(ctx, fileKey, active, baseVersion, content)
+	if err != nil {
+		log.Warn("[Config][Merge] three-way merge config file.", utils.RequestID(ctx),
+			utils.ZapNamespace(fileKey.Namespace), utils.ZapGroup(fileKey.Group),
+			utils.ZapFileName(fileKey.Name), zap.Error(err))
+		result = &model.ConfigFileMergeResult{BaseVersion: baseVersion, Stale: true, Conflict: true}
+		if active != nil {
+			result.LatestVersion = active.Version
+		}
+	}
+	return result
+}
+
+// findReleaseHistoryByVersion 在发布历史中查找指定版本的发布内容
+func (s *Server) findReleaseHistoryByVersion(ctx context.Context, fileKey *model.ConfigFileKey,
+	version uint64) (*model.ConfigFileReleaseHistory, error) {
+	filter := map[string]string{
+		"namespace": fileKey.Namespace,
+		"group":     fileKey.Group,
+		"file_name": fileKey.Name,
+	}
+	const pageSize = 100
+	for offset := uint32(0); ; offset += pageSize {
+		total, histories, err := s.storage.QueryConfigFileReleaseHistories(filter, offset, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, history := range histories {
+			if history.Version != version || history.Group != fileKey.Group || history.FileName != fileKey.Name {
+				continue
+			}
+			return s.chains.AfterGetFileHistory(ctx, history)
+		}
+		if len(histories) == 0 || offset+pageSize >= total {
+			return nil, nil
+		}
+	}
+}
+
+// loadConfigFileSnapshot 按照来源加载参与对比的配置内容
+func (s *Server) loadConfigFileSnapshot(ctx context.Context, fileKey *model.ConfigFileKey,
+	source model.ConfigFileSource) (*model.ConfigFileSnapshot, apimodel.Code, error) {
+	snapshot := &model.ConfigFileSnapshot{ConfigFileSource: source}
+	var (
+		release *model.ConfigFileRelease
+		err     error
+	)
+	switch source.Type {
+	case model.ConfigFileSourceWorking:
+		file, err := s.storage.GetConfigFile(fileKey.Namespace, fileKey.Group, fileKey.Name)
+		if err != nil {
+			return nil, commonstore.StoreCode2APICode(err), err
+		}
+		if file == nil {
+			return nil, apimodel.Code_NotFoundResource, fmt.Errorf("config file not found")
+		}
+		if file, err = s.chains.AfterGetFile(ctx, file); err != nil {
+			return nil, apimodel.Code_ExecuteException, err
+		}
+		snapshot.Format = file.Format
+		snapshot.Content = file.Content
+		snapshot.Md5 = CalMd5(file.Content)
+		return snapshot, apimodel.Code_ExecuteSuccess, nil
+	case model.ConfigFileSourceHistory:
+		saved, err := s.findReleaseHistoryByID(fileKey, source.ID)
+		if err != nil {
+			return nil, commonstore.StoreCode2APICode(err), err
+		}
+		if saved == nil {
+			return nil, apimodel.Code_NotFoundResource,
+				fmt.Errorf("config file release history %d not found", source.ID)
+		}
+		history, err := s.chains.AfterGetFileHistory(ctx, saved)
+		if err != nil {
+			return nil, apimodel.Code_ExecuteException, err
+		}
+		snapshot.Version = history.Version
+		snapshot.Format = history.Format
+		snapshot.Content = history.Content
+		snapshot.Md5 = CalMd5(history.Content)
+		return snapshot, apimodel.Code_ExecuteSuccess, nil
+	case model.ConfigFileSourceActive:
+		release, err = s.storage.GetConfigFileActiveRelease(fileKey)
+	case model.ConfigFileSourceRelease:
+		release, err = s.storage.GetConfigFileRelease(&model.ConfigFileReleaseKey{
+			Namespace: fileKey.Namespace,
+			Group:     fileKey.Group,
+			FileName:  fileKey.Name,
+			Name:      source.Name,
+		})
+	case model.ConfigFileSourceGray:
+		release, err = s.getGrayRelease(fileKey)
+	default:
+		return nil, apimodel.Code_BadRequest, fmt.Errorf("unknown config file source type %q", source.Type)
+	}
+	if err != nil {
+		return nil, commonstore.StoreCode2APICode(err), err
+	}
+	if release == nil {
+		return nil, apimodel.Code_NotFoundResource, fmt.Errorf("config file %s release not found", source.Type)
+	}
+	if release, err = s.chains.AfterGetFileRelease(ctx, release); err != nil {
+		return nil, apimodel.Code_ExecuteException, err
+	}
+	snapshot.Name = release.Name
+	snapshot.Version = release.Version
+	snapshot.Format = release.Format
+	snapshot.Content = release.Content
+	snapshot.Md5 = CalMd5(release.Content)
+	return snapshot, apimodel.Code_ExecuteSuccess, nil
+}
+
+func (s *Server) getGrayRelease(fileKey *model.ConfigFileKey) (*model.ConfigFileRelease, error) {
+	tx, err := s.storage.StartReadTx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	return s.storage.GetConfigFileBetaReleaseTx(tx, fileKey)
+}
+
+// findReleaseHistoryByID 查询配置文件指定 ID 的发布历史
+func (s *Server) findReleaseHistoryByID(fileKey *model.ConfigFileKey,
+	id uint64) (*model.ConfigFileReleaseHistory, error) {
+	_, histories, err := s.storage.QueryConfigFileReleaseHistories(map[string]string{
+		"namespace": fileKey.Namespace,
+		"group":     fileKey.Group,
+		"file_name": fileKey.Name,
+		"endId":     strconv.FormatUint(id+1, 10),
+	}, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(histories) == 0 || histories[0].Id != id || histories[0].Group != fileKey.Group ||
+		histories[0].FileName != fileKey.Name {
+		return nil, nil
+	}
+	return histories[0], nil
+}
+
+func newConfigFileDiffResponse(code apimodel.Code, err error,
+	diff *model.ConfigFileDiff) *model.ConfigFileDiffResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.ConfigFileDiffResponse{Code: uint32(code), Info: info, Diff: diff}
+}
+
+func newConfigFileMergeResponse(code apimodel.Code, err error,
+	result *model.ConfigFileMergeResult) *model.ConfigFileMergeResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.ConfigFileMergeResponse{Code: uint32(code), Info: info, Result: result}
+}
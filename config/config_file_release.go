@@ -104,6 +104,14 @@ func (s *Server) handlePublishConfigFile(ctx context.Context, tx store.Tx,
 		return nil, api.NewConfigResponse(apimodel.Code_DataConflict)
 	}
 
+	if resp := s.checkApprovedRelease(ctx, tx, &model.ConfigFileKey{
+		Namespace: namespace,
+		Group:     group,
+		Name:      fileName,
+	}); resp != nil {
+		return nil, resp
+	}
+
 	// 获取待发布的 configFile 信息
 	toPublishFile, err := s.storage.GetConfigFileTx(tx, namespace, group, fileName)
 	if err != nil {
@@ -157,22 +165,16 @@ func (s *Server) handlePublishConfigFile(ctx context.Context, tx store.Tx,
 		}
 	}
 	if req.GetReleaseType().GetValue() == model.ReleaseTypeGray {
-		clientLabels := req.GetBetaLabels()
-		raw := make([]json.RawMessage, 0, len(clientLabels))
-		marshaler := jsonpb.Marshaler{}
-		for i := range clientLabels {
-			data, err := marshaler.MarshalToString(clientLabels[i])
-			if err != nil {
-				log.Error("[Config][Release] marshal gary rule error.",
-					utils.RequestID(ctx), utils.ZapNamespace(namespace), utils.ZapGroup(group),
-					utils.ZapFileName(fileName), zap.Error(err))
-				return fileRelease, api.NewConfigResponseWithInfo(apimodel.Code_InvalidMatchRule, err.Error())
-			}
-			raw = append(raw, json.RawMessage(data))
+		matchRule, err := marshalGrayRule(req.GetBetaLabels())
+		if err != nil {
+			log.Error("[Config][Release] marshal gary rule error.",
+				utils.RequestID(ctx), utils.ZapNamespace(namespace), utils.ZapGroup(group),
+				utils.ZapFileName(fileName), zap.Error(err))
+			return fileRelease, api.NewConfigResponseWithInfo(apimodel.Code_InvalidMatchRule, err.Error())
 		}
 		grayResource := &model.GrayResource{
 			Name:      model.GetGrayConfigRealseKey(fileRelease.SimpleConfigFileRelease),
-			MatchRule: string(utils.MustJson(raw)),
+			MatchRule: matchRule,
 			CreateBy:  utils.ParseUserName(ctx),
 			ModifyBy:  utils.ParseUserName(ctx),
 		}
@@ -188,6 +190,20 @@ func (s *Server) handlePublishConfigFile(ctx context.Context, tx store.Tx,
 	return fileRelease, api.NewConfigResponse(apimodel.Code_ExecuteSuccess)
 }
 
+// marshalGrayRule 将灰度规则序列化为灰度资源中保存的格式
+func marshalGrayRule(clientLabels []*apimodel.ClientLabel) (string, error) {
+	raw := make([]json.RawMessage, 0, len(clientLabels))
+	marshaler := jsonpb.Marshaler{}
+	for i := range clientLabels {
+		data, err := marshaler.MarshalToString(clientLabels[i])
+		if err != nil {
+			return "", err
+		}
+		raw = append(raw, json.RawMessage(data))
+	}
+	return string(utils.MustJson(raw)), nil
+}
+
 // GetConfigFileRelease 获取配置文件发布内容
 func (s *Server) GetConfigFileRelease(ctx context.Context, req *apiconfig.ConfigFileRelease) *apiconfig.ConfigResponse {
 	namespace := req.GetNamespace().GetValue()
@@ -584,8 +600,16 @@ func (s *Server) CasUpsertAndReleaseConfigFile(ctx context.Context,
 	return releaseResp
 }
 
+// UpsertAndReleaseConfigFile 创建/更新配置文件并发布
 func (s *Server) UpsertAndReleaseConfigFile(ctx context.Context,
 	req *apiconfig.ConfigFilePublishInfo) *apiconfig.ConfigResponse {
+	return s.UpsertAndReleaseConfigFileOnBase(ctx, &model.ConfigFilePublishRequest{ConfigFilePublishInfo: req})
+}
+
+// UpsertAndReleaseConfigFileOnBase 基于某个全量发布版本创建/更新配置文件并发布，BaseVersion 为 0 时不做版本检查
+func (s *Server) UpsertAndReleaseConfigFileOnBase(ctx context.Context,
+	publishReq *model.ConfigFilePublishRequest) *apiconfig.ConfigResponse {
+	req := publishReq.ConfigFilePublishInfo
 	upsertFileReq := &apiconfig.ConfigFile{
 		Name:        req.GetFileName(),
 		Namespace:   req.GetNamespace(),
@@ -612,6 +636,31 @@ func (s *Server) UpsertAndReleaseConfigFile(ctx context.Context,
 		_ = tx.Rollback()
 	}()
 
+	if baseVersion := publishReq.BaseVersion; baseVersion != 0 {
+		fileKey := &model.ConfigFileKey{
+			Namespace: req.GetNamespace().GetValue(),
+			Group:     req.GetGroup().GetValue(),
+			Name:      req.GetFileName().GetValue(),
+		}
+		// 锁住配置文件，保证版本检查到发布完成之间不会有其他发布
+		if _, err := s.storage.LockConfigFile(tx, fileKey); err != nil {
+			log.Error("[Config][File] lock config file when check base version.", utils.RequestID(ctx),
+				utils.ZapNamespace(fileKey.Namespace), utils.ZapGroup(fileKey.Group),
+				utils.ZapFileName(fileKey.Name), zap.Error(err))
+			return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
+		}
+		if result := s.checkBaseVersion(ctx, tx, fileKey, baseVersion, req.GetContent().GetValue()); result != nil {
+			info := fmt.Sprintf("base version %d is stale, latest version is %d", result.BaseVersion,
+				result.LatestVersion)
+			if result.Conflict {
+				info += fmt.Sprintf(", %d conflicts with latest release", len(result.Conflicts))
+			} else {
+				info += ", changes can be merged without conflict"
+			}
+			return api.NewConfigResponseWithInfo(apimodel.Code_DataConflict, info)
+		}
+	}
+
 	historyRecords := []func(){}
 	upsertResp := s.handleCreateConfigFile(ctx, tx, upsertFileReq)
 	if upsertResp.GetCode().GetValue() == uint32(apimodel.Code_ExistedResource) {
@@ -708,30 +757,31 @@ func (s *Server) StopGrayConfigFileRelease(ctx context.Context, req *apiconfig.C
 	if betaRelease == nil {
 		return api.NewConfigResponse(apimodel.Code_ExecuteSuccess)
 	}
+	if resp := s.cleanGrayConfigFileReleaseTx(ctx, tx, betaRelease); resp != nil {
+		return resp
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error("[Config][File] stop config file release when commit tx.", utils.RequestID(ctx), zap.Error(err))
+		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
+	}
+	s.recordReleaseHistory(ctx, betaRelease, utils.ReleaseTypeCancelGray, utils.ReleaseStatusSuccess, "")
+	return api.NewConfigResponse(apimodel.Code_ExecuteSuccess)
+}
+
+// cleanGrayConfigFileReleaseTx 清理灰度规则并且下线灰度版本，调用方需要先锁住配置文件
+func (s *Server) cleanGrayConfigFileReleaseTx(ctx context.Context, tx store.Tx,
+	betaRelease *model.ConfigFileRelease) *apiconfig.ConfigResponse {
 	if err := s.storage.CleanGrayResource(tx, &model.GrayResource{
-		Name: model.GetGrayConfigRealseKey(&model.SimpleConfigFileRelease{
-			ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
-				Namespace:   req.GetNamespace().GetValue(),
-				Group:       req.GetGroup().GetValue(),
-				Name:        req.GetFileName().GetValue(),
-				ReleaseType: model.ReleaseTypeGray,
-			},
-		}),
+		Name: model.GetGrayConfigRealseKey(betaRelease.SimpleConfigFileRelease),
 	}); err != nil {
 		log.Error("[Config][File] stop beta config file release when clean beta rule.", utils.RequestID(ctx), zap.Error(err))
 		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
 	}
-
-	if err = s.storage.InactiveConfigFileReleaseTx(tx, betaRelease); err != nil {
+	if err := s.storage.InactiveConfigFileReleaseTx(tx, betaRelease); err != nil {
 		log.Error("[Config][File] stop beta config file release.", utils.RequestID(ctx), zap.Error(err))
 		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
 	}
-	if err := tx.Commit(); err != nil {
-		log.Error("[Config][File] stop config file release when commit tx.", utils.RequestID(ctx), zap.Error(err))
-		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
-	}
-	s.recordReleaseHistory(ctx, betaRelease, utils.ReleaseTypeCancelGray, utils.ReleaseStatusSuccess, "")
-	return api.NewConfigResponse(apimodel.Code_ExecuteSuccess)
+	return nil
 }
 
 func (s *Server) cleanConfigFileReleases(ctx context.Context, tx store.Tx,
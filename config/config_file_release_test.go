@@ -18,6 +18,7 @@
 package config_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -804,3 +805,139 @@ func TestServer_CasUpsertAndReleaseConfigFile(t *testing.T) {
 		})
 	})
 }
+
+func TestServer_DiffAndMergeConfigFile(t *testing.T) {
+	testSuit := newConfigCenterTestSuit(t)
+
+	var (
+		mockNamespace = "mock_namespace_diff"
+		mockGroup     = "mock_group"
+		mockFileName  = "mock_filename.properties"
+		baseContent   = "a=1\nb=2\nc=3\n"
+	)
+
+	nsRsp := testSuit.NamespaceServer().CreateNamespace(testSuit.DefaultCtx, &apimodel.Namespace{
+		Name: utils.NewStringValue(mockNamespace),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), nsRsp.GetCode().GetValue(), nsRsp.GetInfo().GetValue())
+
+	publish := func(ctx context.Context, content string) *config_manage.ConfigResponse {
+		return testSuit.ConfigServer().UpsertAndReleaseConfigFile(ctx, &config_manage.ConfigFilePublishInfo{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+			Format:    utils.NewStringValue(utils.FileFormatProperties),
+			Content:   utils.NewStringValue(content),
+		})
+	}
+	activeVersion := func() uint64 {
+		rsp := testSuit.ConfigServer().GetConfigFileRelease(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+		return rsp.GetConfigFileRelease().GetVersion().GetValue()
+	}
+
+	pubRsp := publish(testSuit.DefaultCtx, baseContent)
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), pubRsp.GetCode().GetValue(), pubRsp.GetInfo().GetValue())
+	baseVersion := activeVersion()
+
+	t.Run("param_check", func(t *testing.T) {
+		diffRsp := testSuit.ConfigServer().DiffConfigFile(testSuit.DefaultCtx, &model.ConfigFileDiffRequest{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+		})
+		assert.Equal(t, uint32(apimodel.Code_BadRequest), diffRsp.Code)
+		assert.Equal(t, "invalid config file name", diffRsp.Info)
+
+		mergeRsp := testSuit.ConfigServer().MergeConfigFile(testSuit.DefaultCtx, &model.ConfigFileMergeRequest{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+		})
+		assert.Equal(t, uint32(apimodel.Code_BadRequest), mergeRsp.Code)
+	})
+
+	t.Run("diff_active_working", func(t *testing.T) {
+		updateRsp := testSuit.ConfigServer().UpdateConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFile{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			Name:      utils.NewStringValue(mockFileName),
+			Format:    utils.NewStringValue(utils.FileFormatProperties),
+			Content:   utils.NewStringValue("a=1\nb=20\nc=3\n"),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), updateRsp.GetCode().GetValue(), updateRsp.GetInfo().GetValue())
+
+		diffRsp := testSuit.ConfigServer().DiffConfigFile(testSuit.DefaultCtx, &model.ConfigFileDiffRequest{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+			From:      model.ConfigFileSource{Type: model.ConfigFileSourceActive},
+			To:        model.ConfigFileSource{Type: model.ConfigFileSourceWorking},
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), diffRsp.Code, diffRsp.Info)
+		assert.False(t, diffRsp.Diff.Identical)
+		assert.Equal(t, baseVersion, diffRsp.Diff.From.Version)
+		assert.Equal(t, []*model.KeyDiff{
+			{Key: "b", Op: model.KeyDiffModify, From: "2", To: "20"},
+		}, diffRsp.Diff.Keys)
+		assert.Len(t, diffRsp.Diff.Lines, 2)
+	})
+
+	// 其他人在 base 之后发布了新的版本
+	pubRsp = publish(testSuit.DefaultCtx, "a=10\nb=2\nc=3\n")
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), pubRsp.GetCode().GetValue(), pubRsp.GetInfo().GetValue())
+	latestVersion := activeVersion()
+	assert.NotEqual(t, baseVersion, latestVersion)
+
+	t.Run("merge_clean", func(t *testing.T) {
+		mergeRsp := testSuit.ConfigServer().MergeConfigFile(testSuit.DefaultCtx, &model.ConfigFileMergeRequest{
+			Namespace:   mockNamespace,
+			Group:       mockGroup,
+			FileName:    mockFileName,
+			BaseVersion: baseVersion,
+			Content:     "a=1\nb=2\nc=30\n",
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), mergeRsp.Code, mergeRsp.Info)
+		assert.True(t, mergeRsp.Result.Stale)
+		assert.False(t, mergeRsp.Result.Conflict)
+		assert.Equal(t, latestVersion, mergeRsp.Result.LatestVersion)
+		assert.Equal(t, "a=10\nb=2\nc=30\n", mergeRsp.Result.Merged)
+	})
+
+	t.Run("merge_conflict", func(t *testing.T) {
+		mergeRsp := testSuit.ConfigServer().MergeConfigFile(testSuit.DefaultCtx, &model.ConfigFileMergeRequest{
+			Namespace:   mockNamespace,
+			Group:       mockGroup,
+			FileName:    mockFileName,
+			BaseVersion: baseVersion,
+			Content:     "a=100\nb=2\nc=3\n",
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), mergeRsp.Code, mergeRsp.Info)
+		assert.True(t, mergeRsp.Result.Conflict)
+		assert.Len(t, mergeRsp.Result.Conflicts, 1)
+	})
+
+	t.Run("publish_with_stale_base", func(t *testing.T) {
+		publishOnBase := func(base uint64, content string) *config_manage.ConfigResponse {
+			return testSuit.ConfigServer().UpsertAndReleaseConfigFileOnBase(testSuit.DefaultCtx,
+				&model.ConfigFilePublishRequest{
+					ConfigFilePublishInfo: &config_manage.ConfigFilePublishInfo{
+						Namespace: utils.NewStringValue(mockNamespace),
+						Group:     utils.NewStringValue(mockGroup),
+						FileName:  utils.NewStringValue(mockFileName),
+						Format:    utils.NewStringValue(utils.FileFormatProperties),
+						Content:   utils.NewStringValue(content),
+					},
+					BaseVersion: base,
+				})
+		}
+		rsp := publishOnBase(baseVersion, "a=1\nb=2\nc=30\n")
+		assert.Equal(t, uint32(apimodel.Code_DataConflict), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+
+		rsp = publishOnBase(latestVersion, "a=10\nb=2\nc=30\n")
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+	})
+}
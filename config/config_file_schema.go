@@ -0,0 +1,193 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// UpsertConfigFileSchema 创建或者更新配置分组/配置文件的内容校验规则
+func (s *Server) UpsertConfigFileSchema(ctx context.Context,
+	req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	if _, err := CompileConfigFileSchema(req); err != nil {
+		return newConfigFileSchemaResponse(apimodel.Code(api.InvalidConfigFileSchema), err, nil)
+	}
+	group, err := s.storage.GetConfigFileGroup(req.Namespace, req.Group)
+	if err != nil {
+		log.Error("[Config][Schema] get config file group error.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), zap.Error(err))
+		return newConfigFileSchemaResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if group == nil {
+		return newConfigFileSchemaResponse(apimodel.Code_NotFoundResource,
+			errors.New("config file group not exist"), nil)
+	}
+
+	userName := utils.ParseUserName(ctx)
+	req.CreateBy = userName
+	req.ModifyBy = userName
+	if err := s.storage.UpsertConfigFileSchema(req); err != nil {
+		log.Error("[Config][Schema] upsert config file schema error.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), utils.ZapFileName(req.FileName),
+			zap.Error(err))
+		return newConfigFileSchemaResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	s.RecordHistory(ctx, configFileSchemaRecordEntry(ctx, req, model.OUpdate))
+
+	saved, err := s.storage.GetConfigFileSchema(req.Namespace, req.Group, req.FileName)
+	if err != nil {
+		return newConfigFileSchemaResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	return newConfigFileSchemaResponse(apimodel.Code_ExecuteSuccess, nil, saved)
+}
+
+// DeleteConfigFileSchema 删除配置分组/配置文件的内容校验规则
+func (s *Server) DeleteConfigFileSchema(ctx context.Context,
+	req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	saved, err := s.storage.GetConfigFileSchema(req.Namespace, req.Group, req.FileName)
+	if err != nil {
+		return newConfigFileSchemaResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if saved == nil {
+		return newConfigFileSchemaResponse(apimodel.Code_ExecuteSuccess, nil, nil)
+	}
+	if err := s.storage.DeleteConfigFileSchema(req.Namespace, req.Group, req.FileName); err != nil {
+		log.Error("[Config][Schema] delete config file schema error.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), utils.ZapFileName(req.FileName),
+			zap.Error(err))
+		return newConfigFileSchemaResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	s.RecordHistory(ctx, configFileSchemaRecordEntry(ctx, saved, model.ODelete))
+	return newConfigFileSchemaResponse(apimodel.Code_ExecuteSuccess, nil, saved)
+}
+
+// GetConfigFileSchemas 查询配置分组下的内容校验规则，指定 name 时只返回该配置文件上的规则
+func (s *Server) GetConfigFileSchemas(ctx context.Context,
+	filter map[string]string) *model.ConfigFileSchemaBatchResponse {
+	var (
+		namespace = filter["namespace"]
+		group     = filter["group"]
+		schemas   []*model.ConfigFileSchema
+		err       error
+	)
+	if fileName, ok := filter["name"]; ok {
+		var schema *model.ConfigFileSchema
+		if schema, err = s.storage.GetConfigFileSchema(namespace, group, fileName); schema != nil {
+			schemas = append(schemas, schema)
+		}
+	} else {
+		schemas, err = s.storage.QueryConfigFileSchemas(namespace, group)
+	}
+	if err != nil {
+		log.Error("[Config][Schema] query config file schemas error.", utils.RequestID(ctx),
+			utils.ZapNamespace(namespace), utils.ZapGroup(group), zap.Error(err))
+		code := commonstore.StoreCode2APICode(err)
+		return &model.ConfigFileSchemaBatchResponse{Code: uint32(code), Info: api.Code2Info(uint32(code))}
+	}
+	if schemas == nil {
+		schemas = []*model.ConfigFileSchema{}
+	}
+	return &model.ConfigFileSchemaBatchResponse{
+		Code:    api.ExecuteSuccess,
+		Info:    api.Code2Info(api.ExecuteSuccess),
+		Total:   uint32(len(schemas)),
+		Schemas: schemas,
+	}
+}
+
+// ValidateConfigFile 试运行配置内容校验，不会保存任何数据
+func (s *Server) ValidateConfigFile(ctx context.Context,
+	req *model.ConfigFileValidateRequest) *model.ConfigFileValidateResponse {
+	format := req.Format
+	if format == "" {
+		file, err := s.storage.GetConfigFile(req.Namespace, req.Group, req.FileName)
+		if err != nil {
+			return newConfigFileValidateResponse(commonstore.StoreCode2APICode(err), nil, nil)
+		}
+		if file == nil {
+			return newConfigFileValidateResponse(apimodel.Code_BadRequest,
+				errors.New("format is required when config file not exist"), nil)
+		}
+		format = file.Format
+	}
+
+	schema := req.Schema
+	if schema != nil {
+		schema.Namespace = req.Namespace
+		schema.Group = req.Group
+	} else {
+		var err error
+		schema, err = LoadConfigFileSchema(s.storage, req.Namespace, req.Group, req.FileName)
+		if err != nil {
+			return newConfigFileValidateResponse(commonstore.StoreCode2APICode(err), nil, nil)
+		}
+	}
+	violations, err := ValidateConfigFileContent(schema, format, req.Content)
+	if err != nil {
+		return newConfigFileValidateResponse(apimodel.Code(api.InvalidConfigFileSchema), err, nil)
+	}
+	return newConfigFileValidateResponse(apimodel.Code_ExecuteSuccess, nil, &model.ConfigFileValidateResult{
+		Valid:      len(violations) == 0,
+		Schema:     schema,
+		Violations: violations,
+	})
+}
+
+func newConfigFileSchemaResponse(code apimodel.Code, err error,
+	schema *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.ConfigFileSchemaResponse{Code: uint32(code), Info: info, Schema: schema}
+}
+
+func newConfigFileValidateResponse(code apimodel.Code, err error,
+	result *model.ConfigFileValidateResult) *model.ConfigFileValidateResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.ConfigFileValidateResponse{Code: uint32(code), Info: info, Result: result}
+}
+
+// configFileSchemaRecordEntry 生成配置校验规则的操作记录
+func configFileSchemaRecordEntry(ctx context.Context, schema *model.ConfigFileSchema,
+	operationType model.OperationType) *model.RecordEntry {
+	detail, _ := json.Marshal(schema)
+	return &model.RecordEntry{
+		ResourceType:  model.RConfigFileSchema,
+		ResourceName:  path.Join(schema.Group, schema.FileName),
+		Namespace:     schema.Namespace,
+		OperationType: operationType,
+		Operator:      utils.ParseOperator(ctx),
+		Detail:        string(detail),
+		HappenTime:    time.Now(),
+	}
+}
@@ -0,0 +1,171 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func TestServer_ConfigFileSchema(t *testing.T) {
+	testSuit := newConfigCenterTestSuit(t)
+
+	var (
+		mockNamespace = "mock_namespace_schema"
+		mockGroup     = "mock_group"
+		mockFileName  = "mock_filename.properties"
+		groupSchema   = `{"rules":[{"key":"server.port","type":"int","required":true,"min":1,"max":65535}]}`
+	)
+
+	nsRsp := testSuit.NamespaceServer().CreateNamespace(testSuit.DefaultCtx, &apimodel.Namespace{
+		Name: utils.NewStringValue(mockNamespace),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), nsRsp.GetCode().GetValue(), nsRsp.GetInfo().GetValue())
+
+	upsertSchema := func(fileName, content string) *model.ConfigFileSchemaResponse {
+		return testSuit.ConfigServer().UpsertConfigFileSchema(testSuit.DefaultCtx, &model.ConfigFileSchema{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  fileName,
+			Type:      model.ConfigFileSchemaTypeProperties,
+			Schema:    content,
+		})
+	}
+	newConfigFile := func(content string) *config_manage.ConfigFile {
+		return &config_manage.ConfigFile{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			Name:      utils.NewStringValue(mockFileName),
+			Format:    utils.NewStringValue(utils.FileFormatProperties),
+			Content:   utils.NewStringValue(content),
+		}
+	}
+
+	t.Run("group_not_exist", func(t *testing.T) {
+		rsp := upsertSchema("", groupSchema)
+		assert.Equal(t, uint32(apimodel.Code_NotFoundResource), rsp.Code, rsp.Info)
+	})
+
+	groupRsp := testSuit.ConfigServer().CreateConfigFileGroup(testSuit.DefaultCtx, &config_manage.ConfigFileGroup{
+		Namespace: utils.NewStringValue(mockNamespace),
+		Name:      utils.NewStringValue(mockGroup),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), groupRsp.GetCode().GetValue(), groupRsp.GetInfo().GetValue())
+
+	t.Run("invalid_schema", func(t *testing.T) {
+		rsp := upsertSchema("", `{"rules":[{"key":"a.*","required":true}]}`)
+		assert.Equal(t, api.InvalidConfigFileSchema, rsp.Code, rsp.Info)
+	})
+
+	t.Run("create_and_update", func(t *testing.T) {
+		rsp := upsertSchema("", groupSchema)
+		assert.Equal(t, api.ExecuteSuccess, rsp.Code, rsp.Info)
+		assert.NotZero(t, rsp.Schema.Id)
+
+		createRsp := testSuit.ConfigServer().CreateConfigFile(testSuit.DefaultCtx, newConfigFile("server.port=abc\n"))
+		assert.Equal(t, api.ConfigFileSchemaViolation, createRsp.GetCode().GetValue(), createRsp.GetInfo().GetValue())
+		assert.Contains(t, createRsp.GetInfo().GetValue(), "server.port")
+
+		createRsp = testSuit.ConfigServer().CreateConfigFile(testSuit.DefaultCtx, newConfigFile("server.port=8080\n"))
+		assert.Equal(t, api.ExecuteSuccess, createRsp.GetCode().GetValue(), createRsp.GetInfo().GetValue())
+
+		updateRsp := testSuit.ConfigServer().UpdateConfigFile(testSuit.DefaultCtx, newConfigFile("server.port=0\n"))
+		assert.Equal(t, api.ConfigFileSchemaViolation, updateRsp.GetCode().GetValue(), updateRsp.GetInfo().GetValue())
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		rsp := testSuit.ConfigServer().ValidateConfigFile(testSuit.DefaultCtx, &model.ConfigFileValidateRequest{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+			Content:   "server.port=99999\n",
+		})
+		assert.Equal(t, api.ExecuteSuccess, rsp.Code, rsp.Info)
+		assert.False(t, rsp.Result.Valid)
+		assert.Len(t, rsp.Result.Violations, 1)
+
+		// 试运行时可以携带新的规则，不会影响已经保存的规则
+		rsp = testSuit.ConfigServer().ValidateConfigFile(testSuit.DefaultCtx, &model.ConfigFileValidateRequest{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+			Content:   "server.port=99999\n",
+			Schema: &model.ConfigFileSchema{
+				Type:   model.ConfigFileSchemaTypeProperties,
+				Schema: `{"rules":[{"key":"server.port","type":"int"}]}`,
+			},
+		})
+		assert.Equal(t, api.ExecuteSuccess, rsp.Code, rsp.Info)
+		assert.True(t, rsp.Result.Valid)
+
+		queryRsp := testSuit.ConfigServer().GetConfigFileSchemas(testSuit.DefaultCtx, map[string]string{
+			"namespace": mockNamespace,
+			"group":     mockGroup,
+		})
+		assert.Equal(t, api.ExecuteSuccess, queryRsp.Code, queryRsp.Info)
+		assert.Equal(t, uint32(1), queryRsp.Total)
+		assert.Equal(t, groupSchema, queryRsp.Schemas[0].Schema)
+	})
+
+	t.Run("file_schema_override_and_publish", func(t *testing.T) {
+		// 配置文件级别的规则优先于分组级别的规则
+		rsp := upsertSchema(mockFileName, `{"rules":[{"key":"server.port","type":"int","max":1024}]}`)
+		assert.Equal(t, api.ExecuteSuccess, rsp.Code, rsp.Info)
+
+		queryRsp := testSuit.ConfigServer().GetConfigFileSchemas(testSuit.DefaultCtx, map[string]string{
+			"namespace": mockNamespace,
+			"group":     mockGroup,
+		})
+		assert.Equal(t, uint32(2), queryRsp.Total)
+
+		pubRsp := testSuit.ConfigServer().PublishConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, api.ConfigFileSchemaViolation, pubRsp.GetCode().GetValue(), pubRsp.GetInfo().GetValue())
+
+		upsertRsp := testSuit.ConfigServer().UpsertAndReleaseConfigFile(testSuit.DefaultCtx,
+			&config_manage.ConfigFilePublishInfo{
+				Namespace: utils.NewStringValue(mockNamespace),
+				Group:     utils.NewStringValue(mockGroup),
+				FileName:  utils.NewStringValue(mockFileName),
+				Format:    utils.NewStringValue(utils.FileFormatProperties),
+				Content:   utils.NewStringValue("server.port=80\n"),
+			})
+		assert.Equal(t, api.ExecuteSuccess, upsertRsp.GetCode().GetValue(), upsertRsp.GetInfo().GetValue())
+
+		delRsp := testSuit.ConfigServer().DeleteConfigFileSchema(testSuit.DefaultCtx, &model.ConfigFileSchema{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+		})
+		assert.Equal(t, api.ExecuteSuccess, delRsp.Code, delRsp.Info)
+
+		// 删除配置文件级别的规则后，分组级别的规则重新生效
+		updateRsp := testSuit.ConfigServer().UpdateConfigFile(testSuit.DefaultCtx, newConfigFile("server.port=\n"))
+		assert.Equal(t, api.ConfigFileSchemaViolation, updateRsp.GetCode().GetValue(), updateRsp.GetInfo().GetValue())
+	})
+}
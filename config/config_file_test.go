@@ -774,6 +774,8 @@ func Test_GetConfigFileRichInfo(t *testing.T) {
 			Name:      configFile.Name.Value,
 			CreateBy:  operator,
 		}, nil)
+		// 替换存储后，配置发布申请的调度任务不再执行
+		storage.EXPECT().IsLeader(gomock.Any()).AnyTimes().Return(false)
 
 		svr := testSuit.OriginConfigServer()
 		svr.TestMockStore(storage)
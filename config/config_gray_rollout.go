@@ -0,0 +1,461 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+// maxGrayRolloutUpdateRetry 多个节点并发记录异常客户端时的最大重试次数
+const maxGrayRolloutUpdateRetry = 3
+
+// errGrayRolloutReleaseLost 灰度版本已经被停止或者被其他灰度发布覆盖
+var errGrayRolloutReleaseLost = errors.New("gray release of the rollout has been stopped or replaced")
+
+// CreateConfigGrayRollout 按照第一个阶段的比例发布灰度版本，后续阶段由调度任务按时推进，最后一个阶段结束后全量发布
+func (s *Server) CreateConfigGrayRollout(ctx context.Context,
+	rollout *model.ConfigGrayRollout) *model.ConfigGrayRolloutResponse {
+	saved, err := s.storage.GetConfigGrayRollout(rollout.Namespace, rollout.Group, rollout.FileName)
+	if err != nil {
+		log.Error("[Config][GrayRollout] get config gray rollout error.", utils.RequestID(ctx),
+			utils.ZapNamespace(rollout.Namespace), utils.ZapGroup(rollout.Group),
+			utils.ZapFileName(rollout.FileName), zap.Error(err))
+		return newConfigGrayRolloutResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if saved != nil && saved.Status == model.GrayRolloutRunning {
+		return newConfigGrayRolloutResponse(apimodel.Code_DataConflict,
+			errors.New("config file is already in gray rollout"), nil)
+	}
+
+	resp := s.PublishConfigFile(ctx, &apiconfig.ConfigFileRelease{
+		Name:               utils.NewStringValue(rollout.ReleaseName),
+		Namespace:          utils.NewStringValue(rollout.Namespace),
+		Group:              utils.NewStringValue(rollout.Group),
+		FileName:           utils.NewStringValue(rollout.FileName),
+		Comment:            utils.NewStringValue(rollout.Comment),
+		ReleaseDescription: utils.NewStringValue(rollout.ReleaseDescription),
+		ReleaseType:        utils.NewStringValue(model.ReleaseTypeGray),
+		BetaLabels:         model.BuildPercentageGrayRule(rollout.Steps[0].Percentage),
+	})
+	if resp.GetCode().GetValue() != uint32(apimodel.Code_ExecuteSuccess) {
+		return &model.ConfigGrayRolloutResponse{Code: resp.GetCode().GetValue(), Info: resp.GetInfo().GetValue()}
+	}
+
+	userName := utils.ParseUserName(ctx)
+	rollout.ReleaseName = resp.GetConfigFileRelease().GetName().GetValue()
+	rollout.CurrentStep = 0
+	rollout.StepTime = time.Now()
+	rollout.ErrorClients = []string{}
+	rollout.Status = model.GrayRolloutRunning
+	rollout.Message = ""
+	rollout.Revision = 1
+	if saved != nil {
+		rollout.Revision = saved.Revision + 1
+	}
+	rollout.CreateBy = userName
+	rollout.ModifyBy = userName
+	if err := s.storage.SaveConfigGrayRollout(rollout); err != nil {
+		log.Error("[Config][GrayRollout] save config gray rollout error.", utils.RequestID(ctx),
+			utils.ZapNamespace(rollout.Namespace), utils.ZapGroup(rollout.Group),
+			utils.ZapFileName(rollout.FileName), zap.Error(err))
+		// 没有灰度记录时灰度版本不会再被推进，直接停止刚发布的灰度版本
+		_ = s.stopGrayRolloutRelease(ctx, rollout)
+		return newConfigGrayRolloutResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	s.RecordHistory(ctx, configGrayRolloutRecordEntry(ctx, rollout, model.OCreate))
+	return s.getConfigGrayRollout(ctx, rollout.Namespace, rollout.Group, rollout.FileName)
+}
+
+// GetConfigGrayRollout 查询配置文件最近一次的按比例灰度
+func (s *Server) GetConfigGrayRollout(ctx context.Context,
+	filter map[string]string) *model.ConfigGrayRolloutResponse {
+	return s.getConfigGrayRollout(ctx, filter["namespace"], filter["group"], filter["file_name"])
+}
+
+func (s *Server) getConfigGrayRollout(ctx context.Context,
+	namespace, group, fileName string) *model.ConfigGrayRolloutResponse {
+	rollout, err := s.storage.GetConfigGrayRollout(namespace, group, fileName)
+	if err != nil {
+		log.Error("[Config][GrayRollout] get config gray rollout error.", utils.RequestID(ctx),
+			utils.ZapNamespace(namespace), utils.ZapGroup(group), utils.ZapFileName(fileName), zap.Error(err))
+		return newConfigGrayRolloutResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if rollout == nil {
+		return newConfigGrayRolloutResponse(apimodel.Code_NotFoundResource,
+			errors.New("config gray rollout not exist"), nil)
+	}
+	return newConfigGrayRolloutResponse(apimodel.Code_ExecuteSuccess, nil, rollout)
+}
+
+// advanceGrayRollouts 推进所有进行中的按比例灰度，只在调度任务的 leader 节点执行
+func (s *Server) advanceGrayRollouts(now time.Time) {
+	rollouts, err := s.storage.GetConfigGrayRolloutsByStatus(model.GrayRolloutRunning)
+	if err != nil {
+		log.Error("[Config][GrayRollout] query running config gray rollouts error.", zap.Error(err))
+		return
+	}
+	for i := range rollouts {
+		s.advanceGrayRollout(rollouts[i], now)
+	}
+}
+
+func (s *Server) advanceGrayRollout(rollout *model.ConfigGrayRollout, now time.Time) {
+	ctx := context.WithValue(context.Background(), utils.StringContext("request-id"),
+		"gray-rollout-"+rollout.Key())
+	ctx = context.WithValue(ctx, utils.ContextOperator, rollout.CreateBy)
+	ctx = context.WithValue(ctx, utils.ContextUserNameKey, rollout.CreateBy)
+
+	if _, err := s.loadGrayRolloutRelease(rollout); err != nil {
+		if errors.Is(err, errGrayRolloutReleaseLost) {
+			s.finishGrayRollout(ctx, rollout, model.GrayRolloutStopped, err.Error())
+		}
+		return
+	}
+	if rollout.ReachErrorThreshold() {
+		s.abortGrayRollout(ctx, rollout)
+		return
+	}
+	step := rollout.Step()
+	if step == nil {
+		s.finishGrayRollout(ctx, rollout, model.GrayRolloutFailed,
+			fmt.Sprintf("invalid current step %d", rollout.CurrentStep))
+		return
+	}
+	if now.Before(rollout.StepTime.Add(time.Duration(step.Duration) * time.Second)) {
+		return
+	}
+
+	// 最后一个阶段结束，灰度版本全量发布
+	if rollout.CurrentStep == len(rollout.Steps)-1 {
+		if err := s.promoteGrayRollout(ctx, rollout); err != nil {
+			log.Error("[Config][GrayRollout] promote gray release fail.", utils.RequestID(ctx), zap.Error(err))
+			status := model.GrayRolloutFailed
+			if errors.Is(err, errGrayRolloutReleaseLost) {
+				status = model.GrayRolloutStopped
+			}
+			s.finishGrayRollout(ctx, rollout, status, err.Error())
+			return
+		}
+		s.finishGrayRollout(ctx, rollout, model.GrayRolloutCompleted, "")
+		return
+	}
+
+	next := rollout.CurrentStep + 1
+	if err := s.applyGrayRolloutStep(ctx, rollout, rollout.Steps[next]); err != nil {
+		log.Error("[Config][GrayRollout] apply gray rollout step fail.", utils.RequestID(ctx),
+			zap.Int("step", next), zap.Error(err))
+		if errors.Is(err, errGrayRolloutReleaseLost) {
+			s.finishGrayRollout(ctx, rollout, model.GrayRolloutStopped, err.Error())
+		}
+		return
+	}
+	rollout.CurrentStep = next
+	rollout.StepTime = now
+	if err := s.updateConfigGrayRollout(ctx, rollout); err != nil {
+		// 灰度规则的更新是幂等的，下一轮调度时重新推进
+		return
+	}
+	s.RecordHistory(ctx, configGrayRolloutRecordEntry(ctx, rollout, model.OUpdate))
+}
+
+// handleConfigClientErrors 记录客户端上报的灰度版本异常，异常客户端数量达到阈值时自动终止灰度
+func (s *Server) handleConfigClientErrors(ctx context.Context, any2 any) error {
+	event, ok := any2.(*eventhub.ConfigClientErrorEvent)
+	if !ok {
+		return nil
+	}
+	for i := range event.Errors {
+		s.recordConfigClientError(ctx, event.Errors[i])
+	}
+	return nil
+}
+
+func (s *Server) recordConfigClientError(ctx context.Context, clientErr *model.ConfigClientError) {
+	for i := 0; i < maxGrayRolloutUpdateRetry; i++ {
+		rollout, err := s.storage.GetConfigGrayRollout(clientErr.Namespace, clientErr.Group, clientErr.FileName)
+		if err != nil {
+			log.Error("[Config][GrayRollout] get config gray rollout error.", utils.ZapNamespace(clientErr.Namespace),
+				utils.ZapGroup(clientErr.Group), utils.ZapFileName(clientErr.FileName), zap.Error(err))
+			return
+		}
+		// 只关心正在灰度中的版本的异常
+		if rollout == nil || rollout.Status != model.GrayRolloutRunning ||
+			rollout.ReleaseName != clientErr.ReleaseName {
+			return
+		}
+		// 只有当前阶段命中灰度的客户端才会使用灰度版本，其他客户端上报的异常不可信
+		if !inGrayRollout(rollout, clientErr.ClientId) {
+			log.Warn("[Config][GrayRollout] ignore error reported by client not in gray rollout",
+				utils.ZapNamespace(clientErr.Namespace), utils.ZapGroup(clientErr.Group),
+				utils.ZapFileName(clientErr.FileName), zap.String("client", clientErr.ClientId))
+			return
+		}
+		if !rollout.AddErrorClient(clientErr.ClientId) {
+			return
+		}
+		if rollout.ReachErrorThreshold() {
+			err = s.abortGrayRollout(ctx, rollout)
+		} else {
+			err = s.updateConfigGrayRollout(ctx, rollout)
+		}
+		if store.Code(err) != store.DataConflictErr {
+			return
+		}
+	}
+}
+
+// inGrayRollout 客户端在当前阶段是否命中灰度，与灰度规则中按客户端分桶的计算方式保持一致
+func inGrayRollout(rollout *model.ConfigGrayRollout, clientId string) bool {
+	step := rollout.Step()
+	if step == nil || clientId == "" {
+		return false
+	}
+	grayKey := model.GetGrayConfigRealseKey(&model.SimpleConfigFileRelease{
+		ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
+			Namespace: rollout.Namespace,
+			Group:     rollout.Group,
+			FileName:  rollout.FileName,
+		},
+	})
+	return model.ClientGrayBucket(grayKey, clientId) < int(step.Percentage)
+}
+
+// abortGrayRollout 先将灰度置为终止状态，保证只有一个节点执行终止，然后停止灰度版本回退到当前的正式版本
+func (s *Server) abortGrayRollout(ctx context.Context, rollout *model.ConfigGrayRollout) error {
+	rollout.Status = model.GrayRolloutAborted
+	rollout.Message = fmt.Sprintf("%d clients reported errors, reach the threshold %d",
+		len(rollout.ErrorClients), rollout.ErrorThreshold)
+	if err := s.updateConfigGrayRollout(ctx, rollout); err != nil {
+		return err
+	}
+	s.RecordHistory(ctx, configGrayRolloutRecordEntry(ctx, rollout, model.OUpdate))
+
+	err := s.stopGrayRolloutRelease(ctx, rollout)
+	if err == nil || errors.Is(err, errGrayRolloutReleaseLost) {
+		return nil
+	}
+	log.Error("[Config][GrayRollout] stop gray release when abort fail.", utils.RequestID(ctx), zap.Error(err))
+	s.finishGrayRollout(ctx, rollout, model.GrayRolloutFailed, "abort gray release fail: "+err.Error())
+	return nil
+}
+
+func (s *Server) finishGrayRollout(ctx context.Context, rollout *model.ConfigGrayRollout, status, message string) {
+	rollout.Status = status
+	rollout.Message = message
+	if err := s.updateConfigGrayRollout(ctx, rollout); err != nil {
+		return
+	}
+	s.RecordHistory(ctx, configGrayRolloutRecordEntry(ctx, rollout, model.OUpdate))
+}
+
+// loadGrayRolloutRelease 读取灰度中的版本，确认仍然是按比例灰度发布的版本
+func (s *Server) loadGrayRolloutRelease(rollout *model.ConfigGrayRollout) (*model.ConfigFileRelease, error) {
+	tx, err := s.storage.StartTx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	return s.getGrayRolloutReleaseTx(tx, rollout)
+}
+
+func (s *Server) getGrayRolloutReleaseTx(tx store.Tx,
+	rollout *model.ConfigGrayRollout) (*model.ConfigFileRelease, error) {
+	betaRelease, err := s.storage.GetConfigFileBetaReleaseTx(tx, &model.ConfigFileKey{
+		Namespace: rollout.Namespace,
+		Group:     rollout.Group,
+		Name:      rollout.FileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if betaRelease == nil || betaRelease.Name != rollout.ReleaseName {
+		return nil, errGrayRolloutReleaseLost
+	}
+	return betaRelease, nil
+}
+
+// lockGrayRolloutReleaseTx 锁住配置文件后读取灰度中的版本，避免与人工停止灰度或者发布并发执行
+func (s *Server) lockGrayRolloutReleaseTx(tx store.Tx,
+	rollout *model.ConfigGrayRollout) (*model.ConfigFileRelease, error) {
+	if _, err := s.storage.LockConfigFile(tx, &model.ConfigFileKey{
+		Namespace: rollout.Namespace,
+		Group:     rollout.Group,
+		Name:      rollout.FileName,
+	}); err != nil {
+		return nil, err
+	}
+	return s.getGrayRolloutReleaseTx(tx, rollout)
+}
+
+// applyGrayRolloutStep 更新灰度规则中的比例，客户端在下一次长轮询时按照新的比例匹配灰度版本
+func (s *Server) applyGrayRolloutStep(ctx context.Context, rollout *model.ConfigGrayRollout,
+	step *model.ConfigGrayRolloutStep) error {
+	tx, err := s.storage.StartTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	betaRelease, err := s.lockGrayRolloutReleaseTx(tx, rollout)
+	if err != nil {
+		return err
+	}
+	matchRule, err := marshalGrayRule(model.BuildPercentageGrayRule(step.Percentage))
+	if err != nil {
+		return err
+	}
+	if err := s.storage.CreateGrayResourceTx(tx, &model.GrayResource{
+		Name:      model.GetGrayConfigRealseKey(betaRelease.SimpleConfigFileRelease),
+		MatchRule: matchRule,
+		CreateBy:  utils.ParseUserName(ctx),
+		ModifyBy:  utils.ParseUserName(ctx),
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// promoteGrayRollout 在同一个事务中停止灰度并且全量发布，只发布灰度时的内容
+func (s *Server) promoteGrayRollout(ctx context.Context, rollout *model.ConfigGrayRollout) error {
+	tx, err := s.storage.StartTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	betaRelease, err := s.lockGrayRolloutReleaseTx(tx, rollout)
+	if err != nil {
+		return err
+	}
+	file, err := s.storage.GetConfigFileTx(tx, rollout.Namespace, rollout.Group, rollout.FileName)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return fmt.Errorf("config file %s/%s/%s not exist", rollout.Namespace, rollout.Group, rollout.FileName)
+	}
+	if CalMd5(file.Content) != betaRelease.Md5 {
+		return errors.New("config file content changed after the gray release published")
+	}
+	if resp := s.cleanGrayConfigFileReleaseTx(ctx, tx, betaRelease); resp != nil {
+		return fmt.Errorf("stop gray release fail, code: %d, info: %s", resp.GetCode().GetValue(),
+			resp.GetInfo().GetValue())
+	}
+	data, resp := s.handlePublishConfigFile(ctx, tx, &apiconfig.ConfigFileRelease{
+		Namespace:          utils.NewStringValue(rollout.Namespace),
+		Group:              utils.NewStringValue(rollout.Group),
+		FileName:           utils.NewStringValue(rollout.FileName),
+		Comment:            utils.NewStringValue(rollout.Comment),
+		ReleaseDescription: utils.NewStringValue(rollout.ReleaseDescription),
+	})
+	if resp.GetCode().GetValue() != uint32(apimodel.Code_ExecuteSuccess) {
+		return fmt.Errorf("publish config file fail, code: %d, info: %s", resp.GetCode().GetValue(),
+			resp.GetInfo().GetValue())
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.recordReleaseHistory(ctx, betaRelease, utils.ReleaseTypeCancelGray, utils.ReleaseStatusSuccess, "")
+	s.recordReleaseSuccess(ctx, utils.ReleaseTypeNormal, data)
+	return nil
+}
+
+// stopGrayRolloutRelease 停止按比例灰度发布的版本，客户端回退到当前的正式版本
+func (s *Server) stopGrayRolloutRelease(ctx context.Context, rollout *model.ConfigGrayRollout) error {
+	tx, err := s.storage.StartTx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	betaRelease, err := s.lockGrayRolloutReleaseTx(tx, rollout)
+	if err != nil {
+		return err
+	}
+	if resp := s.cleanGrayConfigFileReleaseTx(ctx, tx, betaRelease); resp != nil {
+		return fmt.Errorf("stop gray release fail, code: %d, info: %s", resp.GetCode().GetValue(),
+			resp.GetInfo().GetValue())
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.recordReleaseHistory(ctx, betaRelease, utils.ReleaseTypeCancelGray, utils.ReleaseStatusSuccess, "")
+	return nil
+}
+
+// updateConfigGrayRollout 基于 revision 更新按比例灰度，并发修改时返回冲突
+func (s *Server) updateConfigGrayRollout(ctx context.Context, rollout *model.ConfigGrayRollout) error {
+	expect := rollout.Revision
+	rollout.Revision++
+	rollout.ModifyBy = utils.ParseUserName(ctx)
+	if err := s.storage.UpdateConfigGrayRollout(rollout, expect); err != nil {
+		log.Error("[Config][GrayRollout] update config gray rollout error.", utils.RequestID(ctx),
+			utils.ZapNamespace(rollout.Namespace), utils.ZapGroup(rollout.Group),
+			utils.ZapFileName(rollout.FileName), zap.String("status", rollout.Status), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func newConfigGrayRolloutResponse(code apimodel.Code, err error,
+	rollout *model.ConfigGrayRollout) *model.ConfigGrayRolloutResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.ConfigGrayRolloutResponse{Code: uint32(code), Info: info, Rollout: rollout}
+}
+
+// configGrayRolloutRecordEntry 生成按比例灰度的操作记录
+func configGrayRolloutRecordEntry(ctx context.Context, rollout *model.ConfigGrayRollout,
+	operationType model.OperationType) *model.RecordEntry {
+	detail, _ := json.Marshal(rollout)
+	return &model.RecordEntry{
+		ResourceType:  model.RConfigGrayRollout,
+		ResourceName:  path.Join(rollout.Group, rollout.FileName),
+		Namespace:     rollout.Namespace,
+		OperationType: operationType,
+		Operator:      utils.ParseOperator(ctx),
+		Detail:        string(detail),
+		HappenTime:    time.Now(),
+	}
+}
@@ -0,0 +1,247 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func TestServer_ConfigGrayRollout(t *testing.T) {
+	testSuit := newConfigCenterTestSuit(t)
+
+	var (
+		mockNamespace = "mock_namespace_gray_rollout"
+		mockGroup     = "mock_group"
+		mockFileName  = "mock_filename.properties"
+	)
+	grayKey := model.GetGrayConfigRealseKey(&model.SimpleConfigFileRelease{
+		ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+		},
+	})
+
+	nsRsp := testSuit.NamespaceServer().CreateNamespace(testSuit.DefaultCtx, &apimodel.Namespace{
+		Name: utils.NewStringValue(mockNamespace),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), nsRsp.GetCode().GetValue(), nsRsp.GetInfo().GetValue())
+
+	upsertFile := func(content string) {
+		rsp := testSuit.ConfigServer().UpsertAndReleaseConfigFile(testSuit.DefaultCtx,
+			&config_manage.ConfigFilePublishInfo{
+				Namespace: utils.NewStringValue(mockNamespace),
+				Group:     utils.NewStringValue(mockGroup),
+				FileName:  utils.NewStringValue(mockFileName),
+				Format:    utils.NewStringValue(utils.FileFormatProperties),
+				Content:   utils.NewStringValue(content),
+			})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+	}
+	updateFile := func(content string) {
+		rsp := testSuit.ConfigServer().UpdateConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFile{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			Name:      utils.NewStringValue(mockFileName),
+			Format:    utils.NewStringValue(utils.FileFormatProperties),
+			Content:   utils.NewStringValue(content),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+	}
+	createRollout := func() *model.ConfigGrayRollout {
+		// 阶段的持续时间足够长，避免后台的调度任务推进灰度
+		rsp := testSuit.ConfigServer().CreateConfigGrayRollout(testSuit.DefaultCtx, &model.ConfigGrayRollout{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+			Steps: []*model.ConfigGrayRolloutStep{
+				{Percentage: 10, Duration: 3600},
+				{Percentage: 50, Duration: 3600},
+				{Percentage: 100, Duration: 3600},
+			},
+			ErrorThreshold: 2,
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		return rsp.Rollout
+	}
+	getRollout := func() *model.ConfigGrayRollout {
+		rsp := testSuit.ConfigServer().GetConfigGrayRollout(testSuit.DefaultCtx, map[string]string{
+			"namespace": mockNamespace,
+			"group":     mockGroup,
+			"file_name": mockFileName,
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		return rsp.Rollout
+	}
+	// findClient 找到一个分桶落在 [min, max) 的客户端
+	findClient := func(min, max int) string {
+		for i := 0; ; i++ {
+			clientId := fmt.Sprintf("client-%d", i)
+			if bucket := model.ClientGrayBucket(grayKey, clientId); bucket >= min && bucket < max {
+				return clientId
+			}
+		}
+	}
+	hitGray := func(clientId string) bool {
+		_ = testSuit.CacheMgr().TestUpdate()
+		return testSuit.CacheMgr().Gray().HitGrayRule(grayKey, map[string]string{
+			model.ClientLabel_ID: clientId,
+		})
+	}
+	reportError := func(clientId, releaseName string) {
+		testSuit.OriginConfigServer().TestHandleConfigClientErrors(testSuit.DefaultCtx,
+			&eventhub.ConfigClientErrorEvent{
+				Errors: model.ParseConfigClientErrors(clientId, fmt.Sprintf("%s@%s@%s@%s",
+					mockNamespace, mockGroup, mockFileName, releaseName)),
+			})
+	}
+
+	upsertFile("server.port=8080")
+	updateFile("server.port=9090")
+
+	t.Run("invalid_steps", func(t *testing.T) {
+		for _, steps := range [][]*model.ConfigGrayRolloutStep{
+			{},
+			{{Percentage: 50, Duration: 60}},
+			{{Percentage: 50, Duration: 60}, {Percentage: 20, Duration: 60}, {Percentage: 100, Duration: 60}},
+			{{Percentage: 120, Duration: 60}},
+			{{Percentage: 100, Duration: -1}},
+		} {
+			rsp := testSuit.ConfigServer().CreateConfigGrayRollout(testSuit.DefaultCtx, &model.ConfigGrayRollout{
+				Namespace: mockNamespace,
+				Group:     mockGroup,
+				FileName:  mockFileName,
+				Steps:     steps,
+			})
+			assert.Equal(t, uint32(apimodel.Code_BadRequest), rsp.Code, rsp.Info)
+		}
+	})
+
+	t.Run("advance_and_abort", func(t *testing.T) {
+		rollout := createRollout()
+		assert.Equal(t, model.GrayRolloutRunning, rollout.Status)
+		assert.NotEmpty(t, rollout.ReleaseName)
+
+		// 同一个配置文件同时只能有一个按比例灰度
+		rsp := testSuit.ConfigServer().CreateConfigGrayRollout(testSuit.DefaultCtx, rollout)
+		assert.Equal(t, uint32(apimodel.Code_DataConflict), rsp.Code, rsp.Info)
+
+		lowClient, midClient := findClient(0, 10), findClient(10, 50)
+		assert.True(t, hitGray(lowClient))
+		assert.False(t, hitGray(midClient))
+
+		// 阶段未到期
+		testSuit.OriginConfigServer().TestAdvanceGrayRollouts(time.Now())
+		assert.Equal(t, 0, getRollout().CurrentStep)
+
+		testSuit.OriginConfigServer().TestAdvanceGrayRollouts(time.Now().Add(2 * time.Hour))
+		assert.Equal(t, 1, getRollout().CurrentStep)
+		assert.True(t, hitGray(lowClient))
+		assert.True(t, hitGray(midClient))
+
+		// 其他版本的异常、未命中灰度的客户端以及重复上报的客户端不计入
+		highClient := findClient(50, model.GrayBucketCount)
+		reportError(lowClient, "other-release")
+		reportError(highClient, rollout.ReleaseName)
+		reportError(lowClient, rollout.ReleaseName)
+		reportError(lowClient, rollout.ReleaseName)
+		saved := getRollout()
+		assert.Equal(t, model.GrayRolloutRunning, saved.Status)
+		assert.Equal(t, []string{lowClient}, saved.ErrorClients)
+
+		reportError(midClient, rollout.ReleaseName)
+		saved = getRollout()
+		assert.Equal(t, model.GrayRolloutAborted, saved.Status, saved.Message)
+		// 灰度版本已经下线，客户端回退到正式版本
+		_ = testSuit.CacheMgr().TestUpdate()
+		assert.Nil(t, testSuit.CacheMgr().ConfigFile().GetActiveGrayRelease(mockNamespace, mockGroup, mockFileName))
+
+		// 灰度已经终止，可以直接全量发布
+		releaseRsp := testSuit.ConfigServer().PublishConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), releaseRsp.GetCode().GetValue(),
+			releaseRsp.GetInfo().GetValue())
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		updateFile("server.port=9091")
+		createRollout()
+		stopRsp := testSuit.ConfigServer().StopGrayConfigFileReleases(testSuit.DefaultCtx,
+			[]*config_manage.ConfigFileRelease{{
+				Namespace: utils.NewStringValue(mockNamespace),
+				Group:     utils.NewStringValue(mockGroup),
+				FileName:  utils.NewStringValue(mockFileName),
+			}})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), stopRsp.GetCode().GetValue(),
+			stopRsp.GetInfo().GetValue())
+
+		testSuit.OriginConfigServer().TestAdvanceGrayRollouts(time.Now())
+		assert.Equal(t, model.GrayRolloutStopped, getRollout().Status)
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		updateFile("server.port=9092")
+		rollout := createRollout()
+
+		now := time.Now()
+		for i := 1; i <= len(rollout.Steps); i++ {
+			testSuit.OriginConfigServer().TestAdvanceGrayRollouts(now.Add(time.Duration(i) * 2 * time.Hour))
+		}
+		saved := getRollout()
+		assert.Equal(t, model.GrayRolloutCompleted, saved.Status, saved.Message)
+		assert.Equal(t, 2, saved.CurrentStep)
+
+		releaseRsp := testSuit.ConfigServer().GetConfigFileRelease(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), releaseRsp.GetCode().GetValue(),
+			releaseRsp.GetInfo().GetValue())
+		assert.Equal(t, "server.port=9092", releaseRsp.GetConfigFileRelease().GetContent().GetValue())
+		assert.NotEqual(t, rollout.ReleaseName, releaseRsp.GetConfigFileRelease().GetName().GetValue())
+	})
+
+	t.Run("content_changed", func(t *testing.T) {
+		updateFile("server.port=9093")
+		rollout := createRollout()
+		updateFile("server.port=9094")
+
+		now := time.Now()
+		for i := 1; i <= len(rollout.Steps); i++ {
+			testSuit.OriginConfigServer().TestAdvanceGrayRollouts(now.Add(time.Duration(i) * 2 * time.Hour))
+		}
+		// 灰度之后内容发生了变化，不会全量发布未经灰度的内容
+		saved := getRollout()
+		assert.Equal(t, model.GrayRolloutFailed, saved.Status)
+		assert.NotEmpty(t, saved.Message)
+	})
+}
@@ -0,0 +1,279 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// CreateConfigReleaseRequest 创建配置发布申请，申请会锁定当前配置文件的内容，审批通过并且到达计划发布时间后
+// 由调度任务执行发布
+func (s *Server) CreateConfigReleaseRequest(ctx context.Context,
+	req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse {
+	file, err := s.storage.GetConfigFile(req.Namespace, req.Group, req.FileName)
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] get config file error.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), utils.ZapFileName(req.FileName),
+			zap.Error(err))
+		return newConfigReleaseRequestResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if file == nil {
+		return newConfigReleaseRequestResponse(apimodel.Code_NotFoundResource,
+			errors.New("config file not exist"), nil)
+	}
+
+	userName := utils.ParseUserName(ctx)
+	req.Id = utils.NewUUID()
+	req.Md5 = CalMd5(file.Content)
+	req.Approvals = []*model.ConfigReleaseApproval{}
+	req.Status = model.ReleaseRequestPending
+	if req.RequiredApprovals == 0 {
+		req.Status = model.ReleaseRequestApproved
+	}
+	req.Message = ""
+	req.ReleaseTime = time.Time{}
+	req.Revision = 1
+	req.CreatorId = utils.ParseUserID(ctx)
+	req.CreateBy = userName
+	req.ModifyBy = userName
+	if err := s.storage.CreateConfigReleaseRequest(req); err != nil {
+		log.Error("[Config][ReleaseRequest] create config release request error.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), utils.ZapFileName(req.FileName),
+			zap.Error(err))
+		return newConfigReleaseRequestResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	s.RecordHistory(ctx, configReleaseRequestRecordEntry(ctx, req, model.OCreate))
+	return s.getConfigReleaseRequest(ctx, req.Id)
+}
+
+// ApproveConfigReleaseRequest 审批通过发布申请，审批通过人数满足要求后申请进入待发布状态
+func (s *Server) ApproveConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	return s.reviewConfigReleaseRequest(ctx, action, true)
+}
+
+// RejectConfigReleaseRequest 驳回发布申请，任意一个审批人驳回后申请结束
+func (s *Server) RejectConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	return s.reviewConfigReleaseRequest(ctx, action, false)
+}
+
+func (s *Server) reviewConfigReleaseRequest(ctx context.Context, action *model.ConfigReleaseRequestAction,
+	approved bool) *model.ConfigReleaseRequestResponse {
+	req, resp := s.loadConfigReleaseRequest(ctx, action.Id)
+	if resp != nil {
+		return resp
+	}
+	if req.Status != model.ReleaseRequestPending {
+		return newConfigReleaseRequestResponse(apimodel.Code(api.ConfigReleaseRequestStatusConflict),
+			fmt.Errorf("config release request is %s, only pending request can be reviewed", req.Status), nil)
+	}
+
+	user := s.caches.User().GetUserByID(utils.ParseUserID(ctx))
+	if user == nil {
+		return newConfigReleaseRequestResponse(apimodel.Code_NotAllowedAccess,
+			errors.New("only login user can review config release request"), nil)
+	}
+	if user.ID == req.CreatorId {
+		return newConfigReleaseRequestResponse(apimodel.Code_NotAllowedAccess,
+			errors.New("can not review config release request created by yourself"), nil)
+	}
+	if !s.isConfigReleaseApprover(user.ID, req.Approvers) {
+		return newConfigReleaseRequestResponse(apimodel.Code_NotAllowedAccess,
+			fmt.Errorf("user %s is not the approver of config release request", user.Name), nil)
+	}
+	for _, item := range req.Approvals {
+		if item.UserId == user.ID {
+			return newConfigReleaseRequestResponse(apimodel.Code(api.ConfigReleaseRequestStatusConflict),
+				fmt.Errorf("user %s already reviewed config release request", user.Name), nil)
+		}
+	}
+
+	req.Approvals = append(req.Approvals, &model.ConfigReleaseApproval{
+		UserId:   user.ID,
+		UserName: user.Name,
+		Approved: approved,
+		Comment:  action.Comment,
+		Time:     time.Now(),
+	})
+	operation := model.OApprove
+	if !approved {
+		operation = model.OReject
+		req.Status = model.ReleaseRequestRejected
+		req.Message = action.Comment
+	} else if req.ApprovedCount() >= req.RequiredApprovals {
+		req.Status = model.ReleaseRequestApproved
+	}
+	if resp := s.updateConfigReleaseRequest(ctx, req); resp != nil {
+		return resp
+	}
+	s.RecordHistory(ctx, configReleaseRequestRecordEntry(ctx, req, operation))
+	return s.getConfigReleaseRequest(ctx, req.Id)
+}
+
+// CancelConfigReleaseRequest 取消尚未发布的申请，只有申请人或者主账户可以取消
+func (s *Server) CancelConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	req, resp := s.loadConfigReleaseRequest(ctx, action.Id)
+	if resp != nil {
+		return resp
+	}
+	if req.Status != model.ReleaseRequestPending && req.Status != model.ReleaseRequestApproved {
+		return newConfigReleaseRequestResponse(apimodel.Code(api.ConfigReleaseRequestStatusConflict),
+			fmt.Errorf("config release request is %s, can not be cancelled", req.Status), nil)
+	}
+	if !utils.ParseIsOwner(ctx) && utils.ParseUserID(ctx) != req.CreatorId {
+		return newConfigReleaseRequestResponse(apimodel.Code_NotAllowedAccess,
+			errors.New("only the creator or owner can cancel config release request"), nil)
+	}
+
+	req.Status = model.ReleaseRequestCancelled
+	req.Message = action.Comment
+	if resp := s.updateConfigReleaseRequest(ctx, req); resp != nil {
+		return resp
+	}
+	s.RecordHistory(ctx, configReleaseRequestRecordEntry(ctx, req, model.OCancel))
+	return s.getConfigReleaseRequest(ctx, req.Id)
+}
+
+// GetConfigReleaseRequests 查询发布申请
+func (s *Server) GetConfigReleaseRequests(ctx context.Context,
+	filter map[string]string) *model.ConfigReleaseRequestBatchResponse {
+	offset, limit, err := utils.ParseOffsetAndLimit(filter)
+	if err != nil {
+		return &model.ConfigReleaseRequestBatchResponse{
+			Code: uint32(apimodel.Code_InvalidParameter),
+			Info: err.Error(),
+		}
+	}
+	if id := filter["id"]; id != "" {
+		resp := s.getConfigReleaseRequest(ctx, id)
+		ret := &model.ConfigReleaseRequestBatchResponse{Code: resp.Code, Info: resp.Info,
+			Requests: []*model.ConfigReleaseRequest{}}
+		if resp.Request != nil {
+			ret.Total = 1
+			ret.Requests = append(ret.Requests, resp.Request)
+		}
+		return ret
+	}
+
+	total, requests, err := s.storage.QueryConfigReleaseRequests(filter, offset, limit)
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] query config release requests error.", utils.RequestID(ctx),
+			zap.Error(err))
+		code := commonstore.StoreCode2APICode(err)
+		return &model.ConfigReleaseRequestBatchResponse{Code: uint32(code), Info: api.Code2Info(uint32(code))}
+	}
+	if requests == nil {
+		requests = []*model.ConfigReleaseRequest{}
+	}
+	return &model.ConfigReleaseRequestBatchResponse{
+		Code:     api.ExecuteSuccess,
+		Info:     api.Code2Info(api.ExecuteSuccess),
+		Total:    total,
+		Requests: requests,
+	}
+}
+
+// isConfigReleaseApprover 用户本身或者所在的用户组是否为发布申请的审批人
+func (s *Server) isConfigReleaseApprover(userID string, approvers model.ConfigReleaseApprovers) bool {
+	for _, item := range approvers.Users {
+		if item == userID {
+			return true
+		}
+	}
+	for _, groupID := range approvers.Groups {
+		if s.caches.User().IsUserInGroup(userID, groupID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) loadConfigReleaseRequest(ctx context.Context,
+	id string) (*model.ConfigReleaseRequest, *model.ConfigReleaseRequestResponse) {
+	req, err := s.storage.GetConfigReleaseRequest(id)
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] get config release request error.", utils.RequestID(ctx),
+			zap.String("id", id), zap.Error(err))
+		return nil, newConfigReleaseRequestResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	if req == nil {
+		return nil, newConfigReleaseRequestResponse(apimodel.Code_NotFoundResource,
+			errors.New("config release request not exist"), nil)
+	}
+	return req, nil
+}
+
+func (s *Server) getConfigReleaseRequest(ctx context.Context, id string) *model.ConfigReleaseRequestResponse {
+	req, resp := s.loadConfigReleaseRequest(ctx, id)
+	if resp != nil {
+		return resp
+	}
+	return newConfigReleaseRequestResponse(apimodel.Code_ExecuteSuccess, nil, req)
+}
+
+// updateConfigReleaseRequest 基于 revision 更新发布申请，并发修改时返回冲突
+func (s *Server) updateConfigReleaseRequest(ctx context.Context,
+	req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse {
+	expect := req.Revision
+	req.Revision++
+	req.ModifyBy = utils.ParseUserName(ctx)
+	if err := s.storage.UpdateConfigReleaseRequest(req, expect); err != nil {
+		log.Error("[Config][ReleaseRequest] update config release request error.", utils.RequestID(ctx),
+			zap.String("id", req.Id), zap.String("status", req.Status), zap.Error(err))
+		return newConfigReleaseRequestResponse(commonstore.StoreCode2APICode(err), nil, nil)
+	}
+	return nil
+}
+
+func newConfigReleaseRequestResponse(code apimodel.Code, err error,
+	req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse {
+	info := api.Code2Info(uint32(code))
+	if err != nil {
+		info = err.Error()
+	}
+	return &model.ConfigReleaseRequestResponse{Code: uint32(code), Info: info, Request: req}
+}
+
+// configReleaseRequestRecordEntry 生成发布申请的操作记录
+func configReleaseRequestRecordEntry(ctx context.Context, req *model.ConfigReleaseRequest,
+	operationType model.OperationType) *model.RecordEntry {
+	detail, _ := json.Marshal(req)
+	return &model.RecordEntry{
+		ResourceType:  model.RConfigReleaseRequest,
+		ResourceName:  path.Join(req.Group, req.FileName, req.Id),
+		Namespace:     req.Namespace,
+		OperationType: operationType,
+		Operator:      utils.ParseOperator(ctx),
+		Detail:        string(detail),
+		HappenTime:    time.Now(),
+	}
+}
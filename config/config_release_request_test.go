@@ -0,0 +1,303 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+	"github.com/stretchr/testify/assert"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func TestServer_ConfigReleaseRequest(t *testing.T) {
+	testSuit := newConfigCenterTestSuit(t)
+
+	var (
+		mockNamespace = "mock_namespace_release_request"
+		mockGroup     = "mock_group"
+		mockFileName  = "mock_filename.properties"
+	)
+
+	nsRsp := testSuit.NamespaceServer().CreateNamespace(testSuit.DefaultCtx, &apimodel.Namespace{
+		Name: utils.NewStringValue(mockNamespace),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), nsRsp.GetCode().GetValue(), nsRsp.GetInfo().GetValue())
+
+	fileRsp := testSuit.ConfigServer().CreateConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFile{
+		Namespace: utils.NewStringValue(mockNamespace),
+		Group:     utils.NewStringValue(mockGroup),
+		Name:      utils.NewStringValue(mockFileName),
+		Format:    utils.NewStringValue(utils.FileFormatProperties),
+		Content:   utils.NewStringValue("server.port=8080"),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), fileRsp.GetCode().GetValue(), fileRsp.GetInfo().GetValue())
+
+	// 创建审批人
+	userRsp := testSuit.UserServer().CreateUsers(testSuit.DefaultCtx, []*apisecurity.User{
+		{
+			Name:     utils.NewStringValue("release_approver"),
+			Password: utils.NewStringValue("polaris@123456"),
+			Source:   utils.NewStringValue("Polaris"),
+		},
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), userRsp.GetCode().GetValue(), userRsp.GetInfo().GetValue())
+	approverID := userRsp.GetResponses()[0].GetUser().GetId().GetValue()
+	_ = testSuit.CacheMgr().TestUpdate()
+
+	tokenRsp := testSuit.UserServer().GetUserToken(testSuit.DefaultCtx, &apisecurity.User{
+		Id: utils.NewStringValue(approverID),
+	})
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), tokenRsp.GetCode().GetValue(), tokenRsp.GetInfo().GetValue())
+	approverCtx := context.WithValue(context.Background(), utils.ContextAuthTokenKey,
+		tokenRsp.GetUser().GetAuthToken().GetValue())
+
+	createRequest := func(requiredApprovals int, scheduleTime time.Time) *model.ConfigReleaseRequest {
+		rsp := testSuit.ConfigServer().CreateConfigReleaseRequest(testSuit.DefaultCtx, &model.ConfigReleaseRequest{
+			Namespace:         mockNamespace,
+			Group:             mockGroup,
+			FileName:          mockFileName,
+			ReleaseName:       "release-" + utils.NewUUID(),
+			ScheduleTime:      scheduleTime,
+			RequiredApprovals: requiredApprovals,
+			Approvers: model.ConfigReleaseApprovers{
+				Users: []string{approverID},
+			},
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		return rsp.Request
+	}
+	getRequest := func(id string) *model.ConfigReleaseRequest {
+		rsp := testSuit.ConfigServer().GetConfigReleaseRequests(testSuit.DefaultCtx, map[string]string{
+			"id": id,
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		assert.Equal(t, 1, len(rsp.Requests))
+		return rsp.Requests[0]
+	}
+
+	t.Run("invalid_approvers", func(t *testing.T) {
+		rsp := testSuit.ConfigServer().CreateConfigReleaseRequest(testSuit.DefaultCtx, &model.ConfigReleaseRequest{
+			Namespace:         mockNamespace,
+			Group:             mockGroup,
+			FileName:          mockFileName,
+			RequiredApprovals: 2,
+			Approvers: model.ConfigReleaseApprovers{
+				Users: []string{approverID},
+			},
+		})
+		assert.Equal(t, uint32(apimodel.Code_BadRequest), rsp.Code, rsp.Info)
+
+		rsp = testSuit.ConfigServer().CreateConfigReleaseRequest(testSuit.DefaultCtx, &model.ConfigReleaseRequest{
+			Namespace:         mockNamespace,
+			Group:             mockGroup,
+			FileName:          "not_exist.properties",
+			RequiredApprovals: 0,
+		})
+		assert.Equal(t, uint32(apimodel.Code_NotFoundResource), rsp.Code, rsp.Info)
+	})
+
+	t.Run("approve_and_release", func(t *testing.T) {
+		req := createRequest(1, time.Time{})
+		assert.Equal(t, model.ReleaseRequestPending, req.Status)
+
+		// 未审批通过的申请不会被执行
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now())
+		assert.Equal(t, model.ReleaseRequestPending, getRequest(req.Id).Status)
+
+		// 申请人不能审批自己的申请
+		rsp := testSuit.ConfigServer().ApproveConfigReleaseRequest(testSuit.DefaultCtx,
+			&model.ConfigReleaseRequestAction{Id: req.Id})
+		assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), rsp.Code, rsp.Info)
+
+		rsp = testSuit.ConfigServer().ApproveConfigReleaseRequest(approverCtx,
+			&model.ConfigReleaseRequestAction{Id: req.Id, Comment: "lgtm"})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		assert.Equal(t, model.ReleaseRequestApproved, rsp.Request.Status)
+		assert.Equal(t, 1, rsp.Request.ApprovedCount())
+
+		// 重复审批
+		rsp = testSuit.ConfigServer().ApproveConfigReleaseRequest(approverCtx,
+			&model.ConfigReleaseRequestAction{Id: req.Id})
+		assert.Equal(t, api.ConfigReleaseRequestStatusConflict, rsp.Code, rsp.Info)
+
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now())
+		released := getRequest(req.Id)
+		assert.Equal(t, model.ReleaseRequestReleased, released.Status, released.Message)
+
+		releaseRsp := testSuit.ConfigServer().GetConfigFileRelease(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), releaseRsp.GetCode().GetValue(),
+			releaseRsp.GetInfo().GetValue())
+		assert.Equal(t, req.ReleaseName, releaseRsp.GetConfigFileRelease().GetName().GetValue())
+		assert.Equal(t, "server.port=8080", releaseRsp.GetConfigFileRelease().GetContent().GetValue())
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		req := createRequest(1, time.Time{})
+		rsp := testSuit.ConfigServer().RejectConfigReleaseRequest(approverCtx,
+			&model.ConfigReleaseRequestAction{Id: req.Id, Comment: "not now"})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		assert.Equal(t, model.ReleaseRequestRejected, rsp.Request.Status)
+		assert.Equal(t, "not now", rsp.Request.Message)
+
+		// 已经结束的申请不能再取消
+		rsp = testSuit.ConfigServer().CancelConfigReleaseRequest(testSuit.DefaultCtx,
+			&model.ConfigReleaseRequestAction{Id: req.Id})
+		assert.Equal(t, api.ConfigReleaseRequestStatusConflict, rsp.Code, rsp.Info)
+	})
+
+	t.Run("schedule_and_cancel", func(t *testing.T) {
+		req := createRequest(0, time.Now().Add(time.Hour))
+		assert.Equal(t, model.ReleaseRequestApproved, req.Status)
+
+		// 未到达计划发布时间
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now())
+		assert.Equal(t, model.ReleaseRequestApproved, getRequest(req.Id).Status)
+
+		rsp := testSuit.ConfigServer().CancelConfigReleaseRequest(testSuit.DefaultCtx,
+			&model.ConfigReleaseRequestAction{Id: req.Id})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		assert.Equal(t, model.ReleaseRequestCancelled, rsp.Request.Status)
+
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now().Add(2 * time.Hour))
+		assert.Equal(t, model.ReleaseRequestCancelled, getRequest(req.Id).Status)
+	})
+
+	t.Run("content_changed", func(t *testing.T) {
+		req := createRequest(0, time.Time{})
+		updateRsp := testSuit.ConfigServer().UpdateConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFile{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			Name:      utils.NewStringValue(mockFileName),
+			Format:    utils.NewStringValue(utils.FileFormatProperties),
+			Content:   utils.NewStringValue("server.port=9090"),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), updateRsp.GetCode().GetValue(),
+			updateRsp.GetInfo().GetValue())
+
+		// 审批后内容发生了变化，不会发布未经审批的内容
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now())
+		failed := getRequest(req.Id)
+		assert.Equal(t, model.ReleaseRequestFailed, failed.Status)
+		assert.NotEmpty(t, failed.Message)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		rsp := testSuit.ConfigServer().GetConfigReleaseRequests(testSuit.DefaultCtx, map[string]string{
+			"namespace": mockNamespace,
+			"status":    model.ReleaseRequestReleased,
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.Code, rsp.Info)
+		assert.Equal(t, uint32(1), rsp.Total)
+
+		rsp = testSuit.ConfigServer().GetConfigReleaseRequests(testSuit.DefaultCtx, map[string]string{
+			"status": "unknown",
+		})
+		assert.Equal(t, uint32(apimodel.Code_InvalidParameter), rsp.Code, rsp.Info)
+	})
+
+	// 模拟执行发布的节点在更新最终状态前宕机
+	markReleasing := func(req *model.ConfigReleaseRequest) {
+		req = getRequest(req.Id)
+		expect := req.Revision
+		req.Status = model.ReleaseRequestReleasing
+		req.Revision++
+		assert.NoError(t, testSuit.Storage.UpdateConfigReleaseRequest(req, expect))
+	}
+	activeRelease := func() *config_manage.ConfigFileRelease {
+		rsp := testSuit.ConfigServer().GetConfigFileRelease(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), rsp.GetCode().GetValue(), rsp.GetInfo().GetValue())
+		return rsp.GetConfigFileRelease()
+	}
+
+	t.Run("recover_releasing_not_published", func(t *testing.T) {
+		req := createRequest(0, time.Time{})
+		markReleasing(req)
+
+		// 未超时的 releasing 申请不做处理
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now())
+		assert.Equal(t, model.ReleaseRequestReleasing, getRequest(req.Id).Status)
+
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now().Add(10 * time.Minute))
+		recovered := getRequest(req.Id)
+		assert.Equal(t, model.ReleaseRequestReleased, recovered.Status, recovered.Message)
+		assert.Equal(t, req.ReleaseName, activeRelease().GetName().GetValue())
+	})
+
+	t.Run("recover_releasing_published", func(t *testing.T) {
+		req := createRequest(0, time.Time{})
+		markReleasing(req)
+		pubRsp := testSuit.ConfigServer().PublishConfigFile(testSuit.DefaultCtx, &config_manage.ConfigFileRelease{
+			Name:      utils.NewStringValue(req.ReleaseName),
+			Namespace: utils.NewStringValue(mockNamespace),
+			Group:     utils.NewStringValue(mockGroup),
+			FileName:  utils.NewStringValue(mockFileName),
+		})
+		assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), pubRsp.GetCode().GetValue(), pubRsp.GetInfo().GetValue())
+		version := activeRelease().GetVersion().GetValue()
+
+		// 发布已经完成，只补充更新申请状态，不会重复发布
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now().Add(10 * time.Minute))
+		recovered := getRequest(req.Id)
+		assert.Equal(t, model.ReleaseRequestReleased, recovered.Status, recovered.Message)
+		assert.Equal(t, version, activeRelease().GetVersion().GetValue())
+	})
+
+	t.Run("schema_violation", func(t *testing.T) {
+		req := createRequest(0, time.Time{})
+		// 申请创建之后新增的规则同样在执行发布时生效
+		schemaRsp := testSuit.ConfigServer().UpsertConfigFileSchema(testSuit.DefaultCtx, &model.ConfigFileSchema{
+			Namespace: mockNamespace,
+			Group:     mockGroup,
+			FileName:  mockFileName,
+			Type:      model.ConfigFileSchemaTypeProperties,
+			Schema:    `{"rules":[{"key":"server.port","type":"int","max":1024}]}`,
+		})
+		assert.Equal(t, api.ExecuteSuccess, schemaRsp.Code, schemaRsp.Info)
+		defer func() {
+			delRsp := testSuit.ConfigServer().DeleteConfigFileSchema(testSuit.DefaultCtx, &model.ConfigFileSchema{
+				Namespace: mockNamespace,
+				Group:     mockGroup,
+				FileName:  mockFileName,
+			})
+			assert.Equal(t, api.ExecuteSuccess, delRsp.Code, delRsp.Info)
+		}()
+		version := activeRelease().GetVersion().GetValue()
+
+		testSuit.OriginConfigServer().TestExecuteReleaseRequests(time.Now())
+		failed := getRequest(req.Id)
+		assert.Equal(t, model.ReleaseRequestFailed, failed.Status)
+		assert.Contains(t, failed.Message, "server.port")
+		assert.Equal(t, version, activeRelease().GetVersion().GetValue())
+	})
+}
@@ -0,0 +1,244 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	defaultReleaseScheduleInterval = 5 * time.Second
+	defaultReleasingTimeout        = 5 * time.Minute
+)
+
+// startReleaseScheduler 启动执行配置发布申请以及推进按比例灰度的调度任务，多个节点之间通过选主保证只有一个节点执行发布
+func (s *Server) startReleaseScheduler(ctx context.Context) error {
+	if err := s.storage.StartLeaderElection(store.ElectionKeyConfigReleaseScheduler); err != nil {
+		log.Errorf("[Config][ReleaseRequest] start leader election err: %v", err)
+		return err
+	}
+	interval := s.cfg.ReleaseRequest.ScheduleInterval
+	if interval <= 0 {
+		interval = defaultReleaseScheduleInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !s.storage.IsLeader(store.ElectionKeyConfigReleaseScheduler) {
+					continue
+				}
+				s.executeReleaseRequests(time.Now())
+				s.advanceGrayRollouts(time.Now())
+			}
+		}
+	}()
+	return nil
+}
+
+// executeReleaseRequests 执行所有审批通过并且到达计划发布时间的申请
+func (s *Server) executeReleaseRequests(now time.Time) {
+	_, requests, err := s.storage.QueryConfigReleaseRequests(map[string]string{
+		"status": model.ReleaseRequestApproved,
+	}, 0, math.MaxUint32)
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] query approved config release requests error.", zap.Error(err))
+		return
+	}
+	for i := range requests {
+		req := requests[i]
+		if !req.ScheduleTime.IsZero() && req.ScheduleTime.After(now) {
+			continue
+		}
+		s.executeReleaseRequest(req)
+	}
+	s.recoverReleasingRequests(now)
+}
+
+// recoverReleasingRequests 处理长时间停留在 releasing 状态的申请，执行发布的节点在更新最终状态前宕机或者
+// 失去 leader 身份时，申请会一直停留在 releasing 状态，既不会被调度也不能被取消
+func (s *Server) recoverReleasingRequests(now time.Time) {
+	timeout := s.cfg.ReleaseRequest.ReleasingTimeout
+	if timeout <= 0 {
+		timeout = defaultReleasingTimeout
+	}
+	_, requests, err := s.storage.QueryConfigReleaseRequests(map[string]string{
+		"status": model.ReleaseRequestReleasing,
+	}, 0, math.MaxUint32)
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] query releasing config release requests error.", zap.Error(err))
+		return
+	}
+	for i := range requests {
+		req := requests[i]
+		if req.ModifyTime.Add(timeout).After(now) {
+			continue
+		}
+		log.Warn("[Config][ReleaseRequest] config release request stays releasing too long, recover it.",
+			zap.String("id", req.Id), zap.Time("modify-time", req.ModifyTime))
+		s.recoverReleaseRequest(req)
+	}
+}
+
+// recoverReleaseRequest 审批过的内容已经是当前生效的发布时，说明发布已经完成，只需要补充更新申请的状态，
+// 否则重新执行发布
+func (s *Server) recoverReleaseRequest(req *model.ConfigReleaseRequest) {
+	active, err := s.storage.GetConfigFileActiveRelease(&model.ConfigFileKey{
+		Namespace: req.Namespace,
+		Group:     req.Group,
+		Name:      req.FileName,
+	})
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] get active release when recover config release request error.",
+			zap.String("id", req.Id), zap.Error(err))
+		return
+	}
+	if active == nil || active.Md5 != req.Md5 || (req.ReleaseName != "" && active.Name != req.ReleaseName) {
+		s.executeReleaseRequest(req)
+		return
+	}
+
+	ctx := releaseRequestContext(req)
+	req.Status = model.ReleaseRequestReleased
+	if req.ReleaseTime.IsZero() {
+		req.ReleaseTime = active.ModifyTime
+	}
+	if resp := s.updateConfigReleaseRequest(ctx, req); resp != nil {
+		return
+	}
+	s.RecordHistory(ctx, configReleaseRequestRecordEntry(ctx, req, model.OUpdate))
+}
+
+// releaseRequestContext 以申请人的身份执行发布
+func releaseRequestContext(req *model.ConfigReleaseRequest) context.Context {
+	ctx := context.WithValue(context.Background(), utils.StringContext("request-id"),
+		"release-request-"+req.Id)
+	ctx = context.WithValue(ctx, utils.ContextOperator, req.CreateBy)
+	return context.WithValue(ctx, utils.ContextUserNameKey, req.CreateBy)
+}
+
+// executeReleaseRequest 以申请人的身份执行发布，先将申请置为 releasing 状态，避免与取消操作并发执行
+func (s *Server) executeReleaseRequest(req *model.ConfigReleaseRequest) {
+	ctx := releaseRequestContext(req)
+
+	req.Status = model.ReleaseRequestReleasing
+	if resp := s.updateConfigReleaseRequest(ctx, req); resp != nil {
+		// 申请已经被其他操作修改，下一轮调度时重新判断
+		return
+	}
+
+	req.ReleaseTime = time.Now()
+	if err := s.publishReleaseRequest(ctx, req); err != nil {
+		log.Error("[Config][ReleaseRequest] execute config release request fail.", utils.RequestID(ctx),
+			utils.ZapNamespace(req.Namespace), utils.ZapGroup(req.Group), utils.ZapFileName(req.FileName),
+			zap.Error(err))
+		req.Status = model.ReleaseRequestFailed
+		req.Message = err.Error()
+	} else {
+		req.Status = model.ReleaseRequestReleased
+	}
+	if resp := s.updateConfigReleaseRequest(ctx, req); resp != nil {
+		return
+	}
+	s.RecordHistory(ctx, configReleaseRequestRecordEntry(ctx, req, model.OUpdate))
+}
+
+// approvedMd5Key 发布申请审批通过时的配置内容 md5，发布事务内据此校验待发布内容
+type approvedMd5Key struct{}
+
+func (s *Server) publishReleaseRequest(ctx context.Context, req *model.ConfigReleaseRequest) error {
+	// 内容校验放在发布事务中进行，避免校验与发布之间配置被修改
+	ctx = context.WithValue(ctx, approvedMd5Key{}, req.Md5)
+	resp := s.PublishConfigFile(ctx, &apiconfig.ConfigFileRelease{
+		Name:               utils.NewStringValue(req.ReleaseName),
+		Namespace:          utils.NewStringValue(req.Namespace),
+		Group:              utils.NewStringValue(req.Group),
+		FileName:           utils.NewStringValue(req.FileName),
+		Comment:            utils.NewStringValue(req.Comment),
+		ReleaseDescription: utils.NewStringValue(req.ReleaseDescription),
+	})
+	if resp.GetCode().GetValue() != uint32(apimodel.Code_ExecuteSuccess) {
+		return fmt.Errorf("publish config file fail, code: %d, info: %s", resp.GetCode().GetValue(),
+			resp.GetInfo().GetValue())
+	}
+	return nil
+}
+
+// checkApprovedRelease 发布申请由调度任务直接执行，不经过拦截器链，这里在发布事务中锁住配置文件，
+// 确认待发布内容就是审批过的内容，并且重新执行 schema 校验
+func (s *Server) checkApprovedRelease(ctx context.Context, tx store.Tx,
+	fileKey *model.ConfigFileKey) *apiconfig.ConfigResponse {
+	approvedMd5, ok := ctx.Value(approvedMd5Key{}).(string)
+	if !ok {
+		return nil
+	}
+	file, err := s.storage.LockConfigFile(tx, fileKey)
+	if err != nil {
+		log.Error("[Config][ReleaseRequest] lock config file fail.", utils.RequestID(ctx),
+			utils.ZapNamespace(fileKey.Namespace), utils.ZapGroup(fileKey.Group),
+			utils.ZapFileName(fileKey.Name), zap.Error(err))
+		return api.NewConfigResponse(commonstore.StoreCode2APICode(err))
+	}
+	if file == nil {
+		return api.NewConfigResponse(apimodel.Code_NotFoundResource)
+	}
+	// 只发布审批过的内容
+	if CalMd5(file.Content) != approvedMd5 {
+		return api.NewConfigResponseWithInfo(apimodel.Code_DataConflict,
+			"config file content changed after the release request created")
+	}
+	// 加密的配置在保存时已经校验过，这里不再解密校验
+	if file.IsEncrypted() {
+		return nil
+	}
+	schema, err := LoadConfigFileSchema(s.storage, fileKey.Namespace, fileKey.Group, fileKey.Name)
+	if err != nil {
+		return api.NewConfigResponseWithInfo(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	if schema == nil {
+		return nil
+	}
+	violations, err := ValidateConfigFileContent(schema, file.Format, file.Content)
+	if err != nil {
+		log.Warn("[Config][ReleaseRequest] compile config file schema fail", utils.ZapNamespace(fileKey.Namespace),
+			utils.ZapGroup(fileKey.Group), utils.ZapFileName(fileKey.Name), zap.Error(err))
+		return nil
+	}
+	if len(violations) > 0 {
+		return api.NewConfigResponseWithInfo(apimodel.Code(api.ConfigFileSchemaViolation),
+			FormatSchemaViolations(violations))
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v2"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// splitLines 按行拆分配置内容，末尾的换行符不会产生空行
+func splitLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+	content = strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	return strings.Split(content, "\n")
+}
+
+func lineOpCodes(a, b []string) []difflib.OpCode {
+	// 关闭 autoJunk，避免配置中大量重复的空行被当作噪声导致对比结果不准确
+	return difflib.NewMatcherWithJunk(a, b, false, nil).GetOpCodes()
+}
+
+// diffLines 计算两份内容行级别的差异，只返回新增以及删除的行
+func diffLines(from, to string) []*model.LineDiff {
+	a, b := splitLines(from), splitLines(to)
+	ret := make([]*model.LineDiff, 0, 8)
+	for _, op := range lineOpCodes(a, b) {
+		if op.Tag == 'e' {
+			continue
+		}
+		if op.Tag == 'r' || op.Tag == 'd' {
+			for i := op.I1; i < op.I2; i++ {
+				ret = append(ret, &model.LineDiff{Op: model.LineDiffDelete, FromLine: i + 1, Content: a[i]})
+			}
+		}
+		if op.Tag == 'r' || op.Tag == 'i' {
+			for j := op.J1; j < op.J2; j++ {
+				ret = append(ret, &model.LineDiff{Op: model.LineDiffAdd, ToLine: j + 1, Content: b[j]})
+			}
+		}
+	}
+	return ret
+}
+
+// diffKeys 按照配置格式解析内容后计算配置项级别的差异，不支持的格式返回 nil
+func diffKeys(format, from, to string) ([]*model.KeyDiff, error) {
+	if !supportKeyDiff(format) {
+		return nil, nil
+	}
+	fromKeys, err := flattenContent(format, from)
+	if err != nil {
+		return nil, fmt.Errorf("parse from content: %w", err)
+	}
+	toKeys, err := flattenContent(format, to)
+	if err != nil {
+		return nil, fmt.Errorf("parse to content: %w", err)
+	}
+
+	keys := make([]string, 0, len(fromKeys)+len(toKeys))
+	for k := range fromKeys {
+		keys = append(keys, k)
+	}
+	for k := range toKeys {
+		if _, ok := fromKeys[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	ret := make([]*model.KeyDiff, 0, 8)
+	for _, k := range keys {
+		fromVal, inFrom := fromKeys[k]
+		toVal, inTo := toKeys[k]
+		switch {
+		case inFrom && !inTo:
+			ret = append(ret, &model.KeyDiff{Key: k, Op: model.KeyDiffDelete, From: fromVal})
+		case !inFrom && inTo:
+			ret = append(ret, &model.KeyDiff{Key: k, Op: model.KeyDiffAdd, To: toVal})
+		case fromVal != toVal:
+			ret = append(ret, &model.KeyDiff{Key: k, Op: model.KeyDiffModify, From: fromVal, To: toVal})
+		}
+	}
+	return ret, nil
+}
+
+func supportKeyDiff(format string) bool {
+	switch format {
+	case utils.FileFormatYaml, utils.FileFormatJson, utils.FileFormatProperties:
+		return true
+	default:
+		return false
+	}
+}
+
+// flattenContent 把 yaml/json/properties 内容展开为 key -> value，嵌套的 key 使用 . 连接，数组使用 [index]
+func flattenContent(format, content string) (map[string]string, error) {
+	ret := map[string]string{}
+	if strings.TrimSpace(content) == "" {
+		return ret, nil
+	}
+	switch format {
+	case utils.FileFormatProperties:
+		return parseProperties(content)
+	case utils.FileFormatJson:
+		var val interface{}
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&val); err != nil {
+			return nil, err
+		}
+		flattenValue("", val, ret)
+	case utils.FileFormatYaml:
+		var val interface{}
+		if err := yaml.Unmarshal([]byte(content), &val); err != nil {
+			return nil, err
+		}
+		flattenValue("", val, ret)
+	default:
+		return nil, fmt.Errorf("format %s not support", format)
+	}
+	return ret, nil
+}
+
+func flattenValue(prefix string, val interface{}, ret map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			ret[prefix] = "{}"
+		}
+		for k, item := range v {
+			flattenValue(join(k), item, ret)
+		}
+	case map[interface{}]interface{}:
+		if len(v) == 0 {
+			ret[prefix] = "{}"
+		}
+		for k, item := range v {
+			flattenValue(join(fmt.Sprint(k)), item, ret)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			ret[prefix] = "[]"
+		}
+		for i, item := range v {
+			flattenValue(prefix+"["+strconv.Itoa(i)+"]", item, ret)
+		}
+	case nil:
+		ret[prefix] = "null"
+	default:
+		ret[prefix] = fmt.Sprint(v)
+	}
+}
+
+// parseProperties 解析 java properties 格式的内容，支持 = 以及 : 分隔符、注释和行尾 \ 续行
+func parseProperties(content string) (map[string]string, error) {
+	ret := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	var logical bytes.Buffer
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+		if continued := strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\"); continued {
+			logical.WriteString(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		logical.WriteString(line)
+		key, value := splitProperty(logical.String())
+		ret[key] = value
+		logical.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if logical.Len() > 0 {
+		key, value := splitProperty(logical.String())
+		ret[key] = value
+	}
+	return ret, nil
+}
+
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		case ' ', '\t':
+			rest := strings.TrimLeft(line[i:], " \t")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = rest[1:]
+			}
+			return line[:i], strings.TrimSpace(rest)
+		}
+	}
+	return line, ""
+}
+
+// mergeHunk 相对 base 的一处修改，[start, end) 为 base 中被替换的行
+type mergeHunk struct {
+	start, end int
+	lines      []string
+	theirs     bool
+}
+
+func changeHunks(base, other []string, theirs bool) []*mergeHunk {
+	ret := make([]*mergeHunk, 0, 4)
+	for _, op := range lineOpCodes(base, other) {
+		if op.Tag == 'e' {
+			continue
+		}
+		ret = append(ret, &mergeHunk{start: op.I1, end: op.I2, lines: other[op.J1:op.J2], theirs: theirs})
+	}
+	return ret
+}
+
+// applyHunks 使用一方的修改重建 base[start:end) 区间的内容
+func applyHunks(base []string, start, end int, hunks []*mergeHunk) []string {
+	ret := make([]string, 0, end-start)
+	pos := start
+	for _, h := range hunks {
+		ret = append(ret, base[pos:h.start]...)
+		ret = append(ret, h.lines...)
+		pos = h.end
+	}
+	return append(ret, base[pos:end]...)
+}
+
+// threeWayMerge 以 base 为共同祖先合并 ours 以及 theirs 的修改，两边修改了相同或相邻的行时视为冲突
+func threeWayMerge(base, ours, theirs string) (string, []*model.MergeConflict) {
+	baseLines := splitLines(base)
+	hunks := append(changeHunks(baseLines, splitLines(ours), false),
+		changeHunks(baseLines, splitLines(theirs), true)...)
+	sort.SliceStable(hunks, func(i, j int) bool {
+		return hunks[i].start < hunks[j].start
+	})
+
+	merged := make([]string, 0, len(baseLines))
+	conflicts := make([]*model.MergeConflict, 0, 2)
+	pos := 0
+	for i := 0; i < len(hunks); {
+		start, end := hunks[i].start, hunks[i].end
+		group := []*mergeHunk{hunks[i]}
+		for i++; i < len(hunks) && hunks[i].start <= end; i++ {
+			group = append(group, hunks[i])
+			if hunks[i].end > end {
+				end = hunks[i].end
+			}
+		}
+
+		var oursHunks, theirsHunks []*mergeHunk
+		for _, h := range group {
+			if h.theirs {
+				theirsHunks = append(theirsHunks, h)
+			} else {
+				oursHunks = append(oursHunks, h)
+			}
+		}
+		merged = append(merged, baseLines[pos:start]...)
+		pos = end
+		oursRegion := applyHunks(baseLines, start, end, oursHunks)
+		if len(theirsHunks) == 0 {
+			merged = append(merged, oursRegion...)
+			continue
+		}
+		theirsRegion := applyHunks(baseLines, start, end, theirsHunks)
+		if len(oursHunks) == 0 || strings.Join(oursRegion, "\n") == strings.Join(theirsRegion, "\n") {
+			merged = append(merged, theirsRegion...)
+			continue
+		}
+		conflicts = append(conflicts, &model.MergeConflict{
+			BaseStart: start + 1,
+			BaseEnd:   end,
+			Base:      baseLines[start:end],
+			Ours:      oursRegion,
+			Theirs:    theirsRegion,
+		})
+	}
+	if len(conflicts) > 0 {
+		return "", conflicts
+	}
+	merged = append(merged, baseLines[pos:]...)
+	content := strings.Join(merged, "\n")
+	if len(merged) > 0 && strings.HasSuffix(ours, "\n") {
+		content += "\n"
+	}
+	return content, nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func Test_diffLines(t *testing.T) {
+	ret := diffLines("a\nb\nc\n", "a\nB\nc\nd\n")
+	assert.Equal(t, []*model.LineDiff{
+		{Op: model.LineDiffDelete, FromLine: 2, Content: "b"},
+		{Op: model.LineDiffAdd, ToLine: 2, Content: "B"},
+		{Op: model.LineDiffAdd, ToLine: 4, Content: "d"},
+	}, ret)
+	assert.Empty(t, diffLines("a\nb", "a\nb\n"))
+}
+
+func Test_diffKeys(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		ret, err := diffKeys(utils.FileFormatYaml,
+			"server:\n  port: 8080\n  hosts: [a, b]\nname: demo\n",
+			"server:\n  port: 8081\n  hosts: [a]\nlabel: x\n")
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.KeyDiff{
+			{Key: "label", Op: model.KeyDiffAdd, To: "x"},
+			{Key: "name", Op: model.KeyDiffDelete, From: "demo"},
+			{Key: "server.hosts[1]", Op: model.KeyDiffDelete, From: "b"},
+			{Key: "server.port", Op: model.KeyDiffModify, From: "8080", To: "8081"},
+		}, ret)
+	})
+	t.Run("json", func(t *testing.T) {
+		ret, err := diffKeys(utils.FileFormatJson, `{"a":{"b":1,"c":true}}`, `{"a":{"b":1.5,"c":true}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.KeyDiff{
+			{Key: "a.b", Op: model.KeyDiffModify, From: "1", To: "1.5"},
+		}, ret)
+	})
+	t.Run("properties", func(t *testing.T) {
+		ret, err := diffKeys(utils.FileFormatProperties,
+			"# comment\nkey1=value1\nkey2 : a \\\n  b\n",
+			"key1 = value2\nkey2=a b\n")
+		assert.NoError(t, err)
+		assert.Equal(t, []*model.KeyDiff{
+			{Key: "key1", Op: model.KeyDiffModify, From: "value1", To: "value2"},
+		}, ret)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		ret, err := diffKeys(utils.FileFormatText, "a", "b")
+		assert.NoError(t, err)
+		assert.Nil(t, ret)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := diffKeys(utils.FileFormatJson, "{", "{}")
+		assert.Error(t, err)
+	})
+}
+
+func Test_threeWayMerge(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	t.Run("clean", func(t *testing.T) {
+		merged, conflicts := threeWayMerge(base, "a\nB\nc\nd\ne\n", "a\nb\nc\nd\nE\nf\n")
+		assert.Empty(t, conflicts)
+		assert.Equal(t, "a\nB\nc\nd\nE\nf\n", merged)
+	})
+	t.Run("same change", func(t *testing.T) {
+		merged, conflicts := threeWayMerge(base, "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n")
+		assert.Empty(t, conflicts)
+		assert.Equal(t, "a\nB\nc\nd\ne\n", merged)
+	})
+	t.Run("conflict", func(t *testing.T) {
+		merged, conflicts := threeWayMerge(base, "a\nX\nc\nd\ne\n", "a\nY\nc\nd\ne\n")
+		assert.Empty(t, merged)
+		assert.Equal(t, []*model.MergeConflict{{
+			BaseStart: 2,
+			BaseEnd:   2,
+			Base:      []string{"b"},
+			Ours:      []string{"X"},
+			Theirs:    []string{"Y"},
+		}}, conflicts)
+	})
+}
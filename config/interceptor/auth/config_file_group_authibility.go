@@ -51,6 +51,7 @@ func (s *ServerAuthability) QueryConfigFileGroups(ctx context.Context,
 	filter map[string]string) *apiconfig.ConfigBatchQueryResponse {
 
 	authCtx := s.collectConfigGroupAuthContext(ctx, nil, model.Read, "QueryConfigFileGroups")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_ConfigGroups, filter, "namespace")
 
 	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
 		return api.NewConfigBatchQueryResponse(model.ConvertToErrCode(err))
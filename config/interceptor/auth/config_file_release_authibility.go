@@ -21,6 +21,7 @@ import (
 	"context"
 
 	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
@@ -92,6 +93,7 @@ func (s *ServerAuthability) GetConfigFileReleaseVersions(ctx context.Context,
 	filters map[string]string) *apiconfig.ConfigBatchQueryResponse {
 
 	authCtx := s.collectConfigFileReleaseAuthContext(ctx, nil, model.Read, "GetConfigFileReleaseVersions")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_ConfigGroups, filters, "namespace")
 
 	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
 		return api.NewConfigBatchQueryResponseWithInfo(model.ConvertToErrCode(err), err.Error())
@@ -106,6 +108,7 @@ func (s *ServerAuthability) GetConfigFileReleases(ctx context.Context,
 	filters map[string]string) *apiconfig.ConfigBatchQueryResponse {
 
 	authCtx := s.collectConfigFileReleaseAuthContext(ctx, nil, model.Read, "GetConfigFileReleases")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_ConfigGroups, filters, "namespace")
 
 	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
 		return api.NewConfigBatchQueryResponseWithInfo(model.ConvertToErrCode(err), err.Error())
@@ -159,6 +162,21 @@ func (s *ServerAuthability) UpsertAndReleaseConfigFile(ctx context.Context,
 	return s.nextServer.UpsertAndReleaseConfigFile(ctx, req)
 }
 
+// UpsertAndReleaseConfigFileOnBase .
+func (s *ServerAuthability) UpsertAndReleaseConfigFileOnBase(ctx context.Context,
+	req *model.ConfigFilePublishRequest) *apiconfig.ConfigResponse {
+	authCtx := s.collectConfigFilePublishAuthContext(ctx, []*apiconfig.ConfigFilePublishInfo{req.ConfigFilePublishInfo},
+		model.Modify, "UpsertAndReleaseConfigFileOnBase")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return api.NewConfigFileResponse(model.ConvertToErrCode(err), nil)
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return s.nextServer.UpsertAndReleaseConfigFileOnBase(ctx, req)
+}
+
 func (s *ServerAuthability) StopGrayConfigFileReleases(ctx context.Context,
 	reqs []*apiconfig.ConfigFileRelease) *apiconfig.ConfigBatchWriteResponse {
 
@@ -173,3 +191,41 @@ func (s *ServerAuthability) StopGrayConfigFileReleases(ctx context.Context,
 
 	return s.nextServer.StopGrayConfigFileReleases(ctx, reqs)
 }
+
+// DiffConfigFile 对比配置文件的两个版本
+func (s *ServerAuthability) DiffConfigFile(ctx context.Context,
+	req *model.ConfigFileDiffRequest) *model.ConfigFileDiffResponse {
+
+	authCtx := s.collectConfigFileAuthContext(ctx, []*apiconfig.ConfigFile{{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Group:     utils.NewStringValue(req.Group),
+		Name:      utils.NewStringValue(req.FileName),
+	}}, model.Read, "DiffConfigFile")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigFileDiffResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return s.nextServer.DiffConfigFile(ctx, req)
+}
+
+// MergeConfigFile 检查基于旧版本修改的内容能否与最新发布合并
+func (s *ServerAuthability) MergeConfigFile(ctx context.Context,
+	req *model.ConfigFileMergeRequest) *model.ConfigFileMergeResponse {
+
+	authCtx := s.collectConfigFileAuthContext(ctx, []*apiconfig.ConfigFile{{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Group:     utils.NewStringValue(req.Group),
+		Name:      utils.NewStringValue(req.FileName),
+	}}, model.Read, "MergeConfigFile")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigFileMergeResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return s.nextServer.MergeConfigFile(ctx, req)
+}
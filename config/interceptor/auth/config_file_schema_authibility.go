@@ -0,0 +1,91 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config_auth
+
+import (
+	"context"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// UpsertConfigFileSchema create or update config file schema
+func (s *ServerAuthability) UpsertConfigFileSchema(ctx context.Context,
+	req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, []*apiconfig.ConfigFileGroup{{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Name:      utils.NewStringValue(req.Group),
+	}}, model.Modify, "UpsertConfigFileSchema")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigFileSchemaResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.UpsertConfigFileSchema(ctx, req)
+}
+
+// DeleteConfigFileSchema delete config file schema
+func (s *ServerAuthability) DeleteConfigFileSchema(ctx context.Context,
+	req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, []*apiconfig.ConfigFileGroup{{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Name:      utils.NewStringValue(req.Group),
+	}}, model.Modify, "DeleteConfigFileSchema")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigFileSchemaResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.DeleteConfigFileSchema(ctx, req)
+}
+
+// GetConfigFileSchemas query config file schemas
+func (s *ServerAuthability) GetConfigFileSchemas(ctx context.Context,
+	filter map[string]string) *model.ConfigFileSchemaBatchResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, []*apiconfig.ConfigFileGroup{{
+		Namespace: utils.NewStringValue(filter["namespace"]),
+		Name:      utils.NewStringValue(filter["group"]),
+	}}, model.Read, "GetConfigFileSchemas")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigFileSchemaBatchResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.GetConfigFileSchemas(ctx, filter)
+}
+
+// ValidateConfigFile dry-run config file schema validation
+func (s *ServerAuthability) ValidateConfigFile(ctx context.Context,
+	req *model.ConfigFileValidateRequest) *model.ConfigFileValidateResponse {
+	authCtx := s.collectConfigFileAuthContext(ctx, []*apiconfig.ConfigFile{{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Group:     utils.NewStringValue(req.Group),
+		Name:      utils.NewStringValue(req.FileName),
+	}}, model.Read, "ValidateConfigFile")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigFileValidateResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.ValidateConfigFile(ctx, req)
+}
@@ -0,0 +1,61 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package config_auth
+
+import (
+	"context"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// CreateConfigGrayRollout create config gray rollout, need the modify permission like publish
+func (s *ServerAuthability) CreateConfigGrayRollout(ctx context.Context,
+	rollout *model.ConfigGrayRollout) *model.ConfigGrayRolloutResponse {
+	authCtx := s.collectConfigFileAuthContext(ctx, []*apiconfig.ConfigFile{{
+		Namespace: utils.NewStringValue(rollout.Namespace),
+		Group:     utils.NewStringValue(rollout.Group),
+		Name:      utils.NewStringValue(rollout.FileName),
+	}}, model.Modify, "CreateConfigGrayRollout")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigGrayRolloutResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.CreateConfigGrayRollout(ctx, rollout)
+}
+
+// GetConfigGrayRollout query config gray rollout
+func (s *ServerAuthability) GetConfigGrayRollout(ctx context.Context,
+	filter map[string]string) *model.ConfigGrayRolloutResponse {
+	authCtx := s.collectConfigFileAuthContext(ctx, []*apiconfig.ConfigFile{{
+		Namespace: utils.NewStringValue(filter["namespace"]),
+		Group:     utils.NewStringValue(filter["group"]),
+		Name:      utils.NewStringValue(filter["file_name"]),
+	}}, model.Read, "GetConfigGrayRollout")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigGrayRolloutResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.GetConfigGrayRollout(ctx, filter)
+}
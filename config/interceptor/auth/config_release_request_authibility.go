@@ -0,0 +1,97 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config_auth
+
+import (
+	"context"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// CreateConfigReleaseRequest create config release request, need the modify permission like publish
+func (s *ServerAuthability) CreateConfigReleaseRequest(ctx context.Context,
+	req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse {
+	authCtx := s.collectConfigFileAuthContext(ctx, []*apiconfig.ConfigFile{{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Group:     utils.NewStringValue(req.Group),
+		Name:      utils.NewStringValue(req.FileName),
+	}}, model.Modify, "CreateConfigReleaseRequest")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigReleaseRequestResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.CreateConfigReleaseRequest(ctx, req)
+}
+
+// ApproveConfigReleaseRequest approve config release request, the approver is checked by the config server
+func (s *ServerAuthability) ApproveConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, nil, model.Read, "ApproveConfigReleaseRequest")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigReleaseRequestResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.ApproveConfigReleaseRequest(ctx, action)
+}
+
+// RejectConfigReleaseRequest reject config release request, the approver is checked by the config server
+func (s *ServerAuthability) RejectConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, nil, model.Read, "RejectConfigReleaseRequest")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigReleaseRequestResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.RejectConfigReleaseRequest(ctx, action)
+}
+
+// CancelConfigReleaseRequest cancel config release request, only the creator or owner can cancel
+func (s *ServerAuthability) CancelConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, nil, model.Read, "CancelConfigReleaseRequest")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigReleaseRequestResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.CancelConfigReleaseRequest(ctx, action)
+}
+
+// GetConfigReleaseRequests query config release requests
+func (s *ServerAuthability) GetConfigReleaseRequests(ctx context.Context,
+	filter map[string]string) *model.ConfigReleaseRequestBatchResponse {
+	authCtx := s.collectConfigGroupAuthContext(ctx, nil, model.Read, "GetConfigReleaseRequests")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_ConfigGroups, filter, "namespace")
+	if _, err := s.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
+		return &model.ConfigReleaseRequestBatchResponse{Code: uint32(model.ConvertToErrCode(err)), Info: err.Error()}
+	}
+
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	return s.nextServer.GetConfigReleaseRequests(ctx, filter)
+}
@@ -41,6 +41,9 @@ func (s *Server) UpsertAndReleaseConfigFileFromClient(ctx context.Context,
 	if err := CheckFileName(req.GetFileName()); err != nil {
 		return api.NewConfigResponseWithInfo(apimodel.Code_BadRequest, "invalid config file_name")
 	}
+	if errResp := s.checkPublishInfoSchema(req); errResp != nil {
+		return errResp
+	}
 	return s.nextServer.UpsertAndReleaseConfigFileFromClient(ctx, req)
 }
 
@@ -50,6 +53,9 @@ func (s *Server) CreateConfigFileFromClient(ctx context.Context,
 	if checkRsp := s.checkConfigFileParams(req); checkRsp != nil {
 		return api.NewConfigClientResponseFromConfigResponse(checkRsp)
 	}
+	if checkRsp := s.checkFileSchema(req); checkRsp != nil {
+		return api.NewConfigClientResponseFromConfigResponse(checkRsp)
+	}
 	return s.nextServer.CreateConfigFileFromClient(ctx, req)
 }
 
@@ -59,6 +65,9 @@ func (s *Server) UpdateConfigFileFromClient(ctx context.Context,
 	if checkRsp := s.checkConfigFileParams(req); checkRsp != nil {
 		return api.NewConfigClientResponseFromConfigResponse(checkRsp)
 	}
+	if checkRsp := s.checkFileSchema(req); checkRsp != nil {
+		return api.NewConfigClientResponseFromConfigResponse(checkRsp)
+	}
 	return s.nextServer.UpdateConfigFileFromClient(ctx, req)
 }
 
@@ -108,6 +117,9 @@ func (s *Server) PublishConfigFileFromClient(ctx context.Context,
 		ret := api.NewConfigResponse(apimodel.Code_InvalidMatchRule)
 		return api.NewConfigClientResponseFromConfigResponse(ret)
 	}
+	if checkRsp := s.checkPublishSchema(req); checkRsp != nil {
+		return api.NewConfigClientResponseFromConfigResponse(checkRsp)
+	}
 
 	return s.nextServer.PublishConfigFileFromClient(ctx, req)
 }
@@ -215,5 +227,8 @@ func (s *Server) CasUpsertAndReleaseConfigFileFromClient(ctx context.Context,
 	if err := utils.CheckResourceName(req.GetGroup()); err != nil {
 		return api.NewConfigResponse(apimodel.Code_InvalidConfigFileGroupName)
 	}
+	if errResp := s.checkPublishInfoSchema(req); errResp != nil {
+		return errResp
+	}
 	return s.nextServer.CasUpsertAndReleaseConfigFileFromClient(ctx, req)
 }
@@ -31,7 +31,9 @@ import (
 // CreateConfigFile 创建配置文件
 func (s *Server) CreateConfigFile(ctx context.Context,
 	configFile *apiconfig.ConfigFile) *apiconfig.ConfigResponse {
-
+	if errResp := s.checkFileSchema(configFile); errResp != nil {
+		return errResp
+	}
 	return s.nextServer.CreateConfigFile(ctx, configFile)
 }
 
@@ -73,7 +75,9 @@ func (s *Server) SearchConfigFile(ctx context.Context,
 // UpdateConfigFile 更新配置文件
 func (s *Server) UpdateConfigFile(
 	ctx context.Context, configFile *apiconfig.ConfigFile) *apiconfig.ConfigResponse {
-
+	if errResp := s.checkFileSchema(configFile); errResp != nil {
+		return errResp
+	}
 	return s.nextServer.UpdateConfigFile(ctx, configFile)
 }
 
@@ -103,6 +107,10 @@ func (s *Server) ImportConfigFile(ctx context.Context,
 		if checkRsp := s.checkConfigFileParams(configFile); checkRsp != nil {
 			return api.NewConfigFileImportResponse(apimodel.Code(checkRsp.Code.GetValue()), nil, nil, nil)
 		}
+		if checkRsp := s.checkFileSchema(configFile); checkRsp != nil {
+			return api.NewConfigFileImportResponseWithMessage(apimodel.Code(checkRsp.Code.GetValue()),
+				configFile.GetName().GetValue()+" "+checkRsp.GetInfo().GetValue())
+		}
 	}
 	return s.nextServer.ImportConfigFile(ctx, configFiles, conflictHandling)
 }
@@ -111,3 +119,15 @@ func (s *Server) GetAllConfigEncryptAlgorithms(
 	ctx context.Context) *apiconfig.ConfigEncryptAlgorithmResponse {
 	return s.nextServer.GetAllConfigEncryptAlgorithms(ctx)
 }
+
+// checkFileSchema 校验配置文件内容是否满足挂载的规则
+func (s *Server) checkFileSchema(configFile *apiconfig.ConfigFile) *apiconfig.ConfigResponse {
+	return s.checkConfigFileSchema(configFile.GetNamespace().GetValue(), configFile.GetGroup().GetValue(),
+		configFile.GetName().GetValue(), configFile.GetFormat().GetValue(), configFile.GetContent().GetValue())
+}
+
+// checkPublishInfoSchema 校验创建并发布的配置内容是否满足挂载的规则
+func (s *Server) checkPublishInfoSchema(req *apiconfig.ConfigFilePublishInfo) *apiconfig.ConfigResponse {
+	return s.checkConfigFileSchema(req.GetNamespace().GetValue(), req.GetGroup().GetValue(),
+		req.GetFileName().GetValue(), req.GetFormat().GetValue(), req.GetContent().GetValue())
+}
@@ -47,6 +47,9 @@ func (s *Server) PublishConfigFile(ctx context.Context,
 	if req.GetReleaseType().GetValue() == model.ReleaseTypeGray && len(req.GetBetaLabels()) == 0 {
 		return api.NewConfigResponse(apimodel.Code_InvalidMatchRule)
 	}
+	if errResp := s.checkPublishSchema(req); errResp != nil {
+		return errResp
+	}
 	return s.nextServer.PublishConfigFile(ctx, req)
 }
 
@@ -165,16 +168,82 @@ func (s *Server) RollbackConfigFileRelease(ctx context.Context,
 // UpsertAndReleaseConfigFile .
 func (s *Server) UpsertAndReleaseConfigFile(ctx context.Context,
 	req *apiconfig.ConfigFilePublishInfo) *apiconfig.ConfigResponse {
-
+	if errResp := s.checkPublishInfoSchema(req); errResp != nil {
+		return errResp
+	}
 	return s.nextServer.UpsertAndReleaseConfigFile(ctx, req)
 }
 
+// UpsertAndReleaseConfigFileOnBase .
+func (s *Server) UpsertAndReleaseConfigFileOnBase(ctx context.Context,
+	req *model.ConfigFilePublishRequest) *apiconfig.ConfigResponse {
+	if req.ConfigFilePublishInfo == nil {
+		return api.NewConfigResponse(apimodel.Code_EmptyRequest)
+	}
+	if errResp := s.checkPublishInfoSchema(req.ConfigFilePublishInfo); errResp != nil {
+		return errResp
+	}
+	return s.nextServer.UpsertAndReleaseConfigFileOnBase(ctx, req)
+}
+
 func (s *Server) StopGrayConfigFileReleases(ctx context.Context,
 	reqs []*apiconfig.ConfigFileRelease) *apiconfig.ConfigBatchWriteResponse {
 
 	return s.nextServer.StopGrayConfigFileReleases(ctx, reqs)
 }
 
+// DiffConfigFile 对比配置文件的两个版本
+func (s *Server) DiffConfigFile(ctx context.Context,
+	req *model.ConfigFileDiffRequest) *model.ConfigFileDiffResponse {
+	if errCode, errMsg := checkBaseFileParam(req.Namespace, req.Group, req.FileName); errCode != apimodel.Code_ExecuteSuccess {
+		return &model.ConfigFileDiffResponse{Code: uint32(errCode), Info: errMsg}
+	}
+	for _, source := range []model.ConfigFileSource{req.From, req.To} {
+		switch source.Type {
+		case model.ConfigFileSourceWorking, model.ConfigFileSourceActive, model.ConfigFileSourceGray:
+		case model.ConfigFileSourceRelease:
+			if source.Name == "" {
+				return &model.ConfigFileDiffResponse{Code: uint32(apimodel.Code_BadRequest),
+					Info: "invalid config release name"}
+			}
+		case model.ConfigFileSourceHistory:
+			if source.ID == 0 {
+				return &model.ConfigFileDiffResponse{Code: uint32(apimodel.Code_BadRequest),
+					Info: "invalid config release history id"}
+			}
+		default:
+			return &model.ConfigFileDiffResponse{Code: uint32(apimodel.Code_BadRequest),
+				Info: "invalid diff source type " + string(source.Type)}
+		}
+	}
+	return s.nextServer.DiffConfigFile(ctx, req)
+}
+
+// MergeConfigFile 检查基于旧版本修改的内容能否与最新发布合并
+func (s *Server) MergeConfigFile(ctx context.Context,
+	req *model.ConfigFileMergeRequest) *model.ConfigFileMergeResponse {
+	if errCode, errMsg := checkBaseFileParam(req.Namespace, req.Group, req.FileName); errCode != apimodel.Code_ExecuteSuccess {
+		return &model.ConfigFileMergeResponse{Code: uint32(errCode), Info: errMsg}
+	}
+	if req.BaseVersion == 0 {
+		return &model.ConfigFileMergeResponse{Code: uint32(apimodel.Code_BadRequest), Info: "invalid base version"}
+	}
+	return s.nextServer.MergeConfigFile(ctx, req)
+}
+
+func checkBaseFileParam(namespace, group, fileName string) (apimodel.Code, string) {
+	if namespace == "" {
+		return apimodel.Code_BadRequest, "invalid namespace"
+	}
+	if group == "" {
+		return apimodel.Code_BadRequest, "invalid config group"
+	}
+	if fileName == "" {
+		return apimodel.Code_BadRequest, "invalid config file name"
+	}
+	return apimodel.Code_ExecuteSuccess, ""
+}
+
 func checkBaseReleaseParam(req *apiconfig.ConfigFileRelease, checkRelease bool) (apimodel.Code, string) {
 	namespace := req.GetNamespace().GetValue()
 	group := req.GetGroup().GetValue()
@@ -0,0 +1,126 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package paramcheck
+
+import (
+	"context"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	"go.uber.org/zap"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	commonstore "github.com/polarismesh/polaris/common/store"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/config"
+)
+
+// UpsertConfigFileSchema create or update config file schema
+func (s *Server) UpsertConfigFileSchema(ctx context.Context,
+	req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	if errCode, errMsg := checkBaseSchemaParam(req.Namespace, req.Group); errCode != apimodel.Code_ExecuteSuccess {
+		return &model.ConfigFileSchemaResponse{Code: uint32(errCode), Info: errMsg}
+	}
+	return s.nextServer.UpsertConfigFileSchema(ctx, req)
+}
+
+// DeleteConfigFileSchema delete config file schema
+func (s *Server) DeleteConfigFileSchema(ctx context.Context,
+	req *model.ConfigFileSchema) *model.ConfigFileSchemaResponse {
+	if errCode, errMsg := checkBaseSchemaParam(req.Namespace, req.Group); errCode != apimodel.Code_ExecuteSuccess {
+		return &model.ConfigFileSchemaResponse{Code: uint32(errCode), Info: errMsg}
+	}
+	return s.nextServer.DeleteConfigFileSchema(ctx, req)
+}
+
+// GetConfigFileSchemas query config file schemas
+func (s *Server) GetConfigFileSchemas(ctx context.Context,
+	filter map[string]string) *model.ConfigFileSchemaBatchResponse {
+	if errCode, errMsg := checkBaseSchemaParam(filter["namespace"], filter["group"]); errCode != apimodel.Code_ExecuteSuccess {
+		return &model.ConfigFileSchemaBatchResponse{Code: uint32(errCode), Info: errMsg}
+	}
+	return s.nextServer.GetConfigFileSchemas(ctx, filter)
+}
+
+// ValidateConfigFile dry-run config file schema validation
+func (s *Server) ValidateConfigFile(ctx context.Context,
+	req *model.ConfigFileValidateRequest) *model.ConfigFileValidateResponse {
+	if errCode, errMsg := checkBaseFileParam(req.Namespace, req.Group, req.FileName); errCode != apimodel.Code_ExecuteSuccess {
+		return &model.ConfigFileValidateResponse{Code: uint32(errCode), Info: errMsg}
+	}
+	return s.nextServer.ValidateConfigFile(ctx, req)
+}
+
+// checkConfigFileSchema 使用配置文件上挂载的规则校验配置内容，format 为空时使用已保存的配置文件的格式
+func (s *Server) checkConfigFileSchema(namespace, group, fileName, format,
+	content string) *apiconfig.ConfigResponse {
+	schema, err := config.LoadConfigFileSchema(s.storage, namespace, group, fileName)
+	if err != nil {
+		return api.NewConfigResponseWithInfo(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	if schema == nil {
+		return nil
+	}
+	if format == "" {
+		file, err := s.storage.GetConfigFile(namespace, group, fileName)
+		if err != nil {
+			return api.NewConfigResponseWithInfo(commonstore.StoreCode2APICode(err), err.Error())
+		}
+		if file == nil {
+			return nil
+		}
+		format = file.Format
+	}
+	violations, err := config.ValidateConfigFileContent(schema, format, content)
+	if err != nil {
+		// 规则保存时已经编译过，这里出错只会是历史数据的问题，不阻塞配置的变更
+		log.Warn("[Config][ParamCheck] compile config file schema fail", utils.ZapNamespace(namespace),
+			utils.ZapGroup(group), utils.ZapFileName(fileName), zap.Error(err))
+		return nil
+	}
+	if len(violations) > 0 {
+		return api.NewConfigResponseWithInfo(apimodel.Code(api.ConfigFileSchemaViolation),
+			config.FormatSchemaViolations(violations))
+	}
+	return nil
+}
+
+// checkPublishSchema 发布前校验编辑中的配置内容，加密的配置在保存时已经校验过，这里不再解密校验
+func (s *Server) checkPublishSchema(req *apiconfig.ConfigFileRelease) *apiconfig.ConfigResponse {
+	namespace := req.GetNamespace().GetValue()
+	group := req.GetGroup().GetValue()
+	fileName := req.GetFileName().GetValue()
+	file, err := s.storage.GetConfigFile(namespace, group, fileName)
+	if err != nil {
+		return api.NewConfigResponseWithInfo(commonstore.StoreCode2APICode(err), err.Error())
+	}
+	if file == nil || file.IsEncrypted() {
+		return nil
+	}
+	return s.checkConfigFileSchema(namespace, group, fileName, file.Format, file.Content)
+}
+
+func checkBaseSchemaParam(namespace, group string) (apimodel.Code, string) {
+	if namespace == "" {
+		return apimodel.Code_BadRequest, "invalid namespace"
+	}
+	if group == "" {
+		return apimodel.Code_BadRequest, "invalid config group"
+	}
+	return apimodel.Code_ExecuteSuccess, ""
+}
@@ -0,0 +1,115 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package paramcheck
+
+import (
+	"context"
+	"fmt"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// maxGrayRolloutSteps 按比例灰度最多允许的阶段数
+const maxGrayRolloutSteps = 10
+
+// CreateConfigGrayRollout create config gray rollout
+func (s *Server) CreateConfigGrayRollout(ctx context.Context,
+	rollout *model.ConfigGrayRollout) *model.ConfigGrayRolloutResponse {
+	if errResp := s.checkConfigGrayRollout(rollout); errResp != nil {
+		return errResp
+	}
+	errResp := s.checkPublishSchema(&apiconfig.ConfigFileRelease{
+		Namespace: utils.NewStringValue(rollout.Namespace),
+		Group:     utils.NewStringValue(rollout.Group),
+		FileName:  utils.NewStringValue(rollout.FileName),
+	})
+	if errResp != nil {
+		return &model.ConfigGrayRolloutResponse{
+			Code: errResp.GetCode().GetValue(),
+			Info: errResp.GetInfo().GetValue(),
+		}
+	}
+	return s.nextServer.CreateConfigGrayRollout(ctx, rollout)
+}
+
+// GetConfigGrayRollout query config gray rollout
+func (s *Server) GetConfigGrayRollout(ctx context.Context,
+	filter map[string]string) *model.ConfigGrayRolloutResponse {
+	if errResp := checkConfigGrayRolloutFile(filter["namespace"], filter["group"],
+		filter["file_name"]); errResp != nil {
+		return errResp
+	}
+	return s.nextServer.GetConfigGrayRollout(ctx, filter)
+}
+
+func (s *Server) checkConfigGrayRollout(rollout *model.ConfigGrayRollout) *model.ConfigGrayRolloutResponse {
+	if errResp := checkConfigGrayRolloutFile(rollout.Namespace, rollout.Group, rollout.FileName); errResp != nil {
+		return errResp
+	}
+	if !s.checkNamespaceExisted(rollout.Namespace) {
+		return newConfigGrayRolloutErrResp(apimodel.Code_NotFoundNamespace, "")
+	}
+	if len(rollout.Steps) == 0 || len(rollout.Steps) > maxGrayRolloutSteps {
+		return newConfigGrayRolloutErrResp(apimodel.Code_BadRequest,
+			fmt.Sprintf("steps size must be in [1, %d]", maxGrayRolloutSteps))
+	}
+	var last uint32
+	for i, step := range rollout.Steps {
+		if step == nil || step.Percentage <= last || step.Percentage > model.GrayBucketCount {
+			return newConfigGrayRolloutErrResp(apimodel.Code_BadRequest,
+				fmt.Sprintf("percentage of step %d must be increasing and in (0, 100]", i))
+		}
+		if step.Duration < 0 {
+			return newConfigGrayRolloutErrResp(apimodel.Code_BadRequest,
+				fmt.Sprintf("duration of step %d must not be negative", i))
+		}
+		last = step.Percentage
+	}
+	if last != model.GrayBucketCount {
+		return newConfigGrayRolloutErrResp(apimodel.Code_BadRequest, "percentage of the last step must be 100")
+	}
+	if rollout.ErrorThreshold < 0 {
+		return newConfigGrayRolloutErrResp(apimodel.Code_BadRequest, "error_threshold must not be negative")
+	}
+	return nil
+}
+
+func checkConfigGrayRolloutFile(namespace, group, fileName string) *model.ConfigGrayRolloutResponse {
+	if err := utils.CheckResourceName(utils.NewStringValue(namespace)); err != nil {
+		return newConfigGrayRolloutErrResp(apimodel.Code_InvalidNamespaceName, "")
+	}
+	if err := utils.CheckResourceName(utils.NewStringValue(group)); err != nil {
+		return newConfigGrayRolloutErrResp(apimodel.Code_InvalidConfigFileGroupName, "")
+	}
+	if err := CheckFileName(utils.NewStringValue(fileName)); err != nil {
+		return newConfigGrayRolloutErrResp(apimodel.Code_InvalidConfigFileName, "")
+	}
+	return nil
+}
+
+func newConfigGrayRolloutErrResp(code apimodel.Code, info string) *model.ConfigGrayRolloutResponse {
+	if info == "" {
+		info = api.Code2Info(uint32(code))
+	}
+	return &model.ConfigGrayRolloutResponse{Code: uint32(code), Info: info}
+}
@@ -0,0 +1,154 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package paramcheck
+
+import (
+	"context"
+	"fmt"
+
+	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
+	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// CreateConfigReleaseRequest create config release request
+func (s *Server) CreateConfigReleaseRequest(ctx context.Context,
+	req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse {
+	if errResp := s.checkConfigReleaseRequest(req); errResp != nil {
+		return errResp
+	}
+	// 申请时就校验待发布的内容，避免审批通过之后才发现内容不合法
+	errResp := s.checkPublishSchema(&apiconfig.ConfigFileRelease{
+		Namespace: utils.NewStringValue(req.Namespace),
+		Group:     utils.NewStringValue(req.Group),
+		FileName:  utils.NewStringValue(req.FileName),
+	})
+	if errResp != nil {
+		return &model.ConfigReleaseRequestResponse{
+			Code: errResp.GetCode().GetValue(),
+			Info: errResp.GetInfo().GetValue(),
+		}
+	}
+	return s.nextServer.CreateConfigReleaseRequest(ctx, req)
+}
+
+// ApproveConfigReleaseRequest approve config release request
+func (s *Server) ApproveConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	if errResp := checkConfigReleaseRequestAction(action); errResp != nil {
+		return errResp
+	}
+	return s.nextServer.ApproveConfigReleaseRequest(ctx, action)
+}
+
+// RejectConfigReleaseRequest reject config release request
+func (s *Server) RejectConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	if errResp := checkConfigReleaseRequestAction(action); errResp != nil {
+		return errResp
+	}
+	return s.nextServer.RejectConfigReleaseRequest(ctx, action)
+}
+
+// CancelConfigReleaseRequest cancel config release request
+func (s *Server) CancelConfigReleaseRequest(ctx context.Context,
+	action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	if errResp := checkConfigReleaseRequestAction(action); errResp != nil {
+		return errResp
+	}
+	return s.nextServer.CancelConfigReleaseRequest(ctx, action)
+}
+
+// GetConfigReleaseRequests query config release requests
+func (s *Server) GetConfigReleaseRequests(ctx context.Context,
+	filter map[string]string) *model.ConfigReleaseRequestBatchResponse {
+	if status := filter["status"]; status != "" && !isConfigReleaseRequestStatus(status) {
+		return &model.ConfigReleaseRequestBatchResponse{
+			Code: uint32(apimodel.Code_InvalidParameter),
+			Info: fmt.Sprintf("invalid status %s", status),
+		}
+	}
+	return s.nextServer.GetConfigReleaseRequests(ctx, filter)
+}
+
+func (s *Server) checkConfigReleaseRequest(req *model.ConfigReleaseRequest) *model.ConfigReleaseRequestResponse {
+	newErrResp := func(code apimodel.Code, info string) *model.ConfigReleaseRequestResponse {
+		if info == "" {
+			info = api.Code2Info(uint32(code))
+		}
+		return &model.ConfigReleaseRequestResponse{Code: uint32(code), Info: info}
+	}
+	if req.FileName == "" {
+		return newErrResp(apimodel.Code_InvalidConfigFileName, "")
+	}
+	if err := utils.CheckResourceName(utils.NewStringValue(req.Namespace)); err != nil {
+		return newErrResp(apimodel.Code_InvalidNamespaceName, "")
+	}
+	if err := utils.CheckResourceName(utils.NewStringValue(req.Group)); err != nil {
+		return newErrResp(apimodel.Code_InvalidConfigFileGroupName, "")
+	}
+	if !s.checkNamespaceExisted(req.Namespace) {
+		return newErrResp(apimodel.Code_NotFoundNamespace, "")
+	}
+	if req.RequiredApprovals < 0 {
+		return newErrResp(apimodel.Code_BadRequest, "required_approvals must not be negative")
+	}
+	if req.RequiredApprovals == 0 {
+		return nil
+	}
+	if len(req.Approvers.Users) == 0 && len(req.Approvers.Groups) == 0 {
+		return newErrResp(apimodel.Code_BadRequest, "approvers is empty")
+	}
+	if len(req.Approvers.Groups) == 0 && len(req.Approvers.Users) < req.RequiredApprovals {
+		return newErrResp(apimodel.Code_BadRequest, "approvers is less than required_approvals")
+	}
+	for _, id := range req.Approvers.Users {
+		if s.cacheMgr.User().GetUserByID(id) == nil {
+			return newErrResp(apimodel.Code_NotFoundUser, fmt.Sprintf("approver user %s not found", id))
+		}
+	}
+	for _, id := range req.Approvers.Groups {
+		if s.cacheMgr.User().GetGroup(id) == nil {
+			return newErrResp(apimodel.Code_NotFoundUserGroup, fmt.Sprintf("approver group %s not found", id))
+		}
+	}
+	return nil
+}
+
+func checkConfigReleaseRequestAction(action *model.ConfigReleaseRequestAction) *model.ConfigReleaseRequestResponse {
+	if action.Id == "" {
+		return &model.ConfigReleaseRequestResponse{
+			Code: uint32(apimodel.Code_BadRequest),
+			Info: "invalid config release request id",
+		}
+	}
+	return nil
+}
+
+func isConfigReleaseRequestStatus(status string) bool {
+	switch status {
+	case model.ReleaseRequestPending, model.ReleaseRequestApproved, model.ReleaseRequestReleasing,
+		model.ReleaseRequestReleased, model.ReleaseRequestFailed, model.ReleaseRequestRejected,
+		model.ReleaseRequestCancelled:
+		return true
+	default:
+		return false
+	}
+}
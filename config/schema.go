@@ -0,0 +1,394 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v2"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+// maxSchemaViolations 单次校验最多返回的错误数量
+const maxSchemaViolations = 50
+
+// ConfigFileValidator 编译后的配置校验规则
+type ConfigFileValidator interface {
+	// Support 规则是否适用于该格式的配置内容
+	Support(format string) bool
+	// Validate 校验配置内容，返回所有不满足规则的地方
+	Validate(format, content string) []*model.SchemaViolation
+}
+
+// CompileConfigFileSchema 编译配置校验规则，规则不合法时返回 error
+func CompileConfigFileSchema(schema *model.ConfigFileSchema) (ConfigFileValidator, error) {
+	if strings.TrimSpace(schema.Schema) == "" {
+		return nil, errors.New("schema is empty")
+	}
+	switch schema.Type {
+	case model.ConfigFileSchemaTypeJSON:
+		return compileJSONSchema(schema.Schema)
+	case model.ConfigFileSchemaTypeProperties:
+		return compilePropertiesSchema(schema.Schema)
+	default:
+		return nil, fmt.Errorf("schema type %q not support, support %s,%s", schema.Type,
+			model.ConfigFileSchemaTypeJSON, model.ConfigFileSchemaTypeProperties)
+	}
+}
+
+// ValidateConfigFileContent 使用规则校验配置内容，规则不适用于该格式时直接通过
+func ValidateConfigFileContent(schema *model.ConfigFileSchema,
+	format, content string) ([]*model.SchemaViolation, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	validator, err := CompileConfigFileSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	if !validator.Support(format) {
+		return nil, nil
+	}
+	return validator.Validate(format, content), nil
+}
+
+// LoadConfigFileSchema 获取对配置文件生效的校验规则，配置文件级别的规则优先于分组级别的规则
+func LoadConfigFileSchema(s store.ConfigFileSchemaStore, namespace, group,
+	fileName string) (*model.ConfigFileSchema, error) {
+	if fileName != "" {
+		schema, err := s.GetConfigFileSchema(namespace, group, fileName)
+		if err != nil || schema != nil {
+			return schema, err
+		}
+	}
+	return s.GetConfigFileSchema(namespace, group, "")
+}
+
+// FormatSchemaViolations 把校验错误拼接为一行可读的信息
+func FormatSchemaViolations(violations []*model.SchemaViolation) string {
+	msgs := make([]string, 0, len(violations))
+	for i, v := range violations {
+		if i >= 5 {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(violations)-i))
+			break
+		}
+		msgs = append(msgs, v.Path+": "+v.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type violationCollector struct {
+	violations []*model.SchemaViolation
+}
+
+func (c *violationCollector) add(path, format string, args ...interface{}) {
+	if len(c.violations) >= maxSchemaViolations {
+		return
+	}
+	c.violations = append(c.violations, &model.SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *violationCollector) full() bool {
+	return len(c.violations) >= maxSchemaViolations
+}
+
+// jsonSchemaResource 编译规则时使用的资源地址，规则中的 $ref 只能引用自身
+const jsonSchemaResource = "polaris://config-file-schema.json"
+
+// jsonSchema 基于 jsonschema 库实现的 JSON Schema 校验，未声明 $schema 时按照 draft 2020-12 处理，
+// format 关键字会被校验，规则中不允许引用外部资源
+type jsonSchema struct {
+	schema *jsonschema.Schema
+}
+
+func compileJSONSchema(text string) (*jsonSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("load external schema %s not allowed", s)
+	}
+	if err := compiler.AddResource(jsonSchemaResource, strings.NewReader(text)); err != nil {
+		return nil, fmt.Errorf("schema is not a valid json: %w", err)
+	}
+	schema, err := compiler.Compile(jsonSchemaResource)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not a valid json schema: %w", err)
+	}
+	return &jsonSchema{schema: schema}, nil
+}
+
+// Support json schema 用于校验 json 以及 yaml 格式的配置
+func (s *jsonSchema) Support(format string) bool {
+	return format == utils.FileFormatJson || format == utils.FileFormatYaml
+}
+
+// Validate 解析配置内容并按照 JSON Schema 校验
+func (s *jsonSchema) Validate(format, content string) []*model.SchemaViolation {
+	var (
+		val       interface{}
+		collector = &violationCollector{}
+	)
+	switch format {
+	case utils.FileFormatJson:
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&val); err != nil {
+			collector.add("/", "invalid json content: %v", err)
+			return collector.violations
+		}
+		if decoder.More() {
+			collector.add("/", "invalid json content: unexpected data after top-level value")
+			return collector.violations
+		}
+	case utils.FileFormatYaml:
+		var raw interface{}
+		if err := yaml.Unmarshal([]byte(content), &raw); err != nil {
+			collector.add("/", "invalid yaml content: %v", err)
+			return collector.violations
+		}
+		val = normalizeYAMLValue(raw)
+	}
+	err := s.schema.Validate(val)
+	if err == nil {
+		return nil
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		collector.add("/", "%v", err)
+		return collector.violations
+	}
+	collectSchemaViolations(ve, collector)
+	return collector.violations
+}
+
+// collectSchemaViolations 只保留最底层的校验错误，上层的错误只是对下层错误的汇总
+func collectSchemaViolations(ve *jsonschema.ValidationError, c *violationCollector) {
+	if len(ve.Causes) == 0 {
+		path := ve.InstanceLocation
+		if path == "" {
+			path = "/"
+		}
+		c.add(path, "%s", ve.Message)
+		return
+	}
+	for _, cause := range ve.Causes {
+		if c.full() {
+			return
+		}
+		collectSchemaViolations(cause, c)
+	}
+}
+
+// normalizeYAMLValue 把 yaml 解析出来的值转换为与 json 一致的类型
+func normalizeYAMLValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			ret[fmt.Sprint(k)] = normalizeYAMLValue(item)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = normalizeYAMLValue(item)
+		}
+		return ret
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// propertiesRule properties 格式配置中一个配置项的规则
+type propertiesRule struct {
+	// Key 配置项的 key，支持前缀或者后缀通配，例如 feature.*
+	Key string `json:"key"`
+	// Type 配置项的值类型，string/int/float/bool，默认为 string
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Enum     []string `json:"enum"`
+	Pattern  string   `json:"pattern"`
+	// Min/Max 对于 int/float 类型为取值范围，对于 string 类型为长度范围
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+
+	pattern *regexp.Regexp
+	wild    bool
+}
+
+// propertiesSchema properties 格式配置的规则
+type propertiesSchema struct {
+	Rules []*propertiesRule `json:"rules"`
+	// AllowUnknown 是否允许出现规则之外的配置项，默认允许
+	AllowUnknown *bool `json:"allowUnknown"`
+}
+
+var propertiesValueTypes = map[string]struct{}{"": {}, "string": {}, "int": {}, "float": {}, "bool": {}}
+
+func compilePropertiesSchema(text string) (*propertiesSchema, error) {
+	s := &propertiesSchema{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(s); err != nil {
+		return nil, fmt.Errorf("schema is not a valid properties rule: %w", err)
+	}
+	if len(s.Rules) == 0 {
+		return nil, errors.New("rules is empty")
+	}
+	for i, rule := range s.Rules {
+		if rule.Key == "" {
+			return nil, fmt.Errorf("rules[%d]: key is empty", i)
+		}
+		if _, ok := propertiesValueTypes[rule.Type]; !ok {
+			return nil, fmt.Errorf("rules[%d]: type %q not support, support string,int,float,bool", i, rule.Type)
+		}
+		rule.wild = utils.IsPrefixWildName(rule.Key) || utils.IsSuffixWildName(rule.Key)
+		if rule.wild && rule.Required {
+			return nil, fmt.Errorf("rules[%d]: wildcard key %s can not be required", i, rule.Key)
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rules[%d]: %w", i, err)
+			}
+			rule.pattern = pattern
+		}
+	}
+	return s, nil
+}
+
+// Support properties 规则只用于校验 properties 格式的配置
+func (s *propertiesSchema) Support(format string) bool {
+	return format == utils.FileFormatProperties
+}
+
+// Validate 解析配置内容并按照配置项规则校验
+func (s *propertiesSchema) Validate(format, content string) []*model.SchemaViolation {
+	collector := &violationCollector{}
+	values, err := parseProperties(content)
+	if err != nil {
+		collector.add("/", "invalid properties content: %v", err)
+		return collector.violations
+	}
+	for _, rule := range s.Rules {
+		if _, ok := values[rule.Key]; rule.Required && !ok {
+			collector.add(rule.Key, "missing required key")
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		rule := s.match(key)
+		if rule == nil {
+			if s.AllowUnknown != nil && !*s.AllowUnknown {
+				collector.add(key, "unknown key is not allowed")
+			}
+			continue
+		}
+		rule.validate(key, values[key], collector)
+	}
+	return collector.violations
+}
+
+// match 精确匹配的规则优先于通配的规则
+func (s *propertiesSchema) match(key string) *propertiesRule {
+	var wild *propertiesRule
+	for _, rule := range s.Rules {
+		if !rule.wild && rule.Key == key {
+			return rule
+		}
+		if wild == nil && rule.wild && utils.IsWildMatch(key, rule.Key) {
+			wild = rule
+		}
+	}
+	return wild
+}
+
+func (r *propertiesRule) validate(key, value string, c *violationCollector) {
+	var num float64
+	switch r.Type {
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.add(key, "value %q is not an int", value)
+			return
+		}
+		num = float64(v)
+	case "float":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			c.add(key, "value %q is not a float", value)
+			return
+		}
+		num = v
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			c.add(key, "value %q is not a bool", value)
+			return
+		}
+	default:
+		num = float64(utf8.RuneCountInString(value))
+	}
+	if r.Type != "bool" {
+		if r.Min != nil && num < *r.Min {
+			c.add(key, "value %q is less than %v", value, *r.Min)
+		}
+		if r.Max != nil && num > *r.Max {
+			c.add(key, "value %q is greater than %v", value, *r.Max)
+		}
+	}
+	if len(r.Enum) > 0 {
+		matched := false
+		for _, item := range r.Enum {
+			if item == value {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			c.add(key, "value %q is not one of %s", value, strings.Join(r.Enum, ","))
+		}
+	}
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		c.add(key, "value %q does not match pattern %q", value, r.Pattern)
+	}
+}
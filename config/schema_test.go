@@ -0,0 +1,209 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func TestCompileConfigFileSchema(t *testing.T) {
+	cases := []*model.ConfigFileSchema{
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: ""},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: "{"},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: `{"type":"unknown"}`},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: `{"pattern":"("}`},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: `{"$ref":"#/$defs/a"}`},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: `{"$ref":"https://example.com/schema.json"}`},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: `{"minLength":-1}`},
+		{Type: model.ConfigFileSchemaTypeJSON, Schema: `{"required":"a"}`},
+		{Type: model.ConfigFileSchemaTypeProperties, Schema: `{"rules":[]}`},
+		{Type: model.ConfigFileSchemaTypeProperties, Schema: `{"rules":[{"key":"a","type":"long"}]}`},
+		{Type: model.ConfigFileSchemaTypeProperties, Schema: `{"rules":[{"key":"a.*","required":true}]}`},
+		{Type: model.ConfigFileSchemaTypeProperties, Schema: `{"rules":[{"key":"a"}],"unknown":1}`},
+		{Type: "xml_schema", Schema: `{}`},
+	}
+	for _, item := range cases {
+		_, err := CompileConfigFileSchema(item)
+		assert.Error(t, err, item.Schema)
+	}
+}
+
+func TestValidateConfigFileContent_JSONSchema(t *testing.T) {
+	schema := &model.ConfigFileSchema{
+		Type: model.ConfigFileSchemaTypeJSON,
+		Schema: `{
+			"type": "object",
+			"required": ["name", "port"],
+			"additionalProperties": false,
+			"properties": {
+				"name": {"type": "string", "minLength": 1},
+				"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+				"mode": {"enum": ["debug", "release"]},
+				"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true}
+			}
+		}`,
+	}
+
+	t.Run("json", func(t *testing.T) {
+		violations, err := ValidateConfigFileContent(schema, utils.FileFormatJson,
+			`{"name":"polaris","port":8090,"mode":"release","tags":["a","b"]}`)
+		assert.NoError(t, err)
+		assert.Empty(t, violations)
+
+		violations, err = ValidateConfigFileContent(schema, utils.FileFormatJson,
+			`{"port":70000,"mode":"test","tags":["a","a"],"extra":true}`)
+		assert.NoError(t, err)
+		paths := map[string]bool{}
+		for _, v := range violations {
+			paths[v.Path] = true
+		}
+		// 缺少 name 以及多出 extra 都报告在根路径上
+		assert.True(t, paths["/"], violations)
+		assert.True(t, paths["/port"], violations)
+		assert.True(t, paths["/mode"], violations)
+		assert.True(t, paths["/tags"], violations)
+		assert.Len(t, violations, 5)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		violations, err := ValidateConfigFileContent(schema, utils.FileFormatYaml,
+			"name: polaris\nport: 8090\ntags:\n  - a\n")
+		assert.NoError(t, err)
+		assert.Empty(t, violations)
+
+		violations, err = ValidateConfigFileContent(schema, utils.FileFormatYaml, "name: polaris\nport: abc\n")
+		assert.NoError(t, err)
+		assert.Len(t, violations, 1)
+		assert.Equal(t, "/port", violations[0].Path)
+	})
+
+	t.Run("invalid_content", func(t *testing.T) {
+		violations, err := ValidateConfigFileContent(schema, utils.FileFormatJson, "{")
+		assert.NoError(t, err)
+		assert.Len(t, violations, 1)
+	})
+
+	t.Run("format_not_support", func(t *testing.T) {
+		violations, err := ValidateConfigFileContent(schema, utils.FileFormatProperties, "a=1")
+		assert.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+}
+
+func TestValidateConfigFileContent_JSONSchemaKeywords(t *testing.T) {
+	cases := []struct {
+		name    string
+		schema  string
+		valid   string
+		invalid string
+	}{
+		{"type", `{"type":"integer"}`, `1`, `1.5`},
+		{"enum", `{"enum":["a","b"]}`, `"a"`, `"c"`},
+		{"const", `{"const":1}`, `1`, `2`},
+		{"required", `{"required":["a"]}`, `{"a":1}`, `{}`},
+		{"properties", `{"properties":{"a":{"type":"string"}}}`, `{"a":"x"}`, `{"a":1}`},
+		{"additionalProperties", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1}`, `{"b":1}`},
+		{"patternProperties", `{"patternProperties":{"^n_":{"type":"number"}}}`, `{"n_a":1}`, `{"n_a":"x"}`},
+		{"propertyNames", `{"propertyNames":{"maxLength":2}}`, `{"ab":1}`, `{"abc":1}`},
+		{"minProperties", `{"minProperties":1}`, `{"a":1}`, `{}`},
+		{"maxProperties", `{"maxProperties":1}`, `{"a":1}`, `{"a":1,"b":2}`},
+		{"dependentRequired", `{"dependentRequired":{"a":["b"]}}`, `{"a":1,"b":2}`, `{"a":1}`},
+		{"items", `{"items":{"type":"string"}}`, `["a"]`, `[1]`},
+		{"prefixItems", `{"prefixItems":[{"type":"string"}]}`, `["a",1]`, `[1]`},
+		{"contains", `{"contains":{"const":1}}`, `[0,1]`, `[0]`},
+		{"minItems", `{"minItems":1}`, `[1]`, `[]`},
+		{"maxItems", `{"maxItems":1}`, `[1]`, `[1,2]`},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,2]`, `[1,1]`},
+		{"minimum", `{"minimum":1}`, `1`, `0`},
+		{"maximum", `{"maximum":1}`, `1`, `2`},
+		{"exclusiveMinimum", `{"exclusiveMinimum":1}`, `2`, `1`},
+		{"exclusiveMaximum", `{"exclusiveMaximum":1}`, `0`, `1`},
+		{"multipleOf", `{"multipleOf":0.5}`, `1.5`, `1.2`},
+		{"minLength", `{"minLength":2}`, `"ab"`, `"a"`},
+		{"maxLength", `{"maxLength":1}`, `"a"`, `"ab"`},
+		{"pattern", `{"pattern":"^[a-z]+$"}`, `"abc"`, `"ABC"`},
+		{"format", `{"format":"ipv4"}`, `"127.0.0.1"`, `"localhost"`},
+		{"allOf", `{"allOf":[{"minimum":1},{"maximum":2}]}`, `1`, `3`},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"integer"}]}`, `1`, `true`},
+		{"oneOf", `{"oneOf":[{"minimum":1},{"maximum":2}]}`, `3`, `1`},
+		{"not", `{"not":{"type":"string"}}`, `1`, `"a"`},
+		{"if_then_else", `{"if":{"type":"string"},"then":{"minLength":1},"else":{"minimum":1}}`, `"a"`, `0`},
+		{"$ref", `{"$defs":{"port":{"type":"integer"}},"properties":{"p":{"$ref":"#/$defs/port"}}}`,
+			`{"p":1}`, `{"p":"1"}`},
+	}
+	for _, item := range cases {
+		t.Run(item.name, func(t *testing.T) {
+			schema := &model.ConfigFileSchema{Type: model.ConfigFileSchemaTypeJSON, Schema: item.schema}
+			violations, err := ValidateConfigFileContent(schema, utils.FileFormatJson, item.valid)
+			assert.NoError(t, err)
+			assert.Empty(t, violations)
+
+			violations, err = ValidateConfigFileContent(schema, utils.FileFormatJson, item.invalid)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, violations)
+		})
+	}
+}
+
+func TestValidateConfigFileContent_PropertiesRule(t *testing.T) {
+	schema := &model.ConfigFileSchema{
+		Type: model.ConfigFileSchemaTypeProperties,
+		Schema: `{
+			"allowUnknown": false,
+			"rules": [
+				{"key": "server.port", "type": "int", "required": true, "min": 1, "max": 65535},
+				{"key": "server.mode", "enum": ["debug", "release"]},
+				{"key": "feature.*", "type": "bool"}
+			]
+		}`,
+	}
+
+	violations, err := ValidateConfigFileContent(schema, utils.FileFormatProperties,
+		"server.port=8090\nserver.mode=debug\nfeature.gray=true\n")
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = ValidateConfigFileContent(schema, utils.FileFormatProperties,
+		"server.mode=test\nfeature.gray=yes\nunknown=1\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.SchemaViolation{
+		{Path: "server.port", Message: "missing required key"},
+		{Path: "feature.gray", Message: `value "yes" is not a bool`},
+		{Path: "server.mode", Message: `value "test" is not one of debug,release`},
+		{Path: "unknown", Message: "unknown key is not allowed"},
+	}, violations)
+
+	violations, err = ValidateConfigFileContent(schema, utils.FileFormatProperties, "server.port=0\n")
+	assert.NoError(t, err)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, "server.port", violations[0].Path)
+}
+
+func TestFormatSchemaViolations(t *testing.T) {
+	violations := make([]*model.SchemaViolation, 0, 7)
+	for i := 0; i < 7; i++ {
+		violations = append(violations, &model.SchemaViolation{Path: "/a", Message: "invalid"})
+	}
+	msg := FormatSchemaViolations(violations)
+	assert.Contains(t, msg, "/a: invalid; ")
+	assert.Contains(t, msg, "and 2 more")
+}
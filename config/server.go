@@ -21,10 +21,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
 
 	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/namespace"
@@ -59,9 +61,19 @@ func RegisterServerProxy(name string, factor ServerProxyFactory) error {
 
 // Config 配置中心模块启动参数
 type Config struct {
-	Open             bool     `yaml:"open"`
-	ContentMaxLength int64    `yaml:"contentMaxLength"`
-	Interceptors     []string `yaml:"-"`
+	Open             bool                 `yaml:"open"`
+	ContentMaxLength int64                `yaml:"contentMaxLength"`
+	ReleaseRequest   ReleaseRequestConfig `yaml:"releaseRequest"`
+	Interceptors     []string             `yaml:"-"`
+}
+
+// ReleaseRequestConfig 配置发布申请的调度配置
+type ReleaseRequestConfig struct {
+	// ScheduleInterval 检查到期发布申请的间隔
+	ScheduleInterval time.Duration `yaml:"scheduleInterval"`
+	// ReleasingTimeout 申请处于 releasing 状态超过该时间时，认为执行发布的节点已经宕机或者失去了 leader 身份，
+	// 由当前的 leader 重新处理
+	ReleasingTimeout time.Duration `yaml:"releasingTimeout"`
 }
 
 // Server 配置中心核心服务
@@ -175,6 +187,14 @@ func (s *Server) initialize(ctx context.Context, config Config, ss store.Store,
 		&ReleaseConfigFileChain{},
 	})
 
+	if err := s.startReleaseScheduler(ctx); err != nil {
+		return err
+	}
+	// 客户端上报的灰度版本异常，用于自动终止按比例灰度
+	if _, err := eventhub.SubscribeWithFunc(eventhub.ConfigClientErrorTopic, s.handleConfigClientErrors); err != nil {
+		return err
+	}
+
 	log.Infof("[Config][Server] startup config module success.")
 	return nil
 }
@@ -29,6 +29,7 @@ import (
 	mockcache "github.com/polarismesh/polaris/cache/mock"
 	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
 	mockstore "github.com/polarismesh/polaris/store/mock"
 )
 
@@ -50,6 +51,8 @@ func Test_Initialize(t *testing.T) {
 	cacheMgr.EXPECT().ConfigGroup().Return(nil).AnyTimes()
 	cacheMgr.EXPECT().GetReportInterval().Return(time.Second).AnyTimes()
 	cacheMgr.EXPECT().GetUpdateCacheInterval().Return(time.Second).AnyTimes()
+	mockStore.EXPECT().StartLeaderElection(store.ElectionKeyConfigReleaseScheduler).Return(nil).AnyTimes()
+	mockStore.EXPECT().IsLeader(gomock.Any()).Return(false).AnyTimes()
 
 	_, _, err := auth.TestInitialize(context.Background(), &auth.Config{}, mockStore, cacheMgr)
 	assert.NoError(t, err)
@@ -20,12 +20,14 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 
 	apiconfig "github.com/polarismesh/specification/source/go/api/v1/config_manage"
 	"go.uber.org/zap"
 
 	"github.com/polarismesh/polaris/auth"
 	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/namespace"
 	"github.com/polarismesh/polaris/plugin"
@@ -104,6 +106,21 @@ func (s *Server) TestMockStore(ms store.Store) {
 	s.storage = ms
 }
 
+// TestExecuteReleaseRequests 立即执行一次到期的配置发布申请
+func (s *Server) TestExecuteReleaseRequests(now time.Time) {
+	s.executeReleaseRequests(now)
+}
+
+// TestAdvanceGrayRollouts 立即推进一次进行中的按比例灰度
+func (s *Server) TestAdvanceGrayRollouts(now time.Time) {
+	s.advanceGrayRollouts(now)
+}
+
+// TestHandleConfigClientErrors 处理客户端上报的配置文件异常
+func (s *Server) TestHandleConfigClientErrors(ctx context.Context, event *eventhub.ConfigClientErrorEvent) {
+	_ = s.handleConfigClientErrors(ctx, event)
+}
+
 // TestMockCryptoManager 获取加密管理
 func (s *Server) TestMockCryptoManager(mgr plugin.CryptoManager) {
 	s.cryptoManager = mgr
@@ -36,6 +36,8 @@ import (
 const (
 	defaultLongPollingTimeout = 30000 * time.Millisecond
 	QueueSize                 = 10240
+	// watchCenterDurable 配置发布事件的持久订阅名称，重启后从上次提交的位置继续通知
+	watchCenterDurable = "config-watch-center"
 )
 
 var (
@@ -191,7 +193,8 @@ func NewWatchCenter(cacheMgr cachetypes.CacheManager) (*watchCenter, error) {
 	}
 
 	var err error
-	wc.subCtx, err = eventhub.Subscribe(eventhub.ConfigFilePublishTopic, wc, eventhub.WithQueueSize(QueueSize))
+	wc.subCtx, err = eventhub.Subscribe(eventhub.ConfigFilePublishTopic, wc,
+		eventhub.WithQueueSize(QueueSize), eventhub.WithDurable(watchCenterDurable))
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +169,9 @@ var (
 		model.EventInstanceOnline:       {},
 		model.EventInstanceTurnHealth:   {},
 		model.EventInstanceTurnUnHealth: {},
+		// 健康检查保护状态变更
+		model.EventHealthCheckProtectionOpen:  {},
+		model.EventHealthCheckProtectionClose: {},
 	}
 )
 
@@ -0,0 +1,192 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+// EndpointConfig 事件推送的目标地址配置
+type EndpointConfig struct {
+	// Name 目标名称，同时作为磁盘缓冲的子目录名称
+	Name string `json:"name"`
+	// URL 接收事件的 HTTP 地址
+	URL string `json:"url"`
+	// Secret 非空时使用 HMAC-SHA256 对请求签名
+	Secret string `json:"secret"`
+	// Headers 额外携带的请求头
+	Headers map[string]string `json:"headers"`
+	// Timeout 单次请求超时时间
+	Timeout string `json:"timeout"`
+	// MaxRetries 单次推送失败后的最大重试次数
+	MaxRetries int `json:"maxRetries"`
+	// RetryBackoff 首次重试的等待时间，之后每次翻倍
+	RetryBackoff string `json:"retryBackoff"`
+	// MaxBackoff 重试等待时间的上限
+	MaxBackoff string `json:"maxBackoff"`
+	// Namespaces 只推送这些命名空间下的事件，支持通配，为空时不过滤
+	Namespaces []string `json:"namespaces"`
+	// Services 只推送这些服务的事件，支持通配，为空时不过滤
+	Services []string `json:"services"`
+
+	timeout      time.Duration
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+}
+
+// WebhookConfig webhook 事件插件配置
+type WebhookConfig struct {
+	// QueueSize 事件队列长度，队列满时事件交给落盘协程写入磁盘缓冲，不阻塞发布事件的协程
+	QueueSize int `json:"queueSize"`
+	// BatchSize 单次推送的最大事件数
+	BatchSize int `json:"batchSize"`
+	// FlushInterval 未攒满一批时的推送间隔
+	FlushInterval string `json:"flushInterval"`
+	// Events 需要推送的事件类型
+	Events []string `json:"events"`
+	// SpillPath 推送失败的事件落盘目录
+	SpillPath string `json:"spillPath"`
+	// SpillMaxSize 每个目标在磁盘上缓冲的最大字节数，超出后丢弃最旧的数据
+	SpillMaxSize int64 `json:"spillMaxSize"`
+	// Endpoints 推送目标
+	Endpoints []*EndpointConfig `json:"endpoints"`
+
+	flushInterval time.Duration
+	events        map[model.InstanceEventType]struct{}
+}
+
+// DefaultWebhookConfig 创建一个默认的 webhook 事件插件配置
+func DefaultWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		QueueSize:     1024,
+		BatchSize:     100,
+		FlushInterval: "5s",
+		Events: []string{
+			string(model.EventInstanceOnline),
+			string(model.EventInstanceOffline),
+			string(model.EventInstanceTurnHealth),
+			string(model.EventInstanceTurnUnHealth),
+			string(model.EventInstanceOpenIsolate),
+			string(model.EventInstanceCloseIsolate),
+			string(model.EventHealthCheckProtectionOpen),
+			string(model.EventHealthCheckProtectionClose),
+		},
+		SpillPath:    "./discover-event-webhook",
+		SpillMaxSize: 64 * 1024 * 1024,
+	}
+}
+
+// Validate 检查配置是否正确配置，并解析其中的时间配置
+func (c *WebhookConfig) Validate() error {
+	if c.QueueSize <= 0 {
+		return errors.New("QueueSize is <= 0")
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("BatchSize is <= 0")
+	}
+	if c.SpillPath == "" {
+		return errors.New("SpillPath is empty")
+	}
+	if len(c.Endpoints) == 0 {
+		return errors.New("Endpoints is empty")
+	}
+	interval, err := time.ParseDuration(c.FlushInterval)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("FlushInterval %q is invalid", c.FlushInterval)
+	}
+	c.flushInterval = interval
+	c.events = make(map[model.InstanceEventType]struct{}, len(c.Events))
+	for _, event := range c.Events {
+		c.events[model.InstanceEventType(event)] = struct{}{}
+	}
+
+	names := map[string]struct{}{}
+	for i, endpoint := range c.Endpoints {
+		if endpoint.Name == "" {
+			endpoint.Name = fmt.Sprintf("endpoint-%d", i)
+		}
+		if _, ok := names[endpoint.Name]; ok {
+			return fmt.Errorf("endpoint name %s is duplicate", endpoint.Name)
+		}
+		names[endpoint.Name] = struct{}{}
+		if err := endpoint.validate(); err != nil {
+			return fmt.Errorf("endpoint %s: %w", endpoint.Name, err)
+		}
+	}
+	return nil
+}
+
+func (e *EndpointConfig) validate() error {
+	target, err := url.Parse(e.URL)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("URL %q scheme must be http or https", e.URL)
+	}
+	if e.MaxRetries < 0 {
+		return errors.New("MaxRetries is < 0")
+	}
+	if e.timeout, err = parseDuration(e.Timeout, 5*time.Second); err != nil {
+		return err
+	}
+	if e.retryBackoff, err = parseDuration(e.RetryBackoff, time.Second); err != nil {
+		return err
+	}
+	if e.maxBackoff, err = parseDuration(e.MaxBackoff, 30*time.Second); err != nil {
+		return err
+	}
+	return nil
+}
+
+// match 判断事件是否满足目标的命名空间以及服务过滤条件
+func (e *EndpointConfig) match(namespace, service string) bool {
+	return matchAny(namespace, e.Namespaces) && matchAny(service, e.Services)
+}
+
+func matchAny(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" || utils.IsWildMatch(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", value)
+	}
+	return d, nil
+}
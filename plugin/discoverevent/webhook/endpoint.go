@@ -0,0 +1,208 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// HeaderTimestamp 请求签名时使用的时间戳，单位秒
+	HeaderTimestamp = "X-Polaris-Timestamp"
+	// HeaderSignature 请求签名，格式为 sha256=hex(hmac_sha256(secret, timestamp + "." + body))
+	HeaderSignature = "X-Polaris-Signature"
+)
+
+var (
+	// errRejected 目标地址明确拒绝了请求，重试也不会成功
+	errRejected = errors.New("webhook endpoint rejected events")
+)
+
+// endpoint 一个推送目标，拥有独立的发送协程，慢的目标不会影响其他目标
+type endpoint struct {
+	cfg    *EndpointConfig
+	client *http.Client
+	spill  *spillBuffer
+	queue  chan []*webhookEvent
+}
+
+func newEndpoint(cfg *EndpointConfig, spill *spillBuffer) *endpoint {
+	return &endpoint{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.timeout},
+		spill:  spill,
+		queue:  make(chan []*webhookEvent, endpointQueueSize),
+	}
+}
+
+// offer 提交一个批次给发送协程，发送协程积压时直接写入磁盘缓冲
+func (e *endpoint) offer(events []*webhookEvent) {
+	select {
+	case e.queue <- events:
+	default:
+		e.spillEvents(events)
+	}
+}
+
+func (e *endpoint) spillEvents(events []*webhookEvent) {
+	if err := e.spill.write(events); err != nil {
+		log.Errorf("[DiscoverEvent][Webhook] endpoint %s spill %d events fail: %v",
+			e.cfg.Name, len(events), err)
+	}
+}
+
+// run 发送协程，优先重放磁盘缓冲中的批次以保证事件顺序
+func (e *endpoint) run(ctx context.Context, replayInterval time.Duration) {
+	ticker := time.NewTicker(replayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case events := <-e.queue:
+			if !e.replay(ctx) {
+				e.spillEvents(events)
+				continue
+			}
+			if err := e.send(ctx, events); err != nil && !errors.Is(err, errRejected) {
+				e.spillEvents(events)
+			}
+		case <-ticker.C:
+			e.replay(ctx)
+		case <-ctx.Done():
+			for {
+				select {
+				case events := <-e.queue:
+					e.spillEvents(events)
+					continue
+				default:
+				}
+				return
+			}
+		}
+	}
+}
+
+// replay 按顺序重放磁盘缓冲中的批次，全部成功时返回 true
+func (e *endpoint) replay(ctx context.Context) bool {
+	for {
+		file, events, err := e.spill.oldest()
+		if err != nil {
+			log.Errorf("[DiscoverEvent][Webhook] endpoint %s read spill fail: %v", e.cfg.Name, err)
+			return false
+		}
+		if file == "" {
+			return true
+		}
+		if err := e.send(ctx, events); err != nil && !errors.Is(err, errRejected) {
+			return false
+		}
+		e.spill.remove(file)
+	}
+}
+
+// send 推送一个批次，失败时按照指数退避重试
+func (e *endpoint) send(ctx context.Context, events []*webhookEvent) error {
+	backoff := e.cfg.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := e.post(ctx, events)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errRejected) {
+			log.Errorf("[DiscoverEvent][Webhook] endpoint %s drop %d events: %v", e.cfg.Name, len(events), err)
+			return err
+		}
+		if attempt >= e.cfg.MaxRetries {
+			log.Errorf("[DiscoverEvent][Webhook] endpoint %s send %d events fail after %d retries: %v",
+				e.cfg.Name, len(events), attempt, err)
+			return err
+		}
+		log.Warnf("[DiscoverEvent][Webhook] endpoint %s send fail, retry after %v: %v", e.cfg.Name, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+		if backoff > e.cfg.maxBackoff {
+			backoff = e.cfg.maxBackoff
+		}
+	}
+}
+
+func (e *endpoint) post(ctx context.Context, events []*webhookEvent) error {
+	now := time.Now().Unix()
+	body, err := json.Marshal(&webhookPayload{
+		Server:    utils.LocalHost,
+		Timestamp: now,
+		Events:    events,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errRejected, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errRejected, err)
+	}
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	timestamp := strconv.FormatInt(now, 10)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if e.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+sign(e.cfg.Secret, timestamp, body))
+	}
+
+	rsp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(rsp.Body, 4096))
+	switch {
+	case rsp.StatusCode >= 200 && rsp.StatusCode < 300:
+		return nil
+	case rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode == http.StatusRequestTimeout ||
+		rsp.StatusCode >= 500:
+		return fmt.Errorf("webhook endpoint response status %d", rsp.StatusCode)
+	default:
+		return fmt.Errorf("%w: response status %d", errRejected, rsp.StatusCode)
+	}
+}
+
+// sign 计算请求签名
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
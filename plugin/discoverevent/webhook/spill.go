@@ -0,0 +1,158 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const spillFileSuffix = ".json"
+
+// spillBuffer 推送失败的事件批次按照时间顺序保存在磁盘上，每个批次一个文件
+type spillBuffer struct {
+	mu      sync.Mutex
+	dir     string
+	maxSize int64
+	seq     uint64
+}
+
+func newSpillBuffer(dir string, maxSize int64) (*spillBuffer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &spillBuffer{
+		dir:     dir,
+		maxSize: maxSize,
+	}, nil
+}
+
+// write 写入一个事件批次，超出容量时丢弃最旧的批次
+func (s *spillBuffer) write(events []*webhookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	name := fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, spillFileSuffix)
+	tmp := filepath.Join(s.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+	s.evictLocked()
+	return nil
+}
+
+// oldest 读取最早写入的批次，没有数据时返回空
+func (s *spillBuffer) oldest() (string, []*webhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, _, err := s.listLocked()
+	if err != nil || len(files) == 0 {
+		return "", nil, err
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		return "", nil, err
+	}
+	events := make([]*webhookEvent, 0, 8)
+	if err := json.Unmarshal(data, &events); err != nil {
+		// 损坏的文件无法重放，直接删除
+		log.Errorf("[DiscoverEvent][Webhook] drop broken spill file %s: %v", files[0], err)
+		_ = os.Remove(files[0])
+		return "", nil, err
+	}
+	return files[0], events, nil
+}
+
+// remove 删除已经推送成功的批次
+func (s *spillBuffer) remove(file string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		log.Errorf("[DiscoverEvent][Webhook] remove spill file %s: %v", file, err)
+	}
+}
+
+// size 当前缓冲的批次数量
+func (s *spillBuffer) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, _, _ := s.listLocked()
+	return len(files)
+}
+
+func (s *spillBuffer) evictLocked() {
+	if s.maxSize <= 0 {
+		return
+	}
+	files, sizes, err := s.listLocked()
+	if err != nil {
+		return
+	}
+	var total int64
+	for i := range sizes {
+		total += sizes[i]
+	}
+	for i := 0; i < len(files) && total > s.maxSize; i++ {
+		log.Warnf("[DiscoverEvent][Webhook] spill buffer %s full, drop %s", s.dir, files[i])
+		_ = os.Remove(files[i])
+		total -= sizes[i]
+	}
+}
+
+func (s *spillBuffer) listLocked() ([]string, []int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), spillFileSuffix) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	files := make([]string, 0, len(names))
+	sizes := make([]int64, 0, len(names))
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(s.dir, name))
+		if err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(s.dir, name))
+		sizes = append(sizes, info.Size())
+	}
+	return files, sizes, nil
+}
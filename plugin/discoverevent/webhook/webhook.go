@@ -0,0 +1,272 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	commonlog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// PluginName plugin name
+	PluginName = "discoverEventWebhook"
+
+	endpointQueueSize = 16
+	// spillQueueSize 事件队列满时等待写入磁盘缓冲的事件队列长度
+	spillQueueSize = 1024
+)
+
+var log = commonlog.RegisterScope(PluginName, "", 0)
+
+func init() {
+	d := &discoverEventWebhook{}
+	plugin.RegisterPlugin(d.Name(), d)
+}
+
+// webhookEvent 推送给目标地址的单个事件
+type webhookEvent struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Namespace  string            `json:"namespace"`
+	Service    string            `json:"service"`
+	InstanceID string            `json:"instanceId"`
+	Host       string            `json:"host"`
+	Port       uint32            `json:"port"`
+	Healthy    bool              `json:"healthy"`
+	Isolate    bool              `json:"isolate"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	CreateTime time.Time         `json:"createTime"`
+}
+
+// webhookPayload 一次推送的请求体
+type webhookPayload struct {
+	Server    string          `json:"server"`
+	Timestamp int64           `json:"timestamp"`
+	Events    []*webhookEvent `json:"events"`
+}
+
+func newWebhookEvent(event model.InstanceEvent) *webhookEvent {
+	return &webhookEvent{
+		ID:         event.Id,
+		Type:       string(event.EType),
+		Namespace:  event.Namespace,
+		Service:    event.Service,
+		InstanceID: event.Instance.GetId().GetValue(),
+		Host:       event.Instance.GetHost().GetValue(),
+		Port:       event.Instance.GetPort().GetValue(),
+		Healthy:    event.Instance.GetHealthy().GetValue(),
+		Isolate:    event.Instance.GetIsolate().GetValue(),
+		Metadata:   event.MetaData,
+		CreateTime: event.CreateTime,
+	}
+}
+
+type discoverEventWebhook struct {
+	cfg     *WebhookConfig
+	eventCh chan model.InstanceEvent
+	// spillCh 事件队列满时交给落盘协程的事件，磁盘写入不占用发布事件的协程
+	spillCh chan model.InstanceEvent
+	// dropped 落盘队列也满时丢弃的事件数
+	dropped   atomic.Int64
+	endpoints []*endpoint
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// Name 插件名称
+// @return string 返回插件名称
+func (w *discoverEventWebhook) Name() string {
+	return PluginName
+}
+
+// Initialize 根据配置文件进行初始化插件 discoverEventWebhook
+// @param conf 配置文件内容
+// @return error 初始化失败，返回 error 信息
+func (w *discoverEventWebhook) Initialize(conf *plugin.ConfigEntry) error {
+	contentBytes, err := json.Marshal(conf.Option)
+	if err != nil {
+		return err
+	}
+	config := DefaultWebhookConfig()
+	if err := json.Unmarshal(contentBytes, config); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	w.cfg = config
+	w.eventCh = make(chan model.InstanceEvent, config.QueueSize)
+	w.spillCh = make(chan model.InstanceEvent, spillQueueSize)
+	w.endpoints = make([]*endpoint, 0, len(config.Endpoints))
+	for _, item := range config.Endpoints {
+		spill, err := newSpillBuffer(filepath.Join(config.SpillPath, item.Name), config.SpillMaxSize)
+		if err != nil {
+			return err
+		}
+		w.endpoints = append(w.endpoints, newEndpoint(item, spill))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	for i := range w.endpoints {
+		w.wg.Add(1)
+		go func(e *endpoint) {
+			defer w.wg.Done()
+			e.run(ctx, config.flushInterval)
+		}(w.endpoints[i])
+	}
+	w.wg.Add(2)
+	go func() {
+		defer w.wg.Done()
+		w.Run(ctx)
+	}()
+	go func() {
+		defer w.wg.Done()
+		w.runSpill(ctx)
+	}()
+	return nil
+}
+
+// Destroy 执行插件销毁，尚未推送的事件会写入磁盘缓冲，重启后继续推送
+func (w *discoverEventWebhook) Destroy() error {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+	return nil
+}
+
+// PublishEvent 发布一个服务事件
+func (w *discoverEventWebhook) PublishEvent(event model.InstanceEvent) {
+	if _, ok := w.cfg.events[event.EType]; !ok {
+		return
+	}
+	select {
+	case w.eventCh <- event:
+		return
+	default:
+	}
+	// 队列已满时交给落盘协程写入磁盘缓冲，避免磁盘写入阻塞注册发现的主流程
+	select {
+	case w.spillCh <- event:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// runSpill 落盘协程，将事件队列满时的事件写入磁盘缓冲，并定期输出丢弃的事件数
+func (w *discoverEventWebhook) runSpill(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-w.spillCh:
+			w.dispatch([]*webhookEvent{newWebhookEvent(event)}, true)
+		case <-ticker.C:
+			w.reportDropped()
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-w.spillCh:
+					w.dispatch([]*webhookEvent{newWebhookEvent(event)}, true)
+					continue
+				default:
+				}
+				break
+			}
+			w.reportDropped()
+			return
+		}
+	}
+}
+
+func (w *discoverEventWebhook) reportDropped() {
+	if dropped := w.dropped.Swap(0); dropped > 0 {
+		log.Errorf("[DiscoverEvent][Webhook] event queue and spill queue full, drop %d events", dropped)
+	}
+}
+
+// Run 执行主逻辑，按照批次大小或者时间间隔将事件分发给各个推送目标
+func (w *discoverEventWebhook) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*webhookEvent, 0, w.cfg.BatchSize)
+	flush := func(spill bool) {
+		if len(batch) == 0 {
+			return
+		}
+		w.dispatch(batch, spill)
+		batch = make([]*webhookEvent, 0, w.cfg.BatchSize)
+	}
+	for {
+		select {
+		case event := <-w.eventCh:
+			// 确保事件是顺序的
+			event.CreateTime = time.Now()
+			batch = append(batch, newWebhookEvent(event))
+			if len(batch) >= w.cfg.BatchSize {
+				flush(false)
+			}
+		case <-ticker.C:
+			flush(false)
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-w.eventCh:
+					event.CreateTime = time.Now()
+					batch = append(batch, newWebhookEvent(event))
+					continue
+				default:
+				}
+				break
+			}
+			flush(true)
+			return
+		}
+	}
+}
+
+// dispatch 按照推送目标的过滤条件拆分批次，spill 为 true 时直接写入磁盘缓冲
+func (w *discoverEventWebhook) dispatch(events []*webhookEvent, spill bool) {
+	for _, e := range w.endpoints {
+		matched := make([]*webhookEvent, 0, len(events))
+		for _, event := range events {
+			if e.cfg.match(event.Namespace, event.Service) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		if spill {
+			e.spillEvents(matched)
+			continue
+		}
+		e.offer(matched)
+	}
+}
@@ -0,0 +1,220 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+type mockReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	payloads []*webhookPayload
+	fail     atomic.Bool
+}
+
+func (m *mockReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.fail.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	if m.secret != "" {
+		expect := "sha256=" + sign(m.secret, r.Header.Get(HeaderTimestamp), body)
+		if r.Header.Get(HeaderSignature) != expect {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	payload := &webhookPayload{}
+	if err := json.Unmarshal(body, payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payloads = append(m.payloads, payload)
+}
+
+func (m *mockReceiver) events() []*webhookEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ret := make([]*webhookEvent, 0, 8)
+	for _, payload := range m.payloads {
+		ret = append(ret, payload.Events...)
+	}
+	return ret
+}
+
+func newTestEvent(namespace, service string, etype model.InstanceEventType) model.InstanceEvent {
+	return model.InstanceEvent{
+		Id:        "event-id",
+		Namespace: namespace,
+		Service:   service,
+		Instance: &apiservice.Instance{
+			Id:   &wrappers.StringValue{Value: "ins-1"},
+			Host: &wrappers.StringValue{Value: "127.0.0.1"},
+			Port: &wrappers.UInt32Value{Value: 8080},
+		},
+		EType: etype,
+	}
+}
+
+func Test_discoverEventWebhook_Publish(t *testing.T) {
+	receiver := &mockReceiver{secret: "polaris"}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	w := &discoverEventWebhook{}
+	err := w.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"batchSize":     2,
+			"flushInterval": "50ms",
+			"spillPath":     t.TempDir(),
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"name":       "oncall",
+					"url":        server.URL,
+					"secret":     "polaris",
+					"namespaces": []interface{}{"prod*"},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	defer w.Destroy()
+
+	w.PublishEvent(newTestEvent("production", "svc", model.EventInstanceTurnUnHealth))
+	// 非订阅的事件类型以及不匹配过滤条件的事件不会推送
+	w.PublishEvent(newTestEvent("production", "svc", model.EventInstanceSendHeartbeat))
+	w.PublishEvent(newTestEvent("test", "svc", model.EventInstanceOnline))
+	w.PublishEvent(newTestEvent("production", "svc", model.EventInstanceOpenIsolate))
+
+	assert.Eventually(t, func() bool { return len(receiver.events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	events := receiver.events()
+	assert.Equal(t, string(model.EventInstanceTurnUnHealth), events[0].Type)
+	assert.Equal(t, string(model.EventInstanceOpenIsolate), events[1].Type)
+	assert.Equal(t, "ins-1", events[0].InstanceID)
+}
+
+func Test_discoverEventWebhook_SpillAndReplay(t *testing.T) {
+	receiver := &mockReceiver{}
+	receiver.fail.Store(true)
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	w := &discoverEventWebhook{}
+	err := w.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"batchSize":     1,
+			"flushInterval": "50ms",
+			"spillPath":     t.TempDir(),
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"url":          server.URL,
+					"maxRetries":   1,
+					"retryBackoff": "10ms",
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	defer w.Destroy()
+
+	w.PublishEvent(newTestEvent("default", "svc", model.EventInstanceOnline))
+	w.PublishEvent(newTestEvent("default", "svc", model.EventInstanceTurnUnHealth))
+	spill := w.endpoints[0].spill
+	assert.Eventually(t, func() bool { return spill.size() == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, receiver.events())
+
+	// 目标恢复后按顺序重放磁盘中的事件
+	receiver.fail.Store(false)
+	assert.Eventually(t, func() bool { return len(receiver.events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	events := receiver.events()
+	assert.Equal(t, string(model.EventInstanceOnline), events[0].Type)
+	assert.Equal(t, string(model.EventInstanceTurnUnHealth), events[1].Type)
+	assert.Equal(t, 0, spill.size())
+}
+
+func Test_discoverEventWebhook_PublishQueueFull(t *testing.T) {
+	spill, err := newSpillBuffer(t.TempDir(), 0)
+	assert.NoError(t, err)
+	config := DefaultWebhookConfig()
+	config.Endpoints = []*EndpointConfig{{Name: "oncall", URL: "http://127.0.0.1:1"}}
+	assert.NoError(t, config.Validate())
+	w := &discoverEventWebhook{
+		cfg:       config,
+		eventCh:   make(chan model.InstanceEvent),
+		spillCh:   make(chan model.InstanceEvent, 1),
+		endpoints: []*endpoint{newEndpoint(config.Endpoints[0], spill)},
+	}
+
+	// 事件队列满时发布事件的协程不写磁盘，落盘队列也满时丢弃事件
+	w.PublishEvent(newTestEvent("default", "svc", model.EventInstanceOnline))
+	w.PublishEvent(newTestEvent("default", "svc", model.EventInstanceOffline))
+	assert.Len(t, w.spillCh, 1)
+	assert.Equal(t, int64(1), w.dropped.Load())
+	assert.Equal(t, 0, spill.size())
+
+	// 落盘协程将事件写入磁盘缓冲
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.runSpill(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return spill.size() == 1 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, int64(0), w.dropped.Load())
+}
+
+func TestWebhookConfig_Validate(t *testing.T) {
+	cfg := DefaultWebhookConfig()
+	assert.Error(t, cfg.Validate())
+
+	cfg.Endpoints = []*EndpointConfig{{URL: "ftp://127.0.0.1"}}
+	assert.Error(t, cfg.Validate())
+
+	cfg.Endpoints = []*EndpointConfig{{URL: "http://127.0.0.1", Timeout: "1s"}}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "endpoint-0", cfg.Endpoints[0].Name)
+	assert.Equal(t, time.Second, cfg.Endpoints[0].timeout)
+
+	assert.True(t, cfg.Endpoints[0].match("default", "svc"))
+	cfg.Endpoints[0].Services = []string{"order-*"}
+	assert.True(t, cfg.Endpoints[0].match("default", "order-api"))
+	assert.False(t, cfg.Endpoints[0].match("default", "user-api"))
+}
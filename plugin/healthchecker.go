@@ -38,6 +38,8 @@ type CheckRequest struct {
 	QueryRequest
 	ExpireDurationSec uint32
 	CurTimeSec        func() int64
+	// Metadata instance metadata, carries the probe settings for the probe checker
+	Metadata map[string]string
 }
 
 // CheckResponse check heartbeat response
@@ -85,10 +87,14 @@ type HealthCheckType int32
 
 const (
 	HealthCheckerHeartbeat HealthCheckType = iota + 1
+	// HealthCheckerProbe server side active probing, instance is checked by HTTP/TCP/gRPC probe
+	HealthCheckerProbe
 )
 
 var (
-	healthCheckOnce = &sync.Once{}
+	// healthCheckOnces each health checker plugin is initialized only once
+	healthCheckOnces = map[string]*sync.Once{}
+	healthCheckLock  = &sync.Mutex{}
 )
 
 // HealthChecker health checker plugin interface
@@ -114,6 +120,27 @@ type HealthChecker interface {
 	DebugHandlers() []model.DebugHandler
 }
 
+const (
+	// BeatRecordSnapshotKey grpc metadata key, marks the request that fetches all the heartbeat records
+	BeatRecordSnapshotKey = "internal-beat-snapshot"
+	// BeatRecordSnapshotAfterKey grpc metadata key, the snapshot page only contains instance ids
+	// greater than this value
+	BeatRecordSnapshotAfterKey = "internal-beat-snapshot-after"
+	// BeatRecordSnapshotLimitKey grpc metadata key, the max records count of one snapshot page
+	BeatRecordSnapshotLimitKey = "internal-beat-snapshot-limit"
+	// MaxBeatRecordSnapshotLimit the max records count of one snapshot page, larger limits are clamped to it
+	MaxBeatRecordSnapshotLimit = 5000
+)
+
+// BeatRecordExporter optional interface of health checker, exports all the heartbeat records
+// so that other nodes can keep a replica of them
+type BeatRecordExporter interface {
+	// ExportRecords export one page of the heartbeat records ordered by instance id, only the
+	// instance ids greater than after are returned, limit <= 0 or larger than MaxBeatRecordSnapshotLimit
+	// means MaxBeatRecordSnapshotLimit, key is instance id
+	ExportRecords(ctx context.Context, after string, limit int) (map[string]*QueryResponse, error)
+}
+
 // GetHealthChecker get the health checker by name
 func GetHealthChecker(name string, cfg *ConfigEntry) HealthChecker {
 	plugin, exist := pluginSet[name]
@@ -121,7 +148,15 @@ func GetHealthChecker(name string, cfg *ConfigEntry) HealthChecker {
 		return nil
 	}
 
-	healthCheckOnce.Do(func() {
+	healthCheckLock.Lock()
+	initOnce, ok := healthCheckOnces[name]
+	if !ok {
+		initOnce = &sync.Once{}
+		healthCheckOnces[name] = initOnce
+	}
+	healthCheckLock.Unlock()
+
+	initOnce.Do(func() {
 		if err := plugin.Initialize(cfg); err != nil {
 			log.Errorf("HealthChecker plugin init err: %s", err.Error())
 			os.Exit(-1)
@@ -61,6 +61,8 @@ type (
 	RecordDelter func(req *apiservice.DelHeartbeatsRequest) error
 	// RecordGetter beat record getter
 	RecordGetter func(req *apiservice.GetHeartbeatsRequest) (*apiservice.GetHeartbeatsResponse, error)
+	// RecordSnapshoter beat record snapshoter, fetch one page of records whose key greater than after
+	RecordSnapshoter func(after string, limit int) (*apiservice.GetHeartbeatsResponse, error)
 	// BeatRecordCache Heartbeat data cache
 	BeatRecordCache interface {
 		// Get get records
@@ -71,7 +73,9 @@ type (
 		Del(keys ...string) error
 		// Clean .
 		Clean()
-		// Snapshot
+		// Replace 使用给定的心跳数据整体替换当前的全部心跳数据
+		Replace(records ...WriteBeatRecord) error
+		// Snapshot 获取全量心跳数据, 获取失败时返回 nil
 		Snapshot() map[string]*ReadBeatRecord
 		// Ping
 		Ping() error
@@ -153,6 +157,20 @@ func (lc *LocalBeatRecordCache) Clean() {
 	})
 }
 
+func (lc *LocalBeatRecordCache) Replace(records ...WriteBeatRecord) error {
+	// 先在新的 SegmentMap 中构建好数据，再整体替换，避免读请求看到数据被清空的中间状态
+	beatCache := utils.NewSegmentMap[string, RecordValue](int(lc.soltNum), func(k string) int {
+		return lc.hashFunc(k)
+	})
+	for i := range records {
+		beatCache.Put(records[i].Key, records[i].Record)
+	}
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	lc.beatCache = beatCache
+	return nil
+}
+
 func (lc *LocalBeatRecordCache) Snapshot() map[string]*ReadBeatRecord {
 	lc.lock.RLock()
 	defer lc.lock.RUnlock()
@@ -160,6 +178,7 @@ func (lc *LocalBeatRecordCache) Snapshot() map[string]*ReadBeatRecord {
 	lc.beatCache.Range(func(k string, v RecordValue) {
 		ret[k] = &ReadBeatRecord{
 			Record: v,
+			Exist:  true,
 		}
 	})
 	return ret
@@ -167,21 +186,26 @@ func (lc *LocalBeatRecordCache) Snapshot() map[string]*ReadBeatRecord {
 
 // newRemoteBeatRecordCache
 func newRemoteBeatRecordCache(getter RecordGetter, saver RecordSaver,
-	delter RecordDelter, ping func() error) BeatRecordCache {
+	delter RecordDelter, snapshoter RecordSnapshoter, ping func() error) BeatRecordCache {
 	return &RemoteBeatRecordCache{
-		getter: getter,
-		saver:  saver,
-		delter: delter,
-		ping:   ping,
+		getter:           getter,
+		saver:            saver,
+		delter:           delter,
+		snapshoter:       snapshoter,
+		ping:             ping,
+		snapshotPageSize: DefaultSnapshotPageSize,
 	}
 }
 
 // RemoteBeatRecordCache
 type RemoteBeatRecordCache struct {
-	saver  RecordSaver
-	delter RecordDelter
-	getter RecordGetter
-	ping   func() error
+	saver      RecordSaver
+	delter     RecordDelter
+	getter     RecordGetter
+	snapshoter RecordSnapshoter
+	ping       func() error
+	// snapshotPageSize 分页拉取全量心跳数据时每一页的大小
+	snapshotPageSize int
 }
 
 func (rc *RemoteBeatRecordCache) Ping() error {
@@ -241,6 +265,43 @@ func (lc *RemoteBeatRecordCache) Clean() {
 	// do nothing
 }
 
-func (lc *RemoteBeatRecordCache) Snapshot() map[string]*ReadBeatRecord {
-	return map[string]*ReadBeatRecord{}
+func (lc *RemoteBeatRecordCache) Replace(records ...WriteBeatRecord) error {
+	// do nothing
+	return nil
+}
+
+func (rc *RemoteBeatRecordCache) Snapshot() map[string]*ReadBeatRecord {
+	if rc.snapshoter == nil {
+		return map[string]*ReadBeatRecord{}
+	}
+	ret := make(map[string]*ReadBeatRecord)
+	after := ""
+	for {
+		resp, err := rc.snapshoter(after, rc.snapshotPageSize)
+		if err != nil {
+			return nil
+		}
+		records := resp.GetRecords()
+		next := after
+		for i := range records {
+			record := records[i]
+			if record.GetInstanceId() > next {
+				next = record.GetInstanceId()
+			}
+			if !record.GetExist() {
+				continue
+			}
+			ret[record.GetInstanceId()] = &ReadBeatRecord{
+				Record: RecordValue{
+					CurTimeSec: record.GetLastHeartbeatSec(),
+				},
+				Exist: true,
+			}
+		}
+		// 最后一页或者游标没有前进（对端不支持分页），结束拉取
+		if len(records) < rc.snapshotPageSize || next == after {
+			return ret
+		}
+		after = next
+	}
 }
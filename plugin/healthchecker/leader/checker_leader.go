@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"runtime"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -67,6 +68,8 @@ const (
 var (
 	// DefaultSoltNum default soltNum of LocalBeatRecordCache
 	DefaultSoltNum = int32(runtime.GOMAXPROCS(0) * 16)
+	// DefaultSnapshotPageSize default page size when fetching all beat records from leader
+	DefaultSnapshotPageSize = 2000
 	// streamNum
 	streamNum = runtime.GOMAXPROCS(0)
 )
@@ -74,6 +77,7 @@ var (
 var (
 	ErrorRedirectOnlyOnce    = errors.New("redirect request only once")
 	ErrorLeaderNotInitialize = errors.New("leader checker uninitialize")
+	ErrorNotLeader           = errors.New("self is not leader")
 )
 
 // LeaderHealthChecker Leader~Follower 节点心跳健康检查
@@ -86,12 +90,19 @@ var (
 //   - 非 Leader 节点
 //     a. 心跳写请求通过 gRPC 长连接直接发给 Leader 节点
 //     b. 心跳读请求通过 gRPC 长连接直接发给 Leader 节点，Leader 节点返回心跳时间戳信息
+//     c. 开启 replicate 后，定期从 Leader 节点拉取全量心跳数据作为本地副本，当选 Leader 时直接使用
 type LeaderHealthChecker struct {
 	initialize int32
 	// leaderChangeTimeSec last peer list start refresh occur timestamp
 	leaderChangeTimeSec int64
 	// suspendTimeSec healthcheck last suspend timestamp
 	suspendTimeSec int64
+	// replicaSyncTimeMilli follower last sync beat records replica from leader timestamp
+	replicaSyncTimeMilli int64
+	// warmGraceSec 携带心跳数据副本当选 leader 后的保护时长，为 0 时表示使用完整的心跳周期
+	warmGraceSec int64
+	// replicaCancel stop sync beat records replica
+	replicaCancel context.CancelFunc
 	// conf leaderChecker config
 	conf *Config
 	// lock keeps safe to change leader info
@@ -162,6 +173,9 @@ func (c *LeaderHealthChecker) OnEvent(ctx context.Context, i interface{}) error
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	// 停止副本同步之前，先判断本地的心跳数据副本是否足够新
+	replicaLagSec, warm := c.replicaLagSec()
+	c.stopReplicate()
 	atomic.AddInt64(&c.leaderVersion, 1)
 	atomic.StoreInt32(&c.initialize, uninitializeSignal)
 	curLeaderVersion := atomic.LoadInt64(&c.leaderVersion)
@@ -169,12 +183,131 @@ func (c *LeaderHealthChecker) OnEvent(ctx context.Context, i interface{}) error
 		c.becomeLeader()
 	} else {
 		c.becomeFollower(e, curLeaderVersion)
-		c.self.Storage().Clean()
+		c.cleanSelfStorage()
+		c.startReplicate(curLeaderVersion)
+	}
+	if e.Leader && warm {
+		// 本地已经有 leader 的心跳数据副本，只需要等待副本落后的这段时间即可
+		plog.Info("[HealthCheck][Leader] become leader with beat records replica",
+			zap.Int64("replicaLagSec", replicaLagSec))
+		atomic.StoreInt64(&c.warmGraceSec, replicaLagSec)
+	} else {
+		atomic.StoreInt64(&c.warmGraceSec, 0)
 	}
 	c.refreshLeaderChangeTimeSec()
 	return nil
 }
 
+func (c *LeaderHealthChecker) cleanSelfStorage() {
+	c.self.Storage().Clean()
+	atomic.StoreInt64(&c.replicaSyncTimeMilli, 0)
+}
+
+// replicaLagSec 返回本地心跳数据副本落后 leader 的时长，以及副本是否可以直接使用
+func (c *LeaderHealthChecker) replicaLagSec() (int64, bool) {
+	if c.conf == nil || !c.conf.Replicate || c.isLeader() {
+		return 0, false
+	}
+	syncTimeMilli := atomic.LoadInt64(&c.replicaSyncTimeMilli)
+	if syncTimeMilli <= 0 {
+		return 0, false
+	}
+	lagMilli := commontime.CurrentMillisecond() - syncTimeMilli
+	if lagMilli < 0 {
+		lagMilli = 0
+	}
+	// 超过两个同步周期没有同步成功，认为副本已经不可信
+	if lagMilli > 2*c.conf.ReplicateInterval.Milliseconds() {
+		return 0, false
+	}
+	lagSec := (lagMilli + 999) / 1000
+	if lagSec < 1 {
+		lagSec = 1
+	}
+	return lagSec, true
+}
+
+// startReplicate follower 定期从 leader 拉取全量心跳数据作为副本。采用轮询全量快照而不是同步增量，
+// 实现简单且不依赖 leader 维护每个 follower 的同步位点，代价是副本最多落后两个同步周期
+func (c *LeaderHealthChecker) startReplicate(leaderVersion int64) {
+	if c.conf == nil || !c.conf.Replicate {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.replicaCancel = cancel
+	go c.runReplicate(ctx, leaderVersion)
+}
+
+func (c *LeaderHealthChecker) stopReplicate() {
+	if c.replicaCancel != nil {
+		c.replicaCancel()
+		c.replicaCancel = nil
+	}
+}
+
+func (c *LeaderHealthChecker) runReplicate(ctx context.Context, leaderVersion int64) {
+	ticker := time.NewTicker(c.conf.ReplicateInterval)
+	defer ticker.Stop()
+	for {
+		c.syncReplica(leaderVersion)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *LeaderHealthChecker) syncReplica(leaderVersion int64) {
+	remote := c.followingLeader(leaderVersion)
+	if remote == nil {
+		return
+	}
+	// 拉取全量心跳数据是远程调用，不能持有 c.lock，避免阻塞 leader 切换以及心跳读写
+	records := remote.Storage().Snapshot()
+	if records == nil {
+		plog.Warn("[HealthCheck][Leader] sync beat records replica from leader fail",
+			zap.String("leader", remote.Host()))
+		return
+	}
+	writes := make([]WriteBeatRecord, 0, len(records))
+	for key, record := range records {
+		writes = append(writes, WriteBeatRecord{
+			Record: RecordValue{
+				Server:     utils.LocalHost,
+				CurTimeSec: record.Record.CurTimeSec,
+				Count:      record.Record.Count,
+			},
+			Key: key,
+		})
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	// 拉取期间 leader 已经发生变化，本次同步作废
+	if leaderVersion != atomic.LoadInt64(&c.leaderVersion) || c.isLeader() || c.remote != remote {
+		return
+	}
+	if err := c.self.Storage().Replace(writes...); err != nil {
+		plog.Error("[HealthCheck][Leader] save beat records replica", zap.Error(err))
+		return
+	}
+	atomic.StoreInt64(&c.replicaSyncTimeMilli, commontime.CurrentMillisecond())
+	if log.DebugEnabled() {
+		log.Debugf("[HealthCheck][Leader] sync beat records replica from %s, size %d", remote.Host(), len(writes))
+	}
+}
+
+// followingLeader 返回当前跟随的 leader 节点，leader 已经发生变化或者自己就是 leader 时返回 nil
+func (c *LeaderHealthChecker) followingLeader(leaderVersion int64) Peer {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if leaderVersion != atomic.LoadInt64(&c.leaderVersion) || c.isLeader() || c.remote == nil {
+		return nil
+	}
+	return c.remote
+}
+
 func (c *LeaderHealthChecker) becomeLeader() {
 	if c.remote != nil {
 		plog.Info("[HealthCheck][Leader] become leader and close old leader",
@@ -239,6 +372,9 @@ func (c *LeaderHealthChecker) becomeFollower(e store.LeaderChangeEvent, leaderVe
 // Destroy .
 func (c *LeaderHealthChecker) Destroy() error {
 	c.subCtx.Cancel()
+	c.lock.Lock()
+	c.stopReplicate()
+	c.lock.Unlock()
 	return nil
 }
 
@@ -396,6 +532,44 @@ func (c *LeaderHealthChecker) Query(ctx context.Context, request *plugin.QueryRe
 	}, nil
 }
 
+// ExportRecords 按照实例 ID 顺序分页导出 leader 节点的心跳数据，用于 follower 节点同步心跳数据副本
+func (c *LeaderHealthChecker) ExportRecords(ctx context.Context, after string,
+	limit int) (map[string]*plugin.QueryResponse, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.isInitialize() {
+		return nil, ErrorLeaderNotInitialize
+	}
+	if !c.isLeader() {
+		return nil, ErrorNotLeader
+	}
+	records := c.self.Storage().Snapshot()
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		if key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if limit <= 0 || limit > plugin.MaxBeatRecordSnapshotLimit {
+		limit = plugin.MaxBeatRecordSnapshotLimit
+	}
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	ret := make(map[string]*plugin.QueryResponse, len(keys))
+	for _, key := range keys {
+		record := records[key]
+		ret[key] = &plugin.QueryResponse{
+			Server:           c.self.Host(),
+			LastHeartbeatSec: record.Record.CurTimeSec,
+			Count:            record.Record.Count,
+			Exists:           true,
+		}
+	}
+	return ret, nil
+}
+
 // Delete delete record by key
 func (c *LeaderHealthChecker) Delete(ctx context.Context, key string) error {
 	if !c.isLeader() && isSendFromPeer(ctx) {
@@ -450,9 +624,14 @@ func (c *LeaderHealthChecker) skipCheck(key string, expireDurationSec int64) boo
 	// 当 T1 时刻出现 Leader 节点切换，到 T2 时刻 Leader 节点切换成，在这期间，可能会出现以下情况
 	// case 1: T1~T2 时刻不存在 Leader
 	// case 2: T1～T2 时刻存在多个 Leader
+	// 如果当选 leader 时本地已有心跳数据副本，则只需要等待副本落后的时长
 	leaderChangeTimeSec := c.LeaderChangeTimeSec()
+	graceSec := expireDurationSec
+	if warmGraceSec := atomic.LoadInt64(&c.warmGraceSec); warmGraceSec > 0 && warmGraceSec < graceSec {
+		graceSec = warmGraceSec
+	}
 	if leaderChangeTimeSec > 0 && localCurTimeSec >= leaderChangeTimeSec &&
-		localCurTimeSec-leaderChangeTimeSec < expireDurationSec {
+		localCurTimeSec-leaderChangeTimeSec < graceSec {
 		log.Infof("[Health Check][Leader] health check peers on refresh, "+
 			"refreshPeerTimeSec is %d, localCurTimeSec is %d, expireDurationSec is %d, id %s",
 			suspendTimeSec, localCurTimeSec, expireDurationSec, key)
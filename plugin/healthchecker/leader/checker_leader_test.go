@@ -28,6 +28,7 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/polarismesh/polaris/common/eventhub"
+	commonhash "github.com/polarismesh/polaris/common/hash"
 	commontime "github.com/polarismesh/polaris/common/time"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/plugin"
@@ -193,6 +194,140 @@ func TestLeaderHealthChecker_Switch(t *testing.T) {
 	})
 }
 
+// TestLeaderHealthChecker_Replicate 测试 follower 同步心跳数据副本后当选 leader
+func TestLeaderHealthChecker_Replicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	eventhub.InitEventHub()
+	t.Cleanup(func() {
+		ctrl.Finish()
+	})
+	mockStore := mock.NewMockStore(ctrl)
+	mockStore.EXPECT().StartLeaderElection(gomock.Any()).Return(nil).AnyTimes()
+
+	checker := &LeaderHealthChecker{
+		self: NewLocalPeerFunc(),
+		s:    mockStore,
+	}
+	err := checker.Initialize(&plugin.ConfigEntry{
+		Option: map[string]interface{}{
+			"replicate":         true,
+			"replicateInterval": "100ms",
+		},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = checker.Destroy()
+	})
+
+	mockInstanceId := utils.NewUUID()
+	mockBeatTimeSec := commontime.CurrentMillisecond() / 1000
+	leaderCache := newLocalBeatRecordCache(1, commonhash.Fnv32)
+	err = leaderCache.Put(WriteBeatRecord{
+		Record: RecordValue{
+			CurTimeSec: mockBeatTimeSec,
+		},
+		Key: mockInstanceId,
+	})
+	assert.NoError(t, err)
+
+	oldNewRemoteFunc := NewRemotePeerFunc
+	t.Cleanup(func() {
+		NewRemotePeerFunc = oldNewRemoteFunc
+	})
+	NewRemotePeerFunc = func() Peer {
+		return &replicaMockPeer{cache: leaderCache}
+	}
+
+	t.Run("follower-sync-replica", func(t *testing.T) {
+		checker.OnEvent(context.Background(), store.LeaderChangeEvent{
+			Key:        electionKey,
+			Leader:     false,
+			LeaderHost: "127.0.0.1",
+		})
+		assert.False(t, checker.isLeader())
+
+		assert.Eventually(t, func() bool {
+			return atomic.LoadInt64(&checker.replicaSyncTimeMilli) > 0
+		}, 5*time.Second, 50*time.Millisecond)
+
+		ret, err := checker.self.Storage().Get(mockInstanceId)
+		assert.NoError(t, err)
+		assert.True(t, ret[mockInstanceId].Exist)
+		assert.Equal(t, mockBeatTimeSec, ret[mockInstanceId].Record.CurTimeSec)
+	})
+
+	t.Run("become-leader-with-replica", func(t *testing.T) {
+		checker.OnEvent(context.Background(), store.LeaderChangeEvent{
+			Key:        electionKey,
+			Leader:     true,
+			LeaderHost: utils.LocalHost,
+		})
+		assert.True(t, checker.isLeader())
+
+		rsp, err := checker.Query(context.Background(), &plugin.QueryRequest{
+			InstanceId: mockInstanceId,
+		})
+		assert.NoError(t, err)
+		assert.True(t, rsp.Exists)
+		assert.Equal(t, mockBeatTimeSec, rsp.LastHeartbeatSec)
+
+		records, err := checker.ExportRecords(context.Background(), "", 0)
+		assert.NoError(t, err)
+		assert.True(t, records[mockInstanceId].Exists)
+
+		records, err = checker.ExportRecords(context.Background(), mockInstanceId, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+
+		// 不再需要等待一个完整的心跳周期
+		time.Sleep(2 * time.Second)
+		assert.False(t, checker.skipCheck(mockInstanceId, 15))
+	})
+
+	t.Run("become-leader-without-replica", func(t *testing.T) {
+		checker.OnEvent(context.Background(), store.LeaderChangeEvent{
+			Key:        electionKey,
+			Leader:     true,
+			LeaderHost: utils.LocalHost,
+		})
+		assert.True(t, checker.skipCheck(mockInstanceId, 15))
+	})
+}
+
+// TestLeaderHealthChecker_ReplicaLag follower 轮询全量快照，副本最多落后两个同步周期
+func TestLeaderHealthChecker_ReplicaLag(t *testing.T) {
+	checker := &LeaderHealthChecker{
+		conf: &Config{Replicate: true, ReplicateInterval: 2 * time.Second},
+	}
+	nowMilli := commontime.CurrentMillisecond()
+
+	// 从未同步成功
+	_, ok := checker.replicaLagSec()
+	assert.False(t, ok)
+
+	// 落后不足两个周期，当选 leader 后的保护时长为副本落后的时长
+	atomic.StoreInt64(&checker.replicaSyncTimeMilli, nowMilli-3000)
+	lagSec, ok := checker.replicaLagSec()
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, lagSec, int64(3))
+	assert.LessOrEqual(t, lagSec, int64(4))
+
+	// 超过两个周期没有同步成功，副本不再使用
+	atomic.StoreInt64(&checker.replicaSyncTimeMilli, nowMilli-2*checker.conf.ReplicateInterval.Milliseconds()-1000)
+	_, ok = checker.replicaLagSec()
+	assert.False(t, ok)
+}
+
+// replicaMockPeer 模拟 leader 节点，Storage 返回 leader 上的心跳数据
+type replicaMockPeer struct {
+	MockPeerImpl
+	cache BeatRecordCache
+}
+
+func (mp *replicaMockPeer) Storage() BeatRecordCache {
+	return mp.cache
+}
+
 func TestLeaderHealthChecker_Normal(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	eventhub.InitEventHub()
@@ -18,20 +18,35 @@
 package leader
 
 import (
+	"time"
+
 	"github.com/mitchellh/mapstructure"
 )
 
+const (
+	// DefaultReplicateInterval default interval of follower sync beat records from leader
+	DefaultReplicateInterval = 2 * time.Second
+)
+
 type Config struct {
 	SoltNum   int32 `json:"soltNum"`
 	StreamNum int32 `json:"streamNum"`
+	// Replicate follower 是否定期从 leader 同步全量心跳数据副本，开启后 follower 当选 leader 时无需等待完整的心跳周期。
+	// follower 每个周期通过 Peer 拉取一次全量快照而不是接收增量，副本最多落后 leader 两个同步周期，
+	// 超过两个周期没有同步成功的副本不再使用；携带副本当选 leader 后仍然要等待副本落后的时长才开始判断心跳超时
+	Replicate bool `json:"replicate"`
+	// ReplicateInterval follower 拉取全量心跳数据快照的间隔，间隔越大副本落后越多，当选 leader 后的保护时长也越长，
+	// 同时每个 follower 每个周期都要传输一次全量数据，需要在两者之间权衡
+	ReplicateInterval time.Duration `json:"replicateInterval"`
 	// only use for test
 	checkLeader bool
 }
 
 func unmarshal(options map[string]interface{}) (*Config, error) {
 	config := &Config{
-		SoltNum:   DefaultSoltNum,
-		StreamNum: int32(streamNum),
+		SoltNum:           DefaultSoltNum,
+		StreamNum:         int32(streamNum),
+		ReplicateInterval: DefaultReplicateInterval,
 	}
 	decodeConfig := &mapstructure.DecoderConfig{
 		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
@@ -44,5 +59,8 @@ func unmarshal(options map[string]interface{}) (*Config, error) {
 	if err = decoder.Decode(options); err != nil {
 		return nil, err
 	}
+	if config.ReplicateInterval <= 0 {
+		config.ReplicateInterval = DefaultReplicateInterval
+	}
 	return config, nil
 }
@@ -20,6 +20,7 @@ package leader
 import (
 	"encoding/json"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/polarismesh/polaris/common/utils"
 )
@@ -42,6 +43,7 @@ func handleDescribeLeaderInfo(checker *LeaderHealthChecker) func(http.ResponseWr
 		}
 		ret["self"] = utils.LocalHost
 		ret["lastLeaderRefreshTimeSec"] = checker.LeaderChangeTimeSec()
+		ret["lastReplicaSyncTimeMilli"] = atomic.LoadInt64(&checker.replicaSyncTimeMilli)
 
 		data, _ := json.Marshal(ret)
 		resp.WriteHeader(http.StatusOK)
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -34,6 +35,7 @@ import (
 
 	commonhash "github.com/polarismesh/polaris/common/hash"
 	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
 )
 
 var (
@@ -162,7 +164,7 @@ func (p *RemotePeer) Serve(ctx context.Context, checker *LeaderHealthChecker,
 	if err := execConnectPeer(ctx, p); err != nil {
 		return err
 	}
-	p.Cache = newRemoteBeatRecordCache(p.GetFunc, p.PutFunc, p.DelFunc, p.Ping)
+	p.Cache = newRemoteBeatRecordCache(p.GetFunc, p.PutFunc, p.DelFunc, p.SnapshotFunc, p.Ping)
 	go p.checkLeaderAlive(subCtx)
 	return nil
 }
@@ -225,6 +227,37 @@ func (p *RemotePeer) GetFunc(req *apiservice.GetHeartbeatsRequest) (*apiservice.
 	return resp, nil
 }
 
+// SnapshotFunc 从 leader 分页拉取全量心跳数据，只返回实例 ID 大于 after 的最多 limit 条记录
+func (p *RemotePeer) SnapshotFunc(after string, limit int) (*apiservice.GetHeartbeatsResponse, error) {
+	if !p.IsAlive() {
+		return nil, ErrorLeaderNotAlive
+	}
+	start := time.Now()
+	code := "0"
+	defer func() {
+		observer := beatRecordCost.With(map[string]string{
+			labelAction: "SNAPSHOT",
+			labelCode:   code,
+		})
+		observer.Observe(float64(time.Since(start).Milliseconds()))
+	}()
+	client, err := p.choseOneClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), sendResource, utils.LocalHost,
+		plugin.BeatRecordSnapshotKey, "true", plugin.BeatRecordSnapshotAfterKey, after,
+		plugin.BeatRecordSnapshotLimitKey, strconv.Itoa(limit))
+	resp, err := client.BatchGetHeartbeat(ctx, &apiservice.GetHeartbeatsRequest{})
+	if err != nil {
+		code = "-1"
+		plog.Error("[HealthCheck][Leader] send snapshot record request", zap.String("host", p.Host()),
+			zap.Uint32("port", p.port), zap.Error(err))
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (p *RemotePeer) PutFunc(req *apiservice.HeartbeatsRequest) error {
 	if !p.IsAlive() {
 		return ErrorLeaderNotAlive
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -169,6 +171,38 @@ func (mc *MockPolarisHeartbeatClient) BatchGetHeartbeat(ctx context.Context,
 	rsp := &service_manage.GetHeartbeatsResponse{
 		Records: []*service_manage.HeartbeatRecord{},
 	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(plugin.BeatRecordSnapshotKey)) > 0 {
+		var (
+			after string
+			limit int
+		)
+		if vals := md.Get(plugin.BeatRecordSnapshotAfterKey); len(vals) > 0 {
+			after = vals[0]
+		}
+		if vals := md.Get(plugin.BeatRecordSnapshotLimitKey); len(vals) > 0 {
+			limit, _ = strconv.Atoi(vals[0])
+		}
+		snapshot := mc.peer.Storage().Snapshot()
+		keys := make([]string, 0, len(snapshot))
+		for key := range snapshot {
+			if key > after {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		if limit > 0 && len(keys) > limit {
+			keys = keys[:limit]
+		}
+		for _, key := range keys {
+			val := snapshot[key]
+			rsp.Records = append(rsp.Records, &service_manage.HeartbeatRecord{
+				InstanceId:       key,
+				LastHeartbeatSec: val.Record.CurTimeSec,
+				Exist:            val.Exist,
+			})
+		}
+		return rsp, nil
+	}
 	for i := range in.InstanceIds {
 		key := in.InstanceIds[i]
 		ret, err := mc.peer.Storage().Get(key)
@@ -174,6 +174,47 @@ func TestRemotePeer(t *testing.T) {
 		assert.True(t, mockVal <= ret[mockKey].Record.CurTimeSec)
 	})
 
+	t.Run("拉取全量数据", func(t *testing.T) {
+		err = remotePeer.Storage().Put(WriteBeatRecord{
+			Record: RecordValue{
+				CurTimeSec: mockVal,
+			},
+			Key: mockKey,
+		})
+		assert.NoError(t, err)
+
+		ret := remotePeer.Storage().Snapshot()
+		assert.NotNil(t, ret)
+		assert.True(t, ret[mockKey].Exist)
+		assert.True(t, mockVal <= ret[mockKey].Record.CurTimeSec)
+	})
+
+	t.Run("分页拉取全量数据", func(t *testing.T) {
+		keys := []string{mockKey}
+		for i := 0; i < 4; i++ {
+			key := utils.NewUUID()
+			keys = append(keys, key)
+			err = remotePeer.Storage().Put(WriteBeatRecord{
+				Record: RecordValue{
+					CurTimeSec: mockVal,
+				},
+				Key: key,
+			})
+			assert.NoError(t, err)
+		}
+
+		remoteCache := remotePeer.Storage().(*RemoteBeatRecordCache)
+		remoteCache.snapshotPageSize = 2
+		defer func() {
+			remoteCache.snapshotPageSize = DefaultSnapshotPageSize
+		}()
+		ret := remotePeer.Storage().Snapshot()
+		assert.Len(t, ret, len(keys))
+		for _, key := range keys {
+			assert.True(t, ret[key].Exist, key)
+		}
+	})
+
 	t.Run("验证删除场景", func(t *testing.T) {
 		err = remotePeer.Storage().Del(mockKey)
 		assert.NoError(t, err)
@@ -0,0 +1,228 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthprobe
+
+import (
+	"context"
+	"sync/atomic"
+
+	commonLog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/model"
+	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const (
+	// PluginName plugin name
+	PluginName = "activeProbe"
+)
+
+var log = commonLog.GetScopeOrDefaultByName(commonLog.HealthcheckLoggerName)
+
+// ProbeRecord record for probe result
+type ProbeRecord struct {
+	// LastProbeSec 最近一次探测的时间
+	LastProbeSec int64
+	// LastSuccessSec 最近一次探测成功的时间
+	LastSuccessSec int64
+	// Successes 连续探测成功的次数
+	Successes int32
+	// Failures 连续探测失败的次数
+	Failures int32
+	// Count 累计探测次数
+	Count int64
+}
+
+// ProbeHealthChecker 服务端主动探测实例的健康检查插件，支持 HTTP/TCP/gRPC 探测
+// 1. 实例通过元数据 internal-healthcheck_protocol 指定使用主动探测（见 model.IsProbeHealthCheck），heartbeat.ttl 作为探测间隔
+// 2. 探测任务与心跳检查一样由 Dispatcher 通过一致性 hash 分配到各个 Polaris 节点
+// 3. 探测参数从实例元数据中读取，未指定时使用实例端口进行 TCP 探测
+type ProbeHealthChecker struct {
+	conf           *Config
+	prober         *prober
+	limiter        chan struct{}
+	records        *utils.SyncMap[string, *ProbeRecord]
+	suspendTimeSec int64
+}
+
+// Name return plugin name
+func (r *ProbeHealthChecker) Name() string {
+	return PluginName
+}
+
+// Initialize initialize plugin
+func (r *ProbeHealthChecker) Initialize(c *plugin.ConfigEntry) error {
+	conf, err := unmarshal(c.Option)
+	if err != nil {
+		return err
+	}
+	r.conf = conf
+	r.prober = newProber(conf)
+	r.limiter = make(chan struct{}, conf.Concurrency)
+	r.records = utils.NewSyncMap[string, *ProbeRecord]()
+	return nil
+}
+
+// Destroy plugin destruction
+func (r *ProbeHealthChecker) Destroy() error {
+	return nil
+}
+
+// Type for health check plugin, only one same type plugin is allowed
+func (r *ProbeHealthChecker) Type() plugin.HealthCheckType {
+	return plugin.HealthCheckerProbe
+}
+
+// Report 主动探测的实例不依赖心跳上报，忽略即可
+func (r *ProbeHealthChecker) Report(ctx context.Context, request *plugin.ReportRequest) error {
+	return nil
+}
+
+// Query queries the last success probe time
+func (r *ProbeHealthChecker) Query(ctx context.Context, request *plugin.QueryRequest) (*plugin.QueryResponse, error) {
+	record, ok := r.records.Load(request.InstanceId)
+	if !ok {
+		return &plugin.QueryResponse{
+			LastHeartbeatSec: 0,
+		}, nil
+	}
+	return &plugin.QueryResponse{
+		Exists:           true,
+		LastHeartbeatSec: record.LastSuccessSec,
+		Count:            record.Count,
+	}, nil
+}
+
+// BatchQuery batch queries the last success probe time
+func (r *ProbeHealthChecker) BatchQuery(ctx context.Context,
+	request *plugin.BatchQueryRequest) (*plugin.BatchQueryResponse, error) {
+	rsp := &plugin.BatchQueryResponse{Responses: make([]*plugin.QueryResponse, 0, len(request.Requests))}
+	for i := range request.Requests {
+		subRsp, err := r.Query(ctx, request.Requests[i])
+		if err != nil {
+			return nil, err
+		}
+		rsp.Responses = append(rsp.Responses, subRsp)
+	}
+	return rsp, nil
+}
+
+func (r *ProbeHealthChecker) skipCheck(instanceId string, expireDurationSec int64) bool {
+	suspendTimeSec := r.SuspendTimeSec()
+	localCurTimeSec := commontime.CurrentMillisecond() / 1000
+	if suspendTimeSec > 0 && localCurTimeSec >= suspendTimeSec && localCurTimeSec-suspendTimeSec < expireDurationSec {
+		log.Infof("[Health Check][ProbeCheck]health check probe suspended, "+
+			"suspendTimeSec is %d, localCurTimeSec is %d, expireDurationSec is %d, instanceId %s",
+			suspendTimeSec, localCurTimeSec, expireDurationSec, instanceId)
+		return true
+	}
+	return false
+}
+
+// Check 探测实例，连续失败次数达到阈值后置为不健康，连续成功次数达到阈值后恢复健康
+func (r *ProbeHealthChecker) Check(request *plugin.CheckRequest) (*plugin.CheckResponse, error) {
+	var record ProbeRecord
+	if last, ok := r.records.Load(request.InstanceId); ok {
+		record = *last
+	}
+	checkResp := &plugin.CheckResponse{
+		LastHeartbeatTimeSec: record.LastSuccessSec,
+		Regular:              true,
+	}
+	if r.skipCheck(request.InstanceId, int64(request.ExpireDurationSec)*int64(r.conf.FailureThreshold)) {
+		checkResp.Healthy = request.Healthy
+		checkResp.StayUnchanged = true
+		return checkResp, nil
+	}
+
+	err := r.doProbe(request)
+	curTimeSec := request.CurTimeSec()
+	record.LastProbeSec = curTimeSec
+	record.Count++
+	if err == nil {
+		record.LastSuccessSec = curTimeSec
+		record.Successes++
+		record.Failures = 0
+	} else {
+		record.Successes = 0
+		record.Failures++
+		log.Debugf("[Health Check][ProbeCheck]probe instance %s %s:%d fail, count %d, err: %v",
+			request.InstanceId, request.Host, request.Port, record.Failures, err)
+	}
+	r.records.Store(request.InstanceId, &record)
+	checkResp.LastHeartbeatTimeSec = record.LastSuccessSec
+
+	if request.Healthy {
+		checkResp.Healthy = record.Failures < r.conf.FailureThreshold
+		checkResp.StayUnchanged = checkResp.Healthy
+		if !checkResp.Healthy {
+			log.Infof("[Health Check][ProbeCheck]health check probe failed, "+
+				"failures is %d, last success timestamp is %d, instanceId %s, err: %v",
+				record.Failures, record.LastSuccessSec, request.InstanceId, err)
+		}
+		return checkResp, nil
+	}
+	checkResp.Healthy = record.Successes >= r.conf.SuccessThreshold
+	checkResp.StayUnchanged = !checkResp.Healthy
+	if checkResp.Healthy {
+		log.Infof("[Health Check][ProbeCheck]health check probe resumed, "+
+			"successes is %d, curTimeSec is %d, instanceId %s", record.Successes, curTimeSec, request.InstanceId)
+	}
+	return checkResp, nil
+}
+
+func (r *ProbeHealthChecker) doProbe(request *plugin.CheckRequest) error {
+	target, err := newProbeTarget(request.Host, request.Port, request.Metadata, r.conf)
+	if err != nil {
+		return err
+	}
+	r.limiter <- struct{}{}
+	defer func() {
+		<-r.limiter
+	}()
+	return r.prober.probe(target)
+}
+
+// Delete delete the id
+func (r *ProbeHealthChecker) Delete(ctx context.Context, id string) error {
+	r.records.Delete(id)
+	return nil
+}
+
+// Suspend 暂停探测结果的生效
+func (r *ProbeHealthChecker) Suspend() {
+	curTimeMilli := commontime.CurrentMillisecond() / 1000
+	log.Infof("[Health Check][ProbeCheck] suspend checker, start time %d", curTimeMilli)
+	atomic.StoreInt64(&r.suspendTimeSec, curTimeMilli)
+}
+
+// SuspendTimeSec get suspend time in seconds
+func (r *ProbeHealthChecker) SuspendTimeSec() int64 {
+	return atomic.LoadInt64(&r.suspendTimeSec)
+}
+
+// DebugHandlers return debug handlers
+func (r *ProbeHealthChecker) DebugHandlers() []model.DebugHandler {
+	return []model.DebugHandler{}
+}
+
+func init() {
+	d := &ProbeHealthChecker{}
+	plugin.RegisterPlugin(d.Name(), d)
+}
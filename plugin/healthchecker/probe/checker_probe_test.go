@@ -0,0 +1,289 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthprobe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+func newTestChecker(t *testing.T) *ProbeHealthChecker {
+	checker := &ProbeHealthChecker{}
+	err := checker.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"timeout":          "500ms",
+			"failureThreshold": 2,
+			// 测试中的实例都监听在回环地址上
+			"denyCIDRs": []string{"169.254.0.0/16"},
+		},
+	})
+	assert.NoError(t, err)
+	return checker
+}
+
+func newCheckRequest(id, addr string, healthy bool, metadata map[string]string) *plugin.CheckRequest {
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	return &plugin.CheckRequest{
+		QueryRequest: plugin.QueryRequest{
+			InstanceId: id,
+			Host:       host,
+			Port:       uint32(port),
+			Healthy:    healthy,
+		},
+		ExpireDurationSec: 5,
+		CurTimeSec: func() int64 {
+			return time.Now().Unix()
+		},
+		Metadata: metadata,
+	}
+}
+
+func TestNewProbeTarget(t *testing.T) {
+	conf, err := unmarshal(map[string]interface{}{
+		"allowPorts": []string{"9090", "10000-10010"},
+	})
+	assert.NoError(t, err)
+	target, err := newProbeTarget("127.0.0.1", 8080, nil, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, probeProtocolTCP, target.protocol)
+	assert.Equal(t, "127.0.0.1:8080", target.address)
+
+	target, err = newProbeTarget("::1", 8080, map[string]string{
+		model.MetadataInternalMetaHealthCheckProtocol:     "HTTP",
+		model.MetadataInternalMetaHealthCheckPort:         "9090",
+		model.MetadataInternalMetaHealthCheckPath:         "health",
+		model.MetadataInternalMetaHealthCheckExpectStatus: "200, 300-302",
+	}, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, probeProtocolHTTP, target.protocol)
+	assert.Equal(t, "[::1]:9090", target.address)
+	assert.Equal(t, "/health", target.path)
+	assert.True(t, target.matchStatus(200))
+	assert.True(t, target.matchStatus(301))
+	assert.False(t, target.matchStatus(204))
+
+	for _, metadata := range []map[string]string{
+		{model.MetadataInternalMetaHealthCheckProtocol: "udp"},
+		{model.MetadataInternalMetaHealthCheckPort: "0"},
+		{model.MetadataInternalMetaHealthCheckPort: "70000"},
+		{model.MetadataInternalMetaHealthCheckExpectStatus: "500-200"},
+		{model.MetadataInternalMetaHealthCheckExpectStatus: "abc"},
+		{model.MetadataInternalMetaHealthCheckExpectStatus: ","},
+		// 不在允许范围内的探测端口
+		{model.MetadataInternalMetaHealthCheckPort: "22"},
+		{model.MetadataInternalMetaHealthCheckPort: "10011"},
+	} {
+		_, err = newProbeTarget("127.0.0.1", 8080, metadata, conf)
+		assert.Error(t, err, metadata)
+	}
+
+	target, err = newProbeTarget("127.0.0.1", 8080, map[string]string{
+		model.MetadataInternalMetaHealthCheckPort: "10005",
+	}, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:10005", target.address)
+
+	for _, option := range []map[string]interface{}{
+		{"allowPorts": []string{"0"}},
+		{"allowPorts": []string{"200-100"}},
+		{"denyCIDRs": []string{"10.0.0.1"}},
+	} {
+		_, err = unmarshal(option)
+		assert.Error(t, err, option)
+	}
+}
+
+func TestProbeHealthChecker_DenyAddress(t *testing.T) {
+	checker := &ProbeHealthChecker{}
+	assert.NoError(t, checker.Initialize(&plugin.ConfigEntry{
+		Name:   PluginName,
+		Option: map[string]interface{}{"timeout": "500ms"},
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	// 默认不探测回环地址
+	err = checker.doProbe(newCheckRequest("ins-1", ln.Addr().String(), true, map[string]string{
+		model.MetadataInternalMetaHealthCheckProtocol: "tcp",
+	}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+}
+
+func TestProbeHealthChecker_NotFollowRedirect(t *testing.T) {
+	checker := newTestChecker(t)
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer svr.Close()
+
+	err := checker.doProbe(newCheckRequest("ins-1", svr.Listener.Addr().String(), true, map[string]string{
+		model.MetadataInternalMetaHealthCheckProtocol:     "http",
+		model.MetadataInternalMetaHealthCheckExpectStatus: "200",
+	}))
+	assert.Error(t, err)
+	assert.False(t, redirected)
+}
+
+func TestProbeHealthChecker_HTTP(t *testing.T) {
+	checker := newTestChecker(t)
+	status := http.StatusOK
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"status":"UP"}`))
+	}))
+	defer svr.Close()
+
+	metadata := map[string]string{
+		model.MetadataInternalMetaHealthCheckProtocol:   "http",
+		model.MetadataInternalMetaHealthCheckPath:       "/health",
+		model.MetadataInternalMetaHealthCheckExpectBody: `"UP"`,
+	}
+	addr := svr.Listener.Addr().String()
+
+	// 不健康的实例探测成功后恢复健康
+	resp, err := checker.Check(newCheckRequest("ins-1", addr, false, metadata))
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+	assert.True(t, resp.Regular)
+	assert.NotZero(t, resp.LastHeartbeatTimeSec)
+
+	// 连续失败次数未达到阈值时保持健康
+	status = http.StatusServiceUnavailable
+	resp, err = checker.Check(newCheckRequest("ins-1", addr, true, metadata))
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+
+	resp, err = checker.Check(newCheckRequest("ins-1", addr, true, metadata))
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+
+	queryResp, err := checker.Query(context.Background(), &plugin.QueryRequest{InstanceId: "ins-1"})
+	assert.NoError(t, err)
+	assert.True(t, queryResp.Exists)
+	assert.Equal(t, int64(3), queryResp.Count)
+
+	// 响应内容不符合预期
+	status = http.StatusOK
+	metadata[model.MetadataInternalMetaHealthCheckExpectBody] = "DOWN"
+	resp, err = checker.Check(newCheckRequest("ins-1", addr, false, metadata))
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+
+	assert.NoError(t, checker.Delete(context.Background(), "ins-1"))
+	queryResp, err = checker.Query(context.Background(), &plugin.QueryRequest{InstanceId: "ins-1"})
+	assert.NoError(t, err)
+	assert.False(t, queryResp.Exists)
+}
+
+func TestProbeHealthChecker_TCP(t *testing.T) {
+	checker := newTestChecker(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	resp, err := checker.Check(newCheckRequest("ins-2", addr, false, nil))
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+
+	_ = ln.Close()
+	for i := 0; i < 2; i++ {
+		resp, err = checker.Check(newCheckRequest("ins-2", addr, true, nil))
+		assert.NoError(t, err)
+	}
+	assert.False(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+}
+
+func TestProbeHealthChecker_GRPC(t *testing.T) {
+	checker := newTestChecker(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	healthSvr := health.NewServer()
+	healthSvr.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	grpcSvr := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcSvr, healthSvr)
+	go func() {
+		_ = grpcSvr.Serve(ln)
+	}()
+	defer grpcSvr.Stop()
+
+	metadata := map[string]string{
+		model.MetadataInternalMetaHealthCheckProtocol:    "grpc",
+		model.MetadataInternalMetaHealthCheckGrpcService: "echo",
+	}
+	resp, err := checker.Check(newCheckRequest("ins-3", ln.Addr().String(), false, metadata))
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+
+	healthSvr.SetServingStatus("echo", healthpb.HealthCheckResponse_NOT_SERVING)
+	for i := 0; i < 2; i++ {
+		resp, err = checker.Check(newCheckRequest("ins-3", ln.Addr().String(), true, metadata))
+		assert.NoError(t, err)
+	}
+	assert.False(t, resp.Healthy)
+	assert.False(t, resp.StayUnchanged)
+}
+
+func TestProbeHealthChecker_Suspend(t *testing.T) {
+	checker := newTestChecker(t)
+	checker.Suspend()
+	resp, err := checker.Check(newCheckRequest("ins-4", "127.0.0.1:1", true, nil))
+	assert.NoError(t, err)
+	assert.True(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+	queryResp, err := checker.Query(context.Background(), &plugin.QueryRequest{InstanceId: "ins-4"})
+	assert.NoError(t, err)
+	assert.False(t, queryResp.Exists)
+}
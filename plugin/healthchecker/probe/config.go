@@ -0,0 +1,157 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthprobe
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	defaultTimeout          = 2 * time.Second
+	defaultFailureThreshold = 3
+	defaultSuccessThreshold = 1
+	defaultConcurrency      = 256
+	// maxBodySize 校验响应体时最多读取的字节数
+	maxBodySize = 64 * 1024
+)
+
+// defaultDenyCIDRs 默认不允许探测的地址段：回环地址、链路本地地址（包括云厂商的元数据服务）以及未指定地址
+var defaultDenyCIDRs = []string{
+	"127.0.0.0/8",
+	"::1/128",
+	"169.254.0.0/16",
+	"fe80::/10",
+	"0.0.0.0/8",
+	"::/128",
+}
+
+// Config 主动探测插件配置
+type Config struct {
+	// Timeout 单次探测的超时时间
+	Timeout time.Duration `json:"timeout"`
+	// FailureThreshold 连续探测失败多少次后将实例置为不健康
+	FailureThreshold int32 `json:"failureThreshold"`
+	// SuccessThreshold 连续探测成功多少次后将实例恢复为健康
+	SuccessThreshold int32 `json:"successThreshold"`
+	// Concurrency 同时执行的探测数量上限
+	Concurrency int `json:"concurrency"`
+	// AllowPorts 允许通过实例元数据指定的探测端口，格式如 8080、9000-9100，为空时只探测实例自身的端口
+	AllowPorts []string `json:"allowPorts"`
+	// DenyCIDRs 不允许探测的地址段，未设置时使用 defaultDenyCIDRs
+	DenyCIDRs []string `json:"denyCIDRs"`
+	// InsecureSkipVerify HTTPS 探测时是否跳过证书校验
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+
+	allowPorts []portRange
+	denyNets   []*net.IPNet
+}
+
+// portRange 允许探测的端口区间，闭区间
+type portRange struct {
+	min uint32
+	max uint32
+}
+
+func unmarshal(options map[string]interface{}) (*Config, error) {
+	config := &Config{}
+	decodeConfig := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     config,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err = decoder.Decode(options); err != nil {
+		return nil, err
+	}
+	config.setDefault()
+	if err = config.parse(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) setDefault() {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = defaultFailureThreshold
+	}
+	if c.SuccessThreshold <= 0 {
+		c.SuccessThreshold = defaultSuccessThreshold
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+	if c.DenyCIDRs == nil {
+		c.DenyCIDRs = defaultDenyCIDRs
+	}
+}
+
+func (c *Config) parse() error {
+	for _, item := range c.AllowPorts {
+		bounds := strings.SplitN(strings.TrimSpace(item), "-", 2)
+		min, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 16)
+		if err != nil || min == 0 {
+			return fmt.Errorf("invalid allow port %s", item)
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 16); err != nil || max < min {
+				return fmt.Errorf("invalid allow port %s", item)
+			}
+		}
+		c.allowPorts = append(c.allowPorts, portRange{min: uint32(min), max: uint32(max)})
+	}
+	for _, item := range c.DenyCIDRs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(item))
+		if err != nil {
+			return fmt.Errorf("invalid deny cidr %s", item)
+		}
+		c.denyNets = append(c.denyNets, ipNet)
+	}
+	return nil
+}
+
+// allowPort 实例元数据中指定的探测端口是否在允许的范围内
+func (c *Config) allowPort(port uint32) bool {
+	for _, r := range c.allowPorts {
+		if port >= r.min && port <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// denyIP 探测地址是否落在不允许探测的地址段中
+func (c *Config) denyIP(ip net.IP) bool {
+	for _, ipNet := range c.denyNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthprobe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+const (
+	probeProtocolTCP   = "tcp"
+	probeProtocolHTTP  = "http"
+	probeProtocolHTTPS = "https"
+	probeProtocolGRPC  = "grpc"
+)
+
+// statusRange 期望的 HTTP 状态码区间，闭区间
+type statusRange struct {
+	min int
+	max int
+}
+
+var defaultExpectStatus = []statusRange{{min: 200, max: 399}}
+
+// probeTarget 根据实例地址以及元数据解析出来的探测目标
+type probeTarget struct {
+	protocol     string
+	address      string
+	path         string
+	expectStatus []statusRange
+	expectBody   string
+	grpcService  string
+}
+
+// newProbeTarget 解析探测目标，探测协议默认为 TCP，只探测实例自身的地址，
+// 探测端口默认为实例端口，通过元数据指定其他端口时需要在允许的端口范围内
+func newProbeTarget(host string, port uint32, metadata map[string]string, conf *Config) (*probeTarget, error) {
+	target := &probeTarget{
+		protocol:     strings.ToLower(metadata[model.MetadataInternalMetaHealthCheckProtocol]),
+		path:         metadata[model.MetadataInternalMetaHealthCheckPath],
+		expectStatus: defaultExpectStatus,
+		expectBody:   metadata[model.MetadataInternalMetaHealthCheckExpectBody],
+		grpcService:  metadata[model.MetadataInternalMetaHealthCheckGrpcService],
+	}
+	switch target.protocol {
+	case "":
+		target.protocol = probeProtocolTCP
+	case probeProtocolTCP, probeProtocolHTTP, probeProtocolHTTPS, probeProtocolGRPC:
+	default:
+		return nil, fmt.Errorf("unsupported probe protocol %s", target.protocol)
+	}
+	if val, ok := metadata[model.MetadataInternalMetaHealthCheckPort]; ok {
+		probePort, err := strconv.ParseUint(val, 10, 16)
+		if err != nil || probePort == 0 {
+			return nil, fmt.Errorf("invalid probe port %s", val)
+		}
+		if uint32(probePort) != port && !conf.allowPort(uint32(probePort)) {
+			return nil, fmt.Errorf("probe port %s not allowed", val)
+		}
+		port = uint32(probePort)
+	}
+	target.address = net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10))
+	if !strings.HasPrefix(target.path, "/") {
+		target.path = "/" + target.path
+	}
+	if val := metadata[model.MetadataInternalMetaHealthCheckExpectStatus]; val != "" {
+		expectStatus, err := parseStatusRanges(val)
+		if err != nil {
+			return nil, err
+		}
+		target.expectStatus = expectStatus
+	}
+	return target, nil
+}
+
+// parseStatusRanges 解析期望的状态码，格式如 200,204,300-399
+func parseStatusRanges(val string) ([]statusRange, error) {
+	ranges := make([]statusRange, 0, 2)
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid expect status %s", item)
+		}
+		max := min
+		if len(bounds) == 2 {
+			if max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				return nil, fmt.Errorf("invalid expect status %s", item)
+			}
+		}
+		if min < 100 || max > 599 || min > max {
+			return nil, fmt.Errorf("invalid expect status %s", item)
+		}
+		ranges = append(ranges, statusRange{min: min, max: max})
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("invalid expect status %s", val)
+	}
+	return ranges, nil
+}
+
+func (t *probeTarget) matchStatus(code int) bool {
+	for _, r := range t.expectStatus {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+// prober 执行具体的探测动作
+type prober struct {
+	timeout    time.Duration
+	conf       *Config
+	dialer     *net.Dialer
+	httpClient *http.Client
+}
+
+func newProber(conf *Config) *prober {
+	p := &prober{
+		timeout: conf.Timeout,
+		conf:    conf,
+	}
+	// 在建立链接时校验实际连接的地址，实例地址为域名时同样生效
+	p.dialer = &net.Dialer{Control: p.checkAddress}
+	p.httpClient = &http.Client{
+		Timeout: conf.Timeout,
+		Transport: &http.Transport{
+			DialContext:       p.dialer.DialContext,
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify},
+		},
+		// 不跟随重定向，避免探测请求被引导到实例以外的地址
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return p
+}
+
+func (p *prober) checkAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid probe address %s", address)
+	}
+	if p.conf.denyIP(ip) {
+		return fmt.Errorf("probe address %s not allowed", address)
+	}
+	return nil
+}
+
+func (p *prober) probe(target *probeTarget) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	switch target.protocol {
+	case probeProtocolHTTP, probeProtocolHTTPS:
+		return p.probeHTTP(ctx, target)
+	case probeProtocolGRPC:
+		return p.probeGRPC(ctx, target)
+	default:
+		return p.probeTCP(ctx, target)
+	}
+}
+
+func (p *prober) probeTCP(ctx context.Context, target *probeTarget) error {
+	conn, err := p.dialer.DialContext(ctx, "tcp", target.address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p *prober) probeHTTP(ctx context.Context, target *probeTarget) error {
+	url := target.protocol + "://" + target.address + target.path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if !target.matchStatus(resp.StatusCode) {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if target.expectBody == "" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(body), target.expectBody) {
+		return errors.New("response body not contains expect content")
+	}
+	return nil
+}
+
+func (p *prober) probeGRPC(ctx context.Context, target *probeTarget) error {
+	conn, err := grpc.DialContext(ctx, target.address, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return p.dialer.DialContext(ctx, "tcp", addr)
+		}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: target.grpcService,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("unexpected serving status %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	commonLog "github.com/polarismesh/polaris/common/log"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store"
+)
+
+// 把操作记录持久化到存储层中，可以通过运维接口查询
+const (
+	// PluginName plugin name
+	PluginName = "HistoryStore"
+)
+
+var (
+	log = commonLog.GetScopeOrDefaultByName(commonLog.DefaultLoggerName)
+	// fallbackLog 无法写入存储层的操作记录按行写入兜底日志，每行为一条 json 格式的记录，可用于补录
+	fallbackLog = commonLog.RegisterScope(FallbackLoggerName, "", 0)
+)
+
+const (
+	// FallbackLoggerName 兜底日志的 scope 名称
+	FallbackLoggerName = "HistoryStoreFallback"
+
+	fallbackReasonQueueFull  = "queue_full"
+	fallbackReasonStoreError = "store_error"
+)
+
+// init 初始化注册函数
+func init() {
+	plugin.RegisterPlugin(PluginName, &HistoryStore{})
+}
+
+// Config 插件配置
+type Config struct {
+	// QueueSize 等待写入的操作记录队列长度
+	QueueSize int `mapstructure:"queueSize"`
+	// EnqueueTimeout 队列满时调用方最多等待的时间，超时后记录写入兜底日志。默认为 0，队列满时不阻塞调用方，
+	// 直接写入兜底日志并通过 history_record_fallback 指标计数
+	EnqueueTimeout time.Duration `mapstructure:"enqueueTimeout"`
+	// BatchSize 单次写入存储层的最大记录数
+	BatchSize int `mapstructure:"batchSize"`
+	// FlushInterval 未攒满一批时的写入间隔
+	FlushInterval time.Duration `mapstructure:"flushInterval"`
+}
+
+// DefaultConfig 默认的插件配置
+func DefaultConfig() *Config {
+	return &Config{
+		QueueSize:      10240,
+		EnqueueTimeout: 0,
+		BatchSize:      128,
+		FlushInterval:  time.Second,
+	}
+}
+
+// HistoryStore 操作记录异步批量写入存储层
+type HistoryStore struct {
+	cfg     *Config
+	storage store.Store
+	queue   chan *model.RecordEntry
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// Name 返回插件名字
+func (h *HistoryStore) Name() string {
+	return PluginName
+}
+
+// Initialize 插件初始化
+func (h *HistoryStore) Initialize(c *plugin.ConfigEntry) error {
+	cfg := DefaultConfig()
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(c.Option); err != nil {
+		return err
+	}
+	if cfg.QueueSize <= 0 || cfg.BatchSize <= 0 || cfg.FlushInterval <= 0 || cfg.EnqueueTimeout < 0 {
+		return errors.New("history store queueSize, batchSize, flushInterval must be positive " +
+			"and enqueueTimeout must not be negative")
+	}
+	if h.storage == nil {
+		s, err := store.GetStore()
+		if err != nil {
+			return err
+		}
+		h.storage = s
+	}
+
+	h.cfg = cfg
+	h.queue = make(chan *model.RecordEntry, cfg.QueueSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		h.run(ctx)
+	}()
+	return nil
+}
+
+// Destroy 销毁插件，写入队列中剩余的操作记录
+func (h *HistoryStore) Destroy() error {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.wg.Wait()
+	return nil
+}
+
+// Record 记录操作记录，队列满时最多阻塞调用方 EnqueueTimeout，仍然无法入队的记录写入兜底日志。
+// EnqueueTimeout 为 0 时不阻塞调用方
+func (h *HistoryStore) Record(entry *model.RecordEntry) {
+	entry.Server = utils.LocalHost
+	if entry.HappenTime.IsZero() {
+		entry.HappenTime = time.Now()
+	}
+	select {
+	case h.queue <- entry:
+		return
+	default:
+	}
+	if h.cfg.EnqueueTimeout == 0 {
+		h.fallback(fallbackReasonQueueFull, []*model.RecordEntry{entry})
+		return
+	}
+	timer := time.NewTimer(h.cfg.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case h.queue <- entry:
+	case <-timer.C:
+		h.fallback(fallbackReasonQueueFull, []*model.RecordEntry{entry})
+	}
+}
+
+// fallback 将无法写入存储层的操作记录写入兜底日志并上报监控
+func (h *HistoryStore) fallback(reason string, entries []*model.RecordEntry) {
+	log.Warnf("[History][Store] %d operation records write to fallback log, reason: %s", len(entries), reason)
+	metrics.ReportHistoryRecordFallback(reason, len(entries))
+	for i := range entries {
+		data, err := json.Marshal(entries[i])
+		if err != nil {
+			fallbackLog.Error(entries[i].String())
+			continue
+		}
+		fallbackLog.Info(string(data))
+	}
+}
+
+func (h *HistoryStore) run(ctx context.Context) {
+	ticker := time.NewTicker(h.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*model.RecordEntry, 0, h.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := h.storage.AddOperationRecords(batch); err != nil {
+			log.Errorf("[History][Store] save %d operation records fail: %v", len(batch), err)
+			h.fallback(fallbackReasonStoreError, batch)
+		}
+		batch = make([]*model.RecordEntry, 0, h.cfg.BatchSize)
+	}
+	for {
+		select {
+		case entry := <-h.queue:
+			batch = append(batch, entry)
+			if len(batch) >= h.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-h.queue:
+					batch = append(batch, entry)
+					if len(batch) >= h.cfg.BatchSize {
+						flush()
+					}
+					continue
+				default:
+				}
+				break
+			}
+			flush()
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+	"github.com/polarismesh/polaris/store/mock"
+)
+
+func TestHistoryStore_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		mu    sync.Mutex
+		saved = make([]*model.RecordEntry, 0, 4)
+		sizes = make([]int, 0, 4)
+	)
+	mockStore := mock.NewMockStore(ctrl)
+	mockStore.EXPECT().AddOperationRecords(gomock.Any()).DoAndReturn(func(records []*model.RecordEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		saved = append(saved, records...)
+		sizes = append(sizes, len(records))
+		return nil
+	}).AnyTimes()
+
+	h := &HistoryStore{storage: mockStore}
+	err := h.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"batchSize":     2,
+			"flushInterval": "1h",
+		},
+	})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"r1", "r2", "r3"} {
+		h.Record(&model.RecordEntry{
+			ResourceType:  model.RRouting,
+			ResourceName:  name,
+			Namespace:     "default",
+			Operator:      "polaris",
+			OperationType: model.OUpdate,
+		})
+	}
+	// 攒满一批后立即写入
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(saved) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 销毁时写入剩余的记录
+	assert.NoError(t, h.Destroy())
+	assert.Len(t, saved, 3)
+	assert.Equal(t, []int{2, 1}, sizes)
+	for _, entry := range saved {
+		assert.NotEmpty(t, entry.Server)
+		assert.False(t, entry.HappenTime.IsZero())
+	}
+}
+
+func TestHistoryStore_InvalidConfig(t *testing.T) {
+	h := &HistoryStore{}
+	err := h.Initialize(&plugin.ConfigEntry{
+		Name:   PluginName,
+		Option: map[string]interface{}{"batchSize": 0},
+	})
+	assert.Error(t, err)
+}
+
+func TestHistoryStore_QueueFullBackpressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	release := make(chan struct{})
+	saved := make(chan int, 8)
+	mockStore := mock.NewMockStore(ctrl)
+	mockStore.EXPECT().AddOperationRecords(gomock.Any()).DoAndReturn(func(records []*model.RecordEntry) error {
+		<-release
+		saved <- len(records)
+		return nil
+	}).AnyTimes()
+
+	h := &HistoryStore{storage: mockStore}
+	err := h.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"queueSize":      1,
+			"batchSize":      1,
+			"flushInterval":  "1h",
+			"enqueueTimeout": "2s",
+		},
+	})
+	assert.NoError(t, err)
+
+	// 第一条记录阻塞在存储层写入，第二条占满队列
+	h.Record(&model.RecordEntry{ResourceName: "r1"})
+	h.Record(&model.RecordEntry{ResourceName: "r2"})
+
+	// 队列满时调用方等待队列空出，而不是直接丢弃记录
+	done := make(chan struct{})
+	go func() {
+		h.Record(&model.RecordEntry{ResourceName: "r3"})
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("record should wait for queue space")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	assert.NoError(t, h.Destroy())
+	assert.Len(t, saved, 3)
+}
+
+func TestHistoryStore_QueueFullNonBlocking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	release := make(chan struct{})
+	saved := make(chan int, 8)
+	mockStore := mock.NewMockStore(ctrl)
+	mockStore.EXPECT().AddOperationRecords(gomock.Any()).DoAndReturn(func(records []*model.RecordEntry) error {
+		<-release
+		saved <- len(records)
+		return nil
+	}).AnyTimes()
+
+	h := &HistoryStore{storage: mockStore}
+	err := h.Initialize(&plugin.ConfigEntry{
+		Name: PluginName,
+		Option: map[string]interface{}{
+			"queueSize":     1,
+			"batchSize":     1,
+			"flushInterval": "1h",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), h.cfg.EnqueueTimeout)
+
+	// 第一条记录阻塞在存储层写入，第二条占满队列
+	h.Record(&model.RecordEntry{ResourceName: "r1"})
+	assert.Eventually(t, func() bool {
+		return len(h.queue) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	h.Record(&model.RecordEntry{ResourceName: "r2"})
+
+	// 默认不阻塞调用方，队列满时记录直接写入兜底日志
+	done := make(chan struct{})
+	go func() {
+		h.Record(&model.RecordEntry{ResourceName: "r3"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("record should not wait for queue space")
+	}
+	close(release)
+	assert.NoError(t, h.Destroy())
+	assert.Len(t, saved, 2)
+}
@@ -20,6 +20,7 @@ package plugin
 import (
 	"os"
 	"sync"
+	"time"
 )
 
 // RatelimitType rate limit type
@@ -37,14 +38,22 @@ const (
 
 	// InstanceRatelimit Based on Instance flow control
 	InstanceRatelimit
+
+	// PrincipalRatelimit Based on authenticated principal(user or user group) flow control
+	PrincipalRatelimit
+
+	// NamespaceRatelimit Based on namespace flow control
+	NamespaceRatelimit
 )
 
 // RatelimitStr rate limit string map
 var RatelimitStr = map[RatelimitType]string{
-	IPRatelimit:       "ip-limit",
-	APIRatelimit:      "api-limit",
-	ServiceRatelimit:  "service-limit",
-	InstanceRatelimit: "instance-limit",
+	IPRatelimit:        "ip-limit",
+	APIRatelimit:       "api-limit",
+	ServiceRatelimit:   "service-limit",
+	InstanceRatelimit:  "instance-limit",
+	PrincipalRatelimit: "principal-limit",
+	NamespaceRatelimit: "namespace-limit",
 }
 
 var (
@@ -62,6 +71,39 @@ type Ratelimit interface {
 	Allow(typ RatelimitType, key string) bool
 }
 
+// RatelimitBackoff Optional interface of the Ratelimit plugin, tell the client how long to back off
+type RatelimitBackoff interface {
+	// Enabled Whether the flow control of typ is opened, caller can skip building the key when it is closed
+	Enabled(typ RatelimitType) bool
+	// AllowWithBackoff Whether to allow access, when not allowed, also return the duration the client
+	// should wait before retrying
+	AllowWithBackoff(typ RatelimitType, key string) (bool, time.Duration)
+}
+
+// RatelimitEnabled Whether the flow control of typ is opened, plugins without RatelimitBackoff are
+// treated as opened
+func RatelimitEnabled(r Ratelimit, typ RatelimitType) bool {
+	if r == nil {
+		return false
+	}
+	if b, ok := r.(RatelimitBackoff); ok {
+		return b.Enabled(typ)
+	}
+	return true
+}
+
+// AllowWithBackoff Whether to allow access, the back off duration is zero when the plugin does not
+// implement RatelimitBackoff
+func AllowWithBackoff(r Ratelimit, typ RatelimitType, key string) (bool, time.Duration) {
+	if r == nil {
+		return true, 0
+	}
+	if b, ok := r.(RatelimitBackoff); ok {
+		return b.AllowWithBackoff(typ, key)
+	}
+	return r.Allow(typ, key), 0
+}
+
 // GetRatelimit Get the Ratelimit plugin
 func GetRatelimit() Ratelimit {
 	c := &config.RateLimit
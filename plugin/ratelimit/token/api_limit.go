@@ -20,6 +20,7 @@ package token
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -29,6 +30,7 @@ type apiRatelimit struct {
 	rules  map[string]*BucketRatelimit // 存储规则
 	apis   sync.Map                    // 存储api -> apiLimiter
 	config *APILimitConfig
+	shared sharedRef // 集群限流下所有节点共享的令牌桶
 }
 
 // newAPIRatelimit 新建一个接口限流类
@@ -113,6 +115,22 @@ func (art *apiRatelimit) createLimiter(name string, limit *BucketRatelimit) *api
 	return limiter
 }
 
+// setShared 实现sharableLimiter，使用所有节点共享的令牌桶
+func (art *apiRatelimit) setShared(shared sharedLimiter) {
+	art.shared.store(shared)
+}
+
+// setScale 实现scalableLimiter，按照比例调整所有接口的令牌桶
+func (art *apiRatelimit) setScale(scale float64) {
+	art.apis.Range(func(_, value interface{}) bool {
+		limiter := value.(*apiLimiter)
+		if limiter.open {
+			scaleLimiter(limiter.Limiter, limiter.rate, limiter.bucket, scale)
+		}
+		return true
+	})
+}
+
 // 获取limiter
 func (art *apiRatelimit) acquireLimiter(name string) *apiLimiter {
 	if value, ok := art.apis.Load(name); ok {
@@ -135,13 +153,25 @@ func (art *apiRatelimit) allow(name string) bool {
 		return true
 	}
 
+	pass, _ := art.reserve(name)
+	return pass
+}
+
+// 令牌桶限流，被限流时返回需要等待的时间
+func (art *apiRatelimit) reserve(name string) (bool, time.Duration) {
+	if !art.isOpen() {
+		return true, 0
+	}
+
 	limiter := art.acquireLimiter(name)
-	if limiter == nil {
-		// 找不到limiter，默认返回true
-		return true
+	if limiter == nil || !limiter.open {
+		return true, 0
+	}
+	if pass, wait, ok := reserveShared(art.shared.load(), "api|"+name, limiter.rate, limiter.bucket); ok {
+		return pass, wait
 	}
 
-	return limiter.Allow()
+	return reserveToken(limiter.Limiter)
 }
 
 // 封装rate.Limiter
@@ -149,6 +179,8 @@ func (art *apiRatelimit) allow(name string) bool {
 type apiLimiter struct {
 	open          bool   // 该接口是否开启限流
 	name          string // 接口名
+	rate          int    // 配置的每秒令牌数
+	bucket        int    // 配置的令牌桶大小
 	*rate.Limiter        // 令牌桶对象
 }
 
@@ -164,6 +196,8 @@ func newAPILimiter(name string, open bool, r int, b int) *apiLimiter {
 	}
 
 	limiter.open = true
+	limiter.rate = r
+	limiter.bucket = b
 	limiter.Limiter = rate.NewLimiter(rate.Limit(r), b)
 	return limiter
 }
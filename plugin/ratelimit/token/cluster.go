@@ -0,0 +1,222 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/time/rate"
+
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/redispool"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// sharedBucketKeyPrefix 共享令牌桶在 redis 中的 key 前缀
+	sharedBucketKeyPrefix = "polaris_ratelimit|"
+	// sharedBucketTimeout 访问共享令牌桶的超时时间，超时后退化为本地限流
+	sharedBucketTimeout = 200 * time.Millisecond
+	// sharedBucketBackoff 访问共享令牌桶失败后，在该时间内所有请求直接使用本地令牌桶，不再访问 redis
+	sharedBucketBackoff = 5 * time.Second
+)
+
+// sharedLimiter 所有北极星节点共享的令牌桶，各个节点从同一个令牌桶中获取令牌
+type sharedLimiter interface {
+	// reserve 从 key 对应的令牌桶中获取一个令牌，r 为每秒加入的令牌数，b 为令牌桶大小，获取不到时返回需要等待的时间
+	reserve(key string, r int, b int) (bool, time.Duration, error)
+}
+
+// sharableLimiter 支持使用共享令牌桶的限制器
+type sharableLimiter interface {
+	setShared(shared sharedLimiter)
+}
+
+// sharedRef 限制器引用的共享令牌桶，支持并发替换
+type sharedRef struct {
+	p atomic.Pointer[sharedLimiter]
+}
+
+func (r *sharedRef) store(shared sharedLimiter) {
+	r.p.Store(&shared)
+}
+
+func (r *sharedRef) load() sharedLimiter {
+	if p := r.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// scalableLimiter 支持按照比例调整配额的限制器，用于共享令牌桶不可用时的本地限流
+type scalableLimiter interface {
+	setScale(scale float64)
+}
+
+// scaleLimiter 按照比例调整令牌桶，令牌桶大小至少保留一个令牌。scale 为 0 表示还没有获取到集群的节点列表，
+// 无法确定本节点的配额，此时本地令牌桶拒绝所有请求，避免每个节点都使用整个集群的配额
+func scaleLimiter(limiter *rate.Limiter, r int, b int, scale float64) {
+	if scale == 0 {
+		limiter.SetLimit(0)
+		limiter.SetBurst(0)
+		return
+	}
+	burst := int(math.Ceil(float64(b) * scale))
+	if burst < 1 {
+		burst = 1
+	}
+	limiter.SetLimit(rate.Limit(float64(r) * scale))
+	limiter.SetBurst(burst)
+}
+
+// reserveShared 优先从共享令牌桶中获取令牌，返回 false 表示共享令牌桶不可用，需要使用本地令牌桶
+func reserveShared(shared sharedLimiter, key string, r int, b int) (bool, time.Duration, bool) {
+	if shared == nil {
+		return false, 0, false
+	}
+	// 访问失败的错误日志由共享令牌桶在进入退避时输出一次，这里直接退化为本地令牌桶
+	pass, wait, err := shared.reserve(key, r, b)
+	if err != nil {
+		return false, 0, false
+	}
+	return pass, wait, true
+}
+
+// redisSharedLimiter 基于 redis 的共享令牌桶，redis 访问失败后在退避时间内不再访问 redis
+type redisSharedLimiter struct {
+	quota *redispool.QuotaClient
+}
+
+func newRedisSharedLimiter(client redis.UniversalClient) sharedLimiter {
+	return &redisSharedLimiter{
+		quota: redispool.NewQuotaClient(client, sharedBucketTimeout, sharedBucketBackoff),
+	}
+}
+
+// reserve 实现 sharedLimiter
+func (l *redisSharedLimiter) reserve(key string, r int, b int) (bool, time.Duration, error) {
+	return l.quota.ReserveToken(sharedBucketKeyPrefix+key, r, b)
+}
+
+// fetchClusterNodes 获取北极星集群的节点列表
+var fetchClusterNodes = func(namespace, service string) ([]*model.Instance, error) {
+	cacheMgr, err := cache.GetCacheManager()
+	if err != nil {
+		return nil, err
+	}
+	svc := cacheMgr.Service().GetServiceByName(service, namespace)
+	if svc == nil {
+		return nil, errors.New("polaris cluster service not found")
+	}
+	return cacheMgr.Instance().GetInstancesByServiceID(svc.ID), nil
+}
+
+// clusterAllocator 集群配额分配器，所有节点通过 redis 中的共享令牌桶限流，限流规则中的配额即为整个集群的总配额。
+// 共享令牌桶不可用时，各个节点按照自己在北极星集群中的权重比例使用本地令牌桶限流
+type clusterAllocator struct {
+	tb     *tokenBucket
+	conf   *ClusterLimitConfig
+	shared sharedLimiter
+	// scale 本节点的配额比例，为 0 表示还没有获取到集群的节点列表
+	scale  float64
+	cancel context.CancelFunc
+}
+
+// newClusterAllocator 创建集群配额分配器
+func newClusterAllocator(tb *tokenBucket, conf *ClusterLimitConfig) (*clusterAllocator, error) {
+	conf.setDefault()
+	redisConfig, err := conf.parseRedis()
+	if err != nil {
+		return nil, err
+	}
+	return &clusterAllocator{
+		tb:     tb,
+		conf:   conf,
+		shared: newRedisSharedLimiter(redispool.NewRedisClient(redisConfig)),
+	}, nil
+}
+
+// run 定时重新计算本节点在共享令牌桶不可用时的配额比例
+func (c *clusterAllocator) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.tb.setShared(c.shared)
+	// 获取到节点列表之前不知道本节点的配额，共享令牌桶不可用时拒绝请求而不是使用全部的配额
+	c.tb.setScale(c.scale)
+	c.refresh()
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(c.conf.RefreshInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.refresh()
+			}
+		}
+	}()
+}
+
+// stop 停止配额分配
+func (c *clusterAllocator) stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// refresh 重新计算配额比例，比例发生变化时调整所有的限制器
+func (c *clusterAllocator) refresh() {
+	scale := c.computeScale()
+	if math.Abs(scale-c.scale) < 1e-6 {
+		return
+	}
+	log.Infof("[Plugin][%s] cluster fallback quota scale change from %.4f to %.4f", PluginName, c.scale, scale)
+	c.scale = scale
+	c.tb.setScale(scale)
+}
+
+// computeScale 计算本节点的配额比例。无法获取节点列表时沿用上一次的比例，从未获取成功时比例为 0，
+// 本节点不在列表中时按照平均权重估算本节点的比例，避免每个节点都使用全部的配额
+func (c *clusterAllocator) computeScale() float64 {
+	nodes, err := fetchClusterNodes(c.conf.Namespace, c.conf.Service)
+	if err != nil {
+		log.Errorf("[Plugin][%s] fetch cluster nodes fail, keep fallback quota scale %.4f: %s",
+			PluginName, c.scale, err.Error())
+		return c.scale
+	}
+
+	scale, ok := model.HostWeightRatio(nodes, utils.LocalHost)
+	if scale == 0 {
+		log.Errorf("[Plugin][%s] no available cluster nodes, keep fallback quota scale %.4f",
+			PluginName, c.scale)
+		return c.scale
+	}
+	if !ok {
+		log.Errorf("[Plugin][%s] local host %s not in cluster nodes, estimate fallback quota scale %.4f",
+			PluginName, utils.LocalHost, scale)
+	}
+	return scale
+}
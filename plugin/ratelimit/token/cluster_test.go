@@ -0,0 +1,173 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+func mockClusterNode(host string, healthy bool) *model.Instance {
+	return &model.Instance{
+		Proto: &apiservice.Instance{
+			Host:    utils.NewStringValue(host),
+			Port:    utils.NewUInt32Value(8091),
+			Weight:  utils.NewUInt32Value(100),
+			Healthy: utils.NewBoolValue(healthy),
+		},
+	}
+}
+
+// mockSharedLimiter 模拟 redis 中的共享令牌桶，令牌不会补充
+type mockSharedLimiter struct {
+	lock   sync.Mutex
+	used   map[string]int
+	broken bool
+}
+
+func (m *mockSharedLimiter) reserve(key string, r int, b int) (bool, time.Duration, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.broken {
+		return false, 0, errors.New("mock redis error")
+	}
+	if m.used[key] >= b {
+		return false, time.Second / time.Duration(r), nil
+	}
+	m.used[key]++
+	return true, 0, nil
+}
+
+// TestClusterAllocator 测试集群限流的配额分配
+func TestClusterAllocator(t *testing.T) {
+	var (
+		nodes    []*model.Instance
+		fetchErr error
+	)
+	oldFetch := fetchClusterNodes
+	defer func() {
+		fetchClusterNodes = oldFetch
+	}()
+	fetchClusterNodes = func(namespace, service string) ([]*model.Instance, error) {
+		return nodes, fetchErr
+	}
+
+	countAllow := func(tb *tokenBucket, key string) int {
+		cnt := 0
+		for i := 0; i < 20; i++ {
+			if tb.Allow(plugin.IPRatelimit, key) {
+				cnt++
+			}
+		}
+		return cnt
+	}
+	newTokenBucket := func(shared sharedLimiter) *tokenBucket {
+		option := baseConfigOption()
+		option["cluster"] = &ClusterLimitConfig{
+			Open:  true,
+			Redis: map[string]interface{}{"kvAddr": "127.0.0.1:6379"},
+		}
+		tb := &tokenBucket{}
+		if err := tb.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: option}); err != nil {
+			t.Fatalf("error: %s", err.Error())
+		}
+		tb.setShared(shared)
+		return tb
+	}
+
+	nodes = []*model.Instance{
+		mockClusterNode(utils.LocalHost, true),
+		mockClusterNode("127.0.0.2", true),
+		mockClusterNode("127.0.0.3", true),
+	}
+	shared := &mockSharedLimiter{used: map[string]int{}}
+	tb := newTokenBucket(shared)
+	defer func() {
+		_ = tb.Destroy()
+	}()
+	peer := newTokenBucket(shared)
+	defer func() {
+		_ = peer.Destroy()
+	}()
+
+	Convey("未配置 redis 时无法开启集群限流", t, func() {
+		option := baseConfigOption()
+		option["cluster"] = &ClusterLimitConfig{Open: true}
+		err := (&tokenBucket{}).Initialize(&plugin.ConfigEntry{Name: PluginName, Option: option})
+		So(err, ShouldNotBeNil)
+	})
+	Convey("所有节点共享同一个令牌桶", t, func() {
+		// ip-limit 的令牌桶大小为 10，两个节点一共只能通过 10 个请求
+		So(countAllow(tb, "1.1.1.1")+countAllow(peer, "1.1.1.1"), ShouldEqual, 10)
+		pass, backoff := tb.AllowWithBackoff(plugin.IPRatelimit, "1.1.1.1")
+		So(pass, ShouldBeFalse)
+		So(backoff, ShouldBeGreaterThan, 0)
+	})
+
+	shared.broken = true
+	Convey("redis 不可用时三个健康节点平分配额", t, func() {
+		So(tb.cluster.scale, ShouldAlmostEqual, 1.0/3, 1e-6)
+		// ip-limit 的令牌桶大小为 10，本节点只能分到 4 个
+		So(countAllow(tb, "2.2.2.2"), ShouldEqual, 4)
+	})
+	Convey("不健康的节点不参与分配", t, func() {
+		nodes[2] = mockClusterNode("127.0.0.3", false)
+		tb.cluster.refresh()
+		So(tb.cluster.scale, ShouldAlmostEqual, 0.5, 1e-6)
+		So(countAllow(tb, "3.3.3.3"), ShouldEqual, 5)
+	})
+	Convey("获取节点列表失败，沿用上一次的配额比例", t, func() {
+		fetchErr = errors.New("mock error")
+		tb.cluster.refresh()
+		So(tb.cluster.scale, ShouldAlmostEqual, 0.5, 1e-6)
+		So(countAllow(tb, "4.4.4.4"), ShouldEqual, 5)
+	})
+	Convey("本节点不在节点列表中，按照平均权重估算配额比例", t, func() {
+		fetchErr = nil
+		nodes = []*model.Instance{
+			mockClusterNode("127.0.0.2", true),
+			mockClusterNode("127.0.0.3", true),
+		}
+		tb.cluster.refresh()
+		So(tb.cluster.scale, ShouldAlmostEqual, 1.0/3, 1e-6)
+		So(countAllow(tb, "5.5.5.5"), ShouldEqual, 4)
+	})
+	Convey("还没有获取到节点列表时 redis 不可用，拒绝所有请求", t, func() {
+		fetchErr = errors.New("mock error")
+		unknown := newTokenBucket(shared)
+		defer func() {
+			_ = unknown.Destroy()
+		}()
+		So(unknown.cluster.scale, ShouldEqual, 0)
+		So(countAllow(unknown, "6.6.6.6"), ShouldEqual, 0)
+
+		fetchErr = nil
+		unknown.cluster.refresh()
+		So(unknown.cluster.scale, ShouldAlmostEqual, 1.0/3, 1e-6)
+		So(countAllow(unknown, "7.7.7.7"), ShouldEqual, 4)
+	})
+}
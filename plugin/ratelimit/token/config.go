@@ -18,11 +18,15 @@
 package token
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/yaml.v2"
+
+	"github.com/polarismesh/polaris/common/redispool"
 )
 
 // Config 限流配置类
@@ -31,14 +35,121 @@ type Config struct {
 	Enable bool `yaml:"enable" mapstructure:"enable"`
 	// RuleFile
 	RuleFile string `yaml:"rule-file" mapstructure:"rule-file"`
-	// 是否启用远程配置，默认false。TODO 暂时无远程配置，后续版本补全，如果开启，则默认监听 Polaris/polaris-system 分组下的 plugin-ratelimit.yaml 配置文件
+	// 是否启用远程配置，默认false。如果开启，则默认监听 Polaris/polaris-system 分组下的 plugin-ratelimit.yaml 配置文件，
+	// 配置文件发布后热更新限流规则
 	RemoteConf bool `yaml:"remote-conf" mapstructure:"remote-conf"`
+	// 远程配置文件的位置，不填写时使用默认的配置文件
+	RemoteFile *RemoteFileConfig `yaml:"remote-file" mapstructure:"remote-file"`
+	// 集群限流配置，开启后限流规则中的配额视为整个北极星集群的总配额，所有节点共享 redis 中的令牌桶
+	ClusterConf *ClusterLimitConfig `yaml:"cluster" mapstructure:"cluster"`
 	// IP限流相关配置
 	IPLimitConf *ResourceLimitConfig `yaml:"ip-limit" mapstructure:"ip-limit"`
 	// 接口限流相关配置
 	APILimitConf *APILimitConfig `yaml:"api-limit" mapstructure:"api-limit"`
 	// 基于实例的限流配置
 	InstanceLimitConf *ResourceLimitConfig `yaml:"instance-limit" mapstructure:"instance-limit"`
+	// 基于操作者（用户/用户组）的限流配置，资源的格式为 user:{用户名} 或者 group:{用户组名}
+	PrincipalLimitConf *ResourceLimitConfig `yaml:"principal-limit" mapstructure:"principal-limit"`
+	// 基于命名空间的限流配置
+	NamespaceLimitConf *ResourceLimitConfig `yaml:"namespace-limit" mapstructure:"namespace-limit"`
+}
+
+// ClusterLimitConfig 集群限流配置，所有节点从 redis 中的同一个令牌桶获取令牌，因此不论请求落到哪个节点，
+// 整个集群的通过量都不会超过配置的配额。redis 不可用时，各个节点按照自己在北极星集群中的权重比例分摊配额，
+// 使用本地令牌桶限流，并且在 5 秒内不再访问 redis；还没有获取到集群节点列表时无法确定本节点的配额，拒绝请求
+type ClusterLimitConfig struct {
+	// 是否开启集群限流
+	Open bool `yaml:"open" mapstructure:"open"`
+	// 保存共享令牌桶的 redis，开启集群限流时必须配置
+	Redis map[string]interface{} `yaml:"redis" mapstructure:"redis"`
+	// 北极星节点所在的命名空间，默认为 Polaris
+	Namespace string `yaml:"namespace" mapstructure:"namespace"`
+	// 北极星节点所在的服务，默认为 polaris.checker
+	Service string `yaml:"service" mapstructure:"service"`
+	// 重新计算 redis 不可用时本节点配额比例的周期，单位为秒，默认为 5 秒
+	RefreshInterval int `yaml:"refresh-interval" mapstructure:"refresh-interval"`
+}
+
+const (
+	// DefaultClusterNamespace 默认北极星节点所在的命名空间
+	DefaultClusterNamespace = "Polaris"
+	// DefaultClusterService 默认北极星节点所在的服务
+	DefaultClusterService = "polaris.checker"
+	// DefaultClusterRefreshInterval 默认重新分配配额的周期
+	DefaultClusterRefreshInterval = 5
+)
+
+// setDefault 填充集群限流配置的默认值
+func (c *ClusterLimitConfig) setDefault() {
+	if c.Namespace == "" {
+		c.Namespace = DefaultClusterNamespace
+	}
+	if c.Service == "" {
+		c.Service = DefaultClusterService
+	}
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = DefaultClusterRefreshInterval
+	}
+}
+
+// parseRedis 解析共享令牌桶使用的 redis 配置
+func (c *ClusterLimitConfig) parseRedis() (*redispool.Config, error) {
+	if len(c.Redis) == 0 {
+		return nil, errors.New("cluster ratelimit requires redis to share the quota")
+	}
+	data, err := json.Marshal(c.Redis)
+	if err != nil {
+		return nil, err
+	}
+	redisConfig := &redispool.Config{}
+	if err := json.Unmarshal(data, redisConfig); err != nil {
+		return nil, err
+	}
+	if err := redisConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return redisConfig, nil
+}
+
+// RemoteFileConfig 配置中心上限流规则文件的位置
+type RemoteFileConfig struct {
+	// 命名空间
+	Namespace string `yaml:"namespace" mapstructure:"namespace"`
+	// 配置分组
+	Group string `yaml:"group" mapstructure:"group"`
+	// 配置文件名
+	FileName string `yaml:"file-name" mapstructure:"file-name"`
+}
+
+const (
+	// DefaultRemoteNamespace 默认远程配置所在的命名空间
+	DefaultRemoteNamespace = "Polaris"
+	// DefaultRemoteGroup 默认远程配置所在的分组
+	DefaultRemoteGroup = "polaris-system"
+	// DefaultRemoteFileName 默认远程配置的文件名
+	DefaultRemoteFileName = "plugin-ratelimit.yaml"
+)
+
+// remoteFile 返回需要监听的远程配置文件，未配置的字段使用默认值
+func (c *Config) remoteFile() *RemoteFileConfig {
+	ret := &RemoteFileConfig{
+		Namespace: DefaultRemoteNamespace,
+		Group:     DefaultRemoteGroup,
+		FileName:  DefaultRemoteFileName,
+	}
+	if c.RemoteFile == nil {
+		return ret
+	}
+	if c.RemoteFile.Namespace != "" {
+		ret.Namespace = c.RemoteFile.Namespace
+	}
+	if c.RemoteFile.Group != "" {
+		ret.Group = c.RemoteFile.Group
+	}
+	if c.RemoteFile.FileName != "" {
+		ret.FileName = c.RemoteFile.FileName
+	}
+	return ret
 }
 
 // BucketRatelimit 针对令牌桶的具体配置
@@ -62,6 +173,16 @@ type ResourceLimitConfig struct {
 	MaxResourceCacheAmount int `yaml:"resource-cache-amount" mapstructure:"resource-cache-amount"`
 	// 白名单
 	WhiteList []string `yaml:"white-list" mapstructure:"white-list"`
+	// 针对部分资源单独设置的限制规则，优先级高于 Global
+	Rules []*ResourceLimitRule `yaml:"rules" mapstructure:"rules"`
+}
+
+// ResourceLimitRule 针对具体资源的限流规则
+type ResourceLimitRule struct {
+	// 规则生效的资源列表
+	Resources []string `yaml:"resources" mapstructure:"resources"`
+	// 规则的限制，open 为 false 时表示这些资源不限流
+	Limit *BucketRatelimit `yaml:"limit" mapstructure:"limit"`
 }
 
 // APILimitConfig api限流配置
@@ -109,6 +230,11 @@ func loadLocalConfig(file string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseRuleConfig(data)
+}
+
+// parseRuleConfig 解析 yaml 格式的限流规则
+func parseRuleConfig(data []byte) (*Config, error) {
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		log.Errorf("[Plugin][%s] decode config err: %s", PluginName, err.Error())
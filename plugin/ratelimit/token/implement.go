@@ -18,58 +18,149 @@
 package token
 
 import (
+	"time"
+
 	"github.com/polarismesh/polaris/plugin"
 )
 
 // initialize 插件初始化函数
 func (tb *tokenBucket) initialize(c *plugin.ConfigEntry) error {
-	config, err := decodeConfig(c.Option)
+	option, err := decodeConfig(c.Option)
 	if err != nil {
 		log.Errorf("[Plugin][%s] initialize err: %s", PluginName, err.Error())
 		return err
 	}
-	if !config.Enable {
-		tb.config = config
+	if !option.Enable {
+		tb.config = option
 		return nil
 	}
+	config := option
 	// 加载本地配置
-	if config.RuleFile != "" {
-		config, err = loadLocalConfig(config.RuleFile)
+	if option.RuleFile != "" {
+		config, err = loadLocalConfig(option.RuleFile)
 		if err != nil {
 			log.Errorf("[Plugin][%s] load local rule fail err: %s", PluginName, err.Error())
 			return err
 		}
+		config.Enable = option.Enable
+	}
+	// 远程配置以及集群限流的开关以插件配置为准
+	config.RemoteConf = option.RemoteConf
+	config.RemoteFile = option.RemoteFile
+	config.ClusterConf = option.ClusterConf
+
+	limiters, err := newLimiters(config)
+	if err != nil {
+		return err
+	}
+	tb.config = config
+	tb.setLimiters(limiters)
+
+	if config.ClusterConf != nil && config.ClusterConf.Open {
+		if tb.cluster, err = newClusterAllocator(tb, config.ClusterConf); err != nil {
+			log.Errorf("[Plugin][%s] initialize cluster ratelimit err: %s", PluginName, err.Error())
+			return err
+		}
+		tb.cluster.run()
 	}
+
 	// 注册一个配置中心的 Change Event
 	if config.RemoteConf {
-		// TODO 监听规则
+		if err := tb.watchRemoteConfig(config.remoteFile()); err != nil {
+			log.Errorf("[Plugin][%s] watch remote rule fail err: %s", PluginName, err.Error())
+			return err
+		}
 	}
+	return nil
+}
 
-	tb.config = config
-	tb.limiters = make(map[plugin.RatelimitType]limiter)
+// newLimiters 根据限流配置创建各个维度的限制器
+func newLimiters(config *Config) (map[plugin.RatelimitType]limiter, error) {
+	limiters := make(map[plugin.RatelimitType]limiter)
 
 	// IP限流
 	irt, err := newResourceRatelimit(plugin.IPRatelimit, config.IPLimitConf)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tb.limiters[plugin.IPRatelimit] = irt
+	limiters[plugin.IPRatelimit] = irt
 
 	// 接口限流
 	art, err := newAPIRatelimit(config.APILimitConf)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tb.limiters[plugin.APIRatelimit] = art
+	limiters[plugin.APIRatelimit] = art
 
 	// 操作实例限流
 	instance, err := newResourceRatelimit(plugin.InstanceRatelimit, config.InstanceLimitConf)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	tb.limiters[plugin.InstanceRatelimit] = instance
+	limiters[plugin.InstanceRatelimit] = instance
 
-	return nil
+	// 操作者限流
+	principal, err := newResourceRatelimit(plugin.PrincipalRatelimit, config.PrincipalLimitConf)
+	if err != nil {
+		return nil, err
+	}
+	limiters[plugin.PrincipalRatelimit] = principal
+
+	// 命名空间限流
+	namespace, err := newResourceRatelimit(plugin.NamespaceRatelimit, config.NamespaceLimitConf)
+	if err != nil {
+		return nil, err
+	}
+	limiters[plugin.NamespaceRatelimit] = namespace
+
+	return limiters, nil
+}
+
+// setLimiters 替换当前生效的限制器，新的限制器沿用当前集群限流的共享令牌桶以及配额比例
+func (tb *tokenBucket) setLimiters(limiters map[plugin.RatelimitType]limiter) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	for _, l := range limiters {
+		if sl, ok := l.(sharableLimiter); ok && tb.shared != nil {
+			sl.setShared(tb.shared)
+		}
+		if sl, ok := l.(scalableLimiter); ok && tb.cluster != nil {
+			sl.setScale(tb.scale)
+		}
+	}
+	tb.limiters = limiters
+}
+
+// setShared 所有限制器使用共享令牌桶
+func (tb *tokenBucket) setShared(shared sharedLimiter) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	tb.shared = shared
+	for _, l := range tb.limiters {
+		if sl, ok := l.(sharableLimiter); ok {
+			sl.setShared(shared)
+		}
+	}
+}
+
+// setScale 调整所有限制器的配额比例
+func (tb *tokenBucket) setScale(scale float64) {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+	tb.scale = scale
+	for _, l := range tb.limiters {
+		if sl, ok := l.(scalableLimiter); ok {
+			sl.setScale(scale)
+		}
+	}
+}
+
+// getLimiter 获取某个维度的限制器
+func (tb *tokenBucket) getLimiter(typ plugin.RatelimitType) (limiter, bool) {
+	tb.lock.RLock()
+	defer tb.lock.RUnlock()
+	l, ok := tb.limiters[typ]
+	return l, ok
 }
 
 // allow 插件的限流实现函数
@@ -78,10 +169,37 @@ func (tb *tokenBucket) allow(typ plugin.RatelimitType, key string) bool {
 	if key == "" {
 		return true
 	}
-	l, ok := tb.limiters[typ]
+	l, ok := tb.getLimiter(typ)
 	if !ok {
 		return true
 	}
 
 	return l.allow(key)
 }
+
+// reserve 插件的限流实现函数，被限流时返回需要等待的时间
+func (tb *tokenBucket) reserve(typ plugin.RatelimitType, key string) (bool, time.Duration) {
+	if key == "" {
+		return true, 0
+	}
+	l, ok := tb.getLimiter(typ)
+	if !ok {
+		return true, 0
+	}
+	if bl, ok := l.(backoffLimiter); ok {
+		return bl.reserve(key)
+	}
+	return l.allow(key), 0
+}
+
+// enabled 某个维度的限流是否开启
+func (tb *tokenBucket) enabled(typ plugin.RatelimitType) bool {
+	l, ok := tb.getLimiter(typ)
+	if !ok {
+		return false
+	}
+	if bl, ok := l.(backoffLimiter); ok {
+		return bl.isOpen()
+	}
+	return true
+}
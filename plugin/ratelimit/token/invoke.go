@@ -18,13 +18,24 @@
 package token
 
 import (
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/plugin"
 )
 
 // tokenBucket 实现Plugin接口
 type tokenBucket struct {
 	config   *Config
+	lock     sync.RWMutex
 	limiters map[plugin.RatelimitType]limiter
+	// 集群限流下所有节点共享的令牌桶
+	shared sharedLimiter
+	// 集群限流下共享令牌桶不可用时本节点分配到的配额比例
+	scale   float64
+	subCtx  *eventhub.SubscribtionContext
+	cluster *clusterAllocator
 }
 
 // Name 实现Plugin接口，Name方法
@@ -39,6 +50,12 @@ func (tb *tokenBucket) Initialize(c *plugin.ConfigEntry) error {
 
 // Destroy 实现Plugin接口，Destroy方法
 func (tb *tokenBucket) Destroy() error {
+	if tb.subCtx != nil {
+		tb.subCtx.Cancel()
+	}
+	if tb.cluster != nil {
+		tb.cluster.stop()
+	}
 	return nil
 }
 
@@ -49,3 +66,19 @@ func (tb *tokenBucket) Allow(typ plugin.RatelimitType, key string) bool {
 	}
 	return tb.allow(typ, key)
 }
+
+// Enabled 实现 plugin.RatelimitBackoff 接口，判断某个维度的限流是否开启
+func (tb *tokenBucket) Enabled(typ plugin.RatelimitType) bool {
+	if !tb.config.Enable {
+		return false
+	}
+	return tb.enabled(typ)
+}
+
+// AllowWithBackoff 实现 plugin.RatelimitBackoff 接口，被限流时返回客户端需要等待的时间
+func (tb *tokenBucket) AllowWithBackoff(typ plugin.RatelimitType, key string) (bool, time.Duration) {
+	if !tb.config.Enable {
+		return true, 0
+	}
+	return tb.reserve(typ, key)
+}
@@ -17,7 +17,18 @@
 
 package token
 
+import "time"
+
 // limiter 限制器
 type limiter interface {
 	allow(key string) bool
 }
+
+// backoffLimiter 被限流时可以给出建议等待时间的限制器
+type backoffLimiter interface {
+	limiter
+	// reserve 是否允许访问，不允许时返回需要等待的时间
+	reserve(key string) (bool, time.Duration)
+	// isOpen 限制器是否开启
+	isOpen() bool
+}
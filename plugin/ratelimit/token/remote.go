@@ -0,0 +1,109 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"context"
+	"errors"
+
+	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+)
+
+// remoteWatcherDurable 远程限流规则文件监听的持久订阅名称
+const remoteWatcherDurable = "ratelimit-token-remote-rule"
+
+// fetchRemoteRelease 从配置中心获取已发布的限流规则文件
+var fetchRemoteRelease = func(file *RemoteFileConfig) (*model.ConfigFileRelease, error) {
+	cacheMgr, err := cache.GetCacheManager()
+	if err != nil {
+		return nil, err
+	}
+	return cacheMgr.ConfigFile().GetActiveRelease(file.Namespace, file.Group, file.FileName), nil
+}
+
+// remoteWatcher 监听配置中心上的限流规则文件
+type remoteWatcher struct {
+	tb   *tokenBucket
+	file *RemoteFileConfig
+}
+
+// watchRemoteConfig 监听远程配置文件的发布事件，先尝试加载一次当前已发布的规则
+func (tb *tokenBucket) watchRemoteConfig(file *RemoteFileConfig) error {
+	w := &remoteWatcher{tb: tb, file: file}
+	subCtx, err := eventhub.Subscribe(eventhub.ConfigFilePublishTopic, w, eventhub.WithDurable(remoteWatcherDurable))
+	if err != nil {
+		return err
+	}
+	tb.subCtx = subCtx
+	if err := w.reload(); err != nil {
+		log.Warnf("[Plugin][%s] load remote rule %s/%s/%s fail, keep local rule: %s", PluginName,
+			file.Namespace, file.Group, file.FileName, err.Error())
+	}
+	return nil
+}
+
+// PreProcess do preprocess logic for event
+func (w *remoteWatcher) PreProcess(_ context.Context, e any) any {
+	return e
+}
+
+// OnEvent event process logic
+func (w *remoteWatcher) OnEvent(_ context.Context, arg any) error {
+	event, ok := arg.(*eventhub.PublishConfigFileEvent)
+	if !ok || event.Message == nil || event.Message.ConfigFileReleaseKey == nil {
+		return nil
+	}
+	key := event.Message.ConfigFileReleaseKey
+	if key.ReleaseType != model.ReleaseTypeFull || key.Namespace != w.file.Namespace ||
+		key.Group != w.file.Group || key.FileName != w.file.FileName {
+		return nil
+	}
+	if err := w.reload(); err != nil {
+		log.Errorf("[Plugin][%s] reload remote rule %s/%s/%s fail: %s", PluginName,
+			key.Namespace, key.Group, key.FileName, err.Error())
+	}
+	return nil
+}
+
+// reload 拉取远程配置文件并重建限制器，解析失败时保留原有的规则
+func (w *remoteWatcher) reload() error {
+	release, err := fetchRemoteRelease(w.file)
+	if err != nil {
+		return err
+	}
+	if release == nil {
+		return errors.New("remote rule file not release")
+	}
+	if release.GetEncryptAlgo() != "" {
+		return errors.New("encrypted remote rule file is not supported")
+	}
+	config, err := parseRuleConfig([]byte(release.Content))
+	if err != nil {
+		return err
+	}
+	limiters, err := newLimiters(config)
+	if err != nil {
+		return err
+	}
+	w.tb.setLimiters(limiters)
+	log.Infof("[Plugin][%s] reload remote rule %s/%s/%s success, version %d", PluginName,
+		w.file.Namespace, w.file.Group, w.file.FileName, release.Version)
+	return nil
+}
@@ -0,0 +1,117 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/plugin"
+)
+
+const testRemoteRule = `
+namespace-limit:
+  open: true
+  global:
+    open: true
+    bucket: 2
+    rate: 1
+  resource-cache-amount: 10
+`
+
+// TestRemoteWatcher 测试远程规则热更新
+func TestRemoteWatcher(t *testing.T) {
+	var content string
+	oldFetch := fetchRemoteRelease
+	defer func() {
+		fetchRemoteRelease = oldFetch
+	}()
+	fetchRemoteRelease = func(file *RemoteFileConfig) (*model.ConfigFileRelease, error) {
+		if content == "" {
+			return nil, errors.New("not found")
+		}
+		return &model.ConfigFileRelease{
+			SimpleConfigFileRelease: &model.SimpleConfigFileRelease{
+				ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
+					Namespace: file.Namespace,
+					Group:     file.Group,
+					FileName:  file.FileName,
+				},
+			},
+			Content: content,
+		}, nil
+	}
+
+	tb := &tokenBucket{}
+	if err := tb.Initialize(&plugin.ConfigEntry{Name: PluginName, Option: baseConfigOption()}); err != nil {
+		t.Fatalf("error: %s", err.Error())
+	}
+	w := &remoteWatcher{tb: tb, file: (&Config{}).remoteFile()}
+
+	Convey("远程规则发布后热更新", t, func() {
+		So(tb.Enabled(plugin.NamespaceRatelimit), ShouldBeFalse)
+		content = testRemoteRule
+		event := &eventhub.PublishConfigFileEvent{
+			Message: &model.SimpleConfigFileRelease{
+				ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
+					Namespace: DefaultRemoteNamespace,
+					Group:     DefaultRemoteGroup,
+					FileName:  DefaultRemoteFileName,
+				},
+			},
+		}
+		So(w.OnEvent(context.Background(), event), ShouldBeNil)
+		So(tb.Enabled(plugin.NamespaceRatelimit), ShouldBeTrue)
+		So(tb.Enabled(plugin.IPRatelimit), ShouldBeFalse)
+
+		cnt := 0
+		for i := 0; i < 5; i++ {
+			if ok, _ := tb.AllowWithBackoff(plugin.NamespaceRatelimit, "default"); ok {
+				cnt++
+			}
+		}
+		So(cnt, ShouldEqual, 2)
+		ok, backoff := tb.AllowWithBackoff(plugin.NamespaceRatelimit, "default")
+		So(ok, ShouldBeFalse)
+		So(backoff, ShouldBeGreaterThan, 0)
+	})
+	Convey("非法的远程规则不影响当前规则", t, func() {
+		content = "namespace-limit:\n  open: true\n"
+		So(w.reload(), ShouldNotBeNil)
+		So(tb.Enabled(plugin.NamespaceRatelimit), ShouldBeTrue)
+	})
+	Convey("其他配置文件的发布事件忽略", t, func() {
+		content = "ip-limit:\n  open: false\n"
+		event := &eventhub.PublishConfigFileEvent{
+			Message: &model.SimpleConfigFileRelease{
+				ConfigFileReleaseKey: &model.ConfigFileReleaseKey{
+					Namespace: DefaultRemoteNamespace,
+					Group:     DefaultRemoteGroup,
+					FileName:  "other.yaml",
+				},
+			},
+		}
+		So(w.OnEvent(context.Background(), event), ShouldBeNil)
+		So(tb.Enabled(plugin.NamespaceRatelimit), ShouldBeTrue)
+	})
+}
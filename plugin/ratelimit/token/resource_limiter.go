@@ -19,6 +19,9 @@ package token
 
 import (
 	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"golang.org/x/time/rate"
@@ -31,12 +34,18 @@ type resourceRatelimit struct {
 	typStr    string
 	resources *lru.Cache
 	whiteList map[string]bool
+	rules     map[string]*BucketRatelimit
 	config    *ResourceLimitConfig
+	// 集群限流下所有节点共享的令牌桶
+	shared sharedRef
+	// 集群限流下共享令牌桶不可用时本节点分配到的配额比例
+	scale atomic.Uint64
 }
 
 // 新建资源限制器
 func newResourceRatelimit(typ plugin.RatelimitType, config *ResourceLimitConfig) (*resourceRatelimit, error) {
 	r := &resourceRatelimit{typStr: plugin.RatelimitStr[typ]}
+	r.scale.Store(math.Float64bits(1))
 	if err := r.initialize(config); err != nil {
 		return nil, err
 	}
@@ -75,6 +84,19 @@ func (r *resourceRatelimit) initialize(config *ResourceLimitConfig) error {
 		r.whiteList[item] = true
 	}
 
+	r.rules = make(map[string]*BucketRatelimit)
+	for _, rule := range config.Rules {
+		if rule == nil || rule.Limit == nil {
+			return fmt.Errorf("resource(%s) ratelimit rule limit is empty", r.typStr)
+		}
+		if rule.Limit.Open && (rule.Limit.Bucket <= 0 || rule.Limit.Rate <= 0) {
+			return fmt.Errorf("resource(%s) ratelimit rule bucket or rate invalid", r.typStr)
+		}
+		for _, item := range rule.Resources {
+			r.rules[item] = rule.Limit
+		}
+	}
+
 	log.Infof("[Plugin][%s] resource(%s) ratelimit open", PluginName, r.typStr)
 	return nil
 }
@@ -92,26 +114,84 @@ func (r *resourceRatelimit) isWhiteList(key string) bool {
 
 // 实现limiter
 func (r *resourceRatelimit) allow(key string) bool {
+	pass, _ := r.reserve(key)
+	return pass
+}
+
+// 实现backoffLimiter，被限流时返回令牌桶补充一个令牌需要的时间
+func (r *resourceRatelimit) reserve(key string) (bool, time.Duration) {
 	if ok := r.isOpen(); !ok {
-		return true
+		return true, 0
 	}
 	if ok := r.isWhiteList(key); ok {
-		return true
+		return true, 0
+	}
+	if rule, ok := r.rules[key]; ok && !rule.Open {
+		return true, 0
+	}
+	limit := r.limitOf(key)
+	if pass, wait, ok := reserveShared(r.shared.load(), r.typStr+"|"+key, limit.Rate, limit.Bucket); ok {
+		return pass, wait
 	}
 
 	value, ok := r.resources.Get(key)
 	if !ok {
-		r.resources.ContainsOrAdd(key,
-			rate.NewLimiter(rate.Limit(r.config.Global.Rate), r.config.Global.Bucket))
+		limiter := rate.NewLimiter(rate.Limit(limit.Rate), limit.Bucket)
+		scaleLimiter(limiter, limit.Rate, limit.Bucket, math.Float64frombits(r.scale.Load()))
+		r.resources.ContainsOrAdd(key, limiter)
 		// 上面已经加了value，这里正常情况会有value
 		value, ok = r.resources.Get(key)
 		if !ok {
 			// 还找不到，打印日志，返回true
 			log.Warnf("[Plugin][%s] not found the resources(%s) key(%s) in the cache",
 				PluginName, r.typStr, key)
-			return true
+			return true, 0
+		}
+	}
+
+	return reserveToken(value.(*rate.Limiter))
+}
+
+// limitOf 获取资源对应的限制规则，没有单独配置时使用全局规则
+func (r *resourceRatelimit) limitOf(key string) *BucketRatelimit {
+	if rule, ok := r.rules[key]; ok {
+		return rule
+	}
+	return r.config.Global
+}
+
+// setShared 实现sharableLimiter，使用所有节点共享的令牌桶
+func (r *resourceRatelimit) setShared(shared sharedLimiter) {
+	r.shared.store(shared)
+}
+
+// setScale 实现scalableLimiter，按照比例调整已经创建的令牌桶
+func (r *resourceRatelimit) setScale(scale float64) {
+	r.scale.Store(math.Float64bits(scale))
+	if !r.isOpen() {
+		return
+	}
+	for _, key := range r.resources.Keys() {
+		value, ok := r.resources.Peek(key)
+		if !ok {
+			continue
 		}
+		limit := r.limitOf(key.(string))
+		scaleLimiter(value.(*rate.Limiter), limit.Rate, limit.Bucket, scale)
 	}
+}
 
-	return value.(*rate.Limiter).Allow()
+// reserveToken 尝试从令牌桶中获取一个令牌，获取不到时归还预定并返回需要等待的时间
+func reserveToken(limiter *rate.Limiter) (bool, time.Duration) {
+	now := time.Now()
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, 0
+	}
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return true, 0
+	}
+	reservation.CancelAt(now)
+	return false, delay
 }
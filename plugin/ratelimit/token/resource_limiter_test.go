@@ -166,3 +166,53 @@ func TestResourceAllow(t *testing.T) {
 		})
 	})
 }
+
+// TestResourceRules 测试针对单个资源的规则以及退避时间
+func TestResourceRules(t *testing.T) {
+	Convey("资源规则覆盖全局配置", t, func() {
+		limiter, err := newResourceRatelimit(plugin.PrincipalRatelimit, &ResourceLimitConfig{
+			Open:                   true,
+			Global:                 &BucketRatelimit{true, 5, 1},
+			MaxResourceCacheAmount: 100,
+			Rules: []*ResourceLimitRule{
+				{Resources: []string{"user:polaris"}, Limit: &BucketRatelimit{true, 2, 1}},
+				{Resources: []string{"group:admin"}, Limit: &BucketRatelimit{Open: false}},
+			},
+		})
+		So(err, ShouldBeNil)
+
+		cnt := 0
+		for i := 0; i < 10; i++ {
+			if ok, _ := limiter.reserve("user:polaris"); ok {
+				cnt++
+			}
+		}
+		So(cnt, ShouldEqual, 2)
+		ok, backoff := limiter.reserve("user:polaris")
+		So(ok, ShouldBeFalse)
+		So(backoff, ShouldBeGreaterThan, 0)
+		So(backoff, ShouldBeLessThanOrEqualTo, time.Second)
+
+		for i := 0; i < 10; i++ {
+			So(limiter.allow("group:admin"), ShouldBeTrue)
+		}
+		cnt = 0
+		for i := 0; i < 10; i++ {
+			if limiter.allow("user:other") {
+				cnt++
+			}
+		}
+		So(cnt, ShouldEqual, 5)
+	})
+	Convey("资源规则不合法", t, func() {
+		_, err := newResourceRatelimit(plugin.NamespaceRatelimit, &ResourceLimitConfig{
+			Open:                   true,
+			Global:                 &BucketRatelimit{true, 5, 1},
+			MaxResourceCacheAmount: 100,
+			Rules: []*ResourceLimitRule{
+				{Resources: []string{"default"}, Limit: &BucketRatelimit{Open: true}},
+			},
+		})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -168,6 +168,8 @@ type ClientServer interface {
 	ReportClient(ctx context.Context, req *apiservice.Client) *apiservice.Response
 	// GetPrometheusTargets Used to obtain the ReportClient information and serve as the SD result of Prometheus
 	GetPrometheusTargets(ctx context.Context, query map[string]string) *model.PrometheusDiscoveryResponse
+	// GetPrometheusInstanceTargets Used to obtain the service instances and serve as the http_sd result of Prometheus
+	GetPrometheusInstanceTargets(ctx context.Context, query map[string]string) *model.PrometheusDiscoveryResponse
 	// GetServiceWithCache Used for client acquisition service information
 	GetServiceWithCache(ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse
 	// ServiceInstancesCache Used for client acquisition service instance information
@@ -21,6 +21,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -32,6 +33,7 @@ import (
 	"github.com/polarismesh/polaris/cache"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	apiv1 "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
 	"github.com/polarismesh/polaris/service"
@@ -152,6 +154,65 @@ func TestServer_ReportClient(t *testing.T) {
 	})
 }
 
+func TestServer_ReportClientConfigError(t *testing.T) {
+	discoverSuit := &DiscoverTestSuit{}
+	if err := discoverSuit.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		discoverSuit.cleanReportClient()
+		discoverSuit.Destroy()
+	})
+
+	received := make(chan *model.ConfigClientError, 8)
+	subCtx, err := eventhub.SubscribeWithFunc(eventhub.ConfigClientErrorTopic, func(ctx context.Context, any2 any) error {
+		event := any2.(*eventhub.ConfigClientErrorEvent)
+		for i := range event.Errors {
+			received <- event.Errors[i]
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	t.Cleanup(subCtx.Cancel)
+
+	configErrCtx := context.WithValue(discoverSuit.DefaultCtx, utils.ContextConfigErrorKey,
+		"mock_namespace@mock_group@mock_file@mock_release")
+	expectNoError := func() {
+		select {
+		case clientErr := <-received:
+			t.Fatalf("unexpected config client error: %+v", clientErr)
+		case <-time.After(time.Second):
+		}
+	}
+
+	client := mockReportClients(1)[0]
+
+	// 未注册过的客户端上报的异常不会被转发
+	rsp := discoverSuit.DiscoverServer().ReportClient(configErrCtx, client)
+	assert.True(t, respSuccess(rsp), rsp.GetInfo().GetValue())
+	expectNoError()
+
+	_ = discoverSuit.CacheMgr().TestUpdate()
+
+	rsp = discoverSuit.DiscoverServer().ReportClient(configErrCtx, client)
+	assert.True(t, respSuccess(rsp), rsp.GetInfo().GetValue())
+	select {
+	case clientErr := <-received:
+		assert.Equal(t, client.GetId().GetValue(), clientErr.ClientId)
+		assert.Equal(t, "mock_release", clientErr.ReleaseName)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config client error not published")
+	}
+
+	// 冒用已注册客户端的身份，但是上报的地址不一致
+	rsp = discoverSuit.DiscoverServer().ReportClient(configErrCtx, &apiservice.Client{
+		Host: utils.NewStringValue("127.0.0.2"),
+		Id:   client.GetId(),
+	})
+	assert.True(t, respSuccess(rsp), rsp.GetInfo().GetValue())
+	expectNoError()
+}
+
 func TestServer_GetReportClient(t *testing.T) {
 	t.Run("客户端上报-查询客户端信息", func(t *testing.T) {
 		discoverSuit := &DiscoverTestSuit{}
@@ -31,6 +31,7 @@ import (
 
 	cachetypes "github.com/polarismesh/polaris/cache/api"
 	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/eventhub"
 	"github.com/polarismesh/polaris/common/metrics"
 	"github.com/polarismesh/polaris/common/model"
 	"github.com/polarismesh/polaris/common/utils"
@@ -82,6 +83,7 @@ func isSuccessReportContract(rsp *apiservice.Response) bool {
 func (s *Server) ReportClient(ctx context.Context, req *apiservice.Client) *apiservice.Response {
 	// 客户端信息不写入到DB中
 	host := req.GetHost().GetValue()
+	s.publishConfigClientErrors(ctx, req)
 	// 从CMDB查询地理位置信息
 	if s.cmdb != nil {
 		location, err := s.cmdb.GetLocation(host)
@@ -104,6 +106,39 @@ func (s *Server) ReportClient(ctx context.Context, req *apiservice.Client) *apis
 	return api.NewClientResponse(apimodel.Code_ExecuteSuccess, out)
 }
 
+// publishConfigClientErrors 客户端上报时携带了配置文件的使用异常，转发给配置中心判断是否需要终止灰度
+// 异常会终止灰度，只接受已经注册过的客户端以自身的身份上报的异常
+func (s *Server) publishConfigClientErrors(ctx context.Context, req *apiservice.Client) {
+	value := utils.ParseConfigError(ctx)
+	if value == "" {
+		return
+	}
+	clientId := req.GetId().GetValue()
+	if clientId == "" {
+		log.Warn("[Client] ignore config error reported without client id", utils.RequestID(ctx),
+			zap.String("host", req.GetHost().GetValue()))
+		return
+	}
+	if s.caches == nil {
+		return
+	}
+	registered := s.Cache().Client().GetClient(clientId)
+	if registered == nil || registered.Proto().GetHost().GetValue() != req.GetHost().GetValue() {
+		log.Warn("[Client] ignore config error reported by unknown client", utils.RequestID(ctx),
+			zap.String("client", clientId), zap.String("host", req.GetHost().GetValue()))
+		return
+	}
+	errs := model.ParseConfigClientErrors(clientId, value)
+	if len(errs) == 0 {
+		return
+	}
+	if err := eventhub.Publish(eventhub.ConfigClientErrorTopic, &eventhub.ConfigClientErrorEvent{
+		Errors: errs,
+	}); err != nil {
+		log.Error("[Client] publish config client error event", utils.RequestID(ctx), zap.Error(err))
+	}
+}
+
 // GetPrometheusTargets Used for client acquisition service information
 func (s *Server) GetPrometheusTargets(ctx context.Context,
 	query map[string]string) *model.PrometheusDiscoveryResponse {
@@ -140,7 +140,7 @@ func (c *CacheProvider) isHealthCheckEnable(instance *apiservice.Instance) (bool
 	if !instance.GetEnableHealthCheck().GetValue() || instance.GetHealthCheck() == nil {
 		return false, nil
 	}
-	checker, ok := c.getHealthChecker(instance.GetHealthCheck().GetType())
+	checker, ok := c.svr.instanceChecker(instance)
 	if !ok {
 		return false, nil
 	}
@@ -114,7 +114,7 @@ func (handler *ResourceHealthCheckHandler) OnEvent(ctx context.Context, i interf
 			log.Errorf("[Health Check] cannot get instance from cache, instance id is %s", event.Id)
 			break
 		}
-		checker, ok := s.checkers[int32(insCache.HealthCheck().GetType())]
+		checker, ok := s.instanceChecker(insCache.Proto)
 		if !ok {
 			log.Errorf("[Health Check]heart beat type not found checkType %d",
 				int32(insCache.HealthCheck().GetType()))
@@ -268,7 +268,7 @@ func (c *CheckScheduler) upsertInstanceChecker(instanceWithChecker *InstanceWith
 	)
 	instValue, exist = c.scheduledInstances[instance.ID()]
 	if exist {
-		if ttl == instValue.ttlDurationSec {
+		if ttl == instValue.ttlDurationSec && instValue.checker == instanceWithChecker.checker {
 			return true, instValue
 		}
 		// force update check info
@@ -362,8 +362,12 @@ func (c *CheckScheduler) AddClient(clientWithChecker *ClientWithChecker) {
 		client.Proto().GetId().GetValue(), client.Proto().GetHost(), 0)
 }
 
+// getExpireDurationSec 心跳检查的超时时间为 ttl 的 3 倍；主动探测以 ttl 作为探测间隔，失败次数由探测插件判断
 func getExpireDurationSec(instance *apiservice.Instance) uint32 {
 	ttlValue := instance.GetHealthCheck().GetHeartbeat().GetTtl().GetValue()
+	if model.IsProbeHealthCheck(instance.GetMetadata()) {
+		return ttlValue
+	}
 	return expireTtlCount * ttlValue
 }
 
@@ -494,6 +498,7 @@ func (c *CheckScheduler) checkCallbackInstance(value interface{}) {
 		},
 		CurTimeSec:        c.svr.currentTimeSec,
 		ExpireDurationSec: instanceValue.expireDurationSec,
+		Metadata:          cachedInstance.Metadata(),
 	}
 	checkResp, err = instanceValue.checker.Check(request)
 	if err != nil {
@@ -502,6 +507,10 @@ func (c *CheckScheduler) checkCallbackInstance(value interface{}) {
 		return
 	}
 	if !checkResp.StayUnchanged {
+		if !checkResp.Healthy && !c.svr.protector.allowUnhealthy(cachedInstance) {
+			// 健康检查处于保护状态，暂不将实例置为不健康，等待下一次检查
+			return
+		}
 		code := setInsDbStatus(c.svr, cachedInstance, checkResp.Healthy, checkResp.LastHeartbeatTimeSec)
 		if checkResp.Healthy {
 			// from unhealthy to healthy
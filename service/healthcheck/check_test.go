@@ -20,6 +20,7 @@ package healthcheck_test
 import (
 	"context"
 	"fmt"
+	"net"
 	"testing"
 	"time"
 
@@ -36,7 +37,7 @@ import (
 
 func Test_serialSetInsDbStatus(t *testing.T) {
 	testSuit := &testsuit.DiscoverTestSuit{}
-	testSuit.Initialize()
+	testSuit.Initialize(testsuit.WithLogDir(t.TempDir()))
 
 	var (
 		mockService   = "mock_service"
@@ -111,3 +112,51 @@ func Test_serialSetInsDbStatus(t *testing.T) {
 		})
 	})
 }
+
+func Test_probeChecker(t *testing.T) {
+	testSuit := &testsuit.DiscoverTestSuit{}
+	testSuit.Initialize(testsuit.WithLogDir(t.TempDir()))
+	defer testSuit.Destroy()
+
+	mockSvr, err := healthcheck.NewHealthServer(context.TODO(), &healthcheck.Config{
+		Open: utils.BoolPtr(true),
+		Checkers: []plugin.ConfigEntry{
+			{
+				Name: "heartbeatMemory",
+			},
+			{
+				Name: "activeProbe",
+			},
+		},
+	}, healthcheck.WithStore(testSuit.Storage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkers := mockSvr.Checkers()
+	assert.Contains(t, checkers, int32(plugin.HealthCheckerHeartbeat))
+	assert.Contains(t, checkers, int32(plugin.HealthCheckerProbe))
+
+	// 获取一个未被监听的端口
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().(*net.TCPAddr)
+	_ = ln.Close()
+
+	// 每个健康检查插件都需要完成初始化
+	resp, err := checkers[int32(plugin.HealthCheckerProbe)].Check(&plugin.CheckRequest{
+		QueryRequest: plugin.QueryRequest{
+			InstanceId: "probe-instance",
+			Host:       addr.IP.String(),
+			Port:       uint32(addr.Port),
+			Healthy:    false,
+		},
+		ExpireDurationSec: 5,
+		CurTimeSec: func() int64 {
+			return time.Now().Unix()
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, resp.Healthy)
+	assert.True(t, resp.StayUnchanged)
+}
@@ -36,6 +36,7 @@ type Config struct {
 	ClientCheckTtl      time.Duration          `yaml:"clientCheckTtl"`
 	Checkers            []plugin.ConfigEntry   `yaml:"checkers"`
 	Batch               map[string]interface{} `yaml:"batch"`
+	Protection          ProtectionConfig       `yaml:"protection"`
 }
 
 const (
@@ -80,4 +81,5 @@ func (c *Config) SetDefault() {
 	if c.ClientCheckTtl == 0 {
 		c.ClientCheckTtl = defaultClientReportTtl
 	}
+	c.Protection.SetDefault()
 }
@@ -0,0 +1,472 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/metrics"
+	"github.com/polarismesh/polaris/common/model"
+	commontime "github.com/polarismesh/polaris/common/time"
+	"github.com/polarismesh/polaris/store"
+)
+
+// ProtectionMode 健康检查保护模式的运行方式
+type ProtectionMode string
+
+const (
+	// ProtectionModeAuto 根据窗口内实例转为不健康的比例自动进入、退出保护
+	ProtectionModeAuto ProtectionMode = "auto"
+	// ProtectionModeOn 人工强制开启保护，所有实例都不会被置为不健康
+	ProtectionModeOn ProtectionMode = "on"
+	// ProtectionModeOff 人工强制关闭保护
+	ProtectionModeOff ProtectionMode = "off"
+)
+
+const (
+	defaultProtectionWindow              = 60 * time.Second
+	defaultProtectionGlobalThreshold     = 0.3
+	defaultProtectionServiceThreshold    = 0.5
+	defaultProtectionMinServiceInstances = 4
+	protectionRefreshInterval            = time.Second
+	// protectionSyncInterval 从存储中同步人工设置的保护模式、从缓存中统计集群实例数的周期
+	protectionSyncInterval = 5 * time.Second
+)
+
+var (
+	// ErrorInvalidProtectionMode 不支持的保护模式
+	ErrorInvalidProtectionMode = errors.New("invalid protection mode, only support auto/on/off")
+)
+
+// ProtectionConfig 健康检查保护模式配置，当大量实例在短时间内心跳超时（例如网络分区），
+// 停止将实例置为不健康，避免整个服务的实例被摘除
+type ProtectionConfig struct {
+	// Open 是否开启保护模式
+	Open bool `yaml:"open"`
+	// Window 统计实例转为不健康的时间窗口
+	Window time.Duration `yaml:"window"`
+	// GlobalThreshold 窗口内全局转为不健康的实例比例超过该值，所有实例进入保护
+	GlobalThreshold float64 `yaml:"globalThreshold"`
+	// ServiceThreshold 窗口内单个服务转为不健康的实例比例超过该值，该服务进入保护
+	ServiceThreshold float64 `yaml:"serviceThreshold"`
+	// MinServiceInstances 服务实例数小于该值时，不进行服务级别的保护判断
+	MinServiceInstances int `yaml:"minServiceInstances"`
+}
+
+// SetDefault 设置默认值
+func (c *ProtectionConfig) SetDefault() {
+	if c.Window <= 0 {
+		c.Window = defaultProtectionWindow
+	}
+	if c.GlobalThreshold <= 0 || c.GlobalThreshold > 1 {
+		c.GlobalThreshold = defaultProtectionGlobalThreshold
+	}
+	if c.ServiceThreshold <= 0 || c.ServiceThreshold > 1 {
+		c.ServiceThreshold = defaultProtectionServiceThreshold
+	}
+	if c.MinServiceInstances <= 0 {
+		c.MinServiceInstances = defaultProtectionMinServiceInstances
+	}
+}
+
+// ProtectionState 健康检查保护模式的当前状态，实例数按照整个集群统计
+type ProtectionState struct {
+	// Node 返回该状态的北极星节点，人工设置的保护模式对集群内所有节点生效
+	Node                string              `json:"node"`
+	Open                bool                `json:"open"`
+	Mode                ProtectionMode      `json:"mode"`
+	Window              string              `json:"window"`
+	GlobalThreshold     float64             `json:"globalThreshold"`
+	ServiceThreshold    float64             `json:"serviceThreshold"`
+	MinServiceInstances int                 `json:"minServiceInstances"`
+	GlobalProtected     bool                `json:"globalProtected"`
+	GlobalSince         int64               `json:"globalSince,omitempty"`
+	Expired             int                 `json:"expired"`
+	Total               int                 `json:"total"`
+	SuppressedCount     int64               `json:"suppressedCount"`
+	Services            []*ServiceProtected `json:"services"`
+}
+
+// ServiceProtected 处于保护状态的服务
+type ServiceProtected struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+	Since     int64  `json:"since"`
+	Expired   int    `json:"expired"`
+	Total     int    `json:"total"`
+}
+
+type serviceExpiredStat struct {
+	namespace string
+	service   string
+	// instances 窗口内转为不健康的实例及其时间（毫秒）
+	instances map[string]int64
+	// protectSince 进入保护的时间（秒），为 0 表示未处于保护
+	protectSince int64
+}
+
+// protector 健康检查保护，统计窗口内由健康转为不健康的实例比例，超过阈值后停止修改实例的健康状态。
+// 实例总数以及转为不健康的实例从缓存中按照整个集群统计，再合并当前节点刚刚检查到、还没有同步到缓存中的实例，
+// 人工设置的模式保存在存储中，所有节点定期同步
+type protector struct {
+	svr  *Server
+	conf ProtectionConfig
+	lock sync.Mutex
+	mode ProtectionMode
+	// total 集群内各个服务开启了健康检查的实例数
+	total      map[string]int
+	totalCount int
+	// expired 各个服务在窗口内转为不健康的实例
+	expired      map[string]*serviceExpiredStat
+	expiredCount int
+	// globalSince 全局进入保护的时间（秒），为 0 表示未处于保护
+	globalSince int64
+	suppressed  int64
+	nowFunc     func() int64
+	// rangeInstances 遍历缓存中集群内所有的实例
+	rangeInstances func(iterProc cachetypes.InstanceIterProc) error
+}
+
+func newProtector(svr *Server, conf ProtectionConfig) *protector {
+	conf.SetDefault()
+	p := &protector{
+		svr:     svr,
+		conf:    conf,
+		mode:    ProtectionModeAuto,
+		total:   map[string]int{},
+		expired: map[string]*serviceExpiredStat{},
+		nowFunc: commontime.CurrentMillisecond,
+	}
+	p.rangeInstances = func(iterProc cachetypes.InstanceIterProc) error {
+		if p.svr == nil || p.svr.instanceCache == nil {
+			return nil
+		}
+		return p.svr.instanceCache.IteratorInstances(iterProc)
+	}
+	return p
+}
+
+func (p *protector) run(ctx context.Context) {
+	p.sync()
+	go func() {
+		refreshTicker := time.NewTicker(protectionRefreshInterval)
+		defer refreshTicker.Stop()
+		syncTicker := time.NewTicker(protectionSyncInterval)
+		defer syncTicker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-syncTicker.C:
+				p.sync()
+			case <-refreshTicker.C:
+				if p.conf.Open {
+					p.refresh()
+				}
+			}
+		}
+	}()
+}
+
+// sync 同步人工设置的保护模式，并从缓存中重新统计集群内的实例
+func (p *protector) sync() {
+	p.syncMode()
+	if p.conf.Open {
+		p.syncCluster()
+	}
+}
+
+// syncMode 从存储中加载人工设置的保护模式，其他节点修改的模式在一个同步周期内生效
+func (p *protector) syncMode() {
+	modeStore := p.modeStore()
+	if modeStore == nil {
+		return
+	}
+	protection, err := modeStore.GetHealthCheckProtection()
+	if err != nil {
+		log.Error("[Health Check][Protection] load protection mode fail", zap.Error(err))
+		return
+	}
+	mode := ProtectionModeAuto
+	if protection != nil {
+		mode = ProtectionMode(protection.Mode)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.applyMode(mode)
+}
+
+// syncCluster 从缓存中统计集群内各个服务开启了健康检查的实例数，以及窗口内转为不健康的实例
+func (p *protector) syncCluster() {
+	total := map[string]int{}
+	totalCount := 0
+	expired := map[string]*model.Instance{}
+	windowStart := p.nowFunc() - p.conf.Window.Milliseconds()
+	err := p.rangeInstances(func(_ string, instance *model.Instance) (bool, error) {
+		if !instance.EnableHealthCheck() {
+			return true, nil
+		}
+		total[instance.ServiceID]++
+		totalCount++
+		if !instance.Healthy() && instance.ModifyTime.UnixMilli() >= windowStart {
+			expired[instance.ID()] = instance
+		}
+		return true, nil
+	})
+	if err != nil {
+		log.Error("[Health Check][Protection] count cluster instances fail", zap.Error(err))
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.total = total
+	p.totalCount = totalCount
+	for _, instance := range expired {
+		p.recordExpired(instance, instance.ModifyTime.UnixMilli())
+	}
+}
+
+// recordExpired 记录转为不健康的实例，同一个实例在窗口内只计数一次
+func (p *protector) recordExpired(instance *model.Instance, expireMilli int64) *serviceExpiredStat {
+	stat, ok := p.expired[instance.ServiceID]
+	if !ok {
+		stat = &serviceExpiredStat{
+			namespace: instance.Namespace(),
+			service:   instance.Service(),
+			instances: map[string]int64{},
+		}
+		p.expired[instance.ServiceID] = stat
+	}
+	if _, exist := stat.instances[instance.ID()]; !exist {
+		p.expiredCount++
+		stat.instances[instance.ID()] = expireMilli
+	}
+	return stat
+}
+
+// allowUnhealthy 实例心跳超时时调用，判断是否允许将实例置为不健康
+func (p *protector) allowUnhealthy(instance *model.Instance) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	switch p.mode {
+	case ProtectionModeOn:
+		p.suppressed++
+		return false
+	case ProtectionModeOff:
+		return true
+	}
+	if !p.conf.Open {
+		return true
+	}
+
+	nowMilli := p.nowFunc()
+	stat := p.recordExpired(instance, nowMilli)
+	stat.instances[instance.ID()] = nowMilli
+	p.evaluateService(instance.ServiceID, stat, nowMilli)
+	p.evaluateGlobal(nowMilli)
+
+	if p.globalSince > 0 || stat.protectSince > 0 {
+		p.suppressed++
+		log.Warn("[Health Check][Protection] instance expired but health check is under protection",
+			zap.String("id", instance.ID()), zap.String("namespace", instance.Namespace()),
+			zap.String("service", instance.Service()), zap.Bool("global", p.globalSince > 0))
+		return false
+	}
+	return true
+}
+
+// refresh 淘汰窗口外的数据，并重新判断各个服务以及全局的保护状态
+func (p *protector) refresh() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	nowMilli := p.nowFunc()
+	for serviceID, stat := range p.expired {
+		p.evaluateService(serviceID, stat, nowMilli)
+		if len(stat.instances) == 0 && stat.protectSince == 0 {
+			delete(p.expired, serviceID)
+		}
+	}
+	p.evaluateGlobal(nowMilli)
+}
+
+func (p *protector) evaluateService(serviceID string, stat *serviceExpiredStat, nowMilli int64) {
+	windowStart := nowMilli - p.conf.Window.Milliseconds()
+	for id, expireMilli := range stat.instances {
+		if expireMilli < windowStart {
+			delete(stat.instances, id)
+			p.expiredCount--
+		}
+	}
+	total := p.total[serviceID]
+	protected := total >= p.conf.MinServiceInstances &&
+		float64(len(stat.instances)) > float64(total)*p.conf.ServiceThreshold
+	if protected == (stat.protectSince > 0) {
+		return
+	}
+	if protected {
+		stat.protectSince = nowMilli / 1000
+		log.Warn("[Health Check][Protection] service enter protection", zap.String("namespace", stat.namespace),
+			zap.String("service", stat.service), zap.Int("expired", len(stat.instances)), zap.Int("total", total))
+	} else {
+		stat.protectSince = 0
+		log.Info("[Health Check][Protection] service exit protection", zap.String("namespace", stat.namespace),
+			zap.String("service", stat.service))
+	}
+	p.notify(serviceID, stat.namespace, stat.service, protected)
+}
+
+func (p *protector) evaluateGlobal(nowMilli int64) {
+	protected := p.totalCount > 0 &&
+		float64(p.expiredCount) > float64(p.totalCount)*p.conf.GlobalThreshold
+	if protected == (p.globalSince > 0) {
+		return
+	}
+	if protected {
+		p.globalSince = nowMilli / 1000
+		log.Warn("[Health Check][Protection] enter global protection", zap.Int("expired", p.expiredCount),
+			zap.Int("total", p.totalCount))
+	} else {
+		p.globalSince = 0
+		log.Info("[Health Check][Protection] exit global protection")
+	}
+	p.notify("", "", "", protected)
+}
+
+// notify 上报保护状态指标，并发布保护状态变更的事件
+func (p *protector) notify(serviceID, namespace, service string, protected bool) {
+	if gauge := metrics.GetHealthCheckProtection(); gauge != nil {
+		val := float64(0)
+		if protected {
+			val = 1
+		}
+		gauge.With(map[string]string{
+			metrics.LabelNamespace: namespace,
+			metrics.LabelService:   service,
+		}).Set(val)
+	}
+	if p.svr == nil {
+		return
+	}
+	eType := model.EventHealthCheckProtectionClose
+	if protected {
+		eType = model.EventHealthCheckProtectionOpen
+	}
+	p.svr.publishInstanceEvent(serviceID, model.InstanceEvent{
+		Namespace: namespace,
+		Service:   service,
+		EType:     eType,
+	})
+}
+
+// setMode 人工修改保护模式，保存到存储中后立即在当前节点生效，其他节点在一个同步周期内生效，节点重启后依然保留
+func (p *protector) setMode(mode ProtectionMode) error {
+	switch mode {
+	case ProtectionModeAuto, ProtectionModeOn, ProtectionModeOff:
+	default:
+		return ErrorInvalidProtectionMode
+	}
+	if modeStore := p.modeStore(); modeStore != nil {
+		if err := modeStore.SetHealthCheckProtection(&model.HealthCheckProtection{
+			Mode:       string(mode),
+			ModifyTime: time.UnixMilli(p.nowFunc()),
+		}); err != nil {
+			log.Error("[Health Check][Protection] save protection mode fail", zap.String("mode", string(mode)),
+				zap.Error(err))
+			return err
+		}
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.applyMode(mode)
+	return nil
+}
+
+// applyMode 修改当前节点生效的保护模式，调用方需要持有锁
+func (p *protector) applyMode(mode ProtectionMode) {
+	if p.mode != mode {
+		log.Info("[Health Check][Protection] change protection mode", zap.String("node", p.node()),
+			zap.String("from", string(p.mode)), zap.String("to", string(mode)))
+	}
+	p.mode = mode
+}
+
+// modeStore 保存人工设置的保护模式的存储
+func (p *protector) modeStore() store.HealthCheckProtectionStore {
+	if p.svr == nil || p.svr.storage == nil {
+		return nil
+	}
+	return p.svr.storage
+}
+
+// node 当前节点的地址
+func (p *protector) node() string {
+	if p.svr == nil {
+		return ""
+	}
+	return p.svr.localHost
+}
+
+func (p *protector) state() *ProtectionState {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	ret := &ProtectionState{
+		Node:                p.node(),
+		Open:                p.conf.Open,
+		Mode:                p.mode,
+		Window:              p.conf.Window.String(),
+		GlobalThreshold:     p.conf.GlobalThreshold,
+		ServiceThreshold:    p.conf.ServiceThreshold,
+		MinServiceInstances: p.conf.MinServiceInstances,
+		GlobalProtected:     p.globalSince > 0 || p.mode == ProtectionModeOn,
+		GlobalSince:         p.globalSince,
+		Expired:             p.expiredCount,
+		Total:               p.totalCount,
+		SuppressedCount:     p.suppressed,
+		Services:            []*ServiceProtected{},
+	}
+	for serviceID, stat := range p.expired {
+		if stat.protectSince == 0 {
+			continue
+		}
+		ret.Services = append(ret.Services, &ServiceProtected{
+			Namespace: stat.namespace,
+			Service:   stat.service,
+			Since:     stat.protectSince,
+			Expired:   len(stat.instances),
+			Total:     p.total[serviceID],
+		})
+	}
+	sort.Slice(ret.Services, func(i, j int) bool {
+		if ret.Services[i].Namespace != ret.Services[j].Namespace {
+			return ret.Services[i].Namespace < ret.Services[j].Namespace
+		}
+		return ret.Services[i].Service < ret.Services[j].Service
+	})
+	return ret
+}
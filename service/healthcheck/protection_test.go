@@ -0,0 +1,245 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	storemock "github.com/polarismesh/polaris/store/mock"
+)
+
+func mockProtectionInstance(serviceID, service string, index int) *model.Instance {
+	return &model.Instance{
+		ServiceID: serviceID,
+		Proto: &apiservice.Instance{
+			Id:                utils.NewStringValue(fmt.Sprintf("%s-%d", service, index)),
+			Namespace:         utils.NewStringValue("default"),
+			Service:           utils.NewStringValue(service),
+			EnableHealthCheck: utils.NewBoolValue(true),
+			Healthy:           utils.NewBoolValue(true),
+		},
+	}
+}
+
+func newTestProtector(conf ProtectionConfig) (*protector, *int64) {
+	nowMilli := time.Now().UnixMilli()
+	p := newProtector(nil, conf)
+	p.nowFunc = func() int64 {
+		return nowMilli
+	}
+	return p, &nowMilli
+}
+
+// mockClusterInstances 模拟缓存中集群内所有的实例
+func mockClusterInstances(p *protector, instances *[]*model.Instance) {
+	p.rangeInstances = func(iterProc cachetypes.InstanceIterProc) error {
+		for _, instance := range *instances {
+			if _, err := iterProc(instance.ID(), instance); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestProtector_Service(t *testing.T) {
+	p, nowMilli := newTestProtector(ProtectionConfig{
+		Open:             true,
+		Window:           time.Minute,
+		GlobalThreshold:  0.9,
+		ServiceThreshold: 0.5,
+	})
+	instances := []*model.Instance{}
+	for i := 0; i < 10; i++ {
+		instances = append(instances, mockProtectionInstance("svc-a", "a", i))
+	}
+	for i := 0; i < 20; i++ {
+		instances = append(instances, mockProtectionInstance("svc-b", "b", i))
+	}
+	// 少于 minServiceInstances 的服务不进行服务级别保护
+	for i := 0; i < 3; i++ {
+		instances = append(instances, mockProtectionInstance("svc-c", "c", i))
+	}
+	// 未开启健康检查的实例不参与统计
+	disabled := mockProtectionInstance("svc-a", "a", 100)
+	disabled.Proto.EnableHealthCheck = utils.NewBoolValue(false)
+	instances = append(instances, disabled)
+	mockClusterInstances(p, &instances)
+	p.syncCluster()
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, p.allowUnhealthy(mockProtectionInstance("svc-a", "a", i)))
+	}
+	// 超过一半的实例转为不健康，服务进入保护
+	assert.False(t, p.allowUnhealthy(mockProtectionInstance("svc-a", "a", 5)))
+	// 同一个实例重复检查不重复计数
+	assert.False(t, p.allowUnhealthy(mockProtectionInstance("svc-a", "a", 5)))
+	// 其他服务不受影响
+	assert.True(t, p.allowUnhealthy(mockProtectionInstance("svc-b", "b", 0)))
+	for i := 0; i < 3; i++ {
+		assert.True(t, p.allowUnhealthy(mockProtectionInstance("svc-c", "c", i)))
+	}
+
+	state := p.state()
+	assert.False(t, state.GlobalProtected)
+	assert.Equal(t, 33, state.Total)
+	assert.Equal(t, 10, state.Expired)
+	assert.Equal(t, int64(2), state.SuppressedCount)
+	assert.Len(t, state.Services, 1)
+	assert.Equal(t, "a", state.Services[0].Service)
+	assert.Equal(t, 6, state.Services[0].Expired)
+	assert.Equal(t, 10, state.Services[0].Total)
+
+	// 窗口滑过之后，服务退出保护
+	*nowMilli += time.Minute.Milliseconds() + 1
+	p.refresh()
+	state = p.state()
+	assert.Empty(t, state.Services)
+	assert.Equal(t, 0, state.Expired)
+	assert.True(t, p.allowUnhealthy(mockProtectionInstance("svc-a", "a", 5)))
+}
+
+func TestProtector_Cluster(t *testing.T) {
+	p, nowMilli := newTestProtector(ProtectionConfig{
+		Open:             true,
+		Window:           time.Minute,
+		GlobalThreshold:  0.9,
+		ServiceThreshold: 0.5,
+	})
+	instances := []*model.Instance{}
+	for i := 0; i < 10; i++ {
+		instances = append(instances, mockProtectionInstance("svc-a", "a", i))
+	}
+	mockClusterInstances(p, &instances)
+
+	// 其他节点在窗口内已经将 5 个实例置为不健康
+	for i := 0; i < 5; i++ {
+		instances[i].Proto.Healthy = utils.NewBoolValue(false)
+		instances[i].ModifyTime = time.UnixMilli(*nowMilli)
+	}
+	// 窗口之前转为不健康的实例不参与统计
+	instances[9].Proto.Healthy = utils.NewBoolValue(false)
+	instances[9].ModifyTime = time.UnixMilli(*nowMilli - 2*time.Minute.Milliseconds())
+	p.syncCluster()
+	assert.Equal(t, 10, p.state().Total)
+	assert.Equal(t, 5, p.state().Expired)
+
+	// 当前节点检查到的实例和缓存中的实例合并计数，超过一半的实例转为不健康，服务进入保护
+	assert.True(t, p.allowUnhealthy(instances[0]))
+	assert.False(t, p.allowUnhealthy(instances[5]))
+	assert.Len(t, p.state().Services, 1)
+	assert.Equal(t, 6, p.state().Services[0].Expired)
+}
+
+func TestProtector_Global(t *testing.T) {
+	p, nowMilli := newTestProtector(ProtectionConfig{
+		Open:             true,
+		GlobalThreshold:  0.3,
+		ServiceThreshold: 1,
+	})
+	instances := []*model.Instance{}
+	for i := 0; i < 10; i++ {
+		instances = append(instances, mockProtectionInstance(fmt.Sprintf("svc-%d", i%5), "s", i))
+	}
+	mockClusterInstances(p, &instances)
+	p.syncCluster()
+	for i := 0; i < 3; i++ {
+		assert.True(t, p.allowUnhealthy(instances[i]))
+	}
+	assert.False(t, p.allowUnhealthy(instances[3]))
+	assert.True(t, p.state().GlobalProtected)
+
+	// 窗口滑过之后退出全局保护
+	*nowMilli += p.conf.Window.Milliseconds() + 1
+	p.refresh()
+	state := p.state()
+	assert.False(t, state.GlobalProtected)
+	assert.Equal(t, 10, state.Total)
+	assert.Equal(t, 0, state.Expired)
+}
+
+func TestProtector_Mode(t *testing.T) {
+	p, _ := newTestProtector(ProtectionConfig{})
+	ins := mockProtectionInstance("svc-a", "a", 0)
+	// 未开启保护模式
+	assert.True(t, p.allowUnhealthy(ins))
+
+	assert.NoError(t, p.setMode(ProtectionModeOn))
+	assert.False(t, p.allowUnhealthy(ins))
+	assert.True(t, p.state().GlobalProtected)
+
+	assert.NoError(t, p.setMode(ProtectionModeOff))
+	assert.True(t, p.allowUnhealthy(ins))
+
+	assert.ErrorIs(t, p.setMode("unknown"), ErrorInvalidProtectionMode)
+	assert.Equal(t, ProtectionModeOff, p.state().Mode)
+}
+
+func TestProtector_ClusterMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	storage := storemock.NewMockStore(ctrl)
+
+	svr := &Server{localHost: "10.0.0.1", storage: storage}
+	svr.protector = newProtector(svr, ProtectionConfig{})
+
+	// 人工设置的模式保存到存储中，返回的状态中带上处理请求的节点地址
+	storage.EXPECT().SetHealthCheckProtection(gomock.Any()).DoAndReturn(
+		func(protection *model.HealthCheckProtection) error {
+			assert.Equal(t, string(ProtectionModeOn), protection.Mode)
+			return nil
+		})
+	state, err := svr.SetProtectionMode(ProtectionModeOn)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", state.Node)
+	assert.Equal(t, ProtectionModeOn, state.Mode)
+	_, err = svr.SetProtectionMode("unknown")
+	assert.ErrorIs(t, err, ErrorInvalidProtectionMode)
+
+	// 保存失败时不修改当前节点的模式
+	storage.EXPECT().SetHealthCheckProtection(gomock.Any()).Return(errors.New("mock error"))
+	_, err = svr.SetProtectionMode(ProtectionModeOff)
+	assert.Error(t, err)
+	assert.Equal(t, ProtectionModeOn, svr.ProtectionState().Mode)
+
+	// 其他节点修改的模式在同步之后生效
+	storage.EXPECT().GetHealthCheckProtection().Return(&model.HealthCheckProtection{
+		Mode: string(ProtectionModeOff)}, nil)
+	svr.protector.sync()
+	assert.Equal(t, ProtectionModeOff, svr.ProtectionState().Mode)
+
+	// 人工设置被清除后恢复为 auto
+	storage.EXPECT().GetHealthCheckProtection().Return(nil, nil)
+	svr.protector.sync()
+	assert.Equal(t, ProtectionModeAuto, svr.ProtectionState().Mode)
+
+	// 加载失败时保留当前的模式
+	storage.EXPECT().GetHealthCheckProtection().Return(nil, errors.New("mock error"))
+	svr.protector.sync()
+	assert.Equal(t, ProtectionModeAuto, svr.ProtectionState().Mode)
+}
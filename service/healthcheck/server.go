@@ -54,6 +54,7 @@ type Server struct {
 	timeAdjuster   *TimeAdjuster
 	dispatcher     *Dispatcher
 	checkScheduler *CheckScheduler
+	protector      *protector
 	history        plugin.History
 	discoverEvent  plugin.DiscoverChannel
 	localHost      string
@@ -86,6 +87,7 @@ func NewHealthServer(ctx context.Context, hcOpt *Config, options ...serverOption
 		hcOpt:     hcOpt,
 		localHost: hcOpt.LocalHost,
 	}
+	svr.protector = newProtector(svr, hcOpt.Protection)
 	for i := range options {
 		if err := options[i](svr); err != nil {
 			return nil, err
@@ -137,6 +139,7 @@ func (s *Server) run(ctx context.Context) error {
 	}
 
 	s.checkScheduler.run(ctx)
+	s.protector.run(ctx)
 	s.timeAdjuster.doTimeAdjust(ctx)
 	s.dispatcher.startDispatchingJob(ctx)
 	return nil
@@ -247,7 +250,7 @@ func (s *Server) GetLastHeartbeat(req *apiservice.Instance) *apiservice.Response
 	if insCache == nil {
 		return api.NewInstanceResponse(apimodel.Code_NotFoundResource, req)
 	}
-	checker, ok := s.checkers[int32(insCache.HealthCheck().GetType())]
+	checker, ok := s.instanceChecker(insCache.Proto)
 	if !ok {
 		return api.NewInstanceResponse(apimodel.Code_HeartbeatTypeNotFound, req)
 	}
@@ -272,6 +275,31 @@ func (s *Server) GetLastHeartbeat(req *apiservice.Instance) *apiservice.Response
 	return api.NewInstanceResponse(apimodel.Code_ExecuteSuccess, req)
 }
 
+// ProtectionState 获取健康检查保护模式的状态
+func (s *Server) ProtectionState() *ProtectionState {
+	return s.protector.state()
+}
+
+// SetProtectionMode 人工修改健康检查保护模式, 支持 auto/on/off, 返回修改后当前节点的状态。
+// 修改保存在存储中, 其他节点定期同步后生效, 节点重启后依然保留
+func (s *Server) SetProtectionMode(mode ProtectionMode) (*ProtectionState, error) {
+	if err := s.protector.setMode(mode); err != nil {
+		return nil, err
+	}
+	return s.protector.state(), nil
+}
+
+// instanceChecker 获取实例使用的健康检查插件, 元数据中设置了探测协议的实例由主动探测插件检查
+func (s *Server) instanceChecker(instance *apiservice.Instance) (plugin.HealthChecker, bool) {
+	if model.IsProbeHealthCheck(instance.GetMetadata()) {
+		if checker, ok := s.checkers[int32(plugin.HealthCheckerProbe)]; ok {
+			return checker, true
+		}
+	}
+	checker, ok := s.checkers[int32(instance.GetHealthCheck().GetType())]
+	return checker, ok
+}
+
 // Checkers get all health checker, for test only
 func (s *Server) Checkers() map[int32]plugin.HealthChecker {
 	return s.checkers
@@ -23,7 +23,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/polarismesh/polaris/auth/policy"
 	"github.com/polarismesh/polaris/cache"
+	"github.com/polarismesh/polaris/common/model"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	"github.com/stretchr/testify/assert"
@@ -312,3 +314,47 @@ func TestSelfInstanceImmediatelyCheck(t *testing.T) {
 	assert.Equal(t, true, instance2.Proto.GetHealthy().GetValue())
 
 }
+
+func TestRegisterProbeInstanceRequireAuth(t *testing.T) {
+	discoverSuit := &DiscoverTestSuit{}
+	if err := discoverSuit.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer discoverSuit.Destroy()
+
+	_, svc := discoverSuit.createCommonService(t, 902)
+	defer discoverSuit.cleanServiceName(svc.GetName().GetValue(), svc.GetNamespace().GetValue())
+	newInstance := func() *apiservice.Instance {
+		return &apiservice.Instance{
+			Service:   svc.GetName(),
+			Namespace: svc.GetNamespace(),
+			Host:      wrapperspb.String("10.0.0.1"),
+			Port:      wrapperspb.UInt32(8080),
+			Metadata: map[string]string{
+				model.MetadataInternalMetaHealthCheckProtocol: "tcp",
+			},
+		}
+	}
+
+	// 未开启鉴权时，匿名的请求也可以注册主动探测的实例
+	resp := discoverSuit.DiscoverServer().RegisterInstance(context.Background(), newInstance())
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	discoverSuit.cleanInstance(resp.GetInstance().GetId().GetValue())
+
+	checker := discoverSuit.StrategyServer().GetAuthChecker().(*policy.DefaultAuthChecker)
+	oldConf := checker.GetConfig()
+	defer func() {
+		checker.SetConfig(oldConf)
+	}()
+	// 严格模式下匿名的请求不能注册主动探测的实例
+	checker.SetConfig(&policy.AuthConfig{
+		ClientOpen:   true,
+		ClientStrict: true,
+	})
+	resp = discoverSuit.DiscoverServer().RegisterInstance(context.Background(), newInstance())
+	assert.Equal(t, uint32(apimodel.Code_NotAllowedAccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+
+	resp = discoverSuit.DiscoverServer().RegisterInstance(discoverSuit.DefaultCtx, newInstance())
+	assert.Equal(t, uint32(apimodel.Code_ExecuteSuccess), resp.GetCode().GetValue(), resp.GetInfo().GetValue())
+	discoverSuit.cleanInstance(resp.GetInstance().GetId().GetValue())
+}
@@ -34,6 +34,10 @@ func (svr *ServerAuthAbility) RegisterInstance(ctx context.Context, req *apiserv
 		ctx, []*apiservice.Instance{req}, model.Create, "RegisterInstance")
 
 	_, err := svr.policyMgr.GetAuthChecker().CheckClientPermission(authCtx)
+	if err == nil {
+		err = checkProbeRegistration(authCtx, []*apiservice.Instance{req},
+			svr.policyMgr.GetAuthChecker().IsOpenClientAuth())
+	}
 	if err != nil {
 		resp := api.NewResponseWithMsg(convertToErrCode(err), err.Error())
 		return resp
@@ -64,6 +68,17 @@ func (svr *ServerAuthAbility) DeregisterInstance(ctx context.Context, req *apise
 
 // ReportClient is the interface for reporting client authability
 func (svr *ServerAuthAbility) ReportClient(ctx context.Context, req *apiservice.Client) *apiservice.Response {
+	// 携带配置文件异常的上报会终止按比例灰度，需要上报方对异常涉及的配置分组有读权限
+	if configError := utils.ParseConfigError(ctx); configError != "" {
+		authCtx := svr.collectConfigClientErrorAuthContext(ctx,
+			model.ParseConfigClientErrors(req.GetId().GetValue(), configError), "ReportClient")
+		_, err := svr.policyMgr.GetAuthChecker().CheckClientPermission(authCtx)
+		if err != nil {
+			return api.NewResponseWithMsg(convertToErrCode(err), err.Error())
+		}
+		ctx = authCtx.GetRequestContext()
+		ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+	}
 	return svr.nextSvr.ReportClient(ctx, req)
 }
 
@@ -93,6 +108,44 @@ func (svr *ServerAuthAbility) GetPrometheusTargets(ctx context.Context,
 	return svr.nextSvr.GetPrometheusTargets(ctx, query)
 }
 
+// GetPrometheusInstanceTargets Used for prometheus http service discovery of service instances
+func (svr *ServerAuthAbility) GetPrometheusInstanceTargets(ctx context.Context,
+	query map[string]string) *model.PrometheusDiscoveryResponse {
+	authCtx := svr.collectServiceAuthContext(
+		ctx, svr.queryPrometheusServices(query), model.Read, "GetPrometheusInstanceTargets")
+	_, err := svr.policyMgr.GetAuthChecker().CheckClientPermission(authCtx)
+	if err != nil {
+		return &model.PrometheusDiscoveryResponse{
+			Code:     uint32(convertToErrCode(err)),
+			Response: make([]model.PrometheusTarget, 0),
+		}
+	}
+	ctx = authCtx.GetRequestContext()
+	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
+
+	return svr.nextSvr.GetPrometheusInstanceTargets(ctx, query)
+}
+
+// queryPrometheusServices 根据筛选条件得到本次会返回实例的服务，未指定命名空间时涉及所有的命名空间
+func (svr *ServerAuthAbility) queryPrometheusServices(query map[string]string) []*apiservice.Service {
+	namespace, service := query["namespace"], query["service"]
+	if namespace != "" {
+		return []*apiservice.Service{{
+			Name:      wrapperspb.String(service),
+			Namespace: wrapperspb.String(namespace),
+		}}
+	}
+	namespaces := svr.Cache().Namespace().GetNamespaceList()
+	ret := make([]*apiservice.Service, 0, len(namespaces))
+	for i := range namespaces {
+		ret = append(ret, &apiservice.Service{
+			Name:      wrapperspb.String(service),
+			Namespace: wrapperspb.String(namespaces[i].Name),
+		})
+	}
+	return ret
+}
+
 // GetServiceWithCache is the interface for getting service with cache
 func (svr *ServerAuthAbility) GetServiceWithCache(
 	ctx context.Context, req *apiservice.Service) *apiservice.DiscoverResponse {
@@ -206,6 +259,10 @@ func (svr *ServerAuthAbility) UpdateInstance(ctx context.Context, req *apiservic
 		ctx, []*apiservice.Instance{req}, model.Modify, "UpdateInstance")
 
 	_, err := svr.policyMgr.GetAuthChecker().CheckClientPermission(authCtx)
+	if err == nil {
+		err = checkProbeRegistration(authCtx, []*apiservice.Instance{req},
+			svr.policyMgr.GetAuthChecker().IsOpenClientAuth())
+	}
 	if err != nil {
 		resp := api.NewResponseWithMsg(convertToErrCode(err), err.Error())
 		return resp
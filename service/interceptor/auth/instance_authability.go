@@ -19,10 +19,13 @@ package service_auth
 
 import (
 	"context"
+	"errors"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 
+	"github.com/polarismesh/polaris/auth"
 	api "github.com/polarismesh/polaris/common/api/v1"
 	"github.com/polarismesh/polaris/common/model"
 	authcommon "github.com/polarismesh/polaris/common/model/auth"
@@ -35,6 +38,9 @@ func (svr *ServerAuthAbility) CreateInstances(ctx context.Context,
 	authCtx := svr.collectInstanceAuthContext(ctx, reqs, model.Create, "CreateInstances")
 
 	_, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err == nil {
+		err = checkProbeRegistration(authCtx, reqs, svr.policyMgr.GetAuthChecker().IsOpenConsoleAuth())
+	}
 	if err != nil {
 		resp := api.NewResponseWithMsg(convertToErrCode(err), err.Error())
 		batchResp := api.NewBatchWriteResponse(apimodel.Code_ExecuteSuccess)
@@ -92,6 +98,9 @@ func (svr *ServerAuthAbility) UpdateInstances(ctx context.Context,
 	authCtx := svr.collectInstanceAuthContext(ctx, reqs, model.Modify, "UpdateInstances")
 
 	_, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx)
+	if err == nil {
+		err = checkProbeRegistration(authCtx, reqs, svr.policyMgr.GetAuthChecker().IsOpenConsoleAuth())
+	}
 	if err != nil {
 		return api.NewBatchWriteResponseWithMsg(convertToErrCode(err), err.Error())
 	}
@@ -122,6 +131,7 @@ func (svr *ServerAuthAbility) UpdateInstancesIsolate(ctx context.Context,
 func (svr *ServerAuthAbility) GetInstances(ctx context.Context,
 	query map[string]string) *apiservice.BatchQueryResponse {
 	authCtx := svr.collectInstanceAuthContext(ctx, nil, model.Read, "GetInstances")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_Services, query, "namespace")
 	_, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx)
 	if err != nil {
 		return api.NewBatchQueryResponseWithMsg(convertToErrCode(err), err.Error())
@@ -150,6 +160,7 @@ func (svr *ServerAuthAbility) GetInstanceLabels(ctx context.Context,
 	query map[string]string) *apiservice.Response {
 
 	authCtx := svr.collectInstanceAuthContext(ctx, nil, model.Read, "GetInstanceLabels")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_Services, query, "namespace")
 	_, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx)
 	if err != nil {
 		return api.NewResponseWithMsg(convertToErrCode(err), err.Error())
@@ -158,3 +169,30 @@ func (svr *ServerAuthAbility) GetInstanceLabels(ctx context.Context,
 	ctx = context.WithValue(ctx, utils.ContextAuthContextKey, authCtx)
 	return svr.nextSvr.GetInstanceLabels(ctx, query)
 }
+
+// errProbeNotAuthenticated 主动探测的实例注册没有携带合法的 token
+var errProbeNotAuthenticated = errors.New("active probe health check requires an authenticated registration")
+
+// checkProbeRegistration 开启主动探测后服务端会向实例发起请求，鉴权处于严格模式时只允许携带合法 token 的请求注册这类实例。
+// 未开启鉴权或者鉴权允许匿名访问时，匿名的请求同样可以注册，探测的端口以及地址依然受探测插件的白名单限制
+func checkProbeRegistration(authCtx *model.AcquireContext, reqs []*apiservice.Instance, authOpen bool) error {
+	if !authOpen || authCtx.IsAllowAnonymous() {
+		return nil
+	}
+	needProbe := false
+	for i := range reqs {
+		if model.IsProbeHealthCheck(reqs[i].GetMetadata()) {
+			needProbe = true
+			break
+		}
+	}
+	if !needProbe {
+		return nil
+	}
+	val, _ := authCtx.GetAttachment(model.TokenDetailInfoKey)
+	operator, ok := val.(auth.OperatorInfo)
+	if !ok || operator.Anonymous || operator.Disable {
+		return errProbeNotAuthenticated
+	}
+	return nil
+}
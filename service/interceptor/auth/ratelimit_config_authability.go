@@ -21,6 +21,7 @@ import (
 	"context"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
+	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
 	apitraffic "github.com/polarismesh/specification/source/go/api/v1/traffic_manage"
 
@@ -97,6 +98,7 @@ func (svr *ServerAuthAbility) EnableRateLimits(
 func (svr *ServerAuthAbility) GetRateLimits(
 	ctx context.Context, query map[string]string) *apiservice.BatchQueryResponse {
 	authCtx := svr.collectRateLimitAuthContext(ctx, nil, model.Read, "GetRateLimits")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_Services, query, "namespace")
 
 	_, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx)
 	if err != nil {
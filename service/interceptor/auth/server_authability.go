@@ -20,6 +20,7 @@ package service_auth
 import (
 	"context"
 	"errors"
+	"strconv"
 
 	apifault "github.com/polarismesh/specification/source/go/api/v1/fault_tolerance"
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
@@ -247,6 +248,19 @@ func (svr *ServerAuthAbility) collectFaultDetectAuthContext(ctx context.Context,
 	)
 }
 
+// collectConfigClientErrorAuthContext 客户端上报配置文件异常时，收集异常涉及的配置分组，上报方需要有配置分组的读权限
+func (svr *ServerAuthAbility) collectConfigClientErrorAuthContext(ctx context.Context,
+	req []*model.ConfigClientError, methodName string) *model.AcquireContext {
+	return model.NewAcquireContext(
+		model.WithRequestContext(ctx),
+		model.WithOperation(model.Read),
+		model.WithModule(model.ConfigModule),
+		model.WithMethod(methodName),
+		model.WithFromClient(),
+		model.WithAccessResources(svr.queryConfigClientErrorResource(req)),
+	)
+}
+
 // queryServiceResource  根据所给的 service 信息，收集对应的 ResourceEntry 列表
 func (svr *ServerAuthAbility) queryServiceResource(
 	req []*apiservice.Service) map[apisecurity.ResourceType][]model.ResourceEntry {
@@ -447,6 +461,32 @@ func (svr *ServerAuthAbility) queryRateLimitConfigResource(
 	return ret
 }
 
+// queryConfigClientErrorResource 根据客户端上报的配置文件异常，收集对应配置分组的 ResourceEntry 列表
+func (svr *ServerAuthAbility) queryConfigClientErrorResource(
+	req []*model.ConfigClientError) map[apisecurity.ResourceType][]model.ResourceEntry {
+	groups := map[uint64]*model.ConfigFileGroup{}
+	for index := range req {
+		group := svr.Cache().ConfigGroup().GetGroupByName(req[index].Namespace, req[index].Group)
+		if group != nil {
+			groups[group.Id] = group
+		}
+	}
+	entries := make([]model.ResourceEntry, 0, len(groups))
+	for id, group := range groups {
+		entries = append(entries, model.ResourceEntry{
+			ID:    strconv.FormatUint(id, 10),
+			Owner: group.Owner,
+		})
+	}
+	ret := map[apisecurity.ResourceType][]model.ResourceEntry{
+		apisecurity.ResourceType_ConfigGroups: entries,
+	}
+	if authLog.DebugEnabled() {
+		authLog.Debug("[Auth][Server] collect config client error access res", zap.Any("res", ret))
+	}
+	return ret
+}
+
 // convertToDiscoverResourceEntryMaps 通用方法，进行转换为期望的、服务相关的 ResourceEntry
 func (svr *ServerAuthAbility) convertToDiscoverResourceEntryMaps(nsSet *utils.Set[string],
 	svcSet *utils.Map[string, *model.Service]) map[apisecurity.ResourceType][]model.ResourceEntry {
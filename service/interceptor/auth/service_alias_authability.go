@@ -85,6 +85,7 @@ func (svr *ServerAuthAbility) UpdateServiceAlias(
 func (svr *ServerAuthAbility) GetServiceAliases(ctx context.Context,
 	query map[string]string) *apiservice.BatchQueryResponse {
 	authCtx := svr.collectServiceAliasAuthContext(ctx, nil, model.Read, "GetServiceAliases")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_Services, query, "alias_namespace")
 
 	if _, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
 		return api.NewBatchQueryResponse(convertToErrCode(err))
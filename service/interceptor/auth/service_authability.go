@@ -114,6 +114,7 @@ func (svr *ServerAuthAbility) UpdateServiceToken(
 func (svr *ServerAuthAbility) GetAllServices(ctx context.Context,
 	query map[string]string) *apiservice.BatchQueryResponse {
 	authCtx := svr.collectServiceAuthContext(ctx, nil, model.Read, "GetAllServices")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_Services, query, "namespace")
 
 	if _, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
 		return api.NewBatchQueryResponseWithMsg(convertToErrCode(err), err.Error())
@@ -129,6 +130,7 @@ func (svr *ServerAuthAbility) GetAllServices(ctx context.Context,
 func (svr *ServerAuthAbility) GetServices(
 	ctx context.Context, query map[string]string) *apiservice.BatchQueryResponse {
 	authCtx := svr.collectServiceAuthContext(ctx, nil, model.Read, "GetServices")
+	authCtx.SetNamespaceFilter(apisecurity.ResourceType_Services, query, "namespace")
 
 	if _, err := svr.policyMgr.GetAuthChecker().CheckConsolePermission(authCtx); err != nil {
 		return api.NewBatchQueryResponseWithMsg(convertToErrCode(err), err.Error())
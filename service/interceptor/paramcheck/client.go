@@ -19,6 +19,8 @@ package paramcheck
 
 import (
 	"context"
+	"strconv"
+	"strings"
 
 	apimodel "github.com/polarismesh/specification/source/go/api/v1/model"
 	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
@@ -46,6 +48,31 @@ func (svr *Server) GetPrometheusTargets(ctx context.Context,
 	return svr.nextSvr.GetPrometheusTargets(ctx, query)
 }
 
+// GetPrometheusInstanceTargets implements service.DiscoverServer.
+func (svr *Server) GetPrometheusInstanceTargets(ctx context.Context,
+	query map[string]string) *model.PrometheusDiscoveryResponse {
+	newErrResp := func() *model.PrometheusDiscoveryResponse {
+		return &model.PrometheusDiscoveryResponse{
+			Code:     api.InvalidParameter,
+			Response: make([]model.PrometheusTarget, 0),
+		}
+	}
+	for _, key := range []string{"healthy", "isolate"} {
+		if val, ok := query[key]; ok && val != "" {
+			if _, err := strconv.ParseBool(val); err != nil {
+				return newErrResp()
+			}
+		}
+	}
+	// 元数据条件的 key 与 value 需要一一对应
+	if keys, values := query["keys"], query["values"]; keys != "" || values != "" {
+		if keys == "" || len(strings.Split(keys, ",")) != len(strings.Split(values, ",")) {
+			return newErrResp()
+		}
+	}
+	return svr.nextSvr.GetPrometheusInstanceTargets(ctx, query)
+}
+
 // RegisterInstance create one instance by client
 func (s *Server) RegisterInstance(ctx context.Context, req *apiservice.Instance) *apiservice.Response {
 	// 参数检查
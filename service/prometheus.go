@@ -0,0 +1,260 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	cachetypes "github.com/polarismesh/polaris/cache/api"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+const (
+	// prometheusMetaLabelPrefix prometheus http_sd 中以 __meta_ 开头的标签只用于 relabel，不会写入指标
+	prometheusMetaLabelPrefix = "__meta_polaris_"
+	// prometheusMetadataLabelPrefix 实例元数据对应的标签前缀
+	prometheusMetadataLabelPrefix = prometheusMetaLabelPrefix + "metadata_"
+)
+
+// prometheusInstanceSelector 服务实例的筛选条件
+type prometheusInstanceSelector struct {
+	namespace string
+	service   string
+	protocols map[string]struct{}
+	healthy   *bool
+	isolate   *bool
+	metadata  map[string]string
+}
+
+// GetPrometheusInstanceTargets 按照 prometheus http_sd 的格式返回服务实例，支持所有协议以及健康状态的实例，
+// 请求中的 revision 与当前的版本一致时返回 DataNoChange，避免 prometheus 轮询时重复传输相同的结果
+func (s *Server) GetPrometheusInstanceTargets(ctx context.Context,
+	query map[string]string) *model.PrometheusDiscoveryResponse {
+	if s.caches == nil {
+		return &model.PrometheusDiscoveryResponse{
+			Code:     api.NotFoundInstance,
+			Response: make([]model.PrometheusTarget, 0),
+		}
+	}
+
+	selector := parsePrometheusInstanceSelector(query)
+	services := s.selectPrometheusServices(selector)
+	revision, err := s.computePrometheusRevision(query, services)
+	if err != nil {
+		log.Error("[Server][Prometheus] compute instance targets revision", utils.RequestID(ctx), zap.Error(err))
+		return &model.PrometheusDiscoveryResponse{
+			Code:     api.ExecuteException,
+			Response: make([]model.PrometheusTarget, 0),
+		}
+	}
+	if expect := query["revision"]; expect != "" && expect == revision {
+		return &model.PrometheusDiscoveryResponse{
+			Code:     api.DataNoChange,
+			Response: make([]model.PrometheusTarget, 0),
+			Revision: revision,
+		}
+	}
+
+	targets := make([]model.PrometheusTarget, 0, 8)
+	for _, svc := range services {
+		instances := s.caches.Instance().GetInstancesByServiceID(svc.ID)
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].ID() < instances[j].ID()
+		})
+		for _, ins := range instances {
+			if !selector.match(ins) {
+				continue
+			}
+			targets = append(targets, model.PrometheusTarget{
+				Targets: []string{fmt.Sprintf("%s:%d", ins.Host(), ins.Port())},
+				Labels:  s.buildPrometheusInstanceLabels(svc, ins),
+			})
+		}
+	}
+
+	return &model.PrometheusDiscoveryResponse{
+		Code:     api.ExecuteSuccess,
+		Response: targets,
+		Revision: revision,
+	}
+}
+
+// selectPrometheusServices 按照命名空间以及服务名筛选服务，返回的服务按照命名空间以及服务名排序
+func (s *Server) selectPrometheusServices(selector *prometheusInstanceSelector) []*model.Service {
+	var services []*model.Service
+	if selector.namespace != "" {
+		_, services = s.caches.Service().ListServices(selector.namespace)
+	} else {
+		_, services = s.caches.Service().ListAllServices()
+	}
+
+	ret := make([]*model.Service, 0, len(services))
+	for _, svc := range services {
+		// 别名服务没有实例，不需要重复返回
+		if svc.IsAlias() {
+			continue
+		}
+		if selector.service != "" && svc.Name != selector.service {
+			continue
+		}
+		ret = append(ret, svc)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Namespace != ret[j].Namespace {
+			return ret[i].Namespace < ret[j].Namespace
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// computePrometheusRevision 结合筛选条件以及服务实例的 revision 计算结果的版本，
+// 服务实例的 revision 由 ServiceRevisionWorker 在实例变化时重新计算
+func (s *Server) computePrometheusRevision(query map[string]string,
+	services []*model.Service) (string, error) {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k == "revision" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	selector := strings.Builder{}
+	for _, k := range keys {
+		selector.WriteString(k + "=" + query[k] + "&")
+	}
+	revisions := make([]string, 0, len(services)+1)
+	revisions = append(revisions, selector.String())
+	worker := s.caches.Service().GetRevisionWorker()
+	for _, svc := range services {
+		revisions = append(revisions, svc.ID+"@"+worker.GetServiceInstanceRevision(svc.ID))
+	}
+	return cachetypes.CompositeComputeRevision(revisions)
+}
+
+// buildPrometheusInstanceLabels 生成便于 relabel 的实例标签，实例没有地域信息时从 CMDB 中获取
+func (s *Server) buildPrometheusInstanceLabels(svc *model.Service, ins *model.Instance) map[string]string {
+	labels := map[string]string{
+		prometheusMetaLabelPrefix + "namespace":   svc.Namespace,
+		prometheusMetaLabelPrefix + "service":     svc.Name,
+		prometheusMetaLabelPrefix + "instance_id": ins.ID(),
+		prometheusMetaLabelPrefix + "host":        ins.Host(),
+		prometheusMetaLabelPrefix + "port":        strconv.FormatUint(uint64(ins.Port()), 10),
+		prometheusMetaLabelPrefix + "protocol":    ins.Protocol(),
+		prometheusMetaLabelPrefix + "version":     ins.Version(),
+		prometheusMetaLabelPrefix + "weight":      strconv.FormatUint(uint64(ins.Weight()), 10),
+		prometheusMetaLabelPrefix + "healthy":     strconv.FormatBool(ins.Healthy()),
+		prometheusMetaLabelPrefix + "isolate":     strconv.FormatBool(ins.Isolate()),
+	}
+
+	location := ins.Location()
+	if location.GetRegion().GetValue() == "" && location.GetZone().GetValue() == "" &&
+		location.GetCampus().GetValue() == "" {
+		if cmdbLocation := s.getLocation(ins.Host()); cmdbLocation != nil {
+			location = cmdbLocation.Proto
+		}
+	}
+	labels[prometheusMetaLabelPrefix+"region"] = location.GetRegion().GetValue()
+	labels[prometheusMetaLabelPrefix+"zone"] = location.GetZone().GetValue()
+	labels[prometheusMetaLabelPrefix+"campus"] = location.GetCampus().GetValue()
+
+	switch strings.ToLower(ins.Protocol()) {
+	case "http", "https":
+		labels["__scheme__"] = strings.ToLower(ins.Protocol())
+	}
+	for k, v := range ins.Metadata() {
+		labels[prometheusMetadataLabelPrefix+sanitizePrometheusLabelName(k)] = v
+	}
+	return labels
+}
+
+func parsePrometheusInstanceSelector(query map[string]string) *prometheusInstanceSelector {
+	selector := &prometheusInstanceSelector{
+		namespace: query["namespace"],
+		service:   query["service"],
+		protocols: map[string]struct{}{},
+		metadata:  map[string]string{},
+	}
+	for _, protocol := range strings.Split(query["protocol"], ",") {
+		if protocol = strings.TrimSpace(protocol); protocol != "" {
+			selector.protocols[strings.ToLower(protocol)] = struct{}{}
+		}
+	}
+	if val, err := strconv.ParseBool(query["healthy"]); err == nil {
+		selector.healthy = &val
+	}
+	if val, err := strconv.ParseBool(query["isolate"]); err == nil {
+		selector.isolate = &val
+	}
+	keys, values := splitPrometheusMetadata(query)
+	for i := range keys {
+		if i < len(values) {
+			selector.metadata[keys[i]] = values[i]
+		}
+	}
+	return selector
+}
+
+// splitPrometheusMetadata 元数据条件通过 keys 以及 values 传入，多个条件之间使用逗号分隔
+func splitPrometheusMetadata(query map[string]string) ([]string, []string) {
+	if query["keys"] == "" {
+		return nil, nil
+	}
+	return strings.Split(query["keys"], ","), strings.Split(query["values"], ",")
+}
+
+func (p *prometheusInstanceSelector) match(ins *model.Instance) bool {
+	if len(p.protocols) > 0 {
+		if _, ok := p.protocols[strings.ToLower(ins.Protocol())]; !ok {
+			return false
+		}
+	}
+	if p.healthy != nil && *p.healthy != ins.Healthy() {
+		return false
+	}
+	if p.isolate != nil && *p.isolate != ins.Isolate() {
+		return false
+	}
+	metadata := ins.Metadata()
+	for k, v := range p.metadata {
+		if metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizePrometheusLabelName prometheus 的标签名只允许字母、数字以及下划线
+func sanitizePrometheusLabelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
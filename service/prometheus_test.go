@@ -0,0 +1,176 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/auth/policy"
+	"github.com/polarismesh/polaris/cache"
+	api "github.com/polarismesh/polaris/common/api/v1"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+)
+
+func TestServer_GetPrometheusInstanceTargets(t *testing.T) {
+	discoverSuit := &DiscoverTestSuit{}
+	if err := discoverSuit.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		discoverSuit.Destroy()
+	})
+
+	_, svc := discoverSuit.createCommonService(t, 901)
+	t.Cleanup(func() {
+		discoverSuit.cleanServiceName(svc.GetName().GetValue(), svc.GetNamespace().GetValue())
+	})
+	addInstance := func(host, protocol string, healthy bool, metadata map[string]string) {
+		_, ins := discoverSuit.addInstance(t, &apiservice.Instance{
+			ServiceToken: utils.NewStringValue(svc.GetToken().GetValue()),
+			Service:      utils.NewStringValue(svc.GetName().GetValue()),
+			Namespace:    utils.NewStringValue(svc.GetNamespace().GetValue()),
+			Host:         utils.NewStringValue(host),
+			Port:         utils.NewUInt32Value(8080),
+			Protocol:     utils.NewStringValue(protocol),
+			Version:      utils.NewStringValue("1.0.0"),
+			Healthy:      utils.NewBoolValue(healthy),
+			Isolate:      utils.NewBoolValue(false),
+			Metadata:     metadata,
+		})
+		t.Cleanup(func() {
+			discoverSuit.cleanInstance(ins.GetId().GetValue())
+		})
+	}
+	addInstance("127.0.0.1", "http", true, map[string]string{"env": "prod", "app.kubernetes.io/name": "demo"})
+	addInstance("127.0.0.2", "grpc", false, map[string]string{"env": "test"})
+
+	query := map[string]string{
+		"namespace": svc.GetNamespace().GetValue(),
+		"service":   svc.GetName().GetValue(),
+	}
+	getTargets := func(query map[string]string) *model.PrometheusDiscoveryResponse {
+		return discoverSuit.DiscoverServer().GetPrometheusInstanceTargets(context.Background(), query)
+	}
+	// 等待缓存中出现所有的实例，并且 ServiceRevisionWorker 计算出实例的 revision
+	waitRevision := func(old string, expect int) string {
+		var revision string
+		assert.Eventually(t, func() bool {
+			_ = discoverSuit.DiscoverServer().Cache().(*cache.CacheManager).TestUpdate()
+			resp := getTargets(query)
+			revision = resp.Revision
+			return len(resp.Response) == expect && revision != old &&
+				discoverSuit.DiscoverServer().Cache().Service().GetRevisionWorker().
+					GetServiceInstanceRevision(svc.GetId().GetValue()) != ""
+		}, 10*time.Second, 100*time.Millisecond)
+		return revision
+	}
+	waitRevision("", 2)
+
+	t.Run("all_protocols_and_health_states", func(t *testing.T) {
+		resp := getTargets(query)
+		assert.Equal(t, api.ExecuteSuccess, resp.Code)
+		assert.Equal(t, 2, len(resp.Response))
+
+		target := resp.Response[0]
+		if target.Targets[0] != "127.0.0.1:8080" {
+			target = resp.Response[1]
+		}
+		assert.Equal(t, []string{"127.0.0.1:8080"}, target.Targets)
+		assert.Equal(t, svc.GetName().GetValue(), target.Labels["__meta_polaris_service"])
+		assert.Equal(t, "http", target.Labels["__meta_polaris_protocol"])
+		assert.Equal(t, "http", target.Labels["__scheme__"])
+		assert.Equal(t, "1.0.0", target.Labels["__meta_polaris_version"])
+		assert.Equal(t, "true", target.Labels["__meta_polaris_healthy"])
+		assert.Equal(t, "false", target.Labels["__meta_polaris_isolate"])
+		assert.Equal(t, "demo", target.Labels["__meta_polaris_metadata_app_kubernetes_io_name"])
+	})
+
+	t.Run("selectors", func(t *testing.T) {
+		for _, item := range []struct {
+			filter map[string]string
+			expect int
+		}{
+			{filter: map[string]string{"protocol": "GRPC,dubbo"}, expect: 1},
+			{filter: map[string]string{"healthy": "true"}, expect: 1},
+			{filter: map[string]string{"isolate": "true"}, expect: 0},
+			{filter: map[string]string{"keys": "env", "values": "test"}, expect: 1},
+			{filter: map[string]string{"keys": "env,app.kubernetes.io/name", "values": "prod,demo"}, expect: 1},
+		} {
+			filter := map[string]string{}
+			for k, v := range query {
+				filter[k] = v
+			}
+			for k, v := range item.filter {
+				filter[k] = v
+			}
+			resp := getTargets(filter)
+			assert.Equal(t, api.ExecuteSuccess, resp.Code)
+			assert.Equal(t, item.expect, len(resp.Response), item.filter)
+		}
+
+		resp := getTargets(map[string]string{"healthy": "yes"})
+		assert.Equal(t, api.InvalidParameter, resp.Code)
+		resp = getTargets(map[string]string{"keys": "env", "values": "prod,test"})
+		assert.Equal(t, api.InvalidParameter, resp.Code)
+	})
+
+	t.Run("revision", func(t *testing.T) {
+		revision := getTargets(query).Revision
+		filter := map[string]string{"revision": revision}
+		for k, v := range query {
+			filter[k] = v
+		}
+		resp := getTargets(filter)
+		assert.Equal(t, api.DataNoChange, resp.Code)
+		assert.Equal(t, 0, len(resp.Response))
+
+		// 筛选条件不同，结果的版本也不同
+		filter["healthy"] = "true"
+		assert.Equal(t, api.ExecuteSuccess, getTargets(filter).Code)
+
+		// 实例变化后版本发生变化
+		addInstance("127.0.0.3", "http", true, nil)
+		newRevision := waitRevision(revision, 3)
+		assert.NotEqual(t, revision, newRevision)
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		checker := discoverSuit.StrategyServer().GetAuthChecker().(*policy.DefaultAuthChecker)
+		oldConf := checker.GetConfig()
+		defer func() {
+			checker.SetConfig(oldConf)
+		}()
+		checker.SetConfig(&policy.AuthConfig{
+			ClientOpen:   true,
+			ClientStrict: true,
+		})
+
+		// 开启客户端鉴权后，未携带 token 的请求不能获取任何命名空间下的实例
+		for _, filter := range []map[string]string{query, {}} {
+			resp := getTargets(filter)
+			assert.NotEqual(t, api.ExecuteSuccess, resp.Code)
+			assert.Equal(t, 0, len(resp.Response))
+		}
+	})
+}
@@ -24,8 +24,9 @@ import (
 )
 
 const (
-	ElectionKeySelfServiceChecker = "polaris.checker"
-	ElectionKeyMaintainJob        = "MaintainJob"
+	ElectionKeySelfServiceChecker     = "polaris.checker"
+	ElectionKeyMaintainJob            = "MaintainJob"
+	ElectionKeyConfigReleaseScheduler = "ConfigReleaseScheduler"
 )
 
 type AdminStore interface {
@@ -57,3 +58,34 @@ type LeaderChangeEvent struct {
 	Leader     bool
 	LeaderHost string
 }
+
+// OperationRecordStore 资源操作记录的存储接口
+type OperationRecordStore interface {
+	// AddOperationRecords 批量保存操作记录
+	AddOperationRecords(records []*model.RecordEntry) error
+	// GetOperationRecords 查询操作记录，按照发生时间倒序返回
+	// filter 支持 resource_type, resource_name, namespace, operator, operation_type 精确匹配,
+	// 以及 start_time, end_time 按照秒级时间戳过滤
+	GetOperationRecords(filter map[string]string, offset, limit uint32) (uint32, []*model.RecordEntry, error)
+	// CleanOperationRecords 清理 endTime 之前的操作记录
+	CleanOperationRecords(endTime time.Time, limit uint64) error
+}
+
+// CARootStore xds 内置 CA 根证书的存储接口
+type CARootStore interface {
+	// CreateCARootIfAbsent 信任域下没有根证书时保存 root, 返回该信任域当前生效的根证书
+	CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error)
+	// GetCARoot 查询信任域当前生效的根证书, 不存在时返回 nil
+	GetCARoot(trustDomain string) (*model.CARoot, error)
+	// RotateCARoot 信任域当前的根证书为 prevCertPEM 时替换为 root, 并且保留 prevCertPEM 作为上一个根证书,
+	// 返回该信任域当前生效的根证书
+	RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error)
+}
+
+// HealthCheckProtectionStore 人工设置的健康检查保护模式的存储接口
+type HealthCheckProtectionStore interface {
+	// SetHealthCheckProtection 保存人工设置的保护模式
+	SetHealthCheckProtection(protection *model.HealthCheckProtection) error
+	// GetHealthCheckProtection 查询人工设置的保护模式, 未设置时返回 nil
+	GetHealthCheckProtection() (*model.HealthCheckProtection, error)
+}
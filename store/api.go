@@ -49,6 +49,12 @@ type Store interface {
 	AdminStore
 	// GrayStore mgr gray resource
 	GrayStore
+	// OperationRecordStore resource operation records
+	OperationRecordStore
+	// CARootStore root cert of xds built-in ca
+	CARootStore
+	// HealthCheckProtectionStore manual override of health check protection mode
+	HealthCheckProtectionStore
 }
 
 // NamespaceStore Namespace storage interface
@@ -44,6 +44,18 @@ type UserStore interface {
 	// GetUsersForCache Used to refresh user cache
 	// 此方法用于 cache 增量更新，需要注意 mtime 应为数据库时间戳
 	GetUsersForCache(mtime time.Time, firstUpdate bool) ([]*model.User, error)
+	// GetLoginLock Get the login failure counter and lock state by key
+	GetLoginLock(key string) (*model.LoginLock, error)
+	// SaveLoginLock Create or replace the login failure counter and lock state
+	SaveLoginLock(lock *model.LoginLock) error
+	// IncrLoginFailure Atomically record a login failure and return the new counter and lock state
+	IncrLoginFailure(failure *model.LoginFailure) (*model.LoginLock, error)
+	// DeleteLoginLock Delete the login failure counter and lock state by key
+	DeleteLoginLock(key string) error
+	// GetUserPasswordRecord Get the password history and last change time of a user
+	GetUserPasswordRecord(userID string) (*model.UserPasswordRecord, error)
+	// SaveUserPasswordRecord Create or replace the password history and last change time of a user
+	SaveUserPasswordRecord(record *model.UserPasswordRecord) error
 }
 
 // GroupStore User group storage operation interface
@@ -72,6 +84,20 @@ type GroupStore interface {
 	GetGroupsForCache(mtime time.Time, firstUpdate bool) ([]*model.UserGroupDetail, error)
 }
 
+// APITokenStore API token storage operation interface
+type APITokenStore interface {
+	// AddAPIToken Create an API token
+	AddAPIToken(token *model.APIToken) error
+	// DeleteAPIToken Revoke an API token
+	DeleteAPIToken(id string) error
+	// UpdateAPITokenLastUsed Update the last used time of an API token
+	UpdateAPITokenLastUsed(id string, lastUsed time.Time) error
+	// GetAPIToken Get a valid API token by id
+	GetAPIToken(id string) (*model.APIToken, error)
+	// GetAPITokens Query valid API tokens, support filter by principal_type, principal_id, owner and name
+	GetAPITokens(filters map[string]string, offset uint32, limit uint32) (uint32, []*model.APIToken, error)
+}
+
 // StrategyStore Authentication policy related storage operation interface
 type StrategyStore interface {
 
@@ -91,6 +117,9 @@ type StrategyStore interface {
 	// RemoveStrategyResources Clean all the strategies associated with corresponding resources
 	RemoveStrategyResources(resources []model.StrategyResource) error
 
+	// UpdateStrategyConditions Replace the conditions of an authentication strategy, nil means no condition
+	UpdateStrategyConditions(id string, conditions *model.StrategyConditions) error
+
 	// GetStrategyResources Gets a Principal's corresponding resource ID data information
 	GetStrategyResources(principalId string, principalRole model.PrincipalType) ([]model.StrategyResource, error)
 
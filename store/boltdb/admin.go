@@ -19,6 +19,7 @@ package boltdb
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -103,7 +104,11 @@ func (m *adminStore) ReleaseLeaderElection(key string) error {
 
 // BatchCleanDeletedInstances
 func (m *adminStore) BatchCleanDeletedInstances(timeout time.Duration, batchSize uint32) (uint32, error) {
-	mtime := time.Now().Add(-timeout)
+	return m.BatchCleanDeletedInstancesBefore(time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedInstancesBefore batch clean instances soft deleted before mtime
+func (m *adminStore) BatchCleanDeletedInstancesBefore(mtime time.Time, batchSize uint32) (uint32, error) {
 	fields := []string{insFieldValid, insFieldModifyTime}
 	values, err := m.handler.LoadValuesByFilter(tblNameInstance, fields, &model.Instance{},
 		func(m map[string]interface{}) bool {
@@ -132,20 +137,11 @@ func (m *adminStore) BatchCleanDeletedInstances(timeout time.Duration, batchSize
 		return 0, nil
 	}
 
-	var count uint32 = 0
-	keys := make([]string, 0, batchSize)
-	for k := range values {
-		keys = append(keys, k)
-		count++
-		if count >= batchSize {
-			break
-		}
+	keys := firstKeys(values, batchSize)
+	if err = m.handler.DeleteValues(tblNameInstance, keys); err != nil {
+		return uint32(len(keys)), err
 	}
-	err = m.handler.DeleteValues(tblNameInstance, keys)
-	if err != nil {
-		return count, err
-	}
-	return count, nil
+	return uint32(len(keys)), nil
 }
 
 func (m *adminStore) GetUnHealthyInstances(timeout time.Duration, limit uint32) ([]string, error) {
@@ -210,7 +206,11 @@ func (m *adminStore) getUnHealthyInstancesBefore(mtime time.Time, limit uint32)
 
 // BatchCleanDeletedClients
 func (m *adminStore) BatchCleanDeletedClients(timeout time.Duration, batchSize uint32) (uint32, error) {
-	mtime := time.Now().Add(-timeout)
+	return m.BatchCleanDeletedClientsBefore(time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedClientsBefore batch clean clients soft deleted before mtime
+func (m *adminStore) BatchCleanDeletedClientsBefore(mtime time.Time, batchSize uint32) (uint32, error) {
 	fields := []string{ClientFieldValid, ClientFieldMtime}
 	values, err := m.handler.LoadValuesByFilter(tblClient, fields, &clientObject{},
 		func(m map[string]interface{}) bool {
@@ -239,30 +239,52 @@ func (m *adminStore) BatchCleanDeletedClients(timeout time.Duration, batchSize u
 		return 0, nil
 	}
 
-	keys := make([]string, 0, batchSize)
-	for k := range values {
-		keys = append(keys, k)
-		if uint32(len(keys)) >= batchSize {
-			break
-		}
-	}
+	keys := firstKeys(values, batchSize)
 	if err = m.handler.DeleteValues(tblClient, keys); err != nil {
 		return 0, err
 	}
 	return uint32(len(keys)), nil
 }
 
+// firstKeys 按 key 排序后取前 batchSize 个，保证多副本删除同一批数据
+func firstKeys(values map[string]interface{}, batchSize uint32) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if uint32(len(keys)) > batchSize {
+		keys = keys[:batchSize]
+	}
+	return keys
+}
+
 // BatchCleanDeletedServices batch clean soft deleted clients
 func (m *adminStore) BatchCleanDeletedServices(timeout time.Duration, batchSize uint32) (uint32, error) {
+	return m.BatchCleanDeletedServicesBefore(time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedServicesBefore batch clean services soft deleted before mtime
+func (m *adminStore) BatchCleanDeletedServicesBefore(mtime time.Time, batchSize uint32) (uint32, error) {
 	return 0, nil
 }
 
 // BatchCleanDeletedRules batch clean soft deleted clients
 func (m *adminStore) BatchCleanDeletedRules(rule string, timeout time.Duration, batchSize uint32) (uint32, error) {
+	return m.BatchCleanDeletedRulesBefore(rule, time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedRulesBefore batch clean rules soft deleted before mtime
+func (m *adminStore) BatchCleanDeletedRulesBefore(rule string, mtime time.Time, batchSize uint32) (uint32, error) {
 	return 0, nil
 }
 
 // BatchCleanDeletedConfigFiles batch clean soft deleted clients
 func (m *adminStore) BatchCleanDeletedConfigFiles(timeout time.Duration, batchSize uint32) (uint32, error) {
+	return m.BatchCleanDeletedConfigFilesBefore(time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedConfigFilesBefore batch clean config files soft deleted before mtime
+func (m *adminStore) BatchCleanDeletedConfigFilesBefore(mtime time.Time, batchSize uint32) (uint32, error) {
 	return 0, nil
 }
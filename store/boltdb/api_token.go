@@ -0,0 +1,259 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblAPIToken string = "api_token"
+
+	APITokenFieldPrincipalType string = "PrincipalType"
+	APITokenFieldPrincipalID   string = "PrincipalID"
+	APITokenFieldOwner         string = "Owner"
+	APITokenFieldName          string = "Name"
+	APITokenFieldLastUsedTime  string = "LastUsedTime"
+	APITokenFieldValid         string = "Valid"
+	APITokenFieldModifyTime    string = "ModifyTime"
+)
+
+// apiToken API token 的存储结构
+type apiToken struct {
+	ID            string
+	Name          string
+	PrincipalType int
+	PrincipalID   string
+	Owner         string
+	TokenHash     string
+	// Namespaces/ResourceTypes/Actions 以 JSON 数组的形式保存
+	Namespaces    string
+	ResourceTypes string
+	Actions       string
+	// ExpireTime/LastUsedTime 保存为秒级时间戳，0 表示未设置
+	ExpireTime   int64
+	LastUsedTime int64
+	Comment      string
+	Valid        bool
+	CreateTime   time.Time
+	CreateBy     string
+	ModifyTime   time.Time
+}
+
+type apiTokenStore struct {
+	handler BoltHandler
+}
+
+// AddAPIToken 创建 API token
+func (as *apiTokenStore) AddAPIToken(token *model.APIToken) error {
+	if token.ID == "" || token.PrincipalID == "" || token.TokenHash == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "add api token missing some params")
+	}
+	tn := nowOf(as.handler)
+	data := toAPITokenStore(token)
+	data.Valid = true
+	data.LastUsedTime = 0
+	data.CreateTime = tn
+	data.ModifyTime = tn
+	if err := as.handler.SaveValue(tblAPIToken, token.ID, data); err != nil {
+		log.Error("[Store][APIToken] save api token", zap.String("id", token.ID), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// DeleteAPIToken 吊销 API token
+func (as *apiTokenStore) DeleteAPIToken(id string) error {
+	properties := map[string]interface{}{
+		APITokenFieldValid:      false,
+		APITokenFieldModifyTime: nowOf(as.handler),
+	}
+	if err := as.handler.UpdateValue(tblAPIToken, id, properties); err != nil {
+		log.Error("[Store][APIToken] revoke api token", zap.String("id", id), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// UpdateAPITokenLastUsed 更新 API token 最近一次使用的时间
+func (as *apiTokenStore) UpdateAPITokenLastUsed(id string, lastUsed time.Time) error {
+	properties := map[string]interface{}{
+		APITokenFieldLastUsedTime: lastUsed.Unix(),
+	}
+	if err := as.handler.UpdateValue(tblAPIToken, id, properties); err != nil {
+		log.Error("[Store][APIToken] update api token last used time", zap.String("id", id), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetAPIToken 获取有效的 API token
+func (as *apiTokenStore) GetAPIToken(id string) (*model.APIToken, error) {
+	values, err := as.handler.LoadValues(tblAPIToken, []string{id}, &apiToken{})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	data, ok := values[id].(*apiToken)
+	if !ok || !data.Valid {
+		return nil, nil
+	}
+	return toAPITokenModel(data), nil
+}
+
+// GetAPITokens 查询有效的 API token，按照创建时间倒序返回
+func (as *apiTokenStore) GetAPITokens(filters map[string]string, offset uint32,
+	limit uint32) (uint32, []*model.APIToken, error) {
+	fields := []string{APITokenFieldPrincipalType, APITokenFieldPrincipalID, APITokenFieldOwner,
+		APITokenFieldName, APITokenFieldValid}
+	values, err := as.handler.LoadValuesByFilter(tblAPIToken, fields, &apiToken{},
+		func(m map[string]interface{}) bool {
+			if valid, _ := m[APITokenFieldValid].(bool); !valid {
+				return false
+			}
+			if expect := filters["principal_type"]; expect != "" {
+				save, _ := m[APITokenFieldPrincipalType].(int64)
+				if strconv.FormatInt(save, 10) != expect {
+					return false
+				}
+			}
+			for field, key := range map[string]string{
+				APITokenFieldPrincipalID: "principal_id",
+				APITokenFieldOwner:       "owner",
+				APITokenFieldName:        "name",
+			} {
+				if expect := filters[key]; expect != "" {
+					if save, _ := m[field].(string); save != expect {
+						return false
+					}
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+
+	datas := make([]*apiToken, 0, len(values))
+	for k := range values {
+		datas = append(datas, values[k].(*apiToken))
+	}
+	sort.Slice(datas, func(i, j int) bool {
+		if !datas[i].CreateTime.Equal(datas[j].CreateTime) {
+			return datas[i].CreateTime.After(datas[j].CreateTime)
+		}
+		return datas[i].ID > datas[j].ID
+	})
+
+	total := uint32(len(datas))
+	if offset >= total {
+		return total, []*model.APIToken{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	ret := make([]*model.APIToken, 0, end-offset)
+	for _, data := range datas[offset:end] {
+		ret = append(ret, toAPITokenModel(data))
+	}
+	return total, ret, nil
+}
+
+func toAPITokenStore(token *model.APIToken) *apiToken {
+	return &apiToken{
+		ID:            token.ID,
+		Name:          token.Name,
+		PrincipalType: int(token.PrincipalType),
+		PrincipalID:   token.PrincipalID,
+		Owner:         token.Owner,
+		TokenHash:     token.TokenHash,
+		Namespaces:    marshalAPITokenScope(token.Namespaces),
+		ResourceTypes: marshalAPITokenScope(token.ResourceTypes),
+		Actions:       marshalAPITokenScope(token.Actions),
+		ExpireTime:    unixOrZero(token.ExpireTime),
+		LastUsedTime:  unixOrZero(token.LastUsedTime),
+		Comment:       token.Comment,
+		Valid:         token.Valid,
+		CreateTime:    token.CreateTime,
+		CreateBy:      token.CreateBy,
+		ModifyTime:    token.ModifyTime,
+	}
+}
+
+func toAPITokenModel(data *apiToken) *model.APIToken {
+	return &model.APIToken{
+		ID:            data.ID,
+		Name:          data.Name,
+		PrincipalType: model.PrincipalType(data.PrincipalType),
+		PrincipalID:   data.PrincipalID,
+		Owner:         data.Owner,
+		TokenHash:     data.TokenHash,
+		Namespaces:    unmarshalAPITokenScope(data.Namespaces),
+		ResourceTypes: unmarshalAPITokenScope(data.ResourceTypes),
+		Actions:       unmarshalAPITokenScope(data.Actions),
+		ExpireTime:    timeOrZero(data.ExpireTime),
+		LastUsedTime:  timeOrZero(data.LastUsedTime),
+		Comment:       data.Comment,
+		Valid:         data.Valid,
+		CreateTime:    data.CreateTime,
+		CreateBy:      data.CreateBy,
+		ModifyTime:    data.ModifyTime,
+	}
+}
+
+func marshalAPITokenScope(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(items)
+	return string(data)
+}
+
+func unmarshalAPITokenScope(data string) []string {
+	if data == "" {
+		return nil
+	}
+	var items []string
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		log.Error("[Store][APIToken] unmarshal api token scope", zap.String("scope", data), zap.Error(err))
+		return nil
+	}
+	return items
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func timeOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
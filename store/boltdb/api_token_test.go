@@ -0,0 +1,95 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package boltdb
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestAPITokenStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblAPIToken, func(t *testing.T, handler BoltHandler) {
+		s := &apiTokenStore{handler: handler}
+
+		expire := time.Now().Add(time.Hour)
+		for i := 0; i < 3; i++ {
+			token := &model.APIToken{
+				ID:            "token-" + strconv.Itoa(i),
+				Name:          "ci-" + strconv.Itoa(i),
+				PrincipalType: model.PrincipalUser,
+				PrincipalID:   "user-1",
+				Owner:         "owner-1",
+				TokenHash:     "hash-" + strconv.Itoa(i),
+				Namespaces:    []string{"prod", "test"},
+				Actions:       []string{model.APITokenActionRead},
+				ExpireTime:    expire,
+			}
+			if i == 2 {
+				token.PrincipalType = model.PrincipalGroup
+				token.PrincipalID = "group-1"
+			}
+			assert.NoError(t, s.AddAPIToken(token))
+			time.Sleep(time.Millisecond)
+		}
+
+		saved, err := s.GetAPIToken("token-0")
+		assert.NoError(t, err)
+		assert.Equal(t, "ci-0", saved.Name)
+		assert.Equal(t, "hash-0", saved.TokenHash)
+		assert.Equal(t, []string{"prod", "test"}, saved.Namespaces)
+		assert.Nil(t, saved.ResourceTypes)
+		assert.Equal(t, []string{model.APITokenActionRead}, saved.Actions)
+		assert.Equal(t, expire.Unix(), saved.ExpireTime.Unix())
+		assert.True(t, saved.LastUsedTime.IsZero())
+
+		lastUsed := time.Now()
+		assert.NoError(t, s.UpdateAPITokenLastUsed("token-0", lastUsed))
+		saved, err = s.GetAPIToken("token-0")
+		assert.NoError(t, err)
+		assert.Equal(t, lastUsed.Unix(), saved.LastUsedTime.Unix())
+
+		total, tokens, err := s.GetAPITokens(map[string]string{
+			"principal_type": strconv.Itoa(int(model.PrincipalUser)),
+			"principal_id":   "user-1",
+		}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(2), total)
+		// 按照创建时间倒序返回
+		assert.Equal(t, "token-1", tokens[0].ID)
+		assert.Equal(t, "token-0", tokens[1].ID)
+
+		total, tokens, err = s.GetAPITokens(map[string]string{"owner": "owner-1"}, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(3), total)
+		assert.Equal(t, 1, len(tokens))
+		assert.Equal(t, "token-1", tokens[0].ID)
+
+		// 吊销之后不再返回
+		assert.NoError(t, s.DeleteAPIToken("token-1"))
+		saved, err = s.GetAPIToken("token-1")
+		assert.NoError(t, err)
+		assert.Nil(t, saved)
+		total, _, err = s.GetAPITokens(map[string]string{"owner": "owner-1"}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(2), total)
+	})
+}
@@ -0,0 +1,154 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblCARoot string = "ca_root"
+)
+
+// caRoot 内置 CA 根证书的存储结构
+type caRoot struct {
+	TrustDomain   string
+	CertPEM       string
+	KeyPEM        string
+	KeyCryptoAlgo string
+	PrevCertPEM   string
+	CreateTime    time.Time
+	ModifyTime    time.Time
+}
+
+func toCARootStore(root *model.CARoot) *caRoot {
+	return &caRoot{
+		TrustDomain:   root.TrustDomain,
+		CertPEM:       root.CertPEM,
+		KeyPEM:        root.KeyPEM,
+		KeyCryptoAlgo: root.KeyCryptoAlgo,
+		PrevCertPEM:   root.PrevCertPEM,
+		CreateTime:    root.CreateTime,
+		ModifyTime:    root.ModifyTime,
+	}
+}
+
+func toCARootModel(data *caRoot) *model.CARoot {
+	return &model.CARoot{
+		TrustDomain:   data.TrustDomain,
+		CertPEM:       data.CertPEM,
+		KeyPEM:        data.KeyPEM,
+		KeyCryptoAlgo: data.KeyCryptoAlgo,
+		PrevCertPEM:   data.PrevCertPEM,
+		CreateTime:    data.CreateTime,
+		ModifyTime:    data.ModifyTime,
+	}
+}
+
+type caRootStore struct {
+	handler BoltHandler
+}
+
+// CreateCARootIfAbsent 在同一个写事务中检查并保存根证书, 信任域下已经存在根证书时不覆盖
+func (cs *caRootStore) CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error) {
+	if root.TrustDomain == "" || root.CertPEM == "" || root.KeyPEM == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "create ca root missing some params")
+	}
+	var ret *model.CARoot
+	err := cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		exist, err := loadCARoot(tx, root.TrustDomain)
+		if err != nil {
+			return err
+		}
+		if exist != nil {
+			ret = toCARootModel(exist)
+			return nil
+		}
+		ret = root
+		return saveValue(tx, tblCARoot, root.TrustDomain, toCARootStore(root))
+	})
+	if err != nil {
+		log.Error("[Store][CA] create ca root", zap.String("trust-domain", root.TrustDomain), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+// GetCARoot 查询信任域当前生效的根证书
+func (cs *caRootStore) GetCARoot(trustDomain string) (*model.CARoot, error) {
+	var ret *model.CARoot
+	err := cs.handler.Execute(false, func(tx *bolt.Tx) error {
+		exist, err := loadCARoot(tx, trustDomain)
+		if err != nil || exist == nil {
+			return err
+		}
+		ret = toCARootModel(exist)
+		return nil
+	})
+	if err != nil {
+		log.Error("[Store][CA] get ca root", zap.String("trust-domain", trustDomain), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+// RotateCARoot 在同一个写事务中比较并替换根证书, 其他节点已经完成轮转时返回其他节点保存的根证书
+func (cs *caRootStore) RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error) {
+	if root.TrustDomain == "" || root.CertPEM == "" || root.KeyPEM == "" || prevCertPEM == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "rotate ca root missing some params")
+	}
+	var ret *model.CARoot
+	err := cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		exist, err := loadCARoot(tx, root.TrustDomain)
+		if err != nil {
+			return err
+		}
+		if exist == nil {
+			return store.NewStatusError(store.NotFoundResource, "ca root not found")
+		}
+		if exist.CertPEM != prevCertPEM {
+			ret = toCARootModel(exist)
+			return nil
+		}
+		data := toCARootStore(root)
+		data.PrevCertPEM = prevCertPEM
+		data.CreateTime = exist.CreateTime
+		ret = toCARootModel(data)
+		return saveValue(tx, tblCARoot, root.TrustDomain, data)
+	})
+	if err != nil {
+		log.Error("[Store][CA] rotate ca root", zap.String("trust-domain", root.TrustDomain), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	return ret, nil
+}
+
+func loadCARoot(tx *bolt.Tx, trustDomain string) (*caRoot, error) {
+	values := map[string]interface{}{}
+	if err := loadValues(tx, tblCARoot, []string{trustDomain}, &caRoot{}, values); err != nil {
+		return nil, err
+	}
+	data, _ := values[trustDomain].(*caRoot)
+	return data, nil
+}
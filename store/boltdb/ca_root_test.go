@@ -0,0 +1,94 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestCARootStore_CreateIfAbsent(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblCARoot, func(t *testing.T, handler BoltHandler) {
+		cs := &caRootStore{handler: handler}
+		now := time.Now()
+
+		first := &model.CARoot{TrustDomain: "cluster.local", CertPEM: "cert-1", KeyPEM: "key-1", CreateTime: now}
+		saved, err := cs.CreateCARootIfAbsent(first)
+		assert.NoError(t, err)
+		assert.Equal(t, "cert-1", saved.CertPEM)
+
+		// 已经存在根证书时不覆盖, 返回已经保存的根证书
+		saved, err = cs.CreateCARootIfAbsent(&model.CARoot{TrustDomain: "cluster.local", CertPEM: "cert-2",
+			KeyPEM: "key-2", CreateTime: now.Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, "cert-1", saved.CertPEM)
+		assert.Equal(t, "key-1", saved.KeyPEM)
+		assert.Equal(t, now.Unix(), saved.CreateTime.Unix())
+
+		saved, err = cs.CreateCARootIfAbsent(&model.CARoot{TrustDomain: "other.local", CertPEM: "cert-3",
+			KeyPEM: "key-3", CreateTime: now})
+		assert.NoError(t, err)
+		assert.Equal(t, "cert-3", saved.CertPEM)
+
+		_, err = cs.CreateCARootIfAbsent(&model.CARoot{TrustDomain: "cluster.local"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCARootStore_Rotate(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblCARoot, func(t *testing.T, handler BoltHandler) {
+		cs := &caRootStore{handler: handler}
+		now := time.Now()
+
+		root, err := cs.GetCARoot("cluster.local")
+		assert.NoError(t, err)
+		assert.Nil(t, root)
+
+		_, err = cs.RotateCARoot(&model.CARoot{TrustDomain: "cluster.local", CertPEM: "cert-2", KeyPEM: "key-2",
+			KeyCryptoAlgo: "AES"}, "cert-1")
+		assert.Error(t, err)
+
+		_, err = cs.CreateCARootIfAbsent(&model.CARoot{TrustDomain: "cluster.local", CertPEM: "cert-1",
+			KeyPEM: "key-1", KeyCryptoAlgo: "AES", CreateTime: now, ModifyTime: now})
+		assert.NoError(t, err)
+
+		rotated, err := cs.RotateCARoot(&model.CARoot{TrustDomain: "cluster.local", CertPEM: "cert-2",
+			KeyPEM: "key-2", KeyCryptoAlgo: "AES", ModifyTime: now.Add(time.Hour)}, "cert-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "cert-2", rotated.CertPEM)
+		assert.Equal(t, "cert-1", rotated.PrevCertPEM)
+
+		// 其他节点基于旧的根证书轮转时不生效, 返回已经轮转后的根证书
+		rotated, err = cs.RotateCARoot(&model.CARoot{TrustDomain: "cluster.local", CertPEM: "cert-3",
+			KeyPEM: "key-3", KeyCryptoAlgo: "AES"}, "cert-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "cert-2", rotated.CertPEM)
+
+		root, err = cs.GetCARoot("cluster.local")
+		assert.NoError(t, err)
+		assert.Equal(t, "cert-2", root.CertPEM)
+		assert.Equal(t, "key-2", root.KeyPEM)
+		assert.Equal(t, "AES", root.KeyCryptoAlgo)
+		assert.Equal(t, "cert-1", root.PrevCertPEM)
+		assert.Equal(t, now.Unix(), root.CreateTime.Unix())
+	})
+}
@@ -66,7 +66,7 @@ func (cs *clientStore) BatchAddClients(clients []*model.Client) error {
 	if err := cs.handler.Execute(true, func(tx *bolt.Tx) error {
 		for i := range clients {
 			client := clients[i]
-			saveVal, err := convertToClientObject(client)
+			saveVal, err := convertToClientObject(client, nowOf(cs.handler))
 			if err != nil {
 				return err
 			}
@@ -90,7 +90,7 @@ func (cs *clientStore) BatchDeleteClients(ids []string) error {
 		for i := range ids {
 			properties := make(map[string]interface{})
 			properties[ClientFieldValid] = false
-			properties[ClientFieldMtime] = time.Now()
+			properties[ClientFieldMtime] = nowOf(cs.handler)
 
 			if err := updateValue(tx, tblClient, ids[i], properties); err != nil {
 				log.Error("[Client] batch delete clients", zap.Error(err))
@@ -138,13 +138,12 @@ func (cs *clientStore) GetMoreClients(mtime time.Time, firstUpdate bool) (map[st
 	return clients, nil
 }
 
-func convertToClientObject(client *model.Client) (*clientObject, error) {
+func convertToClientObject(client *model.Client, tn time.Time) (*clientObject, error) {
 	stat := client.Proto().Stat
 	data, err := json.Marshal(stat)
 	if err != nil {
 		return nil, err
 	}
-	tn := time.Now()
 	return &clientObject{
 		Host:    client.Proto().GetHost().GetValue(),
 		Type:    client.Proto().GetType().String(),
@@ -0,0 +1,38 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import "time"
+
+// Clock provides the time written into create/modify time fields. A BoltHandler driven by
+// a replication layer (e.g. raft) implements it to return the time carried by the log entry
+// being applied, so every replica and every log replay writes exactly the same timestamps
+type Clock interface {
+	// Now return the current time of the write being applied
+	Now() time.Time
+}
+
+// nowOf return the write time of handler, fallback to the local time when handler is not a Clock
+func nowOf(handler BoltHandler) time.Time {
+	if clock, ok := handler.(Clock); ok {
+		if now := clock.Now(); !now.IsZero() {
+			return now
+		}
+	}
+	return time.Now()
+}
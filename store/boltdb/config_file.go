@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"sort"
 	"strings"
-	"time"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/zap"
@@ -87,7 +86,7 @@ func (cf *configFileStore) CreateConfigFileTx(proxyTx store.Tx, file *model.Conf
 
 	file.Id = nextId
 	file.Valid = true
-	file.CreateTime = time.Now()
+	file.CreateTime = nowOf(cf.handler)
 	file.ModifyTime = file.CreateTime
 
 	key := fmt.Sprintf("%s@%s@%s", file.Namespace, file.Group, file.Name)
@@ -198,7 +197,7 @@ func (cf *configFileStore) UpdateConfigFileTx(tx store.Tx, file *model.ConfigFil
 	properties[FileFieldMetadata] = file.Metadata
 	properties[FileFieldEncrypt] = file.Encrypt
 	properties[FileFieldEncryptAlgo] = file.EncryptAlgo
-	properties[FileFieldModifyTime] = time.Now()
+	properties[FileFieldModifyTime] = nowOf(cf.handler)
 	properties[FileFieldModifyBy] = file.ModifyBy
 	if err := updateValue(dbTx, tblConfigFile, key, properties); err != nil {
 		return err
@@ -213,7 +212,7 @@ func (cf *configFileStore) DeleteConfigFileTx(proxyTx store.Tx, namespace, group
 
 		properties := make(map[string]interface{})
 		properties[FileFieldValid] = false
-		properties[FileFieldModifyTime] = time.Now()
+		properties[FileFieldModifyTime] = nowOf(cf.handler)
 
 		err := updateValue(tx, tblConfigFile, key, properties)
 		return nil, err
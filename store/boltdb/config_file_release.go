@@ -94,7 +94,7 @@ func (cfr *configFileReleaseStore) CreateConfigFileReleaseTx(proxyTx store.Tx,
 	}
 	fileRelease.Id = nextId
 	fileRelease.Valid = true
-	tN := time.Now()
+	tN := nowOf(cfr.handler)
 	fileRelease.CreateTime = tN
 	fileRelease.ModifyTime = tN
 
@@ -240,7 +240,7 @@ func (cfr *configFileReleaseStore) DeleteConfigFileReleaseTx(tx store.Tx, data *
 
 	properties[FileReleaseFieldValid] = false
 	properties[FileReleaseFieldFlag] = 1
-	properties[FileReleaseFieldModifyTime] = time.Now()
+	properties[FileReleaseFieldModifyTime] = nowOf(cfr.handler)
 	if err := updateValue(dbTx, tblConfigFileRelease, data.ReleaseKey(), properties); err != nil {
 		log.Error("[ConfigFileRelease] delete info", zap.Error(err))
 		return store.Error(err)
@@ -300,7 +300,7 @@ func (cfr *configFileReleaseStore) CleanConfigFileReleasesTx(tx store.Tx, namesp
 	properties := map[string]interface{}{
 		FileReleaseFieldFlag:       1,
 		FileReleaseFieldValid:      false,
-		FileReleaseFieldModifyTime: time.Now(),
+		FileReleaseFieldModifyTime: nowOf(cfr.handler),
 	}
 	for key := range values {
 		if err := updateValue(dbTx, tblConfigFileRelease, key, properties); err != nil {
@@ -349,7 +349,7 @@ func (cfr *configFileReleaseStore) ActiveConfigFileReleaseTx(tx store.Tx, releas
 	properties := make(map[string]interface{})
 	properties[FileReleaseFieldVersion] = maxVersion + 1
 	properties[FileReleaseFieldActive] = true
-	properties[FileReleaseFieldModifyTime] = time.Now()
+	properties[FileReleaseFieldModifyTime] = nowOf(cfr.handler)
 	return updateValue(dbTx, tblConfigFileRelease, release.ReleaseKey(), properties)
 }
 
@@ -357,7 +357,7 @@ func (cfr *configFileReleaseStore) InactiveConfigFileReleaseTx(tx store.Tx, rele
 	dbTx := tx.GetDelegateTx().(*bolt.Tx)
 	properties := make(map[string]interface{})
 	properties[FileReleaseFieldActive] = false
-	properties[FileReleaseFieldModifyTime] = time.Now()
+	properties[FileReleaseFieldModifyTime] = nowOf(cfr.handler)
 	return updateValue(dbTx, tblConfigFileRelease, release.ReleaseKey(), properties)
 }
 
@@ -402,7 +402,7 @@ func (cfr *configFileReleaseStore) inactiveConfigFileRelease(tx *bolt.Tx,
 	}
 	properties := map[string]interface{}{
 		FileReleaseFieldActive:     false,
-		FileReleaseFieldModifyTime: time.Now(),
+		FileReleaseFieldModifyTime: nowOf(cfr.handler),
 	}
 	for key := range values {
 		if err := updateValue(tx, tblConfigFileRelease, key, properties); err != nil {
@@ -78,7 +78,7 @@ func (rh *configFileReleaseHistoryStore) CreateConfigFileReleaseHistory(
 		history.Id = nextId
 		key := strconv.FormatUint(history.Id, 10)
 		history.Valid = true
-		history.CreateTime = time.Now()
+		history.CreateTime = nowOf(rh.handler)
 		history.ModifyTime = history.CreateTime
 
 		if err := saveValue(tx, tblConfigFileReleaseHistory, key, history); err != nil {
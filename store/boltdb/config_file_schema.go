@@ -0,0 +1,127 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package boltdb
+
+import (
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblConfigFileSchema string = "ConfigFileSchema"
+
+	FileSchemaFieldNamespace string = "Namespace"
+	FileSchemaFieldGroup     string = "Group"
+)
+
+type configFileSchemaStore struct {
+	handler BoltHandler
+}
+
+func newConfigFileSchemaStore(handler BoltHandler) *configFileSchemaStore {
+	return &configFileSchemaStore{handler: handler}
+}
+
+// UpsertConfigFileSchema 创建或者更新配置校验规则
+func (cs *configFileSchemaStore) UpsertConfigFileSchema(schema *model.ConfigFileSchema) error {
+	err := cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		key := configFileSchemaKey(schema.Namespace, schema.Group, schema.FileName)
+		values := map[string]interface{}{}
+		if err := loadValues(tx, tblConfigFileSchema, []string{key}, &model.ConfigFileSchema{}, values); err != nil {
+			return err
+		}
+
+		tN := nowOf(cs.handler)
+		if saved, ok := values[key].(*model.ConfigFileSchema); ok {
+			schema.Id = saved.Id
+			schema.CreateTime = saved.CreateTime
+			schema.CreateBy = saved.CreateBy
+		} else {
+			table, err := tx.CreateBucketIfNotExists([]byte(tblConfigFileSchema))
+			if err != nil {
+				return err
+			}
+			nextId, err := table.NextSequence()
+			if err != nil {
+				return err
+			}
+			schema.Id = nextId
+			schema.CreateTime = tN
+		}
+		schema.ModifyTime = tN
+		if err := saveValue(tx, tblConfigFileSchema, key, schema); err != nil {
+			log.Error("[ConfigFileSchema] save error", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// DeleteConfigFileSchema 删除配置校验规则
+func (cs *configFileSchemaStore) DeleteConfigFileSchema(namespace, group, fileName string) error {
+	err := cs.handler.DeleteValues(tblConfigFileSchema, []string{configFileSchemaKey(namespace, group, fileName)})
+	return store.Error(err)
+}
+
+// GetConfigFileSchema 获取配置校验规则，fileName 为空时获取分组级别的规则
+func (cs *configFileSchemaStore) GetConfigFileSchema(namespace, group,
+	fileName string) (*model.ConfigFileSchema, error) {
+	key := configFileSchemaKey(namespace, group, fileName)
+	values, err := cs.handler.LoadValues(tblConfigFileSchema, []string{key}, &model.ConfigFileSchema{})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	ret, ok := values[key].(*model.ConfigFileSchema)
+	if !ok {
+		return nil, nil
+	}
+	return ret, nil
+}
+
+// QueryConfigFileSchemas 查询配置分组下的所有校验规则，分组级别的规则排在最前面
+func (cs *configFileSchemaStore) QueryConfigFileSchemas(namespace,
+	group string) ([]*model.ConfigFileSchema, error) {
+	fields := []string{FileSchemaFieldNamespace, FileSchemaFieldGroup}
+	values, err := cs.handler.LoadValuesByFilter(tblConfigFileSchema, fields, &model.ConfigFileSchema{},
+		func(m map[string]interface{}) bool {
+			saveNs, _ := m[FileSchemaFieldNamespace].(string)
+			saveGroup, _ := m[FileSchemaFieldGroup].(string)
+			return saveNs == namespace && saveGroup == group
+		})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	ret := make([]*model.ConfigFileSchema, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, v.(*model.ConfigFileSchema))
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].FileName < ret[j].FileName
+	})
+	return ret, nil
+}
+
+func configFileSchemaKey(namespace, group, fileName string) string {
+	return fmt.Sprintf("%s@@%s@@%s", namespace, group, fileName)
+}
@@ -0,0 +1,80 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func Test_configFileSchemaStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblConfigFileSchema, func(t *testing.T, handler BoltHandler) {
+		s := newConfigFileSchemaStore(handler)
+		groupSchema := &model.ConfigFileSchema{
+			Namespace: "default",
+			Group:     "group",
+			Type:      model.ConfigFileSchemaTypeJSON,
+			Schema:    `{"type":"object"}`,
+			CreateBy:  "polaris",
+		}
+		assert.NoError(t, s.UpsertConfigFileSchema(groupSchema))
+		fileSchema := &model.ConfigFileSchema{
+			Namespace: "default",
+			Group:     "group",
+			FileName:  "app.json",
+			Type:      model.ConfigFileSchemaTypeJSON,
+			Schema:    `{"type":"object"}`,
+		}
+		assert.NoError(t, s.UpsertConfigFileSchema(fileSchema))
+		assert.NoError(t, s.UpsertConfigFileSchema(&model.ConfigFileSchema{
+			Namespace: "default",
+			Group:     "other",
+			Type:      model.ConfigFileSchemaTypeJSON,
+			Schema:    `{"type":"object"}`,
+		}))
+
+		// 更新时保留创建信息
+		update := &model.ConfigFileSchema{
+			Namespace: "default",
+			Group:     "group",
+			Type:      model.ConfigFileSchemaTypeJSON,
+			Schema:    `{"type":"array"}`,
+			CreateBy:  "guest",
+		}
+		assert.NoError(t, s.UpsertConfigFileSchema(update))
+		saved, err := s.GetConfigFileSchema("default", "group", "")
+		assert.NoError(t, err)
+		assert.Equal(t, groupSchema.Id, saved.Id)
+		assert.Equal(t, "polaris", saved.CreateBy)
+		assert.Equal(t, `{"type":"array"}`, saved.Schema)
+
+		schemas, err := s.QueryConfigFileSchemas("default", "group")
+		assert.NoError(t, err)
+		assert.Len(t, schemas, 2)
+		assert.Equal(t, "", schemas[0].FileName)
+		assert.Equal(t, "app.json", schemas[1].FileName)
+
+		assert.NoError(t, s.DeleteConfigFileSchema("default", "group", "app.json"))
+		saved, err = s.GetConfigFileSchema("default", "group", "app.json")
+		assert.NoError(t, err)
+		assert.Nil(t, saved)
+	})
+}
@@ -0,0 +1,218 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblConfigGrayRollout string = "ConfigGrayRollout"
+
+	GrayRolloutFieldStatus string = "Status"
+)
+
+// configGrayRollout 按比例灰度的存储结构，灰度阶段以及异常客户端以 JSON 的方式保存
+type configGrayRollout struct {
+	Namespace          string
+	Group              string
+	FileName           string
+	ReleaseName        string
+	ReleaseDescription string
+	Comment            string
+	Steps              string
+	CurrentStep        int
+	StepTime           time.Time
+	ErrorThreshold     int
+	ErrorClients       string
+	Status             string
+	Message            string
+	Revision           uint64
+	CreateTime         time.Time
+	CreateBy           string
+	ModifyTime         time.Time
+	ModifyBy           string
+}
+
+type configGrayRolloutStore struct {
+	handler BoltHandler
+}
+
+func newConfigGrayRolloutStore(handler BoltHandler) *configGrayRolloutStore {
+	return &configGrayRolloutStore{handler: handler}
+}
+
+// SaveConfigGrayRollout 创建或者覆盖配置文件的按比例灰度
+func (cs *configGrayRolloutStore) SaveConfigGrayRollout(rollout *model.ConfigGrayRollout) error {
+	tN := nowOf(cs.handler)
+	rollout.CreateTime = tN
+	rollout.ModifyTime = tN
+	data, err := toConfigGrayRolloutStore(rollout)
+	if err != nil {
+		return store.Error(err)
+	}
+	err = cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		if err := saveValue(tx, tblConfigGrayRollout, rollout.Key(), data); err != nil {
+			log.Error("[ConfigGrayRollout] save error", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// UpdateConfigGrayRollout 更新按比例灰度，存储中的 revision 与 expectRevision 不一致时返回冲突错误
+func (cs *configGrayRolloutStore) UpdateConfigGrayRollout(rollout *model.ConfigGrayRollout,
+	expectRevision uint64) error {
+	key := rollout.Key()
+	err := cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		values := map[string]interface{}{}
+		if err := loadValues(tx, tblConfigGrayRollout, []string{key}, &configGrayRollout{}, values); err != nil {
+			return err
+		}
+		saved, ok := values[key].(*configGrayRollout)
+		if !ok {
+			return store.NewStatusError(store.DataConflictErr,
+				fmt.Sprintf("config gray rollout %s not found", key))
+		}
+		if saved.Revision != expectRevision {
+			return store.NewStatusError(store.DataConflictErr,
+				fmt.Sprintf("config gray rollout %s revision %d not match %d", key, saved.Revision,
+					expectRevision))
+		}
+		rollout.CreateTime = saved.CreateTime
+		rollout.ModifyTime = nowOf(cs.handler)
+		data, err := toConfigGrayRolloutStore(rollout)
+		if err != nil {
+			return err
+		}
+		if err := saveValue(tx, tblConfigGrayRollout, key, data); err != nil {
+			log.Error("[ConfigGrayRollout] update error", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// GetConfigGrayRollout 获取配置文件的按比例灰度
+func (cs *configGrayRolloutStore) GetConfigGrayRollout(namespace, group,
+	fileName string) (*model.ConfigGrayRollout, error) {
+	key := (&model.ConfigGrayRollout{Namespace: namespace, Group: group, FileName: fileName}).Key()
+	values, err := cs.handler.LoadValues(tblConfigGrayRollout, []string{key}, &configGrayRollout{})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	data, ok := values[key].(*configGrayRollout)
+	if !ok {
+		return nil, nil
+	}
+	return toConfigGrayRolloutModel(data)
+}
+
+// GetConfigGrayRolloutsByStatus 获取处于某个状态的所有按比例灰度
+func (cs *configGrayRolloutStore) GetConfigGrayRolloutsByStatus(status string) ([]*model.ConfigGrayRollout, error) {
+	values, err := cs.handler.LoadValuesByFilter(tblConfigGrayRollout, []string{GrayRolloutFieldStatus},
+		&configGrayRollout{}, func(m map[string]interface{}) bool {
+			saveStatus, _ := m[GrayRolloutFieldStatus].(string)
+			return saveStatus == status
+		})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	ret := make([]*model.ConfigGrayRollout, 0, len(values))
+	for k := range values {
+		item, err := toConfigGrayRolloutModel(values[k].(*configGrayRollout))
+		if err != nil {
+			return nil, store.Error(err)
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+func toConfigGrayRolloutStore(rollout *model.ConfigGrayRollout) (*configGrayRollout, error) {
+	steps, err := json.Marshal(rollout.Steps)
+	if err != nil {
+		return nil, err
+	}
+	errorClients, err := json.Marshal(rollout.ErrorClients)
+	if err != nil {
+		return nil, err
+	}
+	return &configGrayRollout{
+		Namespace:          rollout.Namespace,
+		Group:              rollout.Group,
+		FileName:           rollout.FileName,
+		ReleaseName:        rollout.ReleaseName,
+		ReleaseDescription: rollout.ReleaseDescription,
+		Comment:            rollout.Comment,
+		Steps:              string(steps),
+		CurrentStep:        rollout.CurrentStep,
+		StepTime:           rollout.StepTime,
+		ErrorThreshold:     rollout.ErrorThreshold,
+		ErrorClients:       string(errorClients),
+		Status:             rollout.Status,
+		Message:            rollout.Message,
+		Revision:           rollout.Revision,
+		CreateTime:         rollout.CreateTime,
+		CreateBy:           rollout.CreateBy,
+		ModifyTime:         rollout.ModifyTime,
+		ModifyBy:           rollout.ModifyBy,
+	}, nil
+}
+
+func toConfigGrayRolloutModel(data *configGrayRollout) (*model.ConfigGrayRollout, error) {
+	rollout := &model.ConfigGrayRollout{
+		Namespace:          data.Namespace,
+		Group:              data.Group,
+		FileName:           data.FileName,
+		ReleaseName:        data.ReleaseName,
+		ReleaseDescription: data.ReleaseDescription,
+		Comment:            data.Comment,
+		CurrentStep:        data.CurrentStep,
+		StepTime:           data.StepTime,
+		ErrorThreshold:     data.ErrorThreshold,
+		Status:             data.Status,
+		Message:            data.Message,
+		Revision:           data.Revision,
+		CreateTime:         data.CreateTime,
+		CreateBy:           data.CreateBy,
+		ModifyTime:         data.ModifyTime,
+		ModifyBy:           data.ModifyBy,
+	}
+	if data.Steps != "" {
+		if err := json.Unmarshal([]byte(data.Steps), &rollout.Steps); err != nil {
+			return nil, err
+		}
+	}
+	if data.ErrorClients != "" {
+		if err := json.Unmarshal([]byte(data.ErrorClients), &rollout.ErrorClients); err != nil {
+			return nil, err
+		}
+	}
+	return rollout, nil
+}
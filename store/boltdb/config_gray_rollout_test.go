@@ -0,0 +1,86 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+func TestConfigGrayRolloutStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblConfigGrayRollout, func(t *testing.T, handler BoltHandler) {
+		s := newConfigGrayRolloutStore(handler)
+
+		rollout := &model.ConfigGrayRollout{
+			Namespace:   "default",
+			Group:       "group",
+			FileName:    "file",
+			ReleaseName: "release-1",
+			Steps: []*model.ConfigGrayRolloutStep{
+				{Percentage: 10, Duration: 60},
+				{Percentage: 100, Duration: 60},
+			},
+			StepTime:       time.Now(),
+			ErrorThreshold: 2,
+			ErrorClients:   []string{},
+			Status:         model.GrayRolloutRunning,
+			Revision:       1,
+			CreateBy:       "polaris",
+		}
+		assert.NoError(t, s.SaveConfigGrayRollout(rollout))
+
+		saved, err := s.GetConfigGrayRollout("default", "group", "file")
+		assert.NoError(t, err)
+		assert.Equal(t, "release-1", saved.ReleaseName)
+		assert.Equal(t, 2, len(saved.Steps))
+		assert.Equal(t, uint32(10), saved.Step().Percentage)
+
+		saved.CurrentStep = 1
+		saved.AddErrorClient("client-1")
+		saved.Revision = 2
+		assert.NoError(t, s.UpdateConfigGrayRollout(saved, 1))
+
+		// 版本号不一致时更新失败
+		err = s.UpdateConfigGrayRollout(saved, 1)
+		assert.Equal(t, store.DataConflictErr, store.Code(err))
+
+		running, err := s.GetConfigGrayRolloutsByStatus(model.GrayRolloutRunning)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(running))
+		assert.Equal(t, 1, running[0].CurrentStep)
+		assert.Equal(t, []string{"client-1"}, running[0].ErrorClients)
+
+		// 重新创建灰度时覆盖之前的记录
+		rollout.ReleaseName = "release-2"
+		rollout.Status = model.GrayRolloutAborted
+		rollout.Revision = 3
+		assert.NoError(t, s.SaveConfigGrayRollout(rollout))
+		running, err = s.GetConfigGrayRolloutsByStatus(model.GrayRolloutRunning)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(running))
+
+		saved, err = s.GetConfigGrayRollout("default", "group", "not-exist")
+		assert.NoError(t, err)
+		assert.Nil(t, saved)
+	})
+}
@@ -0,0 +1,276 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package boltdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblConfigReleaseRequest string = "ConfigReleaseRequest"
+
+	ReleaseRequestFieldNamespace  string = "Namespace"
+	ReleaseRequestFieldGroup      string = "Group"
+	ReleaseRequestFieldFileName   string = "FileName"
+	ReleaseRequestFieldStatus     string = "Status"
+	ReleaseRequestFieldCreateBy   string = "CreateBy"
+	ReleaseRequestFieldCreateTime string = "CreateTime"
+)
+
+// configReleaseRequest 发布申请的存储结构，审批人以及审批记录以 JSON 的方式保存
+type configReleaseRequest struct {
+	Id                 string
+	Namespace          string
+	Group              string
+	FileName           string
+	ReleaseName        string
+	ReleaseDescription string
+	Comment            string
+	Md5                string
+	ScheduleTime       time.Time
+	RequiredApprovals  int
+	Approvers          string
+	Approvals          string
+	Status             string
+	Message            string
+	ReleaseTime        time.Time
+	Revision           uint64
+	CreatorId          string
+	CreateTime         time.Time
+	CreateBy           string
+	ModifyTime         time.Time
+	ModifyBy           string
+}
+
+type configReleaseRequestStore struct {
+	handler BoltHandler
+}
+
+func newConfigReleaseRequestStore(handler BoltHandler) *configReleaseRequestStore {
+	return &configReleaseRequestStore{handler: handler}
+}
+
+// CreateConfigReleaseRequest 创建发布申请
+func (cs *configReleaseRequestStore) CreateConfigReleaseRequest(req *model.ConfigReleaseRequest) error {
+	tN := nowOf(cs.handler)
+	req.CreateTime = tN
+	req.ModifyTime = tN
+	data, err := toConfigReleaseRequestStore(req)
+	if err != nil {
+		return store.Error(err)
+	}
+	err = cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		values := map[string]interface{}{}
+		if err := loadValues(tx, tblConfigReleaseRequest, []string{req.Id}, &configReleaseRequest{},
+			values); err != nil {
+			return err
+		}
+		if _, ok := values[req.Id]; ok {
+			return store.NewStatusError(store.DuplicateEntryErr,
+				fmt.Sprintf("config release request %s already exist", req.Id))
+		}
+		if err := saveValue(tx, tblConfigReleaseRequest, req.Id, data); err != nil {
+			log.Error("[ConfigReleaseRequest] save error", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// UpdateConfigReleaseRequest 更新发布申请，存储中的 revision 与 expectRevision 不一致时返回冲突错误
+func (cs *configReleaseRequestStore) UpdateConfigReleaseRequest(req *model.ConfigReleaseRequest,
+	expectRevision uint64) error {
+	err := cs.handler.Execute(true, func(tx *bolt.Tx) error {
+		values := map[string]interface{}{}
+		if err := loadValues(tx, tblConfigReleaseRequest, []string{req.Id}, &configReleaseRequest{},
+			values); err != nil {
+			return err
+		}
+		saved, ok := values[req.Id].(*configReleaseRequest)
+		if !ok {
+			return store.NewStatusError(store.DataConflictErr,
+				fmt.Sprintf("config release request %s not found", req.Id))
+		}
+		if saved.Revision != expectRevision {
+			return store.NewStatusError(store.DataConflictErr,
+				fmt.Sprintf("config release request %s revision %d not match %d", req.Id, saved.Revision,
+					expectRevision))
+		}
+		req.CreateTime = saved.CreateTime
+		req.ModifyTime = nowOf(cs.handler)
+		data, err := toConfigReleaseRequestStore(req)
+		if err != nil {
+			return err
+		}
+		if err := saveValue(tx, tblConfigReleaseRequest, req.Id, data); err != nil {
+			log.Error("[ConfigReleaseRequest] update error", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// GetConfigReleaseRequest 获取发布申请
+func (cs *configReleaseRequestStore) GetConfigReleaseRequest(id string) (*model.ConfigReleaseRequest, error) {
+	values, err := cs.handler.LoadValues(tblConfigReleaseRequest, []string{id}, &configReleaseRequest{})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	data, ok := values[id].(*configReleaseRequest)
+	if !ok {
+		return nil, nil
+	}
+	return toConfigReleaseRequestModel(data)
+}
+
+// QueryConfigReleaseRequests 查询发布申请，按照创建时间倒序返回
+func (cs *configReleaseRequestStore) QueryConfigReleaseRequests(filter map[string]string,
+	offset, limit uint32) (uint32, []*model.ConfigReleaseRequest, error) {
+	exactFields := map[string]string{
+		ReleaseRequestFieldNamespace: filter["namespace"],
+		ReleaseRequestFieldGroup:     filter["group"],
+		ReleaseRequestFieldFileName:  filter["file_name"],
+		ReleaseRequestFieldStatus:    filter["status"],
+		ReleaseRequestFieldCreateBy:  filter["create_by"],
+	}
+	fields := []string{ReleaseRequestFieldNamespace, ReleaseRequestFieldGroup, ReleaseRequestFieldFileName,
+		ReleaseRequestFieldStatus, ReleaseRequestFieldCreateBy}
+	values, err := cs.handler.LoadValuesByFilter(tblConfigReleaseRequest, fields, &configReleaseRequest{},
+		func(m map[string]interface{}) bool {
+			for field, expect := range exactFields {
+				if expect == "" {
+					continue
+				}
+				if save, _ := m[field].(string); save != expect {
+					return false
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+
+	datas := make([]*configReleaseRequest, 0, len(values))
+	for k := range values {
+		datas = append(datas, values[k].(*configReleaseRequest))
+	}
+	sort.Slice(datas, func(i, j int) bool {
+		if !datas[i].CreateTime.Equal(datas[j].CreateTime) {
+			return datas[i].CreateTime.After(datas[j].CreateTime)
+		}
+		return datas[i].Id > datas[j].Id
+	})
+
+	total := uint32(len(datas))
+	if offset >= total {
+		return total, []*model.ConfigReleaseRequest{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	ret := make([]*model.ConfigReleaseRequest, 0, end-offset)
+	for _, data := range datas[offset:end] {
+		item, err := toConfigReleaseRequestModel(data)
+		if err != nil {
+			return 0, nil, store.Error(err)
+		}
+		ret = append(ret, item)
+	}
+	return total, ret, nil
+}
+
+func toConfigReleaseRequestStore(req *model.ConfigReleaseRequest) (*configReleaseRequest, error) {
+	approvers, err := json.Marshal(req.Approvers)
+	if err != nil {
+		return nil, err
+	}
+	approvals, err := json.Marshal(req.Approvals)
+	if err != nil {
+		return nil, err
+	}
+	return &configReleaseRequest{
+		Id:                 req.Id,
+		Namespace:          req.Namespace,
+		Group:              req.Group,
+		FileName:           req.FileName,
+		ReleaseName:        req.ReleaseName,
+		ReleaseDescription: req.ReleaseDescription,
+		Comment:            req.Comment,
+		Md5:                req.Md5,
+		ScheduleTime:       req.ScheduleTime,
+		RequiredApprovals:  req.RequiredApprovals,
+		Approvers:          string(approvers),
+		Approvals:          string(approvals),
+		Status:             req.Status,
+		Message:            req.Message,
+		ReleaseTime:        req.ReleaseTime,
+		Revision:           req.Revision,
+		CreatorId:          req.CreatorId,
+		CreateTime:         req.CreateTime,
+		CreateBy:           req.CreateBy,
+		ModifyTime:         req.ModifyTime,
+		ModifyBy:           req.ModifyBy,
+	}, nil
+}
+
+func toConfigReleaseRequestModel(data *configReleaseRequest) (*model.ConfigReleaseRequest, error) {
+	req := &model.ConfigReleaseRequest{
+		Id:                 data.Id,
+		Namespace:          data.Namespace,
+		Group:              data.Group,
+		FileName:           data.FileName,
+		ReleaseName:        data.ReleaseName,
+		ReleaseDescription: data.ReleaseDescription,
+		Comment:            data.Comment,
+		Md5:                data.Md5,
+		ScheduleTime:       data.ScheduleTime,
+		RequiredApprovals:  data.RequiredApprovals,
+		Status:             data.Status,
+		Message:            data.Message,
+		ReleaseTime:        data.ReleaseTime,
+		Revision:           data.Revision,
+		CreatorId:          data.CreatorId,
+		CreateTime:         data.CreateTime,
+		CreateBy:           data.CreateBy,
+		ModifyTime:         data.ModifyTime,
+		ModifyBy:           data.ModifyBy,
+	}
+	if data.Approvers != "" {
+		if err := json.Unmarshal([]byte(data.Approvers), &req.Approvers); err != nil {
+			return nil, err
+		}
+	}
+	if data.Approvals != "" {
+		if err := json.Unmarshal([]byte(data.Approvals), &req.Approvals); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,100 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+func mockConfigReleaseRequest(total int) []*model.ConfigReleaseRequest {
+	ret := make([]*model.ConfigReleaseRequest, 0, total)
+	now := time.Now()
+	for i := 0; i < total; i++ {
+		ret = append(ret, &model.ConfigReleaseRequest{
+			Id:                fmt.Sprintf("request-%d", i),
+			Namespace:         "default",
+			Group:             "group",
+			FileName:          fmt.Sprintf("file-%d", i%2),
+			ReleaseName:       fmt.Sprintf("release-%d", i),
+			RequiredApprovals: 1,
+			Approvers: model.ConfigReleaseApprovers{
+				Users: []string{"user-1"},
+			},
+			Status:     model.ReleaseRequestPending,
+			Revision:   1,
+			CreateTime: now.Add(time.Duration(i) * time.Second),
+			CreateBy:   "polaris",
+		})
+	}
+	return ret
+}
+
+func TestConfigReleaseRequestStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblConfigReleaseRequest, func(t *testing.T, handler BoltHandler) {
+		s := newConfigReleaseRequestStore(handler)
+
+		mocks := mockConfigReleaseRequest(4)
+		for i := range mocks {
+			assert.NoError(t, s.CreateConfigReleaseRequest(mocks[i]))
+		}
+		err := s.CreateConfigReleaseRequest(mocks[0])
+		assert.Equal(t, store.DuplicateEntryErr, store.Code(err))
+
+		// 按照创建时间倒序返回
+		total, ret, err := s.QueryConfigReleaseRequests(map[string]string{"file_name": "file-0"}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(2), total)
+		assert.Equal(t, "request-2", ret[0].Id)
+		assert.Equal(t, "request-0", ret[1].Id)
+		assert.Equal(t, []string{"user-1"}, ret[0].Approvers.Users)
+
+		req, err := s.GetConfigReleaseRequest("request-1")
+		assert.NoError(t, err)
+		req.Status = model.ReleaseRequestApproved
+		req.Approvals = []*model.ConfigReleaseApproval{{UserId: "user-1", Approved: true}}
+		req.Revision = 2
+		assert.NoError(t, s.UpdateConfigReleaseRequest(req, 1))
+
+		// 版本号不一致时更新失败
+		err = s.UpdateConfigReleaseRequest(req, 1)
+		assert.Equal(t, store.DataConflictErr, store.Code(err))
+
+		req, err = s.GetConfigReleaseRequest("request-1")
+		assert.NoError(t, err)
+		assert.Equal(t, model.ReleaseRequestApproved, req.Status)
+		assert.Equal(t, 1, req.ApprovedCount())
+		assert.Equal(t, uint64(2), req.Revision)
+
+		total, _, err = s.QueryConfigReleaseRequests(map[string]string{
+			"status": model.ReleaseRequestApproved,
+		}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(1), total)
+
+		req, err = s.GetConfigReleaseRequest("not-exist")
+		assert.NoError(t, err)
+		assert.Nil(t, req)
+	})
+}
@@ -18,8 +18,10 @@
 package boltdb
 
 import (
+	"encoding/hex"
 	"time"
 
+	"github.com/google/uuid"
 	apisecurity "github.com/polarismesh/specification/source/go/api/v1/security"
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/zap"
@@ -80,19 +82,30 @@ type boltStore struct {
 	*configFileReleaseStore
 	*configFileReleaseHistoryStore
 	*configFileTemplateStore
+	*configFileSchemaStore
+	*configReleaseRequestStore
+	*configGrayRolloutStore
 
 	// adminStore store
 	*adminStore
+	*operationRecordStore
+	*caRootStore
+	*healthCheckProtectionStore
 	// 工具
 	*toolStore
 	// 鉴权模块相关
 	*userStore
 	*groupStore
 	*strategyStore
+	*apiTokenStore
 	*grayStore
 
 	handler BoltHandler
 	start   bool
+	// tokenSeed 非空时默认数据的 token 由该种子推导，保证多副本之间数据一致
+	tokenSeed string
+	// initTime 非零时默认数据的创建以及修改时间固定为该值，保证多副本之间数据一致
+	initTime time.Time
 }
 
 // Name store name
@@ -235,10 +248,10 @@ var (
 
 func (m *boltStore) initNamingStoreData() error {
 	for _, namespace := range namespacesToInit {
-		curTime := time.Now()
+		curTime := nowOf(m.handler)
 		err := m.AddNamespace(&model.Namespace{
 			Name:       namespace,
-			Token:      utils.NewUUID(),
+			Token:      m.newToken(namespace),
 			Owner:      ownerToInit,
 			Valid:      true,
 			CreateTime: curTime,
@@ -247,16 +260,19 @@ func (m *boltStore) initNamingStoreData() error {
 		if err != nil {
 			return err
 		}
+		if err := m.pinInitTime(tblNameNamespace, namespace); err != nil {
+			return err
+		}
 	}
 	for svc, id := range servicesToInit {
-		curTime := time.Now()
+		curTime := nowOf(m.handler)
 		err := m.AddService(&model.Service{
 			ID:         id,
 			Name:       svc,
 			Namespace:  namespacePolaris,
-			Token:      utils.NewUUID(),
+			Token:      m.newToken(svc),
 			Owner:      ownerToInit,
-			Revision:   utils.NewUUID(),
+			Revision:   m.newToken(svc + "@revision"),
 			Valid:      true,
 			CreateTime: curTime,
 			ModifyTime: curTime,
@@ -264,10 +280,33 @@ func (m *boltStore) initNamingStoreData() error {
 		if err != nil {
 			return err
 		}
+		if err := m.pinInitTime(tblNameService, id); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// pinInitTime 设置了 initTime 时，将默认数据的创建以及修改时间改写为 initTime
+func (m *boltStore) pinInitTime(typ string, key string) error {
+	if m.initTime.IsZero() {
+		return nil
+	}
+	return m.handler.UpdateValue(typ, key, map[string]interface{}{
+		"CreateTime": m.initTime,
+		"ModifyTime": m.initTime,
+	})
+}
+
+// newToken 生成默认数据的 token，设置了 tokenSeed 时按照 key 确定性生成
+func (m *boltStore) newToken(key string) string {
+	if m.tokenSeed == "" {
+		return utils.NewUUID()
+	}
+	id := uuid.NewSHA1(uuid.NameSpaceOID, []byte(m.tokenSeed+"@"+key))
+	return hex.EncodeToString(id[:])
+}
+
 func (m *boltStore) initAuthStoreData() error {
 	return m.handler.Execute(true, func(tx *bolt.Tx) error {
 		user, err := m.getUser(tx, mainUser.ID)
@@ -277,6 +316,11 @@ func (m *boltStore) initAuthStoreData() error {
 
 		if user == nil {
 			user = mainUser
+			if !m.initTime.IsZero() {
+				copied := *mainUser
+				copied.CreateTime, copied.ModifyTime = m.initTime, m.initTime
+				user = &copied
+			}
 			// 添加主账户主体信息
 			if err := saveValue(tx, tblUser, user.ID, converToUserStore(user)); err != nil {
 				authLog.Error("[Store][User] save user fail", zap.Error(err), zap.String("name", user.Name))
@@ -291,6 +335,11 @@ func (m *boltStore) initAuthStoreData() error {
 
 		if rule == nil {
 			strategy := mainDefaultStrategy
+			if !m.initTime.IsZero() {
+				copied := *mainDefaultStrategy
+				copied.CreateTime, copied.ModifyTime = m.initTime, m.initTime
+				strategy = &copied
+			}
 			// 添加主账户的默认鉴权策略信息
 			if err := saveValue(tx, tblStrategy, strategy.ID, convertForStrategyStore(strategy)); err != nil {
 				authLog.Error("[Store][Strategy] save auth_strategy", zap.Error(err),
@@ -338,6 +387,7 @@ func (m *boltStore) newAuthModuleStore() {
 	m.userStore = &userStore{handler: m.handler}
 	m.strategyStore = &strategyStore{handler: m.handler}
 	m.groupStore = &groupStore{handler: m.handler}
+	m.apiTokenStore = &apiTokenStore{handler: m.handler}
 }
 
 func (m *boltStore) newConfigModuleStore() {
@@ -346,10 +396,16 @@ func (m *boltStore) newConfigModuleStore() {
 	m.configFileReleaseHistoryStore = newConfigFileReleaseHistoryStore(m.handler)
 	m.configFileReleaseStore = newConfigFileReleaseStore(m.handler)
 	m.configFileTemplateStore = newConfigFileTemplateStore(m.handler)
+	m.configFileSchemaStore = newConfigFileSchemaStore(m.handler)
+	m.configReleaseRequestStore = newConfigReleaseRequestStore(m.handler)
+	m.configGrayRolloutStore = newConfigGrayRolloutStore(m.handler)
 }
 
 func (m *boltStore) newMaintainModuleStore() {
 	m.adminStore = &adminStore{handler: m.handler, leMap: make(map[string]bool)}
+	m.operationRecordStore = &operationRecordStore{handler: m.handler}
+	m.caRootStore = &caRootStore{handler: m.handler}
+	m.healthCheckProtectionStore = &healthCheckProtectionStore{handler: m.handler}
 }
 
 // Destroy store
@@ -102,7 +102,7 @@ func (gs *groupStore) AddGroup(group *model.UserGroupDetail) error {
 func (gs *groupStore) addGroup(tx *bolt.Tx, group *model.UserGroupDetail) error {
 
 	group.Valid = true
-	group.CreateTime = time.Now()
+	group.CreateTime = nowOf(gs.handler)
 	group.ModifyTime = group.CreateTime
 
 	data := convertForGroupStore(group)
@@ -175,7 +175,7 @@ func (gs *groupStore) updateGroup(group *model.ModifyUserGroup) error {
 	ret.Comment = group.Comment
 	ret.Token = group.Token
 	ret.TokenEnable = group.TokenEnable
-	ret.ModifyTime = time.Now()
+	ret.ModifyTime = nowOf(gs.handler)
 
 	updateGroupRelation(ret, group)
 
@@ -224,16 +224,17 @@ func (gs *groupStore) deleteGroup(group *model.UserGroupDetail) error {
 		_ = tx.Rollback()
 	}()
 
+	tn := nowOf(gs.handler)
 	properties := make(map[string]interface{})
 	properties[GroupFieldValid] = false
-	properties[GroupFieldModifyTime] = time.Now()
+	properties[GroupFieldModifyTime] = tn
 
 	if err := updateValue(tx, tblGroup, group.ID, properties); err != nil {
 		log.Error("[Store][Group] remove usergroup", zap.Error(err), zap.String("id", group.ID))
 		return err
 	}
 
-	if err := cleanLinkStrategy(tx, model.PrincipalGroup, group.ID, group.Owner); err != nil {
+	if err := cleanLinkStrategy(tx, model.PrincipalGroup, group.ID, group.Owner, tn); err != nil {
 		log.Error("[Store][Group] clean usergroup default strategy",
 			zap.Error(err), zap.String("id", group.ID))
 		return err
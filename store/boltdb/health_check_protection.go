@@ -0,0 +1,74 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblHealthCheckProtection string = "health_check_protection"
+	// healthCheckProtectionKey 集群内只保存一个人工设置的保护模式
+	healthCheckProtectionKey = "default"
+)
+
+// healthCheckProtection 人工设置的健康检查保护模式的存储结构
+type healthCheckProtection struct {
+	Mode       string
+	ModifyTime time.Time
+}
+
+type healthCheckProtectionStore struct {
+	handler BoltHandler
+}
+
+// SetHealthCheckProtection 保存人工设置的保护模式
+func (hs *healthCheckProtectionStore) SetHealthCheckProtection(protection *model.HealthCheckProtection) error {
+	if protection.Mode == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "set health check protection missing mode")
+	}
+	err := hs.handler.SaveValue(tblHealthCheckProtection, healthCheckProtectionKey, &healthCheckProtection{
+		Mode:       protection.Mode,
+		ModifyTime: protection.ModifyTime,
+	})
+	if err != nil {
+		log.Error("[Store][HealthCheck] set protection mode", zap.String("mode", protection.Mode), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetHealthCheckProtection 查询人工设置的保护模式, 未设置时返回 nil
+func (hs *healthCheckProtectionStore) GetHealthCheckProtection() (*model.HealthCheckProtection, error) {
+	values, err := hs.handler.LoadValues(tblHealthCheckProtection, []string{healthCheckProtectionKey},
+		&healthCheckProtection{})
+	if err != nil {
+		log.Error("[Store][HealthCheck] get protection mode", zap.Error(err))
+		return nil, store.Error(err)
+	}
+	data, ok := values[healthCheckProtectionKey].(*healthCheckProtection)
+	if !ok {
+		return nil, nil
+	}
+	return &model.HealthCheckProtection{Mode: data.Mode, ModifyTime: data.ModifyTime}, nil
+}
@@ -0,0 +1,48 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestHealthCheckProtectionStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblHealthCheckProtection, func(t *testing.T, handler BoltHandler) {
+		hs := &healthCheckProtectionStore{handler: handler}
+
+		// 未设置时返回 nil
+		ret, err := hs.GetHealthCheckProtection()
+		assert.NoError(t, err)
+		assert.Nil(t, ret)
+
+		now := time.Now()
+		assert.NoError(t, hs.SetHealthCheckProtection(&model.HealthCheckProtection{Mode: "on", ModifyTime: now}))
+		assert.NoError(t, hs.SetHealthCheckProtection(&model.HealthCheckProtection{Mode: "off", ModifyTime: now}))
+		ret, err = hs.GetHealthCheckProtection()
+		assert.NoError(t, err)
+		assert.Equal(t, "off", ret.Mode)
+		assert.Equal(t, now.Unix(), ret.ModifyTime.Unix())
+
+		assert.Error(t, hs.SetHealthCheckProtection(&model.HealthCheckProtection{}))
+	})
+}
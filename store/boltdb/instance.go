@@ -50,7 +50,7 @@ const (
 
 // AddInstance add an instance
 func (i *instanceStore) AddInstance(instance *model.Instance) error {
-	initInstance([]*model.Instance{instance})
+	initInstance([]*model.Instance{instance}, nowOf(i.handler))
 	// Before adding new data, you must clean up the old data
 	if err := i.handler.DeleteValues(tblNameInstance, []string{instance.ID()}); err != nil {
 		log.Errorf("[Store][boltdb] delete instance to kv error, %v", err)
@@ -83,7 +83,7 @@ func (i *instanceStore) BatchAddInstances(instances []*model.Instance) error {
 		return err
 	}
 
-	initInstance(instances)
+	initInstance(instances, nowOf(i.handler))
 	for _, instance := range instances {
 		if err := i.handler.SaveValue(tblNameInstance, instance.ID(), instance); err != nil {
 			log.Errorf("[Store][boltdb] save instance to kv error, %v", err)
@@ -99,7 +99,7 @@ func (i *instanceStore) UpdateInstance(instance *model.Instance) error {
 
 	properties := make(map[string]interface{})
 	properties[insFieldProto] = instance.Proto
-	curr := time.Now()
+	curr := nowOf(i.handler)
 	properties[insFieldModifyTime] = curr
 	instance.Proto.Mtime = &wrappers.StringValue{Value: commontime.Time2String(curr)}
 
@@ -116,7 +116,7 @@ func (i *instanceStore) DeleteInstance(instanceID string) error {
 
 	properties := make(map[string]interface{})
 	properties[insFieldValid] = false
-	properties[insFieldModifyTime] = time.Now()
+	properties[insFieldModifyTime] = nowOf(i.handler)
 
 	if err := i.handler.UpdateValue(tblNameInstance, instanceID, properties); err != nil {
 		log.Errorf("[Store][boltdb] delete instance from kv error, %v", err)
@@ -137,7 +137,7 @@ func (i *instanceStore) BatchDeleteInstances(ids []interface{}) error {
 
 		properties := make(map[string]interface{})
 		properties[insFieldValid] = false
-		properties[insFieldModifyTime] = time.Now()
+		properties[insFieldModifyTime] = nowOf(i.handler)
 
 		if err := i.handler.UpdateValue(tblNameInstance, id.(string), properties); err != nil {
 			log.Errorf("[Store][boltdb] batch delete instance from kv error, %v", err)
@@ -621,7 +621,7 @@ func (i *instanceStore) SetInstanceHealthStatus(instanceID string, flag int, rev
 
 	properties := make(map[string]interface{})
 	properties[insFieldProto] = ins.Proto
-	curr := time.Now()
+	curr := nowOf(i.handler)
 	properties[insFieldModifyTime] = curr
 	ins.Proto.Mtime = &wrappers.StringValue{Value: commontime.Time2String(curr)}
 
@@ -679,7 +679,7 @@ func (i *instanceStore) BatchSetInstanceIsolate(ids []interface{}, isolate int,
 
 		properties := make(map[string]interface{})
 		properties[insFieldProto] = instance
-		curr := time.Now()
+		curr := nowOf(i.handler)
 		properties[insFieldModifyTime] = curr
 		instance.Mtime = &wrappers.StringValue{Value: commontime.Time2String(curr)}
 		err = i.handler.UpdateValue(tblNameInstance, id, properties)
@@ -697,6 +697,7 @@ func (i *instanceStore) BatchAppendInstanceMetadata(requests []*store.InstanceMe
 	if len(requests) == 0 {
 		return nil
 	}
+	currT := nowOf(i.handler)
 	return i.handler.Execute(true, func(tx *bolt.Tx) error {
 		values := map[string]interface{}{}
 		fields := []string{insFieldProto, insFieldValid}
@@ -740,7 +741,7 @@ func (i *instanceStore) BatchAppendInstanceMetadata(requests []*store.InstanceMe
 			properties := make(map[string]interface{})
 			properties[insFieldProto] = ins.Proto
 			properties[CommonFieldRevision] = requests[i].Revision
-			properties[insFieldModifyTime] = time.Now()
+			properties[insFieldModifyTime] = currT
 			if err := updateValue(tx, tblNameInstance, instanceID, properties); err != nil {
 				log.Errorf("[Store][boltdb] do batch append InstanceMetadata update instance by %s error, %v",
 					instanceID, err)
@@ -756,6 +757,7 @@ func (i *instanceStore) BatchRemoveInstanceMetadata(requests []*store.InstanceMe
 	if len(requests) == 0 {
 		return nil
 	}
+	currT := nowOf(i.handler)
 	return i.handler.Execute(true, func(tx *bolt.Tx) error {
 		values := map[string]interface{}{}
 		fields := []string{insFieldProto, insFieldValid}
@@ -799,7 +801,7 @@ func (i *instanceStore) BatchRemoveInstanceMetadata(requests []*store.InstanceMe
 			properties := make(map[string]interface{})
 			properties[insFieldProto] = ins.Proto
 			properties[CommonFieldRevision] = requests[i].Revision
-			properties[insFieldModifyTime] = time.Now()
+			properties[insFieldModifyTime] = currT
 			if err := updateValue(tx, tblNameInstance, instanceID, properties); err != nil {
 				log.Errorf("[Store][boltdb] do batch remove InstanceMetadata update instance by %s error, %v",
 					instanceID, err)
@@ -855,7 +857,7 @@ func getRealInstancesList(originServices map[string]interface{}, offset, limit u
 	return instances[beginIndex:endIndex]
 }
 
-func initInstance(instance []*model.Instance) {
+func initInstance(instance []*model.Instance, currT time.Time) {
 
 	if len(instance) == 0 {
 		return
@@ -863,7 +865,6 @@ func initInstance(instance []*model.Instance) {
 
 	for _, ins := range instance {
 		if ins != nil {
-			currT := time.Now()
 			timeStamp := commontime.Time2String(currT)
 			if ins.Proto != nil {
 				if ins.Proto.GetMtime().GetValue() == "" {
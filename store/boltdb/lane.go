@@ -56,20 +56,20 @@ func (l *laneStore) AddLaneGroup(tx store.Tx, item *model.LaneGroup) error {
 	for i := range item.LaneRules {
 		rule := item.LaneRules[i]
 		if rule.IsAdd() {
-			rule.CreateTime = time.Now()
-			rule.ModifyTime = time.Now()
+			rule.CreateTime = nowOf(l.handler)
+			rule.ModifyTime = nowOf(l.handler)
 		} else {
-			rule.ModifyTime = time.Now()
+			rule.ModifyTime = nowOf(l.handler)
 		}
 
 		if rule.IsChangeEnable() {
-			rule.EnableTime = time.Now()
+			rule.EnableTime = nowOf(l.handler)
 		} else {
 			rule.EnableTime = time.Unix(0, 1)
 		}
 	}
 
-	tn := time.Now()
+	tn := nowOf(l.handler)
 	item.CreateTime = tn
 	item.ModifyTime = tn
 	item.Valid = true
@@ -88,14 +88,14 @@ func (l *laneStore) UpdateLaneGroup(tx store.Tx, item *model.LaneGroup) error {
 	for i := range item.LaneRules {
 		rule := item.LaneRules[i]
 		if rule.IsAdd() {
-			rule.CreateTime = time.Now()
-			rule.ModifyTime = time.Now()
+			rule.CreateTime = nowOf(l.handler)
+			rule.ModifyTime = nowOf(l.handler)
 		} else {
-			rule.ModifyTime = time.Now()
+			rule.ModifyTime = nowOf(l.handler)
 		}
 
 		if rule.IsChangeEnable() {
-			rule.EnableTime = time.Now()
+			rule.EnableTime = nowOf(l.handler)
 		} else {
 			rule.EnableTime = time.Unix(0, 1)
 		}
@@ -104,7 +104,7 @@ func (l *laneStore) UpdateLaneGroup(tx store.Tx, item *model.LaneGroup) error {
 	properties := map[string]interface{}{
 		FieldLaneDescription:  item.Description,
 		FieldLaneRuleText:     item.Rule,
-		CommonFieldModifyTime: time.Now(),
+		CommonFieldModifyTime: nowOf(l.handler),
 		CommonFieldRevision:   item.Revision,
 		FieldLaneRules:        utils.MustJson(item.LaneRules),
 	}
@@ -251,7 +251,7 @@ func pageLaneGroups(items []*model.LaneGroup, offset, limit uint32, order map[st
 func (l *laneStore) DeleteLaneGroup(id string) error {
 	err := l.handler.Execute(true, func(tx *bolt.Tx) error {
 		properties := map[string]interface{}{
-			CommonFieldModifyTime: time.Now(),
+			CommonFieldModifyTime: nowOf(l.handler),
 			CommonFieldValid:      false,
 		}
 		if err := updateValue(tx, tblLaneGroup, id, properties); err != nil {
@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"os"
 	"sort"
-	"time"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/zap"
@@ -88,7 +87,7 @@ func (m *boltStore) loadFromData(data *DefaultData) error {
 		return users[i].Type < users[j].Type
 	})
 
-	tn := time.Now()
+	tn := nowOf(m.handler)
 	var (
 		superUser, mainUser *model.User
 	)
@@ -0,0 +1,215 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblOperationRecord string = "OperationRecord"
+
+	OperationRecordFieldID            string = "ID"
+	OperationRecordFieldResourceType  string = "ResourceType"
+	OperationRecordFieldResourceName  string = "ResourceName"
+	OperationRecordFieldNamespace     string = "Namespace"
+	OperationRecordFieldOperator      string = "Operator"
+	OperationRecordFieldOperationType string = "OperationType"
+	OperationRecordFieldHappenTime    string = "HappenTime"
+)
+
+// operationRecord 操作记录的存储结构，RecordEntry 中的自定义字符串类型无法直接反序列化
+type operationRecord struct {
+	ID            uint64
+	ResourceType  string
+	ResourceName  string
+	Namespace     string
+	Operator      string
+	OperationType string
+	Detail        string
+	Server        string
+	HappenTime    time.Time
+}
+
+type operationRecordStore struct {
+	handler BoltHandler
+}
+
+// AddOperationRecords 批量保存操作记录
+func (o *operationRecordStore) AddOperationRecords(records []*model.RecordEntry) error {
+	if len(records) == 0 {
+		return nil
+	}
+	err := o.handler.Execute(true, func(tx *bolt.Tx) error {
+		table, err := tx.CreateBucketIfNotExists([]byte(tblOperationRecord))
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			nextId, err := table.NextSequence()
+			if err != nil {
+				return err
+			}
+			records[i].ID = nextId
+			key := strconv.FormatUint(nextId, 10)
+			if err := saveValue(tx, tblOperationRecord, key, toOperationRecordStore(records[i])); err != nil {
+				log.Error("[OperationRecord] save info", zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	})
+	return store.Error(err)
+}
+
+// GetOperationRecords 查询操作记录，按照发生时间倒序返回
+func (o *operationRecordStore) GetOperationRecords(filter map[string]string,
+	offset, limit uint32) (uint32, []*model.RecordEntry, error) {
+
+	var (
+		exactFields = map[string]string{
+			OperationRecordFieldResourceType:  filter["resource_type"],
+			OperationRecordFieldResourceName:  filter["resource_name"],
+			OperationRecordFieldNamespace:     filter["namespace"],
+			OperationRecordFieldOperator:      filter["operator"],
+			OperationRecordFieldOperationType: filter["operation_type"],
+		}
+		startTime, hasStart = parseUnixFilter(filter["start_time"])
+		endTime, hasEnd     = parseUnixFilter(filter["end_time"])
+		fields              = []string{OperationRecordFieldResourceType, OperationRecordFieldResourceName,
+			OperationRecordFieldNamespace, OperationRecordFieldOperator, OperationRecordFieldOperationType,
+			OperationRecordFieldHappenTime}
+	)
+
+	ret, err := o.handler.LoadValuesByFilter(tblOperationRecord, fields, &operationRecord{},
+		func(m map[string]interface{}) bool {
+			for field, expect := range exactFields {
+				if expect == "" {
+					continue
+				}
+				if save, _ := m[field].(string); save != expect {
+					return false
+				}
+			}
+			happenTime, _ := m[OperationRecordFieldHappenTime].(time.Time)
+			if hasStart && happenTime.Before(startTime) {
+				return false
+			}
+			if hasEnd && happenTime.After(endTime) {
+				return false
+			}
+			return true
+		})
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+
+	records := make([]*operationRecord, 0, len(ret))
+	for k := range ret {
+		records = append(records, ret[k].(*operationRecord))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].HappenTime.Equal(records[j].HappenTime) {
+			return records[i].HappenTime.After(records[j].HappenTime)
+		}
+		return records[i].ID > records[j].ID
+	})
+
+	total := uint32(len(records))
+	if offset >= total {
+		return total, []*model.RecordEntry{}, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	entries := make([]*model.RecordEntry, 0, end-offset)
+	for _, record := range records[offset:end] {
+		entries = append(entries, toOperationRecordModel(record))
+	}
+	return total, entries, nil
+}
+
+// CleanOperationRecords 清理 endTime 之前的操作记录
+func (o *operationRecordStore) CleanOperationRecords(endTime time.Time, limit uint64) error {
+	fields := []string{OperationRecordFieldHappenTime, OperationRecordFieldID}
+	needDel := make([]string, 0, limit)
+
+	_, err := o.handler.LoadValuesByFilter(tblOperationRecord, fields, &operationRecord{},
+		func(m map[string]interface{}) bool {
+			if uint64(len(needDel)) >= limit {
+				return false
+			}
+			happenTime, _ := m[OperationRecordFieldHappenTime].(time.Time)
+			if endTime.After(happenTime) {
+				needDel = append(needDel, strconv.FormatUint(m[OperationRecordFieldID].(uint64), 10))
+			}
+			return false
+		})
+	if err != nil {
+		return store.Error(err)
+	}
+	return o.handler.DeleteValues(tblOperationRecord, needDel)
+}
+
+func parseUnixFilter(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+func toOperationRecordStore(entry *model.RecordEntry) *operationRecord {
+	return &operationRecord{
+		ID:            entry.ID,
+		ResourceType:  string(entry.ResourceType),
+		ResourceName:  entry.ResourceName,
+		Namespace:     entry.Namespace,
+		Operator:      entry.Operator,
+		OperationType: string(entry.OperationType),
+		Detail:        entry.Detail,
+		Server:        entry.Server,
+		HappenTime:    entry.HappenTime,
+	}
+}
+
+func toOperationRecordModel(record *operationRecord) *model.RecordEntry {
+	return &model.RecordEntry{
+		ID:            record.ID,
+		ResourceType:  model.Resource(record.ResourceType),
+		ResourceName:  record.ResourceName,
+		Namespace:     record.Namespace,
+		Operator:      record.Operator,
+		OperationType: model.OperationType(record.OperationType),
+		Detail:        record.Detail,
+		Server:        record.Server,
+		HappenTime:    record.HappenTime,
+	}
+}
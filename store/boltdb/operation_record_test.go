@@ -0,0 +1,106 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func mockOperationRecords(total int, base time.Time) []*model.RecordEntry {
+	ret := make([]*model.RecordEntry, 0, total)
+	for i := 0; i < total; i++ {
+		operator := "polaris"
+		if i%2 == 1 {
+			operator = "guest"
+		}
+		ret = append(ret, &model.RecordEntry{
+			ResourceType:  model.RRouting,
+			ResourceName:  fmt.Sprintf("rule-%d", i),
+			Namespace:     "default",
+			Operator:      operator,
+			OperationType: model.OUpdate,
+			Detail:        fmt.Sprintf("detail-%d", i),
+			Server:        "127.0.0.1",
+			HappenTime:    base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return ret
+}
+
+func Test_operationRecordStore(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblOperationRecord, func(t *testing.T, handler BoltHandler) {
+		s := &operationRecordStore{handler: handler}
+		base := time.Now().Add(-time.Hour).Truncate(time.Second)
+		records := mockOperationRecords(10, base)
+		assert.NoError(t, s.AddOperationRecords(records))
+		for i := range records {
+			assert.Equal(t, uint64(i+1), records[i].ID)
+		}
+
+		t.Run("按照条件分页查询", func(t *testing.T) {
+			total, ret, err := s.GetOperationRecords(map[string]string{
+				"resource_type": string(model.RRouting),
+				"operator":      "polaris",
+			}, 0, 2)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(5), total)
+			assert.Len(t, ret, 2)
+			// 按照发生时间倒序返回
+			assert.Equal(t, "rule-8", ret[0].ResourceName)
+			assert.Equal(t, "rule-6", ret[1].ResourceName)
+			assert.Equal(t, model.OUpdate, ret[0].OperationType)
+			assert.True(t, ret[0].HappenTime.Equal(records[8].HappenTime))
+
+			total, ret, err = s.GetOperationRecords(map[string]string{"operator": "polaris"}, 4, 2)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(5), total)
+			assert.Len(t, ret, 1)
+			assert.Equal(t, "rule-0", ret[0].ResourceName)
+		})
+
+		t.Run("按照时间范围查询", func(t *testing.T) {
+			total, ret, err := s.GetOperationRecords(map[string]string{
+				"start_time": strconv.FormatInt(records[2].HappenTime.Unix(), 10),
+				"end_time":   strconv.FormatInt(records[4].HappenTime.Unix(), 10),
+			}, 0, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(3), total)
+			assert.Equal(t, "rule-4", ret[0].ResourceName)
+			assert.Equal(t, "rule-2", ret[2].ResourceName)
+
+			total, _, err = s.GetOperationRecords(map[string]string{"namespace": "test"}, 0, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(0), total)
+		})
+
+		t.Run("清理过期记录", func(t *testing.T) {
+			assert.NoError(t, s.CleanOperationRecords(records[3].HappenTime, 100))
+			total, ret, err := s.GetOperationRecords(map[string]string{}, 0, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(7), total)
+			assert.Equal(t, "rule-3", ret[len(ret)-1].ResourceName)
+		})
+	})
+}
@@ -0,0 +1,54 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import "time"
+
+// ReplicaStore boltdb store driven by an external replication layer (e.g. raft),
+// it shares the data layout with the registered boltdbStore but never loads default
+// data by itself, the replication layer decides when LoadDefault is applied
+type ReplicaStore struct {
+	*boltStore
+}
+
+// NewReplicaStore create a boltdb store on top of an opened handler
+func NewReplicaStore(handler BoltHandler) (*ReplicaStore, error) {
+	s := &boltStore{handler: handler}
+	if err := s.newStore(); err != nil {
+		return nil, err
+	}
+	s.start = true
+	return &ReplicaStore{boltStore: s}, nil
+}
+
+// Handler return the boltdb handler of this store
+func (r *ReplicaStore) Handler() BoltHandler {
+	return r.handler
+}
+
+// LoadDefault load the default namespaces, services and main user, the tokens of
+// default data are derived from seed and the create/modify time are pinned to initTime,
+// so all replicas load exactly the same data
+func (r *ReplicaStore) LoadDefault(seed string, initTime time.Time) error {
+	r.tokenSeed = seed
+	r.initTime = initTime
+	if err := r.initAuthStoreData(); err != nil {
+		return err
+	}
+	return r.initNamingStoreData()
+}
@@ -97,14 +97,14 @@ func (r *routingStoreV2) createRoutingConfigV2(tx *bolt.Tx, conf *model.RouterCo
 		return err
 	}
 
-	currTime := time.Now()
+	currTime := nowOf(r.handler)
 	conf.CreateTime = currTime
 	conf.ModifyTime = currTime
 	conf.EnableTime = time.Time{}
 	conf.Valid = true
 
 	if conf.Enable {
-		conf.EnableTime = time.Now()
+		conf.EnableTime = nowOf(r.handler)
 	} else {
 		conf.EnableTime = time.Time{}
 	}
@@ -151,7 +151,7 @@ func (r *routingStoreV2) updateRoutingConfigV2Tx(tx *bolt.Tx, conf *model.Router
 	properties[routingV2FieldPriority] = conf.Priority
 	properties[routingV2FieldRevision] = conf.Revision
 	properties[routingV2FieldDescription] = conf.Description
-	properties[routingV2FieldModifyTime] = time.Now()
+	properties[routingV2FieldModifyTime] = nowOf(r.handler)
 
 	err := updateValue(tx, tblNameRoutingV2, conf.ID, properties)
 	if err != nil {
@@ -168,7 +168,7 @@ func (r *routingStoreV2) EnableRouting(conf *model.RouterConfig) error {
 	}
 
 	if conf.Enable {
-		conf.EnableTime = time.Now()
+		conf.EnableTime = nowOf(r.handler)
 	} else {
 		conf.EnableTime = time.Time{}
 	}
@@ -177,7 +177,7 @@ func (r *routingStoreV2) EnableRouting(conf *model.RouterConfig) error {
 	properties[routingV2FieldEnable] = conf.Enable
 	properties[routingV2FieldEnableTime] = conf.EnableTime
 	properties[routingV2FieldRevision] = conf.Revision
-	properties[routingV2FieldModifyTime] = time.Now()
+	properties[routingV2FieldModifyTime] = nowOf(r.handler)
 
 	err := r.handler.UpdateValue(tblNameRoutingV2, conf.ID, properties)
 	if err != nil {
@@ -195,7 +195,7 @@ func (r *routingStoreV2) DeleteRoutingConfigV2(ruleID string) error {
 	}
 	properties := make(map[string]interface{})
 	properties[routingV2FieldValid] = false
-	properties[routingV2FieldModifyTime] = time.Now()
+	properties[routingV2FieldModifyTime] = nowOf(r.handler)
 
 	err := r.handler.UpdateValue(tblNameRoutingV2, ruleID, properties)
 	if err != nil {
@@ -72,7 +72,7 @@ func (ss *serviceStore) AddService(s *model.Service) error {
 		return err
 	}
 
-	initService(s)
+	initService(s, nowOf(ss.handler))
 
 	if s.ID == "" || s.Name == "" || s.Namespace == "" {
 		return store.NewStatusError(store.EmptyParamsErr, "add Service missing some params")
@@ -90,7 +90,7 @@ func (ss *serviceStore) DeleteService(id, serviceName, namespaceName string) err
 
 	properties := make(map[string]interface{})
 	properties[SvcFieldValid] = false
-	properties[SvcFieldModifyTime] = time.Now()
+	properties[SvcFieldModifyTime] = nowOf(ss.handler)
 
 	err := ss.handler.UpdateValue(tblNameService, id, properties)
 	return store.Error(err)
@@ -113,7 +113,7 @@ func (ss *serviceStore) DeleteServiceAlias(name string, namespace string) error
 
 	properties := make(map[string]interface{})
 	properties[SvcFieldValid] = false
-	properties[SvcFieldModifyTime] = time.Now()
+	properties[SvcFieldModifyTime] = nowOf(ss.handler)
 
 	if err = ss.handler.UpdateValue(tblNameService, svc.ID, properties); err != nil {
 		log.Errorf("[Store][boltdb] delete service alias error, %v", err)
@@ -138,7 +138,7 @@ func (ss *serviceStore) UpdateServiceAlias(alias *model.Service, needUpdateOwner
 	properties[SvcFieldOwner] = alias.Owner
 	properties[SvcFieldReference] = alias.Reference
 	properties[SvcFieldExportTo] = utils.MustJson(alias.ExportTo)
-	properties[SvcFieldModifyTime] = time.Now()
+	properties[SvcFieldModifyTime] = nowOf(ss.handler)
 
 	err := ss.handler.UpdateValue(tblNameService, alias.ID, properties)
 
@@ -169,7 +169,7 @@ func (ss *serviceStore) UpdateService(service *model.Service, needUpdateOwner bo
 	properties[SvcFieldCmdbMod2] = service.CmdbMod2
 	properties[SvcFieldCmdbMod3] = service.CmdbMod3
 	properties[SvcFieldExportTo] = utils.MustJson(service.ExportTo)
-	properties[SvcFieldModifyTime] = time.Now()
+	properties[SvcFieldModifyTime] = nowOf(ss.handler)
 
 	err := ss.handler.UpdateValue(tblNameService, service.ID, properties)
 
@@ -186,7 +186,7 @@ func (ss *serviceStore) UpdateServiceToken(serviceID string, token string, revis
 	properties := make(map[string]interface{})
 	properties[SvcFieldToken] = token
 	properties[SvcFieldRevision] = revision
-	properties[SvcFieldModifyTime] = time.Now()
+	properties[SvcFieldModifyTime] = nowOf(ss.handler)
 
 	err := ss.handler.UpdateValue(tblNameService, serviceID, properties)
 
@@ -1006,8 +1006,7 @@ func doPageAliasServices(originServices []*model.ServiceAlias, offset, limit uin
 	return services[beginIndex:endIndex]
 }
 
-func initService(s *model.Service) {
-	current := time.Now()
+func initService(s *model.Service, current time.Time) {
 	if s != nil {
 		s.CreateTime = current
 		s.ModifyTime = current
@@ -52,7 +52,7 @@ type serviceContractStore struct {
 
 // CreateServiceContract 创建服务契约
 func (s *serviceContractStore) CreateServiceContract(contract *model.ServiceContract) error {
-	tn := time.Now()
+	tn := nowOf(s.handler)
 	contract.Valid = true
 	contract.CreateTime = tn
 	contract.ModifyTime = tn
@@ -70,7 +70,7 @@ func (s *serviceContractStore) UpdateServiceContract(contract *model.ServiceCont
 	properties := map[string]interface{}{
 		ContractFieldRevision:   contract.Revision,
 		ContractFieldContent:    contract.Content,
-		ContractFieldModifyTime: time.Now(),
+		ContractFieldModifyTime: nowOf(s.handler),
 	}
 
 	if err := s.handler.UpdateValue(tblServiceContract, contract.ID, properties); err != nil {
@@ -83,7 +83,7 @@ func (s *serviceContractStore) UpdateServiceContract(contract *model.ServiceCont
 func (s *serviceContractStore) DeleteServiceContract(contract *model.ServiceContract) error {
 	properties := map[string]interface{}{
 		ContractFieldValid:      false,
-		ContractFieldModifyTime: time.Now(),
+		ContractFieldModifyTime: nowOf(s.handler),
 	}
 
 	if err := s.handler.UpdateValue(tblServiceContract, contract.ID, properties); err != nil {
@@ -118,7 +118,7 @@ func (s *serviceContractStore) AddServiceContractInterfaces(contract *model.Enri
 		if len(values) == 0 {
 			return store.NewStatusError(store.NotFoundResource, "not found target service_contract")
 		}
-		tN := time.Now()
+		tN := nowOf(s.handler)
 		for i := range contract.Interfaces {
 			contract.Interfaces[i].CreateTime = tN
 			contract.Interfaces[i].ModifyTime = tN
@@ -128,7 +128,7 @@ func (s *serviceContractStore) AddServiceContractInterfaces(contract *model.Enri
 		enrichRecord := s.toModel(record)
 		enrichRecord.Interfaces = contract.Interfaces
 		enrichRecord.Revision = contract.Revision
-		enrichRecord.ModifyTime = time.Now()
+		enrichRecord.ModifyTime = nowOf(s.handler)
 
 		return saveValue(tx, tblServiceContract, contract.ID, s.toStore(enrichRecord))
 	})
@@ -152,7 +152,7 @@ func (s *serviceContractStore) AppendServiceContractInterfaces(contract *model.E
 		for i := range enrichRecord.Interfaces {
 			interfaceMap[enrichRecord.Interfaces[i].ID] = enrichRecord.Interfaces[i]
 		}
-		tN := time.Now()
+		tN := nowOf(s.handler)
 		for i := range contract.Interfaces {
 			contract.Interfaces[i].ModifyTime = tN
 			contract.Interfaces[i].CreateTime = tN
@@ -165,7 +165,7 @@ func (s *serviceContractStore) AppendServiceContractInterfaces(contract *model.E
 
 		enrichRecord.Interfaces = interfaceSlice
 		enrichRecord.Revision = contract.Revision
-		enrichRecord.ModifyTime = time.Now()
+		enrichRecord.ModifyTime = nowOf(s.handler)
 
 		return saveValue(tx, tblServiceContract, contract.ID, s.toStore(enrichRecord))
 	})
@@ -199,7 +199,7 @@ func (s *serviceContractStore) DeleteServiceContractInterfaces(contract *model.E
 
 		enrichRecord.Interfaces = interfaceSlice
 		enrichRecord.Revision = contract.Revision
-		enrichRecord.ModifyTime = time.Now()
+		enrichRecord.ModifyTime = nowOf(s.handler)
 
 		return saveValue(tx, tblServiceContract, contract.ID, s.toStore(enrichRecord))
 	})
@@ -18,6 +18,7 @@
 package boltdb
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
@@ -51,6 +52,7 @@ const (
 	StrategyFieldRevision        string = "Revision"
 	StrategyFieldCreateTime      string = "CreateTime"
 	StrategyFieldModifyTime      string = "ModifyTime"
+	StrategyFieldConditions      string = "Conditions"
 )
 
 var (
@@ -72,8 +74,10 @@ type strategyForStore struct {
 	CfgResources map[string]string
 	Valid        bool
 	Revision     string
-	CreateTime   time.Time
-	ModifyTime   time.Time
+	// Conditions 策略生效条件，JSON 格式
+	Conditions string
+	CreateTime time.Time
+	ModifyTime time.Time
 }
 
 // StrategyStore
@@ -89,7 +93,7 @@ func (ss *strategyStore) AddStrategy(strategy *model.StrategyDetail) error {
 			strategy.ID, strategy.Name, strategy.Owner))
 	}
 
-	initStrategy(strategy)
+	initStrategy(strategy, nowOf(ss.handler))
 
 	proxy, err := ss.handler.StartTx()
 	if err != nil {
@@ -168,7 +172,7 @@ func (ss *strategyStore) updateStrategy(tx *bolt.Tx, modify *model.ModifyStrateg
 	computeResources(false, modify.AddResources, saveVal)
 	computeResources(true, modify.RemoveResources, saveVal)
 
-	saveVal.ModifyTime = time.Now()
+	saveVal.ModifyTime = nowOf(ss.handler)
 
 	if err := saveValue(tx, tblStrategy, saveVal.ID, saveVal); err != nil {
 		log.Error("[Store][Strategy] update auth_strategy", zap.Error(err),
@@ -243,7 +247,7 @@ func (ss *strategyStore) DeleteStrategy(id string) error {
 
 	properties := make(map[string]interface{})
 	properties[StrategyFieldValid] = false
-	properties[StrategyFieldModifyTime] = time.Now()
+	properties[StrategyFieldModifyTime] = nowOf(ss.handler)
 
 	if err := ss.handler.UpdateValue(tblStrategy, id, properties); err != nil {
 		log.Error("[Store][Strategy] delete auth_strategy", zap.Error(err), zap.String("id", id))
@@ -253,6 +257,54 @@ func (ss *strategyStore) DeleteStrategy(id string) error {
 	return nil
 }
 
+// UpdateStrategyConditions 替换策略的生效条件
+func (ss *strategyStore) UpdateStrategyConditions(id string, conditions *model.StrategyConditions) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "update auth_strategy conditions missing id")
+	}
+
+	proxy, err := ss.handler.StartTx()
+	if err != nil {
+		return err
+	}
+	tx := proxy.GetDelegateTx().(*bolt.Tx)
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	saveVal, err := loadStrategyById(tx, id)
+	if err != nil {
+		return err
+	}
+	if saveVal == nil {
+		return ErrorStrategyNotFound
+	}
+
+	saveVal.Conditions = marshalStrategyConditions(conditions)
+	saveVal.Revision = utils.NewUUID()
+	saveVal.ModifyTime = nowOf(ss.handler)
+
+	if err := saveValue(tx, tblStrategy, saveVal.ID, saveVal); err != nil {
+		log.Error("[Store][Strategy] update auth_strategy conditions", zap.Error(err), zap.String("id", id))
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error("[Store][Strategy] update auth_strategy conditions tx commit", zap.Error(err),
+			zap.String("id", id))
+		return err
+	}
+	return nil
+}
+
+func marshalStrategyConditions(conditions *model.StrategyConditions) string {
+	if conditions.IsEmpty() {
+		return ""
+	}
+	data, _ := json.Marshal(conditions)
+	return string(data)
+}
+
 // RemoveStrategyResources 删除策略的资源数据信息
 func (ss *strategyStore) RemoveStrategyResources(resources []model.StrategyResource) error {
 	return ss.operateStrategyResources(true, resources)
@@ -285,7 +337,7 @@ func (ss *strategyStore) operateStrategyResources(remove bool, resources []model
 		}
 
 		computeResources(remove, ress, rule)
-		rule.ModifyTime = time.Now()
+		rule.ModifyTime = nowOf(ss.handler)
 		if err := saveValue(tx, tblStrategy, rule.ID, rule); err != nil {
 			log.Error("[Store][Strategy] operate strategy resource", zap.Error(err),
 				zap.Bool("remove", remove), zap.String("id", id))
@@ -758,7 +810,8 @@ func createDefaultStrategy(tx *bolt.Tx, role model.PrincipalType, principalId, n
 	return saveValue(tx, tblStrategy, strategy.ID, convertForStrategyStore(strategy))
 }
 
-func cleanLinkStrategy(tx *bolt.Tx, role model.PrincipalType, principalId, owner string) error {
+func cleanLinkStrategy(tx *bolt.Tx, role model.PrincipalType, principalId, owner string,
+	now time.Time) error {
 
 	fields := []string{StrategyFieldDefault, StrategyFieldUsersPrincipal, StrategyFieldGroupsPrincipal}
 	values := make(map[string]interface{})
@@ -801,7 +854,7 @@ func cleanLinkStrategy(tx *bolt.Tx, role model.PrincipalType, principalId, owner
 
 		properties := make(map[string]interface{})
 		properties[StrategyFieldValid] = false
-		properties[StrategyFieldModifyTime] = time.Now()
+		properties[StrategyFieldModifyTime] = now
 
 		if err := updateValue(tx, tblStrategy, k, properties); err != nil {
 			log.Error("[Store][Strategy] clean link auth_strategy", zap.Error(err),
@@ -862,6 +915,7 @@ func convertForStrategyStore(strategy *model.StrategyDetail) *strategyForStore {
 		CfgResources: cfg,
 		Valid:        strategy.Valid,
 		Revision:     strategy.Revision,
+		Conditions:   marshalStrategyConditions(strategy.Conditions),
 		CreateTime:   strategy.CreateTime,
 		ModifyTime:   strategy.ModifyTime,
 	}
@@ -906,6 +960,12 @@ func convertForStrategyDetail(strategy *strategyForStore) *model.StrategyDetail
 	resources = append(resources, fillRes(strategy.SvcResources, apisecurity.ResourceType_Services)...)
 	resources = append(resources, fillRes(strategy.CfgResources, apisecurity.ResourceType_ConfigGroups)...)
 
+	conditions, err := model.ParseStrategyConditions(strategy.Conditions)
+	if err != nil {
+		log.Error("[Store][Strategy] parse auth_strategy conditions", zap.Error(err),
+			zap.String("id", strategy.ID))
+	}
+
 	return &model.StrategyDetail{
 		ID:         strategy.ID,
 		Name:       strategy.Name,
@@ -917,16 +977,16 @@ func convertForStrategyDetail(strategy *strategyForStore) *model.StrategyDetail
 		Owner:      strategy.Owner,
 		Valid:      strategy.Valid,
 		Revision:   strategy.Revision,
+		Conditions: conditions,
 		CreateTime: strategy.CreateTime,
 		ModifyTime: strategy.ModifyTime,
 	}
 }
 
-func initStrategy(rule *model.StrategyDetail) {
+func initStrategy(rule *model.StrategyDetail, tn time.Time) {
 	if rule != nil {
 		rule.Valid = true
 
-		tn := time.Now()
 		rule.CreateTime = tn
 		rule.ModifyTime = tn
 
@@ -222,6 +222,39 @@ func Test_strategyStore_GetStrategyDetail(t *testing.T) {
 	})
 }
 
+func Test_strategyStore_UpdateStrategyConditions(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
+		ss := &strategyStore{handler: handler}
+
+		rules := createTestStrategy(1)
+		err := ss.AddStrategy(rules[0])
+		assert.Nil(t, err, "add strategy must success")
+
+		conditions := &model.StrategyConditions{
+			SourceIPs: []string{"10.0.0.0/8"},
+			Protocols: []string{model.ProtocolConsole},
+		}
+		err = ss.UpdateStrategyConditions(rules[0].ID, conditions)
+		assert.Nil(t, err, "update strategy conditions must success")
+
+		v, err := ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, v.Conditions)
+		assert.Equal(t, conditions.SourceIPs, v.Conditions.SourceIPs)
+		assert.Equal(t, conditions.Protocols, v.Conditions.Protocols)
+		assert.NotEqual(t, rules[0].Revision, v.Revision)
+
+		err = ss.UpdateStrategyConditions(rules[0].ID, nil)
+		assert.Nil(t, err, "clean strategy conditions must success")
+		v, err = ss.GetStrategyDetail(rules[0].ID)
+		assert.Nil(t, err)
+		assert.Nil(t, v.Conditions)
+
+		err = ss.UpdateStrategyConditions("not-exist", conditions)
+		assert.Equal(t, ErrorStrategyNotFound, err)
+	})
+}
+
 func Test_strategyStore_GetStrategyResources(t *testing.T) {
 	CreateTableDBHandlerAndRun(t, "test_strategy", func(t *testing.T, handler BoltHandler) {
 		ss := &strategyStore{handler: handler}
@@ -78,7 +78,7 @@ type userStore struct {
 // AddUser 添加用户
 func (us *userStore) AddUser(user *model.User) error {
 
-	initUser(user)
+	initUser(user, nowOf(us.handler))
 
 	if user.ID == "" || user.Name == "" || user.Source == "" ||
 		user.Owner == "" || user.Token == "" {
@@ -146,7 +146,7 @@ func (us *userStore) UpdateUser(user *model.User) error {
 	properties[UserFieldEmail] = user.Email
 	properties[UserFieldMobile] = user.Mobile
 	properties[UserFieldPassword] = user.Password
-	properties[UserFieldModifyTime] = time.Now()
+	properties[UserFieldModifyTime] = nowOf(us.handler)
 
 	err := us.handler.UpdateValue(tblUser, user.ID, properties)
 	if err != nil {
@@ -177,9 +177,10 @@ func (us *userStore) deleteUser(user *model.User) error {
 		_ = tx.Rollback()
 	}()
 
+	tn := nowOf(us.handler)
 	properties := make(map[string]interface{})
 	properties[UserFieldValid] = false
-	properties[UserFieldModifyTime] = time.Now()
+	properties[UserFieldModifyTime] = tn
 
 	if err := updateValue(tx, tblUser, user.ID, properties); err != nil {
 		log.Error("[Store][User] delete user by id", zap.Error(err), zap.String("id", user.ID))
@@ -191,7 +192,16 @@ func (us *userStore) deleteUser(user *model.User) error {
 		owner = user.ID
 	}
 
-	if err := cleanLinkStrategy(tx, model.PrincipalUser, user.ID, user.Owner); err != nil {
+	if err := cleanLinkStrategy(tx, model.PrincipalUser, user.ID, user.Owner, tn); err != nil {
+		return err
+	}
+
+	if err := deleteValues(tx, tblUserPasswordRecord, []string{user.ID}); err != nil {
+		log.Error("[Store][User] delete user password record", zap.Error(err), zap.String("id", user.ID))
+		return err
+	}
+	if err := deleteValues(tx, tblLoginLock, []string{model.UserLoginLockKey(user.ID)}); err != nil {
+		log.Error("[Store][User] delete user login lock", zap.Error(err), zap.String("id", user.ID))
 		return err
 	}
 
@@ -576,9 +586,8 @@ func converToUserModel(user *userForStore) *model.User {
 	}
 }
 
-func initUser(user *model.User) {
+func initUser(user *model.User, tn time.Time) {
 	if user != nil {
-		tn := time.Now()
 		user.Valid = true
 		user.CreateTime = tn
 		user.ModifyTime = tn
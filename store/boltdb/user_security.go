@@ -0,0 +1,176 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	tblLoginLock          string = "login_lock"
+	tblUserPasswordRecord string = "user_password_record"
+)
+
+// loginLock 登录锁定的存储结构，时间保存为秒级时间戳，0 表示未设置
+type loginLock struct {
+	Key         string
+	FailCount   int
+	WindowStart int64
+	LockedUntil int64
+	ModifyTime  time.Time
+}
+
+// userPasswordRecord 用户密码记录的存储结构，History 以 JSON 数组的形式保存
+type userPasswordRecord struct {
+	UserID     string
+	History    string
+	ChangeTime int64
+}
+
+// GetLoginLock 获取登录失败计数以及锁定状态
+func (us *userStore) GetLoginLock(key string) (*model.LoginLock, error) {
+	values, err := us.handler.LoadValues(tblLoginLock, []string{key}, &loginLock{})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	data, ok := values[key].(*loginLock)
+	if !ok {
+		return nil, nil
+	}
+	return &model.LoginLock{
+		Key:         data.Key,
+		FailCount:   data.FailCount,
+		WindowStart: timeOrZero(data.WindowStart),
+		LockedUntil: timeOrZero(data.LockedUntil),
+		ModifyTime:  data.ModifyTime,
+	}, nil
+}
+
+// SaveLoginLock 保存登录失败计数以及锁定状态
+func (us *userStore) SaveLoginLock(lock *model.LoginLock) error {
+	if lock.Key == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "save login lock missing key")
+	}
+	data := &loginLock{
+		Key:         lock.Key,
+		FailCount:   lock.FailCount,
+		WindowStart: unixOrZero(lock.WindowStart),
+		LockedUntil: unixOrZero(lock.LockedUntil),
+		ModifyTime:  nowOf(us.handler),
+	}
+	if err := us.handler.SaveValue(tblLoginLock, lock.Key, data); err != nil {
+		log.Error("[Store][User] save login lock", zap.String("key", lock.Key), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// IncrLoginFailure 在同一个写事务中读取、累加登录失败计数并保存，返回新的计数以及锁定状态
+func (us *userStore) IncrLoginFailure(failure *model.LoginFailure) (*model.LoginLock, error) {
+	if failure.Key == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "incr login failure missing key")
+	}
+	var lock *model.LoginLock
+	err := us.handler.Execute(true, func(tx *bolt.Tx) error {
+		values := map[string]interface{}{}
+		if err := loadValues(tx, tblLoginLock, []string{failure.Key}, &loginLock{}, values); err != nil {
+			return err
+		}
+		if data, ok := values[failure.Key].(*loginLock); ok {
+			lock = &model.LoginLock{
+				Key:         data.Key,
+				FailCount:   data.FailCount,
+				WindowStart: timeOrZero(data.WindowStart),
+				LockedUntil: timeOrZero(data.LockedUntil),
+			}
+		}
+		lock = failure.Apply(lock)
+		lock.ModifyTime = nowOf(us.handler)
+		return saveValue(tx, tblLoginLock, failure.Key, &loginLock{
+			Key:         lock.Key,
+			FailCount:   lock.FailCount,
+			WindowStart: unixOrZero(lock.WindowStart),
+			LockedUntil: unixOrZero(lock.LockedUntil),
+			ModifyTime:  lock.ModifyTime,
+		})
+	})
+	if err != nil {
+		log.Error("[Store][User] incr login failure", zap.String("key", failure.Key), zap.Error(err))
+		return nil, store.Error(err)
+	}
+	return lock, nil
+}
+
+// DeleteLoginLock 删除登录失败计数以及锁定状态
+func (us *userStore) DeleteLoginLock(key string) error {
+	if err := us.handler.DeleteValues(tblLoginLock, []string{key}); err != nil {
+		log.Error("[Store][User] delete login lock", zap.String("key", key), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetUserPasswordRecord 获取用户最近使用过的密码以及最近一次修改密码的时间
+func (us *userStore) GetUserPasswordRecord(userID string) (*model.UserPasswordRecord, error) {
+	values, err := us.handler.LoadValues(tblUserPasswordRecord, []string{userID}, &userPasswordRecord{})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	data, ok := values[userID].(*userPasswordRecord)
+	if !ok {
+		return nil, nil
+	}
+	record := &model.UserPasswordRecord{
+		UserID:     data.UserID,
+		ChangeTime: timeOrZero(data.ChangeTime),
+	}
+	if data.History != "" {
+		if err := json.Unmarshal([]byte(data.History), &record.History); err != nil {
+			return nil, store.Error(err)
+		}
+	}
+	return record, nil
+}
+
+// SaveUserPasswordRecord 保存用户最近使用过的密码以及最近一次修改密码的时间
+func (us *userStore) SaveUserPasswordRecord(record *model.UserPasswordRecord) error {
+	if record.UserID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "save user password record missing user id")
+	}
+	history, err := json.Marshal(record.History)
+	if err != nil {
+		return store.Error(err)
+	}
+	data := &userPasswordRecord{
+		UserID:     record.UserID,
+		History:    string(history),
+		ChangeTime: unixOrZero(record.ChangeTime),
+	}
+	if err := us.handler.SaveValue(tblUserPasswordRecord, record.UserID, data); err != nil {
+		log.Error("[Store][User] save user password record", zap.String("user", record.UserID), zap.Error(err))
+		return store.Error(err)
+	}
+	return nil
+}
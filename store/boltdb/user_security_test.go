@@ -0,0 +1,122 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package boltdb
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/model"
+)
+
+func TestUserStore_LoginLock(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblLoginLock, func(t *testing.T, handler BoltHandler) {
+		us := &userStore{handler: handler}
+		key := model.UserLoginLockKey("user-1")
+
+		saved, err := us.GetLoginLock(key)
+		assert.NoError(t, err)
+		assert.Nil(t, saved)
+
+		now := time.Now()
+		assert.NoError(t, us.SaveLoginLock(&model.LoginLock{Key: key, FailCount: 2, WindowStart: now}))
+		saved, err = us.GetLoginLock(key)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, saved.FailCount)
+		assert.Equal(t, now.Unix(), saved.WindowStart.Unix())
+		assert.True(t, saved.LockedUntil.IsZero())
+		assert.False(t, saved.IsLocked(now))
+
+		until := now.Add(time.Minute)
+		assert.NoError(t, us.SaveLoginLock(&model.LoginLock{Key: key, WindowStart: now, LockedUntil: until}))
+		saved, err = us.GetLoginLock(key)
+		assert.NoError(t, err)
+		assert.Equal(t, until.Unix(), saved.LockedUntil.Unix())
+		assert.True(t, saved.IsLocked(now))
+
+		assert.NoError(t, us.DeleteLoginLock(key))
+		saved, err = us.GetLoginLock(key)
+		assert.NoError(t, err)
+		assert.Nil(t, saved)
+	})
+}
+
+func TestUserStore_IncrLoginFailure(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblLoginLock, func(t *testing.T, handler BoltHandler) {
+		us := &userStore{handler: handler}
+		key := model.IPLoginLockKey("127.0.0.1")
+		now := time.Now()
+		failure := &model.LoginFailure{
+			Key:       key,
+			Time:      now,
+			Threshold: 100,
+			Window:    time.Minute,
+			LockTime:  time.Minute,
+		}
+
+		// 并发记录失败，计数不能丢失
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := us.IncrLoginFailure(failure)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		saved, err := us.GetLoginLock(key)
+		assert.NoError(t, err)
+		assert.Equal(t, 20, saved.FailCount)
+		assert.False(t, saved.IsLocked(now))
+
+		// 达到阈值后锁定并重新计数
+		failure.Threshold = 21
+		lock, err := us.IncrLoginFailure(failure)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, lock.FailCount)
+		assert.True(t, lock.IsLocked(now))
+		saved, err = us.GetLoginLock(key)
+		assert.NoError(t, err)
+		assert.True(t, saved.IsLocked(now))
+	})
+}
+
+func TestUserStore_PasswordRecord(t *testing.T) {
+	CreateTableDBHandlerAndRun(t, tblUserPasswordRecord, func(t *testing.T, handler BoltHandler) {
+		us := &userStore{handler: handler}
+
+		saved, err := us.GetUserPasswordRecord("user-1")
+		assert.NoError(t, err)
+		assert.Nil(t, saved)
+
+		now := time.Now()
+		assert.NoError(t, us.SaveUserPasswordRecord(&model.UserPasswordRecord{
+			UserID:     "user-1",
+			History:    []string{"hash-2", "hash-1"},
+			ChangeTime: now,
+		}))
+		saved, err = us.GetUserPasswordRecord("user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hash-2", "hash-1"}, saved.History)
+		assert.Equal(t, now.Unix(), saved.ChangeTime.Unix())
+	})
+}
@@ -30,6 +30,9 @@ type ConfigFileModuleStore interface {
 	ConfigFileReleaseStore
 	ConfigFileReleaseHistoryStore
 	ConfigFileTemplateStore
+	ConfigFileSchemaStore
+	ConfigReleaseRequestStore
+	ConfigGrayRolloutStore
 }
 
 // ConfigFileGroupStore 配置文件组存储接口
@@ -117,3 +120,42 @@ type ConfigFileTemplateStore interface {
 	// GetConfigFileTemplate get config file template by name
 	GetConfigFileTemplate(name string) (*model.ConfigFileTemplate, error)
 }
+
+// ConfigFileSchemaStore config file schema store
+type ConfigFileSchemaStore interface {
+	// UpsertConfigFileSchema create or update the schema attached to a config group or config file
+	UpsertConfigFileSchema(schema *model.ConfigFileSchema) error
+	// DeleteConfigFileSchema delete the schema attached to a config group or config file
+	DeleteConfigFileSchema(namespace, group, fileName string) error
+	// GetConfigFileSchema get schema by namespace/group/fileName, empty fileName means the group level schema
+	GetConfigFileSchema(namespace, group, fileName string) (*model.ConfigFileSchema, error)
+	// QueryConfigFileSchemas query all schemas in the config group, include group level and file level
+	QueryConfigFileSchemas(namespace, group string) ([]*model.ConfigFileSchema, error)
+}
+
+// ConfigReleaseRequestStore config release request store
+type ConfigReleaseRequestStore interface {
+	// CreateConfigReleaseRequest create config release request
+	CreateConfigReleaseRequest(req *model.ConfigReleaseRequest) error
+	// UpdateConfigReleaseRequest update config release request, only success when the saved revision
+	// equals to expectRevision, otherwise return DataConflictErr
+	UpdateConfigReleaseRequest(req *model.ConfigReleaseRequest, expectRevision uint64) error
+	// GetConfigReleaseRequest get config release request by id
+	GetConfigReleaseRequest(id string) (*model.ConfigReleaseRequest, error)
+	// QueryConfigReleaseRequests query config release requests, order by create time desc
+	QueryConfigReleaseRequests(filter map[string]string, offset, limit uint32) (uint32,
+		[]*model.ConfigReleaseRequest, error)
+}
+
+// ConfigGrayRolloutStore config file percentage gray rollout store
+type ConfigGrayRolloutStore interface {
+	// SaveConfigGrayRollout create or replace the gray rollout of the config file
+	SaveConfigGrayRollout(rollout *model.ConfigGrayRollout) error
+	// UpdateConfigGrayRollout update gray rollout, only success when the saved revision
+	// equals to expectRevision, otherwise return DataConflictErr
+	UpdateConfigGrayRollout(rollout *model.ConfigGrayRollout, expectRevision uint64) error
+	// GetConfigGrayRollout get gray rollout of the config file
+	GetConfigGrayRollout(namespace, group, fileName string) (*model.ConfigGrayRollout, error)
+	// GetConfigGrayRolloutsByStatus get all gray rollouts in the status
+	GetConfigGrayRolloutsByStatus(status string) ([]*model.ConfigGrayRollout, error)
+}
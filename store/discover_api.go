@@ -46,6 +46,8 @@ type NamingModuleStore interface {
 	GroupStore
 	// StrategyStore 鉴权策略接口
 	StrategyStore
+	// APITokenStore 具名 API token 接口
+	APITokenStore
 	// RoutingConfigStoreV2 路由策略 v2 接口
 	RoutingConfigStoreV2
 	// FaultDetectRuleStore fault detect rule interface
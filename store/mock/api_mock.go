@@ -51,6 +51,20 @@ func (mr *MockStoreMockRecorder) ActiveConfigFileReleaseTx(tx, release interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActiveConfigFileReleaseTx", reflect.TypeOf((*MockStore)(nil).ActiveConfigFileReleaseTx), tx, release)
 }
 
+// AddAPIToken mocks base method.
+func (m *MockStore) AddAPIToken(token *model.APIToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIToken", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIToken indicates an expected call of AddAPIToken.
+func (mr *MockStoreMockRecorder) AddAPIToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIToken", reflect.TypeOf((*MockStore)(nil).AddAPIToken), token)
+}
+
 // AddGroup mocks base method.
 func (m *MockStore) AddGroup(group *model.UserGroupDetail) error {
 	m.ctrl.T.Helper()
@@ -107,6 +121,20 @@ func (mr *MockStoreMockRecorder) AddNamespace(namespace interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNamespace", reflect.TypeOf((*MockStore)(nil).AddNamespace), namespace)
 }
 
+// AddOperationRecords mocks base method.
+func (m *MockStore) AddOperationRecords(records []*model.RecordEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOperationRecords", records)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOperationRecords indicates an expected call of AddOperationRecords.
+func (mr *MockStoreMockRecorder) AddOperationRecords(records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOperationRecords", reflect.TypeOf((*MockStore)(nil).AddOperationRecords), records)
+}
+
 // AddService mocks base method.
 func (m *MockStore) AddService(service *model.Service) error {
 	m.ctrl.T.Helper()
@@ -435,6 +463,20 @@ func (mr *MockStoreMockRecorder) CleanInstance(instanceID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanInstance", reflect.TypeOf((*MockStore)(nil).CleanInstance), instanceID)
 }
 
+// CleanOperationRecords mocks base method.
+func (m *MockStore) CleanOperationRecords(endTime time.Time, limit uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanOperationRecords", endTime, limit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CleanOperationRecords indicates an expected call of CleanOperationRecords.
+func (mr *MockStoreMockRecorder) CleanOperationRecords(endTime, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanOperationRecords", reflect.TypeOf((*MockStore)(nil).CleanOperationRecords), endTime, limit)
+}
+
 // CountConfigFileEachGroup mocks base method.
 func (m *MockStore) CountConfigFileEachGroup() (map[string]map[string]int64, error) {
 	m.ctrl.T.Helper()
@@ -495,6 +537,21 @@ func (mr *MockStoreMockRecorder) CountConfigReleases(namespace, group, onlyActiv
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountConfigReleases", reflect.TypeOf((*MockStore)(nil).CountConfigReleases), namespace, group, onlyActive)
 }
 
+// CreateCARootIfAbsent mocks base method.
+func (m *MockStore) CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCARootIfAbsent", root)
+	ret0, _ := ret[0].(*model.CARoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCARootIfAbsent indicates an expected call of CreateCARootIfAbsent.
+func (mr *MockStoreMockRecorder) CreateCARootIfAbsent(root interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCARootIfAbsent", reflect.TypeOf((*MockStore)(nil).CreateCARootIfAbsent), root)
+}
+
 // CreateCircuitBreakerRule mocks base method.
 func (m *MockStore) CreateCircuitBreakerRule(cbRule *model.CircuitBreakerRule) error {
 	m.ctrl.T.Helper()
@@ -581,6 +638,20 @@ func (mr *MockStoreMockRecorder) CreateConfigFileTx(tx, file interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConfigFileTx", reflect.TypeOf((*MockStore)(nil).CreateConfigFileTx), tx, file)
 }
 
+// CreateConfigReleaseRequest mocks base method.
+func (m *MockStore) CreateConfigReleaseRequest(req *model.ConfigReleaseRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateConfigReleaseRequest", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateConfigReleaseRequest indicates an expected call of CreateConfigReleaseRequest.
+func (mr *MockStoreMockRecorder) CreateConfigReleaseRequest(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConfigReleaseRequest", reflect.TypeOf((*MockStore)(nil).CreateConfigReleaseRequest), req)
+}
+
 // CreateFaultDetectRule mocks base method.
 func (m *MockStore) CreateFaultDetectRule(conf *model.FaultDetectRule) error {
 	m.ctrl.T.Helper()
@@ -694,6 +765,20 @@ func (mr *MockStoreMockRecorder) CreateTransaction() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransaction", reflect.TypeOf((*MockStore)(nil).CreateTransaction))
 }
 
+// DeleteAPIToken mocks base method.
+func (m *MockStore) DeleteAPIToken(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIToken", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIToken indicates an expected call of DeleteAPIToken.
+func (mr *MockStoreMockRecorder) DeleteAPIToken(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIToken", reflect.TypeOf((*MockStore)(nil).DeleteAPIToken), id)
+}
+
 // DeleteCircuitBreakerRule mocks base method.
 func (m *MockStore) DeleteCircuitBreakerRule(id string) error {
 	m.ctrl.T.Helper()
@@ -736,6 +821,20 @@ func (mr *MockStoreMockRecorder) DeleteConfigFileReleaseTx(tx, data interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConfigFileReleaseTx", reflect.TypeOf((*MockStore)(nil).DeleteConfigFileReleaseTx), tx, data)
 }
 
+// DeleteConfigFileSchema mocks base method.
+func (m *MockStore) DeleteConfigFileSchema(namespace, group, fileName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConfigFileSchema", namespace, group, fileName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConfigFileSchema indicates an expected call of DeleteConfigFileSchema.
+func (mr *MockStoreMockRecorder) DeleteConfigFileSchema(namespace, group, fileName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConfigFileSchema", reflect.TypeOf((*MockStore)(nil).DeleteConfigFileSchema), namespace, group, fileName)
+}
+
 // DeleteConfigFileTx mocks base method.
 func (m *MockStore) DeleteConfigFileTx(tx store.Tx, namespace, group, name string) error {
 	m.ctrl.T.Helper()
@@ -806,6 +905,20 @@ func (mr *MockStoreMockRecorder) DeleteLaneGroup(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLaneGroup", reflect.TypeOf((*MockStore)(nil).DeleteLaneGroup), id)
 }
 
+// DeleteLoginLock mocks base method.
+func (m *MockStore) DeleteLoginLock(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginLock", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginLock indicates an expected call of DeleteLoginLock.
+func (mr *MockStoreMockRecorder) DeleteLoginLock(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginLock", reflect.TypeOf((*MockStore)(nil).DeleteLoginLock), key)
+}
+
 // DeleteRateLimit mocks base method.
 func (m *MockStore) DeleteRateLimit(limiting *model.RateLimit) error {
 	m.ctrl.T.Helper()
@@ -1017,6 +1130,52 @@ func (mr *MockStoreMockRecorder) GenNextL5Sid(layoutID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenNextL5Sid", reflect.TypeOf((*MockStore)(nil).GenNextL5Sid), layoutID)
 }
 
+// GetAPIToken mocks base method.
+func (m *MockStore) GetAPIToken(id string) (*model.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIToken", id)
+	ret0, _ := ret[0].(*model.APIToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIToken indicates an expected call of GetAPIToken.
+func (mr *MockStoreMockRecorder) GetAPIToken(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIToken", reflect.TypeOf((*MockStore)(nil).GetAPIToken), id)
+}
+
+// GetAPITokens mocks base method.
+func (m *MockStore) GetAPITokens(filters map[string]string, offset uint32, limit uint32) (uint32, []*model.APIToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPITokens", filters, offset, limit)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].([]*model.APIToken)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAPITokens indicates an expected call of GetAPITokens.
+func (mr *MockStoreMockRecorder) GetAPITokens(filters, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPITokens", reflect.TypeOf((*MockStore)(nil).GetAPITokens), filters, offset, limit)
+}
+
+// GetCARoot mocks base method.
+func (m *MockStore) GetCARoot(trustDomain string) (*model.CARoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCARoot", trustDomain)
+	ret0, _ := ret[0].(*model.CARoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCARoot indicates an expected call of GetCARoot.
+func (mr *MockStoreMockRecorder) GetCARoot(trustDomain interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCARoot", reflect.TypeOf((*MockStore)(nil).GetCARoot), trustDomain)
+}
+
 // GetCircuitBreakerRules mocks base method.
 func (m *MockStore) GetCircuitBreakerRules(filter map[string]string, offset, limit uint32) (uint32, []*model.CircuitBreakerRule, error) {
 	m.ctrl.T.Helper()
@@ -1153,6 +1312,21 @@ func (mr *MockStoreMockRecorder) GetConfigFileReleaseTx(tx, req interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigFileReleaseTx", reflect.TypeOf((*MockStore)(nil).GetConfigFileReleaseTx), tx, req)
 }
 
+// GetConfigFileSchema mocks base method.
+func (m *MockStore) GetConfigFileSchema(namespace, group, fileName string) (*model.ConfigFileSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigFileSchema", namespace, group, fileName)
+	ret0, _ := ret[0].(*model.ConfigFileSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigFileSchema indicates an expected call of GetConfigFileSchema.
+func (mr *MockStoreMockRecorder) GetConfigFileSchema(namespace, group, fileName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigFileSchema", reflect.TypeOf((*MockStore)(nil).GetConfigFileSchema), namespace, group, fileName)
+}
+
 // GetConfigFileTemplate mocks base method.
 func (m *MockStore) GetConfigFileTemplate(name string) (*model.ConfigFileTemplate, error) {
 	m.ctrl.T.Helper()
@@ -1183,6 +1357,51 @@ func (mr *MockStoreMockRecorder) GetConfigFileTx(tx, namespace, group, name inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigFileTx", reflect.TypeOf((*MockStore)(nil).GetConfigFileTx), tx, namespace, group, name)
 }
 
+// GetConfigGrayRollout mocks base method.
+func (m *MockStore) GetConfigGrayRollout(namespace, group, fileName string) (*model.ConfigGrayRollout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigGrayRollout", namespace, group, fileName)
+	ret0, _ := ret[0].(*model.ConfigGrayRollout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigGrayRollout indicates an expected call of GetConfigGrayRollout.
+func (mr *MockStoreMockRecorder) GetConfigGrayRollout(namespace, group, fileName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigGrayRollout", reflect.TypeOf((*MockStore)(nil).GetConfigGrayRollout), namespace, group, fileName)
+}
+
+// GetConfigGrayRolloutsByStatus mocks base method.
+func (m *MockStore) GetConfigGrayRolloutsByStatus(status string) ([]*model.ConfigGrayRollout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigGrayRolloutsByStatus", status)
+	ret0, _ := ret[0].([]*model.ConfigGrayRollout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigGrayRolloutsByStatus indicates an expected call of GetConfigGrayRolloutsByStatus.
+func (mr *MockStoreMockRecorder) GetConfigGrayRolloutsByStatus(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigGrayRolloutsByStatus", reflect.TypeOf((*MockStore)(nil).GetConfigGrayRolloutsByStatus), status)
+}
+
+// GetConfigReleaseRequest mocks base method.
+func (m *MockStore) GetConfigReleaseRequest(id string) (*model.ConfigReleaseRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigReleaseRequest", id)
+	ret0, _ := ret[0].(*model.ConfigReleaseRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigReleaseRequest indicates an expected call of GetConfigReleaseRequest.
+func (mr *MockStoreMockRecorder) GetConfigReleaseRequest(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigReleaseRequest", reflect.TypeOf((*MockStore)(nil).GetConfigReleaseRequest), id)
+}
+
 // GetDefaultStrategyDetailByPrincipal mocks base method.
 func (m *MockStore) GetDefaultStrategyDetailByPrincipal(principalId string, principalType model.PrincipalType) (*model.StrategyDetail, error) {
 	m.ctrl.T.Helper()
@@ -1322,6 +1541,21 @@ func (mr *MockStoreMockRecorder) GetGroupsForCache(mtime, firstUpdate interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupsForCache", reflect.TypeOf((*MockStore)(nil).GetGroupsForCache), mtime, firstUpdate)
 }
 
+// GetHealthCheckProtection mocks base method.
+func (m *MockStore) GetHealthCheckProtection() (*model.HealthCheckProtection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHealthCheckProtection")
+	ret0, _ := ret[0].(*model.HealthCheckProtection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHealthCheckProtection indicates an expected call of GetHealthCheckProtection.
+func (mr *MockStoreMockRecorder) GetHealthCheckProtection() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHealthCheckProtection", reflect.TypeOf((*MockStore)(nil).GetHealthCheckProtection))
+}
+
 // GetInstance mocks base method.
 func (m *MockStore) GetInstance(instanceID string) (*model.Instance, error) {
 	m.ctrl.T.Helper()
@@ -1489,6 +1723,21 @@ func (mr *MockStoreMockRecorder) GetLaneRuleMaxPriority() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLaneRuleMaxPriority", reflect.TypeOf((*MockStore)(nil).GetLaneRuleMaxPriority))
 }
 
+// GetLoginLock mocks base method.
+func (m *MockStore) GetLoginLock(key string) (*model.LoginLock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginLock", key)
+	ret0, _ := ret[0].(*model.LoginLock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginLock indicates an expected call of GetLoginLock.
+func (mr *MockStoreMockRecorder) GetLoginLock(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLock", reflect.TypeOf((*MockStore)(nil).GetLoginLock), key)
+}
+
 // GetMoreClients mocks base method.
 func (m *MockStore) GetMoreClients(mtime time.Time, firstUpdate bool) (map[string]*model.Client, error) {
 	m.ctrl.T.Helper()
@@ -1730,6 +1979,22 @@ func (mr *MockStoreMockRecorder) GetNamespaces(filter, offset, limit interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamespaces", reflect.TypeOf((*MockStore)(nil).GetNamespaces), filter, offset, limit)
 }
 
+// GetOperationRecords mocks base method.
+func (m *MockStore) GetOperationRecords(filter map[string]string, offset, limit uint32) (uint32, []*model.RecordEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperationRecords", filter, offset, limit)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].([]*model.RecordEntry)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetOperationRecords indicates an expected call of GetOperationRecords.
+func (mr *MockStoreMockRecorder) GetOperationRecords(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperationRecords", reflect.TypeOf((*MockStore)(nil).GetOperationRecords), filter, offset, limit)
+}
+
 // GetRateLimitWithID mocks base method.
 func (m *MockStore) GetRateLimitWithID(id string) (*model.RateLimit, error) {
 	m.ctrl.T.Helper()
@@ -2170,6 +2435,21 @@ func (mr *MockStoreMockRecorder) GetUserByName(name, ownerId interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByName", reflect.TypeOf((*MockStore)(nil).GetUserByName), name, ownerId)
 }
 
+// GetUserPasswordRecord mocks base method.
+func (m *MockStore) GetUserPasswordRecord(userID string) (*model.UserPasswordRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPasswordRecord", userID)
+	ret0, _ := ret[0].(*model.UserPasswordRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPasswordRecord indicates an expected call of GetUserPasswordRecord.
+func (mr *MockStoreMockRecorder) GetUserPasswordRecord(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPasswordRecord", reflect.TypeOf((*MockStore)(nil).GetUserPasswordRecord), userID)
+}
+
 // GetUsers mocks base method.
 func (m *MockStore) GetUsers(filters map[string]string, offset, limit uint32) (uint32, []*model.User, error) {
 	m.ctrl.T.Helper()
@@ -2305,6 +2585,21 @@ func (mr *MockStoreMockRecorder) InactiveConfigFileReleaseTx(tx, release interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InactiveConfigFileReleaseTx", reflect.TypeOf((*MockStore)(nil).InactiveConfigFileReleaseTx), tx, release)
 }
 
+// IncrLoginFailure mocks base method.
+func (m *MockStore) IncrLoginFailure(failure *model.LoginFailure) (*model.LoginLock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrLoginFailure", failure)
+	ret0, _ := ret[0].(*model.LoginLock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrLoginFailure indicates an expected call of IncrLoginFailure.
+func (mr *MockStoreMockRecorder) IncrLoginFailure(failure interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrLoginFailure", reflect.TypeOf((*MockStore)(nil).IncrLoginFailure), failure)
+}
+
 // Initialize mocks base method.
 func (m *MockStore) Initialize(c *store.Config) error {
 	m.ctrl.T.Helper()
@@ -2452,6 +2747,21 @@ func (mr *MockStoreMockRecorder) QueryConfigFileReleaseHistories(filter, offset,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryConfigFileReleaseHistories", reflect.TypeOf((*MockStore)(nil).QueryConfigFileReleaseHistories), filter, offset, limit)
 }
 
+// QueryConfigFileSchemas mocks base method.
+func (m *MockStore) QueryConfigFileSchemas(namespace, group string) ([]*model.ConfigFileSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryConfigFileSchemas", namespace, group)
+	ret0, _ := ret[0].([]*model.ConfigFileSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryConfigFileSchemas indicates an expected call of QueryConfigFileSchemas.
+func (mr *MockStoreMockRecorder) QueryConfigFileSchemas(namespace, group interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryConfigFileSchemas", reflect.TypeOf((*MockStore)(nil).QueryConfigFileSchemas), namespace, group)
+}
+
 // QueryConfigFiles mocks base method.
 func (m *MockStore) QueryConfigFiles(filter map[string]string, offset, limit uint32) (uint32, []*model.ConfigFile, error) {
 	m.ctrl.T.Helper()
@@ -2468,6 +2778,22 @@ func (mr *MockStoreMockRecorder) QueryConfigFiles(filter, offset, limit interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryConfigFiles", reflect.TypeOf((*MockStore)(nil).QueryConfigFiles), filter, offset, limit)
 }
 
+// QueryConfigReleaseRequests mocks base method.
+func (m *MockStore) QueryConfigReleaseRequests(filter map[string]string, offset, limit uint32) (uint32, []*model.ConfigReleaseRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryConfigReleaseRequests", filter, offset, limit)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].([]*model.ConfigReleaseRequest)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// QueryConfigReleaseRequests indicates an expected call of QueryConfigReleaseRequests.
+func (mr *MockStoreMockRecorder) QueryConfigReleaseRequests(filter, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryConfigReleaseRequests", reflect.TypeOf((*MockStore)(nil).QueryConfigReleaseRequests), filter, offset, limit)
+}
+
 // ReleaseLeaderElection mocks base method.
 func (m *MockStore) ReleaseLeaderElection(key string) error {
 	m.ctrl.T.Helper()
@@ -2496,6 +2822,77 @@ func (mr *MockStoreMockRecorder) RemoveStrategyResources(resources interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveStrategyResources", reflect.TypeOf((*MockStore)(nil).RemoveStrategyResources), resources)
 }
 
+// RotateCARoot mocks base method.
+func (m *MockStore) RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateCARoot", root, prevCertPEM)
+	ret0, _ := ret[0].(*model.CARoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateCARoot indicates an expected call of RotateCARoot.
+func (mr *MockStoreMockRecorder) RotateCARoot(root, prevCertPEM interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateCARoot", reflect.TypeOf((*MockStore)(nil).RotateCARoot), root, prevCertPEM)
+}
+
+// SaveConfigGrayRollout mocks base method.
+func (m *MockStore) SaveConfigGrayRollout(rollout *model.ConfigGrayRollout) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConfigGrayRollout", rollout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConfigGrayRollout indicates an expected call of SaveConfigGrayRollout.
+func (mr *MockStoreMockRecorder) SaveConfigGrayRollout(rollout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConfigGrayRollout", reflect.TypeOf((*MockStore)(nil).SaveConfigGrayRollout), rollout)
+}
+
+// SaveLoginLock mocks base method.
+func (m *MockStore) SaveLoginLock(lock *model.LoginLock) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginLock", lock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLoginLock indicates an expected call of SaveLoginLock.
+func (mr *MockStoreMockRecorder) SaveLoginLock(lock interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginLock", reflect.TypeOf((*MockStore)(nil).SaveLoginLock), lock)
+}
+
+// SaveUserPasswordRecord mocks base method.
+func (m *MockStore) SaveUserPasswordRecord(record *model.UserPasswordRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUserPasswordRecord", record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUserPasswordRecord indicates an expected call of SaveUserPasswordRecord.
+func (mr *MockStoreMockRecorder) SaveUserPasswordRecord(record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserPasswordRecord", reflect.TypeOf((*MockStore)(nil).SaveUserPasswordRecord), record)
+}
+
+// SetHealthCheckProtection mocks base method.
+func (m *MockStore) SetHealthCheckProtection(protection *model.HealthCheckProtection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHealthCheckProtection", protection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHealthCheckProtection indicates an expected call of SetHealthCheckProtection.
+func (mr *MockStoreMockRecorder) SetHealthCheckProtection(protection interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHealthCheckProtection", reflect.TypeOf((*MockStore)(nil).SetHealthCheckProtection), protection)
+}
+
 // SetInstanceHealthStatus mocks base method.
 func (m *MockStore) SetInstanceHealthStatus(instanceID string, flag int, revision string) error {
 	m.ctrl.T.Helper()
@@ -2569,6 +2966,20 @@ func (mr *MockStoreMockRecorder) StartTx() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTx", reflect.TypeOf((*MockStore)(nil).StartTx))
 }
 
+// UpdateAPITokenLastUsed mocks base method.
+func (m *MockStore) UpdateAPITokenLastUsed(id string, lastUsed time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAPITokenLastUsed", id, lastUsed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPITokenLastUsed indicates an expected call of UpdateAPITokenLastUsed.
+func (mr *MockStoreMockRecorder) UpdateAPITokenLastUsed(id, lastUsed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAPITokenLastUsed", reflect.TypeOf((*MockStore)(nil).UpdateAPITokenLastUsed), id, lastUsed)
+}
+
 // UpdateCircuitBreakerRule mocks base method.
 func (m *MockStore) UpdateCircuitBreakerRule(cbRule *model.CircuitBreakerRule) error {
 	m.ctrl.T.Helper()
@@ -2611,6 +3022,34 @@ func (mr *MockStoreMockRecorder) UpdateConfigFileTx(tx, file interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfigFileTx", reflect.TypeOf((*MockStore)(nil).UpdateConfigFileTx), tx, file)
 }
 
+// UpdateConfigGrayRollout mocks base method.
+func (m *MockStore) UpdateConfigGrayRollout(rollout *model.ConfigGrayRollout, expectRevision uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfigGrayRollout", rollout, expectRevision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConfigGrayRollout indicates an expected call of UpdateConfigGrayRollout.
+func (mr *MockStoreMockRecorder) UpdateConfigGrayRollout(rollout, expectRevision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfigGrayRollout", reflect.TypeOf((*MockStore)(nil).UpdateConfigGrayRollout), rollout, expectRevision)
+}
+
+// UpdateConfigReleaseRequest mocks base method.
+func (m *MockStore) UpdateConfigReleaseRequest(req *model.ConfigReleaseRequest, expectRevision uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateConfigReleaseRequest", req, expectRevision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateConfigReleaseRequest indicates an expected call of UpdateConfigReleaseRequest.
+func (mr *MockStoreMockRecorder) UpdateConfigReleaseRequest(req, expectRevision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfigReleaseRequest", reflect.TypeOf((*MockStore)(nil).UpdateConfigReleaseRequest), req, expectRevision)
+}
+
 // UpdateFaultDetectRule mocks base method.
 func (m *MockStore) UpdateFaultDetectRule(conf *model.FaultDetectRule) error {
 	m.ctrl.T.Helper()
@@ -2821,6 +3260,20 @@ func (mr *MockStoreMockRecorder) UpdateStrategy(strategy interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStrategy", reflect.TypeOf((*MockStore)(nil).UpdateStrategy), strategy)
 }
 
+// UpdateStrategyConditions mocks base method.
+func (m *MockStore) UpdateStrategyConditions(id string, conditions *model.StrategyConditions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStrategyConditions", id, conditions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStrategyConditions indicates an expected call of UpdateStrategyConditions.
+func (mr *MockStoreMockRecorder) UpdateStrategyConditions(id, conditions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStrategyConditions", reflect.TypeOf((*MockStore)(nil).UpdateStrategyConditions), id, conditions)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStore) UpdateUser(user *model.User) error {
 	m.ctrl.T.Helper()
@@ -2835,6 +3288,20 @@ func (mr *MockStoreMockRecorder) UpdateUser(user interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), user)
 }
 
+// UpsertConfigFileSchema mocks base method.
+func (m *MockStore) UpsertConfigFileSchema(schema *model.ConfigFileSchema) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertConfigFileSchema", schema)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertConfigFileSchema indicates an expected call of UpsertConfigFileSchema.
+func (mr *MockStoreMockRecorder) UpsertConfigFileSchema(schema interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertConfigFileSchema", reflect.TypeOf((*MockStore)(nil).UpsertConfigFileSchema), schema)
+}
+
 // MockNamespaceStore is a mock of NamespaceStore interface.
 type MockNamespaceStore struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,193 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type apiTokenStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// AddAPIToken create an api token
+func (as *apiTokenStore) AddAPIToken(token *model.APIToken) error {
+	namespaces, resourceTypes, actions, err := marshalAPITokenScope(token)
+	if err != nil {
+		return store.Error(err)
+	}
+	insertSql := `
+	INSERT INTO api_token (id, name, principal_type, principal_id, owner, token_hash, namespaces
+		, resource_types, actions, expire_time, last_used_time, comment, flag, create_time, create_by
+		, modify_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?
+		, ?, ?, ?, 0, ?, 0, sysdate(), ?
+		, sysdate())
+	`
+	_, err = as.master.Exec(insertSql, token.ID, token.Name, int(token.PrincipalType), token.PrincipalID,
+		token.Owner, token.TokenHash, namespaces, resourceTypes, actions, unixOrZero(token.ExpireTime),
+		token.Comment, token.CreateBy)
+	return store.Error(err)
+}
+
+// DeleteAPIToken revoke an api token
+func (as *apiTokenStore) DeleteAPIToken(id string) error {
+	_, err := as.master.Exec("UPDATE api_token SET flag = 1, modify_time = sysdate() WHERE id = ?", id)
+	return store.Error(err)
+}
+
+// UpdateAPITokenLastUsed update the last used time of an api token
+func (as *apiTokenStore) UpdateAPITokenLastUsed(id string, lastUsed time.Time) error {
+	_, err := as.master.Exec("UPDATE api_token SET last_used_time = ? WHERE id = ? AND flag = 0",
+		lastUsed.Unix(), id)
+	return store.Error(err)
+}
+
+// GetAPIToken get a valid api token by id
+func (as *apiTokenStore) GetAPIToken(id string) (*model.APIToken, error) {
+	rows, err := as.master.Query(as.baseSelectSql()+" WHERE flag = 0 AND id = ?", id)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	ret, err := as.transferRows(rows)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret[0], nil
+}
+
+// GetAPITokens query valid api tokens, order by create time desc
+func (as *apiTokenStore) GetAPITokens(filters map[string]string, offset uint32,
+	limit uint32) (uint32, []*model.APIToken, error) {
+	columns := []string{"principal_type", "principal_id", "owner", "name"}
+	conditions := " WHERE flag = 0"
+	args := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		if val := filters[column]; val != "" {
+			conditions += " AND " + column + " = ?"
+			args = append(args, val)
+		}
+	}
+
+	var total uint32
+	if err := as.master.QueryRow("SELECT COUNT(*) FROM api_token"+conditions, args...).Scan(&total); err != nil {
+		return 0, nil, store.Error(err)
+	}
+	querySql := as.baseSelectSql() + conditions + " ORDER BY create_time DESC, id DESC LIMIT ?, ?"
+	rows, err := as.master.Query(querySql, append(args, offset, limit)...)
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	ret, err := as.transferRows(rows)
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	return total, ret, nil
+}
+
+func (as *apiTokenStore) baseSelectSql() string {
+	return `
+SELECT id, name, principal_type, principal_id, owner, token_hash
+	, IFNULL(namespaces, ''), IFNULL(resource_types, ''), IFNULL(actions, ''), expire_time, last_used_time
+	, IFNULL(comment, '')
+	, UNIX_TIMESTAMP(create_time)
+	, IFNULL(create_by, '')
+	, UNIX_TIMESTAMP(modify_time)
+FROM api_token
+	`
+}
+
+func (as *apiTokenStore) transferRows(rows *sql.Rows) ([]*model.APIToken, error) {
+	if rows == nil {
+		return nil, nil
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	ret := make([]*model.APIToken, 0, 4)
+	for rows.Next() {
+		token := &model.APIToken{Valid: true}
+		var (
+			principalType                          int
+			namespaces, resourceTypes, actions     string
+			expireTime, lastUsedTime, ctime, mtime int64
+		)
+		err := rows.Scan(&token.ID, &token.Name, &principalType, &token.PrincipalID, &token.Owner,
+			&token.TokenHash, &namespaces, &resourceTypes, &actions, &expireTime, &lastUsedTime,
+			&token.Comment, &ctime, &token.CreateBy, &mtime)
+		if err != nil {
+			return nil, err
+		}
+		token.PrincipalType = model.PrincipalType(principalType)
+		if expireTime > 0 {
+			token.ExpireTime = time.Unix(expireTime, 0)
+		}
+		if lastUsedTime > 0 {
+			token.LastUsedTime = time.Unix(lastUsedTime, 0)
+		}
+		token.CreateTime = time.Unix(ctime, 0)
+		token.ModifyTime = time.Unix(mtime, 0)
+		if err := unmarshalAPITokenScope(token, namespaces, resourceTypes, actions); err != nil {
+			return nil, err
+		}
+		ret = append(ret, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func marshalAPITokenScope(token *model.APIToken) (string, string, string, error) {
+	ret := make([]string, 0, 3)
+	for _, item := range [][]string{token.Namespaces, token.ResourceTypes, token.Actions} {
+		if item == nil {
+			item = []string{}
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return "", "", "", err
+		}
+		ret = append(ret, string(data))
+	}
+	return ret[0], ret[1], ret[2], nil
+}
+
+func unmarshalAPITokenScope(token *model.APIToken, namespaces, resourceTypes, actions string) error {
+	vals := []string{namespaces, resourceTypes, actions}
+	targets := []*[]string{&token.Namespaces, &token.ResourceTypes, &token.Actions}
+	for i := range vals {
+		if vals[i] == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(vals[i]), targets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type caRootStore struct {
+	master *BaseDB
+}
+
+// CreateCARootIfAbsent save the ca root of the trust domain if absent, return the effective one
+func (cs *caRootStore) CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error) {
+	insertSql := "INSERT IGNORE INTO ca_root (trust_domain, cert, private_key, key_crypto_algo, prev_cert, " +
+		"ctime, mtime) VALUES (?, ?, ?, ?, '', sysdate(), sysdate())"
+	if _, err := cs.master.Exec(insertSql, root.TrustDomain, root.CertPEM, root.KeyPEM,
+		root.KeyCryptoAlgo); err != nil {
+		return nil, store.Error(err)
+	}
+	ret, err := cs.GetCARoot(root.TrustDomain)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, store.NewStatusError(store.NotFoundResource, "ca root not found")
+	}
+	return ret, nil
+}
+
+// GetCARoot get the effective ca root of the trust domain, return nil if absent
+func (cs *caRootStore) GetCARoot(trustDomain string) (*model.CARoot, error) {
+	var ctime, mtime int64
+	ret := &model.CARoot{}
+	querySql := "SELECT trust_domain, cert, private_key, key_crypto_algo, prev_cert, UNIX_TIMESTAMP(ctime), " +
+		"UNIX_TIMESTAMP(mtime) FROM ca_root WHERE trust_domain = ?"
+	err := cs.master.QueryRow(querySql, trustDomain).Scan(&ret.TrustDomain, &ret.CertPEM, &ret.KeyPEM,
+		&ret.KeyCryptoAlgo, &ret.PrevCertPEM, &ctime, &mtime)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, store.Error(err)
+	}
+	ret.CreateTime = time.Unix(ctime, 0)
+	ret.ModifyTime = time.Unix(mtime, 0)
+	return ret, nil
+}
+
+// RotateCARoot replace the ca root only when the current one is prevCertPEM, return the effective one
+func (cs *caRootStore) RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error) {
+	updateSql := "UPDATE ca_root SET cert = ?, private_key = ?, key_crypto_algo = ?, prev_cert = ?, " +
+		"mtime = sysdate() WHERE trust_domain = ? AND cert = ?"
+	if _, err := cs.master.Exec(updateSql, root.CertPEM, root.KeyPEM, root.KeyCryptoAlgo, prevCertPEM,
+		root.TrustDomain, prevCertPEM); err != nil {
+		return nil, store.Error(err)
+	}
+	ret, err := cs.GetCARoot(root.TrustDomain)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, store.NewStatusError(store.NotFoundResource, "ca root not found")
+	}
+	return ret, nil
+}
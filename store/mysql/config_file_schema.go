@@ -0,0 +1,123 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package sqldb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type configFileSchemaStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// UpsertConfigFileSchema create or update config file schema
+func (cs *configFileSchemaStore) UpsertConfigFileSchema(schema *model.ConfigFileSchema) error {
+	upsertSql := `
+	INSERT INTO config_file_schema (namespace, ` + "`group`" + `, file_name, type, ` + "`schema`" + `, comment
+		, create_time, create_by, modify_time, modify_by)
+	VALUES (?, ?, ?, ?, ?, ?
+		, sysdate(), ?, sysdate(), ?)
+	ON DUPLICATE KEY UPDATE type = VALUES(type), ` + "`schema`" + ` = VALUES(` + "`schema`" + `)
+		, comment = VALUES(comment), modify_time = sysdate(), modify_by = VALUES(modify_by)
+	`
+	_, err := cs.master.Exec(upsertSql, schema.Namespace, schema.Group, schema.FileName, schema.Type,
+		schema.Schema, schema.Comment, schema.CreateBy, schema.ModifyBy)
+	return store.Error(err)
+}
+
+// DeleteConfigFileSchema delete config file schema
+func (cs *configFileSchemaStore) DeleteConfigFileSchema(namespace, group, fileName string) error {
+	deleteSql := "DELETE FROM config_file_schema WHERE namespace = ? AND `group` = ? AND file_name = ?"
+	_, err := cs.master.Exec(deleteSql, namespace, group, fileName)
+	return store.Error(err)
+}
+
+// GetConfigFileSchema get config file schema, empty fileName means the group level schema
+func (cs *configFileSchemaStore) GetConfigFileSchema(namespace, group,
+	fileName string) (*model.ConfigFileSchema, error) {
+	querySql := cs.baseSelectConfigFileSchemaSql() + " WHERE namespace = ? AND `group` = ? AND file_name = ?"
+	rows, err := cs.master.Query(querySql, namespace, group, fileName)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+
+	schemas, err := cs.transferRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(schemas) > 0 {
+		return schemas[0], nil
+	}
+	return nil, nil
+}
+
+// QueryConfigFileSchemas query all config file schemas in the config group
+func (cs *configFileSchemaStore) QueryConfigFileSchemas(namespace,
+	group string) ([]*model.ConfigFileSchema, error) {
+	querySql := cs.baseSelectConfigFileSchemaSql() + " WHERE namespace = ? AND `group` = ? ORDER BY file_name"
+	rows, err := cs.master.Query(querySql, namespace, group)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return cs.transferRows(rows)
+}
+
+func (cs *configFileSchemaStore) baseSelectConfigFileSchemaSql() string {
+	return `
+SELECT id, namespace, ` + "`group`" + `, file_name, type, ` + "`schema`" + `
+	, IFNULL(comment, '')
+	, UNIX_TIMESTAMP(create_time)
+	, IFNULL(create_by, '')
+	, UNIX_TIMESTAMP(modify_time)
+	, IFNULL(modify_by, '')
+FROM config_file_schema 
+	`
+}
+
+func (cs *configFileSchemaStore) transferRows(rows *sql.Rows) ([]*model.ConfigFileSchema, error) {
+	if rows == nil {
+		return nil, nil
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	schemas := make([]*model.ConfigFileSchema, 0, 4)
+	for rows.Next() {
+		schema := &model.ConfigFileSchema{}
+		var ctime, mtime int64
+		err := rows.Scan(&schema.Id, &schema.Namespace, &schema.Group, &schema.FileName, &schema.Type,
+			&schema.Schema, &schema.Comment, &ctime, &schema.CreateBy, &mtime, &schema.ModifyBy)
+		if err != nil {
+			return nil, err
+		}
+		schema.CreateTime = time.Unix(ctime, 0)
+		schema.ModifyTime = time.Unix(mtime, 0)
+
+		schemas = append(schemas, schema)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
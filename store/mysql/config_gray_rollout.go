@@ -0,0 +1,188 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type configGrayRolloutStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// SaveConfigGrayRollout create or replace the gray rollout of the config file
+func (cs *configGrayRolloutStore) SaveConfigGrayRollout(rollout *model.ConfigGrayRollout) error {
+	steps, errorClients, err := marshalGrayRolloutSteps(rollout)
+	if err != nil {
+		return store.Error(err)
+	}
+	insertSql := `
+	INSERT INTO config_gray_rollout (namespace, ` + "`group`" + `, file_name, release_name, release_description
+		, comment, steps, current_step, step_time, error_threshold, error_clients, status, message, revision
+		, create_time, create_by, modify_time, modify_by)
+	VALUES (?, ?, ?, ?, ?
+		, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		, sysdate(), ?, sysdate(), ?)
+	ON DUPLICATE KEY UPDATE release_name = VALUES(release_name), release_description = VALUES(release_description)
+		, comment = VALUES(comment), steps = VALUES(steps), current_step = VALUES(current_step)
+		, step_time = VALUES(step_time), error_threshold = VALUES(error_threshold)
+		, error_clients = VALUES(error_clients), status = VALUES(status), message = VALUES(message)
+		, revision = VALUES(revision), create_time = sysdate(), create_by = VALUES(create_by)
+		, modify_time = sysdate(), modify_by = VALUES(modify_by)
+	`
+	_, err = cs.master.Exec(insertSql, rollout.Namespace, rollout.Group, rollout.FileName, rollout.ReleaseName,
+		rollout.ReleaseDescription, rollout.Comment, steps, rollout.CurrentStep, unixOrZero(rollout.StepTime),
+		rollout.ErrorThreshold, errorClients, rollout.Status, rollout.Message, rollout.Revision,
+		rollout.CreateBy, rollout.ModifyBy)
+	return store.Error(err)
+}
+
+// UpdateConfigGrayRollout update gray rollout with optimistic lock
+func (cs *configGrayRolloutStore) UpdateConfigGrayRollout(rollout *model.ConfigGrayRollout,
+	expectRevision uint64) error {
+	steps, errorClients, err := marshalGrayRolloutSteps(rollout)
+	if err != nil {
+		return store.Error(err)
+	}
+	updateSql := `
+	UPDATE config_gray_rollout SET release_name = ?, steps = ?, current_step = ?, step_time = ?
+		, error_threshold = ?, error_clients = ?, status = ?, message = ?, revision = ?
+		, modify_time = sysdate(), modify_by = ?
+	WHERE namespace = ? AND ` + "`group`" + ` = ? AND file_name = ? AND revision = ?
+	`
+	result, err := cs.master.Exec(updateSql, rollout.ReleaseName, steps, rollout.CurrentStep,
+		unixOrZero(rollout.StepTime), rollout.ErrorThreshold, errorClients, rollout.Status, rollout.Message,
+		rollout.Revision, rollout.ModifyBy, rollout.Namespace, rollout.Group, rollout.FileName, expectRevision)
+	if err != nil {
+		return store.Error(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return store.Error(err)
+	}
+	if rows == 0 {
+		return store.NewStatusError(store.DataConflictErr,
+			fmt.Sprintf("config gray rollout %s revision not match %d", rollout.Key(), expectRevision))
+	}
+	return nil
+}
+
+// GetConfigGrayRollout get gray rollout of the config file
+func (cs *configGrayRolloutStore) GetConfigGrayRollout(namespace, group,
+	fileName string) (*model.ConfigGrayRollout, error) {
+	querySql := cs.baseSelectSql() + " WHERE namespace = ? AND `group` = ? AND file_name = ?"
+	rows, err := cs.master.Query(querySql, namespace, group, fileName)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	ret, err := cs.transferRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret[0], nil
+}
+
+// GetConfigGrayRolloutsByStatus get all gray rollouts in the status
+func (cs *configGrayRolloutStore) GetConfigGrayRolloutsByStatus(status string) ([]*model.ConfigGrayRollout, error) {
+	rows, err := cs.master.Query(cs.baseSelectSql()+" WHERE status = ?", status)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	return cs.transferRows(rows)
+}
+
+func (cs *configGrayRolloutStore) baseSelectSql() string {
+	return `
+SELECT namespace, ` + "`group`" + `, file_name, release_name, IFNULL(release_description, '')
+	, IFNULL(comment, ''), IFNULL(steps, ''), current_step, step_time, error_threshold
+	, IFNULL(error_clients, ''), status, IFNULL(message, ''), revision
+	, UNIX_TIMESTAMP(create_time)
+	, IFNULL(create_by, '')
+	, UNIX_TIMESTAMP(modify_time)
+	, IFNULL(modify_by, '')
+FROM config_gray_rollout
+	`
+}
+
+func (cs *configGrayRolloutStore) transferRows(rows *sql.Rows) ([]*model.ConfigGrayRollout, error) {
+	if rows == nil {
+		return nil, nil
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	ret := make([]*model.ConfigGrayRollout, 0, 4)
+	for rows.Next() {
+		rollout := &model.ConfigGrayRollout{}
+		var (
+			stepTime, ctime, mtime int64
+			steps, errorClients    string
+		)
+		err := rows.Scan(&rollout.Namespace, &rollout.Group, &rollout.FileName, &rollout.ReleaseName,
+			&rollout.ReleaseDescription, &rollout.Comment, &steps, &rollout.CurrentStep, &stepTime,
+			&rollout.ErrorThreshold, &errorClients, &rollout.Status, &rollout.Message, &rollout.Revision,
+			&ctime, &rollout.CreateBy, &mtime, &rollout.ModifyBy)
+		if err != nil {
+			return nil, err
+		}
+		if stepTime > 0 {
+			rollout.StepTime = time.Unix(stepTime, 0)
+		}
+		rollout.CreateTime = time.Unix(ctime, 0)
+		rollout.ModifyTime = time.Unix(mtime, 0)
+		if steps != "" {
+			if err := json.Unmarshal([]byte(steps), &rollout.Steps); err != nil {
+				return nil, err
+			}
+		}
+		if errorClients != "" {
+			if err := json.Unmarshal([]byte(errorClients), &rollout.ErrorClients); err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, rollout)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func marshalGrayRolloutSteps(rollout *model.ConfigGrayRollout) (string, string, error) {
+	steps, err := json.Marshal(rollout.Steps)
+	if err != nil {
+		return "", "", err
+	}
+	errorClients, err := json.Marshal(rollout.ErrorClients)
+	if err != nil {
+		return "", "", err
+	}
+	return string(steps), string(errorClients), nil
+}
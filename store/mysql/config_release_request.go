@@ -0,0 +1,223 @@
+/*
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type configReleaseRequestStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// CreateConfigReleaseRequest create config release request
+func (cs *configReleaseRequestStore) CreateConfigReleaseRequest(req *model.ConfigReleaseRequest) error {
+	approvers, approvals, err := marshalReleaseRequestApprovals(req)
+	if err != nil {
+		return store.Error(err)
+	}
+	insertSql := `
+	INSERT INTO config_release_request (id, namespace, ` + "`group`" + `, file_name, release_name
+		, release_description, comment, md5, schedule_time, required_approvals, approvers, approvals
+		, status, message, release_time, revision, creator_id, create_time, create_by, modify_time, modify_by)
+	VALUES (?, ?, ?, ?, ?
+		, ?, ?, ?, ?, ?, ?, ?
+		, ?, ?, ?, ?, ?, sysdate(), ?, sysdate(), ?)
+	`
+	_, err = cs.master.Exec(insertSql, req.Id, req.Namespace, req.Group, req.FileName, req.ReleaseName,
+		req.ReleaseDescription, req.Comment, req.Md5, unixOrZero(req.ScheduleTime), req.RequiredApprovals,
+		approvers, approvals, req.Status, req.Message, unixOrZero(req.ReleaseTime), req.Revision,
+		req.CreatorId, req.CreateBy, req.ModifyBy)
+	return store.Error(err)
+}
+
+// UpdateConfigReleaseRequest update config release request with optimistic lock
+func (cs *configReleaseRequestStore) UpdateConfigReleaseRequest(req *model.ConfigReleaseRequest,
+	expectRevision uint64) error {
+	approvers, approvals, err := marshalReleaseRequestApprovals(req)
+	if err != nil {
+		return store.Error(err)
+	}
+	updateSql := `
+	UPDATE config_release_request SET release_name = ?, release_description = ?, comment = ?
+		, schedule_time = ?, required_approvals = ?, approvers = ?, approvals = ?, status = ?, message = ?
+		, release_time = ?, revision = ?, modify_time = sysdate(), modify_by = ?
+	WHERE id = ? AND revision = ?
+	`
+	result, err := cs.master.Exec(updateSql, req.ReleaseName, req.ReleaseDescription, req.Comment,
+		unixOrZero(req.ScheduleTime), req.RequiredApprovals, approvers, approvals, req.Status, req.Message,
+		unixOrZero(req.ReleaseTime), req.Revision, req.ModifyBy, req.Id, expectRevision)
+	if err != nil {
+		return store.Error(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return store.Error(err)
+	}
+	if rows == 0 {
+		return store.NewStatusError(store.DataConflictErr,
+			fmt.Sprintf("config release request %s revision not match %d", req.Id, expectRevision))
+	}
+	return nil
+}
+
+// GetConfigReleaseRequest get config release request by id
+func (cs *configReleaseRequestStore) GetConfigReleaseRequest(id string) (*model.ConfigReleaseRequest, error) {
+	rows, err := cs.master.Query(cs.baseSelectSql()+" WHERE id = ?", id)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	ret, err := cs.transferRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret[0], nil
+}
+
+// QueryConfigReleaseRequests query config release requests, order by create time desc
+func (cs *configReleaseRequestStore) QueryConfigReleaseRequests(filter map[string]string,
+	offset, limit uint32) (uint32, []*model.ConfigReleaseRequest, error) {
+	columns := map[string]string{
+		"namespace": "namespace",
+		"group":     "`group`",
+		"file_name": "file_name",
+		"status":    "status",
+		"create_by": "create_by",
+	}
+	conditions := ""
+	args := make([]interface{}, 0, len(columns))
+	for key, column := range columns {
+		if val := filter[key]; val != "" {
+			if conditions == "" {
+				conditions = " WHERE "
+			} else {
+				conditions += " AND "
+			}
+			conditions += column + " = ?"
+			args = append(args, val)
+		}
+	}
+
+	var total uint32
+	countSql := "SELECT COUNT(*) FROM config_release_request" + conditions
+	if err := cs.master.QueryRow(countSql, args...).Scan(&total); err != nil {
+		return 0, nil, store.Error(err)
+	}
+
+	querySql := cs.baseSelectSql() + conditions + " ORDER BY create_time DESC, id DESC LIMIT ?, ?"
+	rows, err := cs.master.Query(querySql, append(args, offset, limit)...)
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	ret, err := cs.transferRows(rows)
+	if err != nil {
+		return 0, nil, err
+	}
+	return total, ret, nil
+}
+
+func (cs *configReleaseRequestStore) baseSelectSql() string {
+	return `
+SELECT id, namespace, ` + "`group`" + `, file_name, release_name
+	, IFNULL(release_description, ''), IFNULL(comment, ''), md5, schedule_time, required_approvals
+	, IFNULL(approvers, ''), IFNULL(approvals, ''), status, IFNULL(message, ''), release_time, revision
+	, creator_id
+	, UNIX_TIMESTAMP(create_time)
+	, IFNULL(create_by, '')
+	, UNIX_TIMESTAMP(modify_time)
+	, IFNULL(modify_by, '')
+FROM config_release_request 
+	`
+}
+
+func (cs *configReleaseRequestStore) transferRows(rows *sql.Rows) ([]*model.ConfigReleaseRequest, error) {
+	if rows == nil {
+		return nil, nil
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	ret := make([]*model.ConfigReleaseRequest, 0, 4)
+	for rows.Next() {
+		req := &model.ConfigReleaseRequest{}
+		var (
+			scheduleTime, releaseTime, ctime, mtime int64
+			approvers, approvals                    string
+		)
+		err := rows.Scan(&req.Id, &req.Namespace, &req.Group, &req.FileName, &req.ReleaseName,
+			&req.ReleaseDescription, &req.Comment, &req.Md5, &scheduleTime, &req.RequiredApprovals,
+			&approvers, &approvals, &req.Status, &req.Message, &releaseTime, &req.Revision,
+			&req.CreatorId, &ctime, &req.CreateBy, &mtime, &req.ModifyBy)
+		if err != nil {
+			return nil, err
+		}
+		if scheduleTime > 0 {
+			req.ScheduleTime = time.Unix(scheduleTime, 0)
+		}
+		if releaseTime > 0 {
+			req.ReleaseTime = time.Unix(releaseTime, 0)
+		}
+		req.CreateTime = time.Unix(ctime, 0)
+		req.ModifyTime = time.Unix(mtime, 0)
+		if approvers != "" {
+			if err := json.Unmarshal([]byte(approvers), &req.Approvers); err != nil {
+				return nil, err
+			}
+		}
+		if approvals != "" {
+			if err := json.Unmarshal([]byte(approvals), &req.Approvals); err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func marshalReleaseRequestApprovals(req *model.ConfigReleaseRequest) (string, string, error) {
+	approvers, err := json.Marshal(req.Approvers)
+	if err != nil {
+		return "", "", err
+	}
+	approvals, err := json.Marshal(req.Approvals)
+	if err != nil {
+		return "", "", err
+	}
+	return string(approvers), string(approvals), nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
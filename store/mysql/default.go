@@ -66,13 +66,20 @@ type stableStore struct {
 	*configFileReleaseStore
 	*configFileReleaseHistoryStore
 	*configFileTemplateStore
+	*configFileSchemaStore
+	*configReleaseRequestStore
+	*configGrayRolloutStore
 
 	*clientStore
 	*adminStore
+	*operationRecordStore
+	*caRootStore
+	*healthCheckProtectionStore
 	*toolStore
 	*userStore
 	*groupStore
 	*strategyStore
+	*apiTokenStore
 	*grayStore
 
 	// 主数据库，可以进行读写
@@ -268,13 +275,20 @@ func (s *stableStore) newStore() {
 	s.configFileReleaseStore = &configFileReleaseStore{master: s.master, slave: s.slave}
 	s.configFileReleaseHistoryStore = &configFileReleaseHistoryStore{master: s.master, slave: s.slave}
 	s.configFileTemplateStore = &configFileTemplateStore{master: s.master, slave: s.slave}
+	s.configFileSchemaStore = &configFileSchemaStore{master: s.master, slave: s.slave}
+	s.configReleaseRequestStore = &configReleaseRequestStore{master: s.master, slave: s.slave}
+	s.configGrayRolloutStore = &configGrayRolloutStore{master: s.master, slave: s.slave}
 	s.clientStore = &clientStore{master: s.master, slave: s.slave}
 
 	s.adminStore = newAdminStore(s.master)
+	s.operationRecordStore = &operationRecordStore{master: s.master, slave: s.slave}
+	s.caRootStore = &caRootStore{master: s.master}
+	s.healthCheckProtectionStore = &healthCheckProtectionStore{master: s.master}
 	s.toolStore = &toolStore{db: s.master}
 	s.userStore = &userStore{master: s.master, slave: s.slave}
 	s.groupStore = &groupStore{master: s.master, slave: s.slave}
 	s.strategyStore = &strategyStore{master: s.master, slave: s.slave}
+	s.apiTokenStore = &apiTokenStore{master: s.master, slave: s.slave}
 	s.grayStore = &grayStore{master: s.master, slave: s.slave}
 }
 
@@ -0,0 +1,63 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// healthCheckProtectionKey the cluster only keeps one manual override of health check protection mode
+const healthCheckProtectionKey = "default"
+
+type healthCheckProtectionStore struct {
+	master *BaseDB
+}
+
+// SetHealthCheckProtection save the manual override of health check protection mode
+func (hs *healthCheckProtectionStore) SetHealthCheckProtection(protection *model.HealthCheckProtection) error {
+	if protection.Mode == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "set health check protection missing mode")
+	}
+	insertSql := "INSERT INTO health_check_protection (name, mode, mtime) VALUES (?, ?, FROM_UNIXTIME(?)) " +
+		"ON DUPLICATE KEY UPDATE mode = VALUES(mode), mtime = VALUES(mtime)"
+	if _, err := hs.master.Exec(insertSql, healthCheckProtectionKey, protection.Mode,
+		protection.ModifyTime.Unix()); err != nil {
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetHealthCheckProtection get the manual override of health check protection mode, return nil if absent
+func (hs *healthCheckProtectionStore) GetHealthCheckProtection() (*model.HealthCheckProtection, error) {
+	var mtime int64
+	ret := &model.HealthCheckProtection{}
+	querySql := "SELECT mode, UNIX_TIMESTAMP(mtime) FROM health_check_protection WHERE name = ?"
+	err := hs.master.QueryRow(querySql, healthCheckProtectionKey).Scan(&ret.Mode, &mtime)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, store.Error(err)
+	}
+	ret.ModifyTime = time.Unix(mtime, 0)
+	return ret, nil
+}
@@ -0,0 +1,137 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+type operationRecordStore struct {
+	master *BaseDB
+	slave  *BaseDB
+}
+
+// AddOperationRecords 批量保存操作记录
+func (o *operationRecordStore) AddOperationRecords(records []*model.RecordEntry) error {
+	if len(records) == 0 {
+		return nil
+	}
+	insertSql := "INSERT INTO operation_record(resource_type, resource_name, namespace, operator, " +
+		" operation_type, detail, server, happen_time) VALUES "
+	values := make([]string, 0, len(records))
+	args := make([]interface{}, 0, len(records)*8)
+	for _, record := range records {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, FROM_UNIXTIME(?))")
+		args = append(args, string(record.ResourceType), record.ResourceName, record.Namespace,
+			record.Operator, string(record.OperationType), record.Detail, record.Server,
+			record.HappenTime.Unix())
+	}
+	insertSql += strings.Join(values, ", ")
+	if _, err := o.master.Exec(insertSql, args...); err != nil {
+		log.Errorf("[Store][database] add %d operation records err: %s", len(records), err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// GetOperationRecords 查询操作记录，按照发生时间倒序返回
+func (o *operationRecordStore) GetOperationRecords(filter map[string]string,
+	offset, limit uint32) (uint32, []*model.RecordEntry, error) {
+
+	conditions := make([]string, 0, len(filter))
+	args := make([]interface{}, 0, len(filter)+2)
+	for _, column := range []string{"resource_type", "resource_name", "namespace", "operator", "operation_type"} {
+		if value := filter[column]; value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if value, err := strconv.ParseInt(filter["start_time"], 10, 64); err == nil {
+		conditions = append(conditions, "happen_time >= FROM_UNIXTIME(?)")
+		args = append(args, value)
+	}
+	if value, err := strconv.ParseInt(filter["end_time"], 10, 64); err == nil {
+		conditions = append(conditions, "happen_time <= FROM_UNIXTIME(?)")
+		args = append(args, value)
+	}
+	whereSql := ""
+	if len(conditions) > 0 {
+		whereSql = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count uint32
+	countSql := "SELECT COUNT(*) FROM operation_record " + whereSql
+	if err := o.slave.QueryRow(countSql, args...).Scan(&count); err != nil {
+		log.Errorf("[Store][database] count operation records err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+
+	querySql := "SELECT id, resource_type, resource_name, namespace, operator, operation_type, " +
+		" IFNULL(detail, ''), server, UNIX_TIMESTAMP(happen_time) FROM operation_record " + whereSql +
+		" ORDER BY happen_time DESC, id DESC LIMIT ?, ?"
+	args = append(args, offset, limit)
+	rows, err := o.slave.Query(querySql, args...)
+	if err != nil {
+		log.Errorf("[Store][database] query operation records err: %s", err.Error())
+		return 0, nil, store.Error(err)
+	}
+	records, err := o.transferRows(rows)
+	if err != nil {
+		return 0, nil, store.Error(err)
+	}
+	return count, records, nil
+}
+
+// CleanOperationRecords 清理 endTime 之前的操作记录
+func (o *operationRecordStore) CleanOperationRecords(endTime time.Time, limit uint64) error {
+	delSql := "DELETE FROM operation_record WHERE happen_time < ? LIMIT ?"
+	_, err := o.master.Exec(delSql, endTime, limit)
+	return store.Error(err)
+}
+
+func (o *operationRecordStore) transferRows(rows *sql.Rows) ([]*model.RecordEntry, error) {
+	defer rows.Close()
+
+	records := make([]*model.RecordEntry, 0, 16)
+	for rows.Next() {
+		var (
+			item                        = &model.RecordEntry{}
+			resourceType, operationType string
+			happenTime                  int64
+		)
+		err := rows.Scan(&item.ID, &resourceType, &item.ResourceName, &item.Namespace, &item.Operator,
+			&operationType, &item.Detail, &item.Server, &happenTime)
+		if err != nil {
+			return nil, err
+		}
+		item.ResourceType = model.Resource(resourceType)
+		item.OperationType = model.OperationType(operationType)
+		item.HappenTime = time.Unix(happenTime, 0)
+		records = append(records, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
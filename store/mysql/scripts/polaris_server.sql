@@ -1005,4 +1005,174 @@ CREATE TABLE
         `mtime` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
         PRIMARY KEY (`id`),
         UNIQUE KEY `name` (`group_name`, `name`)
-    ) ENGINE = InnoDB;
\ No newline at end of file
+    ) ENGINE = InnoDB;
+
+-- --------------------------------------------------------
+--
+-- Table structure `operation_record`
+--
+CREATE TABLE
+    `operation_record` (
+        `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键',
+        `resource_type` VARCHAR(64) NOT NULL COMMENT '资源类型',
+        `resource_name` VARCHAR(256) NOT NULL DEFAULT '' COMMENT '资源名称',
+        `namespace` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '所属的namespace',
+        `operator` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作人',
+        `operation_type` VARCHAR(32) NOT NULL COMMENT '操作类型',
+        `detail` LONGTEXT COMMENT '操作详情',
+        `server` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '处理请求的北极星节点',
+        `happen_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+        PRIMARY KEY (`id`),
+        KEY `idx_happen_time` (`happen_time`),
+        KEY `idx_resource` (`resource_type`, `namespace`, `happen_time`),
+        KEY `idx_operator` (`operator`, `happen_time`)
+    ) ENGINE = InnoDB AUTO_INCREMENT = 1 COMMENT = '资源操作记录表';
+
+-- 配置内容校验规则
+CREATE TABLE
+    `config_file_schema` (
+        `id` BIGINT(10) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键',
+        `namespace` VARCHAR(64) COLLATE utf8_bin NOT NULL COMMENT '所属的namespace',
+        `group` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '所属的文件组',
+        `file_name` VARCHAR(128) COLLATE utf8_bin NOT NULL DEFAULT '' COMMENT '配置文件名，为空时对整个文件组生效',
+        `type` VARCHAR(32) COLLATE utf8_bin NOT NULL COMMENT '规则类型, json_schema/properties_rule',
+        `schema` LONGTEXT COLLATE utf8_bin NOT NULL COMMENT '规则内容',
+        `comment` VARCHAR(512) COLLATE utf8_bin DEFAULT NULL COMMENT '规则描述信息',
+        `create_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `create_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '创建人',
+        `modify_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        `modify_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '最后更新人',
+        PRIMARY KEY (`id`),
+        UNIQUE KEY `uk_file` (`namespace`, `group`, `file_name`)
+    ) ENGINE = InnoDB AUTO_INCREMENT = 1 DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '配置内容校验规则表';
+
+-- 配置发布申请
+CREATE TABLE
+    `config_release_request` (
+        `id` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '申请ID',
+        `namespace` VARCHAR(64) COLLATE utf8_bin NOT NULL COMMENT '所属的namespace',
+        `group` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '所属的文件组',
+        `file_name` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '配置文件名',
+        `release_name` VARCHAR(128) COLLATE utf8_bin NOT NULL DEFAULT '' COMMENT '发布时使用的版本名称',
+        `release_description` VARCHAR(512) COLLATE utf8_bin DEFAULT NULL COMMENT '发布描述',
+        `comment` VARCHAR(512) COLLATE utf8_bin DEFAULT NULL COMMENT '申请描述信息',
+        `md5` VARCHAR(128) NOT NULL COMMENT '申请时配置内容的md5',
+        `schedule_time` BIGINT NOT NULL DEFAULT 0 COMMENT '计划发布时间，unix 秒，0 表示审批通过后立即发布',
+        `required_approvals` INT NOT NULL DEFAULT 0 COMMENT '需要的审批通过人数',
+        `approvers` TEXT COMMENT '审批人以及审批用户组，JSON 格式',
+        `approvals` TEXT COMMENT '审批记录，JSON 格式',
+        `status` VARCHAR(32) NOT NULL COMMENT '申请状态',
+        `message` VARCHAR(1024) COLLATE utf8_bin DEFAULT NULL COMMENT '驳回、取消或者发布失败的原因',
+        `release_time` BIGINT NOT NULL DEFAULT 0 COMMENT '实际发布时间，unix 秒',
+        `revision` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '版本号，用于并发更新时的乐观锁',
+        `creator_id` VARCHAR(128) COLLATE utf8_bin NOT NULL DEFAULT '' COMMENT '申请人ID',
+        `create_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `create_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '创建人',
+        `modify_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        `modify_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '最后更新人',
+        PRIMARY KEY (`id`),
+        KEY `idx_file` (`namespace`, `group`, `file_name`),
+        KEY `idx_status` (`status`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '配置发布申请表';
+
+-- 配置按比例灰度发布
+CREATE TABLE
+    `config_gray_rollout` (
+        `namespace` VARCHAR(64) COLLATE utf8_bin NOT NULL COMMENT '所属的namespace',
+        `group` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '所属的文件组',
+        `file_name` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '配置文件名',
+        `release_name` VARCHAR(128) COLLATE utf8_bin NOT NULL DEFAULT '' COMMENT '灰度版本名称',
+        `release_description` VARCHAR(512) COLLATE utf8_bin DEFAULT NULL COMMENT '发布描述',
+        `comment` VARCHAR(512) COLLATE utf8_bin DEFAULT NULL COMMENT '描述信息',
+        `steps` TEXT COMMENT '灰度阶段，JSON 格式',
+        `current_step` INT NOT NULL DEFAULT 0 COMMENT '当前所处的阶段',
+        `step_time` BIGINT NOT NULL DEFAULT 0 COMMENT '进入当前阶段的时间，unix 秒',
+        `error_threshold` INT NOT NULL DEFAULT 0 COMMENT '自动终止灰度的异常客户端数量，0 表示不自动终止',
+        `error_clients` TEXT COMMENT '上报异常的客户端，JSON 格式',
+        `status` VARCHAR(32) NOT NULL COMMENT '灰度状态',
+        `message` VARCHAR(1024) COLLATE utf8_bin DEFAULT NULL COMMENT '终止或者失败的原因',
+        `revision` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '版本号，用于并发更新时的乐观锁',
+        `create_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `create_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '创建人',
+        `modify_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        `modify_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '最后更新人',
+        PRIMARY KEY (`namespace`, `group`, `file_name`),
+        KEY `idx_status` (`status`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '配置按比例灰度发布表';
+
+-- 用户以及用户组的具名 API token
+CREATE TABLE
+    `api_token` (
+        `id` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT 'token ID',
+        `name` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT 'token 名称',
+        `principal_type` INT NOT NULL COMMENT '所属成员类型，1 为用户，2 为用户组',
+        `principal_id` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '所属的用户或者用户组ID',
+        `owner` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '所属的主账户ID',
+        `token_hash` VARCHAR(128) NOT NULL COMMENT 'token 的 sha256 摘要',
+        `namespaces` TEXT COMMENT '允许操作的命名空间，JSON 格式',
+        `resource_types` TEXT COMMENT '允许操作的资源类型，JSON 格式',
+        `actions` TEXT COMMENT '允许的动作，JSON 格式',
+        `expire_time` BIGINT NOT NULL DEFAULT 0 COMMENT '过期时间，unix 秒，0 表示永不过期',
+        `last_used_time` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次使用时间，unix 秒',
+        `comment` VARCHAR(512) COLLATE utf8_bin DEFAULT NULL COMMENT '描述信息',
+        `flag` TINYINT(4) NOT NULL DEFAULT 0 COMMENT '是否被吊销，0 为有效，1 为已吊销',
+        `create_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `create_by` VARCHAR(32) COLLATE utf8_bin DEFAULT NULL COMMENT '创建人',
+        `modify_time` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        PRIMARY KEY (`id`),
+        KEY `idx_principal` (`principal_type`, `principal_id`),
+        KEY `idx_owner` (`owner`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '具名 API token 表';
+
+-- 鉴权策略的生效条件
+CREATE TABLE
+    `auth_strategy_condition` (
+        `strategy_id` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '策略ID',
+        `conditions` TEXT COMMENT '生效条件，JSON 格式',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        PRIMARY KEY (`strategy_id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '鉴权策略生效条件表';
+
+-- 登录失败计数以及锁定状态
+CREATE TABLE
+    `login_lock` (
+        `lock_key` VARCHAR(256) COLLATE utf8_bin NOT NULL COMMENT '锁定对象，格式为 user:{用户ID} 或者 ip:{来源IP}',
+        `fail_count` INT NOT NULL DEFAULT 0 COMMENT '统计窗口内的登录失败次数',
+        `window_start` BIGINT NOT NULL DEFAULT 0 COMMENT '统计窗口的开始时间，unix 秒',
+        `locked_until` BIGINT NOT NULL DEFAULT 0 COMMENT '锁定的截止时间，unix 秒，0 表示没有锁定',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        PRIMARY KEY (`lock_key`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '登录失败锁定表';
+
+-- 用户最近使用过的密码以及最近一次修改密码的时间
+CREATE TABLE
+    `user_password_record` (
+        `user_id` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT '用户ID',
+        `history` TEXT COMMENT '最近使用过的密码 hash，JSON 格式',
+        `change_time` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次修改密码的时间，unix 秒',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+        PRIMARY KEY (`user_id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '用户密码记录表';
+
+-- xds 内置 CA 的根证书，同一个信任域下集群内所有节点共享
+CREATE TABLE
+    `ca_root` (
+        `trust_domain` VARCHAR(128) COLLATE utf8_bin NOT NULL COMMENT 'SPIFFE 信任域',
+        `cert` TEXT NOT NULL COMMENT '根证书，PEM 格式',
+        `private_key` TEXT NOT NULL COMMENT '加密后的根证书私钥，不保存明文',
+        `key_crypto_algo` VARCHAR(32) NOT NULL COMMENT '私钥的加密算法',
+        `prev_cert` TEXT NOT NULL COMMENT '轮转前的根证书，过期之前仍然被信任',
+        `ctime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '最后轮转时间',
+        PRIMARY KEY (`trust_domain`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = 'xds 内置 CA 根证书表';
+
+-- 人工设置的健康检查保护模式，集群内所有节点共享
+CREATE TABLE
+    `health_check_protection` (
+        `name` VARCHAR(32) COLLATE utf8_bin NOT NULL COMMENT '设置的名称，固定为 default',
+        `mode` VARCHAR(16) NOT NULL COMMENT '保护模式，auto/on/off',
+        `mtime` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '最后修改时间',
+        PRIMARY KEY (`name`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8 COLLATE = utf8_bin COMMENT = '健康检查保护模式表';
@@ -19,6 +19,7 @@ package sqldb
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -204,6 +205,10 @@ func (s *strategyStore) deleteStrategy(id string) error {
 		return err
 	}
 
+	if _, err = tx.Exec("DELETE FROM auth_strategy_condition WHERE strategy_id = ?", id); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Errorf("[Store][Strategy] delete auth_strategy tx commit err: %s", err.Error())
 		return err
@@ -343,6 +348,107 @@ func (s *strategyStore) LooseAddStrategyResources(resources []model.StrategyReso
 	return nil
 }
 
+// UpdateStrategyConditions 替换策略的生效条件，conditions 为空时删除生效条件
+func (s *strategyStore) UpdateStrategyConditions(id string, conditions *model.StrategyConditions) error {
+	if id == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "update auth_strategy conditions missing id")
+	}
+	tx, err := s.master.Begin()
+	if err != nil {
+		return store.Error(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if conditions.IsEmpty() {
+		if _, err = tx.Exec("DELETE FROM auth_strategy_condition WHERE strategy_id = ?", id); err != nil {
+			return store.Error(err)
+		}
+	} else {
+		data, err := json.Marshal(conditions)
+		if err != nil {
+			return store.Error(err)
+		}
+		if _, err = tx.Exec("REPLACE INTO auth_strategy_condition(strategy_id, conditions) VALUES (?, ?)",
+			id, string(data)); err != nil {
+			return store.Error(err)
+		}
+	}
+	// 主要是为了能够触发 StrategyCache 的刷新逻辑
+	result, err := tx.Exec("UPDATE auth_strategy SET revision = ?, mtime = sysdate() WHERE id = ? AND flag = 0",
+		utils.NewUUID(), id)
+	if err != nil {
+		return store.Error(err)
+	}
+	if err := checkDataBaseAffectedRows(result, 1); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Errorf("[Store][Strategy] update auth_strategy conditions tx commit err: %s", err.Error())
+		return store.Error(err)
+	}
+	return nil
+}
+
+// getStrategyConditions 获取策略的生效条件
+func (s *strategyStore) getStrategyConditions(queryHander QueryHandler, id string) (*model.StrategyConditions, error) {
+	rows, err := queryHander("SELECT conditions FROM auth_strategy_condition WHERE strategy_id = ?", id)
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	defer rows.Close()
+
+	var data string
+	for rows.Next() {
+		if err := rows.Scan(&data); err != nil {
+			return nil, store.Error(err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, store.Error(err)
+	}
+	return model.ParseStrategyConditions(data)
+}
+
+// fillStrategiesConditions 批量查询并填充策略的生效条件，避免逐个策略查询
+func (s *strategyStore) fillStrategiesConditions(queryHander QueryHandler, strategies []*model.StrategyDetail) error {
+	if len(strategies) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, 0, len(strategies))
+	for i := range strategies {
+		ids = append(ids, strategies[i].ID)
+	}
+	conditions := make(map[string]string, len(strategies))
+	err := BatchOperation("get-strategy-conditions", ids, func(objects []interface{}) error {
+		rows, err := queryHander("SELECT strategy_id, conditions FROM auth_strategy_condition WHERE strategy_id IN ("+
+			PlaceholdersN(len(objects))+")", objects...)
+		if err != nil {
+			return store.Error(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id, data string
+			if err := rows.Scan(&id, &data); err != nil {
+				return store.Error(err)
+			}
+			conditions[id] = data
+		}
+		return store.Error(rows.Err())
+	})
+	if err != nil {
+		return err
+	}
+	for i := range strategies {
+		ret, err := model.ParseStrategyConditions(conditions[strategies[i].ID])
+		if err != nil {
+			return store.Error(err)
+		}
+		strategies[i].Conditions = ret
+	}
+	return nil
+}
+
 // RemoveStrategyResources 删除策略的资源
 func (s *strategyStore) RemoveStrategyResources(resources []model.StrategyResource) error {
 	tx, err := s.master.Begin()
@@ -451,9 +557,14 @@ func (s *strategyStore) getStrategyDetail(row *sql.Row) (*model.StrategyDetail,
 	if err != nil {
 		return nil, store.Error(err)
 	}
+	conditions, err := s.getStrategyConditions(s.slave.Query, ret.ID)
+	if err != nil {
+		return nil, store.Error(err)
+	}
 
 	ret.Resources = resArr
 	ret.Principals = principals
+	ret.Conditions = conditions
 	return ret, nil
 }
 
@@ -615,6 +726,11 @@ func (s *strategyStore) collectStrategies(handler QueryHandler, querySql string,
 
 		ret = append(ret, detail)
 	}
+	if showDetail {
+		if err := s.fillStrategiesConditions(s.slave.Query, ret); err != nil {
+			return nil, err
+		}
+	}
 
 	return ret, nil
 }
@@ -665,6 +781,9 @@ func (s *strategyStore) GetStrategyDetailsForCache(mtime time.Time,
 
 		ret = append(ret, detail)
 	}
+	if err := s.fillStrategiesConditions(s.slave.Query, ret); err != nil {
+		return nil, err
+	}
 
 	return ret, nil
 }
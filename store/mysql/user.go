@@ -222,6 +222,16 @@ func (u *userStore) deleteUser(user *model.User) error {
 		return err
 	}
 
+	if _, err = tx.Exec("DELETE FROM user_password_record WHERE user_id = ?", user.ID); err != nil {
+		log.Error("[Store][User] delete user password record", zap.Error(err))
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM login_lock WHERE lock_key = ?", model.UserLoginLockKey(user.ID)); err != nil {
+		log.Error("[Store][User] delete user login lock", zap.Error(err))
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Error("[Store][User] delete user tx commit", zap.Error(err))
 		return err
@@ -0,0 +1,152 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// GetLoginLock get the login failure counter and lock state by key
+func (u *userStore) GetLoginLock(key string) (*model.LoginLock, error) {
+	var (
+		lock                     = &model.LoginLock{Key: key}
+		windowStart, lockedUntil int64
+		mtime                    int64
+	)
+	err := u.master.QueryRow("SELECT fail_count, window_start, locked_until, UNIX_TIMESTAMP(mtime) "+
+		" FROM login_lock WHERE lock_key = ?", key).Scan(&lock.FailCount, &windowStart, &lockedUntil, &mtime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, store.Error(err)
+	}
+	lock.WindowStart = timeOrZero(windowStart)
+	lock.LockedUntil = timeOrZero(lockedUntil)
+	lock.ModifyTime = time.Unix(mtime, 0)
+	return lock, nil
+}
+
+// SaveLoginLock create or replace the login failure counter and lock state
+func (u *userStore) SaveLoginLock(lock *model.LoginLock) error {
+	if lock.Key == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "save login lock missing key")
+	}
+	_, err := u.master.Exec("REPLACE INTO login_lock(lock_key, fail_count, window_start, locked_until, mtime) "+
+		" VALUES (?, ?, ?, ?, sysdate())", lock.Key, lock.FailCount, unixOrZero(lock.WindowStart),
+		unixOrZero(lock.LockedUntil))
+	return store.Error(err)
+}
+
+// IncrLoginFailure record a login failure in one transaction, the row is locked by SELECT ... FOR UPDATE
+// so that concurrent failures on different nodes are counted one by one
+func (u *userStore) IncrLoginFailure(failure *model.LoginFailure) (*model.LoginLock, error) {
+	if failure.Key == "" {
+		return nil, store.NewStatusError(store.EmptyParamsErr, "incr login failure missing key")
+	}
+	// make sure the row exists, so that concurrent transactions wait on the same row lock
+	if _, err := u.master.Exec("INSERT IGNORE INTO login_lock(lock_key, fail_count, window_start, locked_until, "+
+		" mtime) VALUES (?, 0, ?, 0, sysdate())", failure.Key, unixOrZero(failure.Time)); err != nil {
+		return nil, store.Error(err)
+	}
+
+	tx, err := u.master.Begin()
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		lock                     = &model.LoginLock{Key: failure.Key}
+		windowStart, lockedUntil int64
+	)
+	if err := tx.QueryRow("SELECT fail_count, window_start, locked_until FROM login_lock WHERE lock_key = ? "+
+		" FOR UPDATE", failure.Key).Scan(&lock.FailCount, &windowStart, &lockedUntil); err != nil {
+		return nil, store.Error(err)
+	}
+	lock.WindowStart = timeOrZero(windowStart)
+	lock.LockedUntil = timeOrZero(lockedUntil)
+
+	lock = failure.Apply(lock)
+	if _, err := tx.Exec("UPDATE login_lock SET fail_count = ?, window_start = ?, locked_until = ?, "+
+		" mtime = sysdate() WHERE lock_key = ?", lock.FailCount, unixOrZero(lock.WindowStart),
+		unixOrZero(lock.LockedUntil), failure.Key); err != nil {
+		return nil, store.Error(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, store.Error(err)
+	}
+	lock.ModifyTime = time.Now()
+	return lock, nil
+}
+
+// DeleteLoginLock delete the login failure counter and lock state by key
+func (u *userStore) DeleteLoginLock(key string) error {
+	_, err := u.master.Exec("DELETE FROM login_lock WHERE lock_key = ?", key)
+	return store.Error(err)
+}
+
+// GetUserPasswordRecord get the password history and last change time of a user
+func (u *userStore) GetUserPasswordRecord(userID string) (*model.UserPasswordRecord, error) {
+	var (
+		record     = &model.UserPasswordRecord{UserID: userID}
+		history    string
+		changeTime int64
+	)
+	err := u.master.QueryRow("SELECT history, change_time FROM user_password_record WHERE user_id = ?",
+		userID).Scan(&history, &changeTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, store.Error(err)
+	}
+	if history != "" {
+		if err := json.Unmarshal([]byte(history), &record.History); err != nil {
+			return nil, store.Error(err)
+		}
+	}
+	record.ChangeTime = timeOrZero(changeTime)
+	return record, nil
+}
+
+// SaveUserPasswordRecord create or replace the password history and last change time of a user
+func (u *userStore) SaveUserPasswordRecord(record *model.UserPasswordRecord) error {
+	if record.UserID == "" {
+		return store.NewStatusError(store.EmptyParamsErr, "save user password record missing user id")
+	}
+	history, err := json.Marshal(record.History)
+	if err != nil {
+		return store.Error(err)
+	}
+	_, err = u.master.Exec("REPLACE INTO user_password_record(user_id, history, change_time, mtime) "+
+		" VALUES (?, ?, ?, sysdate())", record.UserID, string(history), unixOrZero(record.ChangeTime))
+	return store.Error(err)
+}
+
+func timeOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
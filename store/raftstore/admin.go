@@ -0,0 +1,232 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// leaderElections raft 集群只有一个 leader，所有选举 key 的 leader 均为 raft leader
+type leaderElections struct {
+	mu         sync.Mutex
+	keys       map[string]time.Time
+	leader     bool
+	leaderHost string
+	mtime      time.Time
+}
+
+func newLeaderElections() *leaderElections {
+	return &leaderElections{
+		keys: make(map[string]time.Time),
+	}
+}
+
+// start 开始参与 key 的选举，如果已经知道 leader 则立即通知
+func (l *leaderElections) start(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.keys[key]; ok {
+		return
+	}
+	l.keys[key] = time.Now()
+	if l.leader || l.leaderHost != "" {
+		publishLeaderChange(key, l.leader, l.leaderHost)
+	}
+}
+
+// notify raft leader 发生变化
+func (l *leaderElections) notify(leader bool, host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.leader == leader && l.leaderHost == host {
+		return
+	}
+	l.leader = leader
+	l.leaderHost = host
+	l.mtime = time.Now()
+	for key := range l.keys {
+		publishLeaderChange(key, leader, host)
+	}
+}
+
+func (l *leaderElections) isLeader(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.keys[key]
+	return ok && l.leader
+}
+
+func (l *leaderElections) started(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.keys[key]
+	return ok
+}
+
+func (l *leaderElections) list() []*model.LeaderElection {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ret := make([]*model.LeaderElection, 0, len(l.keys))
+	for key, ctime := range l.keys {
+		mtime := l.mtime
+		if mtime.Before(ctime) {
+			mtime = ctime
+		}
+		ret = append(ret, &model.LeaderElection{
+			ElectKey:   key,
+			Host:       l.leaderHost,
+			Ctime:      ctime.Unix(),
+			CreateTime: ctime,
+			Mtime:      mtime.Unix(),
+			ModifyTime: mtime,
+			Valid:      l.leaderHost != "",
+		})
+	}
+	return ret
+}
+
+func publishLeaderChange(key string, leader bool, host string) {
+	_ = eventhub.Publish(eventhub.LeaderChangeEventTopic, store.LeaderChangeEvent{
+		Key:        key,
+		Leader:     leader,
+		LeaderHost: host,
+	})
+}
+
+// StartLeaderElection start leader election
+func (s *raftStore) StartLeaderElection(key string) error {
+	s.elections.start(key)
+	return nil
+}
+
+// IsLeader whether it is leader node
+func (s *raftStore) IsLeader(key string) bool {
+	return s.elections.isLeader(key)
+}
+
+// ListLeaderElections list all leaderelection
+func (s *raftStore) ListLeaderElections() ([]*model.LeaderElection, error) {
+	return s.elections.list(), nil
+}
+
+// ReleaseLeaderElection force release leader status, raft store will transfer the
+// leadership to another node
+func (s *raftStore) ReleaseLeaderElection(key string) error {
+	if !s.elections.started(key) {
+		return fmt.Errorf("LeaderElection(%s) not started", key)
+	}
+	if !s.elections.isLeader(key) {
+		return nil
+	}
+	return s.raft.LeadershipTransfer().Error()
+}
+
+// BatchCleanDeletedInstances batch clean soft deleted instances, the cleanup job only runs on
+// the leader, the cutoff is resolved to an absolute time before it is written into the raft log
+// so that every replica and every replay deletes the same rows
+func (s *raftStore) BatchCleanDeletedInstances(timeout time.Duration, batchSize uint32) (uint32, error) {
+	return s.callCount("BatchCleanDeletedInstancesBefore", time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedClients batch clean soft deleted clients
+func (s *raftStore) BatchCleanDeletedClients(timeout time.Duration, batchSize uint32) (uint32, error) {
+	return s.callCount("BatchCleanDeletedClientsBefore", time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedServices batch clean soft deleted services
+func (s *raftStore) BatchCleanDeletedServices(timeout time.Duration, batchSize uint32) (uint32, error) {
+	return s.callCount("BatchCleanDeletedServicesBefore", time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedRules batch clean soft deleted rules
+func (s *raftStore) BatchCleanDeletedRules(rule string, timeout time.Duration, batchSize uint32) (uint32, error) {
+	return s.callCount("BatchCleanDeletedRulesBefore", rule, time.Now().Add(-timeout), batchSize)
+}
+
+// BatchCleanDeletedConfigFiles batch clean soft deleted config files
+func (s *raftStore) BatchCleanDeletedConfigFiles(timeout time.Duration, batchSize uint32) (uint32, error) {
+	return s.callCount("BatchCleanDeletedConfigFilesBefore", time.Now().Add(-timeout), batchSize)
+}
+
+// AddOperationRecords 批量保存操作记录
+func (s *raftStore) AddOperationRecords(records []*model.RecordEntry) error {
+	return s.exec("AddOperationRecords", records)
+}
+
+// CleanOperationRecords 清理 endTime 之前的操作记录
+func (s *raftStore) CleanOperationRecords(endTime time.Time, limit uint64) error {
+	return s.exec("CleanOperationRecords", endTime, limit)
+}
+
+// CreateCARootIfAbsent 信任域下没有根证书时保存 root, 返回该信任域当前生效的根证书
+func (s *raftStore) CreateCARootIfAbsent(root *model.CARoot) (*model.CARoot, error) {
+	ret, err := s.call("CreateCARootIfAbsent", root)
+	if err != nil {
+		return nil, err
+	}
+	return ret[0].(*model.CARoot), nil
+}
+
+// RotateCARoot 信任域当前的根证书为 prevCertPEM 时替换为 root, 返回该信任域当前生效的根证书
+func (s *raftStore) RotateCARoot(root *model.CARoot, prevCertPEM string) (*model.CARoot, error) {
+	ret, err := s.call("RotateCARoot", root, prevCertPEM)
+	if err != nil {
+		return nil, err
+	}
+	return ret[0].(*model.CARoot), nil
+}
+
+// SetHealthCheckProtection 保存人工设置的健康检查保护模式
+func (s *raftStore) SetHealthCheckProtection(protection *model.HealthCheckProtection) error {
+	return s.exec("SetHealthCheckProtection", protection)
+}
+
+func (s *raftStore) callCount(method string, args ...interface{}) (uint32, error) {
+	ret, err := s.call(method, args...)
+	if err != nil {
+		return 0, err
+	}
+	return ret[0].(uint32), nil
+}
+
+// leaderHost 从 raft 地址中解析出 leader 的主机地址
+func leaderHost(addr string) string {
+	if addr == "" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+func advertiseAddr(addr string) net.Addr {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil
+	}
+	return tcpAddr
+}
@@ -0,0 +1,404 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// commandType raft 日志中携带的命令类型
+type commandType int
+
+const (
+	// commandCall 单个方法调用，由 ReplicaStore 自身保证原子性
+	commandCall commandType = iota
+	// commandBatch 事务内的多次方法调用，在同一个 boltdb 事务中执行
+	commandBatch
+	// commandInit 集群首次选出 leader 后加载默认数据
+	commandInit
+)
+
+// command 写入 raft 日志的命令
+type command struct {
+	Type  commandType `json:"type"`
+	Calls []*call     `json:"calls,omitempty"`
+	// Seed 默认数据 token 的生成种子，由 leader 生成后随日志复制，保证各副本一致
+	Seed string `json:"seed,omitempty"`
+	// InitTime 默认数据的创建时间，由 leader 生成后随日志复制，避免各副本使用本地时间
+	InitTime int64 `json:"initTime,omitempty"`
+	// Now leader 提交命令时的时间，状态机执行写操作时使用该时间作为创建以及修改时间
+	Now int64 `json:"now,omitempty"`
+	// Checks 事务内读操作及其读到的结果，状态机执行命令前逐一重新读取比较，
+	// 任意结果不一致时拒绝整个命令
+	Checks []*check `json:"checks,omitempty"`
+	// TxTime 事务开始的时间，事务内的读操作以及状态机的校验都使用该时间重放事务内的写操作
+	TxTime int64 `json:"txTime,omitempty"`
+}
+
+// check 事务内的一次读操作。事务只能读取本节点的副本，读到的数据在提交前可能已被
+// 其他节点修改，因此读到的结果作为前置条件随命令提交，由状态机比较后决定是否执行
+type check struct {
+	Call *call `json:"call"`
+	// Step 读操作发生时事务内已经追加的写操作数量，状态机在执行完前 Step 个写操作后再比较
+	Step   int               `json:"step"`
+	Values []json.RawMessage `json:"values,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// newCheck 记录一次读操作的结果
+func newCheck(c *call, step int, ret *callResult) *check {
+	return &check{Call: c, Step: step, Values: ret.Values, Error: ret.Error}
+}
+
+// matches 重新读取的结果是否与事务内读到的一致
+func (c *check) matches(ret *callResult) bool {
+	if c.Error != ret.Error || len(c.Values) != len(ret.Values) {
+		return false
+	}
+	for i := range c.Values {
+		if !bytes.Equal(c.Values[i], ret.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// call 一次对状态机方法的调用
+type call struct {
+	Method string            `json:"method"`
+	Args   []json.RawMessage `json:"args"`
+	// args 调用方传入的原始参数，用于回写状态机对入参的修改，比如自增 ID
+	args []interface{}
+}
+
+// callResult 状态机执行一次方法调用后的结果
+type callResult struct {
+	Values []json.RawMessage `json:"values,omitempty"`
+	Args   []json.RawMessage `json:"args,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	// Status 为 true 时表示 error 为 store.StatusError
+	Status bool             `json:"status,omitempty"`
+	Code   store.StatusCode `json:"code,omitempty"`
+}
+
+// applyResponse 状态机执行一条命令的结果
+type applyResponse struct {
+	Results []*callResult `json:"results,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	// Conflict 为 true 时表示命令携带的前置条件不满足，命令没有被执行
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// toError 命令整体执行失败时返回对应的错误
+func (r *applyResponse) toError() error {
+	if r.Error == "" {
+		return nil
+	}
+	if r.Conflict {
+		return store.NewStatusError(store.DataConflictErr, r.Error)
+	}
+	return store.Error(errors.New(r.Error))
+}
+
+var (
+	replicaType = reflect.TypeOf(&replica{})
+	txType      = reflect.TypeOf((*store.Tx)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// newCall 构建一次方法调用，args 不包括事务参数
+func newCall(method string, args ...interface{}) (*call, error) {
+	c := &call{
+		Method: method,
+		Args:   make([]json.RawMessage, 0, len(args)),
+		args:   args,
+	}
+	for i := range args {
+		data, err := encodeValue(args[i])
+		if err != nil {
+			return nil, fmt.Errorf("encode arg %d of %s: %w", i, method, err)
+		}
+		c.Args = append(c.Args, data)
+	}
+	return c, nil
+}
+
+// methodType 查询状态机方法的签名，返回的函数类型不包含接收者
+func methodType(name string) (reflect.Type, bool) {
+	m, ok := replicaType.MethodByName(name)
+	if !ok {
+		return nil, false
+	}
+	in := make([]reflect.Type, 0, m.Type.NumIn()-1)
+	for i := 1; i < m.Type.NumIn(); i++ {
+		in = append(in, m.Type.In(i))
+	}
+	out := make([]reflect.Type, 0, m.Type.NumOut())
+	for i := 0; i < m.Type.NumOut(); i++ {
+		out = append(out, m.Type.Out(i))
+	}
+	return reflect.FuncOf(in, out, false), true
+}
+
+// invoke 在状态机上执行方法调用，tx 不为空时作为方法的第一个参数传入
+func (c *call) invoke(target *replica, tx store.Tx) *callResult {
+	mt, ok := methodType(c.Method)
+	if !ok {
+		return errorResult(fmt.Errorf("raft store method %s not found", c.Method))
+	}
+	offset := 0
+	in := make([]reflect.Value, 0, mt.NumIn())
+	if tx != nil {
+		if mt.NumIn() == 0 || mt.In(0) != txType {
+			return errorResult(fmt.Errorf("raft store method %s not support transaction", c.Method))
+		}
+		in = append(in, reflect.ValueOf(tx))
+		offset = 1
+	}
+	if mt.NumIn()-offset != len(c.Args) {
+		return errorResult(fmt.Errorf("raft store method %s expect %d args, got %d",
+			c.Method, mt.NumIn()-offset, len(c.Args)))
+	}
+	for i := range c.Args {
+		v, err := decodeValue(c.Args[i], mt.In(i+offset))
+		if err != nil {
+			return errorResult(fmt.Errorf("decode arg %d of %s: %w", i, c.Method, err))
+		}
+		in = append(in, v)
+	}
+
+	out := reflect.ValueOf(target).MethodByName(c.Method).Call(in)
+	ret := &callResult{}
+	if last := out[len(out)-1]; last.Type() == errorType && !last.IsNil() {
+		ret.setError(last.Interface().(error))
+		return ret
+	}
+	for i := 0; i < len(out)-1; i++ {
+		data, err := encodeValue(out[i].Interface())
+		if err != nil {
+			return errorResult(err)
+		}
+		ret.Values = append(ret.Values, data)
+	}
+	for i := offset; i < len(in); i++ {
+		data, err := encodeValue(in[i].Interface())
+		if err != nil {
+			return errorResult(err)
+		}
+		ret.Args = append(ret.Args, data)
+	}
+	return ret
+}
+
+// resolve 解析状态机返回的结果，并回写调用方传入的指针参数
+func (c *call) resolve(ret *callResult) ([]interface{}, error) {
+	if ret == nil {
+		return nil, errors.New("raft store empty call result")
+	}
+	if err := ret.toError(); err != nil {
+		return nil, err
+	}
+	mt, ok := methodType(c.Method)
+	if !ok {
+		return nil, fmt.Errorf("raft store method %s not found", c.Method)
+	}
+	for i := range ret.Args {
+		if i >= len(c.args) {
+			break
+		}
+		if err := copyBack(ret.Args[i], c.args[i]); err != nil {
+			return nil, err
+		}
+	}
+	values := make([]interface{}, 0, len(ret.Values))
+	for i := range ret.Values {
+		v, err := decodeValue(ret.Values[i], mt.Out(i))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v.Interface())
+	}
+	return values, nil
+}
+
+func errorResult(err error) *callResult {
+	ret := &callResult{}
+	ret.setError(err)
+	return ret
+}
+
+func (r *callResult) setError(err error) {
+	r.Error = err.Error()
+	if _, ok := err.(*store.StatusError); ok {
+		r.Status = true
+		r.Code = store.Code(err)
+	}
+}
+
+func (r *callResult) toError() error {
+	if r.Error == "" {
+		return nil
+	}
+	if r.Status {
+		return store.NewStatusError(r.Code, r.Error)
+	}
+	return errors.New(r.Error)
+}
+
+// copyBack 将状态机中修改后的参数回写到调用方的结构体指针中
+func copyBack(data json.RawMessage, dst interface{}) error {
+	if dst == nil {
+		return nil
+	}
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v, err := decodeValue(data, dv.Type())
+	if err != nil {
+		return err
+	}
+	if v.IsNil() {
+		return nil
+	}
+	dv.Elem().Set(v.Elem())
+	return nil
+}
+
+// argCodec 无法直接使用 json 序列化的参数类型的编解码器，一般是包含了私有字段的模型
+type argCodec struct {
+	encode func(v interface{}) (interface{}, error)
+	decode func(data json.RawMessage) (reflect.Value, error)
+}
+
+var argCodecs = map[reflect.Type]*argCodec{
+	reflect.TypeOf([]*model.Client{}): {
+		encode: encodeClients,
+		decode: decodeClients,
+	},
+	reflect.TypeOf(&model.LaneGroup{}): {
+		encode: encodeLaneGroup,
+		decode: decodeLaneGroup,
+	},
+}
+
+func encodeValue(v interface{}) (json.RawMessage, error) {
+	if v != nil {
+		if codec, ok := argCodecs[reflect.TypeOf(v)]; ok {
+			wire, err := codec.encode(v)
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(wire)
+		}
+	}
+	return json.Marshal(v)
+}
+
+func decodeValue(data json.RawMessage, typ reflect.Type) (reflect.Value, error) {
+	if codec, ok := argCodecs[typ]; ok {
+		return codec.decode(data)
+	}
+	ptr := reflect.New(typ)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+type clientWire struct {
+	Proto      *apiservice.Client
+	Valid      bool
+	ModifyTime time.Time
+}
+
+func encodeClients(v interface{}) (interface{}, error) {
+	clients := v.([]*model.Client)
+	ret := make([]*clientWire, 0, len(clients))
+	for i := range clients {
+		ret = append(ret, &clientWire{
+			Proto:      clients[i].Proto(),
+			Valid:      clients[i].Valid(),
+			ModifyTime: clients[i].ModifyTime(),
+		})
+	}
+	return ret, nil
+}
+
+func decodeClients(data json.RawMessage) (reflect.Value, error) {
+	wires := make([]*clientWire, 0, 4)
+	if err := json.Unmarshal(data, &wires); err != nil {
+		return reflect.Value{}, err
+	}
+	clients := make([]*model.Client, 0, len(wires))
+	for i := range wires {
+		client := model.NewClient(wires[i].Proto)
+		client.SetValid(wires[i].Valid)
+		clients = append(clients, client)
+	}
+	return reflect.ValueOf(clients), nil
+}
+
+type laneGroupWire struct {
+	Group *model.LaneGroup
+	// ChangeEnable 以及 Add 是 LaneRule 中的私有标记，key 为泳道规则 ID
+	ChangeEnable map[string]bool
+	Add          map[string]bool
+}
+
+func encodeLaneGroup(v interface{}) (interface{}, error) {
+	group := v.(*model.LaneGroup)
+	wire := &laneGroupWire{
+		Group:        group,
+		ChangeEnable: map[string]bool{},
+		Add:          map[string]bool{},
+	}
+	if group == nil {
+		return wire, nil
+	}
+	for id, rule := range group.LaneRules {
+		wire.ChangeEnable[id] = rule.IsChangeEnable()
+		wire.Add[id] = rule.IsAdd()
+	}
+	return wire, nil
+}
+
+func decodeLaneGroup(data json.RawMessage) (reflect.Value, error) {
+	wire := &laneGroupWire{}
+	if err := json.Unmarshal(data, wire); err != nil {
+		return reflect.Value{}, err
+	}
+	if wire.Group == nil {
+		return reflect.ValueOf((*model.LaneGroup)(nil)), nil
+	}
+	for id, rule := range wire.Group.LaneRules {
+		rule.SetChangeEnable(wire.ChangeEnable[id])
+		rule.SetAddFlag(wire.Add[id])
+	}
+	return reflect.ValueOf(wire.Group), nil
+}
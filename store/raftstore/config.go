@@ -0,0 +1,139 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	defaultDataDir           = "./polaris-raft"
+	defaultBindAddress       = "127.0.0.1:8300"
+	defaultForwardAddress    = "127.0.0.1:8301"
+	defaultApplyTimeout      = 10 * time.Second
+	defaultStartTimeout      = time.Minute
+	defaultSnapshotInterval  = 2 * time.Minute
+	defaultSnapshotThreshold = 8192
+	defaultSnapshotRetain    = 3
+)
+
+// PeerConfig 集群中的一个 raft 节点
+type PeerConfig struct {
+	// ID raft 节点 ID，集群内唯一
+	ID string `mapstructure:"id"`
+	// Address raft 协议通信地址
+	Address string `mapstructure:"address"`
+	// ForwardAddress follower 向 leader 转发写请求的地址
+	ForwardAddress string `mapstructure:"forwardAddress"`
+}
+
+// RaftConfig raftStore 的配置
+type RaftConfig struct {
+	// NodeID 当前节点 ID
+	NodeID string `mapstructure:"nodeId"`
+	// DataDir raft 日志、快照以及状态机数据的存放目录
+	DataDir string `mapstructure:"dataDir"`
+	// BindAddress raft 协议监听地址
+	BindAddress string `mapstructure:"bindAddress"`
+	// AdvertiseAddress raft 协议对外公布的地址，默认等于 BindAddress
+	AdvertiseAddress string `mapstructure:"advertiseAddress"`
+	// ForwardAddress 写请求转发的监听地址
+	ForwardAddress string `mapstructure:"forwardAddress"`
+	// Bootstrap 首次启动时是否使用 Peers 引导集群
+	Bootstrap bool `mapstructure:"bootstrap"`
+	// Peers 集群的全部节点，包括当前节点
+	Peers []PeerConfig `mapstructure:"peers"`
+	// ApplyTimeout 单次写操作等待 raft 提交的超时时间
+	ApplyTimeout time.Duration `mapstructure:"applyTimeout"`
+	// StartTimeout 启动时等待集群选出 leader 并完成数据初始化的超时时间
+	StartTimeout time.Duration `mapstructure:"startTimeout"`
+	// SnapshotInterval 检查是否需要生成快照的间隔
+	SnapshotInterval time.Duration `mapstructure:"snapshotInterval"`
+	// SnapshotThreshold 距离上一次快照多少条日志后生成新快照
+	SnapshotThreshold uint64 `mapstructure:"snapshotThreshold"`
+	// SnapshotRetain 保留的快照个数
+	SnapshotRetain int `mapstructure:"snapshotRetain"`
+}
+
+// parseRaftConfig 解析 store.Config 中的 option
+func parseRaftConfig(opt map[string]interface{}) (*RaftConfig, error) {
+	cfg := &RaftConfig{
+		DataDir:           defaultDataDir,
+		BindAddress:       defaultBindAddress,
+		ForwardAddress:    defaultForwardAddress,
+		ApplyTimeout:      defaultApplyTimeout,
+		StartTimeout:      defaultStartTimeout,
+		SnapshotInterval:  defaultSnapshotInterval,
+		SnapshotThreshold: defaultSnapshotThreshold,
+		SnapshotRetain:    defaultSnapshotRetain,
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     cfg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(opt); err != nil {
+		return nil, err
+	}
+	if err := cfg.verify(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *RaftConfig) verify() error {
+	if c.NodeID == "" {
+		return errors.New("raft store nodeId is empty")
+	}
+	if c.AdvertiseAddress == "" {
+		c.AdvertiseAddress = c.BindAddress
+	}
+	if _, err := net.ResolveTCPAddr("tcp", c.AdvertiseAddress); err != nil {
+		return err
+	}
+	if len(c.Peers) == 0 {
+		// 单节点集群
+		c.Peers = []PeerConfig{{
+			ID:             c.NodeID,
+			Address:        c.AdvertiseAddress,
+			ForwardAddress: c.ForwardAddress,
+		}}
+	}
+	for _, peer := range c.Peers {
+		if peer.ID == "" || peer.Address == "" {
+			return errors.New("raft store peer id or address is empty")
+		}
+	}
+	return nil
+}
+
+// forwardAddress 查询节点的写请求转发地址
+func (c *RaftConfig) forwardAddress(id string) (string, bool) {
+	for _, peer := range c.Peers {
+		if peer.ID == id {
+			return peer.ForwardAddress, peer.ForwardAddress != ""
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,133 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"time"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// CreateConfigFileGroup 创建配置文件组
+func (s *raftStore) CreateConfigFileGroup(fileGroup *model.ConfigFileGroup) (*model.ConfigFileGroup, error) {
+	ret, err := s.call("CreateConfigFileGroup", fileGroup)
+	if err != nil {
+		return nil, err
+	}
+	return ret[0].(*model.ConfigFileGroup), nil
+}
+
+// UpdateConfigFileGroup 更新配置文件组
+func (s *raftStore) UpdateConfigFileGroup(fileGroup *model.ConfigFileGroup) error {
+	return s.exec("UpdateConfigFileGroup", fileGroup)
+}
+
+// DeleteConfigFileGroup 删除配置文件组
+func (s *raftStore) DeleteConfigFileGroup(namespace string, name string) error {
+	return s.exec("DeleteConfigFileGroup", namespace, name)
+}
+
+// CreateConfigFileTx 创建配置文件
+func (s *raftStore) CreateConfigFileTx(tx store.Tx, file *model.ConfigFile) error {
+	return s.txCall(tx, "CreateConfigFileTx", file)
+}
+
+// UpdateConfigFileTx 更新配置文件
+func (s *raftStore) UpdateConfigFileTx(tx store.Tx, file *model.ConfigFile) error {
+	return s.txCall(tx, "UpdateConfigFileTx", file)
+}
+
+// DeleteConfigFileTx 删除配置文件
+func (s *raftStore) DeleteConfigFileTx(tx store.Tx, namespace string, group string, name string) error {
+	return s.txCall(tx, "DeleteConfigFileTx", namespace, group, name)
+}
+
+// CreateConfigFileReleaseTx 创建配置文件发布
+func (s *raftStore) CreateConfigFileReleaseTx(tx store.Tx, fileRelease *model.ConfigFileRelease) error {
+	return s.txCall(tx, "CreateConfigFileReleaseTx", fileRelease)
+}
+
+// DeleteConfigFileReleaseTx 删除配置文件发布内容
+func (s *raftStore) DeleteConfigFileReleaseTx(tx store.Tx, data *model.ConfigFileReleaseKey) error {
+	return s.txCall(tx, "DeleteConfigFileReleaseTx", data)
+}
+
+// ActiveConfigFileReleaseTx 指定激活发布的配置文件（激活具有排他性，同一个配置文件的所有 release 中只能有一个处于 active == true 状态）
+func (s *raftStore) ActiveConfigFileReleaseTx(tx store.Tx, release *model.ConfigFileRelease) error {
+	return s.txCall(tx, "ActiveConfigFileReleaseTx", release)
+}
+
+// InactiveConfigFileReleaseTx 指定失效发布的配置文件（失效具有排他性，同一个配置文件的所有 release 中能有多个处于 active == false 状态）
+func (s *raftStore) InactiveConfigFileReleaseTx(tx store.Tx, release *model.ConfigFileRelease) error {
+	return s.txCall(tx, "InactiveConfigFileReleaseTx", release)
+}
+
+// CleanConfigFileReleasesTx 清空配置文件发布
+func (s *raftStore) CleanConfigFileReleasesTx(tx store.Tx, namespace string, group string, fileName string) error {
+	return s.txCall(tx, "CleanConfigFileReleasesTx", namespace, group, fileName)
+}
+
+// CreateConfigFileReleaseHistory 创建配置文件发布历史记录
+func (s *raftStore) CreateConfigFileReleaseHistory(history *model.ConfigFileReleaseHistory) error {
+	return s.exec("CreateConfigFileReleaseHistory", history)
+}
+
+// CleanConfigFileReleaseHistory 清理配置发布历史
+func (s *raftStore) CleanConfigFileReleaseHistory(endTime time.Time, limit uint64) error {
+	return s.exec("CleanConfigFileReleaseHistory", endTime, limit)
+}
+
+// CreateConfigFileTemplate create config file template
+func (s *raftStore) CreateConfigFileTemplate(template *model.ConfigFileTemplate) (*model.ConfigFileTemplate, error) {
+	ret, err := s.call("CreateConfigFileTemplate", template)
+	if err != nil {
+		return nil, err
+	}
+	return ret[0].(*model.ConfigFileTemplate), nil
+}
+
+// UpsertConfigFileSchema create or update config file schema
+func (s *raftStore) UpsertConfigFileSchema(schema *model.ConfigFileSchema) error {
+	return s.exec("UpsertConfigFileSchema", schema)
+}
+
+// DeleteConfigFileSchema delete config file schema
+func (s *raftStore) DeleteConfigFileSchema(namespace, group, fileName string) error {
+	return s.exec("DeleteConfigFileSchema", namespace, group, fileName)
+}
+
+// CreateConfigReleaseRequest create config release request
+func (s *raftStore) CreateConfigReleaseRequest(req *model.ConfigReleaseRequest) error {
+	return s.exec("CreateConfigReleaseRequest", req)
+}
+
+// UpdateConfigReleaseRequest update config release request with optimistic lock
+func (s *raftStore) UpdateConfigReleaseRequest(req *model.ConfigReleaseRequest, expectRevision uint64) error {
+	return s.exec("UpdateConfigReleaseRequest", req, expectRevision)
+}
+
+// SaveConfigGrayRollout create or replace config gray rollout
+func (s *raftStore) SaveConfigGrayRollout(rollout *model.ConfigGrayRollout) error {
+	return s.exec("SaveConfigGrayRollout", rollout)
+}
+
+// UpdateConfigGrayRollout update config gray rollout with optimistic lock
+func (s *raftStore) UpdateConfigGrayRollout(rollout *model.ConfigGrayRollout, expectRevision uint64) error {
+	return s.exec("UpdateConfigGrayRollout", rollout, expectRevision)
+}
@@ -0,0 +1,398 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+const (
+	// STORENAME raft store name
+	STORENAME = "raftStore"
+
+	stateFileName = "state.bolt"
+	logFileName   = "raft.bolt"
+
+	defaultTimeoutForFileLock = 5 * time.Second
+	transportMaxPool          = 3
+	transportTimeout          = 10 * time.Second
+)
+
+var (
+	errNoLeader = errors.New("raft store cluster has no leader")
+)
+
+func init() {
+	s := &raftStore{}
+	_ = store.RegisterStore(s)
+}
+
+// raftStore 基于 raft 复制 boltdb 状态机的存储实现。读请求直接读取本地状态机，
+// 写请求会被编码为命令写入 raft 日志，follower 上的写请求会被转发到 leader 执行
+type raftStore struct {
+	*replica
+
+	cfg       *RaftConfig
+	fsm       *fsm
+	raft      *raft.Raft
+	forwarder forwarder
+	fwdServer *forwardServer
+	closers   []io.Closer
+
+	elections  *leaderElections
+	observer   *raft.Observer
+	observerCh chan raft.Observation
+	stopCh     chan struct{}
+	// txLock 与 boltdb 的写事务一致，同一个节点内的事务串行执行
+	txLock sync.Mutex
+	start  bool
+}
+
+// raftDeps raft 运行依赖的组件，单元测试中可以替换为内存实现
+type raftDeps struct {
+	logs      raft.LogStore
+	stable    raft.StableStore
+	snapshots raft.SnapshotStore
+	transport raft.Transport
+	forwarder forwarder
+	// tune 调整 raft 的运行参数
+	tune func(*raft.Config)
+}
+
+// Name store name
+func (s *raftStore) Name() string {
+	return STORENAME
+}
+
+// Initialize init store
+func (s *raftStore) Initialize(c *store.Config) error {
+	if s.start {
+		return nil
+	}
+	cfg, err := parseRaftConfig(c.Option)
+	if err != nil {
+		return err
+	}
+	deps, err := s.newDeps(cfg)
+	if err != nil {
+		for i := range s.closers {
+			_ = s.closers[i].Close()
+		}
+		s.closers = nil
+		return err
+	}
+	if err := s.open(cfg, deps); err != nil {
+		_ = s.Destroy()
+		return err
+	}
+	fwdServer, err := startForwardServer(cfg.ForwardAddress, s)
+	if err != nil {
+		_ = s.Destroy()
+		return err
+	}
+	s.fwdServer = fwdServer
+	if err := s.waitReady(cfg.StartTimeout); err != nil {
+		_ = s.Destroy()
+		return err
+	}
+	return nil
+}
+
+func (s *raftStore) newDeps(cfg *RaftConfig) (*raftDeps, error) {
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		return nil, err
+	}
+	logs, err := newLogStore(filepath.Join(cfg.DataDir, logFileName))
+	if err != nil {
+		return nil, err
+	}
+	s.closers = append(s.closers, logs)
+	snapshots, err := raft.NewFileSnapshotStore(cfg.DataDir, cfg.SnapshotRetain, newLogWriter())
+	if err != nil {
+		return nil, err
+	}
+	transport, err := raft.NewTCPTransportWithLogger(cfg.BindAddress, advertiseAddr(cfg.AdvertiseAddress),
+		transportMaxPool, transportTimeout, newRaftLogger())
+	if err != nil {
+		return nil, err
+	}
+	s.closers = append(s.closers, transport)
+	return &raftDeps{
+		logs:      logs,
+		stable:    logs,
+		snapshots: snapshots,
+		transport: transport,
+		forwarder: newHTTPForwarder(cfg),
+	}, nil
+}
+
+// open 启动 raft 节点
+func (s *raftStore) open(cfg *RaftConfig, deps *raftDeps) error {
+	stateMachine, err := newFSM(filepath.Join(cfg.DataDir, stateFileName))
+	if err != nil {
+		return err
+	}
+	s.cfg = cfg
+	s.fsm = stateMachine
+	s.replica = stateMachine.replica
+	s.forwarder = deps.forwarder
+	s.elections = newLeaderElections()
+	s.stopCh = make(chan struct{})
+	s.raft = nil
+	s.start = true
+
+	conf := raft.DefaultConfig()
+	conf.LocalID = raft.ServerID(cfg.NodeID)
+	conf.SnapshotInterval = cfg.SnapshotInterval
+	conf.SnapshotThreshold = cfg.SnapshotThreshold
+	conf.Logger = newRaftLogger()
+	if deps.tune != nil {
+		deps.tune(conf)
+	}
+
+	r, err := raft.NewRaft(conf, stateMachine, deps.logs, deps.stable, deps.snapshots, deps.transport)
+	if err != nil {
+		return err
+	}
+	s.raft = r
+
+	if cfg.Bootstrap {
+		exist, err := raft.HasExistingState(deps.logs, deps.stable, deps.snapshots)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			servers := make([]raft.Server, 0, len(cfg.Peers))
+			for _, peer := range cfg.Peers {
+				servers = append(servers, raft.Server{
+					ID:      raft.ServerID(peer.ID),
+					Address: raft.ServerAddress(peer.Address),
+				})
+			}
+			future := r.BootstrapCluster(raft.Configuration{Servers: servers})
+			if err := future.Error(); err != nil && err != raft.ErrCantBootstrap {
+				return err
+			}
+		}
+	}
+
+	s.observerCh = make(chan raft.Observation, 16)
+	s.observer = raft.NewObserver(s.observerCh, false, func(o *raft.Observation) bool {
+		_, ok := o.Data.(raft.LeaderObservation)
+		return ok
+	})
+	r.RegisterObserver(s.observer)
+	go s.watchLeader(r, s.observerCh, s.stopCh)
+	return nil
+}
+
+// waitReady 等待集群选出 leader 并完成默认数据的加载
+func (s *raftStore) waitReady(timeout time.Duration) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		if _, id := s.raft.LeaderWithID(); id != "" && s.fsm.initialized() {
+			log.Infof("[Store][Raft] node %s ready, leader %s", s.cfg.NodeID, id)
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return fmt.Errorf("raft store wait cluster ready timeout after %v", timeout)
+		}
+	}
+}
+
+// watchLeader 监听 leader 变化，通知选主的订阅者，新 leader 负责加载默认数据
+func (s *raftStore) watchLeader(r *raft.Raft, observerCh <-chan raft.Observation, stopCh <-chan struct{}) {
+	s.onLeaderChange(r, stopCh)
+	for {
+		select {
+		case <-observerCh:
+			s.onLeaderChange(r, stopCh)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (s *raftStore) onLeaderChange(r *raft.Raft, stopCh <-chan struct{}) {
+	addr, id := r.LeaderWithID()
+	isLeader := id != "" && string(id) == s.cfg.NodeID
+	s.elections.notify(isLeader, leaderHost(string(addr)))
+	if isLeader {
+		go s.ensureInitialized(r, stopCh)
+	}
+}
+
+// ensureInitialized leader 负责在集群首次启动时写入默认数据的初始化命令
+func (s *raftStore) ensureInitialized(r *raft.Raft, stopCh <-chan struct{}) {
+	for !s.fsm.initialized() {
+		if r.State() != raft.Leader {
+			return
+		}
+		ret, err := s.applyLocal(&command{
+			Type:     commandInit,
+			Seed:     utils.NewUUID(),
+			InitTime: time.Now().UnixMilli(),
+		})
+		if err == nil && ret.Error == "" {
+			return
+		}
+		if err == nil {
+			err = errors.New(ret.Error)
+		}
+		log.Errorf("[Store][Raft] apply init command fail, retry later: %v", err)
+		select {
+		case <-time.After(time.Second):
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// apply 提交命令，当前节点不是 leader 时转发给 leader
+func (s *raftStore) apply(cmd *command) (*applyResponse, error) {
+	if s.raft.State() == raft.Leader {
+		ret, err := s.applyLocal(cmd)
+		if err != raft.ErrNotLeader {
+			return ret, err
+		}
+	}
+	_, leader := s.raft.LeaderWithID()
+	if leader == "" {
+		return nil, errNoLeader
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return s.forwarder.Forward(leader, data)
+}
+
+// applyLocal 在当前节点上提交命令，只有 leader 能够成功。命令中的时间由 leader 写入，
+// 各副本以及日志重放都使用该时间，不再读取本地时间
+func (s *raftStore) applyLocal(cmd *command) (*applyResponse, error) {
+	cmd.Now = time.Now().UnixMilli()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	future := s.raft.Apply(data, s.cfg.ApplyTimeout)
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+	ret, ok := future.Response().(*applyResponse)
+	if !ok {
+		return nil, errors.New("raft store invalid apply response")
+	}
+	return ret, nil
+}
+
+// call 通过 raft 执行一次写方法调用，返回除 error 以外的返回值
+func (s *raftStore) call(method string, args ...interface{}) ([]interface{}, error) {
+	c, err := newCall(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := s.apply(&command{Type: commandCall, Calls: []*call{c}})
+	if err != nil {
+		return nil, store.Error(err)
+	}
+	if ret.Error != "" {
+		return nil, store.Error(errors.New(ret.Error))
+	}
+	if len(ret.Results) != 1 {
+		return nil, errors.New("raft store invalid call result")
+	}
+	return c.resolve(ret.Results[0])
+}
+
+// exec 通过 raft 执行只返回 error 的写方法
+func (s *raftStore) exec(method string, args ...interface{}) error {
+	_, err := s.call(method, args...)
+	return err
+}
+
+// Destroy store
+func (s *raftStore) Destroy() error {
+	if !s.start {
+		return nil
+	}
+	s.start = false
+	close(s.stopCh)
+	if s.fwdServer != nil {
+		_ = s.fwdServer.close()
+		s.fwdServer = nil
+	}
+	if s.raft != nil {
+		if s.observer != nil {
+			s.raft.DeregisterObserver(s.observer)
+		}
+		if err := s.raft.Shutdown().Error(); err != nil {
+			log.Errorf("[Store][Raft] shutdown raft fail: %v", err)
+		}
+	}
+	for i := range s.closers {
+		_ = s.closers[i].Close()
+	}
+	s.closers = nil
+	return s.fsm.close()
+}
+
+// newRaftLogger raft 内部日志输出到 store 日志中
+func newRaftLogger() hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:   "raft",
+		Level:  hclog.Info,
+		Output: newLogWriter(),
+	})
+}
+
+// logWriter 将 raft 的日志写入 store 日志
+type logWriter struct{}
+
+func newLogWriter() io.Writer {
+	return &logWriter{}
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	log.Info(string(trimNewline(p)))
+	return len(p), nil
+}
+
+func trimNewline(p []byte) []byte {
+	for len(p) > 0 && (p[len(p)-1] == '\n' || p[len(p)-1] == '\r') {
+		p = p[:len(p)-1]
+	}
+	return p
+}
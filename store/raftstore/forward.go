@@ -0,0 +1,131 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+const (
+	forwardPath        = "/raftstore/v1/apply"
+	forwardContentType = "application/json"
+)
+
+// forwarder follower 将写命令转发给 leader 执行
+type forwarder interface {
+	// Forward 将编码后的命令发送到 leader 节点，返回状态机的执行结果
+	Forward(leader raft.ServerID, data []byte) (*applyResponse, error)
+}
+
+// httpForwarder 基于 HTTP 的命令转发实现
+type httpForwarder struct {
+	cfg    *RaftConfig
+	client *http.Client
+}
+
+func newHTTPForwarder(cfg *RaftConfig) *httpForwarder {
+	return &httpForwarder{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.ApplyTimeout + time.Second,
+		},
+	}
+}
+
+// Forward .
+func (f *httpForwarder) Forward(leader raft.ServerID, data []byte) (*applyResponse, error) {
+	addr, ok := f.cfg.forwardAddress(string(leader))
+	if !ok {
+		return nil, fmt.Errorf("raft store leader %s forward address not found", leader)
+	}
+	rsp, err := f.client.Post("http://"+addr+forwardPath, forwardContentType, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("raft store forward to %s fail, status %d: %s", leader, rsp.StatusCode, string(body))
+	}
+	ret := &applyResponse{}
+	if err := json.Unmarshal(body, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// forwardServer leader 接收 follower 转发写命令的 HTTP 服务
+type forwardServer struct {
+	server *http.Server
+}
+
+func startForwardServer(address string, s *raftStore) (*forwardServer, error) {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(forwardPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cmd := &command{}
+		if err := json.NewDecoder(r.Body).Decode(cmd); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ret, err := s.applyLocal(cmd)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		body, err := json.Marshal(ret)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", forwardContentType)
+		_, _ = w.Write(body)
+	})
+	fs := &forwardServer{server: &http.Server{Handler: mux}}
+	go func() {
+		if err := fs.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Errorf("[Store][Raft] forward server serve fail: %v", err)
+		}
+	}()
+	return fs, nil
+}
+
+func (fs *forwardServer) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return fs.server.Shutdown(ctx)
+}
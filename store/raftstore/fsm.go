@@ -0,0 +1,439 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/polarismesh/polaris/store"
+	"github.com/polarismesh/polaris/store/boltdb"
+)
+
+const (
+	tblRaftMeta       = "raftstore_meta"
+	metaKeyInitialize = "initialized"
+)
+
+// replica raft 状态机中实际执行命令的对象，在 ReplicaStore 的基础上补充了
+// store.Transaction 中的写操作
+type replica struct {
+	*boltdb.ReplicaStore
+}
+
+// DeleteNamespace 删除命名空间，对应 store.Transaction 中的 DeleteNamespace
+func (r *replica) DeleteNamespace(name string) error {
+	tx, err := r.ReplicaStore.CreateTransaction()
+	if err != nil {
+		return err
+	}
+	return tx.DeleteNamespace(name)
+}
+
+// swapHandler 可替换底层 boltdb 的 BoltHandler，安装快照时需要整体替换状态机的数据文件
+type swapHandler struct {
+	lock     sync.RWMutex
+	delegate boltdb.BoltHandler
+	// now 状态机正在执行的命令携带的 leader 时间，只在 Apply 期间有效
+	now time.Time
+}
+
+// Now 实现 boltdb.Clock，状态机写入的创建以及修改时间都取自 raft 日志
+func (h *swapHandler) Now() time.Time {
+	return h.now
+}
+
+func (h *swapHandler) current() boltdb.BoltHandler {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.delegate
+}
+
+// SaveValue insert data object, each data object should be identified by unique key
+func (h *swapHandler) SaveValue(typ string, key string, object interface{}) error {
+	return h.current().SaveValue(typ, key, object)
+}
+
+// DeleteValues delete data object by unique key
+func (h *swapHandler) DeleteValues(typ string, key []string) error {
+	return h.current().DeleteValues(typ, key)
+}
+
+// UpdateValue update properties of data object
+func (h *swapHandler) UpdateValue(typ string, key string, properties map[string]interface{}) error {
+	return h.current().UpdateValue(typ, key, properties)
+}
+
+// LoadValues load data objects by unique keys, return value is 'key->object' map
+func (h *swapHandler) LoadValues(typ string, keys []string, typObject interface{}) (map[string]interface{}, error) {
+	return h.current().LoadValues(typ, keys, typObject)
+}
+
+// LoadValuesByFilter filter data objects by condition, return value is 'key->object' map
+func (h *swapHandler) LoadValuesByFilter(typ string, fields []string, typObject interface{},
+	filter func(map[string]interface{}) bool) (map[string]interface{}, error) {
+	return h.current().LoadValuesByFilter(typ, fields, typObject, filter)
+}
+
+// LoadValuesAll load all saved data objects, return value is 'key->object' map
+func (h *swapHandler) LoadValuesAll(typ string, typObject interface{}) (map[string]interface{}, error) {
+	return h.current().LoadValuesAll(typ, typObject)
+}
+
+// IterateFields iterate all saved data objects
+func (h *swapHandler) IterateFields(typ string, field string, typObject interface{},
+	process func(interface{})) error {
+	return h.current().IterateFields(typ, field, typObject, process)
+}
+
+// CountValues count all data objects
+func (h *swapHandler) CountValues(typ string) (int, error) {
+	return h.current().CountValues(typ)
+}
+
+// Execute execute scripts directly
+func (h *swapHandler) Execute(writable bool, process func(tx *bolt.Tx) error) error {
+	return h.current().Execute(writable, process)
+}
+
+// StartTx start new tx
+func (h *swapHandler) StartTx() (store.Tx, error) {
+	return h.current().StartTx()
+}
+
+// Close boltdb
+func (h *swapHandler) Close() error {
+	return h.current().Close()
+}
+
+// swap 关闭当前数据文件，执行 replace 替换数据文件后重新打开
+func (h *swapHandler) swap(path string, replace func() error) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err := h.delegate.Close(); err != nil {
+		return err
+	}
+	if err := replace(); err != nil {
+		return err
+	}
+	handler, err := boltdb.NewBoltHandler(&boltdb.BoltConfig{FileName: path})
+	if err != nil {
+		return err
+	}
+	h.delegate = handler
+	return nil
+}
+
+// fsm raft 状态机，状态机数据保存在本地 boltdb 中。由于 raft 启动时会通过快照以及
+// 日志重放恢复全部数据，因此每次启动时都会重建本地的 boltdb 数据文件
+type fsm struct {
+	path    string
+	handler *swapHandler
+	replica *replica
+	// applyLock 串行化状态机的写入以及事务内读操作的预执行，保护 handler.now
+	applyLock sync.Mutex
+}
+
+func newFSM(path string) (*fsm, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	handler, err := boltdb.NewBoltHandler(&boltdb.BoltConfig{FileName: path})
+	if err != nil {
+		return nil, err
+	}
+	swap := &swapHandler{delegate: handler}
+	replicaStore, err := boltdb.NewReplicaStore(swap)
+	if err != nil {
+		_ = handler.Close()
+		return nil, err
+	}
+	return &fsm{
+		path:    path,
+		handler: swap,
+		replica: &replica{ReplicaStore: replicaStore},
+	}, nil
+}
+
+// Apply 执行 raft 日志中的命令
+func (f *fsm) Apply(l *raft.Log) interface{} {
+	cmd := &command{}
+	if err := json.Unmarshal(l.Data, cmd); err != nil {
+		log.Errorf("[Store][Raft] decode command at index %d fail: %v", l.Index, err)
+		return &applyResponse{Error: err.Error()}
+	}
+	f.applyLock.Lock()
+	defer f.applyLock.Unlock()
+	// Apply 由 raft 串行调用，执行期间固定写操作使用的时间
+	if cmd.Now != 0 {
+		f.handler.now = time.UnixMilli(cmd.Now)
+		defer func() {
+			f.handler.now = time.Time{}
+		}()
+	}
+	switch cmd.Type {
+	case commandCall:
+		if !f.checkCalls(cmd) {
+			return conflictResponse()
+		}
+		resp := &applyResponse{}
+		for i := range cmd.Calls {
+			resp.Results = append(resp.Results, cmd.Calls[i].invoke(f.replica, nil))
+		}
+		return resp
+	case commandBatch:
+		return f.applyBatch(cmd)
+	case commandInit:
+		return f.applyInit(cmd)
+	default:
+		return &applyResponse{Error: "unknown raft store command type"}
+	}
+}
+
+// conflictResponse 命令的前置条件不满足
+func conflictResponse() *applyResponse {
+	return &applyResponse{
+		Error:    "data was modified by another transaction, retry later",
+		Conflict: true,
+	}
+}
+
+// checkCalls 校验单次调用命令的前置条件，前置条件都在写操作之前读取，直接读取当前数据比较
+func (f *fsm) checkCalls(cmd *command) bool {
+	for i := range cmd.Checks {
+		if !cmd.Checks[i].matches(cmd.Checks[i].Call.invoke(f.replica, nil)) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkBatch 使用事务开始的时间在回滚的 boltdb 事务中重放事务，在每个读操作发生的位置
+// 重新读取并与事务内读到的结果比较。重放与事务内的预执行使用相同的时间，事务读到的
+// 自身写入的数据能够逐字节比较
+func (f *fsm) checkBatch(cmd *command) (bool, error) {
+	if len(cmd.Checks) == 0 {
+		return true, nil
+	}
+	tx, err := f.handler.StartTx()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	now := f.handler.now
+	f.handler.now = time.UnixMilli(cmd.TxTime)
+	defer func() {
+		f.handler.now = now
+	}()
+
+	next := 0
+	for i := 0; i <= len(cmd.Calls); i++ {
+		for ; next < len(cmd.Checks) && cmd.Checks[next].Step <= i; next++ {
+			if !cmd.Checks[next].matches(cmd.Checks[next].Call.invoke(f.replica, tx)) {
+				return false, nil
+			}
+		}
+		if i == len(cmd.Calls) {
+			break
+		}
+		// 写操作失败时交由实际执行返回具体的错误
+		if ret := cmd.Calls[i].invoke(f.replica, tx); ret.Error != "" {
+			return true, nil
+		}
+	}
+	return true, nil
+}
+
+// dryRun 在回滚的 boltdb 事务中执行事务内尚未提交的写操作后再执行读操作，用于事务读取
+// 自身的写入。写操作使用事务开始的时间，与状态机的校验保持一致
+func (f *fsm) dryRun(txTime int64, calls []*call, read *call) *callResult {
+	f.applyLock.Lock()
+	defer f.applyLock.Unlock()
+
+	if len(calls) == 0 {
+		return f.view(read)
+	}
+	tx, err := f.handler.StartTx()
+	if err != nil {
+		return errorResult(err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	f.handler.now = time.UnixMilli(txTime)
+	defer func() {
+		f.handler.now = time.Time{}
+	}()
+	for i := range calls {
+		if ret := calls[i].invoke(f.replica, tx); ret.Error != "" {
+			return ret
+		}
+	}
+	return read.invoke(f.replica, tx)
+}
+
+// view 在只读事务中执行读操作
+func (f *fsm) view(read *call) *callResult {
+	var ret *callResult
+	err := f.handler.Execute(false, func(tx *bolt.Tx) error {
+		ret = read.invoke(f.replica, boltdb.NewBoltTx(tx))
+		return nil
+	})
+	if err != nil {
+		return errorResult(err)
+	}
+	return ret
+}
+
+// applyBatch 在同一个 boltdb 事务中执行全部调用，任意调用失败则整体回滚
+func (f *fsm) applyBatch(cmd *command) *applyResponse {
+	ok, err := f.checkBatch(cmd)
+	if err != nil {
+		return &applyResponse{Error: err.Error()}
+	}
+	if !ok {
+		return conflictResponse()
+	}
+	tx, err := f.handler.StartTx()
+	if err != nil {
+		return &applyResponse{Error: err.Error()}
+	}
+	resp := &applyResponse{}
+	for i := range cmd.Calls {
+		ret := cmd.Calls[i].invoke(f.replica, tx)
+		resp.Results = append(resp.Results, ret)
+		if ret.Error != "" {
+			_ = tx.Rollback()
+			return resp
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		resp.Error = err.Error()
+	}
+	return resp
+}
+
+// applyInit 加载默认数据，只会执行一次
+func (f *fsm) applyInit(cmd *command) *applyResponse {
+	if f.initialized() {
+		return &applyResponse{}
+	}
+	if err := f.replica.LoadDefault(cmd.Seed, time.UnixMilli(cmd.InitTime)); err != nil {
+		return &applyResponse{Error: err.Error()}
+	}
+	err := f.handler.Execute(true, func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(tblRaftMeta))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(metaKeyInitialize), []byte(cmd.Seed))
+	})
+	if err != nil {
+		return &applyResponse{Error: err.Error()}
+	}
+	return &applyResponse{}
+}
+
+// initialized 默认数据是否已经加载
+func (f *fsm) initialized() bool {
+	var ok bool
+	_ = f.handler.Execute(false, func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(tblRaftMeta))
+		ok = bucket != nil && bucket.Get([]byte(metaKeyInitialize)) != nil
+		return nil
+	})
+	return ok
+}
+
+// Snapshot 将当前的 boltdb 数据拷贝到临时文件中，Persist 时再写入快照
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	file, err := os.CreateTemp(filepath.Dir(f.path), "snapshot-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	err = f.handler.Execute(false, func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(file)
+		return err
+	})
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	return &fsmSnapshot{file: file}, nil
+}
+
+// Restore 使用快照数据替换本地 boltdb 数据文件
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	tmp := f.path + ".restore"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, rc); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return f.handler.swap(f.path, func() error {
+		return os.Rename(tmp, f.path)
+	})
+}
+
+func (f *fsm) close() error {
+	return f.handler.Close()
+}
+
+type fsmSnapshot struct {
+	file *os.File
+}
+
+// Persist 将快照数据写入 sink
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+	if _, err := io.Copy(sink, s.file); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+// Release 清理快照临时文件
+func (s *fsmSnapshot) Release() {
+	_ = s.file.Close()
+	_ = os.Remove(s.file.Name())
+}
@@ -0,0 +1,182 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+
+	"github.com/hashicorp/raft"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketLogs   = []byte("logs")
+	bucketStable = []byte("stable")
+
+	errKeyNotFound = errors.New("not found")
+)
+
+// logStore 基于 boltdb 实现的 raft.LogStore 以及 raft.StableStore
+type logStore struct {
+	db *bolt.DB
+}
+
+func newLogStore(path string) (*logStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: defaultTimeoutForFileLock})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketLogs); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketStable)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &logStore{db: db}, nil
+}
+
+// FirstIndex returns the first index written. 0 for no entries.
+func (s *logStore) FirstIndex() (uint64, error) {
+	var index uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(bucketLogs).Cursor().First(); k != nil {
+			index = bytesToUint64(k)
+		}
+		return nil
+	})
+	return index, err
+}
+
+// LastIndex returns the last index written. 0 for no entries.
+func (s *logStore) LastIndex() (uint64, error) {
+	var index uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(bucketLogs).Cursor().Last(); k != nil {
+			index = bytesToUint64(k)
+		}
+		return nil
+	})
+	return index, err
+}
+
+// GetLog gets a log entry at a given index.
+func (s *logStore) GetLog(index uint64, log *raft.Log) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		val := tx.Bucket(bucketLogs).Get(uint64ToBytes(index))
+		if val == nil {
+			return raft.ErrLogNotFound
+		}
+		return gob.NewDecoder(bytes.NewReader(val)).Decode(log)
+	})
+}
+
+// StoreLog stores a log entry.
+func (s *logStore) StoreLog(log *raft.Log) error {
+	return s.StoreLogs([]*raft.Log{log})
+}
+
+// StoreLogs stores multiple log entries.
+func (s *logStore) StoreLogs(logs []*raft.Log) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketLogs)
+		for i := range logs {
+			buf := &bytes.Buffer{}
+			if err := gob.NewEncoder(buf).Encode(logs[i]); err != nil {
+				return err
+			}
+			if err := bucket.Put(uint64ToBytes(logs[i].Index), buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteRange deletes a range of log entries. The range is inclusive.
+func (s *logStore) DeleteRange(min, max uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketLogs).Cursor()
+		for k, _ := cursor.Seek(uint64ToBytes(min)); k != nil; k, _ = cursor.Next() {
+			if bytesToUint64(k) > max {
+				break
+			}
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Set .
+func (s *logStore) Set(key []byte, val []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStable).Put(key, val)
+	})
+}
+
+// Get returns the value for key, or an empty byte slice if key was not found.
+func (s *logStore) Get(key []byte) ([]byte, error) {
+	var val []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketStable).Get(key)
+		if v == nil {
+			return errKeyNotFound
+		}
+		val = append([]byte(nil), v...)
+		return nil
+	})
+	return val, err
+}
+
+// SetUint64 .
+func (s *logStore) SetUint64(key []byte, val uint64) error {
+	return s.Set(key, uint64ToBytes(val))
+}
+
+// GetUint64 returns the uint64 value for key, or 0 if key was not found.
+func (s *logStore) GetUint64(key []byte) (uint64, error) {
+	val, err := s.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return bytesToUint64(val), nil
+}
+
+// Close .
+func (s *logStore) Close() error {
+	return s.db.Close()
+}
+
+func bytesToUint64(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
+
+func uint64ToBytes(u uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, u)
+	return buf
+}
@@ -0,0 +1,311 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+)
+
+// AddNamespace Save a namespace
+func (s *raftStore) AddNamespace(namespace *model.Namespace) error {
+	return s.exec("AddNamespace", namespace)
+}
+
+// UpdateNamespace Update namespace
+func (s *raftStore) UpdateNamespace(namespace *model.Namespace) error {
+	return s.exec("UpdateNamespace", namespace)
+}
+
+// UpdateNamespaceToken Update namespace token
+func (s *raftStore) UpdateNamespaceToken(name string, token string) error {
+	return s.exec("UpdateNamespaceToken", name, token)
+}
+
+// CleanGrayResource .
+func (s *raftStore) CleanGrayResource(tx store.Tx, data *model.GrayResource) error {
+	return s.txCall(tx, "CleanGrayResource", data)
+}
+
+// CreateGrayResourceTx .
+func (s *raftStore) CreateGrayResourceTx(tx store.Tx, data *model.GrayResource) error {
+	return s.txCall(tx, "CreateGrayResourceTx", data)
+}
+
+// AddService 保存一个服务
+func (s *raftStore) AddService(service *model.Service) error {
+	return s.exec("AddService", service)
+}
+
+// DeleteService 删除服务
+func (s *raftStore) DeleteService(id string, serviceName string, namespaceName string) error {
+	return s.exec("DeleteService", id, serviceName, namespaceName)
+}
+
+// DeleteServiceAlias 删除服务别名
+func (s *raftStore) DeleteServiceAlias(name string, namespace string) error {
+	return s.exec("DeleteServiceAlias", name, namespace)
+}
+
+// UpdateServiceAlias 修改服务别名
+func (s *raftStore) UpdateServiceAlias(alias *model.Service, needUpdateOwner bool) error {
+	return s.exec("UpdateServiceAlias", alias, needUpdateOwner)
+}
+
+// UpdateService 更新服务
+func (s *raftStore) UpdateService(service *model.Service, needUpdateOwner bool) error {
+	return s.exec("UpdateService", service, needUpdateOwner)
+}
+
+// UpdateServiceToken 更新服务token
+func (s *raftStore) UpdateServiceToken(serviceID string, token string, revision string) error {
+	return s.exec("UpdateServiceToken", serviceID, token, revision)
+}
+
+// AddInstance 增加一个实例
+func (s *raftStore) AddInstance(instance *model.Instance) error {
+	return s.exec("AddInstance", instance)
+}
+
+// BatchAddInstances 增加多个实例
+func (s *raftStore) BatchAddInstances(instances []*model.Instance) error {
+	return s.exec("BatchAddInstances", instances)
+}
+
+// UpdateInstance 更新实例
+func (s *raftStore) UpdateInstance(instance *model.Instance) error {
+	return s.exec("UpdateInstance", instance)
+}
+
+// DeleteInstance 删除一个实例，实际是把valid置为false
+func (s *raftStore) DeleteInstance(instanceID string) error {
+	return s.exec("DeleteInstance", instanceID)
+}
+
+// BatchDeleteInstances 批量删除实例，flag=1
+func (s *raftStore) BatchDeleteInstances(ids []interface{}) error {
+	return s.exec("BatchDeleteInstances", ids)
+}
+
+// CleanInstance 清空一个实例，真正删除
+func (s *raftStore) CleanInstance(instanceID string) error {
+	return s.exec("CleanInstance", instanceID)
+}
+
+// SetInstanceHealthStatus 设置实例的健康状态
+func (s *raftStore) SetInstanceHealthStatus(instanceID string, flag int, revision string) error {
+	return s.exec("SetInstanceHealthStatus", instanceID, flag, revision)
+}
+
+// BatchSetInstanceHealthStatus 批量设置实例的健康状态
+func (s *raftStore) BatchSetInstanceHealthStatus(ids []interface{}, healthy int, revision string) error {
+	return s.exec("BatchSetInstanceHealthStatus", ids, healthy, revision)
+}
+
+// BatchSetInstanceIsolate 批量修改实例的隔离状态
+func (s *raftStore) BatchSetInstanceIsolate(ids []interface{}, isolate int, revision string) error {
+	return s.exec("BatchSetInstanceIsolate", ids, isolate, revision)
+}
+
+// BatchAppendInstanceMetadata 追加实例 metadata
+func (s *raftStore) BatchAppendInstanceMetadata(requests []*store.InstanceMetadataRequest) error {
+	return s.exec("BatchAppendInstanceMetadata", requests)
+}
+
+// BatchRemoveInstanceMetadata 删除实例指定的 metadata
+func (s *raftStore) BatchRemoveInstanceMetadata(requests []*store.InstanceMetadataRequest) error {
+	return s.exec("BatchRemoveInstanceMetadata", requests)
+}
+
+// SetL5Extend 设置meta里保存的扩展数据，并返回剩余的meta
+func (s *raftStore) SetL5Extend(serviceID string, meta map[string]interface{}) (map[string]interface{}, error) {
+	ret, err := s.call("SetL5Extend", serviceID, meta)
+	if err != nil {
+		return nil, err
+	}
+	return ret[0].(map[string]interface{}), nil
+}
+
+// GenNextL5Sid 获取module
+func (s *raftStore) GenNextL5Sid(layoutID uint32) (string, error) {
+	ret, err := s.call("GenNextL5Sid", layoutID)
+	if err != nil {
+		return "", err
+	}
+	return ret[0].(string), nil
+}
+
+// CreateRoutingConfig 新增一个路由配置
+func (s *raftStore) CreateRoutingConfig(conf *model.RoutingConfig) error {
+	return s.exec("CreateRoutingConfig", conf)
+}
+
+// UpdateRoutingConfig 更新一个路由配置
+func (s *raftStore) UpdateRoutingConfig(conf *model.RoutingConfig) error {
+	return s.exec("UpdateRoutingConfig", conf)
+}
+
+// DeleteRoutingConfig 删除一个路由配置
+func (s *raftStore) DeleteRoutingConfig(serviceID string) error {
+	return s.exec("DeleteRoutingConfig", serviceID)
+}
+
+// DeleteRoutingConfigTx 删除一个路由配置
+func (s *raftStore) DeleteRoutingConfigTx(tx store.Tx, serviceID string) error {
+	return s.txCall(tx, "DeleteRoutingConfigTx", serviceID)
+}
+
+// CreateRateLimit 新增限流规则
+func (s *raftStore) CreateRateLimit(limiting *model.RateLimit) error {
+	return s.exec("CreateRateLimit", limiting)
+}
+
+// UpdateRateLimit 更新限流规则
+func (s *raftStore) UpdateRateLimit(limiting *model.RateLimit) error {
+	return s.exec("UpdateRateLimit", limiting)
+}
+
+// EnableRateLimit 启用限流规则
+func (s *raftStore) EnableRateLimit(limit *model.RateLimit) error {
+	return s.exec("EnableRateLimit", limit)
+}
+
+// DeleteRateLimit 删除限流规则
+func (s *raftStore) DeleteRateLimit(limiting *model.RateLimit) error {
+	return s.exec("DeleteRateLimit", limiting)
+}
+
+// CreateCircuitBreakerRule create general circuitbreaker rule
+func (s *raftStore) CreateCircuitBreakerRule(cbRule *model.CircuitBreakerRule) error {
+	return s.exec("CreateCircuitBreakerRule", cbRule)
+}
+
+// UpdateCircuitBreakerRule update general circuitbreaker rule
+func (s *raftStore) UpdateCircuitBreakerRule(cbRule *model.CircuitBreakerRule) error {
+	return s.exec("UpdateCircuitBreakerRule", cbRule)
+}
+
+// DeleteCircuitBreakerRule delete general circuitbreaker rule
+func (s *raftStore) DeleteCircuitBreakerRule(id string) error {
+	return s.exec("DeleteCircuitBreakerRule", id)
+}
+
+// EnableCircuitBreakerRule enable specific circuitbreaker rule
+func (s *raftStore) EnableCircuitBreakerRule(cbRule *model.CircuitBreakerRule) error {
+	return s.exec("EnableCircuitBreakerRule", cbRule)
+}
+
+// BatchAddClients insert the client info
+func (s *raftStore) BatchAddClients(clients []*model.Client) error {
+	return s.exec("BatchAddClients", clients)
+}
+
+// BatchDeleteClients delete the client info
+func (s *raftStore) BatchDeleteClients(ids []string) error {
+	return s.exec("BatchDeleteClients", ids)
+}
+
+// EnableRouting 设置路由规则是否启用
+func (s *raftStore) EnableRouting(conf *model.RouterConfig) error {
+	return s.exec("EnableRouting", conf)
+}
+
+// CreateRoutingConfigV2 新增一个路由配置
+func (s *raftStore) CreateRoutingConfigV2(conf *model.RouterConfig) error {
+	return s.exec("CreateRoutingConfigV2", conf)
+}
+
+// CreateRoutingConfigV2Tx 新增一个路由配置
+func (s *raftStore) CreateRoutingConfigV2Tx(tx store.Tx, conf *model.RouterConfig) error {
+	return s.txCall(tx, "CreateRoutingConfigV2Tx", conf)
+}
+
+// UpdateRoutingConfigV2 更新一个路由配置
+func (s *raftStore) UpdateRoutingConfigV2(conf *model.RouterConfig) error {
+	return s.exec("UpdateRoutingConfigV2", conf)
+}
+
+// UpdateRoutingConfigV2Tx 更新一个路由配置
+func (s *raftStore) UpdateRoutingConfigV2Tx(tx store.Tx, conf *model.RouterConfig) error {
+	return s.txCall(tx, "UpdateRoutingConfigV2Tx", conf)
+}
+
+// DeleteRoutingConfigV2 删除一个路由配置
+func (s *raftStore) DeleteRoutingConfigV2(serviceID string) error {
+	return s.exec("DeleteRoutingConfigV2", serviceID)
+}
+
+// CreateFaultDetectRule create fault detect rule
+func (s *raftStore) CreateFaultDetectRule(conf *model.FaultDetectRule) error {
+	return s.exec("CreateFaultDetectRule", conf)
+}
+
+// UpdateFaultDetectRule update fault detect rule
+func (s *raftStore) UpdateFaultDetectRule(conf *model.FaultDetectRule) error {
+	return s.exec("UpdateFaultDetectRule", conf)
+}
+
+// DeleteFaultDetectRule delete fault detect rule
+func (s *raftStore) DeleteFaultDetectRule(id string) error {
+	return s.exec("DeleteFaultDetectRule", id)
+}
+
+// CreateServiceContract 创建服务契约
+func (s *raftStore) CreateServiceContract(contract *model.ServiceContract) error {
+	return s.exec("CreateServiceContract", contract)
+}
+
+// UpdateServiceContract 更新服务契约
+func (s *raftStore) UpdateServiceContract(contract *model.ServiceContract) error {
+	return s.exec("UpdateServiceContract", contract)
+}
+
+// DeleteServiceContract 删除服务契约
+func (s *raftStore) DeleteServiceContract(contract *model.ServiceContract) error {
+	return s.exec("DeleteServiceContract", contract)
+}
+
+// AddServiceContractInterfaces 创建服务契约API接口
+func (s *raftStore) AddServiceContractInterfaces(contract *model.EnrichServiceContract) error {
+	return s.exec("AddServiceContractInterfaces", contract)
+}
+
+// AppendServiceContractInterfaces 追加服务契约API接口
+func (s *raftStore) AppendServiceContractInterfaces(contract *model.EnrichServiceContract) error {
+	return s.exec("AppendServiceContractInterfaces", contract)
+}
+
+// DeleteServiceContractInterfaces 批量删除服务契约API接口
+func (s *raftStore) DeleteServiceContractInterfaces(contract *model.EnrichServiceContract) error {
+	return s.exec("DeleteServiceContractInterfaces", contract)
+}
+
+// AddLaneGroup 添加泳道组
+func (s *raftStore) AddLaneGroup(tx store.Tx, item *model.LaneGroup) error {
+	return s.txCall(tx, "AddLaneGroup", item)
+}
+
+// UpdateLaneGroup 更新泳道组
+func (s *raftStore) UpdateLaneGroup(tx store.Tx, item *model.LaneGroup) error {
+	return s.txCall(tx, "UpdateLaneGroup", item)
+}
+
+// DeleteLaneGroup 删除泳道组
+func (s *raftStore) DeleteLaneGroup(id string) error {
+	return s.exec("DeleteLaneGroup", id)
+}
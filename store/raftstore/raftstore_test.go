@@ -0,0 +1,454 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	apiservice "github.com/polarismesh/specification/source/go/api/v1/service_manage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/polarismesh/polaris/common/eventhub"
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/common/utils"
+	"github.com/polarismesh/polaris/store"
+)
+
+// localForwarder 进程内的命令转发
+type localForwarder struct {
+	nodes map[raft.ServerID]*raftStore
+}
+
+func (f *localForwarder) Forward(leader raft.ServerID, data []byte) (*applyResponse, error) {
+	node, ok := f.nodes[leader]
+	if !ok {
+		return nil, fmt.Errorf("node %s not found", leader)
+	}
+	cmd := &command{}
+	if err := json.Unmarshal(data, cmd); err != nil {
+		return nil, err
+	}
+	return node.applyLocal(cmd)
+}
+
+func newTestCluster(t *testing.T, size int) ([]*raftStore, func()) {
+	eventhub.InitEventHub()
+	forwarder := &localForwarder{nodes: map[raft.ServerID]*raftStore{}}
+	peers := make([]PeerConfig, 0, size)
+	transports := make([]*raft.InmemTransport, 0, size)
+	for i := 0; i < size; i++ {
+		addr, transport := raft.NewInmemTransport(raft.ServerAddress(fmt.Sprintf("127.0.0.%d:8300", i+1)))
+		peers = append(peers, PeerConfig{ID: fmt.Sprintf("node-%d", i), Address: string(addr)})
+		transports = append(transports, transport)
+	}
+	for i := range transports {
+		for j := range transports {
+			if i != j {
+				transports[i].Connect(transports[j].LocalAddr(), transports[j])
+			}
+		}
+	}
+
+	nodes := make([]*raftStore, 0, size)
+	for i := 0; i < size; i++ {
+		cfg := &RaftConfig{
+			NodeID:            peers[i].ID,
+			DataDir:           filepath.Join(t.TempDir(), peers[i].ID),
+			AdvertiseAddress:  peers[i].Address,
+			Bootstrap:         true,
+			Peers:             peers,
+			ApplyTimeout:      5 * time.Second,
+			SnapshotInterval:  time.Hour,
+			SnapshotThreshold: defaultSnapshotThreshold,
+		}
+		logs := raft.NewInmemStore()
+		node := &raftStore{}
+		err := node.open(cfg, &raftDeps{
+			logs:      logs,
+			stable:    logs,
+			snapshots: raft.NewInmemSnapshotStore(),
+			transport: transports[i],
+			forwarder: forwarder,
+			tune: func(c *raft.Config) {
+				c.HeartbeatTimeout = 100 * time.Millisecond
+				c.ElectionTimeout = 100 * time.Millisecond
+				c.LeaderLeaseTimeout = 100 * time.Millisecond
+				c.CommitTimeout = 5 * time.Millisecond
+			},
+		})
+		assert.NoError(t, err)
+		forwarder.nodes[raft.ServerID(cfg.NodeID)] = node
+		nodes = append(nodes, node)
+	}
+	for i := range nodes {
+		assert.NoError(t, nodes[i].waitReady(10*time.Second))
+	}
+	return nodes, func() {
+		for i := range nodes {
+			_ = nodes[i].Destroy()
+		}
+	}
+}
+
+func findLeader(nodes []*raftStore) (*raftStore, []*raftStore) {
+	var (
+		leader    *raftStore
+		followers []*raftStore
+	)
+	for i := range nodes {
+		state := nodes[i].raft.State()
+		if state == raft.Shutdown {
+			continue
+		}
+		if state == raft.Leader {
+			leader = nodes[i]
+		} else {
+			followers = append(followers, nodes[i])
+		}
+	}
+	return leader, followers
+}
+
+// waitApplied 等待所有节点的状态机追上 leader
+func waitApplied(t *testing.T, nodes []*raftStore) {
+	leader, _ := findLeader(nodes)
+	assert.NotNil(t, leader)
+	assert.NoError(t, leader.raft.Barrier(5*time.Second).Error())
+	index := leader.raft.AppliedIndex()
+	assert.Eventually(t, func() bool {
+		for i := range nodes {
+			if nodes[i].raft.State() != raft.Shutdown && nodes[i].raft.AppliedIndex() < index {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRaftStore_DefaultData(t *testing.T) {
+	nodes, closeFn := newTestCluster(t, 3)
+	defer closeFn()
+	waitApplied(t, nodes)
+
+	var (
+		token   string
+		nsTime  time.Time
+		svcTime time.Time
+	)
+	for i := range nodes {
+		ns, err := nodes[i].GetNamespace("default")
+		assert.NoError(t, err)
+		assert.NotNil(t, ns)
+		if token == "" {
+			token = ns.Token
+			nsTime = ns.CreateTime
+		}
+		assert.Equal(t, token, ns.Token)
+		assert.True(t, nsTime.Equal(ns.CreateTime))
+		assert.True(t, nsTime.Equal(ns.ModifyTime))
+
+		svc, err := nodes[i].GetService("polaris.checker", "Polaris")
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+		if svcTime.IsZero() {
+			svcTime = svc.CreateTime
+		}
+		assert.True(t, svcTime.Equal(svc.CreateTime))
+		assert.True(t, svcTime.Equal(svc.ModifyTime))
+	}
+}
+
+func TestRaftStore_WriteOnFollower(t *testing.T) {
+	nodes, closeFn := newTestCluster(t, 3)
+	defer closeFn()
+
+	_, followers := findLeader(nodes)
+	assert.NotEmpty(t, followers)
+
+	err := followers[0].AddNamespace(&model.Namespace{
+		Name:       "raft-ns",
+		Token:      "raft-token",
+		Owner:      "polaris",
+		Valid:      true,
+		CreateTime: time.Now(),
+		ModifyTime: time.Now(),
+	})
+	assert.NoError(t, err)
+	waitApplied(t, nodes)
+
+	for i := range nodes {
+		ns, err := nodes[i].GetNamespace("raft-ns")
+		assert.NoError(t, err)
+		if assert.NotNil(t, ns) {
+			assert.Equal(t, "raft-token", ns.Token)
+		}
+	}
+
+	// store.StatusError 需要原样返回给调用方
+	err = followers[0].UpdateUser(&model.User{})
+	assert.Error(t, err)
+	assert.Equal(t, store.EmptyParamsErr, store.Code(err))
+}
+
+func TestRaftStore_ReplicatedTime(t *testing.T) {
+	nodes, closeFn := newTestCluster(t, 3)
+	defer closeFn()
+
+	_, followers := findLeader(nodes)
+	assert.NotEmpty(t, followers)
+
+	ids := []string{"ins-1", "ins-2", "ins-3"}
+	for _, id := range ids {
+		err := followers[0].AddInstance(&model.Instance{
+			Proto: &apiservice.Instance{
+				Id:   utils.NewStringValue(id),
+				Host: utils.NewStringValue("127.0.0.1"),
+				Port: utils.NewUInt32Value(8080),
+			},
+			ServiceID: "raft-svc",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, followers[0].DeleteInstance(id))
+	}
+	// 清理截止时间由调用方计算后写入日志，各副本删除同一批数据
+	count, err := followers[0].BatchCleanDeletedInstances(0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), count)
+	waitApplied(t, nodes)
+
+	var (
+		remain []string
+		mtime  time.Time
+	)
+	for i := range nodes {
+		values, err := nodes[i].fsm.handler.LoadValuesAll("instance", &model.Instance{})
+		assert.NoError(t, err)
+		assert.Len(t, values, 1)
+		for id, v := range values {
+			ins := v.(*model.Instance)
+			if remain == nil {
+				remain, mtime = []string{id}, ins.ModifyTime
+			}
+			assert.Equal(t, remain, []string{id})
+			assert.True(t, mtime.Equal(ins.ModifyTime))
+		}
+	}
+}
+
+func TestRaftStore_Transaction(t *testing.T) {
+	nodes, closeFn := newTestCluster(t, 3)
+	defer closeFn()
+
+	_, followers := findLeader(nodes)
+	file := &model.ConfigFile{
+		Name:      "app.yaml",
+		Namespace: "default",
+		Group:     "raft",
+		Content:   "key: value",
+		Format:    "yaml",
+		Metadata:  map[string]string{},
+	}
+
+	tx, err := followers[0].StartTx()
+	assert.NoError(t, err)
+	assert.NoError(t, followers[0].CreateConfigFileTx(tx, file))
+	assert.NoError(t, tx.Commit())
+	// 状态机中分配的 ID 需要回写到入参中
+	assert.NotZero(t, file.Id)
+	waitApplied(t, nodes)
+
+	for i := range nodes {
+		saved, err := nodes[i].GetConfigFile("default", "raft", "app.yaml")
+		assert.NoError(t, err)
+		if assert.NotNil(t, saved) {
+			assert.Equal(t, file.Id, saved.Id)
+			assert.Equal(t, "key: value", saved.Content)
+		}
+	}
+
+	// 回滚的事务不会产生任何写入
+	tx, err = nodes[0].StartTx()
+	assert.NoError(t, err)
+	assert.NoError(t, nodes[0].CreateConfigFileTx(tx, &model.ConfigFile{
+		Name: "rollback.yaml", Namespace: "default", Group: "raft", Metadata: map[string]string{},
+	}))
+	assert.NoError(t, tx.Rollback())
+	waitApplied(t, nodes)
+	saved, err := nodes[0].GetConfigFile("default", "raft", "rollback.yaml")
+	assert.NoError(t, err)
+	assert.Nil(t, saved)
+}
+
+func TestRaftStore_TransactionConflict(t *testing.T) {
+	nodes, closeFn := newTestCluster(t, 3)
+	defer closeFn()
+
+	leader, followers := findLeader(nodes)
+	key := &model.ConfigFileKey{Namespace: "default", Group: "raft", Name: "cas.yaml"}
+	newFile := func(content string) *model.ConfigFile {
+		return &model.ConfigFile{
+			Name: key.Name, Namespace: key.Namespace, Group: key.Group,
+			Content: content, Format: "yaml", Metadata: map[string]string{},
+		}
+	}
+
+	// 事务能够读到自身尚未提交的写入，提交时状态机重放得到相同的结果
+	tx, err := followers[0].StartTx()
+	assert.NoError(t, err)
+	assert.NoError(t, followers[0].CreateConfigFileTx(tx, newFile("v1")))
+	pending, err := followers[0].GetConfigFileTx(tx, key.Namespace, key.Group, key.Name)
+	assert.NoError(t, err)
+	if assert.NotNil(t, pending) {
+		assert.Equal(t, "v1", pending.Content)
+	}
+	assert.NoError(t, tx.Commit())
+	waitApplied(t, nodes)
+
+	// 两个节点基于同一份数据加锁检查后更新，后提交的事务被拒绝
+	txA, err := leader.StartTx()
+	assert.NoError(t, err)
+	txB, err := followers[1].StartTx()
+	assert.NoError(t, err)
+	fileA, err := leader.LockConfigFile(txA, key)
+	assert.NoError(t, err)
+	fileB, err := followers[1].LockConfigFile(txB, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", fileA.Content)
+	assert.Equal(t, "v1", fileB.Content)
+
+	fileA.Content = "v2-a"
+	assert.NoError(t, leader.UpdateConfigFileTx(txA, fileA))
+	assert.NoError(t, txA.Commit())
+
+	fileB.Content = "v2-b"
+	assert.NoError(t, followers[1].UpdateConfigFileTx(txB, fileB))
+	err = txB.Commit()
+	assert.Error(t, err)
+	assert.Equal(t, store.DataConflictErr, store.Code(err))
+	waitApplied(t, nodes)
+
+	for i := range nodes {
+		saved, err := nodes[i].GetConfigFile(key.Namespace, key.Group, key.Name)
+		assert.NoError(t, err)
+		if assert.NotNil(t, saved) {
+			assert.Equal(t, "v2-a", saved.Content)
+		}
+	}
+}
+
+func TestRaftStore_LeaderElection(t *testing.T) {
+	nodes, closeFn := newTestCluster(t, 3)
+	defer closeFn()
+
+	for i := range nodes {
+		assert.NoError(t, nodes[i].StartLeaderElection(store.ElectionKeySelfServiceChecker))
+	}
+	countLeader := func() int {
+		cnt := 0
+		for i := range nodes {
+			if nodes[i].raft.State() != raft.Shutdown && nodes[i].IsLeader(store.ElectionKeySelfServiceChecker) {
+				cnt++
+			}
+		}
+		return cnt
+	}
+	assert.Eventually(t, func() bool { return countLeader() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	leader, followers := findLeader(nodes)
+	elections, err := followers[0].ListLeaderElections()
+	assert.NoError(t, err)
+	assert.Len(t, elections, 1)
+
+	// leader 下线后剩余节点重新选出 leader
+	_ = leader.Destroy()
+	assert.Eventually(t, func() bool { return countLeader() == 1 }, 10*time.Second, 10*time.Millisecond)
+	assert.NoError(t, followers[0].AddNamespace(&model.Namespace{Name: "after-failover", Valid: true}))
+}
+
+type bufferSink struct {
+	bytes.Buffer
+}
+
+func (s *bufferSink) ID() string    { return "test" }
+func (s *bufferSink) Cancel() error { return nil }
+func (s *bufferSink) Close() error  { return nil }
+
+func TestFSM_SnapshotRestore(t *testing.T) {
+	source, err := newFSM(filepath.Join(t.TempDir(), stateFileName))
+	assert.NoError(t, err)
+	defer source.close()
+	assert.NoError(t, source.replica.AddNamespace(&model.Namespace{Name: "snapshot-ns", Valid: true}))
+
+	snapshot, err := source.Snapshot()
+	assert.NoError(t, err)
+	sink := &bufferSink{}
+	assert.NoError(t, snapshot.Persist(sink))
+	snapshot.Release()
+
+	target, err := newFSM(filepath.Join(t.TempDir(), stateFileName))
+	assert.NoError(t, err)
+	defer target.close()
+	assert.NoError(t, target.Restore(io.NopCloser(&sink.Buffer)))
+
+	ns, err := target.replica.GetNamespace("snapshot-ns")
+	assert.NoError(t, err)
+	assert.NotNil(t, ns)
+}
+
+func freeAddress(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestRaftStore_Initialize(t *testing.T) {
+	eventhub.InitEventHub()
+	s := &raftStore{}
+	err := s.Initialize(&store.Config{
+		Name: STORENAME,
+		Option: map[string]interface{}{
+			"nodeId":         "single",
+			"dataDir":        t.TempDir(),
+			"bindAddress":    freeAddress(t),
+			"forwardAddress": freeAddress(t),
+			"bootstrap":      true,
+			"startTimeout":   "20s",
+		},
+	})
+	assert.NoError(t, err)
+	defer s.Destroy()
+
+	assert.NoError(t, s.StartLeaderElection(store.ElectionKeyMaintainJob))
+	assert.True(t, s.IsLeader(store.ElectionKeyMaintainJob))
+
+	group, err := s.CreateConfigFileGroup(&model.ConfigFileGroup{Namespace: "default", Name: "single"})
+	assert.NoError(t, err)
+	assert.NotNil(t, group)
+	saved, err := s.GetConfigFileGroup("default", "single")
+	assert.NoError(t, err)
+	assert.NotNil(t, saved)
+}
@@ -0,0 +1,313 @@
+/**
+ * Tencent is pleased to support the open source community by making Polaris available.
+ *
+ * Copyright (C) 2019 THL A29 Limited, a Tencent company. All rights reserved.
+ *
+ * Licensed under the BSD 3-Clause License (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://opensource.org/licenses/BSD-3-Clause
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed
+ * under the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR
+ * CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ */
+
+package raftstore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/polarismesh/polaris/common/model"
+	"github.com/polarismesh/polaris/store"
+	"github.com/polarismesh/polaris/store/boltdb"
+)
+
+var (
+	errTxFinished = errors.New("raft store transaction already finished")
+)
+
+// raftTx 缓存事务内的写操作，在 Commit 时作为一条 raft 日志提交，在状态机中通过
+// 同一个 boltdb 事务执行。事务内的读操作在本地副本上重放尚未提交的写操作后读取，
+// 读到的结果作为前置条件随日志提交，状态机执行前重新读取比较，不一致时拒绝整个事务，
+// 以此保证不同节点上的事务基于同一份数据做出的检查不会相互覆盖
+type raftTx struct {
+	s      *raftStore
+	calls  []*call
+	checks []*check
+	// now 事务开始的时间，事务内的预执行以及状态机的校验都使用该时间
+	now    int64
+	done   bool
+	unlock func()
+}
+
+func (t *raftTx) append(method string, args ...interface{}) error {
+	if t.done {
+		return errTxFinished
+	}
+	c, err := newCall(method, args...)
+	if err != nil {
+		return err
+	}
+	t.calls = append(t.calls, c)
+	return nil
+}
+
+// read 执行事务内的读操作并记录为前置条件
+func (t *raftTx) read(method string, args ...interface{}) ([]interface{}, error) {
+	if t.done {
+		return nil, errTxFinished
+	}
+	c, err := newCall(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	ret := t.s.fsm.dryRun(t.now, t.calls, c)
+	t.checks = append(t.checks, newCheck(c, len(t.calls), ret))
+	return c.resolve(ret)
+}
+
+// Commit Transaction
+func (t *raftTx) Commit() error {
+	if t.done {
+		return errTxFinished
+	}
+	t.done = true
+	defer t.unlock()
+	if len(t.calls) == 0 {
+		return nil
+	}
+	ret, err := t.s.apply(&command{
+		Type:   commandBatch,
+		Calls:  t.calls,
+		Checks: t.checks,
+		TxTime: t.now,
+	})
+	if err != nil {
+		return store.Error(err)
+	}
+	for i := range ret.Results {
+		if i >= len(t.calls) {
+			break
+		}
+		if _, err := t.calls[i].resolve(ret.Results[i]); err != nil {
+			return err
+		}
+	}
+	return ret.toError()
+}
+
+// Rollback transaction
+func (t *raftTx) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+	t.calls = nil
+	t.checks = nil
+	t.unlock()
+	return nil
+}
+
+// GetDelegateTx raft 事务没有对应的底层事务对象
+func (t *raftTx) GetDelegateTx() interface{} {
+	return t
+}
+
+// CreateReadView create a snapshot read view
+func (t *raftTx) CreateReadView() error {
+	return nil
+}
+
+// StartTx starting transactions
+func (s *raftStore) StartTx() (store.Tx, error) {
+	s.txLock.Lock()
+	return &raftTx{s: s, now: time.Now().UnixMilli(), unlock: s.txLock.Unlock}, nil
+}
+
+// StartReadTx starting read transactions
+func (s *raftStore) StartReadTx() (store.Tx, error) {
+	return &raftTx{s: s, now: time.Now().UnixMilli(), unlock: func() {}}, nil
+}
+
+// txCall 将写操作追加到事务中
+func (s *raftStore) txCall(tx store.Tx, method string, args ...interface{}) error {
+	rtx, ok := tx.(*raftTx)
+	if !ok {
+		return fmt.Errorf("raft store %s receive invalid transaction %T", method, tx)
+	}
+	return rtx.append(method, args...)
+}
+
+// txRead 在事务内执行读操作，返回除 error 以外的返回值
+func (s *raftStore) txRead(tx store.Tx, method string, args ...interface{}) ([]interface{}, error) {
+	rtx, ok := tx.(*raftTx)
+	if !ok {
+		return nil, fmt.Errorf("raft store %s receive invalid transaction %T", method, tx)
+	}
+	return rtx.read(method, args...)
+}
+
+// view 在本地状态机的只读事务中执行需要 store.Tx 参数的读操作
+func (s *raftStore) view(process func(tx store.Tx) error) error {
+	return s.fsm.handler.Execute(false, func(tx *bolt.Tx) error {
+		return process(boltdb.NewBoltTx(tx))
+	})
+}
+
+// CreateTransaction create store transaction
+func (s *raftStore) CreateTransaction() (store.Transaction, error) {
+	return &transaction{s: s}, nil
+}
+
+// transaction 实现 store.Transaction，锁操作读取本地数据并记录为前置条件，
+// 事务内的写操作携带前置条件提交，由状态机校验读取之后数据没有被修改
+type transaction struct {
+	s      *raftStore
+	checks []*check
+}
+
+// Commit 提交事务
+func (t *transaction) Commit() error {
+	return nil
+}
+
+// LockBootstrap 启动锁，raft store 不需要限制启动并发
+func (t *transaction) LockBootstrap(key string, server string) error {
+	return nil
+}
+
+// lock 读取数据并记录为前置条件
+func (t *transaction) lock(method string, args ...interface{}) ([]interface{}, error) {
+	c, err := newCall(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	ret := c.invoke(t.s.replica, nil)
+	t.checks = append(t.checks, newCheck(c, 0, ret))
+	return c.resolve(ret)
+}
+
+// LockNamespace 读取 namespace
+func (t *transaction) LockNamespace(name string) (*model.Namespace, error) {
+	values, err := t.lock("GetNamespace", name)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*model.Namespace), nil
+}
+
+// DeleteNamespace 删除 namespace
+func (t *transaction) DeleteNamespace(name string) error {
+	c, err := newCall("DeleteNamespace", name)
+	if err != nil {
+		return err
+	}
+	ret, err := t.s.apply(&command{Type: commandCall, Calls: []*call{c}, Checks: t.checks})
+	if err != nil {
+		return store.Error(err)
+	}
+	if err := ret.toError(); err != nil {
+		return err
+	}
+	if len(ret.Results) != 1 {
+		return errors.New("raft store invalid call result")
+	}
+	_, err = c.resolve(ret.Results[0])
+	return err
+}
+
+// LockService 读取 service
+func (t *transaction) LockService(name string, namespace string) (*model.Service, error) {
+	values, err := t.lock("GetService", name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*model.Service), nil
+}
+
+// RLockService 读取 service
+func (t *transaction) RLockService(name string, namespace string) (*model.Service, error) {
+	return t.LockService(name, namespace)
+}
+
+// GetInstancesCountTx 获取有效的实例总数
+func (s *raftStore) GetInstancesCountTx(tx store.Tx) (uint32, error) {
+	return s.replica.GetInstancesCount()
+}
+
+// GetMoreInstances 根据mtime获取增量instances
+func (s *raftStore) GetMoreInstances(tx store.Tx, mtime time.Time, firstUpdate, needMeta bool,
+	serviceID []string) (map[string]*model.Instance, error) {
+	var ret map[string]*model.Instance
+	err := s.view(func(tx store.Tx) error {
+		var err error
+		ret, err = s.replica.GetMoreInstances(tx, mtime, firstUpdate, needMeta, serviceID)
+		return err
+	})
+	return ret, err
+}
+
+// GetRoutingConfigV2WithIDTx 根据服务ID拉取路由配置
+func (s *raftStore) GetRoutingConfigV2WithIDTx(tx store.Tx, id string) (*model.RouterConfig, error) {
+	values, err := s.txRead(tx, "GetRoutingConfigV2WithIDTx", id)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*model.RouterConfig), nil
+}
+
+// LockConfigFile 读取配置文件
+func (s *raftStore) LockConfigFile(tx store.Tx, file *model.ConfigFileKey) (*model.ConfigFile, error) {
+	return s.GetConfigFileTx(tx, file.Namespace, file.Group, file.Name)
+}
+
+// GetConfigFileTx 获取配置文件
+func (s *raftStore) GetConfigFileTx(tx store.Tx, namespace, group, name string) (*model.ConfigFile, error) {
+	values, err := s.txRead(tx, "GetConfigFileTx", namespace, group, name)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*model.ConfigFile), nil
+}
+
+// GetConfigFileActiveReleaseTx 获取配置文件处于 Active 的配置发布记录
+func (s *raftStore) GetConfigFileActiveReleaseTx(tx store.Tx,
+	file *model.ConfigFileKey) (*model.ConfigFileRelease, error) {
+	return s.txReadRelease(tx, "GetConfigFileActiveReleaseTx", file)
+}
+
+// GetConfigFileReleaseTx 获取配置文件发布内容
+func (s *raftStore) GetConfigFileReleaseTx(tx store.Tx,
+	req *model.ConfigFileReleaseKey) (*model.ConfigFileRelease, error) {
+	return s.txReadRelease(tx, "GetConfigFileReleaseTx", req)
+}
+
+// GetConfigFileBetaReleaseTx 获取灰度发布的配置文件信息
+func (s *raftStore) GetConfigFileBetaReleaseTx(tx store.Tx,
+	file *model.ConfigFileKey) (*model.ConfigFileRelease, error) {
+	return s.txReadRelease(tx, "GetConfigFileBetaReleaseTx", file)
+}
+
+func (s *raftStore) txReadRelease(tx store.Tx, method string, key interface{}) (*model.ConfigFileRelease, error) {
+	values, err := s.txRead(tx, method, key)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*model.ConfigFileRelease), nil
+}
+
+// LockLaneGroup 读取泳道分组
+func (s *raftStore) LockLaneGroup(tx store.Tx, name string) (*model.LaneGroup, error) {
+	values, err := s.txRead(tx, "LockLaneGroup", name)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*model.LaneGroup), nil
+}
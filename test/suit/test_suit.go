@@ -25,6 +25,7 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -244,6 +245,23 @@ func RespSuccess(resp api.ResponseMessage) bool {
 
 type options func(cfg *TestConfig)
 
+// WithLogDir 将测试日志输出重定向到 dir 目录下, 避免日志文件写入被测包的源码目录
+func WithLogDir(dir string) options {
+	return func(cfg *TestConfig) {
+		for _, opt := range cfg.Bootstrap.Logger {
+			if opt == nil {
+				continue
+			}
+			if opt.RotateOutputPath != "" {
+				opt.RotateOutputPath = filepath.Join(dir, opt.RotateOutputPath)
+			}
+			if opt.ErrorRotateOutputPath != "" {
+				opt.ErrorRotateOutputPath = filepath.Join(dir, opt.ErrorRotateOutputPath)
+			}
+		}
+	}
+}
+
 func (d *DiscoverTestSuit) Initialize(opts ...options) error {
 	return d.initialize(opts...)
 }